@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/forest6511/secretctl/internal/mcp"
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/certrenew"
+	"github.com/forest6511/secretctl/pkg/i18n"
+)
+
+// Agent command flags
+var (
+	agentSocket         string
+	agentJSON           bool
+	agentHealthInterval time.Duration
+	agentRenewCerts     time.Duration
+	agentMetricsAddr    string
+	agentDashboard      bool
+	agentDashboardAddr  string
+)
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentStartCmd)
+	agentCmd.AddCommand(agentStatusCmd)
+	agentCmd.AddCommand(agentLockCmd)
+
+	agentCmd.PersistentFlags().StringVar(&agentSocket, "socket", "", "Agent socket path (default: $SECRETCTL_AGENT_SOCK or ~/.secretctl/agent.sock)")
+	agentStartCmd.Flags().DurationVar(&agentHealthInterval, "health-interval", agent.DefaultHealthInterval, "Interval between vault health checks (0 disables)")
+	agentStartCmd.Flags().DurationVar(&agentRenewCerts, "renew-certs", 0, "Interval between ACME certificate renewal runs (0 disables)")
+	agentStartCmd.Flags().StringVar(&agentMetricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this TCP address, e.g. 127.0.0.1:9464 (disabled by default)")
+	agentStartCmd.Flags().BoolVar(&agentDashboard, "dashboard", false, "Serve a read-only HTML dashboard on localhost")
+	agentStartCmd.Flags().StringVar(&agentDashboardAddr, "dashboard-addr", "127.0.0.1:0", "Loopback address of the dashboard (port 0 picks a free one)")
+	agentStatusCmd.Flags().BoolVar(&agentJSON, "json", false, "Output in JSON format")
+}
+
+// agentCmd is the parent command for agent operations
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run and control the secretctl agent daemon",
+	Long: `The agent keeps the vault unlocked in a background process and serves
+a local API (Get, Set, List, Run, Lock, Status) over a Unix socket.
+
+Only processes running as the same user can connect. The API is a
+versioned gRPC service (` + agent.ServiceV1 + `, defined in
+pkg/agent/agentv1/agent.proto), so other local tools and language SDKs can
+integrate without exec-ing the CLI.`,
+}
+
+// agentStartCmd starts the agent in the foreground
+var agentStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the agent in the foreground",
+	Long: `Start the agent in the foreground. Stop it with Ctrl+C or SIGTERM.
+
+Authentication:
+  The master password is prompted for, or read once from SECRETCTL_PASSWORD
+  and immediately cleared from the environment.
+
+Health watchdog:
+  While running, the agent periodically checks the database (PRAGMA
+  quick_check), the audit chain head and vault file permissions. Problems
+  are logged to stderr and reported by 'secretctl agent status'.
+
+Certificate renewal:
+  With --renew-certs, the agent periodically renews ACME-managed
+  certificates that are due (see 'secretctl certs renew').
+
+Metrics:
+  With --metrics-addr, the agent serves Prometheus metrics at
+  http://<addr>/metrics: request counts and latency by method and result,
+  lock state, failed unlock attempts and cooldowns, dynamic secret cache
+  hits and misses, and the time of the newest backup in backup_dir.
+  Metrics contain no key names or values. Bind to a loopback address
+  unless the scraper runs elsewhere.
+
+Running commands:
+  Run executes only the commands that the MCP policy of the vault
+  (mcp-policy.yaml) allows, with the same environment and output
+  redaction rules as the MCP secret_run tool. Without a policy, or with a
+  policy that sets sandbox, resource_limits or egress (which the agent
+  does not apply), Run is refused.
+
+Dashboard:
+  With --dashboard, the agent serves a read-only page on localhost with
+  vault status and health, secrets expiring within 30 days, the last
+  backup, MCP activity and the audit log of the last 24 hours. It shows
+  key names but never values. The printed URL carries a one-time token:
+  opening it signs that browser in, and the URL stops working. Restart
+  the agent to get a new one.
+
+Examples:
+  secretctl agent start &
+  secretctl agent status`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password := os.Getenv("SECRETCTL_PASSWORD")
+		os.Unsetenv("SECRETCTL_PASSWORD")
+		if password == "" {
+			fmt.Fprint(os.Stderr, i18n.T("Enter master password: "))
+			passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			fmt.Fprintln(os.Stderr)
+			password = string(passwordBytes)
+		}
+
+		// Changes made through the agent are attributed to it
+		v.SetAuditSource(audit.SourceAPI)
+		if err := v.Unlock(password); err != nil {
+			return fmt.Errorf("failed to unlock vault: %w", err)
+		}
+		defer v.Lock()
+
+		healthInterval := agentHealthInterval
+		if healthInterval == 0 {
+			healthInterval = -1 // disabled
+		}
+		var tasks []agent.Task
+		if agentRenewCerts > 0 {
+			renewer := certrenew.New(v, audit.SourceAPI)
+			tasks = append(tasks, agent.Task{
+				Name:     "certs.renew",
+				Interval: agentRenewCerts,
+				Run: func(ctx context.Context) error {
+					results, err := renewer.RenewDue(ctx)
+					for _, res := range results {
+						if res.Err != nil {
+							slog.Warn("certificate renewal failed", "key", res.Key, "err", res.Err)
+						} else if res.Renewed {
+							slog.Info("certificate renewed", "key", res.Key, "not_after", res.NotAfter)
+						}
+					}
+					return err
+				},
+			})
+		}
+		opts := &agent.ServerOptions{
+			SocketPath:     agentSocket,
+			HealthInterval: healthInterval,
+			Tasks:          tasks,
+			MetricsAddr:    agentMetricsAddr,
+		}
+		if agentDashboard {
+			opts.DashboardAddr = agentDashboardAddr
+		}
+		policy, err := mcp.LoadPolicy(vaultPath)
+		switch {
+		case err == nil:
+			opts.RunPolicy = agentRunPolicy{policy}
+		case !errors.Is(err, mcp.ErrPolicyNotFound):
+			fmt.Fprintf(os.Stderr, "Warning: running commands is disabled: %v\n", err)
+		}
+		server := agent.NewServer(v, opts)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		fmt.Fprintf(os.Stderr, "Agent listening on %s (pid %d)\n", server.SocketPath(), os.Getpid())
+		if agentMetricsAddr != "" {
+			fmt.Fprintf(os.Stderr, "Metrics on http://%s%s\n", agentMetricsAddr, agent.MetricsPath)
+		}
+		errc := make(chan error, 1)
+		go func() { errc <- server.Serve(ctx) }()
+		if agentDashboard {
+			// The URL is known once Serve is listening
+			for server.DashboardURL() == "" {
+				select {
+				case err := <-errc:
+					if err != nil {
+						return fmt.Errorf("agent error: %w", err)
+					}
+					return nil
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Dashboard on %s (one-time link)\n", server.DashboardURL())
+		}
+		if err := <-errc; err != nil {
+			return fmt.Errorf("agent error: %w", err)
+		}
+		return nil
+	},
+}
+
+// agentStatusCmd reports the status of a running agent
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show status of the running agent",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := dialAgent()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		status, err := client.Status(cmd.Context(), &agentv1.StatusRequest{})
+		if err != nil {
+			return err
+		}
+
+		if agentJSON {
+			data, err := protojson.MarshalOptions{Multiline: true, Indent: "  ", UseProtoNames: true}.Marshal(status)
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		state := "unlocked"
+		if status.Locked {
+			state = "locked"
+		}
+		fmt.Printf("Agent:   running (pid %d, API %s)\n", status.Pid, status.ApiVersion)
+		fmt.Printf("Vault:   %s (%s)\n", status.VaultPath, state)
+		fmt.Printf("Uptime:  %s\n", time.Since(status.StartedAt.AsTime()).Round(time.Second))
+		switch {
+		case status.Health == nil:
+			fmt.Println("Health:  not checked yet")
+		case status.Health.Healthy:
+			fmt.Printf("Health:  ok (checked %s ago)\n", time.Since(status.Health.CheckedAt.AsTime()).Round(time.Second))
+		default:
+			fmt.Printf("Health:  DEGRADED (checked %s ago)\n", time.Since(status.Health.CheckedAt.AsTime()).Round(time.Second))
+			for _, p := range status.Health.Problems {
+				fmt.Printf("  - %s\n", p)
+			}
+		}
+		return nil
+	},
+}
+
+// agentLockCmd locks the vault held by the running agent
+var agentLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the vault held by the running agent",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := dialAgent()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if _, err := client.Lock(cmd.Context(), &agentv1.LockRequest{}); err != nil {
+			return err
+		}
+		fmt.Println("Agent vault locked")
+		return nil
+	},
+}
+
+// dialAgent connects to the agent socket selected by --socket.
+func dialAgent() (*agent.Client, error) {
+	socketPath := agentSocket
+	if socketPath == "" {
+		socketPath = agent.DefaultSocketPath(vaultPath)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := agent.Dial(ctx, socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("agent is not running: %w", err)
+	}
+	return client, nil
+}
+
+// agentRunPolicy lets agent clients run the commands the MCP policy allows.
+// The agent does not apply the policy's sandbox, resource limits and egress
+// rules, so a policy that sets them refuses every command instead of being
+// bypassed through the agent.
+type agentRunPolicy struct {
+	*mcp.Policy
+}
+
+func (p agentRunPolicy) AuthorizeCommand(command string) (string, error) {
+	if p.Sandbox != nil || p.ResourceLimits != nil || p.Egress != nil {
+		return "", errors.New("the MCP policy confines commands (sandbox, resource_limits or egress), which the agent does not apply; use secret_run instead")
+	}
+	return p.Policy.AuthorizeCommand(command)
+}
+
+func (p agentRunPolicy) InheritsEnv(name string) bool {
+	return slices.Contains(p.InheritEnv, name)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Attach command flags
+var (
+	attachName   string
+	attachOutput string
+	attachForce  bool
+)
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+	attachCmd.AddCommand(attachAddCmd)
+	attachCmd.AddCommand(attachListCmd)
+	attachCmd.AddCommand(attachGetCmd)
+	attachCmd.AddCommand(attachRmCmd)
+
+	attachAddCmd.Flags().StringVar(&attachName, "name", "", "Attachment name (default: the file's base name)")
+	attachGetCmd.Flags().StringVarP(&attachOutput, "output", "o", "", "Write to this file, or - for stdout (default: the attachment name)")
+	attachGetCmd.Flags().BoolVarP(&attachForce, "force", "f", false, "Overwrite an existing file")
+}
+
+// attachCmd is the parent command for secret attachments
+var attachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach encrypted files to secrets",
+	Long: fmt.Sprintf(`Attach files such as certificates and key pairs to the secret they belong
+with. Attachments are encrypted like secret values, up to %d KiB each.
+They follow the secret into the trash and are removed when it is purged.`, vault.MaxAttachmentSize/1024),
+}
+
+// attachAddCmd attaches a file to a secret
+var attachAddCmd = &cobra.Command{
+	Use:   "add <key> <file>",
+	Short: "Attach a file to a secret",
+	Long: `Encrypts a file and attaches it to an existing secret. An attachment
+with the same name is replaced.
+
+Examples:
+  secretctl attach add tls/api ./api.crt
+  secretctl attach add tls/api ./key.pem --name api.key`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, path := args[0], args[1]
+		name := attachName
+		if name == "" {
+			name = filepath.Base(path)
+		}
+		if err := vault.ValidateAttachmentName(name); err != nil {
+			return &exitError{code: ExitUsage, err: err}
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.AddAttachment(key, name, f); err != nil {
+			return fmt.Errorf("failed to attach '%s' to '%s': %w", name, key, err)
+		}
+		fmt.Printf("Attached '%s' to '%s'\n", name, key)
+		return nil
+	},
+}
+
+// attachListCmd lists the attachments of a secret
+var attachListCmd = &cobra.Command{
+	Use:     "list <key>",
+	Aliases: []string{"ls"},
+	Short:   "List the attachments of a secret",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		attachments, err := v.ListAttachments(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to list attachments: %w", err)
+		}
+		if len(attachments) == 0 {
+			fmt.Println("No attachments")
+			return nil
+		}
+		for _, a := range attachments {
+			fmt.Printf("%-40s %10d bytes  %s\n", a.Name, a.Size, tf.Time(a.CreatedAt))
+		}
+		return nil
+	},
+}
+
+// attachGetCmd writes an attachment to a file
+var attachGetCmd = &cobra.Command{
+	Use:   "get <key> <name>",
+	Short: "Decrypt an attachment to a file",
+	Long: `Decrypts an attachment and writes it to a new file with mode 0600,
+named after the attachment unless --output is given.
+
+Examples:
+  secretctl attach get tls/api api.crt
+  secretctl attach get tls/api api.key -o /etc/ssl/private/api.key
+  secretctl attach get tls/api api.crt -o - | openssl x509 -noout -text`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, name := args[0], args[1]
+		output := attachOutput
+		if output == "" {
+			output = name
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		r, err := v.OpenAttachmentReader(key, name)
+		if err != nil {
+			return fmt.Errorf("failed to get attachment '%s' of '%s': %w", name, key, err)
+		}
+		defer r.Close()
+		if output == "-" {
+			_, err := io.Copy(os.Stdout, r)
+			return err
+		}
+
+		flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if attachForce {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(output, flags, 0600)
+		if err != nil {
+			if errors.Is(err, os.ErrExist) {
+				return &exitError{code: ExitConflict, err: fmt.Errorf("file already exists: %s (use --force to overwrite)", output)}
+			}
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote '%s' to %s\n", name, output)
+		return nil
+	},
+}
+
+// attachRmCmd removes an attachment
+var attachRmCmd = &cobra.Command{
+	Use:     "rm <key> <name>",
+	Aliases: []string{"remove"},
+	Short:   "Remove an attachment from a secret",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, name := args[0], args[1]
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.RemoveAttachment(key, name); err != nil {
+			return fmt.Errorf("failed to remove attachment '%s' of '%s': %w", name, key, err)
+		}
+		fmt.Printf("Removed '%s' from '%s'\n", name, key)
+		return nil
+	},
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/breach"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Audit breaches command flags
+var auditBreachesJSON bool
+
+func init() {
+	auditCmd.AddCommand(auditBreachesCmd)
+
+	auditBreachesCmd.Flags().BoolVar(&auditBreachesJSON, "json", false, "Output in JSON format")
+}
+
+// auditBreachesCmd checks password fields against Have I Been Pwned
+var auditBreachesCmd = &cobra.Command{
+	Use:   "breaches",
+	Short: "Check passwords against known breaches (Have I Been Pwned)",
+	Long: `Checks password fields against the Have I Been Pwned "Pwned Passwords"
+list and reports the ones that appeared in known breaches.
+
+This is the only command that contacts api.pwnedpasswords.com, and only
+when run. Each password is hashed with SHA-1 locally and just the first
+5 characters of the hash are sent; the response lists every breached
+hash with that prefix (padded with decoys), and the match is made on
+this machine. Passwords and full hashes never leave it.
+
+Fields count as passwords by kind ("password") or by name (password,
+pass, secret, credential, ...). References to other secrets are skipped.
+
+Exits with 1 if a compromised password was found.
+
+Examples:
+  secretctl audit breaches
+  secretctl audit breaches --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		keys, err := v.ListSecrets()
+		if err != nil {
+			return err
+		}
+		entries := make([]*vault.SecretEntry, 0, len(keys))
+		for _, key := range keys {
+			entry, err := v.InspectSecret(key)
+			if err != nil {
+				return fmt.Errorf("failed to read secret '%s': %w", key, err)
+			}
+			entries = append(entries, entry)
+		}
+
+		report, err := breach.NewChecker().Scan(cmd.Context(), entries)
+		if err != nil {
+			return fmt.Errorf("breach check failed: %w", err)
+		}
+
+		if auditBreachesJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else if len(report.Compromised) == 0 {
+			fmt.Printf("✓ None of %d passwords appear in known breaches\n", report.Checked)
+		} else {
+			fmt.Printf("✗ %d of %d passwords appear in known breaches:\n", len(report.Compromised), report.Checked)
+			for _, r := range report.Compromised {
+				fmt.Printf("  - %s (%s): seen %d times\n", r.Key, r.Field, r.Count)
+			}
+			fmt.Println("\nRotate these passwords; attackers try breached passwords first.")
+		}
+
+		if len(report.Compromised) > 0 {
+			return fmt.Errorf("found %d compromised passwords", len(report.Compromised))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Audit proof command flags
+var (
+	auditProofRange   string
+	auditProofOutput  string
+	auditProofKeyOut  string
+	auditProofKeyFile string
+)
+
+func init() {
+	auditCmd.AddCommand(auditExportProofCmd)
+	auditCmd.AddCommand(auditVerifyProofCmd)
+
+	auditExportProofCmd.Flags().StringVar(&auditProofRange, "range", "", "Sequence range FROM:TO (inclusive; TO may be empty for the newest record)")
+	auditExportProofCmd.Flags().StringVarP(&auditProofOutput, "output", "o", "", "Output file path (default: stdout)")
+	auditExportProofCmd.Flags().StringVar(&auditProofKeyOut, "key-out", "", "Also write the audit HMAC key (hex) to this file")
+	_ = auditExportProofCmd.MarkFlagRequired("range")
+
+	auditVerifyProofCmd.Flags().StringVar(&auditProofKeyFile, "key-file", "", "File containing the audit HMAC key (hex)")
+	_ = auditVerifyProofCmd.MarkFlagRequired("key-file")
+}
+
+// auditExportProofCmd exports a verifiable segment of the audit chain
+var auditExportProofCmd = &cobra.Command{
+	Use:   "export-proof",
+	Short: "Export a verifiable proof bundle for a range of audit records",
+	Long: `Export a segment of the audit log as a proof bundle: the records, the
+chain head at export time and an HMAC binding them together.
+
+Anyone holding the audit HMAC key can check the bundle with
+'secretctl audit verify-proof' without access to the vault, e.g. for a
+forensic handoff after an incident. Use --key-out to write the key, and
+hand it over separately from the bundle.
+
+Examples:
+  secretctl audit export-proof --range 1000:2000 -o proof.json
+  secretctl audit export-proof --range 1000: -o proof.json --key-out audit.key`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, to, err := parseSequenceRange(auditProofRange)
+		if err != nil {
+			return err
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		bundle, err := v.AuditLogger().ExportProof(from, to)
+		if err != nil {
+			return fmt.Errorf("failed to export proof: %w", err)
+		}
+		data, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal proof: %w", err)
+		}
+
+		if auditProofKeyOut != "" {
+			key, err := v.AuditLogger().ExportKey()
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(auditProofKeyOut, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+				return fmt.Errorf("failed to write key file: %w", err)
+			}
+		}
+		_ = v.AuditLogger().Log(audit.OpAuditExportProof, audit.SourceCLI, audit.ResultSuccess, "", nil, map[string]interface{}{
+			"from":         bundle.From,
+			"to":           bundle.To,
+			"key_exported": auditProofKeyOut != "",
+		})
+
+		if auditProofOutput == "" {
+			fmt.Println(string(data))
+			return nil
+		}
+		if err := os.WriteFile(auditProofOutput, append(data, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to write proof: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported records %d-%d (%d records) to %s\n", bundle.From, bundle.To, len(bundle.Events), auditProofOutput)
+		return nil
+	},
+}
+
+// auditVerifyProofCmd verifies a proof bundle without the vault
+var auditVerifyProofCmd = &cobra.Command{
+	Use:   "verify-proof <file>",
+	Short: "Verify an audit proof bundle with the audit HMAC key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read proof: %w", err)
+		}
+		var bundle audit.ProofBundle
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("invalid proof bundle: %w", err)
+		}
+
+		keyHex, err := os.ReadFile(auditProofKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read key file: %w", err)
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+		if err != nil {
+			return fmt.Errorf("invalid key file (expected hex): %w", err)
+		}
+
+		result, err := audit.VerifyProof(&bundle, key)
+		if err != nil {
+			return err
+		}
+		if !result.Valid {
+			fmt.Printf("✗ Proof verification FAILED\n")
+			for _, e := range result.Errors {
+				fmt.Printf("    - %s\n", e)
+			}
+			return fmt.Errorf("audit proof verification failed")
+		}
+
+		fmt.Printf("✓ Proof verified: records %d-%d (%d records), created %s\n", bundle.From, bundle.To, result.RecordsVerified, bundle.CreatedAt)
+		if bundle.To == bundle.Head.Sequence {
+			fmt.Println("  Segment ends at the chain head")
+		} else {
+			fmt.Printf("  Chain head at export: sequence %d\n", bundle.Head.Sequence)
+		}
+		return nil
+	},
+}
+
+// parseSequenceRange parses "FROM:TO", where TO may be empty (0 = newest).
+func parseSequenceRange(s string) (from, to int64, err error) {
+	fromStr, toStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q (use FROM:TO, e.g. 1000:2000)", s)
+	}
+	if from, err = strconv.ParseInt(fromStr, 10, 64); err != nil || from < 1 {
+		return 0, 0, fmt.Errorf("invalid range start %q", fromStr)
+	}
+	if toStr != "" {
+		if to, err = strconv.ParseInt(toStr, 10, 64); err != nil || to < from {
+			return 0, 0, fmt.Errorf("invalid range end %q", toStr)
+		}
+	}
+	return from, to, nil
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/forest6511/secretctl/pkg/backup"
+	"github.com/forest6511/secretctl/pkg/i18n"
 )
 
 var (
@@ -18,6 +22,7 @@ var (
 	backupBackupPassword bool
 	backupKeyFile        string
 	backupForce          bool
+	backupGenPassphrase  bool
 )
 
 func init() {
@@ -27,6 +32,7 @@ func init() {
 	backupCmd.Flags().BoolVar(&backupStdout, "stdout", false, "Output to stdout (for piping)")
 	backupCmd.Flags().BoolVar(&backupWithAudit, "with-audit", false, "Include audit log in backup")
 	backupCmd.Flags().BoolVar(&backupBackupPassword, "backup-password", false, "Use separate backup password")
+	backupCmd.Flags().BoolVar(&backupGenPassphrase, "generate-passphrase", false, "Encrypt with a generated diceware passphrase, shown once")
 	backupCmd.Flags().StringVar(&backupKeyFile, "key-file", "", "Encryption key file (32 bytes)")
 	backupCmd.Flags().BoolVarP(&backupForce, "force", "f", false, "Overwrite existing file")
 }
@@ -36,19 +42,29 @@ var backupCmd = &cobra.Command{
 	Short: "Create encrypted backup of the vault",
 	Long: `Create an encrypted backup of the vault data.
 
+Without --output or --stdout, the backup is written to the directory set
+by 'secretctl config set backup_dir <dir>' as
+secretctl-backup-YYYYMMDD-HHMMSS.enc.
+
 Examples:
   # Backup to a file
   secretctl backup -o vault-backup.enc
 
+  # Backup to backup_dir
+  secretctl backup
+
   # Backup with audit log
   secretctl backup -o full-backup.enc --with-audit
 
   # Backup to stdout (for piping)
   secretctl backup --stdout | gpg --encrypt > backup.gpg
 
-  # Use separate backup password
+  # Use separate backup password (same length rules as the master password)
   secretctl backup -o backup.enc --backup-password
 
+  # Use a generated passphrase (printed once, must be typed back)
+  secretctl backup -o backup.enc --generate-passphrase
+
   # Use key file for encryption
   secretctl backup -o backup.enc --key-file=backup.key
 
@@ -58,6 +74,16 @@ Examples:
 }
 
 func executeBackup(cmd *cobra.Command, args []string) error {
+	// Without --output or --stdout, write to the configured backup_dir
+	if !backupStdout && backupOutput == "" {
+		if settings, err := v.Settings(); err == nil && settings.BackupDir != "" {
+			if err := os.MkdirAll(settings.BackupDir, 0700); err != nil {
+				return fmt.Errorf("failed to create backup directory: %w", err)
+			}
+			backupOutput = defaultBackupPath(settings.BackupDir, time.Now())
+		}
+	}
+
 	// Validate flags
 	if err := validateBackupFlags(); err != nil {
 		return err
@@ -102,6 +128,12 @@ func executeBackup(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		password = pwd
+	} else if backupGenPassphrase {
+		pwd, err := generateBackupPassphrase()
+		if err != nil {
+			return err
+		}
+		password = pwd
 	}
 	// If neither key-file nor backup-password, use master password (already unlocked)
 
@@ -111,6 +143,7 @@ func executeBackup(cmd *cobra.Command, args []string) error {
 		IncludeAudit: backupWithAudit,
 		Password:     password,
 		KeyFile:      keyFilePath,
+		ToolVersion:  version,
 	}
 
 	// Perform backup
@@ -125,9 +158,14 @@ func executeBackup(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// defaultBackupPath returns a timestamped backup file name in dir.
+func defaultBackupPath(dir string, now time.Time) string {
+	return filepath.Join(dir, backup.FileName(now))
+}
+
 func validateBackupFlags() error {
 	if !backupStdout && backupOutput == "" {
-		return fmt.Errorf("either --output or --stdout is required")
+		return fmt.Errorf("either --output or --stdout is required (or set backup_dir with 'secretctl config set')")
 	}
 	if backupStdout && backupOutput != "" {
 		return fmt.Errorf("--output and --stdout are mutually exclusive")
@@ -135,18 +173,21 @@ func validateBackupFlags() error {
 	if backupKeyFile != "" && backupBackupPassword {
 		return fmt.Errorf("--key-file and --backup-password are mutually exclusive")
 	}
+	if backupGenPassphrase && (backupKeyFile != "" || backupBackupPassword) {
+		return fmt.Errorf("--generate-passphrase cannot be combined with --key-file or --backup-password")
+	}
 	return nil
 }
 
 func promptBackupPassword() ([]byte, error) {
-	fmt.Print("Enter backup password: ")
+	fmt.Print(i18n.T("Enter backup password: "))
 	password1, err := term.ReadPassword(int(syscall.Stdin))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read password: %w", err)
 	}
 	fmt.Println()
 
-	fmt.Print("Confirm backup password: ")
+	fmt.Print(i18n.T("Confirm backup password: "))
 	password2, err := term.ReadPassword(int(syscall.Stdin))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read password: %w", err)
@@ -154,12 +195,35 @@ func promptBackupPassword() ([]byte, error) {
 	fmt.Println()
 
 	if string(password1) != string(password2) {
-		return nil, fmt.Errorf("passwords do not match")
+		return nil, errors.New(i18n.T("passwords do not match"))
 	}
 
-	if len(password1) == 0 {
-		return nil, fmt.Errorf("password cannot be empty")
+	if err := backup.ValidatePassword(password1); err != nil {
+		return nil, err
 	}
 
 	return password1, nil
 }
+
+// generateBackupPassphrase prints a new diceware passphrase once on stderr,
+// so it stays out of --stdout output, and has the user type it back.
+func generateBackupPassphrase() ([]byte, error) {
+	passphrase, err := backup.GeneratePassphrase()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(os.Stderr, i18n.T("Backup passphrase (shown only once, write it down):"))
+	fmt.Fprintf(os.Stderr, "\n    %s\n\n", passphrase)
+
+	fmt.Fprint(os.Stderr, i18n.T("Type the passphrase to confirm: "))
+	typed, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	if string(typed) != passphrase {
+		return nil, errors.New(i18n.T("passphrase does not match"))
+	}
+	return []byte(passphrase), nil
+}
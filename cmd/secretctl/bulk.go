@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Bulk command flags
+var (
+	bulkFilters      []string
+	bulkAddTags      []string
+	bulkRemoveTags   []string
+	bulkSetExpires   string
+	bulkClearExpires bool
+	bulkDryRun       bool
+)
+
+func init() {
+	rootCmd.AddCommand(bulkCmd)
+
+	bulkCmd.Flags().StringArrayVar(&bulkFilters, "filter", nil, "Select secrets: tag=<tag> or key=<glob> (can be repeated, all must match)")
+	bulkCmd.Flags().StringArrayVar(&bulkAddTags, "add-tag", nil, "Add a tag (can be repeated)")
+	bulkCmd.Flags().StringArrayVar(&bulkRemoveTags, "remove-tag", nil, "Remove a tag (can be repeated)")
+	bulkCmd.Flags().StringVar(&bulkSetExpires, "set-expires", "", "Set expiration duration from now (e.g., 90d, 1y)")
+	bulkCmd.Flags().BoolVar(&bulkClearExpires, "clear-expires", false, "Remove expiration")
+	bulkCmd.Flags().BoolVar(&bulkDryRun, "dry-run", false, "Show what would change without applying it")
+	bulkCmd.MarkFlagsMutuallyExclusive("set-expires", "clear-expires")
+}
+
+// bulkCmd updates tags and expiration of many secrets at once
+var bulkCmd = &cobra.Command{
+	Use:   "bulk",
+	Short: "Update tags and expiration of many secrets at once",
+	Long: `Update tags and expiration of all secrets matching a filter.
+
+All changes are applied in a single transaction: either every matching
+secret is updated or none are. Secret values are never modified.
+
+Filters (all must match):
+  tag=<tag>     Secrets that have the tag
+  key=<glob>    Secrets whose key matches the pattern (e.g., key=db/*)
+
+Examples:
+  secretctl bulk --filter tag=legacy --add-tag deprecated --set-expires 90d --dry-run
+  secretctl bulk --filter 'key=staging/*' --remove-tag prod
+  secretctl bulk --filter tag=temp --clear-expires`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := parseBulkFilters(bulkFilters)
+		if err != nil {
+			return err
+		}
+
+		patch := vault.BulkPatch{
+			AddTags:         bulkAddTags,
+			RemoveTags:      bulkRemoveTags,
+			ClearExpiration: bulkClearExpires,
+		}
+		if bulkSetExpires != "" {
+			duration, err := parseDuration(bulkSetExpires)
+			if err != nil {
+				return fmt.Errorf("invalid expires format: %w", err)
+			}
+			expiresAt := time.Now().Add(duration)
+			patch.ExpiresAt = &expiresAt
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		var result *vault.BulkResult
+		if bulkDryRun {
+			result, err = v.BulkUpdatePreview(filter, patch)
+		} else {
+			result, err = v.BulkUpdate(filter, patch)
+		}
+		if err != nil {
+			if errors.Is(err, vault.ErrEmptyBulkPatch) {
+				return fmt.Errorf("nothing to change: use --add-tag, --remove-tag, --set-expires or --clear-expires")
+			}
+			return fmt.Errorf("bulk update failed: %w", err)
+		}
+
+		for _, c := range result.Changes {
+			fmt.Printf("%s\n", c.Key)
+			if strings.Join(c.OldTags, ",") != strings.Join(c.NewTags, ",") {
+				fmt.Printf("  tags:    [%s] -> [%s]\n", strings.Join(c.OldTags, ","), strings.Join(c.NewTags, ","))
+			}
+			if formatOptionalDate(c.OldExpiresAt) != formatOptionalDate(c.NewExpiresAt) {
+				fmt.Printf("  expires: %s -> %s\n", formatOptionalDate(c.OldExpiresAt), formatOptionalDate(c.NewExpiresAt))
+			}
+		}
+
+		if bulkDryRun {
+			fmt.Printf("\nDry run: %d matched, %d would be updated\n", result.Matched, len(result.Changes))
+		} else {
+			fmt.Printf("\n%d matched, %d updated\n", result.Matched, len(result.Changes))
+		}
+		return nil
+	},
+}
+
+// parseBulkFilters converts --filter name=value flags into a vault.BulkFilter.
+func parseBulkFilters(filters []string) (vault.BulkFilter, error) {
+	var filter vault.BulkFilter
+	if len(filters) == 0 {
+		return filter, fmt.Errorf("at least one --filter is required (e.g., --filter tag=legacy)")
+	}
+	for _, f := range filters {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || value == "" {
+			return filter, fmt.Errorf("invalid filter %q: expected name=value", f)
+		}
+		switch name {
+		case "tag":
+			if filter.Tag != "" {
+				return filter, fmt.Errorf("only one tag filter is supported")
+			}
+			filter.Tag = value
+		case "key":
+			if filter.KeyPattern != "" {
+				return filter, fmt.Errorf("only one key filter is supported")
+			}
+			filter.KeyPattern = value
+		default:
+			return filter, fmt.Errorf("unknown filter %q: supported filters are tag and key", name)
+		}
+	}
+	return filter, nil
+}
+
+// formatOptionalDate formats an optional expiration date for display.
+func formatOptionalDate(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestParseBulkFilters(t *testing.T) {
+	filter, err := parseBulkFilters([]string{"tag=legacy", "key=db/*"})
+	if err != nil {
+		t.Fatalf("parseBulkFilters failed: %v", err)
+	}
+	if filter.Tag != "legacy" || filter.KeyPattern != "db/*" {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	for _, bad := range [][]string{nil, {"tag"}, {"tag="}, {"owner=me"}, {"tag=a", "tag=b"}} {
+		if _, err := parseBulkFilters(bad); err == nil {
+			t.Errorf("expected error for %v", bad)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Character sets of decoy values that mimic real credentials
+const (
+	charsetAWSKeyID     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	charsetAWSSecret    = charsetLowercase + charsetUppercase + charsetDigits + "/+"
+	charsetAlphanumeric = charsetLowercase + charsetUppercase + charsetDigits
+	charsetHex          = "0123456789abcdef"
+)
+
+// decoyGenerators build realistic decoy fields for each canary type.
+var decoyGenerators = map[string]func() (map[string]vault.Field, error){
+	"aws": func() (map[string]vault.Field, error) {
+		id, err := generatePassword(charsetAWSKeyID, 16)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := generatePassword(charsetAWSSecret, 40)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]vault.Field{
+			"access_key_id":     {Value: "AKIA" + id, Sensitive: false},
+			"secret_access_key": {Value: secret, Sensitive: true},
+			"region":            {Value: "us-east-1", Sensitive: false},
+		}, nil
+	},
+	"github": func() (map[string]vault.Field, error) {
+		token, err := generatePassword(charsetAlphanumeric, 36)
+		if err != nil {
+			return nil, err
+		}
+		return vault.ConvertSingleValueToFields([]byte("ghp_" + token)), nil
+	},
+	"api": func() (map[string]vault.Field, error) {
+		key, err := generatePassword(charsetHex, 40)
+		if err != nil {
+			return nil, err
+		}
+		return vault.ConvertSingleValueToFields([]byte(key)), nil
+	},
+	"password": func() (map[string]vault.Field, error) {
+		password, err := generatePassword(charsetAlphanumeric+charsetSymbols, defaultPasswordLength)
+		if err != nil {
+			return nil, err
+		}
+		return vault.ConvertSingleValueToFields([]byte(password)), nil
+	},
+}
+
+// Canary command flags
+var (
+	canaryType  string
+	canaryValue string
+	canaryTags  string
+)
+
+func init() {
+	rootCmd.AddCommand(canaryCmd)
+	canaryCmd.AddCommand(canaryCreateCmd)
+	canaryCmd.AddCommand(canaryListCmd)
+
+	canaryCreateCmd.Flags().StringVar(&canaryType, "type", "api", "Kind of decoy value: "+strings.Join(canaryTypes(), ", "))
+	canaryCreateCmd.Flags().StringVar(&canaryValue, "value", "", "Use this value instead of a generated one (e.g. an external honeytoken)")
+	canaryCreateCmd.Flags().StringVar(&canaryTags, "tags", "", "Comma-separated tags, to make the decoy blend in")
+}
+
+// canaryTypes returns the supported decoy types, sorted.
+func canaryTypes() []string {
+	types := make([]string, 0, len(decoyGenerators))
+	for t := range decoyGenerators {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// canaryCmd is the parent command for canary secrets
+var canaryCmd = &cobra.Command{
+	Use:   "canary",
+	Short: "Plant decoy secrets that raise an alert when read",
+	Long: `Canary secrets (honeytokens) are decoys that nothing legitimate reads.
+Reading one with get, run, the agent or an MCP tool records a
+secret.canary_triggered audit event, publishes a canary_triggered vault
+event and, if the alert_webhook setting is set, posts an alert to it. This
+detects compromised AI agents or scripts enumerating the vault.
+
+Canaries look like ordinary secrets in list output; only 'canary list'
+shows which keys are decoys.
+
+  secretctl config set alert_webhook https://hooks.example.com/secretctl`,
+}
+
+// canaryCreateCmd plants a canary secret
+var canaryCreateCmd = &cobra.Command{
+	Use:   "create <key>",
+	Short: "Create a canary secret",
+	Long: `Creates a canary secret with a generated decoy value that looks like a
+real credential of the given type:
+
+  aws       access_key_id (AKIA...), secret_access_key and region
+  github    a personal access token (ghp_...)
+  api       a 40-character hex API key (default)
+  password  a random password
+
+Choose a tempting name that no script or workflow uses.
+
+Examples:
+  secretctl canary create aws/prod-admin --type aws
+  secretctl canary create legacy/STRIPE_SECRET_KEY --tags payments`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		generate, ok := decoyGenerators[canaryType]
+		if !ok {
+			return fmt.Errorf("unknown canary type %q (valid: %s)", canaryType, strings.Join(canaryTypes(), ", "))
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if _, err := v.InspectSecret(key); err == nil {
+			return fmt.Errorf("secret '%s' already exists", key)
+		}
+
+		entry := &vault.SecretEntry{}
+		if canaryValue != "" {
+			entry.Fields = vault.ConvertSingleValueToFields([]byte(canaryValue))
+		} else {
+			fields, err := generate()
+			if err != nil {
+				return err
+			}
+			entry.Fields = fields
+		}
+		if canaryTags != "" {
+			for _, tag := range strings.Split(canaryTags, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					entry.Tags = append(entry.Tags, tag)
+				}
+			}
+		}
+
+		if err := v.CreateCanary(key, entry); err != nil {
+			return fmt.Errorf("failed to create canary: %w", err)
+		}
+		fmt.Printf("Canary '%s' created\n", key)
+
+		if settings, err := v.Settings(); err == nil && settings.AlertWebhook == "" {
+			fmt.Println("Note: alert_webhook is not set; reads are only recorded in the audit log and vault events")
+		}
+		return nil
+	},
+}
+
+// canaryListCmd lists canary secrets
+var canaryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List canary secrets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		keys, err := v.ListCanaries()
+		if err != nil {
+			return fmt.Errorf("failed to list canaries: %w", err)
+		}
+		if len(keys) == 0 {
+			fmt.Println("No canary secrets")
+			return nil
+		}
+		for _, key := range keys {
+			fmt.Println(key)
+		}
+		return nil
+	},
+}
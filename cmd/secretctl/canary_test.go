@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestDecoyGenerators(t *testing.T) {
+	for _, typ := range canaryTypes() {
+		fields, err := decoyGenerators[typ]()
+		if err != nil {
+			t.Fatalf("%s: generator failed: %v", typ, err)
+		}
+		if len(fields) == 0 {
+			t.Fatalf("%s: no fields generated", typ)
+		}
+		for name, f := range fields {
+			if f.Value == "" {
+				t.Errorf("%s: field %s is empty", typ, name)
+			}
+		}
+	}
+
+	fields, _ := decoyGenerators["aws"]()
+	if id := fields["access_key_id"].Value; !strings.HasPrefix(id, "AKIA") || len(id) != 20 {
+		t.Errorf("aws access_key_id = %q, want AKIA plus 16 characters", id)
+	}
+	fields, _ = decoyGenerators["github"]()
+	if token := vault.GetDefaultFieldValue(fields); !strings.HasPrefix(token, "ghp_") || len(token) != 40 {
+		t.Errorf("github token = %q, want ghp_ plus 36 characters", token)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/certrenew"
+)
+
+// Certs command flags
+var (
+	certsListJSON bool
+	certsRenewDue bool
+)
+
+func init() {
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.AddCommand(certsListCmd)
+	certsCmd.AddCommand(certsRenewCmd)
+
+	certsListCmd.Flags().BoolVar(&certsListJSON, "json", false, "Output in JSON format")
+	certsRenewCmd.Flags().BoolVar(&certsRenewDue, "due", false, "Renew every ACME-managed certificate that is due for renewal")
+}
+
+// certsCmd is the parent command for certificate tracking
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Track X.509 certificates stored in the vault",
+	Long: `Track X.509 certificates stored in the vault.
+
+When a secret is written, a field of kind "certificate", or any field whose
+value starts with a PEM certificate, is parsed. Its notAfter, subject and
+SANs are stored with the secret, and certificates show up in
+'secretctl list --expiring' and 'secretctl security expiring' like
+secrets with an expiration date.
+
+Certificates stored before this feature are picked up when their secret is
+next written.`,
+}
+
+// certJSON is the JSON output of certs list
+type certJSON struct {
+	Key          string    `json:"key"`
+	Field        string    `json:"field"`
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SANs         []string  `json:"sans,omitempty"`
+	Serial       string    `json:"serial"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+	DaysToExpiry int       `json:"days_to_expiry"`
+}
+
+// certsListCmd lists certificates by expiry
+var certsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List certificates with days to expiry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		entries, err := v.ListCertificates()
+		if err != nil {
+			return fmt.Errorf("failed to list certificates: %w", err)
+		}
+
+		if certsListJSON {
+			out := make([]certJSON, 0, len(entries))
+			for _, e := range entries {
+				c := e.Certificate
+				if c == nil {
+					continue
+				}
+				out = append(out, certJSON{
+					Key: e.Key, Field: c.Field, Subject: c.Subject, Issuer: c.Issuer, SANs: c.SANs,
+					Serial: c.Serial, NotBefore: c.NotBefore, NotAfter: c.NotAfter,
+					DaysToExpiry: daysUntil(c.NotAfter),
+				})
+			}
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No certificates found")
+			return nil
+		}
+		for _, e := range entries {
+			c := e.Certificate
+			if c == nil {
+				continue
+			}
+			days := daysUntil(c.NotAfter)
+			status := fmt.Sprintf("%d days", days)
+			switch {
+			case days < 0:
+				status = fmt.Sprintf("EXPIRED %d days ago", -days)
+			case days == 0:
+				status = "expires today"
+			}
+			fmt.Printf("%-30s %-20s %s  %s\n", e.Key, status, c.NotAfter.Local().Format("2006-01-02"), c.Subject)
+			if len(c.SANs) > 0 {
+				fmt.Printf("%-30s SANs: %s\n", "", strings.Join(c.SANs, ", "))
+			}
+		}
+		return nil
+	},
+}
+
+// certsRenewCmd renews certificates through ACME
+var certsRenewCmd = &cobra.Command{
+	Use:   "renew [key]",
+	Short: "Renew a certificate through ACME (Let's Encrypt)",
+	Long: `Obtain a new certificate through ACME and store it in the secret.
+
+The secret's "acme" field configures the renewal as JSON:
+
+  {
+    "domains": ["example.com", "www.example.com"],
+    "email": "admin@example.com",
+    "challenge": "http-01",            // or "dns-01"
+    "http_addr": ":80",                // http-01: serve the challenge here
+    "webroot": "/var/www/html",        // http-01: or write it here
+    "dns_hook": "/usr/local/bin/dns",  // dns-01: <hook> present|cleanup <fqdn> <value>
+    "directory": "https://acme-staging-v02.api.letsencrypt.org/directory",
+    "renew_before_days": 30
+  }
+
+The new certificate chain and private key replace the "cert" and "key"
+fields in a single write; the previous version stays in the secret's
+history. The ACME account key is kept in "acme_account_key".
+
+With --due, every certificate with an "acme" field that expires within its
+renew_before_days is renewed. 'secretctl agent start --renew-certs 12h'
+does this periodically.`,
+	Example: `  secretctl set tls/example --field acme='{"domains":["example.com"],"webroot":"/var/www/html"}'
+  secretctl certs renew tls/example
+  secretctl certs renew --due`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if certsRenewDue == (len(args) == 1) {
+			return fmt.Errorf("specify a key or --due")
+		}
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		renewer := certrenew.New(v, audit.SourceCLI)
+		if !certsRenewDue {
+			fmt.Fprintf(os.Stderr, "Requesting certificate for '%s'...\n", args[0])
+			res, err := renewer.Renew(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Renewed '%s' (valid until %s)\n", res.Key, res.NotAfter.Local().Format("2006-01-02"))
+			return nil
+		}
+
+		results, err := renewer.RenewDue(cmd.Context())
+		if err != nil {
+			return err
+		}
+		var failed int
+		for _, res := range results {
+			switch {
+			case res.Err != nil:
+				failed++
+				fmt.Printf("%-30s FAILED: %v\n", res.Key, res.Err)
+			case res.Renewed:
+				fmt.Printf("%-30s renewed (valid until %s)\n", res.Key, res.NotAfter.Local().Format("2006-01-02"))
+			default:
+				fmt.Printf("%-30s not due (%d days left)\n", res.Key, daysUntil(res.NotAfter))
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d certificate(s) failed to renew", failed)
+		}
+		return nil
+	},
+}
+
+// daysUntil returns whole days until t, negative once t has passed.
+func daysUntil(t time.Time) int {
+	return int(math.Floor(time.Until(t).Hours() / 24))
+}
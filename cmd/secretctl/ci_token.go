@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/backup"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Environment variables that switch the CLI to a CI bundle
+const (
+	ciBundleEnv = "SECRETCTL_CI_BUNDLE"
+	ciTokenEnv  = "SECRETCTL_CI_TOKEN"
+)
+
+// ciTokenPrefix marks CI bundle tokens so they are recognizable in leaks.
+const ciTokenPrefix = "sctci_"
+
+// maxCITokenTTL is the longest a CI bundle may stay usable.
+const maxCITokenTTL = 90 * 24 * time.Hour
+
+// CI token command flags
+var (
+	ciTokenKeys []string
+	ciTokenTTL  time.Duration
+	ciTokenOut  string
+)
+
+// ciBundleDir is the temporary directory holding an opened CI bundle.
+var ciBundleDir string
+
+func init() {
+	rootCmd.AddCommand(ciTokenCmd)
+	ciTokenCmd.AddCommand(ciTokenCreateCmd)
+
+	ciTokenCreateCmd.Flags().StringArrayVarP(&ciTokenKeys, "keys", "k", nil, "Secret keys to include (glob pattern supported, repeatable)")
+	ciTokenCreateCmd.Flags().DurationVar(&ciTokenTTL, "ttl", 24*time.Hour, "How long the bundle stays usable (max 2160h)")
+	ciTokenCreateCmd.Flags().StringVarP(&ciTokenOut, "out", "o", "", "Bundle file to write")
+	_ = ciTokenCreateCmd.MarkFlagRequired("keys")
+	_ = ciTokenCreateCmd.MarkFlagRequired("out")
+}
+
+// ciTokenCmd is the parent command for CI bundles
+var ciTokenCmd = &cobra.Command{
+	Use:   "ci-token",
+	Short: "Export scoped, expiring secret bundles for CI systems",
+	Long: `Export a subset of secrets as a separately keyed, read-only vault bundle
+for CI runners, so CI never holds the master password.
+
+When SECRETCTL_CI_BUNDLE and SECRETCTL_CI_TOKEN are set, every command
+reads from the bundle instead of ~/.secretctl: get, list, run, exec and
+export work as usual, while changes fail because the bundle is read-only.
+After the TTL the bundle can no longer be opened.
+
+A bundle is a regular encrypted backup whose password is the token, so
+'secretctl restore' also accepts it.`,
+}
+
+// ciTokenCreateCmd writes a CI bundle
+var ciTokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a CI bundle and its token",
+	Long: `Create a CI bundle holding the secrets matching --keys, encrypted with a
+new random token. The bundle file can be committed or stored as a CI
+artifact; the token is printed once, as an env snippet for the CI
+system's secret settings.
+
+The expiry is enforced by the CLI reading the bundle. Anyone holding both
+the bundle and the token can decrypt it until you rotate the secrets.
+
+Examples:
+  secretctl ci-token create --keys 'ci/*' --ttl 24h --out ci-vault.enc
+  secretctl ci-token create -k deploy/token -k 'aws/ci-*' -o bundle.enc`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ciTokenTTL <= 0 || ciTokenTTL > maxCITokenTTL {
+			return fmt.Errorf("--ttl must be between 1s and %s", maxCITokenTTL)
+		}
+		if _, err := os.Stat(ciTokenOut); err == nil {
+			return fmt.Errorf("bundle file already exists: %s", ciTokenOut)
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		token, err := newCIToken()
+		if err != nil {
+			return err
+		}
+		expiresAt := time.Now().Add(ciTokenTTL).UTC()
+		count, err := writeCIBundle(ciTokenOut, ciTokenKeys, token, expiresAt)
+		if err != nil {
+			return err
+		}
+		_ = v.Audit().Log(audit.OpCITokenCreate, audit.SourceCLI, audit.ResultSuccess, "", nil,
+			map[string]interface{}{"secrets": count, "expires_at": expiresAt.Format(time.RFC3339)})
+
+		fmt.Fprintf(os.Stderr, "Wrote %s with %d secrets, usable until %s.\n", ciTokenOut, count, expiresAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintln(os.Stderr, "Add this to your CI secrets (the token is not shown again):")
+		fmt.Printf("%s=%s %s=%s\n", ciBundleEnv, ciTokenOut, ciTokenEnv, token)
+		return nil
+	},
+}
+
+// newCIToken returns a random bundle token.
+func newCIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return ciTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// writeCIBundle copies the secrets matching patterns into a temporary
+// read-only vault keyed with token and writes it to path as an encrypted
+// backup. It returns the number of secrets in the bundle.
+func writeCIBundle(path string, patterns []string, token string, expiresAt time.Time) (int, error) {
+	allKeys, err := v.ListSecrets()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	seen := make(map[string]bool)
+	var keys []string
+	for _, pattern := range patterns {
+		matches, err := expandPattern(pattern, allKeys)
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range matches {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "secretctl-ci-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	sub := vault.New(filepath.Join(tmp, "vault"))
+	if err := sub.Init(token); err != nil {
+		return 0, fmt.Errorf("failed to create bundle vault: %w", err)
+	}
+	if err := sub.Unlock(token); err != nil {
+		return 0, fmt.Errorf("failed to open bundle vault: %w", err)
+	}
+	defer sub.Lock()
+
+	for _, key := range keys {
+		entry, err := v.GetSecret(key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get secret '%s': %w", key, err)
+		}
+		// Secrets expire with the bundle at the latest
+		expires := expiresAt
+		if entry.ExpiresAt != nil && entry.ExpiresAt.Before(expires) {
+			expires = *entry.ExpiresAt
+		}
+		copied := &vault.SecretEntry{
+			Fields:    entry.Fields,
+			Bindings:  entry.Bindings,
+			Schema:    entry.Schema,
+			Tags:      entry.Tags,
+			Metadata:  entry.Metadata,
+			ExpiresAt: &expires,
+		}
+		if err := sub.SetSecret(key, copied); err != nil {
+			return 0, fmt.Errorf("failed to copy secret '%s': %w", key, err)
+		}
+	}
+
+	if err := sub.UpdateSettings(func(s *vault.VaultSettings) error {
+		s.BlockExpiredReads = true
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to configure bundle vault: %w", err)
+	}
+	if err := sub.MakeReadOnly(&expiresAt); err != nil {
+		return 0, fmt.Errorf("failed to configure bundle vault: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create bundle file: %w", err)
+	}
+	err = backup.Backup(sub, backup.BackupOptions{Output: f, Password: []byte(token)})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return len(keys), nil
+}
+
+// openCIBundle restores the bundle at path into a temporary directory and
+// unlocks it as the vault for this command. The token is removed from the
+// environment so that commands started by run or exec do not inherit it.
+func openCIBundle(path string) error {
+	token := os.Getenv(ciTokenEnv)
+	os.Unsetenv(ciTokenEnv)
+	if token == "" {
+		return fmt.Errorf("%s is set but %s is not", ciBundleEnv, ciTokenEnv)
+	}
+
+	tmp, err := os.MkdirTemp("", "secretctl-ci-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	ciBundleDir = tmp
+	bundleVault := filepath.Join(tmp, "vault")
+	if _, err := backup.Restore(path, backup.RestoreOptions{VaultPath: bundleVault, Password: []byte(token)}); err != nil {
+		return fmt.Errorf("failed to open CI bundle: %w", err)
+	}
+
+	vaultPath = bundleVault
+	v = vault.New(vaultPath)
+	if err := v.Unlock(token); err != nil {
+		if errors.Is(err, vault.ErrVaultExpired) {
+			return fmt.Errorf("CI bundle %s has expired: %w", path, err)
+		}
+		return fmt.Errorf("failed to open CI bundle: %w", err)
+	}
+	return nil
+}
+
+// closeCIBundle removes an opened CI bundle.
+func closeCIBundle() {
+	if ciBundleDir == "" {
+		return
+	}
+	if v != nil {
+		v.Lock()
+	}
+	os.RemoveAll(ciBundleDir)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/forest6511/secretctl/pkg/vault/vaulttest"
+)
+
+func TestCIBundleRoundTrip(t *testing.T) {
+	savedV, savedPath := v, vaultPath
+	t.Cleanup(func() { v, vaultPath = savedV, savedPath })
+
+	// Generated secrets around the ones the bundle should hold
+	builder := vaulttest.NewBuilder(21).WithSecrets(10).WithAuditEvents(0)
+	for key, value := range map[string]string{"ci/deploy": "d", "ci/npm": "n", "prod/db": "p"} {
+		builder.WithEntry(key, &vault.SecretEntry{Value: []byte(value)})
+	}
+	v = builder.MustBuild(t)
+	vaultPath = v.Path()
+
+	token, err := newCIToken()
+	if err != nil {
+		t.Fatalf("newCIToken failed: %v", err)
+	}
+	if !strings.HasPrefix(token, ciTokenPrefix) {
+		t.Errorf("token %q lacks prefix %q", token, ciTokenPrefix)
+	}
+	bundle := filepath.Join(t.TempDir(), "ci-vault.enc")
+	count, err := writeCIBundle(bundle, []string{"ci/*"}, token, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("writeCIBundle failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("bundle has %d secrets, want 2", count)
+	}
+	v.Lock()
+
+	t.Setenv(ciTokenEnv, token)
+	if err := openCIBundle(bundle); err != nil {
+		t.Fatalf("openCIBundle failed: %v", err)
+	}
+	defer closeCIBundle()
+	if os.Getenv(ciTokenEnv) != "" {
+		t.Error("token should be removed from the environment")
+	}
+
+	keys, err := v.ListSecrets()
+	if err != nil {
+		t.Fatalf("ListSecrets failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("bundle keys = %v, want ci/deploy and ci/npm", keys)
+	}
+	entry, err := v.GetSecret("ci/deploy")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if string(entry.Value) != "d" {
+		t.Errorf("ci/deploy = %q, want d", entry.Value)
+	}
+	if err := v.SetSecret("ci/new", &vault.SecretEntry{Value: []byte("x")}); !errors.Is(err, vault.ErrVaultReadOnly) {
+		t.Errorf("SetSecret on bundle = %v, want ErrVaultReadOnly", err)
+	}
+	if err := v.DeleteSecret("ci/npm"); !errors.Is(err, vault.ErrVaultReadOnly) {
+		t.Errorf("DeleteSecret on bundle = %v, want ErrVaultReadOnly", err)
+	}
+}
+
+func TestOpenCIBundleRequiresToken(t *testing.T) {
+	t.Setenv(ciTokenEnv, "")
+	if err := openCIBundle(filepath.Join(t.TempDir(), "missing.enc")); err == nil {
+		t.Error("expected error without token")
+	}
+}
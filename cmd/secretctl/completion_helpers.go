@@ -107,28 +107,19 @@ func getTagsForCompletion(prefix string) ([]string, error) {
 		return nil, nil
 	}
 
-	// ListSecretsWithMetadata() returns []*SecretEntry with tags
-	secrets, err := v.ListSecretsWithMetadata()
+	all, err := v.ListTags()
 	if err != nil {
 		return nil, err
 	}
 
-	// Collect unique tags
-	tagSet := make(map[string]struct{})
+	var tags []string
 	lowerPrefix := strings.ToLower(prefix)
-	for _, secret := range secrets {
-		for _, tag := range secret.Tags {
-			if strings.HasPrefix(strings.ToLower(tag), lowerPrefix) {
-				tagSet[tag] = struct{}{}
-			}
+	for _, t := range all {
+		if strings.HasPrefix(strings.ToLower(t.Tag), lowerPrefix) {
+			tags = append(tags, t.Tag)
 		}
 	}
 
-	var tags []string
-	for tag := range tagSet {
-		tags = append(tags, tag)
-	}
-
 	return tags, nil
 }
 
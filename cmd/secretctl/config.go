@@ -0,0 +1,423 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/logging"
+	"github.com/forest6511/secretctl/pkg/notify"
+	"github.com/forest6511/secretctl/pkg/timefmt"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configFeaturesCmd)
+}
+
+// vaultSetting describes one user-configurable vault setting
+type vaultSetting struct {
+	description string
+	get         func(*vault.VaultSettings) string
+	set         func(*vault.VaultSettings, string) error
+}
+
+// vaultSettings lists the settings exposed by `secretctl config`
+var vaultSettings = map[string]vaultSetting{
+	"block_expired_reads": {
+		description: "Refuse to return expired secrets from get/run unless --allow-expired is given",
+		get: func(s *vault.VaultSettings) string {
+			return strconv.FormatBool(s.BlockExpiredReads)
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			s.BlockExpiredReads = b
+			return nil
+		},
+	},
+	"deprecated_reads": {
+		description: "What reading a deprecated key does: error or redirect",
+		get: func(s *vault.VaultSettings) string {
+			if s.DeprecatedReads == "" {
+				return vault.DeprecatedReadsError
+			}
+			return s.DeprecatedReads
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			switch value {
+			case vault.DeprecatedReadsError, vault.DeprecatedReadsRedirect:
+				s.DeprecatedReads = value
+				return nil
+			default:
+				return fmt.Errorf("expected %s or %s, got %q", vault.DeprecatedReadsError, vault.DeprecatedReadsRedirect, value)
+			}
+		},
+	},
+	"audit_host_identity": {
+		description: "Record hostname, OS user and process names in audit events",
+		get: func(s *vault.VaultSettings) string {
+			return strconv.FormatBool(!s.OmitAuditHost)
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			s.OmitAuditHost = !b
+			return nil
+		},
+	},
+	"auto_fix_permissions": {
+		description: "On unlock, restore 0700/0600 on vault files you own instead of warning about loose permissions",
+		get: func(s *vault.VaultSettings) string {
+			return strconv.FormatBool(s.AutoFixPermissions)
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			s.AutoFixPermissions = b
+			return nil
+		},
+	},
+	"search_index": {
+		description: "Keep an encrypted index of notes, URLs and non-sensitive fields so search does not decrypt every secret",
+		get: func(s *vault.VaultSettings) string {
+			return strconv.FormatBool(s.SearchIndex)
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			s.SearchIndex = b
+			return nil
+		},
+	},
+	"log_level": {
+		description: "Minimum level of diagnostic messages: debug, info, warn or error",
+		get: func(s *vault.VaultSettings) string {
+			if s.LogLevel == "" {
+				return "info"
+			}
+			return s.LogLevel
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			if _, err := logging.ParseLevel(value); err != nil {
+				return err
+			}
+			s.LogLevel = value
+			return nil
+		},
+	},
+	"log_format": {
+		description: "Format of diagnostic messages: text or json",
+		get: func(s *vault.VaultSettings) string {
+			if s.LogFormat == "" {
+				return logging.FormatText
+			}
+			return s.LogFormat
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			if err := logging.ValidateFormat(value); err != nil {
+				return err
+			}
+			s.LogFormat = value
+			return nil
+		},
+	},
+	"log_file": {
+		description: "Append diagnostic messages to this file instead of stderr (empty for stderr)",
+		get: func(s *vault.VaultSettings) string {
+			return s.LogFile
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			s.LogFile = value
+			return nil
+		},
+	},
+	"dynamic_plugins": {
+		description: "Comma-separated plugins allowed to resolve dynamic:// secrets",
+		get: func(s *vault.VaultSettings) string {
+			return strings.Join(s.DynamicPlugins, ",")
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			var plugins []string
+			for _, name := range strings.Split(value, ",") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+				if err := vault.ValidatePluginName(name); err != nil {
+					return err
+				}
+				plugins = append(plugins, name)
+			}
+			s.DynamicPlugins = plugins
+			return nil
+		},
+	},
+	"mask_policy": {
+		description: "How much of a sensitive value previews reveal: default, none, last:N or first-last:N",
+		get: func(s *vault.VaultSettings) string {
+			if s.MaskPolicy == "" {
+				return vault.MaskDefault
+			}
+			return s.MaskPolicy
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			p, err := vault.ParseMaskPolicy(value)
+			if err != nil {
+				return err
+			}
+			s.MaskPolicy = p.String()
+			return nil
+		},
+	},
+	"mask_policy_overrides": {
+		description: "Comma-separated pattern=policy pairs that replace mask_policy for matching keys",
+		get: func(s *vault.VaultSettings) string {
+			pairs := make([]string, 0, len(s.MaskOverrides))
+			for _, o := range s.MaskOverrides {
+				pairs = append(pairs, o.Pattern+"="+o.Policy)
+			}
+			return strings.Join(pairs, ",")
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			overrides, err := vault.ParseMaskOverrides(value)
+			if err != nil {
+				return err
+			}
+			s.MaskOverrides = overrides
+			return nil
+		},
+	},
+	"expiry_rules": {
+		description: "Comma-separated pattern=duration or pattern=required pairs applied to secrets set without an expiration",
+		get: func(s *vault.VaultSettings) string {
+			pairs := make([]string, 0, len(s.ExpiryRules))
+			for _, r := range s.ExpiryRules {
+				pairs = append(pairs, r.Pattern+"="+r.Expires)
+			}
+			return strings.Join(pairs, ",")
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			rules, err := vault.ParseExpiryRules(value)
+			if err != nil {
+				return err
+			}
+			s.ExpiryRules = rules
+			return nil
+		},
+	},
+	"timezone": {
+		description: "Time zone for displayed times, e.g. Asia/Tokyo (empty for the system zone)",
+		get: func(s *vault.VaultSettings) string {
+			return s.Timezone
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			if _, err := timefmt.LoadLocation(value); err != nil {
+				return err
+			}
+			s.Timezone = value
+			return nil
+		},
+	},
+	"locale": {
+		description: "Language of prompts, errors and relative times: en or ja (empty for the system locale)",
+		get: func(s *vault.VaultSettings) string {
+			return s.Locale
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			if value == "" {
+				s.Locale = ""
+				return nil
+			}
+			locale, err := timefmt.ParseLocale(value)
+			if err != nil {
+				return err
+			}
+			s.Locale = locale
+			return nil
+		},
+	},
+	"backup_dir": {
+		description: "Directory for backups created without --output (empty to require --output)",
+		get: func(s *vault.VaultSettings) string {
+			return s.BackupDir
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			if value != "" && !filepath.IsAbs(value) {
+				return fmt.Errorf("backup_dir must be an absolute path, got %q", value)
+			}
+			s.BackupDir = value
+			return nil
+		},
+	},
+	"alert_webhook": {
+		description: "URL that receives security alerts such as canary reads (empty to disable)",
+		get: func(s *vault.VaultSettings) string {
+			return s.AlertWebhook
+		},
+		set: func(s *vault.VaultSettings, value string) error {
+			if value != "" {
+				if err := notify.ValidateWebhookURL(value); err != nil {
+					return fmt.Errorf("invalid alert_webhook: %w", err)
+				}
+			}
+			s.AlertWebhook = value
+			return nil
+		},
+	},
+}
+
+// configCmd is the parent command for vault settings
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and change vault settings",
+	Long: `View and change vault-level settings stored in vault.meta.
+
+Available settings:
+  block_expired_reads   Refuse to return expired secrets from get/run
+                        unless --allow-expired is given (default: false)
+  deprecated_reads      What reading a deprecated key does: error
+                        (default) or redirect to the replacement key
+  audit_host_identity   Record hostname, OS user and process names in
+                        audit events (default: true)
+  log_level             Minimum level of diagnostic messages (default: info)
+  log_format            text (default) or json
+  log_file              Append diagnostic messages to a file instead of
+                        stderr
+  dynamic_plugins       Comma-separated plugins allowed to resolve
+                        dynamic:// secrets (default: none)
+  mask_policy           How much of a sensitive value previews reveal:
+                        default (1-4 chars: none, 5-8: last 2, 9+: last 4),
+                        none, last:N or first-last:N
+  mask_policy_overrides Comma-separated pattern=policy pairs for keys that
+                        need a different policy, e.g. "prod/*=none"
+  expiry_rules          Comma-separated pattern=duration or
+                        pattern=required pairs for secrets set without
+                        --expires, e.g. "tmp/*=7d,prod/*=required"; the
+                        first matching pattern wins
+  timezone              Time zone for displayed times, e.g. Asia/Tokyo
+                        (default: system time zone)
+  locale                Language of prompts, error descriptions and relative
+                        times: en or ja (default: from LANG)
+  backup_dir            Directory for backups created without --output
+  alert_webhook         https URL that receives a JSON POST when a canary
+                        secret is read (see 'secretctl canary')
+
+The --log-level, --log-format and --log-file flags and the
+SECRETCTL_LOG_LEVEL, SECRETCTL_LOG_FORMAT and SECRETCTL_LOG_FILE
+environment variables override the log settings. Likewise --timezone and
+--locale and SECRETCTL_TIMEZONE and SECRETCTL_LOCALE override timezone
+and locale.`,
+}
+
+// configGetCmd shows one or all settings
+var configGetCmd = &cobra.Command{
+	Use:   "get [name]",
+	Short: "Show vault settings",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := v.Settings()
+		if err != nil {
+			return fmt.Errorf("failed to read settings: %w", err)
+		}
+
+		if len(args) == 1 {
+			setting, ok := vaultSettings[args[0]]
+			if !ok {
+				return fmt.Errorf("unknown setting: %s", args[0])
+			}
+			fmt.Println(setting.get(settings))
+			return nil
+		}
+
+		names := make([]string, 0, len(vaultSettings))
+		for name := range vaultSettings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, vaultSettings[name].get(settings))
+		}
+		return nil
+	},
+}
+
+// configFeaturesCmd shows the features recorded in vault.meta
+var configFeaturesCmd = &cobra.Command{
+	Use:   "features",
+	Short: "Show the vault features recorded in vault.meta",
+	Long: `Shows the features the vault uses, such as its cipher suite. They are
+recorded when the vault is created or first unlocked by this version and
+kept in step with the settings. A version of secretctl that does not
+support one of them refuses to open the vault instead of misreading it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		features, err := v.Features()
+		if err != nil {
+			return fmt.Errorf("failed to read features: %w", err)
+		}
+		if len(features) == 0 {
+			fmt.Println("No features recorded (unlock the vault once to record them)")
+			return nil
+		}
+		names := make([]string, 0, len(features))
+		for name := range features {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, features[name])
+		}
+		return nil
+	},
+}
+
+// configSetCmd changes a setting (requires unlocking the vault)
+var configSetCmd = &cobra.Command{
+	Use:     "set <name> <value>",
+	Short:   "Change a vault setting",
+	Example: `  secretctl config set block_expired_reads true`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, value := args[0], args[1]
+		setting, ok := vaultSettings[name]
+		if !ok {
+			return fmt.Errorf("unknown setting: %s", name)
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		var updated string
+		err := v.UpdateSettings(func(s *vault.VaultSettings) error {
+			if err := setting.set(s, value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+			updated = setting.get(s)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s = %s\n", name, updated)
+		return nil
+	},
+}
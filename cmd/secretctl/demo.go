@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/forest6511/secretctl/pkg/vault/vaulttest"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Demo seed flags
+var (
+	demoSeedDir         string
+	demoSeedSeed        uint64
+	demoSeedCount       int
+	demoSeedAuditEvents int
+)
+
+func init() {
+	rootCmd.AddCommand(demoCmd)
+	demoCmd.AddCommand(demoSeedCmd)
+
+	demoSeedCmd.Flags().StringVar(&demoSeedDir, "dir", "secretctl-demo", "Directory for the demo vault (must not already contain a vault)")
+	demoSeedCmd.Flags().Uint64Var(&demoSeedSeed, "seed", 1, "Seed for deterministic fake data")
+	demoSeedCmd.Flags().IntVarP(&demoSeedCount, "count", "n", vaulttest.DefaultSecretCount, "Number of secrets to create")
+	demoSeedCmd.Flags().IntVar(&demoSeedAuditEvents, "audit-events", vaulttest.DefaultAuditEvents, "Number of synthetic audit events to record")
+}
+
+// demoCmd is the parent command for demo helpers.
+var demoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "Try secretctl with realistic fake data",
+}
+
+// demoSeedCmd creates a separate vault populated with fake secrets.
+var demoSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Create a demo vault populated with fake secrets",
+	Long: `Create a new vault filled with deterministic fake secrets, tags,
+expirations and audit history. The demo vault is created in its own
+directory and never touches your real vault (~/.secretctl).
+
+All generated values are fake and must not be used as real credentials.
+
+Examples:
+  # Create ./secretctl-demo with 20 secrets
+  secretctl demo seed
+
+  # Reproduce the same data set elsewhere
+  secretctl demo seed --dir /tmp/demo --seed 42 -n 50`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := filepath.Abs(demoSeedDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve demo directory: %w", err)
+		}
+		if dir == vaultPath {
+			return fmt.Errorf("refusing to seed the default vault at %s: choose another --dir", dir)
+		}
+		if _, err := os.Stat(filepath.Join(dir, vault.SaltFileName)); err == nil {
+			return fmt.Errorf("a vault already exists at %s", dir)
+		}
+
+		fmt.Print("Enter password for demo vault: ")
+		password, err := term.ReadPassword(int(syscall.Stdin))
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		fmt.Println()
+
+		result := vault.ValidateMasterPassword(string(password))
+		if !result.Valid {
+			return fmt.Errorf("password validation failed: %s", result.Warnings[0])
+		}
+
+		builder := vaulttest.NewBuilder(demoSeedSeed).
+			WithSecrets(demoSeedCount).
+			WithAuditEvents(demoSeedAuditEvents)
+
+		dv, err := builder.Build(dir, string(password))
+		if err != nil {
+			return fmt.Errorf("failed to seed demo vault: %w", err)
+		}
+		defer dv.Lock()
+
+		fmt.Printf("Demo vault created at %s with %d secrets (seed %d)\n", dir, demoSeedCount, demoSeedSeed)
+		return nil
+	},
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Deprecate command flags
+var (
+	deprecateReplacedBy string
+	deprecateUndo       bool
+)
+
+func init() {
+	rootCmd.AddCommand(deprecateCmd)
+
+	deprecateCmd.Flags().StringVar(&deprecateReplacedBy, "replaced-by", "", "Key that replaces the deprecated key")
+	deprecateCmd.Flags().BoolVar(&deprecateUndo, "undo", false, "Remove the deprecation")
+	deprecateCmd.MarkFlagsMutuallyExclusive("replaced-by", "undo")
+	deprecateCmd.MarkFlagsOneRequired("replaced-by", "undo")
+}
+
+// deprecateCmd marks a key as replaced by another key
+var deprecateCmd = &cobra.Command{
+	Use:   "deprecate <key>",
+	Short: "Mark a key as deprecated in favor of another key",
+	Long: `Mark a key as deprecated and point it at its replacement.
+
+The deprecated secret is kept, but reading it (get, run, exec, MCP tools)
+no longer returns its value. What happens instead depends on the
+deprecated_reads setting:
+
+  error     (default) the read fails and names the replacement key
+  redirect  the replacement is returned and a warning is printed
+
+MCP secret_list and secret_exists mark deprecated keys so AI agents
+switch to the replacement.
+
+Examples:
+  secretctl deprecate old/db-password --replaced-by db/prod/password
+  secretctl deprecate old/db-password --undo
+  secretctl config set deprecated_reads redirect`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if deprecateUndo {
+			if err := v.UndeprecateSecret(key); err != nil {
+				return fmt.Errorf("failed to remove deprecation: %w", err)
+			}
+			fmt.Printf("Key '%s' is no longer deprecated\n", key)
+			return nil
+		}
+
+		if err := v.DeprecateSecret(key, deprecateReplacedBy); err != nil {
+			if errors.Is(err, vault.ErrRedirectCycle) {
+				return fmt.Errorf("cannot deprecate '%s': '%s' already redirects back to it", key, deprecateReplacedBy)
+			}
+			return fmt.Errorf("failed to deprecate key: %w", err)
+		}
+		fmt.Printf("Key '%s' deprecated, replaced by '%s'\n", key, deprecateReplacedBy)
+		return nil
+	},
+}
+
+// warnRedirected prints a warning when a read was redirected from a deprecated key.
+func warnRedirected(entry *vault.SecretEntry) {
+	if entry != nil && entry.RedirectedFrom != "" {
+		slog.Warn("key is deprecated, using its replacement", "key", entry.RedirectedFrom, "replacement", entry.Key)
+	}
+}
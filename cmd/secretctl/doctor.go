@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Doctor command flags
+var (
+	doctorDuplicates bool
+	doctorJSON       bool
+)
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().BoolVar(&doctorDuplicates, "duplicates", false, "Also report secret values stored more than once (unlocks the vault)")
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output in JSON format")
+}
+
+// doctorReport is the JSON output of doctor.
+type doctorReport struct {
+	Integrity  *vault.IntegrityCheckResult `json:"integrity"`
+	Duplicates []vault.DuplicateGroup      `json:"duplicates,omitempty"`
+}
+
+// doctorCmd checks the vault for problems
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the vault for problems",
+	Long: `Checks the vault files: the salt, vault.meta, the database and their
+permissions. This does not need the vault to be unlocked.
+
+--duplicates also unlocks the vault and reports sensitive values that are
+stored more than once, such as a password reused across services.
+Values are compared by keyed digests in memory; neither values nor
+digests are printed or saved. Only the keys and fields sharing a value
+are shown.
+
+Exits with 7 if the vault failed a check, and with 1 if duplicate values
+were found.
+
+Examples:
+  secretctl doctor
+  secretctl doctor --duplicates
+  secretctl doctor --duplicates --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		integrity, err := v.CheckIntegrity()
+		if err != nil {
+			return fmt.Errorf("failed to check vault: %w", err)
+		}
+		report := doctorReport{Integrity: integrity}
+
+		if doctorDuplicates && integrity.Valid {
+			if err := ensureUnlocked(); err != nil {
+				return err
+			}
+			defer v.Lock()
+			if report.Duplicates, err = v.FindDuplicateValues(); err != nil {
+				return fmt.Errorf("failed to find duplicate values: %w", err)
+			}
+		}
+
+		if doctorJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			printDoctorReport(report)
+		}
+
+		if !integrity.Valid {
+			return &exitError{code: ExitCorrupted, err: errors.New("vault check failed")}
+		}
+		if len(report.Duplicates) > 0 {
+			return fmt.Errorf("found %d duplicate values", len(report.Duplicates))
+		}
+		return nil
+	},
+}
+
+func printDoctorReport(report doctorReport) {
+	if report.Integrity.Valid {
+		fmt.Println("✓ Vault files: ok")
+	} else {
+		fmt.Println("✗ Vault files:")
+		for _, e := range report.Integrity.Errors {
+			fmt.Printf("    - %s\n", e)
+		}
+		if doctorDuplicates {
+			fmt.Println("  Duplicate values were not checked")
+		}
+		return
+	}
+	if !doctorDuplicates {
+		return
+	}
+	if len(report.Duplicates) == 0 {
+		fmt.Println("✓ Duplicate values: none")
+		return
+	}
+	fmt.Printf("✗ Duplicate values: %d\n", len(report.Duplicates))
+	for i, group := range report.Duplicates {
+		fmt.Printf("  %d. %d fields share a value:\n", i+1, len(group.Locations))
+		for _, loc := range group.Locations {
+			fmt.Printf("     - %s (%s)\n", loc.Key, loc.Field)
+		}
+	}
+}
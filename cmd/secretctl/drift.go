@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Drift statuses
+const (
+	driftCurrent = "current"
+	driftStale   = "stale"
+)
+
+// Drift command flags
+var (
+	driftPID     int
+	driftEnvFile string
+	driftKeys    []string
+	driftJSON    bool
+)
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().IntVar(&driftPID, "pid", 0, "Process whose environment to check (Linux)")
+	driftCmd.Flags().StringVar(&driftEnvFile, "env-file", "", ".env file to check")
+	driftCmd.Flags().StringArrayVarP(&driftKeys, "keys", "k", nil, "Secret keys to compare (glob pattern supported, default: all)")
+	driftCmd.Flags().BoolVar(&driftJSON, "json", false, "Output in JSON format")
+	driftCmd.MarkFlagsMutuallyExclusive("pid", "env-file")
+	driftCmd.MarkFlagsOneRequired("pid", "env-file")
+}
+
+// driftCmd compares an environment with the vault
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Find environments still using outdated secret values",
+	Long: `Compare the environment of a running process or a .env file with the
+values the vault would inject, to find services still running with
+credentials that have since been rotated.
+
+Variables are matched to secrets through their bindings, or by the name
+'run' derives from the key (db/password -> DB_PASSWORD). Values are only
+compared as SHA-256 hashes computed locally and are never printed.
+Variables the vault does not manage are ignored. Canary secrets are
+skipped so that a drift check never triggers them.
+
+For a process, the report includes when it started: a process started
+before the secret last changed cannot have picked up the new value.
+The command exits with an error when a stale value is found.
+
+Examples:
+  secretctl drift --pid 4242
+  secretctl drift --env-file deploy/.env -k 'db/*'
+  secretctl drift --pid "$(pidof api)" --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var env map[string][sha256.Size]byte
+		var startedAt *time.Time
+		var source string
+		if driftEnvFile != "" {
+			data, err := os.ReadFile(driftEnvFile)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", driftEnvFile, err)
+			}
+			values, err := parseEnvFile(data)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", driftEnvFile, err)
+			}
+			env = make(map[string][sha256.Size]byte, len(values))
+			for name, value := range values {
+				env[name] = sha256.Sum256([]byte(value))
+			}
+			source = driftEnvFile
+		} else {
+			data, started, err := readProcessEnv(driftPID)
+			if err != nil {
+				return err
+			}
+			env = hashEnviron(data)
+			startedAt = started
+			source = fmt.Sprintf("pid %d", driftPID)
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		managed, err := managedEnv(driftKeys)
+		if err != nil {
+			return err
+		}
+		results := compareDrift(env, managed, startedAt)
+
+		if driftJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(driftReport{Source: source, StartedAt: startedAt, Results: results}); err != nil {
+				return err
+			}
+		} else {
+			printDrift(source, startedAt, results)
+		}
+		for _, r := range results {
+			if r.Status == driftStale {
+				return fmt.Errorf("%s has stale secret values", source)
+			}
+		}
+		return nil
+	},
+}
+
+// managedVar is an environment variable the vault would inject.
+type managedVar struct {
+	key       string
+	env       string
+	hash      [sha256.Size]byte
+	changedAt time.Time
+}
+
+// driftResult is the comparison of one variable.
+type driftResult struct {
+	Env       string    `json:"env"`
+	Key       string    `json:"key"`
+	Status    string    `json:"status"`
+	ChangedAt time.Time `json:"changed_at"`
+	// StartedBefore is set when the process started before the secret last
+	// changed.
+	StartedBefore bool `json:"started_before_change,omitempty"`
+}
+
+// driftReport is the JSON output of drift
+type driftReport struct {
+	Source    string        `json:"source"`
+	StartedAt *time.Time    `json:"started_at,omitempty"`
+	Results   []driftResult `json:"results"`
+}
+
+// hashEnviron hashes the values of a NUL-separated environ block.
+func hashEnviron(data []byte) map[string][sha256.Size]byte {
+	env := make(map[string][sha256.Size]byte)
+	for _, kv := range bytes.Split(data, []byte{0}) {
+		name, value, ok := bytes.Cut(kv, []byte{'='})
+		if !ok || len(name) == 0 {
+			continue
+		}
+		env[string(name)] = sha256.Sum256(value)
+	}
+	return env
+}
+
+// managedEnv returns the variables the secrets matching patterns (all
+// secrets if none) would inject, with hashed values.
+func managedEnv(patterns []string) ([]managedVar, error) {
+	keys, err := v.ListSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	if len(patterns) > 0 {
+		seen := make(map[string]bool)
+		var matched []string
+		for _, pattern := range patterns {
+			matches, err := expandPattern(pattern, keys)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range matches {
+				if !seen[key] {
+					seen[key] = true
+					matched = append(matched, key)
+				}
+			}
+		}
+		keys = matched
+	}
+	canaries, err := v.ListCanaries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list canaries: %w", err)
+	}
+	isCanary := make(map[string]bool, len(canaries))
+	for _, key := range canaries {
+		isCanary[key] = true
+	}
+
+	var managed []managedVar
+	for _, key := range keys {
+		if isCanary[key] {
+			continue
+		}
+		entry, err := v.GetSecret(key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping '%s': %v\n", key, err)
+			continue
+		}
+		changedAt := lastValueChange(key, entry.UpdatedAt)
+		if len(entry.Bindings) == 0 {
+			managed = append(managed, managedVar{key: key, env: keyToEnvName(key), hash: sha256.Sum256(entry.Value), changedAt: changedAt})
+			continue
+		}
+		for env, field := range entry.Bindings {
+			if f, ok := entry.Fields[field]; ok {
+				managed = append(managed, managedVar{key: key, env: env, hash: sha256.Sum256([]byte(f.Value)), changedAt: changedAt})
+			}
+		}
+	}
+	return managed, nil
+}
+
+// lastValueChange returns when the value of key last changed according to
+// its history, or fallback if no history is recorded.
+func lastValueChange(key string, fallback time.Time) time.Time {
+	history, err := v.GetSecretHistory(key)
+	if err != nil {
+		return fallback
+	}
+	var last time.Time
+	for _, h := range history {
+		if (h.Summary.Created || h.Summary.ValueChanged()) && h.ChangedAt.After(last) {
+			last = h.ChangedAt
+		}
+	}
+	if last.IsZero() {
+		return fallback
+	}
+	return last
+}
+
+// compareDrift compares env with the managed variables it contains.
+func compareDrift(env map[string][sha256.Size]byte, managed []managedVar, startedAt *time.Time) []driftResult {
+	results := []driftResult{}
+	for _, m := range managed {
+		hash, ok := env[m.env]
+		if !ok {
+			continue
+		}
+		r := driftResult{Env: m.env, Key: m.key, Status: driftCurrent, ChangedAt: m.changedAt}
+		if hash != m.hash {
+			r.Status = driftStale
+			r.StartedBefore = startedAt != nil && startedAt.Before(m.changedAt)
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Env < results[j].Env })
+	return results
+}
+
+// printDrift prints a drift report as a table.
+func printDrift(source string, startedAt *time.Time, results []driftResult) {
+	if startedAt != nil {
+		fmt.Printf("%s started %s\n", source, startedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if len(results) == 0 {
+		fmt.Printf("No vault-managed variables found in %s\n", source)
+		return
+	}
+
+	envWidth, keyWidth := len("VARIABLE"), len("KEY")
+	for _, r := range results {
+		envWidth = max(envWidth, len(r.Env))
+		keyWidth = max(keyWidth, len(r.Key))
+	}
+	fmt.Printf("%-*s  %-*s  %-8s  %s\n", envWidth, "VARIABLE", keyWidth, "KEY", "STATUS", "VAULT CHANGED")
+	fmt.Printf("%s\n", strings.Repeat("-", envWidth+keyWidth+33))
+	stale := 0
+	for _, r := range results {
+		note := ""
+		if r.Status == driftStale {
+			stale++
+			if r.StartedBefore {
+				note = "  (process predates change)"
+			}
+		}
+		fmt.Printf("%-*s  %-*s  %-8s  %s%s\n", envWidth, r.Env, keyWidth, r.Key, r.Status,
+			r.ChangedAt.Local().Format("2006-01-02 15:04"), note)
+	}
+	fmt.Printf("\n%d variables checked, %d stale\n", len(results), stale)
+}
@@ -0,0 +1,67 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of /proc/<pid>/stat times. It is 100 on
+// every mainstream Linux architecture.
+const clockTicks = 100
+
+// readProcessEnv returns the environ block of pid and when it started.
+// Reading another user's process requires the same permissions as ptrace.
+func readProcessEnv(pid int) ([]byte, *time.Time, error) {
+	if pid <= 0 {
+		return nil, nil, fmt.Errorf("invalid pid %d", pid)
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read environment of pid %d: %w", pid, err)
+	}
+	return data, processStartTime(pid), nil
+}
+
+// processStartTime returns when pid started, or nil if it cannot be determined.
+func processStartTime(pid int) *time.Time {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil
+	}
+	// The command name may contain spaces; fields resume after its ')'
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return nil
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	// starttime is field 22 overall, the 20th after the command name
+	if len(fields) < 20 {
+		return nil
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	procStat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(procStat), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			boot, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return nil
+			}
+			t := time.Unix(boot, 0).Add(time.Duration(ticks) * time.Second / clockTicks)
+			return &t
+		}
+	}
+	return nil
+}
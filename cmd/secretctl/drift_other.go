@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// readProcessEnv is only implemented on Linux, where /proc exposes the
+// environment of other processes.
+func readProcessEnv(pid int) ([]byte, *time.Time, error) {
+	return nil, nil, errors.New("--pid is only supported on Linux; use --env-file")
+}
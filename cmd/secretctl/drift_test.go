@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestHashEnviron(t *testing.T) {
+	env := hashEnviron([]byte("A=1\x00B=x=y\x00=bad\x00C\x00"))
+	if len(env) != 2 {
+		t.Fatalf("hashEnviron parsed %d variables, want 2", len(env))
+	}
+	if env["B"] != sha256.Sum256([]byte("x=y")) {
+		t.Error("value containing '=' hashed incorrectly")
+	}
+}
+
+func TestDriftCompare(t *testing.T) {
+	savedV, savedPath := v, vaultPath
+	t.Cleanup(func() { v, vaultPath = savedV, savedPath })
+
+	vaultPath = filepath.Join(t.TempDir(), ".secretctl")
+	v = vault.New(vaultPath)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("db/password", &vault.SecretEntry{Value: []byte("new")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("api", &vault.SecretEntry{
+		Fields:   map[string]vault.Field{"token": {Value: "tok", Sensitive: true}},
+		Bindings: map[string]string{"API_TOKEN": "token"},
+	}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.CreateCanary("aws/root", &vault.SecretEntry{Value: []byte("decoy")}); err != nil {
+		t.Fatalf("CreateCanary failed: %v", err)
+	}
+
+	managed, err := managedEnv(nil)
+	if err != nil {
+		t.Fatalf("managedEnv failed: %v", err)
+	}
+	if len(managed) != 2 {
+		t.Fatalf("managedEnv returned %d variables, want 2 (canary skipped)", len(managed))
+	}
+
+	env := hashEnviron([]byte("DB_PASSWORD=old\x00API_TOKEN=tok\x00AWS_ROOT=decoy\x00PATH=/bin\x00"))
+	started := time.Now().Add(-time.Hour)
+	results := compareDrift(env, managed, &started)
+	if len(results) != 2 {
+		t.Fatalf("compareDrift returned %+v, want 2 results", results)
+	}
+	if r := results[0]; r.Env != "API_TOKEN" || r.Status != driftCurrent {
+		t.Errorf("API_TOKEN result = %+v, want current", r)
+	}
+	if r := results[1]; r.Env != "DB_PASSWORD" || r.Status != driftStale || !r.StartedBefore {
+		t.Errorf("DB_PASSWORD result = %+v, want stale and started before change", r)
+	}
+}
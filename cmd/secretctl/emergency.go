@@ -0,0 +1,322 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/emergency"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Emergency command flags
+var (
+	emergencyOut      string
+	emergencyPubKey   string
+	emergencyWait     string
+	emergencyKeyFile  string
+	emergencyVaultDir string
+)
+
+func init() {
+	rootCmd.AddCommand(emergencyCmd)
+	emergencyCmd.AddCommand(emergencyKeygenCmd)
+	emergencyCmd.AddCommand(emergencyAddCmd)
+	emergencyCmd.AddCommand(emergencyListCmd)
+	emergencyCmd.AddCommand(emergencyRemoveCmd)
+	emergencyCmd.AddCommand(emergencyRequestCmd)
+	emergencyCmd.AddCommand(emergencyDenyCmd)
+	emergencyCmd.AddCommand(emergencyReleaseCmd)
+
+	emergencyKeygenCmd.Flags().StringVarP(&emergencyOut, "out", "o", "", "File to write the private key to (required)")
+	_ = emergencyKeygenCmd.MarkFlagRequired("out")
+
+	emergencyAddCmd.Flags().StringVar(&emergencyPubKey, "pubkey", "", "The contact's public key, or a file containing it (required)")
+	emergencyAddCmd.Flags().StringVar(&emergencyWait, "wait", "7d", "How long a request must go undenied before access is granted")
+	_ = emergencyAddCmd.MarkFlagRequired("pubkey")
+
+	for _, cmd := range []*cobra.Command{emergencyRequestCmd, emergencyReleaseCmd} {
+		cmd.Flags().StringVar(&emergencyKeyFile, "key", "", "File containing your private key (required)")
+		cmd.Flags().StringVar(&emergencyVaultDir, "vault", "", "The owner's vault directory (default: ~/.secretctl)")
+		_ = cmd.MarkFlagRequired("key")
+	}
+	emergencyReleaseCmd.Flags().StringVarP(&emergencyOut, "out", "o", vault.RecoveryKitFileName, "File to write the recovery kit to")
+}
+
+// emergencyCmd is the parent command for emergency access
+var emergencyCmd = &cobra.Command{
+	Use:   "emergency",
+	Short: "Let trusted contacts access the vault if you cannot",
+	Long: `Emergency access lets a trusted contact recover your vault if you are
+unable to, for example after an accident. Each contact gets a waiting
+period. When they request access, you are warned every time the vault is
+unlocked; if you do not deny the request before the waiting period ends,
+the contact can release a recovery kit for the vault.
+
+  1. The contact runs 'secretctl emergency keygen' and sends you the
+     public key it prints.
+  2. You run 'secretctl emergency add <name> --pubkey <key> --wait 7d'.
+     The vault's data encryption key is sealed to the contact's key and
+     stored with the vault settings.
+  3. In an emergency, the contact runs 'secretctl emergency request'
+     against your vault directory, and after the waiting period
+     'secretctl emergency release'.
+
+Everything is local: requests are signed files in the vault directory, so
+the contact needs access to it (a shared or synced folder, or a backup).
+
+The waiting period is enforced by secretctl, not by cryptography. Anyone
+with the contact's private key and a copy of vault.meta can open the
+sealed key without waiting. Only add contacts you would trust with the
+vault itself, and keep vault.meta out of their reach until needed.`,
+}
+
+// emergencyKeygenCmd creates a contact key pair
+var emergencyKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Create a key pair for becoming someone's emergency contact",
+	Long: `Creates a key pair for becoming an emergency contact. The private key is
+written to --out and the public key is printed; send the public key to the
+vault owner. Keep the private key safe: it is needed to request and
+release access.
+
+Examples:
+  secretctl emergency keygen --out ~/emergency-alice.key`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := emergency.GenerateKey()
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(emergencyOut, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			if errors.Is(err, os.ErrExist) {
+				return &exitError{code: ExitConflict, err: fmt.Errorf("key file already exists: %s", emergencyOut)}
+			}
+			return fmt.Errorf("failed to create key file: %w", err)
+		}
+		if _, err := fmt.Fprintln(f, key.String()); err != nil {
+			f.Close()
+			os.Remove(emergencyOut)
+			return fmt.Errorf("failed to write key file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write key file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Private key written to %s\n", emergencyOut)
+		fmt.Println(key.Public().String())
+		return nil
+	},
+}
+
+// emergencyAddCmd adds an emergency contact
+var emergencyAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add an emergency contact",
+	Long: `Adds an emergency contact. The waiting period is how long a request must
+go undenied before the contact can release access; it is at least 1h.
+
+Examples:
+  secretctl emergency add alice --pubkey alice.pub --wait 7d`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pub, err := readEmergencyPublicKey(emergencyPubKey)
+		if err != nil {
+			return err
+		}
+		wait, err := parseDuration(emergencyWait)
+		if err != nil {
+			return &exitError{code: ExitUsage, err: fmt.Errorf("invalid --wait: %w", err)}
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.AddEmergencyContact(args[0], pub, wait); err != nil {
+			return fmt.Errorf("failed to add emergency contact: %w", err)
+		}
+		fmt.Printf("Added emergency contact '%s' with a waiting period of %s\n", args[0], emergencyWait)
+		return nil
+	},
+}
+
+// emergencyListCmd lists emergency contacts and pending requests
+var emergencyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List emergency contacts and pending requests",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vlt := vault.New(vaultPath)
+		contacts, err := vlt.EmergencyContacts()
+		if err != nil {
+			return err
+		}
+		if len(contacts) == 0 {
+			fmt.Println("No emergency contacts")
+			return nil
+		}
+		requests, err := vlt.EmergencyRequests()
+		if err != nil {
+			return err
+		}
+		pending := make(map[string]vault.EmergencyRequest, len(requests))
+		for _, r := range requests {
+			pending[r.Contact] = r
+		}
+
+		now := time.Now()
+		for _, c := range contacts {
+			line := fmt.Sprintf("%-20s wait %-8s added %s", c.Name, c.Wait, tf.Date(c.AddedAt))
+			if r, ok := pending[c.Name]; ok {
+				if now.Before(r.ReleaseAt) {
+					line += fmt.Sprintf("  REQUESTED %s, granted %s", tf.Relative(r.RequestedAt, now), tf.Time(r.ReleaseAt))
+				} else {
+					line += fmt.Sprintf("  REQUESTED %s, granted", tf.Relative(r.RequestedAt, now))
+				}
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+// emergencyRemoveCmd removes an emergency contact
+var emergencyRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an emergency contact",
+	Long: `Removes an emergency contact and any pending request from them.
+
+A contact who has already released access, or kept a copy of vault.meta,
+still holds the vault's data encryption key. Rotate it to revoke that.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.RemoveEmergencyContact(args[0]); err != nil {
+			return fmt.Errorf("failed to remove emergency contact: %w", err)
+		}
+		fmt.Printf("Removed emergency contact '%s'\n", args[0])
+		return nil
+	},
+}
+
+// emergencyRequestCmd requests emergency access as a contact
+var emergencyRequestCmd = &cobra.Command{
+	Use:   "request <name>",
+	Short: "Request emergency access to someone's vault",
+	Long: `Requests emergency access as the contact <name>. The request is signed
+with your private key and written to the vault directory; the owner does
+not need to be present. Requesting again does not restart the waiting
+period.
+
+Examples:
+  secretctl emergency request alice --key ~/emergency-alice.key --vault /mnt/shared/.secretctl`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := readEmergencyPrivateKey(emergencyKeyFile)
+		if err != nil {
+			return err
+		}
+		releaseAt, err := emergencyVault().RequestEmergencyAccess(args[0], key)
+		if err != nil {
+			return fmt.Errorf("failed to request emergency access: %w", err)
+		}
+		fmt.Printf("Emergency access requested. Unless the owner denies it, run\n'secretctl emergency release %s' after %s.\n", args[0], tf.Time(releaseAt))
+		return nil
+	},
+}
+
+// emergencyDenyCmd denies a pending request
+var emergencyDenyCmd = &cobra.Command{
+	Use:   "deny <name>",
+	Short: "Deny a pending emergency access request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.DenyEmergencyAccess(args[0]); err != nil {
+			return fmt.Errorf("failed to deny emergency access: %w", err)
+		}
+		fmt.Printf("Denied emergency access for '%s'\n", args[0])
+		return nil
+	},
+}
+
+// emergencyReleaseCmd releases access once the waiting period has ended
+var emergencyReleaseCmd = &cobra.Command{
+	Use:   "release <name>",
+	Short: "Release emergency access after the waiting period",
+	Long: `Opens the vault's data encryption key sealed to you and writes it as a
+recovery kit, once your request has gone undenied for the waiting period.
+
+To read the secrets, copy vault.db from the owner's vault directory to
+~/.secretctl on a machine without a vault and run:
+
+  secretctl init --from-dek-file <recovery kit>
+
+Examples:
+  secretctl emergency release alice --key ~/emergency-alice.key --vault /mnt/shared/.secretctl`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := readEmergencyPrivateKey(emergencyKeyFile)
+		if err != nil {
+			return err
+		}
+		vlt := emergencyVault()
+		dek, err := vlt.ReleaseEmergencyAccess(args[0], key)
+		if err != nil {
+			return fmt.Errorf("failed to release emergency access: %w", err)
+		}
+		defer crypto.SecureWipe(dek)
+
+		if err := vault.WriteRecoveryKit(emergencyOut, vlt.Path(), dek, time.Now()); err != nil {
+			return err
+		}
+		fmt.Printf("Recovery kit written to %s\n", emergencyOut)
+		return nil
+	},
+}
+
+// emergencyVault returns the vault named by --vault, or the default one.
+func emergencyVault() *vault.Vault {
+	if emergencyVaultDir != "" {
+		return vault.New(emergencyVaultDir)
+	}
+	return vault.New(vaultPath)
+}
+
+// readEmergencyPublicKey parses s as a public key, or reads it from the
+// file s names.
+func readEmergencyPublicKey(s string) (*emergency.PublicKey, error) {
+	if !strings.HasPrefix(s, emergency.PublicKeyPrefix) {
+		data, err := os.ReadFile(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		s = string(data)
+	}
+	return emergency.ParsePublicKey(s)
+}
+
+// readEmergencyPrivateKey reads a private key written by keygen.
+func readEmergencyPrivateKey(path string) (*emergency.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	defer crypto.SecureWipe(data)
+	return emergency.ParsePrivateKey(string(data))
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Exec command flags
+var (
+	execEnvTemplate  string
+	execKeys         []string
+	execBindings     []string
+	execCleanEnv     bool
+	execWriteEnvFile bool
+)
+
+// envFileVar is the variable pointing at the temporary env file written by --write-env-file.
+const envFileVar = "SECRETCTL_ENV_FILE"
+
+// execSafeEnvVars are inherited from the parent environment when --clean-env is set.
+var execSafeEnvVars = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LC_ALL", "TERM", "TZ", "TMPDIR"}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().StringVar(&execEnvTemplate, "env-file-template", "", "Render a .env template and inject its variables")
+	execCmd.Flags().StringArrayVarP(&execKeys, "key", "k", nil, "Secret keys to inject (glob pattern supported)")
+	execCmd.Flags().StringArrayVar(&execBindings, "bindings", nil, "Inject env bindings defined on a multi-field secret")
+	execCmd.Flags().BoolVar(&execCleanEnv, "clean-env", false, "Start from a minimal environment instead of inheriting the current one")
+	execCmd.Flags().BoolVar(&execWriteEnvFile, "write-env-file", false, "Also write injected variables to a temporary 0600 file exposed as "+envFileVar)
+	// Shared with `run` so both commands behave identically
+	execCmd.Flags().DurationVarP(&runTimeout, "timeout", "t", 5*time.Minute, "Command timeout")
+	execCmd.Flags().BoolVar(&runNoSanitize, "no-sanitize", false, "Disable output sanitization")
+	execCmd.Flags().BoolVar(&runAllowExpired, "allow-expired", false, "Inject expired secrets (overrides expiry checks and block_expired_reads)")
+}
+
+// execCmd is the unified developer entry point for running local tools with secrets
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- command [args...]",
+	Short: "Run a dev command with template-rendered and bound secrets",
+	Long: `Run an arbitrary local command (go test, make, npm, ...) with secrets
+injected from any combination of:
+
+  --env-file-template  a .env template rendered against the vault
+  --bindings           env bindings stored on a multi-field secret
+  -k/--key             secrets injected by key (same rules as 'run')
+
+Template syntax (Go text/template):
+  DATABASE_URL={{ secret "db/dev/url" }}
+  DB_PASSWORD={{ field "db/dev" "password" }}
+  # comments and blank lines are ignored
+
+A variable defined by more than one source is an error. Secret values are
+sanitized from the command's output, and any temporary env file is removed
+when the command exits.
+
+Examples:
+  secretctl exec --env-file-template .env.tmpl -- make test
+  secretctl exec --bindings db/dev -k "api/dev/*" -- go test ./...
+  secretctl exec --env-file-template .env.tmpl --write-env-file -- docker compose up`,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dashIndex := cmd.ArgsLenAtDash()
+		if dashIndex == -1 || dashIndex >= len(args) {
+			return fmt.Errorf("no command specified; use: secretctl exec [flags] -- command [args...]")
+		}
+		if execEnvTemplate == "" && len(execKeys) == 0 && len(execBindings) == 0 {
+			return fmt.Errorf("nothing to inject: specify --env-file-template, --bindings or --key")
+		}
+		return executeExec(args[dashIndex:])
+	},
+}
+
+// injectedVar is one environment variable produced by exec and where it came from.
+type injectedVar struct {
+	name   string
+	value  []byte
+	source string
+	// keys are the vault keys the value was read from
+	keys []string
+	// line is the line of the template or file that defined the variable
+	line int
+}
+
+// executeExec merges all injection sources and runs the command
+func executeExec(commandArgs []string) error {
+	if err := ensureUnlocked(); err != nil {
+		return err
+	}
+	defer v.Lock()
+
+	var vars []injectedVar
+
+	if execEnvTemplate != "" {
+		data, err := os.ReadFile(execEnvTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to read env template: %w", err)
+		}
+		rendered, err := renderEnvTemplate(execEnvTemplate, string(data), secretValueLookup())
+		if err != nil {
+			return err
+		}
+		vars = append(vars, rendered...)
+	}
+
+	for _, key := range execBindings {
+		bound, err := bindingVars(key, secretValueLookup())
+		if err != nil {
+			return err
+		}
+		vars = append(vars, bound...)
+	}
+
+	if len(execKeys) > 0 {
+		secrets, err := collectSecrets(execKeys)
+		if err != nil {
+			return err
+		}
+		for _, s := range secrets {
+			vars = append(vars, injectedVar{name: keyToEnvName(s.key), value: s.value, source: "key " + s.key, keys: []string{s.key}})
+		}
+	}
+
+	// One entry per vault key, so that usage is recorded under the key
+	// rather than the variable name
+	secrets := make([]secretData, 0, len(vars))
+	for _, iv := range vars {
+		if len(iv.keys) == 0 {
+			secrets = append(secrets, secretData{value: iv.value, envName: iv.name})
+		}
+		for _, key := range iv.keys {
+			secrets = append(secrets, secretData{key: key, value: iv.value, envName: iv.name})
+		}
+	}
+	defer wipeSecrets(secrets)
+
+	env, err := mergeExecEnvironment(baseExecEnvironment(), vars)
+	if err != nil {
+		return err
+	}
+
+	if execWriteEnvFile {
+		path, err := writeTempEnvFile(vars)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(path)
+		env = append(env, envFileVar+"="+path)
+	}
+
+	return executeCommand(commandArgs, env, secrets)
+}
+
+// baseExecEnvironment returns the inherited environment for exec.
+func baseExecEnvironment() []string {
+	if !execCleanEnv {
+		return os.Environ()
+	}
+	var env []string
+	for _, name := range execSafeEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// secretLookup fetches a secret entry by key.
+type secretLookup func(key string) (*vault.SecretEntry, error)
+
+// renderEnvTemplate renders a .env template and parses the result into variables.
+func renderEnvTemplate(name, text string, lookup secretLookup) ([]injectedVar, error) {
+	var buf bytes.Buffer
+	defer func() { wipeBytes(buf.Bytes()) }()
+
+	// The template writes its output as it runs, so the current line of buf
+	// is the line a key is read for
+	keysByLine := make(map[int][]string)
+	cache := make(map[string]*vault.SecretEntry)
+	get := func(key string) (*vault.SecretEntry, error) {
+		line := bytes.Count(buf.Bytes(), []byte("\n")) + 1
+		if !slices.Contains(keysByLine[line], key) {
+			keysByLine[line] = append(keysByLine[line], key)
+		}
+		if entry, ok := cache[key]; ok {
+			return entry, nil
+		}
+		entry, err := lookup(key)
+		if err != nil {
+			return nil, fmt.Errorf("secret '%s': %w", obfuscateKey(key), err)
+		}
+		if !runAllowExpired && entry.ExpiresAt != nil && entry.ExpiresAt.Before(time.Now()) {
+			return nil, fmt.Errorf("secret '%s' has expired at %v", obfuscateKey(key), entry.ExpiresAt.Format(time.RFC3339))
+		}
+		cache[key] = entry
+		return entry, nil
+	}
+
+	funcs := template.FuncMap{
+		"secret": func(key string) (string, error) {
+			entry, err := get(key)
+			if err != nil {
+				return "", err
+			}
+			return vault.GetDefaultFieldValue(entry.Fields), nil
+		},
+		"field": func(key, fieldName string) (string, error) {
+			entry, err := get(key)
+			if err != nil {
+				return "", err
+			}
+			_, f, err := vault.ResolveFieldName(entry.Fields, fieldName)
+			if err != nil {
+				return "", fmt.Errorf("secret '%s': %w", obfuscateKey(key), err)
+			}
+			return f.Value, nil
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env template: %w", err)
+	}
+
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("failed to render env template: %w", err)
+	}
+
+	vars, err := parseDotenv(buf.Bytes(), "template "+name)
+	if err != nil {
+		return nil, err
+	}
+	defined := make(map[int]bool, len(vars))
+	for i := range vars {
+		vars[i].keys = keysByLine[vars[i].line]
+		defined[vars[i].line] = true
+	}
+	// A key read on a line that defines nothing, as in {{ $x := secret "k" }},
+	// may end up in any variable
+	var stray []string
+	for line, keys := range keysByLine {
+		if !defined[line] {
+			stray = append(stray, keys...)
+		}
+	}
+	if len(stray) > 0 {
+		for i := range vars {
+			vars[i].keys = slices.Concat(vars[i].keys, stray)
+		}
+	}
+	return vars, nil
+}
+
+// parseDotenv parses KEY=VALUE lines. Blank lines, comments and an optional
+// "export " prefix are accepted; matching surrounding quotes are stripped.
+func parseDotenv(data []byte, source string) ([]injectedVar, error) {
+	var vars []injectedVar
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("%s line %d: expected NAME=value", source, lineNo)
+		}
+		name = strings.TrimSpace(name)
+		if err := validateEnvName(name); err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid variable name %q: %w", source, lineNo, name, err)
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars = append(vars, injectedVar{name: name, value: []byte(value), source: source, line: lineNo})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return vars, nil
+}
+
+// bindingVars resolves the env bindings stored on a multi-field secret.
+func bindingVars(key string, lookup secretLookup) ([]injectedVar, error) {
+	entry, err := lookup(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret '%s': %w", obfuscateKey(key), err)
+	}
+	if len(entry.Bindings) == 0 {
+		return nil, fmt.Errorf("secret '%s' has no env bindings", obfuscateKey(key))
+	}
+
+	names := make([]string, 0, len(entry.Bindings))
+	for name := range entry.Bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make([]injectedVar, 0, len(names))
+	for _, name := range names {
+		_, f, err := vault.ResolveFieldName(entry.Fields, entry.Bindings[name])
+		if err != nil {
+			return nil, fmt.Errorf("secret '%s' binding %s: %w", obfuscateKey(key), name, err)
+		}
+		vars = append(vars, injectedVar{name: name, value: []byte(f.Value), source: "bindings " + key, keys: []string{key}})
+	}
+	return vars, nil
+}
+
+// mergeExecEnvironment appends injected variables to base, rejecting
+// duplicates across sources and reserved names.
+func mergeExecEnvironment(base []string, vars []injectedVar) ([]string, error) {
+	defined := make(map[string]string, len(vars))
+	env := base
+	for _, iv := range vars {
+		if prev, ok := defined[iv.name]; ok {
+			return nil, fmt.Errorf("%s is defined by both %s and %s", iv.name, prev, iv.source)
+		}
+		defined[iv.name] = iv.source
+
+		if err := validateEnvName(iv.name); err != nil {
+			return nil, fmt.Errorf("invalid environment variable name %q from %s: %w", iv.name, iv.source, err)
+		}
+		if err := validateNoNulBytes(iv.name, iv.value); err != nil {
+			return nil, err
+		}
+		if err := checkReservedEnvVar(iv.name); err != nil {
+			return nil, err
+		}
+		env = append(env, iv.name+"="+string(iv.value))
+	}
+	return env, nil
+}
+
+// writeTempEnvFile writes vars to a private temporary file and returns its path.
+// The caller must remove the file.
+func writeTempEnvFile(vars []injectedVar) (string, error) {
+	f, err := os.CreateTemp("", "secretctl-env-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp env file: %w", err)
+	}
+	path := f.Name()
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to secure temp env file: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for _, iv := range vars {
+		buf.WriteString(iv.name)
+		buf.WriteByte('=')
+		buf.Write(iv.value)
+		buf.WriteByte('\n')
+	}
+	_, err = f.Write(buf.Bytes())
+	wipeBytes(buf.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write temp env file: %w", err)
+	}
+	return path, nil
+}
+
+// wipeBytes zeroes a byte slice.
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
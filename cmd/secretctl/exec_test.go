@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// fakeLookup returns a secretLookup backed by a map
+func fakeLookup(entries map[string]*vault.SecretEntry) secretLookup {
+	return func(key string) (*vault.SecretEntry, error) {
+		if e, ok := entries[key]; ok {
+			return e, nil
+		}
+		return nil, vault.ErrSecretNotFound
+	}
+}
+
+func TestRenderEnvTemplate(t *testing.T) {
+	lookup := fakeLookup(map[string]*vault.SecretEntry{
+		"db/dev": {
+			Fields: map[string]vault.Field{
+				"host":     {Value: "localhost"},
+				"password": {Value: "p@ss"},
+			},
+		},
+		"api/dev/token": {
+			Fields: map[string]vault.Field{vault.DefaultFieldName: {Value: "tok123"}},
+		},
+	})
+
+	text := `# dev settings
+export API_TOKEN={{ secret "api/dev/token" }}
+DB_URL="postgres://app:{{ field "db/dev" "password" }}@{{ field "db/dev" "host" }}/app"
+
+PLAIN=value`
+
+	vars, err := renderEnvTemplate(".env.tmpl", text, lookup)
+	if err != nil {
+		t.Fatalf("renderEnvTemplate failed: %v", err)
+	}
+
+	want := map[string]string{
+		"API_TOKEN": "tok123",
+		"DB_URL":    "postgres://app:p@ss@localhost/app",
+		"PLAIN":     "value",
+	}
+	if len(vars) != len(want) {
+		t.Fatalf("expected %d vars, got %d", len(want), len(vars))
+	}
+	wantKeys := map[string][]string{
+		"API_TOKEN": {"api/dev/token"},
+		"DB_URL":    {"db/dev"},
+		"PLAIN":     nil,
+	}
+	for _, iv := range vars {
+		if want[iv.name] != string(iv.value) {
+			t.Errorf("%s = %q, want %q", iv.name, iv.value, want[iv.name])
+		}
+		if !slices.Equal(iv.keys, wantKeys[iv.name]) {
+			t.Errorf("%s read keys %v, want %v", iv.name, iv.keys, wantKeys[iv.name])
+		}
+	}
+
+	// A key read outside any variable's line is attributed to all of them
+	vars, err = renderEnvTemplate("t", "{{ $t := secret \"api/dev/token\" }}\nA={{ $t }}\nB=x", lookup)
+	if err != nil {
+		t.Fatalf("renderEnvTemplate failed: %v", err)
+	}
+	for _, iv := range vars {
+		if !slices.Equal(iv.keys, []string{"api/dev/token"}) {
+			t.Errorf("%s read keys %v, want [api/dev/token]", iv.name, iv.keys)
+		}
+	}
+}
+
+func TestRenderEnvTemplateErrors(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	lookup := fakeLookup(map[string]*vault.SecretEntry{
+		"old": {
+			Fields:    map[string]vault.Field{vault.DefaultFieldName: {Value: "x"}},
+			ExpiresAt: &past,
+		},
+	})
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"missing secret", `A={{ secret "missing" }}`},
+		{"expired secret", `A={{ secret "old" }}`},
+		{"missing field", `A={{ field "old" "nope" }}`},
+		{"invalid line", `not a variable`},
+		{"invalid name", `1BAD=x`},
+		{"parse error", `A={{ secret `},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := renderEnvTemplate("t", tc.text, lookup); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestBindingVars(t *testing.T) {
+	lookup := fakeLookup(map[string]*vault.SecretEntry{
+		"db/dev": {
+			Fields: map[string]vault.Field{
+				"host":     {Value: "localhost"},
+				"password": {Value: "secret"},
+			},
+			Bindings: map[string]string{"PGHOST": "host", "PGPASSWORD": "password"},
+		},
+		"plain": {
+			Fields: map[string]vault.Field{vault.DefaultFieldName: {Value: "v"}},
+		},
+	})
+
+	vars, err := bindingVars("db/dev", lookup)
+	if err != nil {
+		t.Fatalf("bindingVars failed: %v", err)
+	}
+	if len(vars) != 2 || vars[0].name != "PGHOST" || string(vars[1].value) != "secret" {
+		t.Errorf("unexpected vars: %+v", vars)
+	}
+
+	if _, err := bindingVars("plain", lookup); err == nil {
+		t.Error("expected error for secret without bindings")
+	}
+	if _, err := bindingVars("missing", lookup); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestMergeExecEnvironment(t *testing.T) {
+	vars := []injectedVar{
+		{name: "A", value: []byte("1"), source: "template t"},
+		{name: "B", value: []byte("2"), source: "bindings x"},
+	}
+	env, err := mergeExecEnvironment([]string{"KEEP=1"}, vars)
+	if err != nil {
+		t.Fatalf("mergeExecEnvironment failed: %v", err)
+	}
+	if strings.Join(env, ",") != "KEEP=1,A=1,B=2" {
+		t.Errorf("unexpected env: %v", env)
+	}
+
+	dup := append(vars, injectedVar{name: "A", value: []byte("3"), source: "key a"})
+	if _, err := mergeExecEnvironment(nil, dup); err == nil || !strings.Contains(err.Error(), "defined by both") {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+
+	reserved := []injectedVar{{name: "PATH", value: []byte("/tmp"), source: "template t"}}
+	if _, err := mergeExecEnvironment(nil, reserved); !errors.Is(err, ErrReservedEnvVar) {
+		t.Errorf("expected ErrReservedEnvVar, got %v", err)
+	}
+}
+
+func TestWriteTempEnvFile(t *testing.T) {
+	path, err := writeTempEnvFile([]injectedVar{{name: "A", value: []byte("1")}})
+	if err != nil {
+		t.Fatalf("writeTempEnvFile failed: %v", err)
+	}
+	defer os.Remove(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 && os.PathSeparator == '/' {
+		t.Errorf("expected 0600, got %o", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "A=1\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestExecRecordsUsageUnderKey(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("true is not available")
+	}
+	savedV, savedPath := v, vaultPath
+	savedKeys, savedBindings, savedTemplate := execKeys, execBindings, execEnvTemplate
+	t.Cleanup(func() {
+		v, vaultPath = savedV, savedPath
+		execKeys, execBindings, execEnvTemplate = savedKeys, savedBindings, savedTemplate
+	})
+
+	vaultPath = filepath.Join(t.TempDir(), ".secretctl")
+	v = vault.New(vaultPath)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	for key, entry := range map[string]*vault.SecretEntry{
+		"db/password": {Value: []byte("pw")},
+		"api": {
+			Fields:   map[string]vault.Field{"token": {Value: "tok"}},
+			Bindings: map[string]string{"API_TOKEN": "token"},
+		},
+		"app/url": {Value: []byte("https://example.com")},
+	} {
+		if err := v.SetSecret(key, entry); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+
+	execEnvTemplate = filepath.Join(t.TempDir(), ".env.tmpl")
+	if err := os.WriteFile(execEnvTemplate, []byte(`APP_URL={{ secret "app/url" }}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	execKeys, execBindings = []string{"db/password"}, []string{"api"}
+	if err := executeExec([]string{"true"}); err != nil {
+		t.Fatalf("executeExec failed: %v", err)
+	}
+
+	// exec locks the vault on the way out
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	for _, key := range []string{"db/password", "api", "app/url"} {
+		entry, err := v.GetSecret(key)
+		if err != nil {
+			t.Fatalf("GetSecret(%s) failed: %v", key, err)
+		}
+		if entry.Metadata == nil || len(entry.Metadata.UsedBy) != 1 || entry.Metadata.UsedBy[0].Command != "true" {
+			t.Errorf("%s used by %+v, want [true]", key, entry.Metadata)
+		}
+	}
+}
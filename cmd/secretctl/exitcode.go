@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/backup"
+	"github.com/forest6511/secretctl/pkg/client"
+	"github.com/forest6511/secretctl/pkg/emergency"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Exit codes. They are part of the CLI interface: scripts branch on them,
+// so existing codes never change meaning. `run` passes through the exit
+// code of the command it ran, so these only identify secretctl's own
+// failures before the command started.
+const (
+	ExitOK          = 0
+	ExitError       = 1  // any error not listed below
+	ExitUsage       = 2  // invalid arguments, flags or input values
+	ExitLocked      = 3  // the vault is locked or could not be unlocked
+	ExitNotFound    = 4  // no such secret, folder or vault
+	ExitCooldown    = 5  // too many failed unlock attempts
+	ExitDenied      = 6  // refused by policy: immutable, frozen, read-only, access window
+	ExitCorrupted   = 7  // the vault, a backup or the audit log failed an integrity check
+	ExitExpired     = 8  // the secret or the vault has expired
+	ExitConflict    = 9  // the target already exists
+	ExitUnavailable = 10 // the agent or another required service cannot be reached
+
+	// run only
+	ExitTimeout         = 124 // the command exceeded --timeout
+	ExitCommandNotFound = 127 // the command does not exist
+	ExitSignalBase      = 128 // 128 + signal number when the command was killed
+)
+
+// exitCodes maps error sentinels to exit codes. The first match wins, so
+// more specific errors come first.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{vault.ErrCooldownActive, ExitCooldown},
+	{vault.ErrTooManyAttempts, ExitCooldown},
+	{vault.ErrVaultLocked, ExitLocked},
+	{vault.ErrInvalidPassword, ExitLocked},
+	{vault.ErrKeyRotated, ExitLocked},
+	{backup.ErrVaultLocked, ExitLocked},
+	{client.ErrLocked, ExitLocked},
+
+	{vault.ErrSecretNotFound, ExitNotFound},
+	{vault.ErrVaultNotFound, ExitNotFound},
+	{vault.ErrFolderNotFound, ExitNotFound},
+	{vault.ErrFolderPathNotFound, ExitNotFound},
+	{vault.ErrFieldNotFound, ExitNotFound},
+	{vault.ErrFreezeNotFound, ExitNotFound},
+	{vault.ErrNotInTrash, ExitNotFound},
+	{vault.ErrEmergencyContactUnknown, ExitNotFound},
+	{vault.ErrNoEmergencyRequest, ExitNotFound},
+	{vault.ErrShardNotFound, ExitNotFound},
+	{vault.ErrAttachmentNotFound, ExitNotFound},
+	{backup.ErrVaultNotFound, ExitNotFound},
+	{client.ErrNotFound, ExitNotFound},
+
+	{vault.ErrSecretExpired, ExitExpired},
+	{vault.ErrVaultExpired, ExitExpired},
+	{client.ErrExpired, ExitExpired},
+
+	{vault.ErrSecretImmutable, ExitDenied},
+	{vault.ErrSecretFrozen, ExitDenied},
+	{vault.ErrVaultReadOnly, ExitDenied},
+	{vault.ErrSnapshotReadOnly, ExitDenied},
+	{vault.ErrSecretNotYetValid, ExitDenied},
+	{vault.ErrOutsideAccessWindow, ExitDenied},
+	{vault.ErrSecretDeprecated, ExitDenied},
+	{vault.ErrFieldSensitive, ExitDenied},
+	{vault.ErrPluginNotAllowed, ExitDenied},
+	{vault.ErrEmergencyWaiting, ExitDenied},
+	{client.ErrDenied, ExitDenied},
+	{client.ErrDeprecated, ExitDenied},
+
+	{vault.ErrVaultCorrupted, ExitCorrupted},
+	{vault.ErrMetadataCorrupted, ExitCorrupted},
+	{vault.ErrDatabaseCorrupted, ExitCorrupted},
+	{vault.ErrShardUnavailable, ExitCorrupted},
+	{backup.ErrIntegrityFailed, ExitCorrupted},
+	{backup.ErrAuditChainInvalid, ExitCorrupted},
+	{emergency.ErrInvalidSignature, ExitCorrupted},
+
+	{vault.ErrVaultAlreadyExists, ExitConflict},
+	{vault.ErrFolderExists, ExitConflict},
+	{vault.ErrEmergencyContactExists, ExitConflict},
+	{vault.ErrShardExists, ExitConflict},
+	{vault.ErrShardBusy, ExitConflict},
+	{vault.ErrSecretChanged, ExitConflict},
+	{backup.ErrConflict, ExitConflict},
+
+	{vault.ErrKeyInvalid, ExitUsage},
+	{vault.ErrKeyTooLong, ExitUsage},
+	{vault.ErrKeyTooShort, ExitUsage},
+	{vault.ErrValueTooLarge, ExitUsage},
+	{vault.ErrValidationFailed, ExitUsage},
+	{vault.ErrOwnerInvalid, ExitUsage},
+	{vault.ErrExpirationRequired, ExitUsage},
+	{vault.ErrPasswordTooShort, ExitUsage},
+	{vault.ErrPasswordTooLong, ExitUsage},
+	{vault.ErrBreakGlassNoReason, ExitUsage},
+	{vault.ErrInvalidEmergencyContact, ExitUsage},
+	{vault.ErrInvalidShard, ExitUsage},
+	{vault.ErrInvalidAttachmentName, ExitUsage},
+	{vault.ErrAttachmentTooLarge, ExitUsage},
+	{vault.ErrInvalidRef, ExitUsage},
+	{vault.ErrRefCycle, ExitUsage},
+	{emergency.ErrInvalidKey, ExitUsage},
+	{client.ErrInvalidArgument, ExitUsage},
+
+	{client.ErrUnavailable, ExitUnavailable},
+}
+
+// exitCode returns the exit code for an error returned by a command.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return ExitError
+}
+
+// usageErrors makes argument and flag errors of cmd and its subcommands
+// exit with ExitUsage.
+func usageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return &exitError{code: ExitUsage, err: err}
+			}
+			return nil
+		}
+	}
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &exitError{code: ExitUsage, err: err}
+	})
+	for _, sub := range cmd.Commands() {
+		usageErrors(sub)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/backup"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("boom"), ExitError},
+		{fmt.Errorf("failed to unlock vault: %w", vault.ErrInvalidPassword), ExitLocked},
+		{fmt.Errorf("failed to get secret: %w", vault.ErrSecretNotFound), ExitNotFound},
+		{vault.ErrCooldownActive, ExitCooldown},
+		{fmt.Errorf("failed to set secret: %w", vault.ErrSecretFrozen), ExitDenied},
+		{fmt.Errorf("restore: %w", backup.ErrIntegrityFailed), ExitCorrupted},
+		{vault.ErrVaultExpired, ExitExpired},
+		{&exitError{code: ExitConflict, err: errors.New("folder already exists: x")}, ExitConflict},
+		{&exitError{code: 42}, 42},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	sub := &cobra.Command{Use: "sub", Args: cobra.ExactArgs(1), RunE: func(*cobra.Command, []string) error { return nil }}
+	sub.Flags().Int("n", 0, "")
+	root.AddCommand(sub)
+	root.SilenceErrors, root.SilenceUsage = true, true
+	usageErrors(root)
+
+	for _, args := range [][]string{{"sub"}, {"sub", "a", "--n", "x"}, {"sub", "a", "--nope"}} {
+		root.SetArgs(args)
+		if got := exitCode(root.Execute()); got != ExitUsage {
+			t.Errorf("%v: exit code %d, want %d", args, got, ExitUsage)
+		}
+	}
+	root.SetArgs([]string{"sub", "a"})
+	if err := root.Execute(); err != nil {
+		t.Errorf("valid arguments failed: %v", err)
+	}
+}
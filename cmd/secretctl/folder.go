@@ -57,7 +57,7 @@ Examples:
 			parent, err := v.GetFolderByPath(folderParent)
 			if err != nil {
 				if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-					return fmt.Errorf("parent folder not found: %s", folderParent)
+					return &exitError{code: ExitNotFound, err: fmt.Errorf("parent folder not found: %s", folderParent)}
 				}
 				return fmt.Errorf("failed to find parent folder: %w", err)
 			}
@@ -74,7 +74,7 @@ Examples:
 
 		if err := v.CreateFolder(folder); err != nil {
 			if errors.Is(err, vault.ErrFolderExists) {
-				return fmt.Errorf("folder already exists: %s", name)
+				return &exitError{code: ExitConflict, err: fmt.Errorf("folder already exists: %s", name)}
 			}
 			if errors.Is(err, vault.ErrFolderNameInvalid) || errors.Is(err, vault.ErrFolderNameSlash) ||
 				errors.Is(err, vault.ErrFolderNameTooLong) || errors.Is(err, vault.ErrFolderNameTooShort) {
@@ -188,7 +188,7 @@ Examples:
 		folder, err := v.GetFolderByPath(folderPath)
 		if err != nil {
 			if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-				return fmt.Errorf("folder not found: %s", folderPath)
+				return &exitError{code: ExitNotFound, err: fmt.Errorf("folder not found: %s", folderPath)}
 			}
 			return fmt.Errorf("failed to find folder: %w", err)
 		}
@@ -227,7 +227,7 @@ Examples:
 		folder, err := v.GetFolderByPath(folderPath)
 		if err != nil {
 			if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-				return fmt.Errorf("folder not found: %s", folderPath)
+				return &exitError{code: ExitNotFound, err: fmt.Errorf("folder not found: %s", folderPath)}
 			}
 			return fmt.Errorf("failed to find folder: %w", err)
 		}
@@ -236,7 +236,7 @@ Examples:
 		folder.Name = newName
 		if err := v.UpdateFolder(folder); err != nil {
 			if errors.Is(err, vault.ErrFolderExists) {
-				return fmt.Errorf("a folder with name %q already exists in the same location", newName)
+				return &exitError{code: ExitConflict, err: fmt.Errorf("a folder with name %q already exists in the same location", newName)}
 			}
 			if errors.Is(err, vault.ErrFolderNameInvalid) || errors.Is(err, vault.ErrFolderNameSlash) ||
 				errors.Is(err, vault.ErrFolderNameTooLong) || errors.Is(err, vault.ErrFolderNameTooShort) {
@@ -274,7 +274,7 @@ Examples:
 		folder, err := v.GetFolderByPath(folderPath)
 		if err != nil {
 			if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-				return fmt.Errorf("folder not found: %s", folderPath)
+				return &exitError{code: ExitNotFound, err: fmt.Errorf("folder not found: %s", folderPath)}
 			}
 			return fmt.Errorf("failed to find folder: %w", err)
 		}
@@ -285,7 +285,7 @@ Examples:
 			newParent, err := v.GetFolderByPath(newParentPath)
 			if err != nil {
 				if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-					return fmt.Errorf("target folder not found: %s", newParentPath)
+					return &exitError{code: ExitNotFound, err: fmt.Errorf("target folder not found: %s", newParentPath)}
 				}
 				return fmt.Errorf("failed to find target folder: %w", err)
 			}
@@ -296,7 +296,7 @@ Examples:
 		folder.ParentID = newParentID
 		if err := v.UpdateFolder(folder); err != nil {
 			if errors.Is(err, vault.ErrFolderExists) {
-				return fmt.Errorf("a folder with name %q already exists in the target location", folder.Name)
+				return &exitError{code: ExitConflict, err: fmt.Errorf("a folder with name %q already exists in the target location", folder.Name)}
 			}
 			if errors.Is(err, vault.ErrFolderCircular) {
 				return errors.New("cannot move folder into its own subtree")
@@ -334,7 +334,7 @@ Examples:
 		folder, err := v.GetFolderByPath(folderPath)
 		if err != nil {
 			if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-				return fmt.Errorf("folder not found: %s", folderPath)
+				return &exitError{code: ExitNotFound, err: fmt.Errorf("folder not found: %s", folderPath)}
 			}
 			return fmt.Errorf("failed to find folder: %w", err)
 		}
@@ -401,7 +401,7 @@ func resolveFolderFromFlags() (*string, error) {
 		folder, err := v.GetFolderByPath(setFolder)
 		if err != nil {
 			if errors.Is(err, vault.ErrFolderNotFound) || errors.Is(err, vault.ErrFolderPathNotFound) {
-				return nil, fmt.Errorf("folder not found: %s", setFolder)
+				return nil, &exitError{code: ExitNotFound, err: fmt.Errorf("folder not found: %s", setFolder)}
 			}
 			return nil, fmt.Errorf("failed to find folder: %w", err)
 		}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Freeze command flags
+var (
+	freezeFrom   string
+	freezeUntil  string
+	freezeReason string
+)
+
+func init() {
+	rootCmd.AddCommand(freezeCmd)
+	freezeCmd.AddCommand(freezeAddCmd)
+	freezeCmd.AddCommand(freezeListCmd)
+	freezeCmd.AddCommand(freezeRemoveCmd)
+
+	freezeAddCmd.Flags().StringVar(&freezeFrom, "from", "", "Start of the window: RFC3339 time, YYYY-MM-DD or duration from now (default: now)")
+	freezeAddCmd.Flags().StringVar(&freezeUntil, "until", "", "End of the window: RFC3339 time, YYYY-MM-DD or duration from now (e.g., 7d)")
+	freezeAddCmd.Flags().StringVar(&freezeReason, "reason", "", "Why the keys are frozen (shown in list and the audit log)")
+	_ = freezeAddCmd.MarkFlagRequired("until")
+}
+
+// freezeCmd is the parent command for freeze windows
+var freezeCmd = &cobra.Command{
+	Use:   "freeze",
+	Short: "Make keys append-only for a period of time",
+	Long: `Freeze windows protect keys matching a pattern for a period of time,
+for example production credentials during release week. While a window is
+active, existing matching secrets cannot be overwritten or deleted; new
+keys can still be added.
+
+Changing a frozen secret requires set or delete with --break-glass
+--reason <why>, which is recorded as a secret.break_glass audit event.`,
+}
+
+// freezeAddCmd adds a freeze window
+var freezeAddCmd = &cobra.Command{
+	Use:   "add <pattern>",
+	Short: "Freeze keys matching a pattern",
+	Long: `Adds a freeze window for keys matching pattern (path.Match syntax,
+e.g. prod/*). Windows that have ended are removed at the same time.
+
+Examples:
+  secretctl freeze add 'prod/*' --until 7d --reason "release week"
+  secretctl freeze add 'prod/*' --from 2026-12-20 --until 2027-01-04`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := vault.FreezeWindow{Pattern: args[0], Start: time.Now(), Reason: freezeReason}
+		if freezeFrom != "" {
+			t, err := parseNotBefore(freezeFrom)
+			if err != nil {
+				return fmt.Errorf("invalid --from: %w", err)
+			}
+			w.Start = t
+		}
+		t, err := parseNotBefore(freezeUntil)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+		w.End = t
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.AddFreezeWindow(w); err != nil {
+			return fmt.Errorf("failed to add freeze window: %w", err)
+		}
+		fmt.Printf("Froze '%s' from %s until %s\n", w.Pattern, tf.Time(w.Start), tf.Time(w.End))
+		return nil
+	},
+}
+
+// freezeListCmd lists freeze windows
+var freezeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List freeze windows",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		windows, err := vault.New(vaultPath).FreezeWindows()
+		if err != nil {
+			return err
+		}
+		if len(windows) == 0 {
+			fmt.Println("No freeze windows")
+			return nil
+		}
+		now := time.Now()
+		for _, w := range windows {
+			state := "scheduled"
+			switch {
+			case w.Active(now):
+				state = "active"
+			case !now.Before(w.End):
+				state = "ended"
+			}
+			line := fmt.Sprintf("%-20s %-9s %s - %s", w.Pattern, state, tf.Time(w.Start), tf.Time(w.End))
+			if w.Reason != "" {
+				line += "  " + w.Reason
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+// freezeRemoveCmd ends freeze windows early
+var freezeRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern>",
+	Short: "Remove the freeze windows for a pattern",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.RemoveFreezeWindow(args[0]); err != nil {
+			if errors.Is(err, vault.ErrFreezeNotFound) {
+				return &exitError{code: ExitNotFound, err: fmt.Errorf("no freeze window for '%s'", args[0])}
+			}
+			return fmt.Errorf("failed to remove freeze window: %w", err)
+		}
+		fmt.Printf("Unfroze '%s'\n", args[0])
+		return nil
+	},
+}
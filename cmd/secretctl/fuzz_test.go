@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// chunkReader returns at most n bytes per Read, so secrets straddle reads.
+type chunkReader struct {
+	data []byte
+	n    int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p[:min(len(p), r.n)], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// isPrintable reports whether data would pass isBinaryData in any window.
+func isPrintable(data []byte) bool {
+	for _, b := range data {
+		if (b < 0x20 && b != '\t' && b != '\n' && b != '\r') || b == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeStream runs input through outputSanitizer.copy in chunks.
+func sanitizeStream(secret, input []byte, chunk int) []byte {
+	s := newOutputSanitizer([]secretData{{key: "K", value: secret}})
+	var out bytes.Buffer
+	s.copy(&out, &chunkReader{data: input, n: chunk})
+	return out.Bytes()
+}
+
+// checkStreamRedacted fails if secret survives in out outside of a
+// placeholder. Secrets with brackets or inside the placeholder text are
+// not checked (see the mcp package's FuzzOutputSanitizer).
+func checkStreamRedacted(t *testing.T, secret, input, out []byte) {
+	t.Helper()
+	placeholder := []byte("[REDACTED:K]")
+	for _, segment := range bytes.Split(out, placeholder) {
+		if bytes.Contains(segment, secret) {
+			t.Fatalf("secret %q leaked\ninput:  %q\noutput: %q", secret, input, out)
+		}
+	}
+}
+
+func streamCheckable(secret, prefix, suffix []byte) bool {
+	// Secrets under 4 bytes are not sanitized by design, and binary
+	// output is passed through unchanged
+	return len(secret) >= 4 && !bytes.ContainsAny(secret, "[]") &&
+		!bytes.Contains([]byte("[REDACTED:K]"), secret) &&
+		isPrintable(secret) && isPrintable(prefix) && isPrintable(suffix)
+}
+
+func FuzzOutputSanitizerCopy(f *testing.F) {
+	f.Add([]byte("hunter2!"), []byte("password="), []byte("\n"), uint8(1))
+	f.Add([]byte("sk-proj-abc123"), []byte("token: "), []byte(" ok"), uint8(3))
+	f.Add([]byte("aaaa"), []byte("aaa"), []byte("aaa"), uint8(2))
+
+	f.Fuzz(func(t *testing.T, secret, prefix, suffix []byte, chunk uint8) {
+		// copy re-scans the overlap on every read, so large inputs read a
+		// byte at a time only slow the fuzzer down
+		if len(secret) > 512 || len(prefix)+len(suffix) > 4096 {
+			t.Skip()
+		}
+		if !streamCheckable(secret, prefix, suffix) {
+			t.Skip()
+		}
+		input := bytes.Join([][]byte{prefix, secret, suffix, secret}, nil)
+		out := sanitizeStream(secret, input, 1+int(chunk))
+		checkStreamRedacted(t, secret, input, out)
+	})
+}
+
+// TestOutputSanitizerCopyRandomEmbedding checks the FuzzOutputSanitizerCopy
+// property over random printable secrets, positions and read sizes.
+func TestOutputSanitizerCopyRandomEmbedding(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	printable := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(' ' + rng.Intn(95))
+		}
+		return b
+	}
+
+	for i := 0; i < 2000; i++ {
+		secret := printable(4 + rng.Intn(30))
+		noise := printable(rng.Intn(100))
+		if !streamCheckable(secret, noise, nil) {
+			continue
+		}
+		var input []byte
+		for n := rng.Intn(4); n >= 0; n-- {
+			input = append(input, noise[:rng.Intn(len(noise)+1)]...)
+			input = append(input, secret...)
+		}
+		out := sanitizeStream(secret, input, 1+rng.Intn(64))
+		checkStreamRedacted(t, secret, input, out)
+	}
+}
+
+func FuzzParseDuration(f *testing.F) {
+	for _, seed := range []string{"30s", "5h", "7d", "2w", "1m", "1y", "1h30m", "1.5h", "-5d", "+5d", "5dd", "99999999999999y", ""} {
+		f.Add(seed)
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour, 'm': 30 * 24 * time.Hour, 'y': 365 * 24 * time.Hour}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := parseDuration(s)
+		if err != nil {
+			return
+		}
+		if d < 0 {
+			t.Fatalf("parseDuration(%q) = %v, want a non-negative duration", s, d)
+		}
+		if per, ok := units[s[len(s)-1]]; ok && isDigits(s[:len(s)-1]) {
+			n, _ := strconv.ParseInt(s[:len(s)-1], 10, 64)
+			if d != time.Duration(n)*per {
+				t.Fatalf("parseDuration(%q) = %v, want %d × %v", s, d, n, per)
+			}
+		}
+	})
+}
@@ -2,27 +2,28 @@
 package main
 
 import (
-	"crypto/rand"
 	"fmt"
-	"math/big"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/generate"
+	"github.com/forest6511/secretctl/pkg/vault"
 )
 
 // Character set constants
 const (
-	charsetLowercase = "abcdefghijklmnopqrstuvwxyz"
-	charsetUppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	charsetDigits    = "0123456789"
-	charsetSymbols   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
-
-	minPasswordLength     = 8
-	maxPasswordLength     = 256
-	defaultPasswordLength = 24
+	charsetLowercase = generate.Lowercase
+	charsetUppercase = generate.Uppercase
+	charsetDigits    = generate.Digits
+	charsetSymbols   = generate.Symbols
+
+	minPasswordLength     = generate.MinLength
+	maxPasswordLength     = generate.MaxLength
+	defaultPasswordLength = generate.DefaultLength
 	defaultPasswordCount  = 1
 	maxPasswordCount      = 100
 	maxExcludeLength      = 256
@@ -38,6 +39,9 @@ var (
 	generateNoLowercase bool
 	generateExclude     string
 	generateCopy        bool
+	generatePassphrase  bool
+	generateWords       int
+	generateSeparator   string
 )
 
 func init() {
@@ -51,12 +55,17 @@ func init() {
 	generateCmd.Flags().BoolVar(&generateNoLowercase, "no-lowercase", false, "Exclude lowercase letters")
 	generateCmd.Flags().StringVar(&generateExclude, "exclude", "", "Characters to exclude")
 	generateCmd.Flags().BoolVarP(&generateCopy, "copy", "c", false, "Copy first password to clipboard (accessible to all processes)")
+	generateCmd.Flags().BoolVar(&generatePassphrase, "passphrase", false, "Generate diceware passphrases instead of passwords")
+	generateCmd.Flags().IntVar(&generateWords, "words", generate.DefaultWords, fmt.Sprintf("Words per passphrase (%d-%d)", generate.MinWords, generate.MaxWords))
+	generateCmd.Flags().StringVar(&generateSeparator, "separator", "-", "Separator between passphrase words")
 }
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate secure random passwords",
-	Long: `Generate cryptographically secure random passwords.
+	Long: `Generate cryptographically secure random passwords or diceware
+passphrases. Passwords contain at least one character of every enabled
+type. The strength of the result is printed on stderr.
 
 Examples:
   # Generate a 24-character password (default)
@@ -72,7 +81,13 @@ Examples:
   secretctl generate -c
 
   # Generate password excluding ambiguous characters
-  secretctl generate --exclude "0O1lI"`,
+  secretctl generate --exclude "0O1lI"
+
+  # Generate a passphrase of 8 words separated by spaces
+  secretctl generate --passphrase --words 8 --separator " "
+
+  # Store a generated password directly
+  secretctl set db/prod --generate`,
 	RunE: executeGenerate,
 }
 
@@ -82,16 +97,16 @@ func executeGenerate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Build character set
-	charset, err := buildCharset()
-	if err != nil {
-		return err
-	}
-
 	// Generate passwords
 	passwords := make([]string, generateCount)
 	for i := 0; i < generateCount; i++ {
-		password, err := generatePassword(charset, generateLength)
+		var password string
+		var err error
+		if generatePassphrase {
+			password, err = generate.Passphrase(generate.PassphraseOptions{Words: generateWords, Separator: generateSeparator})
+		} else {
+			password, err = generate.Password(generateOptions())
+		}
 		if err != nil {
 			return fmt.Errorf("failed to generate password %d/%d: %w", i+1, generateCount, err)
 		}
@@ -102,6 +117,7 @@ func executeGenerate(cmd *cobra.Command, args []string) error {
 	for _, password := range passwords {
 		fmt.Println(password)
 	}
+	fmt.Fprintf(os.Stderr, "Strength: %s\n", vault.RatePassword(passwords[0]))
 
 	// Copy to clipboard if requested (best-effort, non-blocking)
 	if generateCopy && len(passwords) > 0 {
@@ -135,70 +151,37 @@ func validateGenerateFlags() error {
 	if len(generateExclude) > maxExcludeLength {
 		return fmt.Errorf("exclude string must be at most %d characters", maxExcludeLength)
 	}
+	if generatePassphrase && (generateWords < generate.MinWords || generateWords > generate.MaxWords) {
+		return fmt.Errorf("passphrase words must be between %d and %d", generate.MinWords, generate.MaxWords)
+	}
 	return nil
 }
 
-// buildCharset builds the character set based on flags
-func buildCharset() (string, error) {
-	var charset strings.Builder
-
-	if !generateNoLowercase {
-		charset.WriteString(charsetLowercase)
-	}
-	if !generateNoUppercase {
-		charset.WriteString(charsetUppercase)
-	}
-	if !generateNoNumbers {
-		charset.WriteString(charsetDigits)
-	}
-	if !generateNoSymbols {
-		charset.WriteString(charsetSymbols)
-	}
-
-	result := charset.String()
-
-	// Remove excluded characters
-	if generateExclude != "" {
-		result = removeChars(result, generateExclude)
-	}
-
-	if result == "" {
-		return "", fmt.Errorf("character set is empty: adjust flags to include at least one character type")
+// generateOptions returns the password options selected by the flags
+func generateOptions() generate.Options {
+	return generate.Options{
+		Length:      generateLength,
+		NoLowercase: generateNoLowercase,
+		NoUppercase: generateNoUppercase,
+		NoDigits:    generateNoNumbers,
+		NoSymbols:   generateNoSymbols,
+		Exclude:     generateExclude,
 	}
+}
 
-	return result, nil
+// buildCharset builds the character set based on flags
+func buildCharset() (string, error) {
+	return generateOptions().Charset()
 }
 
 // removeChars removes specified characters from a string
 func removeChars(s, chars string) string {
-	excludeSet := make(map[rune]bool)
-	for _, c := range chars {
-		excludeSet[c] = true
-	}
-
-	var result strings.Builder
-	for _, c := range s {
-		if !excludeSet[c] {
-			result.WriteRune(c)
-		}
-	}
-	return result.String()
+	return generate.RemoveChars(s, chars)
 }
 
 // generatePassword generates a cryptographically secure random password
 func generatePassword(charset string, length int) (string, error) {
-	charsetLen := big.NewInt(int64(len(charset)))
-	password := make([]byte, length)
-
-	for i := 0; i < length; i++ {
-		idx, err := rand.Int(rand.Reader, charsetLen)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random number: %w", err)
-		}
-		password[i] = charset[idx.Int64()]
-	}
-
-	return string(password), nil
+	return generate.FromCharset(charset, length)
 }
 
 // copyToClipboard copies text to the system clipboard
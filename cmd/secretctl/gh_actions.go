@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/ghactions"
+)
+
+// GitHub Actions command flags
+var (
+	ghKeys        []string
+	ghRepo        string
+	ghStripPrefix string
+	ghTokenKey    string
+	ghSync        bool
+	ghPrune       bool
+	ghDryRun      bool
+	ghDriftJSON   bool
+)
+
+func init() {
+	rootCmd.AddCommand(ghActionsCmd)
+	ghActionsCmd.AddCommand(ghActionsExportCmd)
+	ghActionsCmd.AddCommand(ghActionsDriftCmd)
+
+	for _, cmd := range []*cobra.Command{ghActionsExportCmd, ghActionsDriftCmd} {
+		cmd.Flags().StringArrayVarP(&ghKeys, "keys", "k", nil, "Secret keys to sync (glob pattern supported, repeatable)")
+		cmd.Flags().StringVar(&ghRepo, "repo", "", "GitHub repository (owner/name)")
+		cmd.Flags().StringVar(&ghStripPrefix, "strip-prefix", "", "Key prefix to drop before naming GitHub secrets (e.g. ci/)")
+		cmd.Flags().StringVar(&ghTokenKey, "token-key", "", "Vault key holding the GitHub token (default: $GITHUB_TOKEN or $GH_TOKEN)")
+		_ = cmd.MarkFlagRequired("keys")
+		_ = cmd.MarkFlagRequired("repo")
+	}
+	ghActionsExportCmd.Flags().BoolVar(&ghSync, "sync", false, "Only push secrets that are missing or changed since the last upload")
+	ghActionsExportCmd.Flags().BoolVar(&ghPrune, "prune", false, "Delete repository secrets that are not among the selected keys")
+	ghActionsExportCmd.Flags().BoolVar(&ghDryRun, "dry-run", false, "Show what would change without calling the write API")
+	ghActionsDriftCmd.Flags().BoolVar(&ghDriftJSON, "json", false, "Output in JSON format")
+}
+
+// ghActionsCmd is the parent command for GitHub Actions secrets
+var ghActionsCmd = &cobra.Command{
+	Use:   "gh-actions",
+	Short: "Push vault secrets to GitHub Actions repository secrets",
+	Long: `Push vault secrets to GitHub Actions repository secrets.
+
+Keys are mapped to secret names like 'run' maps them to environment
+variables (ci/npm-token -> CI_NPM_TOKEN); --strip-prefix ci/ makes that
+NPM_TOKEN. Values are sealed with the repository's public key before
+they leave this machine.
+
+The GitHub token needs the repository "Secrets" write permission. It is
+read from the vault key given by --token-key, or from $GITHUB_TOKEN or
+$GH_TOKEN. $GITHUB_API_URL selects a GitHub Enterprise Server endpoint.
+
+GitHub does not return secret values, so drift is judged by update time:
+a secret is stale when the vault copy changed after its last upload.`,
+}
+
+// ghActionsExportCmd pushes secrets to a repository
+var ghActionsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Push selected secrets to a repository",
+	Long: `Push the secrets matching --keys to a repository's Actions secrets.
+
+By default every selected secret is uploaded. With --sync only secrets
+that are missing or stale are uploaded. --prune also deletes repository
+secrets that no selected key maps to.
+
+Examples:
+  secretctl gh-actions export --keys 'ci/*' --repo org/repo
+  secretctl gh-actions export -k 'ci/*' --repo org/repo --strip-prefix ci/ --sync --prune
+  secretctl gh-actions export -k 'ci/*' --repo org/repo --sync --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		client, err := newGHActionsClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		local, names, err := ghLocalSecrets()
+		if err != nil {
+			return err
+		}
+		remote, err := client.ListSecrets(ctx)
+		if err != nil {
+			return err
+		}
+		drift := ghactions.Diff(local, remote)
+
+		push := append(append([]string{}, drift.Missing...), drift.Stale...)
+		if !ghSync {
+			push = append(push, drift.InSync...)
+		}
+		var remove []string
+		if ghPrune {
+			remove = drift.Extra
+		}
+		if len(push) == 0 && len(remove) == 0 {
+			fmt.Printf("%s is in sync (%d secrets)\n", ghRepo, len(local))
+			return nil
+		}
+
+		if ghDryRun {
+			for _, name := range push {
+				fmt.Printf("would push    %s (%s)\n", name, names[name])
+			}
+			for _, name := range remove {
+				fmt.Printf("would delete  %s\n", name)
+			}
+			return nil
+		}
+
+		key, err := client.PublicKey(ctx)
+		if err != nil {
+			return err
+		}
+		for _, name := range push {
+			entry, err := v.GetSecret(names[name])
+			if err != nil {
+				return fmt.Errorf("failed to get secret '%s': %w", names[name], err)
+			}
+			err = client.PutSecret(ctx, name, entry.Value, key)
+			logGHActions(audit.OpGHActionsPush, names[name], name, err)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("pushed   %s (%s)\n", name, names[name])
+		}
+		for _, name := range remove {
+			err := client.DeleteSecret(ctx, name)
+			logGHActions(audit.OpGHActionsDelete, "", name, err)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("deleted  %s\n", name)
+		}
+		fmt.Printf("%s: %d pushed, %d deleted\n", ghRepo, len(push), len(remove))
+		return nil
+	},
+}
+
+// ghDriftJSONOutput is the JSON output of gh-actions drift
+type ghDriftJSONOutput struct {
+	Repo    string   `json:"repo"`
+	Missing []string `json:"missing"`
+	Stale   []string `json:"stale"`
+	InSync  []string `json:"in_sync"`
+	Extra   []string `json:"extra"`
+}
+
+// ghActionsDriftCmd reports drift between the vault and a repository
+var ghActionsDriftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Compare selected secrets with a repository",
+	Long: `Compare the secrets matching --keys with a repository's Actions secrets.
+
+Secrets are reported as missing (not in the repository), stale (changed in
+the vault after the last upload), in sync, or extra (only in the
+repository). The command exits with an error when anything has drifted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		client, err := newGHActionsClient()
+		if err != nil {
+			return err
+		}
+		local, names, err := ghLocalSecrets()
+		if err != nil {
+			return err
+		}
+		remote, err := client.ListSecrets(context.Background())
+		if err != nil {
+			return err
+		}
+		drift := ghactions.Diff(local, remote)
+
+		if ghDriftJSON {
+			out := ghDriftJSONOutput{
+				Repo:    ghRepo,
+				Missing: nonNil(drift.Missing),
+				Stale:   nonNil(drift.Stale),
+				InSync:  nonNil(drift.InSync),
+				Extra:   nonNil(drift.Extra),
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(out); err != nil {
+				return err
+			}
+		} else {
+			for _, name := range drift.Missing {
+				fmt.Printf("missing  %s (%s)\n", name, names[name])
+			}
+			for _, name := range drift.Stale {
+				fmt.Printf("stale    %s (%s)\n", name, names[name])
+			}
+			for _, name := range drift.Extra {
+				fmt.Printf("extra    %s\n", name)
+			}
+			fmt.Printf("%s: %d missing, %d stale, %d in sync, %d extra\n",
+				ghRepo, len(drift.Missing), len(drift.Stale), len(drift.InSync), len(drift.Extra))
+		}
+		if !drift.IsClean() {
+			return fmt.Errorf("%s has drifted from the vault", ghRepo)
+		}
+		return nil
+	},
+}
+
+// newGHActionsClient returns a client for --repo using the configured token.
+func newGHActionsClient() (*ghactions.Client, error) {
+	if err := ghactions.ValidateRepo(ghRepo); err != nil {
+		return nil, err
+	}
+	var token string
+	if ghTokenKey != "" {
+		entry, err := v.GetSecret(ghTokenKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get GitHub token '%s': %w", ghTokenKey, err)
+		}
+		token = strings.TrimSpace(string(entry.Value))
+	} else if token = os.Getenv("GITHUB_TOKEN"); token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no GitHub token: set --token-key, $GITHUB_TOKEN or $GH_TOKEN")
+	}
+	return &ghactions.Client{BaseURL: os.Getenv("GITHUB_API_URL"), Token: token, Repo: ghRepo}, nil
+}
+
+// ghLocalSecrets returns the selected secrets under their GitHub names and
+// a map from GitHub name back to vault key.
+func ghLocalSecrets() ([]ghactions.Local, map[string]string, error) {
+	entries, err := v.ListSecretsWithMetadata()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	selected := make(map[string]bool)
+	for _, pattern := range ghKeys {
+		matches, err := expandPattern(pattern, keys)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range matches {
+			selected[key] = true
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil, fmt.Errorf("no secrets match the specified patterns")
+	}
+
+	var local []ghactions.Local
+	names := make(map[string]string)
+	for _, e := range entries {
+		if !selected[e.Key] {
+			continue
+		}
+		name := ghSecretName(e.Key)
+		if err := ghactions.ValidateSecretName(name); err != nil {
+			return nil, nil, fmt.Errorf("secret '%s': %w", e.Key, err)
+		}
+		if other, dup := names[name]; dup {
+			return nil, nil, fmt.Errorf("secrets '%s' and '%s' both map to %s", other, e.Key, name)
+		}
+		names[name] = e.Key
+		local = append(local, ghactions.Local{Name: name, UpdatedAt: e.UpdatedAt})
+	}
+	return local, names, nil
+}
+
+// ghSecretName maps a vault key to a GitHub secret name.
+func ghSecretName(key string) string {
+	return keyToEnvName(strings.TrimPrefix(key, ghStripPrefix))
+}
+
+// logGHActions records a GitHub Actions write in the audit log.
+func logGHActions(op, key, name string, err error) {
+	ctx := map[string]interface{}{"repo": ghRepo, "name": name}
+	if err != nil {
+		_ = v.Audit().Log(op, audit.SourceCLI, audit.ResultError, key, nil, ctx)
+		return
+	}
+	_ = v.Audit().Log(op, audit.SourceCLI, audit.ResultSuccess, key, nil, ctx)
+}
+
+// nonNil returns s, or an empty slice if s is nil, for JSON output.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Grep command flags
+var (
+	grepIn          []string
+	grepRegexp      bool
+	grepIgnoreCase  bool
+	grepShowContext bool
+	grepYes         bool
+)
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+
+	grepCmd.Flags().StringSliceVar(&grepIn, "in", []string{vault.GrepValues}, "Content to search: values, notes, fields (comma-separated)")
+	grepCmd.Flags().BoolVarP(&grepRegexp, "regexp", "E", false, "Treat pattern as a regular expression")
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "Case-insensitive match")
+	grepCmd.Flags().BoolVar(&grepShowContext, "show-context", false, "Print the matched text and its surroundings (asks for confirmation)")
+	grepCmd.Flags().BoolVarP(&grepYes, "yes", "y", false, "Skip the --show-context confirmation")
+}
+
+// grepCmd finds which secrets contain a pattern
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern>",
+	Short: "Find secrets whose decrypted content contains a pattern",
+	Long: `Search decrypted secret content in memory and print the keys that match.
+
+Useful for finding which entry holds a known token, e.g. from the last
+characters shown in a provider's dashboard. Matched text is never printed
+unless --show-context is given, which asks for confirmation first.
+
+The pattern is a plain substring unless --regexp is set.
+
+Examples:
+  secretctl grep 4f2a
+  secretctl grep --in notes,fields "staging"
+  secretctl grep -E '^sk_live_' --in values,fields
+  secretctl grep 4f2a --show-context`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expr := args[0]
+		if !grepRegexp {
+			expr = regexp.QuoteMeta(expr)
+		}
+		if grepIgnoreCase {
+			expr = "(?i)" + expr
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		if grepShowContext && !grepYes {
+			fmt.Fprint(os.Stderr, "Matched secret text will be printed to the terminal. Continue? [y/N]: ")
+			var confirm string
+			_, _ = fmt.Scanln(&confirm)
+			if confirm != "y" && confirm != "Y" {
+				return errors.New("cancelled")
+			}
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		matches, err := v.Grep(pattern, vault.GrepOptions{In: grepIn, Context: grepShowContext})
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			fmt.Fprintln(os.Stderr, "No matches")
+			return nil
+		}
+
+		if grepShowContext {
+			for _, m := range matches {
+				fmt.Printf("%s (%s): %s\n", m.Key, m.Location, m.Context)
+			}
+			return nil
+		}
+		printed := make(map[string]bool)
+		for _, m := range matches {
+			if !printed[m.Key] {
+				printed[m.Key] = true
+				fmt.Println(m.Key)
+			}
+		}
+		return nil
+	},
+}
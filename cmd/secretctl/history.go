@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// History command flags
+var historyJSON bool
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Output in JSON format")
+}
+
+// historyCmd shows change summaries for a secret
+var historyCmd = &cobra.Command{
+	Use:   "history <key>",
+	Short: "Show the change history of a secret",
+	Long: `Show the recorded versions of a secret with a summary of each change.
+
+Summaries never include secret values. Each version shows:
+  - which fields were changed, added or removed
+  - the change in total value length
+  - a similarity bucket (identical, high, medium, low) comparing the
+    new value with the previous one
+
+Use it to confirm that a rotation actually changed the value: a rotated
+password should show "low" similarity, not "identical".
+
+Examples:
+  secretctl history db/prod
+  secretctl history db/prod --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		history, err := v.GetSecretHistory(key)
+		if err != nil {
+			return fmt.Errorf("failed to get history: %w", err)
+		}
+
+		if historyJSON {
+			return outputHistoryJSON(key, history)
+		}
+
+		if len(history) == 0 {
+			fmt.Printf("No history recorded for '%s'\n", key)
+			return nil
+		}
+
+		for _, h := range history {
+			fmt.Printf("v%-4d %s  %s\n", h.Version, tf.Time(h.ChangedAt), formatChangeSummary(&h.Summary))
+		}
+		return nil
+	},
+}
+
+// formatChangeSummary renders a change summary on one line.
+func formatChangeSummary(s *vault.ChangeSummary) string {
+	if s.Created {
+		return fmt.Sprintf("created (%s)", pluralize(len(s.AddedFields), "field"))
+	}
+	if !s.ValueChanged() {
+		return "metadata only (values unchanged)"
+	}
+
+	var parts []string
+	if len(s.ChangedFields) > 0 {
+		parts = append(parts, "changed: "+strings.Join(s.ChangedFields, ","))
+	}
+	if len(s.AddedFields) > 0 {
+		parts = append(parts, "added: "+strings.Join(s.AddedFields, ","))
+	}
+	if len(s.RemovedFields) > 0 {
+		parts = append(parts, "removed: "+strings.Join(s.RemovedFields, ","))
+	}
+	parts = append(parts, fmt.Sprintf("length %+d", s.LengthDelta))
+	parts = append(parts, "similarity "+s.Similarity)
+	return strings.Join(parts, "  ")
+}
+
+// pluralize formats a count with a singular/plural noun.
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// historyJSONEntry is the JSON representation of one history entry
+type historyJSONEntry struct {
+	Version   int                 `json:"version"`
+	ChangedAt string              `json:"changed_at"`
+	Summary   vault.ChangeSummary `json:"summary"`
+}
+
+func outputHistoryJSON(key string, history []*vault.HistoryEntry) error {
+	entries := make([]historyJSONEntry, 0, len(history))
+	for _, h := range history {
+		entries = append(entries, historyJSONEntry{
+			Version:   h.Version,
+			ChangedAt: h.ChangedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Summary:   h.Summary,
+		})
+	}
+	data, err := json.MarshalIndent(map[string]any{"key": key, "history": entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -70,7 +70,10 @@ Examples:
 Conflict handling:
   --skip       Skip keys that already exist (default)
   --overwrite  Overwrite existing keys with new values
-  --error      Exit with error if any key already exists`,
+  --error      Exit with error if any key already exists
+
+Secrets are written in a single transaction: if one cannot be stored,
+none are imported.`,
 	Args: cobra.ExactArgs(1),
 	RunE: executeImport,
 }
@@ -421,6 +424,7 @@ func processImport(secrets map[string]string) error {
 
 	// Sort keys for consistent output
 	sortedKeys := sortKeys(secrets)
+	batch := make(map[string]*vault.SecretEntry, len(secrets))
 
 	for _, key := range sortedKeys {
 		value := secrets[key]
@@ -444,13 +448,27 @@ func processImport(secrets map[string]string) error {
 			continue
 		}
 
-		// Save secret
-		if err := saveSecret(key, value, exists); err != nil {
-			errs = append(errs, fmt.Sprintf("failed to import '%s': %v", key, err))
-			failed++
-			continue
+		batch[key] = &vault.SecretEntry{Value: []byte(value)}
+	}
+
+	// Save all secrets in one transaction: either all are imported or none
+	if len(batch) > 0 {
+		if err := v.SetSecrets(batch); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to import %v", err))
+			failed += len(batch)
+		} else {
+			for _, key := range sortedKeys {
+				if _, ok := batch[key]; !ok {
+					continue
+				}
+				if existingKeys[key] {
+					fmt.Printf("Overwritten: %s\n", key)
+				} else {
+					fmt.Printf("Imported: %s\n", key)
+				}
+				imported++
+			}
 		}
-		imported++
 	}
 
 	// Print summary
@@ -509,21 +527,6 @@ func handleConflict(key string, exists bool) (action string, errMsg string) {
 	}
 }
 
-func saveSecret(key, value string, exists bool) error {
-	entry := &vault.SecretEntry{
-		Value: []byte(value),
-	}
-	if err := v.SetSecret(key, entry); err != nil {
-		return err
-	}
-	if exists {
-		fmt.Printf("Overwritten: %s\n", key)
-	} else {
-		fmt.Printf("Imported: %s\n", key)
-	}
-	return nil
-}
-
 func printImportSummary(imported, skipped, conflicts, failed int) {
 	fmt.Println()
 	if importDryRun {
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -58,7 +59,7 @@ func executeCompetitorImport(filePath string) error {
 
 	// Print warnings
 	for _, warning := range result.Warnings {
-		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		slog.Warn(warning)
 	}
 
 	// Print skipped items
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forest6511/secretctl/internal/mcp"
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/i18n"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// kdfCalibrationTarget is how long one unlock should take with calibrated
+// KDF parameters.
+const kdfCalibrationTarget = time.Second
+
+// seedKeys suggests a key name for each built-in template.
+var seedKeys = map[string]string{
+	"login":    "web/login",
+	"database": "db/main",
+	"api":      "api/main",
+	"ssh":      "ssh/main",
+	"aws":      "aws/default",
+}
+
+// promptKDFParams asks whether to use the default KDF parameters or
+// calibrate them for this machine.
+func promptKDFParams() (crypto.KDFParams, error) {
+	defaults := crypto.DefaultKDFParams()
+	fmt.Println(i18n.T("Key derivation (Argon2id):"))
+	fmt.Printf("  1) %s (%s)\n", i18n.T("Standard"), defaults)
+	fmt.Printf("  2) %s\n", i18n.T("Calibrate for this machine (about 1s per unlock)"))
+	choice, err := askLine(i18n.T("Choose"), "1")
+	if err != nil {
+		return defaults, err
+	}
+	switch choice {
+	case "1":
+		return defaults, nil
+	case "2":
+		fmt.Println(i18n.T("Calibrating..."))
+		params := crypto.CalibrateKDF(kdfCalibrationTarget)
+		fmt.Printf("%s: %s\n", i18n.T("Using"), params)
+		return params, nil
+	}
+	return defaults, fmt.Errorf("invalid choice %q", choice)
+}
+
+// runInitWizard walks through the optional setup steps after a vault was
+// created: recovery kit, starter secrets, backup directory and MCP policy.
+func runInitWizard(password, home string) error {
+	if err := v.Unlock(password); err != nil {
+		return fmt.Errorf("failed to unlock new vault: %w", err)
+	}
+	defer v.Lock()
+
+	var summary []string
+
+	// 1. Recovery kit
+	fmt.Println()
+	if askYesNo(i18n.T("Create a recovery kit? It contains the data encryption key and must be stored offline. [y/N]: ")) {
+		path, err := askLine(i18n.T("Recovery kit path"), filepath.Join(home, vault.RecoveryKitFileName))
+		if err != nil {
+			return err
+		}
+		if err := v.IssueRecoveryKit(path); err != nil {
+			return err
+		}
+		summary = append(summary, "recovery kit: "+path)
+	}
+
+	// 2. Starter secrets
+	fmt.Println()
+	if askYesNo(i18n.T("Add starter secrets from templates? [y/N]: ")) {
+		seeded, err := seedSecrets()
+		if err != nil {
+			return err
+		}
+		for _, key := range seeded {
+			summary = append(summary, "secret: "+key)
+		}
+	}
+
+	// 3. Backup directory
+	fmt.Println()
+	if askYesNo(i18n.T("Set a backup directory for 'secretctl backup'? [y/N]: ")) {
+		dir, err := askLine(i18n.T("Backup directory"), filepath.Join(home, "secretctl-backups"))
+		if err != nil {
+			return err
+		}
+		if dir, err = filepath.Abs(dir); err != nil {
+			return fmt.Errorf("invalid backup directory: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		if err := v.UpdateSettings(func(s *vault.VaultSettings) error {
+			s.BackupDir = dir
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to save backup directory: %w", err)
+		}
+		summary = append(summary, "backup_dir: "+dir)
+	}
+
+	// 4. MCP policy
+	fmt.Println()
+	if askYesNo(i18n.T("Create an MCP policy for AI agents (deny all commands by default)? [y/N]: ")) {
+		path, err := mcp.WritePolicySkeleton(vaultPath)
+		if err != nil {
+			return err
+		}
+		summary = append(summary, "MCP policy: "+path)
+	}
+
+	fmt.Println()
+	if len(summary) == 0 {
+		fmt.Println(i18n.T("Setup complete."))
+		return nil
+	}
+	fmt.Println(i18n.T("Setup complete:"))
+	for _, line := range summary {
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
+
+// seedSecrets prompts for secrets based on built-in templates until the
+// user enters an empty template name. It returns the keys it stored.
+func seedSecrets() ([]string, error) {
+	names := ListTemplates()
+	sort.Strings(names)
+
+	var seeded []string
+	for {
+		name, err := askLine(fmt.Sprintf("%s (%s; %s)", i18n.T("Template"), strings.Join(names, ", "), i18n.T("empty to finish")), "")
+		if err != nil {
+			return seeded, err
+		}
+		if name == "" {
+			return seeded, nil
+		}
+		template, ok := BuiltinTemplates[name]
+		if !ok {
+			fmt.Printf("unknown template: %s\n", name)
+			continue
+		}
+		key, err := askLine(i18n.T("Key"), seedKeys[name])
+		if err != nil {
+			return seeded, err
+		}
+
+		values := make(map[string]string)
+		for _, tf := range template.Fields {
+			value, err := readTemplateField(tf)
+			if err != nil {
+				return seeded, err
+			}
+			values[tf.Name] = value
+		}
+		entry := &vault.SecretEntry{Fields: TemplateToFields(template, values)}
+		if err := v.SetSecret(key, entry); err != nil {
+			// Keep going so one bad value does not end the wizard
+			fmt.Printf("failed to save %s: %v\n", key, err)
+			continue
+		}
+		fmt.Printf("Secret '%s' saved with %d fields\n", key, len(entry.Fields))
+		seeded = append(seeded, key)
+	}
+}
+
+// askYesNo prints question and reports whether the answer was y or yes.
+func askYesNo(question string) bool {
+	fmt.Print(question)
+	answer, err := readLine()
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// askLine prompts for a line of input, returning def when it is empty.
+func askLine(prompt, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", prompt, def)
+	} else {
+		fmt.Printf("%s: ", prompt)
+	}
+	answer, err := readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
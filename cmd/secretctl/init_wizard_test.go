@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestRecoveryKitRoundTrip(t *testing.T) {
+	dek := bytes.Repeat([]byte{0xab}, vault.DEKLength)
+	path := filepath.Join(t.TempDir(), vault.RecoveryKitFileName)
+
+	if err := vault.WriteRecoveryKit(path, "/home/user/.secretctl", dek, time.Now()); err != nil {
+		t.Fatalf("WriteRecoveryKit failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("recovery kit permissions = %o, want 0600", perm)
+	}
+
+	got, err := readDEKFile(path)
+	if err != nil {
+		t.Fatalf("readDEKFile failed on recovery kit: %v", err)
+	}
+	if !bytes.Equal(got, dek) {
+		t.Error("readDEKFile returned a different DEK")
+	}
+
+	err = vault.WriteRecoveryKit(path, "/home/user/.secretctl", dek, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected existing kit to be kept, got %v", err)
+	}
+}
+
+func TestDefaultBackupPath(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 5, 0, time.UTC)
+	got := defaultBackupPath("/backups", now)
+	want := filepath.Join("/backups", "secretctl-backup-20261016-093005.enc")
+	if got != want {
+		t.Errorf("defaultBackupPath = %q, want %q", got, want)
+	}
+}
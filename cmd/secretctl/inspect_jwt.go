@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/jwt"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Inspect-jwt command flags
+var (
+	inspectJWTField     string
+	inspectJWTJSON      bool
+	inspectJWTSetExpiry bool
+	inspectJWTVerifyKey string
+)
+
+func init() {
+	rootCmd.AddCommand(inspectJWTCmd)
+
+	inspectJWTCmd.Flags().StringVar(&inspectJWTField, "field", "", "Field holding the token (default: the secret's value)")
+	inspectJWTCmd.Flags().BoolVar(&inspectJWTJSON, "json", false, "Output in JSON format")
+	inspectJWTCmd.Flags().BoolVar(&inspectJWTSetExpiry, "set-expiry", false, "Set the secret's expiration from the token's exp claim")
+	inspectJWTCmd.Flags().StringVar(&inspectJWTVerifyKey, "verify-key", "", "Verify the signature with the HMAC secret or PEM public key stored under this key")
+}
+
+// inspectJWTCmd decodes a stored JWT
+var inspectJWTCmd = &cobra.Command{
+	Use:   "inspect-jwt <key>",
+	Short: "Decode a JWT stored in the vault",
+	Long: `Decode the header and claims of a JWT stored in the vault and report
+whether it has expired.
+
+The signature is NOT verified unless --verify-key names a secret holding
+the HMAC secret (HS256/384/512) or a PEM public key or certificate
+(RS*, PS*, ES*, EdDSA).
+
+With --set-expiry, the secret's expiration is set to the token's exp claim
+so it shows up in 'secretctl list --expiring'. Only metadata changes; the
+value is not rewritten.`,
+	Example: `  secretctl inspect-jwt api/token
+  secretctl inspect-jwt oauth/github --field access_token --set-expiry
+  secretctl inspect-jwt api/token --verify-key api/signing-secret`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		entry, err := v.GetSecretAllowExpired(key)
+		if err != nil {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+		raw := string(entry.Value)
+		if inspectJWTField != "" {
+			_, field, err := vault.ResolveFieldName(entry.Fields, inspectJWTField)
+			if err != nil {
+				return fmt.Errorf("field %q not found", inspectJWTField)
+			}
+			raw = field.Value
+		}
+
+		token, err := jwt.Decode(raw)
+		if err != nil {
+			return err
+		}
+
+		verified := false
+		if inspectJWTVerifyKey != "" {
+			keyEntry, err := v.GetSecret(inspectJWTVerifyKey)
+			if err != nil {
+				return fmt.Errorf("failed to get verification key: %w", err)
+			}
+			if err := token.Verify(keyEntry.Value); err != nil {
+				return err
+			}
+			verified = true
+		}
+
+		if inspectJWTSetExpiry {
+			if token.ExpiresAt == nil {
+				return fmt.Errorf("token has no exp claim")
+			}
+			expiresAt := token.ExpiresAt.Local()
+			if _, err := v.BulkUpdate(vault.BulkFilter{KeyPattern: key}, vault.BulkPatch{ExpiresAt: &expiresAt}); err != nil {
+				return fmt.Errorf("failed to set expiration: %w", err)
+			}
+		}
+
+		now := time.Now()
+		if inspectJWTJSON {
+			out := struct {
+				*jwt.Token
+				Expired     bool `json:"expired"`
+				NotYetValid bool `json:"not_yet_valid"`
+				Verified    bool `json:"verified"`
+			}{token, token.Expired(now), token.NotYetValid(now), verified}
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println("Header:")
+		printJWTSection(token.Header)
+		fmt.Println("Claims:")
+		printJWTSection(token.Claims)
+		fmt.Println()
+
+		switch {
+		case token.ExpiresAt == nil:
+			fmt.Println("Expiry:    none (no exp claim)")
+		case token.Expired(now):
+			fmt.Printf("Expiry:    EXPIRED %s (%s ago)\n", token.ExpiresAt.Local().Format(time.RFC3339), now.Sub(*token.ExpiresAt).Round(time.Second))
+		default:
+			fmt.Printf("Expiry:    %s (in %s)\n", token.ExpiresAt.Local().Format(time.RFC3339), token.ExpiresAt.Sub(now).Round(time.Second))
+		}
+		if token.NotYetValid(now) {
+			fmt.Printf("Not valid before %s\n", token.NotBefore.Local().Format(time.RFC3339))
+		}
+		if verified {
+			fmt.Printf("Signature: valid (%s)\n", token.Alg())
+		} else {
+			fmt.Println("Signature: not verified")
+		}
+		if inspectJWTSetExpiry {
+			fmt.Printf("Set expiration of '%s' to %s\n", key, token.ExpiresAt.Local().Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+// printJWTSection prints a header or claims map in key order.
+func printJWTSection(m map[string]any) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := json.Marshal(m[name])
+		if err != nil {
+			value = []byte(fmt.Sprint(m[name]))
+		}
+		fmt.Printf("  %s: %s\n", name, value)
+	}
+}
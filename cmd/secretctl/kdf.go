@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/i18n"
+)
+
+var (
+	kdfTuneTarget time.Duration
+	kdfTuneApply  bool
+)
+
+func init() {
+	rootCmd.AddCommand(kdfCmd)
+	kdfCmd.AddCommand(kdfTuneCmd)
+	kdfTuneCmd.Flags().DurationVar(&kdfTuneTarget, "target", kdfCalibrationTarget, "How long one unlock should take")
+	kdfTuneCmd.Flags().BoolVar(&kdfTuneApply, "apply", false, "Re-wrap the vault key with the recommended parameters")
+}
+
+// kdfCmd groups the key derivation commands
+var kdfCmd = &cobra.Command{
+	Use:   "kdf",
+	Short: "Manage the key derivation (Argon2id) parameters",
+}
+
+// kdfTuneCmd benchmarks Argon2id on this machine
+var kdfTuneCmd = &cobra.Command{
+	Use:   "tune",
+	Short: "Benchmark key derivation and recommend parameters",
+	Long: `Measures how long Argon2id takes on this machine with the vault's
+current parameters and with the defaults, and recommends parameters that
+take about --target per unlock. Memory and parallelism stay at the
+defaults; only the iterations are raised, so the recommendation is never
+weaker than the defaults.
+
+With --apply, the data encryption key is re-wrapped with a key derived
+under the recommended parameters. The secrets are not re-encrypted.
+
+Vaults whose parameters are weaker than the defaults are upgraded
+automatically when they are unlocked.
+
+Examples:
+  secretctl kdf tune
+  secretctl kdf tune --target 2s --apply`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if kdfTuneTarget <= 0 {
+			return fmt.Errorf("invalid --target %s", kdfTuneTarget)
+		}
+		current, err := v.KDFParams()
+		if err != nil {
+			return err
+		}
+		defaults := crypto.DefaultKDFParams()
+
+		fmt.Println(i18n.T("Calibrating..."))
+		recommended := crypto.CalibrateKDF(kdfTuneTarget)
+		fmt.Printf("  %-12s %s (%s)\n", "Current:", current, roundTiming(crypto.BenchmarkKDF(current)))
+		if current != defaults {
+			fmt.Printf("  %-12s %s (%s)\n", "Defaults:", defaults, roundTiming(crypto.BenchmarkKDF(defaults)))
+		}
+		fmt.Printf("  %-12s %s (%s)\n", "Recommended:", recommended, roundTiming(crypto.BenchmarkKDF(recommended)))
+
+		if !kdfTuneApply {
+			if recommended.WeakerThan(current) || recommended == current {
+				fmt.Println("The current parameters are at least as strong as recommended")
+			} else {
+				fmt.Println("Run with --apply to use the recommended parameters")
+			}
+			return nil
+		}
+
+		fmt.Print(i18n.T("Enter master password: "))
+		password, err := term.ReadPassword(int(syscall.Stdin))
+		defer crypto.SecureWipe(password)
+		if err != nil {
+			return &exitError{code: ExitLocked, err: fmt.Errorf("failed to read password: %w", err)}
+		}
+		fmt.Println()
+
+		if v.IsLocked() {
+			if err := v.Unlock(string(password)); err != nil {
+				return fmt.Errorf("failed to unlock vault: %w", err)
+			}
+		}
+		defer v.Lock()
+		if err := v.SetKDFParams(string(password), recommended); err != nil {
+			return fmt.Errorf("failed to change key derivation parameters: %w", err)
+		}
+		fmt.Printf("Key derivation parameters set to %s\n", recommended)
+		return nil
+	},
+}
+
+// roundTiming formats a benchmark duration for display.
+func roundTiming(d time.Duration) string {
+	return d.Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// keyTreeNode is one segment of the key hierarchy rendered by list --tree.
+type keyTreeNode struct {
+	name     string
+	dir      bool // has children; printed with a trailing '/'
+	children []*keyTreeNode
+}
+
+// child returns the child of n with the given name and kind, adding it if
+// missing. A key "aws" and a directory "aws/" are different children.
+func (n *keyTreeNode) child(name string, dir bool) *keyTreeNode {
+	for _, c := range n.children {
+		if c.name == name && c.dir == dir {
+			return c
+		}
+	}
+	c := &keyTreeNode{name: name, dir: dir}
+	n.children = append(n.children, c)
+	return c
+}
+
+// buildKeyTree splits keys on '/' into a tree. Children keep the order of
+// keys, so pass them sorted.
+func buildKeyTree(keys []string) *keyTreeNode {
+	root := &keyTreeNode{}
+	for _, key := range keys {
+		node := root
+		segments := strings.Split(key, "/")
+		for i, seg := range segments {
+			node = node.child(seg, i < len(segments)-1)
+		}
+	}
+	return root
+}
+
+// printKeyTree writes keys as a tree of their '/' hierarchy.
+func printKeyTree(w io.Writer, keys []string) {
+	for _, c := range buildKeyTree(keys).children {
+		fmt.Fprintln(w, c.label())
+		printKeyTreeChildren(w, c, "")
+	}
+}
+
+func printKeyTreeChildren(w io.Writer, n *keyTreeNode, indent string) {
+	for i, c := range n.children {
+		branch, next := "├── ", "│   "
+		if i == len(n.children)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintln(w, indent+branch+c.label())
+		printKeyTreeChildren(w, c, indent+next)
+	}
+}
+
+func (n *keyTreeNode) label() string {
+	if n.dir {
+		return n.name + "/"
+	}
+	return n.name
+}
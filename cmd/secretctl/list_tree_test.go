@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintKeyTree(t *testing.T) {
+	var buf bytes.Buffer
+	printKeyTree(&buf, []string{
+		"TOP_LEVEL",
+		"aws",
+		"aws/dev/DB_URL",
+		"aws/prod/API_KEY",
+		"aws/prod/DB_URL",
+	})
+	want := `TOP_LEVEL
+aws
+aws/
+├── dev/
+│   └── DB_URL
+└── prod/
+    ├── API_KEY
+    └── DB_URL
+`
+	if got := buf.String(); got != want {
+		t.Errorf("printKeyTree:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -1,13 +1,34 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/i18n"
 )
 
+// version is set by release builds with -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// processStart is when the process started, as near as Go lets us tell,
+// for 'secretctl status --timings'.
+var processStart = time.Now()
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// Errors are printed here, localized, instead of by cobra
+	rootCmd.SilenceErrors = true
+	usageErrors(rootCmd)
+	err := rootCmd.Execute()
+	closeCIBundle()
+	_ = closeLog()
+	if err != nil {
+		// A command run by 'secretctl run' that failed has already reported why
+		var exitErr *exitError
+		if !errors.As(err, &exitErr) || exitErr.err != nil {
+			fmt.Fprintln(os.Stderr, i18n.T("Error: %s", i18n.Error(err)))
+		}
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/internal/mcp"
+)
+
+// MCP approve command flags
+var mcpApproveTTL time.Duration
+
+func init() {
+	mcpCmd.AddCommand(mcpApprovalsCmd)
+	mcpCmd.AddCommand(mcpApproveCmd)
+	mcpCmd.AddCommand(mcpDenyCmd)
+	mcpCmd.AddCommand(mcpRevokeCmd)
+
+	mcpApproveCmd.Flags().DurationVar(&mcpApproveTTL, "ttl", 0, "How long the approval lasts (default: grant_ttl from mcp-policy.yaml, 720h if unset)")
+}
+
+// mcpApprovalsCmd lists pending first-use requests and active grants
+var mcpApprovalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "List pending first-use requests and approved keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		requests, grants, err := mcp.ListApprovals(v, vaultPath)
+		if err != nil {
+			return fmt.Errorf("failed to list approvals: %w", err)
+		}
+		if len(requests) == 0 {
+			fmt.Println("No pending requests")
+		} else {
+			fmt.Println("Pending requests:")
+			for _, r := range requests {
+				fmt.Printf("  %s  %s wants '%s' (%s ago)\n", r.ID, r.Client, r.Key, time.Since(r.RequestedAt).Round(time.Second))
+			}
+		}
+		if len(grants) > 0 {
+			fmt.Println("Approved:")
+			for _, g := range grants {
+				fmt.Printf("  %s: '%s' (expires in %s)\n", g.Client, g.Key, time.Until(g.ExpiresAt).Round(time.Minute))
+			}
+		}
+		return nil
+	},
+}
+
+// mcpApproveCmd approves a pending first-use request
+var mcpApproveCmd = &cobra.Command{
+	Use:   "approve <request-id>",
+	Short: "Allow an MCP client to use a key it asked for",
+	Long: `Approve a pending first-use request. With first_use_approval in
+mcp-policy.yaml, the first time an MCP client uses a key its tool call
+fails with a request ID; after approval the client may use the key until
+the approval expires, also after the MCP server restarts.
+
+  first_use_approval:
+    keys: ["prod/*"]   # empty for every key
+    grant_ttl: 720h
+
+Examples:
+  secretctl mcp approvals
+  secretctl mcp approve 3f9a1c02
+  secretctl mcp approve 3f9a1c02 --ttl 8h`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		grant, err := mcp.Approve(v, vaultPath, args[0], mcpApproveTTL)
+		if err != nil {
+			if errors.Is(err, mcp.ErrApprovalNotFound) {
+				return fmt.Errorf("no pending request %s (see 'secretctl mcp approvals')", args[0])
+			}
+			return fmt.Errorf("failed to approve: %w", err)
+		}
+		fmt.Printf("Approved '%s' for %s until %s\n", grant.Key, grant.Client, grant.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+// mcpDenyCmd discards a pending first-use request
+var mcpDenyCmd = &cobra.Command{
+	Use:   "deny <request-id>",
+	Short: "Discard a pending first-use request",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		request, err := mcp.Deny(v, vaultPath, args[0])
+		if err != nil {
+			if errors.Is(err, mcp.ErrApprovalNotFound) {
+				return fmt.Errorf("no pending request %s (see 'secretctl mcp approvals')", args[0])
+			}
+			return fmt.Errorf("failed to deny: %w", err)
+		}
+		fmt.Printf("Denied '%s' for %s\n", request.Key, request.Client)
+		return nil
+	},
+}
+
+// mcpRevokeCmd removes an approval before it expires
+var mcpRevokeCmd = &cobra.Command{
+	Use:   "revoke <client> <key>",
+	Short: "Revoke an approval before it expires",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := mcp.RevokeGrant(v, vaultPath, args[0], args[1]); err != nil {
+			return fmt.Errorf("failed to revoke: %w", err)
+		}
+		fmt.Printf("Revoked '%s' for %s\n", args[1], args[0])
+		return nil
+	},
+}
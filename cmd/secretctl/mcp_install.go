@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/internal/mcp"
+	"github.com/forest6511/secretctl/pkg/mcpconfig"
+)
+
+var (
+	mcpInstallClient   string
+	mcpInstallConfig   string
+	mcpInstallCommand  string
+	mcpInstallVaultDir string
+	mcpInstallPolicy   string
+	mcpInstallDryRun   bool
+)
+
+func init() {
+	mcpCmd.AddCommand(mcpInstallCmd)
+
+	mcpInstallCmd.Flags().StringVar(&mcpInstallClient, "client", "", "Client to configure: claude, cursor or vscode")
+	mcpInstallCmd.Flags().StringVar(&mcpInstallConfig, "config", "", "Config file to edit instead of the client's user-level file")
+	mcpInstallCmd.Flags().StringVar(&mcpInstallCommand, "command", "", "secretctl executable the client starts (default: this executable)")
+	mcpInstallCmd.Flags().StringVar(&mcpInstallVaultDir, "vault-dir", "", "Vault directory (default ~/.secretctl)")
+	mcpInstallCmd.Flags().StringVar(&mcpInstallPolicy, "policy", "", "MCP policy file (default mcp-policy.yaml in the vault directory)")
+	mcpInstallCmd.Flags().BoolVar(&mcpInstallDryRun, "dry-run", false, "Print the new configuration without writing it")
+	_ = mcpInstallCmd.MarkFlagRequired("client")
+}
+
+// mcpInstallCmd registers secretctl mcp-server with an MCP client
+var mcpInstallCmd = &cobra.Command{
+	Use:   "install --client claude|cursor|vscode",
+	Short: "Register secretctl as an MCP server in Claude Desktop, Cursor or VS Code",
+	Long: `Add or update the "secretctl" entry in an MCP client's configuration so
+that it starts 'secretctl mcp-server' with absolute paths to this
+executable, the vault and the MCP policy.
+
+Config files:
+  claude  Claude Desktop: claude_desktop_config.json
+  cursor  Cursor: ~/.cursor/mcp.json
+  vscode  VS Code: mcp.json in the user settings directory
+
+Other servers and settings in the file are kept. The previous file is
+saved next to it as <file>.<timestamp>.bak. Use --config to edit another
+file, such as a project's .cursor/mcp.json or .vscode/mcp.json.
+
+No password is written. Without SECRETCTL_PASSWORD, the server joins the
+unlocked session of the desktop app.
+
+Examples:
+  secretctl mcp install --client claude --dry-run
+  secretctl mcp install --client cursor
+  secretctl mcp install --client vscode --config .vscode/mcp.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := mcpconfig.Client(strings.ToLower(mcpInstallClient))
+
+		path := mcpInstallConfig
+		if path == "" {
+			var err error
+			if path, err = mcpconfig.ConfigPath(client); err != nil {
+				return err
+			}
+		}
+
+		command := mcpInstallCommand
+		if command == "" {
+			exe, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("cannot locate secretctl executable (use --command): %w", err)
+			}
+			if command, err = filepath.EvalSymlinks(exe); err != nil {
+				return fmt.Errorf("cannot locate secretctl executable (use --command): %w", err)
+			}
+		}
+		vaultDir := mcpInstallVaultDir
+		if vaultDir == "" {
+			vaultDir = vaultPath
+		}
+		policyPath := mcpInstallPolicy
+		if policyPath == "" {
+			policyPath = filepath.Join(vaultDir, mcp.PolicyFileName)
+		}
+		for _, p := range []*string{&command, &vaultDir, &policyPath} {
+			abs, err := filepath.Abs(*p)
+			if err != nil {
+				return fmt.Errorf("invalid path %s: %w", *p, err)
+			}
+			*p = abs
+		}
+
+		change, err := mcpconfig.Plan(client, path, mcpconfig.SecretctlServer(command, vaultDir, policyPath))
+		if err != nil {
+			return err
+		}
+
+		if mcpInstallDryRun {
+			if !change.Changed() {
+				fmt.Printf("%s is already up to date\n", change.Path)
+				return nil
+			}
+			fmt.Printf("Would write %s:\n\n%s", change.Path, change.After)
+			if change.Before != nil {
+				fmt.Printf("\nThe current file would be saved as %s\n", change.Backup)
+			}
+			return nil
+		}
+
+		if !change.Changed() {
+			fmt.Printf("%s is already up to date\n", change.Path)
+			return nil
+		}
+		if err := change.Apply(); err != nil {
+			return err
+		}
+		fmt.Printf("Registered secretctl with %s in %s\n", client.Name(), change.Path)
+		if change.Before != nil {
+			fmt.Printf("Previous configuration saved as %s\n", change.Backup)
+		}
+		if _, err := os.Stat(policyPath); errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Note: %s does not exist, so secret_run stays disabled until you create it.\n", policyPath)
+		}
+		fmt.Printf("Restart %s to load the server.\n", client.Name())
+		return nil
+	},
+}
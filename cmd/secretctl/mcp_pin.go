@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/internal/mcp"
+)
+
+// MCP pin command flags
+var mcpPinTTL time.Duration
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+	mcpCmd.AddCommand(mcpPinCmd)
+	mcpCmd.AddCommand(mcpUnpinCmd)
+	mcpCmd.AddCommand(mcpPinsCmd)
+
+	mcpPinCmd.Flags().DurationVar(&mcpPinTTL, "ttl", 30*time.Minute, "How long the pin lasts (e.g., 30m, 2h; max 24h)")
+}
+
+// mcpCmd groups commands that manage the running MCP server session
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Manage MCP server sessions",
+	Long: `Manage the session of a running MCP server (see 'secretctl mcp-server').
+
+Keys matching a pin_required pattern in mcp-policy.yaml can only be used
+by MCP tools after you pin them for the current server session:
+
+  pin_required:
+    - "prod/*"
+
+Pins expire after their TTL and end when the MCP server stops.
+
+With first_use_approval, each MCP client must be approved the first time
+it uses a key ('secretctl mcp approvals', 'secretctl mcp approve <id>').
+Approvals last for their grant_ttl, also across server restarts.`,
+}
+
+// mcpPinCmd authorizes MCP tools to use a pin_required key
+var mcpPinCmd = &cobra.Command{
+	Use:   "pin <key>",
+	Short: "Allow MCP tools to use a pin_required key for a while",
+	Long: `Allow MCP tools to use a pin_required key in the current MCP server
+session until the TTL runs out.
+
+Examples:
+  secretctl mcp pin prod/db-password
+  secretctl mcp pin prod/db-password --ttl 2h`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if _, err := v.InspectSecret(key); err != nil {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+
+		pin, err := mcp.PinKey(v, vaultPath, key, mcpPinTTL)
+		if err != nil {
+			if errors.Is(err, mcp.ErrNoSession) {
+				return errors.New("no MCP server is running; start 'secretctl mcp-server' first")
+			}
+			return fmt.Errorf("failed to pin key: %w", err)
+		}
+		fmt.Printf("Pinned '%s' for MCP tools until %s\n", key, pin.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+// mcpUnpinCmd revokes a pin before it expires
+var mcpUnpinCmd = &cobra.Command{
+	Use:   "unpin <key>",
+	Short: "Revoke a pin before it expires",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := mcp.UnpinKey(v, vaultPath, key); err != nil {
+			if errors.Is(err, mcp.ErrNoSession) {
+				return errors.New("no MCP server is running")
+			}
+			return fmt.Errorf("failed to unpin key: %w", err)
+		}
+		fmt.Printf("Unpinned '%s'\n", key)
+		return nil
+	},
+}
+
+// mcpPinsCmd lists the active pins of the current session
+var mcpPinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "List active pins of the running MCP server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		pins, err := mcp.ListPins(v, vaultPath)
+		if err != nil {
+			if errors.Is(err, mcp.ErrNoSession) {
+				fmt.Println("No MCP server is running")
+				return nil
+			}
+			return fmt.Errorf("failed to list pins: %w", err)
+		}
+		if len(pins) == 0 {
+			fmt.Println("No active pins")
+			return nil
+		}
+		for _, p := range pins {
+			fmt.Printf("%s (expires in %s)\n", p.Key, time.Until(p.ExpiresAt).Round(time.Second))
+		}
+		return nil
+	},
+}
@@ -6,14 +6,27 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/forest6511/secretctl/internal/mcp"
 )
 
+var (
+	mcpServerVaultDir string
+	mcpServerPolicy   string
+	mcpServerSnapshot bool
+	mcpServerAutoLock time.Duration
+)
+
 func init() {
 	rootCmd.AddCommand(mcpServerCmd)
+
+	mcpServerCmd.Flags().StringVar(&mcpServerVaultDir, "vault-dir", "", "Vault directory (default $SECRETCTL_VAULT_DIR or ~/.secretctl)")
+	mcpServerCmd.Flags().StringVar(&mcpServerPolicy, "policy", "", "MCP policy file (default mcp-policy.yaml in the vault directory)")
+	mcpServerCmd.Flags().BoolVar(&mcpServerSnapshot, "snapshot", false, "Read a copy of the vault taken at startup instead of vault.db")
+	mcpServerCmd.Flags().DurationVar(&mcpServerAutoLock, "auto-lock", 0, "Lock the vault after this long without tool calls (0 = never)")
 }
 
 // mcpServerCmd starts the MCP server for AI coding assistant integration
@@ -30,19 +43,50 @@ Available tools:
   - secret_exists:     Check if a secret exists with metadata
   - secret_get_masked: Get masked secret value (e.g., "****WXYZ")
   - secret_run:        Execute command with secrets as environment variables
+  - policy_info:       Describe the effective policy and remaining budget
 
 Authentication:
   Set SECRETCTL_PASSWORD environment variable before starting the server.
   The password is read once and immediately cleared from the environment.
 
+  Without SECRETCTL_PASSWORD, the server asks a running desktop app to join
+  its unlocked session; the app prompts you to allow it. Locking the app
+  then locks the server, and a session_budget lock locks the app.
+
+  The vault is read from --vault-dir, $SECRETCTL_VAULT_DIR, or ~/.secretctl.
+
+  With --auto-lock (e.g. --auto-lock 30m), the vault locks once no tool
+  has used it for that long; later tool calls fail until the server is
+  restarted.
+
+Snapshot:
+  With --snapshot, the server copies the vault database at startup and
+  reads only the copy, so agent traffic never touches the vault.db that
+  the CLI and desktop app write to. Changes made elsewhere are not visible
+  until the agent calls the snapshot_refresh tool, and tools that change
+  the vault (folder_create, folder_move_secret) fail.
+
   SECURITY NOTE: On Linux, the environment variable may briefly be visible
   via /proc/<pid>/environ before it is cleared. For maximum security,
   consider using a secrets manager or setting the variable immediately
   before execution in a subshell.
 
 Policy:
-  Create ~/.secretctl/mcp-policy.yaml to configure allowed commands for secret_run.
+  Create ~/.secretctl/mcp-policy.yaml (or pass --policy) to configure
+  allowed commands for secret_run.
   Without a policy file, secret_run is disabled (deny-by-default).
+  Keys matching pin_required patterns must be pinned with
+  'secretctl mcp pin <key>' before any tool may use them.
+  A session_budget (max_secrets, max_runs, max_injected_bytes) locks the
+  session once exceeded; restart the server to continue.
+  inherit_env lists environment variables, such as SSH_AUTH_SOCK, that
+  commands inherit besides PATH, HOME and the other safe defaults.
+  policy_info_redact lists sections hidden from the policy_info tool
+  (allowed_commands, denied_commands, env_aliases, pin_required,
+  session_budget, or all).
+
+'secretctl mcp install --client claude|cursor|vscode' writes the
+configuration below for you.
 
 Example MCP configuration for Claude Code (~/.claude.json):
   {
@@ -63,8 +107,12 @@ Example MCP configuration for Claude Code (~/.claude.json):
 }
 
 func runMCPServer() error {
-	// Create server with default options
-	server, err := mcp.NewServer(nil)
+	server, err := mcp.NewServer(&mcp.ServerOptions{
+		VaultPath:  mcpServerVaultDir,
+		PolicyPath: mcpServerPolicy,
+		Snapshot:   mcpServerSnapshot,
+		AutoLock:   mcpServerAutoLock,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create MCP server: %w", err)
 	}
@@ -4,9 +4,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
 	"syscall"
 
 	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/i18n"
 	"github.com/forest6511/secretctl/pkg/vault"
 
 	"github.com/spf13/cobra"
@@ -31,7 +33,13 @@ This operation:
   3. Re-wraps the DEK with the new password
   4. All secrets remain accessible with the new password
 
-The change is atomic: either fully succeeds or has no effect.`,
+The change is atomic: either fully succeeds or has no effect.
+
+Recovery kits and emergency contacts hold the data encryption key itself,
+so they keep working after the change. They are listed afterwards, and you
+can invalidate them by rotating the key: each kit can then be re-issued for
+the new key or revoked, and each contact kept (re-sealed to the new key) or
+removed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Ensure vault is unlocked (prompts for password if needed)
 		if err := ensureUnlocked(); err != nil {
@@ -43,7 +51,7 @@ The change is atomic: either fully succeeds or has no effect.`,
 		fmt.Println()
 
 		// 1. Prompt for current password (for verification)
-		fmt.Print("Enter current password: ")
+		fmt.Print(i18n.T("Enter current password: "))
 		currentPassword, err := term.ReadPassword(int(syscall.Stdin))
 		defer crypto.SecureWipe(currentPassword)
 		if err != nil {
@@ -52,7 +60,7 @@ The change is atomic: either fully succeeds or has no effect.`,
 		fmt.Println()
 
 		// 2. Prompt for new password
-		fmt.Print("Enter new password: ")
+		fmt.Print(i18n.T("Enter new password: "))
 		newPassword1, err := term.ReadPassword(int(syscall.Stdin))
 		defer crypto.SecureWipe(newPassword1)
 		if err != nil {
@@ -61,7 +69,7 @@ The change is atomic: either fully succeeds or has no effect.`,
 		fmt.Println()
 
 		// 3. Confirm new password
-		fmt.Print("Confirm new password: ")
+		fmt.Print(i18n.T("Confirm new password: "))
 		newPassword2, err := term.ReadPassword(int(syscall.Stdin))
 		defer crypto.SecureWipe(newPassword2)
 		if err != nil {
@@ -89,12 +97,12 @@ The change is atomic: either fully succeeds or has no effect.`,
 
 		// 6. Execute password change
 		fmt.Println("Changing password...")
-		if err := v.ChangePassword(string(currentPassword), string(newPassword1)); err != nil {
+		if err := v.ChangeMasterPassword(string(currentPassword), string(newPassword1)); err != nil {
 			if errors.Is(err, vault.ErrInvalidPassword) {
-				return errors.New("current password is incorrect")
+				return &exitError{code: ExitLocked, err: errors.New("current password is incorrect")}
 			}
 			if errors.Is(err, vault.ErrSamePassword) {
-				return errors.New("new password must be different from current password")
+				return &exitError{code: ExitUsage, err: errors.New("new password must be different from current password")}
 			}
 			return fmt.Errorf("failed to change password: %w", err)
 		}
@@ -103,6 +111,104 @@ The change is atomic: either fully succeeds or has no effect.`,
 		fmt.Println("Password changed successfully!")
 		fmt.Println("A backup of your vault was created before the change.")
 
-		return nil
+		return reviewRecoveryArtifacts(string(newPassword1))
 	},
 }
+
+// reviewRecoveryArtifacts lists the recovery artifacts that still open the
+// vault after a password change and, if the user agrees, rotates the DEK
+// to invalidate them, re-issuing or revoking each one. It prints a summary
+// of what happened to every artifact.
+func reviewRecoveryArtifacts(password string) error {
+	artifacts, err := v.RecoveryArtifacts()
+	if err != nil {
+		return fmt.Errorf("failed to list recovery artifacts: %w", err)
+	}
+	if len(artifacts) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("These still open the vault without the new password:")
+	for _, a := range artifacts {
+		fmt.Printf("  - %s (created %s)\n", describeArtifact(a), tf.Date(a.CreatedAt))
+	}
+	fmt.Println()
+	if !askYesNo("Rotate the data key to invalidate them? Every secret is re-encrypted. [y/N]: ") {
+		fmt.Println("They remain valid. Run 'secretctl rekey' to invalidate them later.")
+		return nil
+	}
+
+	// Decide for every artifact before rotating
+	keep := make([]bool, len(artifacts))
+	for i, a := range artifacts {
+		switch a.Kind {
+		case vault.ArtifactRecoveryKit:
+			keep[i] = askYesNo(fmt.Sprintf("Re-issue %s for the new key? Otherwise it is revoked. [y/N]: ", describeArtifact(a)))
+		case vault.ArtifactEmergencyContact:
+			keep[i] = !askYesNo(fmt.Sprintf("Remove %s? Otherwise the new key is sealed to them. [y/N]: ", describeArtifact(a)))
+		}
+	}
+
+	summary := make([]string, len(artifacts))
+	for i, a := range artifacts {
+		if a.Kind != vault.ArtifactEmergencyContact || keep[i] {
+			continue
+		}
+		if err := v.RemoveEmergencyContact(a.Name); err != nil {
+			summary[i] = fmt.Sprintf("failed to remove: %v", err)
+		} else {
+			summary[i] = "removed"
+		}
+	}
+
+	bar := newProgressPrinter("Rotating key")
+	err = v.RotateDEK(password, bar.Update)
+	bar.Done()
+	if err != nil {
+		return fmt.Errorf("failed to rotate data key: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Println("Recovery artifacts:")
+	for i, a := range artifacts {
+		switch {
+		case summary[i] != "":
+		case a.Kind == vault.ArtifactEmergencyContact:
+			summary[i] = "kept, re-sealed to the new key"
+		default:
+			summary[i] = handleStaleKit(a.Name, keep[i])
+		}
+		fmt.Printf("  - %s: %s\n", describeArtifact(a), summary[i])
+	}
+	return nil
+}
+
+// describeArtifact names a recovery artifact for display.
+func describeArtifact(a vault.RecoveryArtifact) string {
+	if a.Kind == vault.ArtifactEmergencyContact {
+		return "emergency contact " + a.Name
+	}
+	return "recovery kit " + a.Name
+}
+
+// handleStaleKit deletes a recovery kit for the old key, if it is on this
+// machine, and re-issues it at the same path if reissue is set. It
+// describes the outcome.
+func handleStaleKit(path string, reissue bool) string {
+	err := os.Remove(path)
+	deleted := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Sprintf("revoked, but the old file could not be deleted: %v", err)
+	}
+	if reissue {
+		if err := v.IssueRecoveryKit(path); err != nil {
+			return fmt.Sprintf("revoked, but re-issuing failed: %v", err)
+		}
+		return "re-issued for the new key"
+	}
+	if deleted {
+		return "revoked, file deleted"
+	}
+	return "revoked; the file is not on this machine, destroy any copies"
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+}
+
+// pluginCmd is the parent command for dynamic secret plugins
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage plugins for dynamic:// secrets",
+	Long: `Manage plugins that resolve dynamic:// secrets.
+
+A secret whose value is a dynamic:// URI is fetched from a plugin every time
+it is read (cached for the TTL the plugin returns, until the vault is locked):
+
+  secretctl set db/prod <<< "dynamic://bastion/db/prod?role=readonly"
+
+Plugins are executables named secretctl-plugin-<name> in the vault's plugins
+directory. They must not be writable by group or others, and must be enabled
+before they are run:
+
+  secretctl config set dynamic_plugins bastion
+
+Protocol: the plugin reads one JSON request from stdin,
+  {"version":1,"operation":"get","key":"db/prod","path":"db/prod","params":{"role":"readonly"}}
+and writes one JSON response to stdout, then exits:
+  {"value":"...","fields":{"username":"..."},"ttl_seconds":300}
+or {"error":"message"} on failure. Fields are added to the secret, so they
+can be used with field bindings. SECRETCTL_* environment variables are not
+passed to plugins.`,
+}
+
+// pluginListCmd lists installed plugins
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed plugins",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugins, err := v.ListPlugins()
+		if err != nil {
+			return err
+		}
+		if len(plugins) == 0 {
+			fmt.Printf("No plugins installed in %s\n", v.PluginDir())
+			return nil
+		}
+		for _, p := range plugins {
+			status := "disabled"
+			if p.Enabled {
+				status = "enabled"
+			}
+			fmt.Printf("%-20s %-8s %s\n", p.Name, status, p.Path)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// progressBarWidth is the number of cells in a progress bar.
+const progressBarWidth = 30
+
+// progressPrinter draws vault progress updates on stderr: a bar redrawn in
+// place on a terminal, or a line per 10% otherwise (e.g. in CI logs).
+type progressPrinter struct {
+	w     io.Writer
+	label string
+	tty   bool
+	step  int // last 10% step printed without a terminal
+	drawn bool
+}
+
+// newProgressPrinter returns a printer for an operation described by label.
+func newProgressPrinter(label string) *progressPrinter {
+	return &progressPrinter{w: os.Stderr, label: label, tty: term.IsTerminal(int(os.Stderr.Fd())), step: -1}
+}
+
+// Update draws p. It has the signature of vault.ProgressFunc.
+func (pp *progressPrinter) Update(p vault.Progress) {
+	percent := p.Percent()
+	if !pp.tty {
+		if step := int(percent) / 10; step > pp.step {
+			pp.step = step
+			fmt.Fprintf(pp.w, "%s: %3.0f%% (%d/%d)\n", pp.label, percent, p.Done, p.Total)
+		}
+		return
+	}
+	filled := int(percent * progressBarWidth / 100)
+	fmt.Fprintf(pp.w, "\r%s [%s%s] %3.0f%% (%d/%d)", pp.label,
+		strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), percent, p.Done, p.Total)
+	pp.drawn = true
+}
+
+// Done ends the bar's line.
+func (pp *progressPrinter) Done() {
+	if pp.drawn {
+		fmt.Fprintln(pp.w)
+		pp.drawn = false
+	}
+}
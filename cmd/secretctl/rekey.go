@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/i18n"
+)
+
+var rekeyRecoveryKit string
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+	rekeyCmd.Flags().StringVar(&rekeyRecoveryKit, "recovery-kit", "", "Write a recovery kit for the new key to this file")
+}
+
+// rekeyCmd replaces the data encryption key
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Replace the data encryption key",
+	Long: `Replaces the data encryption key (DEK) with a new random key and
+re-encrypts every secret, key name, metadata blob and history entry with
+it. Use it when the DEK may have been exposed, for example in a memory or
+process dump. Changing the master password does not change the DEK.
+
+The rotation runs in a single transaction. If it is interrupted, the vault
+is unchanged and still uses the old key; run the command again.
+
+After the rotation:
+  - emergency contacts are re-sealed to the new key
+  - recovery kits and shared sessions created earlier no longer work;
+    use --recovery-kit to write a new kit
+  - other processes that unlocked the vault earlier cannot write until
+    they unlock it again; the agent, the MCP server and the desktop app
+    lock themselves
+  - 'secretctl audit verify' still accepts records from before the rotation
+
+Every shard must be available.
+
+Examples:
+  secretctl rekey
+  secretctl rekey --recovery-kit /media/usb/secretctl-recovery-kit.txt`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Print(i18n.T("Enter master password: "))
+		password, err := term.ReadPassword(int(syscall.Stdin))
+		defer crypto.SecureWipe(password)
+		if err != nil {
+			return &exitError{code: ExitLocked, err: fmt.Errorf("failed to read password: %w", err)}
+		}
+		fmt.Println()
+
+		if v.IsLocked() {
+			if err := v.Unlock(string(password)); err != nil {
+				return fmt.Errorf("failed to unlock vault: %w", err)
+			}
+		}
+		defer v.Lock()
+
+		bar := newProgressPrinter("Rotating key")
+		err = v.RotateDEK(string(password), bar.Update)
+		bar.Done()
+		if err != nil {
+			return fmt.Errorf("failed to rotate data key: %w", err)
+		}
+		fmt.Println("Data key rotated")
+
+		if rekeyRecoveryKit == "" {
+			return nil
+		}
+		if err := v.IssueRecoveryKit(rekeyRecoveryKit); err != nil {
+			return err
+		}
+		fmt.Printf("Recovery kit written to %s\n", rekeyRecoveryKit)
+		return nil
+	},
+}
@@ -3,12 +3,16 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/forest6511/secretctl/pkg/backup"
+	"github.com/forest6511/secretctl/pkg/i18n"
+	"github.com/forest6511/secretctl/pkg/vault"
 )
 
 var (
@@ -18,6 +22,9 @@ var (
 	restoreKeyFile    string
 	restoreForce      bool
 	restoreWithAudit  bool
+	restoreVaultPass  bool
+	restoreLogical    bool
+	restoreKeys       []string
 )
 
 func init() {
@@ -29,6 +36,9 @@ func init() {
 	restoreCmd.Flags().StringVar(&restoreKeyFile, "key-file", "", "Decryption key file")
 	restoreCmd.Flags().BoolVarP(&restoreForce, "force", "f", false, "Skip confirmation prompt")
 	restoreCmd.Flags().BoolVar(&restoreWithAudit, "with-audit", false, "Restore audit log (overwrites existing)")
+	restoreCmd.Flags().BoolVar(&restoreVaultPass, "vault-password", false, "Prompt for the backed-up vault's master password (for --dry-run and --logical)")
+	restoreCmd.Flags().BoolVar(&restoreLogical, "logical", false, "Merge secrets into the existing vault instead of replacing its files")
+	restoreCmd.Flags().StringSliceVar(&restoreKeys, "keys", nil, "With --logical, only restore keys matching these patterns (e.g. 'db/*')")
 }
 
 var restoreCmd = &cobra.Command{
@@ -36,6 +46,18 @@ var restoreCmd = &cobra.Command{
 	Short: "Restore vault from encrypted backup",
 	Long: `Restore the vault from an encrypted backup file.
 
+If a vault already exists, --dry-run unlocks it and lists every key as
+new, identical, local-only, or conflicting (local-newer or backup-newer).
+The comparison opens the backed-up vault with the backup password; use
+--vault-password if the backup was made with --backup-password, and for
+key-file backups you are always asked for it.
+
+--logical merges the backed-up secrets into the existing vault through the
+vault API instead of replacing its files. The vault keeps its own master
+password, keys missing from the backup are kept, --on-conflict applies
+per key, and --keys limits which keys are restored. History is not
+carried over.
+
 Examples:
   # Dry run (preview only)
   secretctl restore backup.enc --dry-run
@@ -56,7 +78,10 @@ Examples:
   secretctl restore backup.enc --with-audit
 
   # Use key file for decryption
-  secretctl restore backup.enc --key-file=backup.key`,
+  secretctl restore backup.enc --key-file=backup.key
+
+  # Merge database secrets from a backup into the current vault
+  secretctl restore backup.enc --logical --keys 'db/*' --on-conflict=skip`,
 	Args: cobra.ExactArgs(1),
 	RunE: executeRestore,
 }
@@ -83,7 +108,7 @@ func executeRestore(cmd *cobra.Command, args []string) error {
 	// Get password or key file
 	var password []byte
 	if restoreKeyFile == "" {
-		fmt.Print("Enter backup password (or master password): ")
+		fmt.Print(i18n.T("Enter backup password (or master password): "))
 		pwd, err := term.ReadPassword(int(syscall.Stdin))
 		if err != nil {
 			return fmt.Errorf("failed to read password: %w", err)
@@ -106,12 +131,52 @@ func executeRestore(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Created: %s\n", result.CreatedAt.Format("2006-01-02 15:04:05"))
 		fmt.Printf("  Secrets: %d\n", result.SecretCount)
 		fmt.Printf("  Includes Audit: %v\n", result.IncludesAudit)
+		if result.Hostname != "" {
+			fmt.Printf("  Written by: secretctl %s on %s\n", result.ToolVersion, result.Hostname)
+		}
+		if result.VaultID != "" {
+			fmt.Printf("  Vault ID: %s\n", result.VaultID)
+		}
 		return nil
 	}
 
+	// Warn of a backup from a newer version or of another vault before the
+	// confirmation; the header is authenticated when the backup is opened.
+	// A logical restore merges into the vault, whichever one it is.
+	if header, err := readBackupHeader(backupPath); err == nil {
+		target := vaultPath
+		if restoreLogical {
+			target = ""
+		}
+		for _, warning := range backup.CheckOrigin(header, version, target) {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+	}
+
+	// Compare key by key with an existing vault
+	var current *vault.Vault
+	var vaultPassword []byte
+	if restoreDryRun {
+		if _, err := os.Stat(filepath.Join(vaultPath, "vault.salt")); err == nil {
+			if err := ensureUnlocked(); err != nil {
+				return err
+			}
+			defer v.Lock()
+			current = v
+
+			if vaultPassword, err = promptBackedUpVaultPassword(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if restoreLogical && !restoreDryRun {
+		return executeLogicalRestore(backupPath, password, conflictMode)
+	}
+
 	// Confirmation prompt
 	if !restoreForce && !restoreDryRun {
-		fmt.Print("This will restore the vault from backup. Continue? [y/N]: ")
+		fmt.Print(i18n.T("This will restore the vault from backup. Continue? [y/N]: "))
 		var confirm string
 		_, _ = fmt.Scanln(&confirm)
 		if confirm != "y" && confirm != "Y" {
@@ -129,6 +194,10 @@ func executeRestore(cmd *cobra.Command, args []string) error {
 		WithAudit:  restoreWithAudit,
 		Password:   password,
 		KeyFile:    restoreKeyFile,
+
+		Current:       current,
+		VaultPassword: vaultPassword,
+		ToolVersion:   version,
 	}
 
 	// Perform restore
@@ -148,10 +217,112 @@ func executeRestore(cmd *cobra.Command, args []string) error {
 	if result.AuditRestored {
 		fmt.Printf("  Audit log: restored\n")
 	}
+	if current != nil {
+		printRestoreDiff(result.Diff)
+	}
 
 	return nil
 }
 
+// executeLogicalRestore merges the backup into the existing vault.
+func executeLogicalRestore(backupPath string, password []byte, conflictMode backup.ConflictMode) error {
+	if err := ensureUnlocked(); err != nil {
+		return err
+	}
+	defer v.Lock()
+
+	vaultPassword, err := promptBackedUpVaultPassword()
+	if err != nil {
+		return err
+	}
+
+	if !restoreForce {
+		fmt.Print(i18n.T("This will merge secrets from the backup into the vault. Continue? [y/N]: "))
+		var confirm string
+		_, _ = fmt.Scanln(&confirm)
+		if confirm != "y" && confirm != "Y" {
+			fmt.Println("Restore canceled.")
+			return nil
+		}
+	}
+
+	bar := newProgressPrinter("Restoring")
+	result, err := backup.LogicalRestore(backupPath, v, backup.LogicalRestoreOptions{
+		Password:      password,
+		KeyFile:       restoreKeyFile,
+		VaultPassword: vaultPassword,
+		OnConflict:    conflictMode,
+		Keys:          restoreKeys,
+		Progress:      bar.Update,
+	})
+	bar.Done()
+	if err != nil {
+		if result != nil && result.SecretsRestored > 0 {
+			fmt.Printf("  Secrets restored before the error: %d\n", result.SecretsRestored)
+		}
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	fmt.Printf("Restore complete!\n")
+	fmt.Printf("  Secrets restored: %d\n", result.SecretsRestored)
+	fmt.Printf("  Secrets skipped: %d\n", result.SecretsSkipped)
+	return nil
+}
+
+// readBackupHeader reads the header of the backup at path without
+// verifying it.
+func readBackupHeader(path string) (*backup.Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return backup.ReadHeader(f)
+}
+
+// promptBackedUpVaultPassword asks for the master password of the vault
+// inside the backup when it cannot be the backup password: for key-file
+// backups and with --vault-password. Otherwise it returns nil, meaning the
+// backup password is used.
+func promptBackedUpVaultPassword() ([]byte, error) {
+	if restoreKeyFile == "" && !restoreVaultPass {
+		return nil, nil
+	}
+	fmt.Print(i18n.T("Enter master password of the backed-up vault: "))
+	pwd, err := term.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	fmt.Println()
+	return pwd, nil
+}
+
+// printRestoreDiff prints the per-key comparison of a dry run.
+func printRestoreDiff(diffs []backup.KeyDiff) {
+	counts := make(map[backup.KeyStatus]int)
+	width := len("KEY")
+	for _, d := range diffs {
+		counts[d.Status]++
+		width = max(width, len(d.Key))
+	}
+
+	when := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return tf.Time(*t)
+	}
+	fmt.Println()
+	fmt.Printf("%-*s  %-12s  %-20s  %s\n", width, "KEY", "STATUS", "LOCAL UPDATED", "BACKUP UPDATED")
+	for _, d := range diffs {
+		fmt.Printf("%-*s  %-12s  %-20s  %s\n", width, d.Key, d.Status, when(d.LocalUpdatedAt), when(d.BackupUpdatedAt))
+	}
+	fmt.Println()
+	fmt.Printf("%d new, %d identical, %d local-newer, %d backup-newer, %d local-only\n",
+		counts[backup.KeyNew], counts[backup.KeyIdentical], counts[backup.KeyLocalNewer],
+		counts[backup.KeyBackupNewer], counts[backup.KeyLocalOnly])
+}
+
 func validateRestoreFlags() error {
 	validModes := map[string]bool{"skip": true, "overwrite": true, "error": true}
 	if !validModes[restoreOnConflict] {
@@ -160,6 +331,12 @@ func validateRestoreFlags() error {
 	if restoreDryRun && restoreVerifyOnly {
 		return fmt.Errorf("--dry-run and --verify-only are mutually exclusive")
 	}
+	if len(restoreKeys) > 0 && !restoreLogical {
+		return fmt.Errorf("--keys requires --logical")
+	}
+	if restoreLogical && restoreWithAudit {
+		return fmt.Errorf("--logical cannot restore the audit log")
+	}
 	return nil
 }
 
@@ -2,16 +2,29 @@ package main
 
 import (
 	"bufio"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/generate"
+	"github.com/forest6511/secretctl/pkg/i18n"
+	"github.com/forest6511/secretctl/pkg/logging"
+	"github.com/forest6511/secretctl/pkg/timefmt"
 	"github.com/forest6511/secretctl/pkg/vault"
 
 	"github.com/spf13/cobra"
@@ -23,6 +36,12 @@ var (
 	v         *vault.Vault
 )
 
+// Logging flags (see package logging)
+var (
+	logOptions logging.Options
+	closeLog   = func() error { return nil }
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "secretctl",
 	Short: "secretctl is a simple, AI-ready secrets manager",
@@ -30,15 +49,28 @@ var rootCmd = &cobra.Command{
 	// PersistentPreRunE runs before the root command and all subcommands.
 	// This initializes the Vault object.
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		if err := setupLogging(filepath.Join(home, ".secretctl")); err != nil {
+			return err
+		}
+		if err := setupTimeFormat(filepath.Join(home, ".secretctl")); err != nil {
+			return err
+		}
+		i18n.SetLocale(tf.Locale())
+
 		// Skip for init command since the vault doesn't exist yet
 		if cmd.Use == "init" {
 			return nil
 		}
 
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
+		// In CI, read from a bundle created by 'secretctl ci-token create'
+		if bundle := os.Getenv(ciBundleEnv); bundle != "" {
+			return openCIBundle(bundle)
 		}
+
 		vaultPath = filepath.Join(home, ".secretctl")
 		v = vault.New(vaultPath)
 		return nil
@@ -49,13 +81,33 @@ var rootCmd = &cobra.Command{
 var (
 	setNotes   string
 	setURL     string
+	setOwner   string
+	setTeam    string
 	setTags    string
 	setExpires string
 
+	// Access rules
+	setNotBefore    string
+	setAccessWindow string
+
+	// Generated values
+	setGenerate       string // --generate[=password|passphrase]
+	setGenerateLength int    // --generate-length characters or words
+
+	// Immutable secrets
+	setImmutable  bool
+	setBreakGlass bool
+	setReason     string
+
+	// Delete flags
+	deleteBreakGlass bool
+	deleteReason     string
+
 	// Multi-field support (Phase 2.5b)
-	setFields   []string // --field name=value (can be repeated)
-	setBindings []string // --binding ENV=field (can be repeated)
-	setTemplate string   // --template name
+	setFields     []string // --field name=value (can be repeated)
+	setBindings   []string // --binding ENV=field (can be repeated)
+	setValidators []string // --validator field=name (can be repeated)
+	setTemplate   string   // --template name
 
 	// Folder support (Phase 2c-X2)
 	setFolder   string // --folder path
@@ -64,16 +116,27 @@ var (
 	// Get field support
 	getField      string // --field name (get specific field)
 	getShowFields bool   // --fields (list all fields)
+
+	getAllowExpired bool   // --allow-expired (override block_expired_reads)
+	getMasked       bool   // --masked (preview per mask_policy)
+	getOut          string // --out file (stream the value to a file)
+	getForce        bool   // --force (overwrite the --out file)
 )
 
 // Metadata flags for list command
 var (
 	listTag      string
 	listExpiring string
+	listUnused   string
+	listOwner    string
+	listTeam     string
 
 	// Folder support (Phase 2c-X2)
 	listFolder   string // --folder path
 	listFolderID string // --folder-id UUID
+
+	listPrefix string // --prefix, e.g. "aws/prod/"
+	listTree   bool   // --tree renders the key hierarchy
 )
 
 // Metadata flags for get command
@@ -102,7 +165,62 @@ var (
 	auditPruneForce     bool
 )
 
+// setupLogging configures slog from flags, then SECRETCTL_LOG_* variables,
+// then the vault settings. Logs go to stderr or a file, never stdout.
+func setupLogging(path string) error {
+	explicit := logOptions.Merge(logging.OptionsFromEnv())
+	opts := explicit
+	if settings, err := vault.New(path).Settings(); err == nil {
+		opts = opts.Merge(logging.Options{Level: settings.LogLevel, Format: settings.LogFormat, File: settings.LogFile})
+	}
+	closeFn, err := logging.Setup(opts)
+	if err != nil {
+		// Broken vault settings (e.g. an unwritable log_file) must not
+		// prevent fixing them with `secretctl config set`
+		settingsErr := err
+		if closeFn, err = logging.Setup(explicit); err != nil {
+			return err
+		}
+		slog.Warn("ignoring log settings from vault", "err", settingsErr)
+	}
+	closeLog = closeFn
+	return nil
+}
+
+// timeOptions holds --timezone and --locale; tf formats times for display.
+// The locale also selects the language of prompts and errors (package i18n).
+var (
+	timeOptions timefmt.Options
+	tf          = timefmt.Default()
+)
+
+// setupTimeFormat builds tf from flags, SECRETCTL_TIMEZONE/SECRETCTL_LOCALE
+// and the vault settings, in that order of precedence.
+func setupTimeFormat(path string) error {
+	explicit := timeOptions.Merge(timefmt.OptionsFromEnv())
+	opts := explicit
+	if settings, err := vault.New(path).Settings(); err == nil {
+		opts = opts.Merge(timefmt.Options{Timezone: settings.Timezone, Locale: settings.Locale})
+	}
+	f, err := timefmt.New(opts)
+	if err != nil {
+		settingsErr := err
+		if f, err = timefmt.New(explicit); err != nil {
+			return err
+		}
+		slog.Warn("ignoring time settings from vault", "err", settingsErr)
+	}
+	tf = f
+	return nil
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&timeOptions.Timezone, "timezone", "", "Time zone for displayed times, e.g. Asia/Tokyo or UTC (default: system)")
+	rootCmd.PersistentFlags().StringVar(&timeOptions.Locale, "locale", "", "Language of messages and relative times: en or ja (default: from LANG)")
+	rootCmd.PersistentFlags().StringVar(&logOptions.Level, "log-level", "", "Minimum log level: debug, info, warn or error (default info)")
+	rootCmd.PersistentFlags().StringVar(&logOptions.Format, "log-format", "", "Log format: text or json (default text)")
+	rootCmd.PersistentFlags().StringVar(&logOptions.File, "log-file", "", "Append logs to this file instead of stderr")
+
 	// Add subcommands to rootCmd
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(setCmd)
@@ -113,33 +231,65 @@ func init() {
 	rootCmd.AddCommand(passwordCmd)
 	rootCmd.AddCommand(folderCmd)
 
+	// Disaster recovery flag for init command
+	initCmd.Flags().StringVar(&initFromDEKFile, "from-dek-file", "", "Rebuild the vault around an escrowed DEK (recovery kit, hex or base64 file)")
+	initCmd.Flags().BoolVar(&initMinimal, "minimal", false, "Only create the vault; skip the setup wizard")
+	initCmd.Flags().StringVar(&initCipher, "cipher", vault.CipherAES256GCM, "Cipher suite for the vault's data: "+strings.Join(crypto.Ciphers(), " or "))
+
 	// Add metadata flags to set command
 	setCmd.Flags().StringVar(&setNotes, "notes", "", "Add notes to the secret")
 	setCmd.Flags().StringVar(&setURL, "url", "", "Add URL to the secret")
+	setCmd.Flags().StringVar(&setOwner, "owner", "", "Person responsible for rotating the secret (e.g., alice)")
+	setCmd.Flags().StringVar(&setTeam, "team", "", "Team responsible for the secret (e.g., platform)")
 	setCmd.Flags().StringVar(&setTags, "tags", "", "Comma-separated tags (e.g., dev,api)")
 	setCmd.Flags().StringVar(&setExpires, "expires", "", "Expiration duration (e.g., 30d, 1y)")
+	setCmd.Flags().StringVar(&setNotBefore, "not-before", "", "Not readable before this time (duration from now like 2d, or RFC3339/YYYY-MM-DD)")
+	setCmd.Flags().BoolVar(&setImmutable, "immutable", false, "Make the secret write-once (changes require --break-glass)")
+	setCmd.Flags().BoolVar(&setBreakGlass, "break-glass", false, "Overwrite an immutable or frozen secret (audited, requires --reason)")
+	setCmd.Flags().StringVar(&setReason, "reason", "", "Reason recorded in the audit log for --break-glass")
+	setCmd.MarkFlagsRequiredTogether("break-glass", "reason")
+	setCmd.Flags().StringVar(&setAccessWindow, "access-window", "", "Only readable during this window (e.g., \"mon-fri 09:00-17:00 Europe/Berlin\")")
+	setCmd.Flags().StringVar(&setGenerate, "generate", "", "Store a generated value instead of reading one: password (default) or passphrase")
+	setCmd.Flags().Lookup("generate").NoOptDefVal = "password"
+	setCmd.Flags().IntVar(&setGenerateLength, "generate-length", 0, fmt.Sprintf("Characters of a generated password (default %d) or words of a passphrase (default %d)", generate.DefaultLength, generate.DefaultWords))
 
 	// Multi-field flags for set command (Phase 2.5b)
 	setCmd.Flags().StringArrayVar(&setFields, "field", nil, "Set field value (name=value, can be repeated)")
 	setCmd.Flags().StringArrayVar(&setBindings, "binding", nil, "Set env binding (ENV_VAR=field, can be repeated)")
-	setCmd.Flags().StringVar(&setTemplate, "template", "", "Use template (login, database, api, ssh)")
+	setCmd.Flags().StringArrayVar(&setValidators, "validator", nil, "Check a field's format on write (field=validator, can be repeated; validators: "+strings.Join(vault.Validators(), ", ")+")")
+	setCmd.Flags().StringVar(&setTemplate, "template", "", "Use template (login, database, api, ssh, aws)")
 
 	// Folder flags for set command (Phase 2c-X2)
 	setCmd.Flags().StringVar(&setFolder, "folder", "", "Folder path (e.g., Work/APIs)")
 	setCmd.Flags().StringVar(&setFolderID, "folder-id", "", "Folder ID (UUID)")
 
+	// Break-glass flags for delete command
+	deleteCmd.Flags().BoolVar(&deleteBreakGlass, "break-glass", false, "Delete an immutable or frozen secret (audited, requires --reason)")
+	deleteCmd.Flags().StringVar(&deleteReason, "reason", "", "Reason recorded in the audit log for --break-glass")
+	deleteCmd.MarkFlagsRequiredTogether("break-glass", "reason")
+
 	// Add metadata flags to list command
 	listCmd.Flags().StringVar(&listTag, "tag", "", "Filter by tag")
 	listCmd.Flags().StringVar(&listExpiring, "expiring", "", "Show secrets expiring within duration (e.g., 7d)")
+	listCmd.Flags().StringVar(&listUnused, "unused", "", "Show secrets not read or updated for duration (e.g., 90d)")
+	listCmd.Flags().StringVar(&listOwner, "owner", "", "Filter by owner")
+	listCmd.Flags().StringVar(&listTeam, "team", "", "Filter by team")
 
 	// Folder flags for list command (Phase 2c-X2)
 	listCmd.Flags().StringVar(&listFolder, "folder", "", "Filter by folder path")
 	listCmd.Flags().StringVar(&listFolderID, "folder-id", "", "Filter by folder ID (UUID)")
 
+	listCmd.Flags().StringVar(&listPrefix, "prefix", "", "Only keys starting with this prefix (e.g., aws/prod/)")
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "Show the key hierarchy ('/'-separated) as a tree")
+
 	// Add metadata flags to get command
 	getCmd.Flags().BoolVar(&getShowMetadata, "show-metadata", false, "Show metadata with the secret")
 	getCmd.Flags().StringVar(&getField, "field", "", "Get specific field value")
 	getCmd.Flags().BoolVar(&getShowFields, "fields", false, "List all field names")
+	getCmd.Flags().BoolVar(&getAllowExpired, "allow-expired", false, "Return the secret even if it has expired")
+	getCmd.Flags().BoolVar(&getMasked, "masked", false, "Show a masked preview of sensitive values (see 'config get mask_policy')")
+	getCmd.Flags().StringVar(&getOut, "out", "", "Write the value (or --field) to a new file with mode 0600 instead of stdout")
+	getCmd.Flags().BoolVar(&getForce, "force", false, "With --out, overwrite an existing file")
 
 	// Add audit subcommands
 	auditCmd.AddCommand(auditListCmd)
@@ -166,10 +316,43 @@ func init() {
 	auditPruneCmd.Flags().BoolVarP(&auditPruneForce, "force", "f", false, "Skip confirmation prompt")
 }
 
+// initFromDEKFile is the escrowed DEK used to rebuild a vault (init --from-dek-file)
+var initFromDEKFile string
+
+// initMinimal skips the setup wizard (init --minimal)
+var initMinimal bool
+
+// initCipher is the cipher suite of a new vault (init --cipher)
+var initCipher string
+
 // initCmd initializes a new vault
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initializes a new secret vault",
+	Long: `Initializes a new secret vault.
+
+After the master password is set, a setup wizard offers to:
+  - calibrate the key derivation cost for this machine
+  - write a recovery kit holding the data encryption key
+  - add starter secrets from the built-in templates
+  - set a backup directory for 'secretctl backup'
+  - create a deny-by-default MCP policy (mcp-policy.yaml)
+Every step is optional. Use --minimal to skip the wizard.
+
+With --from-dek-file, the vault is rebuilt around an escrowed data
+encryption key instead of a new one. Use this when vault.salt or
+vault.meta were lost but vault.db and the DEK survived: the existing
+secrets become readable again under the new master password.
+
+The vault's data is encrypted with AES-256-GCM unless --cipher selects
+xchacha20-poly1305, which is faster on processors without AES
+instructions. The cipher suite cannot be changed later; backups keep it.
+
+Examples:
+  secretctl init
+  secretctl init --minimal
+  secretctl init --cipher xchacha20-poly1305
+  secretctl init --from-dek-file /media/usb/secretctl-recovery-kit.txt`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Set vault path
 		home, err := os.UserHomeDir()
@@ -178,10 +361,27 @@ var initCmd = &cobra.Command{
 		}
 		vaultPath = filepath.Join(home, ".secretctl")
 
-		fmt.Println("Initializing new vault...")
+		if !slices.Contains(crypto.Ciphers(), initCipher) {
+			return fmt.Errorf("invalid --cipher %q (use %s)", initCipher, strings.Join(crypto.Ciphers(), " or "))
+		}
+		if initFromDEKFile != "" && cmd.Flags().Changed("cipher") {
+			return errors.New("--cipher cannot be used with --from-dek-file; a rebuilt vault keeps its cipher suite")
+		}
+
+		var dek []byte
+		if initFromDEKFile != "" {
+			dek, err = readDEKFile(initFromDEKFile)
+			if err != nil {
+				return err
+			}
+			defer crypto.SecureWipe(dek)
+			fmt.Println("Rebuilding vault from escrowed DEK...")
+		} else {
+			fmt.Println("Initializing new vault...")
+		}
 
 		// 1. Prompt for master password
-		fmt.Print("Enter master password: ")
+		fmt.Print(i18n.T("Enter master password: "))
 		password1, err := term.ReadPassword(int(syscall.Stdin))
 		if err != nil {
 			return fmt.Errorf("failed to read password: %w", err)
@@ -189,7 +389,7 @@ var initCmd = &cobra.Command{
 		fmt.Println()
 
 		// 2. Confirm password
-		fmt.Print("Confirm master password: ")
+		fmt.Print(i18n.T("Confirm master password: "))
 		password2, err := term.ReadPassword(int(syscall.Stdin))
 		if err != nil {
 			return fmt.Errorf("failed to read password: %w", err)
@@ -198,7 +398,7 @@ var initCmd = &cobra.Command{
 
 		// 3. Check passwords match
 		if string(password1) != string(password2) {
-			return fmt.Errorf("passwords do not match")
+			return errors.New(i18n.T("passwords do not match"))
 		}
 
 		// 4. Validate password strength per requirements-ja.md §2.3
@@ -216,15 +416,63 @@ var initCmd = &cobra.Command{
 
 		// 5. Initialize vault
 		v = vault.New(vaultPath)
-		if err := v.Init(string(password1)); err != nil {
+		if dek != nil {
+			if err := v.InitWithDEK(dek, string(password1)); err != nil {
+				if errors.Is(err, vault.ErrDEKMismatch) {
+					return fmt.Errorf("failed to rebuild vault: the DEK in %s does not match %s", initFromDEKFile, filepath.Join(vaultPath, vault.DBFileName))
+				}
+				return fmt.Errorf("failed to rebuild vault: %w", err)
+			}
+			fmt.Printf("Vault rebuilt successfully at %s\n", vaultPath)
+			return nil
+		}
+		params := crypto.DefaultKDFParams()
+		if !initMinimal {
+			fmt.Println()
+			if params, err = promptKDFParams(); err != nil {
+				return err
+			}
+		}
+		if err := v.InitWithOptions(string(password1), vault.InitOptions{KDF: params, Cipher: initCipher}); err != nil {
 			return fmt.Errorf("failed to initialize vault: %w", err)
 		}
 
 		fmt.Printf("Vault initialized successfully at %s\n", vaultPath)
-		return nil
+		if initMinimal {
+			return nil
+		}
+		return runInitWizard(string(password1), home)
 	},
 }
 
+// readDEKFile reads an escrowed DEK stored as hex, base64 or raw bytes.
+// Lines starting with # are ignored, so a recovery kit can be used as is.
+func readDEKFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DEK file: %w", err)
+	}
+	defer crypto.SecureWipe(data)
+
+	if len(data) == vault.DEKLength {
+		return append([]byte(nil), data...), nil
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if dek, err := hex.DecodeString(text); err == nil && len(dek) == vault.DEKLength {
+		return dek, nil
+	}
+	if dek, err := base64.StdEncoding.DecodeString(text); err == nil && len(dek) == vault.DEKLength {
+		return dek, nil
+	}
+	return nil, fmt.Errorf("DEK file must contain a %d-byte key as hex, base64 or raw bytes", vault.DEKLength)
+}
+
 // setCmd sets a secret value
 var setCmd = &cobra.Command{
 	Use:   "set [key]",
@@ -239,11 +487,41 @@ var setCmd = &cobra.Command{
    secretctl set mykey --field username=admin --field password=secret
    secretctl set mykey --template database
    
-Available templates: login, database, api, ssh`,
+Available templates: login, database, api, ssh, aws
+
+Validators catch malformed values at write time (templates set them for
+their fields):
+   secretctl set aws/ci --field access_key_id=AKIA... --validator access_key_id=aws_access_key_id
+   secretctl set app/tenant --validator value=uuid
+
+Immutable secrets cannot be changed or deleted without --break-glass:
+   secretctl set ca/root-key --immutable
+   secretctl set ca/root-key --break-glass --reason "key compromised, INC-1234"
+
+Access rules restrict when get/run can read the secret:
+   secretctl set deploy/token --not-before 2026-11-01
+   secretctl set deploy/token --access-window "mon-fri 09:00-17:00 Europe/Berlin"
+
+Generated values are stored without being shown (see 'secretctl generate'):
+   secretctl set db/prod --generate
+   secretctl set wifi/home --generate=passphrase --generate-length 8`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 
+		// Generate before asking for the master password, so bad flags fail first
+		var generated string
+		if setGenerate != "" {
+			if setTemplate != "" || len(setFields) > 0 {
+				return &exitError{code: ExitUsage, err: errors.New("--generate cannot be combined with --field or --template")}
+			}
+			value, err := generateSecretValue(setGenerate, setGenerateLength)
+			if err != nil {
+				return &exitError{code: ExitUsage, err: err}
+			}
+			generated = value
+		}
+
 		// 1. Unlock vault
 		if err := ensureUnlocked(); err != nil {
 			return err
@@ -262,9 +540,12 @@ Available templates: login, database, api, ssh`,
 			}
 			entry.Fields = fields
 			entry.Bindings = bindings
+		} else if generated != "" {
+			entry.Value = []byte(generated)
+			fmt.Fprintf(os.Stderr, "Generated a %s %s\n", vault.RatePassword(generated), setGenerate)
 		} else {
 			// Legacy single-value mode
-			fmt.Print("Enter secret value (Ctrl+D to finish): ")
+			fmt.Print(i18n.T("Enter secret value (Ctrl+D to finish): "))
 			valueBytes, err := io.ReadAll(os.Stdin)
 			if err != nil {
 				return fmt.Errorf("failed to read secret value: %w", err)
@@ -279,13 +560,21 @@ Available templates: login, database, api, ssh`,
 				value = value[:len(value)-1]
 			}
 			entry.Value = value
+			if len(setValidators) > 0 {
+				entry.Fields = vault.ConvertSingleValueToFields(value)
+				if err := parseValidatorFlags(entry.Fields); err != nil {
+					return err
+				}
+			}
 		}
 
 		// Add metadata if any flags are set
-		if setNotes != "" || setURL != "" {
+		if setNotes != "" || setURL != "" || setOwner != "" || setTeam != "" {
 			entry.Metadata = &vault.SecretMetadata{
 				Notes: setNotes,
 				URL:   setURL,
+				Owner: setOwner,
+				Team:  setTeam,
 			}
 		}
 
@@ -304,6 +593,22 @@ Available templates: login, database, api, ssh`,
 			entry.ExpiresAt = &expiresAt
 		}
 
+		// Add access rules (plaintext)
+		if setNotBefore != "" {
+			notBefore, err := parseNotBefore(setNotBefore)
+			if err != nil {
+				return fmt.Errorf("invalid not-before format: %w", err)
+			}
+			entry.NotBefore = &notBefore
+		}
+		if setAccessWindow != "" {
+			window, err := vault.ParseAccessWindow(setAccessWindow)
+			if err != nil {
+				return err
+			}
+			entry.AccessWindow = window
+		}
+
 		// Add folder (Phase 2c-X2)
 		folderID, err := resolveFolderFromFlags()
 		if err != nil {
@@ -312,7 +617,26 @@ Available templates: login, database, api, ssh`,
 		entry.FolderID = folderID
 
 		// 3. Save secret
-		if err := v.SetSecret(key, entry); err != nil {
+		entry.Immutable = setImmutable
+		if setBreakGlass {
+			// Break-glass keeps an immutable secret immutable unless --immutable=false is given
+			if !cmd.Flags().Changed("immutable") {
+				if existing, err := v.InspectSecret(key); err == nil {
+					entry.Immutable = existing.Immutable
+				}
+			}
+			fmt.Fprintf(os.Stderr, "BREAK-GLASS: overwriting '%s' (reason: %s)\n", key, setReason)
+			err = v.SetSecretBreakGlass(key, entry, setReason)
+		} else {
+			err = v.SetSecret(key, entry)
+		}
+		if err != nil {
+			if errors.Is(err, vault.ErrSecretImmutable) {
+				return &exitError{code: ExitDenied, err: fmt.Errorf("secret '%s' is immutable; use --break-glass --reason <why> to change it", key)}
+			}
+			if errors.Is(err, vault.ErrSecretFrozen) {
+				return &exitError{code: ExitDenied, err: fmt.Errorf("secret '%s' is frozen (see 'secretctl freeze list'); use --break-glass --reason <why> to change it", key)}
+			}
 			return fmt.Errorf("failed to set secret: %w", err)
 		}
 
@@ -325,6 +649,19 @@ Available templates: login, database, api, ssh`,
 	},
 }
 
+// generateSecretValue generates a value for set --generate: a password of
+// length characters or a passphrase of length words (0 for the default).
+func generateSecretValue(kind string, length int) (string, error) {
+	switch kind {
+	case "password":
+		return generate.Password(generate.Options{Length: length})
+	case "passphrase":
+		return generate.Passphrase(generate.PassphraseOptions{Words: length})
+	default:
+		return "", fmt.Errorf("invalid --generate value %q: use password or passphrase", kind)
+	}
+}
+
 // buildFieldsFromFlags builds Fields and Bindings from CLI flags.
 func buildFieldsFromFlags() (fields map[string]vault.Field, bindings map[string]string, err error) {
 	fields = make(map[string]vault.Field)
@@ -342,6 +679,11 @@ func buildFieldsFromFlags() (fields map[string]vault.Field, bindings map[string]
 		return nil, nil, err
 	}
 
+	// Add validators from --validator flags
+	if err := parseValidatorFlags(fields); err != nil {
+		return nil, nil, err
+	}
+
 	// Add bindings from --binding flags
 	if err := parseBindingFlags(fields, bindings); err != nil {
 		return nil, nil, err
@@ -379,6 +721,7 @@ func promptTemplateFields(fields map[string]vault.Field) error {
 				Sensitive: tf.Sensitive,
 				Kind:      tf.Kind,
 				InputType: tf.InputType,
+				Validator: tf.Validator,
 			}
 		}
 	}
@@ -436,6 +779,40 @@ func readLine() (string, error) {
 	return strings.TrimSuffix(value, "\r"), nil
 }
 
+// printMasked prints a preview of entry with sensitive values masked per
+// the key's mask policy. Non-sensitive fields are shown in full.
+func printMasked(key string, entry *vault.SecretEntry) error {
+	policy := v.MaskPolicyFor(key)
+	if len(entry.Fields) == 0 {
+		fmt.Println(policy.Mask(entry.Value))
+		return nil
+	}
+
+	preview := func(field vault.Field) string {
+		if field.Sensitive {
+			return policy.Mask([]byte(field.Value))
+		}
+		return field.Value
+	}
+	if getField != "" {
+		_, field, err := vault.ResolveFieldName(entry.Fields, getField)
+		if err != nil {
+			return fmt.Errorf("field %q not found", getField)
+		}
+		fmt.Println(preview(*field))
+		return nil
+	}
+	names := make([]string, 0, len(entry.Fields))
+	for name := range entry.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s: %s\n", name, preview(entry.Fields[name]))
+	}
+	return nil
+}
+
 // parseFieldFlags parses --field flags into fields map
 func parseFieldFlags(fields map[string]vault.Field) error {
 	for _, f := range setFields {
@@ -444,10 +821,31 @@ func parseFieldFlags(fields map[string]vault.Field) error {
 			return fmt.Errorf("invalid field format %q (expected name=value)", f)
 		}
 		name, value := parts[0], parts[1]
-		fields[name] = vault.Field{
-			Value:     value,
-			Sensitive: true, // Default to sensitive
+		field, exists := fields[name]
+		if !exists {
+			field.Sensitive = true // Default to sensitive
+		}
+		// Overriding a template field keeps its kind and validator
+		field.Value = value
+		fields[name] = field
+	}
+	return nil
+}
+
+// parseValidatorFlags applies --validator flags to fields
+func parseValidatorFlags(fields map[string]vault.Field) error {
+	for _, f := range setValidators {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid validator format %q (expected field=validator)", f)
+		}
+		name, validator := parts[0], parts[1]
+		field, exists := fields[name]
+		if !exists {
+			return fmt.Errorf("validator %q references non-existent field %q", validator, name)
 		}
+		field.Validator = validator
+		fields[name] = field
 	}
 	return nil
 }
@@ -491,7 +889,11 @@ var getCmd = &cobra.Command{
 
 3. List fields mode:
    secretctl get mykey --fields
-   # Lists all field names`,
+   # Lists all field names
+
+4. File mode:
+   secretctl get tls/api --field cert --out api.crt
+   # Streams the value to a new file (mode 0600) without a trailing newline`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
@@ -502,13 +904,28 @@ var getCmd = &cobra.Command{
 		}
 		defer v.Lock()
 
-		// 2. Get secret
-		entry, err := v.GetSecret(key)
+		if getOut != "" {
+			return writeSecretToFile(key)
+		}
+
+		// 2. Get secret (--allow-expired overrides block_expired_reads)
+		var entry *vault.SecretEntry
+		var err error
+		if getAllowExpired {
+			entry, err = v.GetSecretAllowExpired(key)
+		} else {
+			entry, err = v.GetSecret(key)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to get secret: %w", err)
+			return getSecretError(key, err)
 		}
+		warnRedirected(entry)
 
 		// 3. Handle different output modes
+		if getMasked {
+			return printMasked(key, entry)
+		}
+
 		if getShowFields {
 			// List all field names
 			if len(entry.Fields) == 0 {
@@ -562,6 +979,12 @@ var getCmd = &cobra.Command{
 				// Single value
 				fmt.Printf("Value: %s\n", string(entry.Value))
 			}
+			if len(entry.References) > 0 {
+				fmt.Println("References:")
+				for _, name := range slices.Sorted(maps.Keys(entry.References)) {
+					fmt.Printf("  %s -> %s\n", name, entry.References[name])
+				}
+			}
 
 			// Print encrypted metadata if present
 			if entry.Metadata != nil {
@@ -571,16 +994,38 @@ var getCmd = &cobra.Command{
 				if entry.Metadata.URL != "" {
 					fmt.Printf("URL: %s\n", entry.Metadata.URL)
 				}
+				if entry.Metadata.Owner != "" {
+					fmt.Printf("Owner: %s\n", entry.Metadata.Owner)
+				}
+				if entry.Metadata.Team != "" {
+					fmt.Printf("Team: %s\n", entry.Metadata.Team)
+				}
 			}
 			// Print plaintext metadata
 			if len(entry.Tags) > 0 {
 				fmt.Printf("Tags: %s\n", strings.Join(entry.Tags, ", "))
 			}
 			if entry.ExpiresAt != nil {
-				fmt.Printf("Expires: %s\n", entry.ExpiresAt.Format(time.RFC3339))
+				fmt.Printf("Expires: %s (%s)\n", tf.Time(*entry.ExpiresAt), tf.Expiry(*entry.ExpiresAt, time.Now()))
+			}
+			if entry.NotBefore != nil {
+				fmt.Printf("Not before: %s\n", tf.Time(*entry.NotBefore))
+			}
+			if entry.AccessWindow != nil {
+				fmt.Printf("Access window: %s\n", entry.AccessWindow)
+			}
+			if entry.Immutable {
+				fmt.Println("Immutable: yes")
+			}
+			fmt.Printf("Created: %s\n", tf.Time(entry.CreatedAt))
+			fmt.Printf("Updated: %s\n", tf.Time(entry.UpdatedAt))
+			// Commands the secret was injected into by run, exec or MCP
+			if entry.Metadata != nil && len(entry.Metadata.UsedBy) > 0 {
+				fmt.Println("Used by:")
+				for _, u := range entry.Metadata.UsedBy {
+					fmt.Printf("  %s (%s, last %s)\n", u.Command, u.Source, tf.Time(u.LastUsed))
+				}
 			}
-			fmt.Printf("Created: %s\n", entry.CreatedAt.Format(time.RFC3339))
-			fmt.Printf("Updated: %s\n", entry.UpdatedAt.Format(time.RFC3339))
 		} else {
 			// Default: output value (or default field for multi-field)
 			if len(entry.Fields) > 0 && vault.IsSingleFieldSecret(entry.Fields) {
@@ -600,10 +1045,75 @@ var getCmd = &cobra.Command{
 	},
 }
 
+// getSecretError maps a read error of get to its message and exit code.
+func getSecretError(key string, err error) error {
+	if errors.Is(err, vault.ErrSecretExpired) {
+		return &exitError{code: ExitExpired, err: fmt.Errorf("secret '%s' has expired (use --allow-expired to read it anyway)", key)}
+	}
+	if errors.Is(err, vault.ErrSecretNotYetValid) || errors.Is(err, vault.ErrOutsideAccessWindow) {
+		return fmt.Errorf("secret '%s' cannot be read now: %w", key, err)
+	}
+	return fmt.Errorf("failed to get secret: %w", err)
+}
+
+// writeSecretToFile streams the value selected by get --field to --out.
+func writeSecretToFile(key string) error {
+	var r io.ReadCloser
+	var err error
+	if getAllowExpired {
+		r, err = v.OpenSecretReaderAllowExpired(key, getField)
+	} else {
+		r, err = v.OpenSecretReader(key, getField)
+	}
+	if errors.Is(err, vault.ErrFieldRequired) {
+		return errors.New("multi-field secret; use --field <name> to choose the field to write")
+	}
+	if err != nil {
+		return getSecretError(key, err)
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if getForce {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(getOut, flags, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return &exitError{code: ExitConflict, err: fmt.Errorf("file already exists: %s (use --force to overwrite)", getOut)}
+		}
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote '%s' to %s\n", key, getOut)
+	return nil
+}
+
 // listCmd lists all secret keys
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Lists all secret keys",
+	Long: `Lists secret keys, optionally filtered.
+
+Keys can be organized in a hierarchy with '/', such as 'aws/prod/DB_URL'.
+--prefix lists one branch of it ('aws/prod/'), and --tree renders the keys
+as a tree instead of one per line.
+
+--unused lists the secrets whose values nobody has read (get, run, MCP,
+the desktop app) or updated for the given time, least recently used
+first, to find secrets that can be retired. Listing and inspecting
+metadata does not count as a read.
+
+Examples:
+  secretctl list --prefix aws/prod/
+  secretctl list --tree
+  secretctl list --unused 90d`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 1. Unlock vault
 		if err := ensureUnlocked(); err != nil {
@@ -634,6 +1144,12 @@ var listCmd = &cobra.Command{
 			if err != nil {
 				return fmt.Errorf("failed to list secrets in folder: %w", err)
 			}
+		} else if listOwner != "" || listTeam != "" {
+			// Filter by owner and team
+			entries, err = v.ListSecretsByOwner(listOwner, listTeam)
+			if err != nil {
+				return fmt.Errorf("failed to list secrets: %w", err)
+			}
 		} else if listTag != "" {
 			// Filter by tag
 			entries, err = v.ListSecretsByTag(listTag)
@@ -650,42 +1166,96 @@ var listCmd = &cobra.Command{
 			if err != nil {
 				return fmt.Errorf("failed to list secrets: %w", err)
 			}
+		} else if listUnused != "" {
+			// Filter by last access
+			duration, parseErr := parseDuration(listUnused)
+			if parseErr != nil {
+				return fmt.Errorf("invalid unused format: %w", parseErr)
+			}
+			entries, err = v.ListStaleSecrets(duration)
+			if err != nil {
+				return fmt.Errorf("failed to list secrets: %w", err)
+			}
 		} else {
-			// No filter - use simple list
-			keys, listErr := v.ListSecrets()
+			// No metadata filter - use simple list
+			var keys []string
+			var listErr error
+			if listPrefix != "" {
+				keys, listErr = v.ListSecretsByPrefix(listPrefix)
+			} else {
+				keys, listErr = v.ListSecrets()
+			}
 			if listErr != nil {
 				return fmt.Errorf("failed to list secrets: %w", listErr)
 			}
 
 			// 3. Display key list
 			if len(keys) == 0 {
-				fmt.Println("No secrets stored")
+				if listPrefix != "" {
+					fmt.Println("No secrets found")
+				} else {
+					fmt.Println("No secrets stored")
+				}
 				return nil
 			}
 
+			if listTree {
+				sort.Strings(keys)
+				printKeyTree(os.Stdout, keys)
+				return nil
+			}
 			for _, key := range keys {
 				fmt.Println(key)
 			}
 			return nil
 		}
 
+		if listPrefix != "" {
+			entries = slices.DeleteFunc(entries, func(e *vault.SecretEntry) bool {
+				return !strings.HasPrefix(e.Key, listPrefix)
+			})
+		}
+
 		// 3. Display filtered secrets with metadata
 		if len(entries) == 0 {
 			fmt.Println("No secrets found")
 			return nil
 		}
 
+		if listTree {
+			keys := make([]string, len(entries))
+			for i, entry := range entries {
+				keys[i] = entry.Key
+			}
+			sort.Strings(keys)
+			printKeyTree(os.Stdout, keys)
+			return nil
+		}
+
+		now := time.Now()
 		for _, entry := range entries {
 			line := entry.Key
 			if len(entry.Tags) > 0 {
 				line += fmt.Sprintf(" [%s]", strings.Join(entry.Tags, ","))
 			}
 			if entry.ExpiresAt != nil {
-				line += fmt.Sprintf(" (expires: %s)", entry.ExpiresAt.Format("2006-01-02"))
+				line += fmt.Sprintf(" (expires: %s, %s)", tf.Date(*entry.ExpiresAt), tf.Relative(*entry.ExpiresAt, now))
+			}
+			if entry.Certificate != nil {
+				line += fmt.Sprintf(" (cert expires: %s, %s)", tf.Date(entry.Certificate.NotAfter), tf.Relative(entry.Certificate.NotAfter, now))
 			}
 			if entry.FolderID != nil {
 				line += fmt.Sprintf(" {%s}", formatFolderPath(entry.FolderID))
 			}
+			if owner := formatOwner(entry.Metadata); owner != "" {
+				line += fmt.Sprintf(" (owner: %s)", owner)
+			}
+			if entry.Immutable {
+				line += " (immutable)"
+			}
+			if listUnused != "" {
+				line += " " + formatLastAccess(entry, now)
+			}
 			fmt.Println(line)
 		}
 		return nil
@@ -696,7 +1266,10 @@ var listCmd = &cobra.Command{
 var deleteCmd = &cobra.Command{
 	Use:   "delete [key]",
 	Short: "Deletes a secret",
-	Args:  cobra.ExactArgs(1),
+	Long: `Deletes a secret by moving it to the trash. Deleted secrets are hidden
+from get, list and run, and can be restored with 'secretctl trash restore'
+until they are purged with 'secretctl trash purge'.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 
@@ -707,23 +1280,60 @@ var deleteCmd = &cobra.Command{
 		defer v.Lock()
 
 		// 2. Delete secret
-		if err := v.DeleteSecret(key); err != nil {
+		var err error
+		if deleteBreakGlass {
+			fmt.Fprintf(os.Stderr, "BREAK-GLASS: deleting '%s' (reason: %s)\n", key, deleteReason)
+			err = v.DeleteSecretBreakGlass(key, deleteReason)
+		} else {
+			err = v.DeleteSecret(key)
+		}
+		if err != nil {
+			if errors.Is(err, vault.ErrSecretImmutable) {
+				return &exitError{code: ExitDenied, err: fmt.Errorf("secret '%s' is immutable; use --break-glass --reason <why> to delete it", key)}
+			}
+			if errors.Is(err, vault.ErrSecretFrozen) {
+				return &exitError{code: ExitDenied, err: fmt.Errorf("secret '%s' is frozen (see 'secretctl freeze list'); use --break-glass --reason <why> to delete it", key)}
+			}
 			return fmt.Errorf("failed to delete secret: %w", err)
 		}
 
-		fmt.Printf("Secret '%s' deleted successfully\n", key)
+		fmt.Printf("Secret '%s' moved to the trash (undo with 'secretctl trash restore %s')\n", key, key)
 		return nil
 	},
 }
 
+// formatLastAccess describes when a secret listed by --unused was last
+// read, or updated if it was never read.
+func formatLastAccess(entry *vault.SecretEntry, now time.Time) string {
+	if entry.LastAccessedAt == nil {
+		return fmt.Sprintf("(never read, updated %s)", tf.Relative(entry.UpdatedAt, now))
+	}
+	return fmt.Sprintf("(last read %s, %d reads)", tf.Relative(*entry.LastAccessedAt, now), entry.AccessCount)
+}
+
+// formatOwner formats a secret's owner and team as "alice/platform".
+func formatOwner(meta *vault.SecretMetadata) string {
+	if meta == nil {
+		return ""
+	}
+	switch {
+	case meta.Owner != "" && meta.Team != "":
+		return meta.Owner + "/" + meta.Team
+	case meta.Owner != "":
+		return meta.Owner
+	default:
+		return meta.Team
+	}
+}
+
 // ensureUnlocked ensures the vault is unlocked.
 // If locked, prompts for password and attempts to unlock.
 func ensureUnlocked() error {
 	if v.IsLocked() {
-		fmt.Print("Enter master password: ")
+		fmt.Print(i18n.T("Enter master password: "))
 		passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
 		if err != nil {
-			return fmt.Errorf("failed to read password: %w", err)
+			return &exitError{code: ExitLocked, err: fmt.Errorf("failed to read password: %w", err)}
 		}
 		fmt.Println()
 
@@ -775,7 +1385,11 @@ var auditListCmd = &cobra.Command{
 		// 4. Display events
 		for _, event := range events {
 			// Format: TIMESTAMP OPERATION RESULT [KEY]
-			line := fmt.Sprintf("%s %s %s", event.Timestamp, event.Operation, event.Result)
+			timestamp := event.Timestamp
+			if ts, err := time.Parse(time.RFC3339Nano, event.Timestamp); err == nil {
+				timestamp = tf.Time(ts)
+			}
+			line := fmt.Sprintf("%s %s %s", timestamp, event.Operation, event.Result)
 			if event.Key != "" {
 				// Show truncated key hash
 				keyDisplay := event.Key
@@ -787,6 +1401,12 @@ var auditListCmd = &cobra.Command{
 			if event.Error != nil {
 				line += fmt.Sprintf(" error:%s", event.Error.Code)
 			}
+			if event.Host != nil {
+				line += fmt.Sprintf(" host:%s@%s", event.Host.User, event.Host.Hostname)
+				if event.Host.ParentProcess != "" {
+					line += fmt.Sprintf(" via:%s", event.Host.ParentProcess)
+				}
+			}
 			fmt.Println(line)
 		}
 
@@ -963,7 +1583,7 @@ var auditPruneCmd = &cobra.Command{
 		// 5. Confirmation prompt (unless --force)
 		if !auditPruneForce {
 			fmt.Printf("This will delete %d audit log entries older than %s.\n", count, auditPruneOlderThan)
-			fmt.Print("Are you sure? [y/N]: ")
+			fmt.Print(i18n.T("Are you sure? [y/N]: "))
 			var response string
 			if _, err := fmt.Scanln(&response); err != nil {
 				// Treat read error as "no"
@@ -987,7 +1607,10 @@ var auditPruneCmd = &cobra.Command{
 	},
 }
 
-// parseDuration parses a duration string like "30d", "1y", "24h"
+// parseDuration parses a duration string like "30d", "1y", "24h".
+// d, w, m (30 days) and y (365 days) take a whole number; anything else,
+// such as "90s", "1.5h" or "1h30m", is parsed by time.ParseDuration.
+// Negative and overflowing durations are rejected.
 func parseDuration(s string) (time.Duration, error) {
 	if len(s) < 2 {
 		return 0, fmt.Errorf("duration too short: %s", s)
@@ -996,24 +1619,60 @@ func parseDuration(s string) (time.Duration, error) {
 	unit := s[len(s)-1]
 	valueStr := s[:len(s)-1]
 
-	var value int
-	if _, err := fmt.Sscanf(valueStr, "%d", &value); err != nil {
-		return 0, fmt.Errorf("invalid duration value: %s", valueStr)
-	}
-
+	var per time.Duration
 	switch unit {
-	case 'h':
-		return time.Duration(value) * time.Hour, nil
 	case 'd':
-		return time.Duration(value) * 24 * time.Hour, nil
+		per = 24 * time.Hour
 	case 'w':
-		return time.Duration(value) * 7 * 24 * time.Hour, nil
+		per = 7 * 24 * time.Hour
 	case 'm':
-		return time.Duration(value) * 30 * 24 * time.Hour, nil
+		per = 30 * 24 * time.Hour
 	case 'y':
-		return time.Duration(value) * 365 * 24 * time.Hour, nil
-	default:
-		// Try standard time.ParseDuration
-		return time.ParseDuration(s)
+		per = 365 * 24 * time.Hour
+	}
+	if per == 0 || !isDigits(valueStr) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("duration must not be negative: %s", s)
+		}
+		return d, nil
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil || value > int64(math.MaxInt64/per) {
+		return 0, fmt.Errorf("duration too long: %s", s)
+	}
+	return time.Duration(value) * per, nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseNotBefore parses a --not-before value: an RFC3339 time, a date
+// (YYYY-MM-DD, local midnight) or a duration from now (e.g., 2d).
+func parseNotBefore(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	duration, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, err
 	}
+	return time.Now().Add(duration), nil
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -16,7 +17,11 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+
 	"github.com/forest6511/secretctl/internal/mcp"
+	"github.com/forest6511/secretctl/internal/project"
 )
 
 // Run command flags
@@ -24,30 +29,22 @@ var (
 	runKeys          []string
 	runTimeout       time.Duration
 	runNoSanitize    bool
+	runAllowExpired  bool
 	runEnvPrefix     string
 	runObfuscateKeys bool
 	runEnvAlias      string
 )
 
-// Exit codes per requirements-ja.md §1.3
-const (
-	ExitSecretNotFound  = 2
-	ExitTimeout         = 124
-	ExitCommandNotFound = 127
-	ExitSignalBase      = 128
-)
-
 func init() {
 	rootCmd.AddCommand(runCmd)
 
 	runCmd.Flags().StringArrayVarP(&runKeys, "key", "k", nil, "Secret keys to inject (glob pattern supported)")
 	runCmd.Flags().DurationVarP(&runTimeout, "timeout", "t", 5*time.Minute, "Command timeout")
 	runCmd.Flags().BoolVar(&runNoSanitize, "no-sanitize", false, "Disable output sanitization")
+	runCmd.Flags().BoolVar(&runAllowExpired, "allow-expired", false, "Inject expired secrets (overrides expiry checks and block_expired_reads)")
 	runCmd.Flags().StringVar(&runEnvPrefix, "env-prefix", "", "Environment variable name prefix")
 	runCmd.Flags().BoolVar(&runObfuscateKeys, "obfuscate-keys", false, "Obfuscate secret key names in error messages")
 	runCmd.Flags().StringVar(&runEnvAlias, "env", "", "Environment alias (e.g., dev, staging, prod)")
-
-	_ = runCmd.MarkFlagRequired("key")
 }
 
 // runCmd executes a command with secrets injected as environment variables
@@ -61,6 +58,18 @@ Secrets are converted to environment variable names using these rules:
   - '-' is replaced with '_'
   - Names are converted to UPPERCASE
 
+Project File:
+  Without --key, secrets are taken from the nearest .secretctl.yaml in the
+  current directory or its parents (up to the repository root). The file
+  maps vault keys to environment variable names and contains no values,
+  so it can be committed:
+
+    version: 1
+    secrets:
+      - key: db/dev
+        field: password
+        env: DATABASE_PASSWORD
+
 Environment Aliases:
   Use --env to apply environment-specific key transformations defined in mcp-policy.yaml.
   For example, --env=dev with key "db/*" might resolve to "dev/db/*".
@@ -71,7 +80,8 @@ Examples:
   secretctl run -k "aws/prod/*" -- aws s3 ls
   secretctl run -k API_KEY --timeout=30s -- ./script.sh
   secretctl run --env=dev -k "db/*" -- ./app
-  secretctl run --env=prod -k "api/*" -- kubectl apply -f deployment.yaml`,
+  secretctl run --env=prod -k "api/*" -- kubectl apply -f deployment.yaml
+  secretctl run -- npm test   # uses .secretctl.yaml`,
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Find the command after "--"
@@ -97,6 +107,24 @@ func executeRun(commandArgs []string) error {
 	}
 	defer v.Lock()
 
+	// Without --key, fall back to the project file
+	if len(runKeys) == 0 {
+		if runEnvAlias != "" {
+			return fmt.Errorf("--env requires --key")
+		}
+		secrets, err := collectProjectSecrets()
+		if err != nil {
+			return err
+		}
+		defer wipeSecrets(secrets)
+
+		env, err := buildEnvironment(secrets)
+		if err != nil {
+			return err
+		}
+		return executeCommand(commandArgs, env, secrets)
+	}
+
 	// 2. Resolve environment aliases if --env is specified
 	keys := runKeys
 	if runEnvAlias != "" {
@@ -133,6 +161,9 @@ func executeRun(commandArgs []string) error {
 type secretData struct {
 	key   string
 	value []byte
+	// envName overrides the name derived from key (set from .secretctl.yaml
+	// or by exec)
+	envName string
 }
 
 // wipeSecrets zeroes out all secret values in memory to prevent leakage
@@ -190,23 +221,31 @@ func collectSecrets(patterns []string) ([]secretData, error) {
 	// Use consistent time for all expiration checks
 	now := time.Now()
 
-	// Fetch secret values
+	// Fetch secret values in one read
+	entries, err := secretValuesLookup()(matchedKeys)
+	if err != nil {
+		var keyErr *vault.KeyError
+		if errors.As(err, &keyErr) {
+			return nil, fmt.Errorf("failed to get secret '%s': %w", obfuscateKey(keyErr.Key), keyErr.Err)
+		}
+		return nil, fmt.Errorf("failed to get secrets: %w", err)
+	}
+
 	var secrets []secretData
 	for _, key := range matchedKeys {
-		entry, err := v.GetSecret(key)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get secret '%s': %w", obfuscateKey(key), err)
-		}
+		entry := entries[key]
+		warnRedirected(entry)
 
 		// Check if secret is expired
 		if entry.ExpiresAt != nil {
 			if entry.ExpiresAt.Before(now) {
-				return nil, fmt.Errorf("secret '%s' has expired at %v", obfuscateKey(key), entry.ExpiresAt.Format(time.RFC3339))
-			}
-			// Warn if secret will expire during command execution
-			if entry.ExpiresAt.Before(now.Add(runTimeout)) {
-				fmt.Fprintf(os.Stderr, "warning: secret '%s' will expire at %v (during command execution)\n",
-					obfuscateKey(key), entry.ExpiresAt.Format(time.RFC3339))
+				if !runAllowExpired {
+					return nil, fmt.Errorf("secret '%s' has expired at %v", obfuscateKey(key), entry.ExpiresAt.Format(time.RFC3339))
+				}
+				slog.Warn("using expired secret", "key", obfuscateKey(key), "expired_at", entry.ExpiresAt.Format(time.RFC3339))
+			} else if entry.ExpiresAt.Before(now.Add(runTimeout)) {
+				// Warn if secret will expire during command execution
+				slog.Warn("secret will expire during command execution", "key", obfuscateKey(key), "expires_at", entry.ExpiresAt.Format(time.RFC3339))
 			}
 		}
 
@@ -219,6 +258,56 @@ func collectSecrets(patterns []string) ([]secretData, error) {
 	return secrets, nil
 }
 
+// secretValueLookup returns the lookup used to read secret values for
+// injection. --allow-expired bypasses the vault's block_expired_reads setting.
+func secretValueLookup() secretLookup {
+	if runAllowExpired {
+		return v.GetSecretAllowExpired
+	}
+	return v.GetSecret
+}
+
+// secretValuesLookup is the batch counterpart of secretValueLookup.
+func secretValuesLookup() func([]string) (map[string]*vault.SecretEntry, error) {
+	if runAllowExpired {
+		return v.GetSecretsAllowExpired
+	}
+	return v.GetSecrets
+}
+
+// collectProjectSecrets resolves the secrets declared in the nearest .secretctl.yaml
+func collectProjectSecrets() ([]secretData, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	path, err := project.Find(cwd)
+	if err != nil {
+		if errors.Is(err, project.ErrManifestNotFound) {
+			return nil, fmt.Errorf("no --key specified and no %s found; use: secretctl run -k KEY -- command [args...]", project.FileName)
+		}
+		return nil, err
+	}
+	manifest, err := project.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := manifest.Resolve(secretValueLookup())
+	if err != nil {
+		return nil, err
+	}
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("no secrets available from %s", path)
+	}
+
+	secrets := make([]secretData, 0, len(resolved))
+	for _, r := range resolved {
+		secrets = append(secrets, secretData{key: r.Key, value: r.Value, envName: r.EnvName()})
+	}
+	return secrets, nil
+}
+
 // expandPattern expands a glob pattern against available keys
 func expandPattern(pattern string, availableKeys []string) ([]string, error) {
 	// Validate pattern syntax
@@ -266,6 +355,9 @@ func buildEnvironment(secrets []secretData) ([]string, error) {
 	// Add secrets as environment variables
 	for _, secret := range secrets {
 		envName := keyToEnvName(secret.key)
+		if secret.envName != "" {
+			envName = secret.envName
+		}
 
 		// Apply prefix if specified
 		if runEnvPrefix != "" {
@@ -357,11 +449,26 @@ func checkReservedEnvVar(name string) error {
 	}
 	// Warn (but don't error) for other LC_* variables
 	if strings.HasPrefix(name, "LC_") {
-		fmt.Fprintf(os.Stderr, "warning: overwriting locale environment variable: %s\n", name)
+		slog.Warn("overwriting locale environment variable", "name", name)
 	}
 	return nil
 }
 
+// recordUsage adds command to the "used by" list of each injected secret.
+// Failures are not fatal: the record is informational.
+func recordUsage(command string, secrets []secretData) {
+	recorded := make(map[string]bool, len(secrets))
+	for _, secret := range secrets {
+		if secret.key == "" || recorded[secret.key] {
+			continue
+		}
+		recorded[secret.key] = true
+		if err := v.RecordUsage(secret.key, command, audit.SourceCLI); err != nil {
+			slog.Debug("failed to record secret usage", "key", obfuscateKey(secret.key), "error", err)
+		}
+	}
+}
+
 // executeCommand runs the command with secrets in environment
 func executeCommand(args []string, env []string, secrets []secretData) error {
 	// Prevent core dumps to protect secrets in memory (security requirement)
@@ -378,6 +485,7 @@ func executeCommand(args []string, env []string, secrets []secretData) error {
 	if err != nil {
 		return &exitError{code: ExitCommandNotFound, err: fmt.Errorf("command not found: %s", args[0])}
 	}
+	recordUsage(args[0], secrets)
 
 	// Create command
 	cmd := exec.CommandContext(ctx, cmdPath, args[1:]...)
@@ -537,9 +645,13 @@ func newOutputSanitizer(secrets []secretData) *outputSanitizer {
 			if len(secret.value) > maxLen {
 				maxLen = len(secret.value)
 			}
+			name := secret.envName
+			if name == "" {
+				name = keyToEnvName(secret.key)
+			}
 			replacements = append(replacements, secretReplacement{
 				secret:      secret.value,
-				placeholder: []byte(fmt.Sprintf("[REDACTED:%s]", keyToEnvName(secret.key))),
+				placeholder: []byte(fmt.Sprintf("[REDACTED:%s]", name)),
 			})
 		}
 	}
@@ -439,9 +439,9 @@ func TestExitError(t *testing.T) {
 
 // TestExitCodes tests that exit codes match spec
 func TestExitCodes(t *testing.T) {
-	// Verify exit codes match requirements-ja.md §1.3
-	if ExitSecretNotFound != 2 {
-		t.Errorf("ExitSecretNotFound = %d, want 2", ExitSecretNotFound)
+	// Verify exit codes match requirements-ja.md §1.3 and the documented table
+	if ExitNotFound != 4 {
+		t.Errorf("ExitNotFound = %d, want 4", ExitNotFound)
 	}
 	if ExitTimeout != 124 {
 		t.Errorf("ExitTimeout = %d, want 124", ExitTimeout)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Search command flags
+var searchJSON bool
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Output in JSON format")
+}
+
+// searchCmd finds secrets by the words in their notes, URLs and
+// non-sensitive fields
+var searchCmd = &cobra.Command{
+	Use:   "search <words>...",
+	Short: "Find secrets by words in their notes, URLs and non-sensitive fields",
+	Long: `Print the secrets whose notes, URL or non-sensitive field values contain
+every given word. Words match case-insensitively at the start of a word, so
+"stag" finds "Staging". Sensitive values are never searched; use grep for
+those.
+
+By default every secret is decrypted for each search. With
+
+  secretctl config set search_index true
+
+an encrypted index is kept in the vault and updated on write, and a search
+only decrypts the secrets that changed since the index was last updated.
+
+Examples:
+  secretctl search staging
+  secretctl search billing api.example.com
+  secretctl search postgres --json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		results, err := v.Search(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+
+		if searchJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(os.Stderr, "No matches")
+			return nil
+		}
+		for _, r := range results {
+			fmt.Printf("%s (%s)\n", r.Key, strings.Join(r.Locations, ", "))
+		}
+		return nil
+	},
+}
@@ -79,7 +79,7 @@ Upgrade to Team edition for the full list.`,
 		// Load full secret entries
 		var entries []*vault.SecretEntry
 		for _, key := range secrets {
-			e, err := v.GetSecret(key)
+			e, err := v.InspectSecret(key)
 			if err != nil {
 				continue
 			}
@@ -144,7 +144,7 @@ In Free edition, only the top 3 weak passwords are shown.`,
 
 		count := 0
 		for _, key := range secrets {
-			entry, err := v.GetSecret(key)
+			entry, err := v.InspectSecret(key)
 			if err != nil {
 				continue
 			}
@@ -230,16 +230,21 @@ Example:
 
 		fmt.Printf("⏰ Secrets Expiring Within %d Days (%d found)\n\n", securityDays, len(entries))
 		for i, entry := range entries {
-			if entry.ExpiresAt == nil {
+			expiry := entry.Expiry()
+			if expiry == nil {
 				continue
 			}
-			daysLeft := int(time.Until(*entry.ExpiresAt).Hours() / 24)
+			daysLeft := int(time.Until(*expiry).Hours() / 24)
 			status := "expiring"
 			if daysLeft < 0 {
 				status = "EXPIRED"
 				daysLeft = -daysLeft
 			}
-			fmt.Printf("%d. %s - %s in %d days\n", i+1, entry.Key, status, daysLeft)
+			what := ""
+			if entry.Certificate != nil && expiry.Equal(entry.Certificate.NotAfter) {
+				what = " (certificate)"
+			}
+			fmt.Printf("%d. %s%s - %s in %d days\n", i+1, entry.Key, what, status, daysLeft)
 		}
 
 		return nil
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(shardCmd)
+	shardCmd.AddCommand(shardAddCmd)
+	shardCmd.AddCommand(shardListCmd)
+	shardCmd.AddCommand(shardRemoveCmd)
+}
+
+// shardCmd is the parent command for namespace shards
+var shardCmd = &cobra.Command{
+	Use:   "shard",
+	Short: "Store namespaces in separate database files",
+	Long: `A namespace is the part of a key before the first '/': the namespace of
+'prod/DB_URL' is 'prod'. Sharding a namespace moves its secrets out of
+vault.db into shards/<namespace>.db, which is attached whenever the vault
+is unlocked. Commands work as before, whichever file a secret is in.
+
+Sharding keeps very large vaults manageable: each file can be synced or
+copied on its own, and a corrupted shard only makes its own namespace
+unavailable. Backups include every shard.`,
+}
+
+// shardAddCmd shards a namespace
+var shardAddCmd = &cobra.Command{
+	Use:   "add <namespace>",
+	Short: "Move a namespace into its own database file",
+	Long: `Moves the secrets of <namespace>, including those in the trash, into
+shards/<namespace>.db. Secrets added to the namespace later are stored
+there too.
+
+Examples:
+  secretctl shard add prod`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.AddShard(args[0]); err != nil {
+			return fmt.Errorf("failed to add shard: %w", err)
+		}
+		fmt.Printf("Namespace '%s' is now stored in its own shard\n", args[0])
+		return nil
+	},
+}
+
+// shardListCmd lists sharded namespaces
+var shardListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sharded namespaces",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		shards, err := v.Shards()
+		if err != nil {
+			return fmt.Errorf("failed to list shards: %w", err)
+		}
+		if len(shards) == 0 {
+			fmt.Println("No sharded namespaces")
+			return nil
+		}
+		for _, s := range shards {
+			if s.Err != nil {
+				fmt.Printf("%-20s UNAVAILABLE: %v\n", s.Namespace, s.Err)
+				continue
+			}
+			fmt.Printf("%-20s %d secret(s)  %s\n", s.Namespace, s.Secrets, s.Path)
+		}
+		return nil
+	},
+}
+
+// shardRemoveCmd moves a namespace back into vault.db
+var shardRemoveCmd = &cobra.Command{
+	Use:   "remove <namespace>",
+	Short: "Move a namespace back into vault.db",
+	Long: `Moves the secrets of <namespace> back into vault.db and deletes its shard
+file. The shard must be available; restore a corrupted shard from a
+backup first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		if err := v.RemoveShard(args[0]); err != nil {
+			return fmt.Errorf("failed to remove shard: %w", err)
+		}
+		fmt.Printf("Namespace '%s' moved back into vault.db\n", args[0])
+		return nil
+	},
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+)
+
+// Shell-init command flags
+var shellInitInstall bool
+
+// historyIgnoredCommands are the subcommands kept out of shell history, since
+// their arguments may contain secret values or reveal which keys are used.
+var historyIgnoredCommands = []string{"set", "get"}
+
+func init() {
+	rootCmd.AddCommand(shellInitCmd)
+
+	shellInitCmd.Flags().BoolVar(&shellInitInstall, "install", false, "Add the integration to your shell startup file")
+}
+
+// shellInitCmd prints shell integration for bash, zsh and fish
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init [bash|zsh|fish]",
+	Short: "Print shell integration (history protection, completion, agent socket)",
+	Long: `Print shell configuration that:
+
+  - keeps 'secretctl set' and 'secretctl get' out of shell history
+  - loads secretctl completion
+  - exports SECRETCTL_AGENT_SOCK so clients find the agent socket
+
+The shell is detected from $SHELL when not given.
+
+Install with one command (adds an eval line to your startup file):
+  secretctl shell-init --install
+
+Or add it manually:
+  bash (~/.bashrc):               eval "$(secretctl shell-init bash)"
+  zsh  (~/.zshrc):                eval "$(secretctl shell-init zsh)"
+  fish (~/.config/fish/config.fish): secretctl shell-init fish | source`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	Args:                  cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell := ""
+		if len(args) == 1 {
+			shell = args[0]
+		} else {
+			shell = filepath.Base(os.Getenv("SHELL"))
+		}
+
+		if shellInitInstall {
+			return installShellInit(shell)
+		}
+
+		script, err := shellInitScript(shell, agent.DefaultSocketPath(vaultPath))
+		if err != nil {
+			return err
+		}
+		fmt.Print(script)
+		return nil
+	},
+}
+
+// shellInitScript returns the integration script for shell.
+func shellInitScript(shell, socketPath string) (string, error) {
+	var b strings.Builder
+	b.WriteString("# secretctl shell integration\n")
+
+	switch shell {
+	case "bash":
+		patterns := make([]string, len(historyIgnoredCommands))
+		for i, c := range historyIgnoredCommands {
+			patterns[i] = "secretctl " + c + "*"
+		}
+		fmt.Fprintf(&b, "[ -n \"$%[1]s\" ] || export %[1]s=%[2]s\n", agent.SocketEnv, shellQuote(socketPath))
+		fmt.Fprintf(&b, "HISTIGNORE=\"${HISTIGNORE:+$HISTIGNORE:}%s\"\n", strings.Join(patterns, ":"))
+		b.WriteString("source <(secretctl completion bash)\n")
+	case "zsh":
+		fmt.Fprintf(&b, "[ -n \"$%[1]s\" ] || export %[1]s=%[2]s\n", agent.SocketEnv, shellQuote(socketPath))
+		b.WriteString("_secretctl_zshaddhistory() {\n")
+		fmt.Fprintf(&b, "  [[ ${1%%%%$'\\n'} != secretctl\\ (%s)(\\ *|) ]]\n", strings.Join(historyIgnoredCommands, "|"))
+		b.WriteString("}\n")
+		b.WriteString("autoload -Uz add-zsh-hook\n")
+		b.WriteString("add-zsh-hook zshaddhistory _secretctl_zshaddhistory\n")
+		b.WriteString("(( $+functions[compdef] )) || { autoload -Uz compinit && compinit }\n")
+		b.WriteString("source <(secretctl completion zsh)\n")
+	case "fish":
+		fmt.Fprintf(&b, "set -q %[1]s; or set -gx %[1]s %[2]s\n", agent.SocketEnv, fishQuote(socketPath))
+		// Keep a history filter the user already has, once even if the
+		// script is sourced again
+		b.WriteString("if not set -q __secretctl_history_hook\n")
+		b.WriteString("    set -g __secretctl_history_hook 1\n")
+		b.WriteString("    functions -q fish_should_add_to_history; and functions -c fish_should_add_to_history __secretctl_orig_should_add\n")
+		b.WriteString("end\n")
+		b.WriteString("function fish_should_add_to_history\n")
+		fmt.Fprintf(&b, "    string match -qr '^\\s*secretctl\\s+(%s)\\b' -- $argv[1]; and return 1\n", strings.Join(historyIgnoredCommands, "|"))
+		b.WriteString("    if functions -q __secretctl_orig_should_add\n")
+		b.WriteString("        __secretctl_orig_should_add $argv\n")
+		b.WriteString("        return\n")
+		b.WriteString("    end\n")
+		// Without a filter of its own fish leaves out commands starting
+		// with a space, which defining one turns off
+		b.WriteString("    string match -qr '^\\s' -- $argv[1]; and return 1\n")
+		b.WriteString("    return 0\n")
+		b.WriteString("end\n")
+		b.WriteString("secretctl completion fish | source\n")
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish)", shell)
+	}
+	return b.String(), nil
+}
+
+// installShellInit appends the eval line for shell to its startup file.
+func installShellInit(shell string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	var rcFile, line string
+	switch shell {
+	case "bash":
+		rcFile, line = filepath.Join(home, ".bashrc"), `eval "$(secretctl shell-init bash)"`
+	case "zsh":
+		rcFile, line = filepath.Join(home, ".zshrc"), `eval "$(secretctl shell-init zsh)"`
+	case "fish":
+		rcFile, line = filepath.Join(home, ".config", "fish", "config.fish"), "secretctl shell-init fish | source"
+	default:
+		return fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish)", shell)
+	}
+
+	existing, err := os.ReadFile(rcFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", rcFile, err)
+	}
+	if strings.Contains(string(existing), line) {
+		fmt.Printf("Shell integration already installed in %s\n", rcFile)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rcFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(rcFile), err)
+	}
+	f, err := os.OpenFile(rcFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", rcFile, err)
+	}
+	defer f.Close()
+
+	prefix := "\n"
+	if len(existing) == 0 || strings.HasSuffix(string(existing), "\n") {
+		prefix = ""
+	}
+	if _, err := fmt.Fprintf(f, "%s\n# secretctl shell integration\n%s\n", prefix, line); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rcFile, err)
+	}
+	fmt.Printf("Shell integration added to %s (restart your shell to apply)\n", rcFile)
+	return nil
+}
+
+// shellQuote single-quotes s for bash and zsh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, where \\ and \' are escapes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
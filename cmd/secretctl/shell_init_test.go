@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellInitScript(t *testing.T) {
+	tests := []struct {
+		shell    string
+		contains []string
+	}{
+		{"bash", []string{"HISTIGNORE=", "secretctl set*:secretctl get*", `export SECRETCTL_AGENT_SOCK='/tmp/it'\''s/agent.sock'`, "completion bash"}},
+		{"zsh", []string{"add-zsh-hook zshaddhistory", "(set|get)", "completion zsh"}},
+		{"fish", []string{"fish_should_add_to_history", "(set|get)", `'/tmp/it\'s/agent.sock'`, "completion fish"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			script, err := shellInitScript(tt.shell, "/tmp/it's/agent.sock")
+			if err != nil {
+				t.Fatalf("shellInitScript failed: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(script, want) {
+					t.Errorf("script missing %q:\n%s", want, script)
+				}
+			}
+		})
+	}
+
+	if _, err := shellInitScript("tcsh", "/tmp/agent.sock"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
+
+func TestShellInitScript_BashSyntax(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	script, err := shellInitScript("bash", "/tmp/agent.sock")
+	if err != nil {
+		t.Fatalf("shellInitScript failed: %v", err)
+	}
+	cmd := exec.Command(bash, "-n")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("bash -n failed: %v\n%s", err, out)
+	}
+}
+
+func TestShellInitScript_FishChainsHistoryFilter(t *testing.T) {
+	script, err := shellInitScript("fish", "/tmp/agent.sock")
+	if err != nil {
+		t.Fatalf("shellInitScript failed: %v", err)
+	}
+
+	// An existing filter is saved before ours replaces it, and ours calls
+	// it once secretctl commands are ruled out
+	save := strings.Index(script, "functions -c fish_should_add_to_history __secretctl_orig_should_add")
+	define := strings.Index(script, "function fish_should_add_to_history")
+	check := strings.Index(script, "secretctl\\s+(set|get)")
+	chain := strings.Index(script, "__secretctl_orig_should_add $argv")
+	if save < 0 || define < 0 || check < 0 || chain < 0 {
+		t.Fatalf("fish script does not chain to the original filter:\n%s", script)
+	}
+	if !(save < define && define < check && check < chain) {
+		t.Errorf("fish script saves, defines, checks and chains out of order:\n%s", script)
+	}
+	// Sourcing the script again must not save our own filter as the original
+	if !strings.Contains(script, "if not set -q __secretctl_history_hook") {
+		t.Errorf("fish script saves the filter on every source:\n%s", script)
+	}
+}
+
+func TestShellInitScript_FishHistory(t *testing.T) {
+	fish, err := exec.LookPath("fish")
+	if err != nil {
+		t.Skip("fish not available")
+	}
+	script, err := shellInitScript("fish", "/tmp/agent.sock")
+	if err != nil {
+		t.Fatalf("shellInitScript failed: %v", err)
+	}
+	script = strings.ReplaceAll(script, "secretctl completion fish | source\n", "")
+
+	tests := []struct {
+		name, setup, command string
+		added                bool
+	}{
+		{"secretctl set", "", "secretctl set API_KEY", false},
+		{"other command", "", "ls -l", true},
+		{"leading space", "", " ls -l", false},
+		{"user filter", "function fish_should_add_to_history; string match -q '*private*' -- $argv[1]; and return 1; return 0; end", "cat private.txt", false},
+		{"user filter allows", "function fish_should_add_to_history; return 0; end", "ls -l", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Sourcing twice must not make our filter call itself
+			prog := tt.setup + "\n" + script + script + "\nfish_should_add_to_history " + fishQuote(tt.command) + "\n"
+			err := exec.Command(fish, "--no-config", "-c", prog).Run()
+			if added := err == nil; added != tt.added {
+				t.Errorf("%q added to history = %v (%v), want %v", tt.command, added, err, tt.added)
+			}
+		})
+	}
+}
+
+func TestInstallShellInit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	rcFile := filepath.Join(home, ".bashrc")
+	if err := os.WriteFile(rcFile, []byte("alias ll='ls -l'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Installing twice adds the line once
+	for i := 0; i < 2; i++ {
+		if err := installShellInit("bash"); err != nil {
+			t.Fatalf("installShellInit failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(rcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "secretctl shell-init bash"); n != 1 {
+		t.Errorf("expected eval line once, found %d times:\n%s", n, data)
+	}
+	if !strings.HasPrefix(string(data), "alias ll='ls -l'\n") {
+		t.Errorf("existing content not preserved:\n%s", data)
+	}
+
+	if err := installShellInit("fish"); err != nil {
+		t.Fatalf("installShellInit fish failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "fish", "config.fish")); err != nil {
+		t.Errorf("expected fish config to be created: %v", err)
+	}
+}
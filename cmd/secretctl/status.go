@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Status command flags
+var (
+	statusTimings bool
+	statusJSON    bool
+)
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().BoolVar(&statusTimings, "timings", false, "Unlock the vault and show how long each step took")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output in JSON format")
+}
+
+// statusOutput is the JSON output of the status command.
+type statusOutput struct {
+	VaultPath     string               `json:"vault_path"`
+	SchemaVersion int                  `json:"schema_version"`
+	AgentRunning  bool                 `json:"agent_running"`
+	AgentLocked   bool                 `json:"agent_locked,omitempty"`
+	Startup       time.Duration        `json:"startup_ns,omitempty"`
+	Unlock        *vault.UnlockTimings `json:"unlock,omitempty"`
+}
+
+// statusCmd reports the vault and agent state and, with --timings, where
+// the time of unlocking goes
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the vault and agent status and unlock timings",
+	Long: `Shows where the vault is, its schema version and whether an agent is
+running for it.
+
+With --timings the vault is unlocked, which asks for the master password,
+and the time spent in each step is shown: starting secretctl, checking
+vault.meta, opening the database, deriving the key from the password,
+migrating and attaching shards, and opening the audit log. Preparing the
+database runs while the key is derived, so it is not counted separately
+in the total. Key derivation is expected to dominate; it is what makes
+guessing the password slow. To avoid paying it on every command, run
+'secretctl agent'.
+
+Examples:
+  secretctl status
+  secretctl status --timings
+  secretctl status --timings --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := statusOutput{VaultPath: vaultPath}
+		if statusTimings {
+			out.Startup = time.Since(processStart)
+		}
+		info, err := v.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read vault info: %w", err)
+		}
+		out.SchemaVersion = info.SchemaVersion
+		if client, err := dialAgent(); err == nil {
+			status, err := client.Status(cmd.Context(), &agentv1.StatusRequest{})
+			client.Close()
+			if err == nil {
+				out.AgentRunning = true
+				out.AgentLocked = status.Locked
+			}
+		}
+
+		if statusTimings {
+			if err := ensureUnlocked(); err != nil {
+				return err
+			}
+			v.Lock()
+			out.Unlock = v.UnlockTimings()
+		}
+
+		if statusJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+
+		fmt.Printf("%-9s %s (schema %d)\n", "Vault:", out.VaultPath, out.SchemaVersion)
+		switch {
+		case !out.AgentRunning:
+			fmt.Printf("%-9s %s\n", "Agent:", "not running")
+		case out.AgentLocked:
+			fmt.Printf("%-9s %s\n", "Agent:", "running (vault locked)")
+		default:
+			fmt.Printf("%-9s %s\n", "Agent:", "running (vault unlocked)")
+		}
+		if out.Unlock != nil {
+			fmt.Printf("%-9s %s\n", "Startup:", formatTiming(out.Startup))
+			fmt.Printf("%-9s %s\n", "Unlock:", formatTiming(out.Unlock.Total))
+			for _, p := range out.Unlock.Phases {
+				note := ""
+				if p.Overlapped {
+					note = " (during kdf)"
+				}
+				fmt.Printf("  %-9s %s%s\n", p.Name, formatTiming(p.Duration), note)
+			}
+		}
+		return nil
+	},
+}
+
+// formatTiming rounds d for display.
+func formatTiming(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(100 * time.Microsecond).String()
+}
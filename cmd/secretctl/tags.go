@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Tags command flags
+var tagsListJSON bool
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+	tagsCmd.AddCommand(tagsListCmd)
+	tagsCmd.AddCommand(tagsRenameCmd)
+	tagsCmd.AddCommand(tagsDeleteCmd)
+
+	tagsListCmd.Flags().BoolVar(&tagsListJSON, "json", false, "Output in JSON format")
+}
+
+// tagsCmd is the parent command for tag management
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List, rename or delete tags across all secrets",
+	Long: `Manage tags across the whole vault. Renaming or deleting a tag updates
+every secret that has it in a single transaction: either all of them are
+changed or none are, e.g. when one of them is immutable or frozen.
+
+Secret values are never modified. To add tags to a selection of secrets,
+use 'secretctl bulk'.`,
+}
+
+// tagsListCmd lists tags with the number of secrets using them
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tags and how many secrets have each",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		tags, err := v.ListTags()
+		if err != nil {
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+		if tagsListJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(tags)
+		}
+		if len(tags) == 0 {
+			fmt.Println("No tags")
+			return nil
+		}
+		for _, t := range tags {
+			fmt.Printf("%s (%d)\n", t.Tag, t.Count)
+		}
+		return nil
+	},
+}
+
+// tagsRenameCmd renames a tag on every secret
+var tagsRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a tag on every secret that has it",
+	Long: `Rename a tag on every secret that has it. The tag keeps its position;
+secrets that already have the new tag end up with a single copy.
+
+Examples:
+  secretctl tags rename prod production`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTagArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		result, err := v.RenameTag(args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("failed to rename tag: %w", err)
+		}
+		printTagChanges(result)
+		fmt.Printf("Renamed '%s' to '%s' on %d secret(s)\n", args[0], args[1], len(result.Changes))
+		return nil
+	},
+}
+
+// tagsDeleteCmd removes a tag from every secret
+var tagsDeleteCmd = &cobra.Command{
+	Use:   "delete <tag>",
+	Short: "Remove a tag from every secret that has it",
+	Long: `Remove a tag from every secret that has it. The secrets are kept.
+
+Examples:
+  secretctl tags delete temp`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTagArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		result, err := v.DeleteTag(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+		printTagChanges(result)
+		fmt.Printf("Removed '%s' from %d secret(s)\n", args[0], len(result.Changes))
+		return nil
+	},
+}
+
+// printTagChanges lists the secrets a tag command changed.
+func printTagChanges(result *vault.BulkResult) {
+	for _, c := range result.Changes {
+		fmt.Printf("  %s\n", c.Key)
+	}
+}
+
+// completeTagArgs completes the first argument with tag names.
+func completeTagArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeTags(cmd, args, toComplete)
+}
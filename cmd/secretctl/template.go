@@ -18,6 +18,7 @@ type TemplateField struct {
 	Required  bool
 	Kind      string
 	InputType string // "text" (default) | "textarea" per ADR-005
+	Validator string // value format checked on write (see vault.Validators)
 }
 
 // BuiltinTemplates contains the predefined secret templates.
@@ -47,7 +48,7 @@ var BuiltinTemplates = map[string]SecretTemplate{
 		Fields: []TemplateField{
 			{Name: "api_key", Prompt: "API Key", Sensitive: true, Required: true},
 			{Name: "api_secret", Prompt: "API Secret", Sensitive: true, Required: false},
-			{Name: "endpoint", Prompt: "Endpoint URL", Sensitive: false, Required: false, Kind: "url"},
+			{Name: "endpoint", Prompt: "Endpoint URL", Sensitive: false, Required: false, Kind: "url", Validator: vault.ValidatorURL},
 		},
 	},
 	"ssh": {
@@ -57,7 +58,16 @@ var BuiltinTemplates = map[string]SecretTemplate{
 			{Name: "host", Prompt: "Host", Sensitive: false, Required: true, Kind: "hostname"},
 			{Name: "port", Prompt: "Port (default: 22)", Sensitive: false, Required: false, Kind: "port"},
 			{Name: "username", Prompt: "Username", Sensitive: false, Required: true},
-			{Name: "private_key", Prompt: "Private Key (paste, then Ctrl+D)", Sensitive: true, Required: true, InputType: "textarea"},
+			{Name: "private_key", Prompt: "Private Key (paste, then Ctrl+D)", Sensitive: true, Required: true, InputType: "textarea", Validator: vault.ValidatorPEM},
+		},
+	},
+	"aws": {
+		Name:        "aws",
+		Description: "AWS access key (access_key_id, secret_access_key, region)",
+		Fields: []TemplateField{
+			{Name: "access_key_id", Prompt: "Access Key ID", Sensitive: false, Required: true, Validator: vault.ValidatorAWSAccessKeyID},
+			{Name: "secret_access_key", Prompt: "Secret Access Key", Sensitive: true, Required: true},
+			{Name: "region", Prompt: "Region", Sensitive: false, Required: false},
 		},
 	},
 }
@@ -77,6 +87,7 @@ func TemplateToFields(template SecretTemplate, values map[string]string) map[str
 			Sensitive: tf.Sensitive,
 			Kind:      tf.Kind,
 			InputType: tf.InputType,
+			Validator: tf.Validator,
 		}
 	}
 	return fields
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/i18n"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Trash command flags
+var (
+	trashOlderThan string
+	trashForce     bool
+)
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+
+	trashPurgeCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only purge secrets deleted at least this long ago (e.g., 30d)")
+	trashPurgeCmd.Flags().BoolVarP(&trashForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+// trashCmd is the parent command for deleted secrets
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List, restore or purge deleted secrets",
+	Long: `'secretctl delete' moves secrets to the trash instead of removing them.
+Secrets in the trash keep their fields, metadata and history, but are
+hidden from get, list and run until they are restored.
+
+Setting a key that is in the trash replaces the deleted secret.`,
+}
+
+// trashListCmd lists deleted secrets
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted secrets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		deleted, err := v.ListDeleted()
+		if err != nil {
+			return fmt.Errorf("failed to list deleted secrets: %w", err)
+		}
+		if len(deleted) == 0 {
+			fmt.Println("Trash is empty")
+			return nil
+		}
+		now := time.Now()
+		for _, d := range deleted {
+			line := fmt.Sprintf("%s (deleted %s)", d.Key, tf.Relative(d.DeletedAt, now))
+			if len(d.Tags) > 0 {
+				line += fmt.Sprintf(" [%s]", strings.Join(d.Tags, ","))
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+// trashRestoreCmd restores deleted secrets
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <key>...",
+	Short: "Restore deleted secrets",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		for _, key := range args {
+			if err := v.RestoreSecret(key); err != nil {
+				if errors.Is(err, vault.ErrNotInTrash) {
+					return &exitError{code: ExitNotFound, err: fmt.Errorf("'%s' is not in the trash", key)}
+				}
+				return fmt.Errorf("failed to restore '%s': %w", key, err)
+			}
+			fmt.Printf("Restored '%s'\n", key)
+		}
+		return nil
+	},
+}
+
+// trashPurgeCmd permanently removes deleted secrets
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove deleted secrets",
+	Long: `Permanently removes secrets from the trash. Without --older-than the
+whole trash is emptied. Purged secrets can only be recovered from a backup.
+
+Examples:
+  secretctl trash purge --older-than 30d
+  secretctl trash purge --force`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var olderThan time.Duration
+		if trashOlderThan != "" {
+			d, err := parseDuration(trashOlderThan)
+			if err != nil {
+				return &exitError{code: ExitUsage, err: fmt.Errorf("invalid --older-than: %w", err)}
+			}
+			olderThan = d
+		}
+
+		if !trashForce {
+			if trashOlderThan != "" {
+				fmt.Printf("This will permanently remove secrets deleted more than %s ago.\n", trashOlderThan)
+			} else {
+				fmt.Println("This will permanently remove all secrets in the trash.")
+			}
+			if !askYesNo(i18n.T("Are you sure? [y/N]: ")) {
+				fmt.Println("Aborted")
+				return nil
+			}
+		}
+
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		keys, err := v.PurgeDeleted(olderThan)
+		if err != nil {
+			return fmt.Errorf("failed to purge trash: %w", err)
+		}
+		fmt.Printf("Purged %d secret(s)\n", len(keys))
+		return nil
+	},
+}
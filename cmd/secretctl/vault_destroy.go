@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/backup"
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/i18n"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// destroyConfirmation is the word the user must type to destroy the vault.
+const destroyConfirmation = "destroy"
+
+var (
+	destroyKeepBackup     string
+	destroyBackupPassword bool
+)
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+	vaultCmd.AddCommand(vaultDestroyCmd)
+
+	vaultDestroyCmd.Flags().StringVar(&destroyKeepBackup, "keep-backup", "", "Write an encrypted backup to this file before destroying the vault")
+	vaultDestroyCmd.Flags().BoolVar(&destroyBackupPassword, "backup-password", false, "Encrypt the kept backup with a separate password instead of the master password")
+}
+
+// vaultCmd is the parent command for whole-vault operations
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Whole-vault operations",
+}
+
+// vaultDestroyCmd securely wipes the vault
+var vaultDestroyCmd = &cobra.Command{
+	Use:   "destroy",
+	Short: "Securely wipe the vault and everything in its directory",
+	Long: `Securely wipes the vault: every file in the vault directory (vault.db,
+vault.salt, vault.meta, audit logs, MCP policy and session files) is
+overwritten with random data and removed, and the directory is deleted.
+A running agent is locked and its socket removed.
+
+You must type "destroy" and enter the master password. With --keep-backup,
+an encrypted backup is written first (it must be outside the vault
+directory); it can later be restored with 'secretctl restore'.
+
+Overwriting cannot reach old copies kept by SSDs or copy-on-write
+filesystems. Those copies are still encrypted; use full-disk encryption if
+they must be unrecoverable. Recovery kits and backups stored elsewhere are
+not touched.
+
+Examples:
+  secretctl vault destroy
+  secretctl vault destroy --keep-backup /media/usb/last-backup.enc`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := os.Stat(filepath.Join(vaultPath, vault.SaltFileName)); err != nil {
+			return fmt.Errorf("no vault found at %s", vaultPath)
+		}
+		if destroyBackupPassword && destroyKeepBackup == "" {
+			return errors.New("--backup-password requires --keep-backup")
+		}
+		if destroyKeepBackup != "" {
+			if err := checkKeepBackupPath(destroyKeepBackup, vaultPath); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("This permanently destroys the vault at %s and every secret in it.\n", vaultPath)
+		fmt.Print(i18n.T("Type %q to confirm: ", destroyConfirmation))
+		answer, err := readLine()
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(answer) != destroyConfirmation {
+			fmt.Println("Aborted")
+			return nil
+		}
+
+		fmt.Print(i18n.T("Enter master password: "))
+		password, err := term.ReadPassword(int(syscall.Stdin))
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		fmt.Println()
+		defer crypto.SecureWipe(password)
+		if err := v.Unlock(string(password)); err != nil {
+			return fmt.Errorf("failed to unlock vault: %w", err)
+		}
+		defer v.Lock()
+
+		if destroyKeepBackup != "" {
+			backupPassword := password
+			if destroyBackupPassword {
+				if backupPassword, err = promptBackupPassword(); err != nil {
+					return err
+				}
+				defer crypto.SecureWipe(backupPassword)
+			}
+			if err := writeFinalBackup(destroyKeepBackup, backupPassword); err != nil {
+				return err
+			}
+			fmt.Printf("Backup written to %s\n", destroyKeepBackup)
+		}
+
+		lockRunningAgent(cmd.Context())
+
+		wiped, err := v.Destroy()
+		if err != nil {
+			return fmt.Errorf("failed to destroy vault: %w", err)
+		}
+		// A socket configured outside the vault directory survives the wipe
+		if socketPath := agent.DefaultSocketPath(vaultPath); !strings.HasPrefix(socketPath, vaultPath+string(filepath.Separator)) {
+			if info, err := os.Lstat(socketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+				os.Remove(socketPath)
+			}
+		}
+
+		fmt.Printf("Vault destroyed: %d files wiped, %s removed\n", wiped, vaultPath)
+		return nil
+	},
+}
+
+// checkKeepBackupPath rejects backup paths inside the vault directory
+// (they would be wiped) and existing files.
+func checkKeepBackupPath(path, vaultDir string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid backup path: %w", err)
+	}
+	dir, err := filepath.Abs(vaultDir)
+	if err != nil {
+		return fmt.Errorf("invalid vault path: %w", err)
+	}
+	if rel, err := filepath.Rel(dir, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("--keep-backup must be outside the vault directory %s", vaultDir)
+	}
+	if _, err := os.Stat(abs); err == nil {
+		return fmt.Errorf("backup file already exists: %s", path)
+	}
+	return nil
+}
+
+// writeFinalBackup writes an encrypted backup including the audit log.
+func writeFinalBackup(path string, password []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	err = backup.Backup(v, backup.BackupOptions{
+		Output:       f,
+		IncludeAudit: true,
+		Password:     password,
+		ToolVersion:  version,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("backup failed, vault not destroyed: %w", err)
+	}
+	return nil
+}
+
+// lockRunningAgent asks an agent serving this vault to lock, so that it no
+// longer holds the DEK. It is best-effort: no agent is the common case.
+func lockRunningAgent(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	client, err := agent.Dial(ctx, agent.DefaultSocketPath(vaultPath))
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	if _, err := client.Lock(ctx, &agentv1.LockRequest{}); err == nil {
+		fmt.Println("Locked the running agent (stop it with Ctrl+C or kill)")
+	}
+}
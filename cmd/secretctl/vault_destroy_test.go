@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckKeepBackupPath(t *testing.T) {
+	vaultDir := filepath.Join(t.TempDir(), ".secretctl")
+	outside := t.TempDir()
+	existing := filepath.Join(outside, "existing.enc")
+	if err := os.WriteFile(existing, []byte("x"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{filepath.Join(outside, "final.enc"), false},
+		{filepath.Join(vaultDir, "final.enc"), true},
+		{filepath.Join(vaultDir, "audit", "final.enc"), true},
+		{vaultDir + "-backup.enc", false},
+		{existing, true},
+	}
+	for _, tt := range tests {
+		err := checkKeepBackupPath(tt.path, vaultDir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkKeepBackupPath(%q) = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+var vaultInfoJSON bool
+
+func init() {
+	vaultCmd.AddCommand(vaultInfoCmd)
+
+	vaultInfoCmd.Flags().BoolVar(&vaultInfoJSON, "json", false, "Output in JSON format")
+}
+
+// vaultInfoCmd prints the identity and format of the vault
+var vaultInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show the vault's ID, schema, cipher and features",
+	Long: `Shows the vault's identity and on-disk format: its ID, when it was
+created, the database schema version, the cipher suite, the key derivation
+parameters and the features recorded in vault.meta. Nothing secret is
+shown, so the vault does not need to be unlocked.
+
+The ID is a UUID assigned when the vault is created. It is recorded in
+audit events and backup headers, so inventories, logs and backups
+collected from several machines can be matched to their vault. A vault
+created by an older version gets its ID when it is next unlocked.
+
+Examples:
+  secretctl vault info
+  secretctl vault info --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := v.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read vault info: %w", err)
+		}
+		if vaultInfoJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+
+		id := info.ID
+		if id == "" {
+			id = "(none yet, unlock the vault once to assign one)"
+		}
+		fmt.Printf("%-14s %s\n", "ID:", id)
+		fmt.Printf("%-14s %s\n", "Path:", info.Path)
+		fmt.Printf("%-14s %s\n", "Created:", tf.Time(info.CreatedAt))
+		fmt.Printf("%-14s %s\n", "Format:", info.FormatVersion)
+		fmt.Printf("%-14s %d (this version supports up to %d)\n", "Schema:", info.SchemaVersion, vault.CurrentSchemaVersion)
+		fmt.Printf("%-14s %s\n", "Cipher:", info.Cipher)
+		fmt.Printf("%-14s %s\n", "KDF:", info.KDF)
+		if info.KeyRotatedAt != nil {
+			fmt.Printf("%-14s %s\n", "Key rotated:", tf.Time(*info.KeyRotatedAt))
+		}
+		if len(info.Features) == 0 {
+			fmt.Printf("%-14s %s\n", "Features:", "(none recorded)")
+		} else {
+			fmt.Println("Features:")
+			for _, name := range slices.Sorted(maps.Keys(info.Features)) {
+				fmt.Printf("  %s = %s\n", name, info.Features[name])
+			}
+		}
+		if !info.Supported {
+			fmt.Println("Warning: this version of secretctl cannot open the vault; upgrade secretctl")
+		}
+		return nil
+	},
+}
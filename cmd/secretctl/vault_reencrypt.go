@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func init() {
+	vaultCmd.AddCommand(vaultReencryptCmd)
+}
+
+// vaultReencryptCmd re-encrypts the vault contents with fresh nonces
+var vaultReencryptCmd = &cobra.Command{
+	Use:   "reencrypt",
+	Short: "Re-encrypt every secret with fresh nonces",
+	Long: `Re-encrypts every secret, its metadata and its change history under the
+current data key with fresh nonces, showing progress as it goes.
+
+The work is committed in batches. If it is interrupted (Ctrl+C, crash,
+power loss) the vault stays fully readable, the next unlock warns about
+the unfinished run, and running the command again continues where it
+stopped.
+
+Examples:
+  secretctl vault reencrypt`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := ensureUnlocked(); err != nil {
+			return err
+		}
+		defer v.Lock()
+
+		pending, err := v.PendingRewrites()
+		if err != nil {
+			return err
+		}
+		for _, p := range pending {
+			if p.Op == vault.OpReencrypt {
+				fmt.Printf("Resuming interrupted run at %.0f%%\n", p.Percent())
+			}
+		}
+
+		bar := newProgressPrinter("Re-encrypting")
+		err = v.Reencrypt(bar.Update)
+		bar.Done()
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt vault: %w", err)
+		}
+		fmt.Println("Vault re-encrypted")
+		return nil
+	},
+}
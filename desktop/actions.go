@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/backup"
+)
+
+// ActionInfo describes an action that ExecuteAction can run, for the
+// command palette and for automation driving the app.
+type ActionInfo struct {
+	ID             string        `json:"id"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description"`
+	Params         []ActionParam `json:"params"`
+	RequiresUnlock bool          `json:"requiresUnlock"`
+}
+
+// ActionParam describes a parameter of an action.
+type ActionParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// ActionResult is the outcome of ExecuteAction.
+type ActionResult struct {
+	Message  string            `json:"message,omitempty"`
+	Navigate string            `json:"navigate,omitempty"` // page the frontend should show, e.g. "audit"
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// action is an entry of the action registry.
+type action struct {
+	ActionInfo
+	run func(a *App, params map[string]string) (*ActionResult, error)
+}
+
+// actions is the registry behind ExecuteAction, in the order ListActions
+// returns it.
+var actions = []action{
+	{
+		ActionInfo: ActionInfo{
+			ID:             "vault.lock",
+			Title:          "Lock vault",
+			Description:    "Lock the vault and clear the clipboard. MCP servers sharing the session are locked too.",
+			RequiresUnlock: true,
+		},
+		run: func(a *App, _ map[string]string) (*ActionResult, error) {
+			if err := a.Lock(); err != nil {
+				return nil, err
+			}
+			return &ActionResult{Message: "Vault locked"}, nil
+		},
+	},
+	{
+		ActionInfo: ActionInfo{
+			ID:          "backup.create",
+			Title:       "Back up now",
+			Description: "Write an encrypted backup to the vault's backup directory (backup_dir). Without a password or key file a passphrase is generated and copied to the clipboard.",
+			Params: []ActionParam{
+				{Name: "password", Description: "Backup password"},
+				{Name: "keyFile", Description: "Key file to encrypt the backup with instead of a password"},
+				{Name: "withAudit", Description: `"true" to include the audit log`},
+			},
+			RequiresUnlock: true,
+		},
+		run: (*App).backupNow,
+	},
+	{
+		ActionInfo: ActionInfo{
+			ID:          "secret.copyField",
+			Title:       "Copy field",
+			Description: "Copy a field of a secret to the clipboard, which is cleared after 30 seconds.",
+			Params: []ActionParam{
+				{Name: "key", Description: "Secret key", Required: true},
+				{Name: "field", Description: "Field name", Required: true},
+			},
+			RequiresUnlock: true,
+		},
+		run: func(a *App, params map[string]string) (*ActionResult, error) {
+			if err := a.CopyFieldValue(params["key"], params["field"]); err != nil {
+				return nil, err
+			}
+			return &ActionResult{Message: fmt.Sprintf("Copied %s of %s", params["field"], params["key"])}, nil
+		},
+	},
+	{
+		ActionInfo: ActionInfo{
+			ID:             "audit.open",
+			Title:          "Open audit log",
+			Description:    "Show the audit log.",
+			RequiresUnlock: true,
+		},
+		run: func(*App, map[string]string) (*ActionResult, error) {
+			return &ActionResult{Navigate: "audit"}, nil
+		},
+	},
+	{
+		ActionInfo: ActionInfo{
+			ID:             "mcp.start",
+			Title:          "Share session with MCP servers",
+			Description:    "Serve the unlocked vault on the agent socket, restarting it if it stopped, so that MCP servers started without SECRETCTL_PASSWORD can join after approval.",
+			RequiresUnlock: true,
+		},
+		run: (*App).startMCPSharing,
+	},
+}
+
+// ListActions returns the actions ExecuteAction can run.
+func (a *App) ListActions() []ActionInfo {
+	infos := make([]ActionInfo, len(actions))
+	for i, act := range actions {
+		infos[i] = act.ActionInfo
+	}
+	return infos
+}
+
+// ExecuteAction runs the action actionID with params, which must be
+// parameters the action declares.
+func (a *App) ExecuteAction(actionID string, params map[string]string) (*ActionResult, error) {
+	i := slices.IndexFunc(actions, func(act action) bool { return act.ID == actionID })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown action %q", actionID)
+	}
+	act := actions[i]
+
+	for name := range params {
+		if !slices.ContainsFunc(act.Params, func(p ActionParam) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("action %s has no parameter %q", act.ID, name)
+		}
+	}
+	for _, p := range act.Params {
+		if p.Required && params[p.Name] == "" {
+			return nil, fmt.Errorf("action %s requires parameter %q", act.ID, p.Name)
+		}
+	}
+	if act.RequiresUnlock && !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	if params == nil {
+		params = map[string]string{}
+	}
+	return act.run(a, params)
+}
+
+// backupNow writes a backup to the configured backup directory, named as
+// by `secretctl backup`.
+func (a *App) backupNow(params map[string]string) (*ActionResult, error) {
+	settings, err := a.vault.Settings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.BackupDir == "" {
+		return nil, errors.New("no backup directory configured; set backup_dir with `secretctl config set backup_dir <dir>`")
+	}
+	if err := os.MkdirAll(settings.BackupDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	opts := backup.BackupOptions{
+		IncludeAudit: params["withAudit"] == "true",
+		KeyFile:      params["keyFile"],
+		ToolVersion:  version,
+	}
+	var passphrase string
+	switch {
+	case opts.KeyFile != "":
+	case params["password"] != "":
+		opts.Password = []byte(params["password"])
+	default:
+		if passphrase, err = backup.GeneratePassphrase(); err != nil {
+			return nil, err
+		}
+		opts.Password = []byte(passphrase)
+	}
+
+	path := filepath.Join(settings.BackupDir, backup.FileName(time.Now()))
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	opts.Output = out
+	err = backup.Backup(a.vault, opts)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+
+	result := &ActionResult{Message: "Backup written to " + path, Data: map[string]string{"path": path}}
+	if passphrase != "" {
+		if err := a.CopyToClipboard(passphrase); err != nil {
+			return nil, fmt.Errorf("backup written to %s, but copying its passphrase failed: %w", path, err)
+		}
+		result.Message += "; its passphrase is on the clipboard for 30 seconds, store it now"
+	}
+	return result, nil
+}
+
+// startMCPSharing (re)starts serving the unlocked vault to MCP servers.
+func (a *App) startMCPSharing(map[string]string) (*ActionResult, error) {
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	a.stopSharedSession()
+	a.startSharedSession(a.vault)
+
+	socket := agent.DefaultSocketPath(a.vaultDir)
+	return &ActionResult{
+		Message: "Sharing the unlocked vault on " + socket,
+		Data:    map[string]string{"socket": socket},
+	}, nil
+}
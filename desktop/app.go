@@ -12,20 +12,35 @@ import (
 	"sync"
 	"time"
 
+	"github.com/forest6511/secretctl/pkg/agent"
 	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/i18n"
+	"github.com/forest6511/secretctl/pkg/timefmt"
 	"github.com/forest6511/secretctl/pkg/vault"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct - Wails binds this to the frontend
 type App struct {
-	ctx          context.Context
-	vault        *vault.Vault
-	vaultDir     string
-	unlocked     bool
-	lastActivity time.Time
-	activityMu   sync.Mutex
-	stateMu      sync.Mutex // Protects vault and unlocked fields
+	ctx      context.Context
+	vault    *vault.Vault
+	vaultDir string
+	unlocked bool
+	stateMu  sync.Mutex // Protects vault and unlocked fields
+
+	// sharedSession serves the unlocked vault to approved MCP servers
+	sharedSession *agent.Server
+	stopShared    context.CancelFunc
+	stopEvents    func() // Stops forwarding vault events to the frontend
+
+	// edits holds the versions of secrets being edited, by revision token
+	// (see BeginEdit)
+	edits  map[string]*vault.SecretEntry
+	editMu sync.Mutex
+
+	// pendingUpdate is the release found by the automatic update check
+	pendingUpdate *UpdateInfo
+	updateMu      sync.Mutex
 }
 
 // NewApp creates a new App application struct
@@ -38,10 +53,8 @@ func NewApp(vaultDir string) *App {
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
-	a.lastActivity = time.Now()
 
-	// Start idle timeout watcher
-	go a.watchIdleTimeout()
+	go a.startUpdater(ctx)
 }
 
 // shutdown is called at app termination
@@ -49,42 +62,23 @@ func (a *App) shutdown(ctx context.Context) {
 	// Clear clipboard before exit to prevent secret leakage
 	a.ClearClipboard()
 
+	a.stateMu.Lock()
+	a.stopSharedSession()
+	a.stopVaultEvents()
+	a.stateMu.Unlock()
+
 	if a.vault != nil {
 		a.vault.Lock()
 	}
 }
 
-// watchIdleTimeout monitors for idle and auto-locks vault
-func (a *App) watchIdleTimeout() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			a.activityMu.Lock()
-			idle := time.Since(a.lastActivity)
-			a.activityMu.Unlock()
-
-			a.stateMu.Lock()
-			isUnlocked := a.unlocked
-			a.stateMu.Unlock()
-
-			if isUnlocked && idle > 15*time.Minute {
-				a.Lock()
-				runtime.EventsEmit(a.ctx, "vault:locked")
-			}
-		case <-a.ctx.Done():
-			return
-		}
-	}
-}
-
-// ResetIdleTimer is called on user activity
+// ResetIdleTimer is called on user activity, postponing the auto-lock
 func (a *App) ResetIdleTimer() {
-	a.activityMu.Lock()
-	a.lastActivity = time.Now()
-	a.activityMu.Unlock()
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	if a.vault != nil {
+		a.vault.Touch()
+	}
 }
 
 // ============================================================================
@@ -105,30 +99,10 @@ func (a *App) CheckVaultExists() bool {
 
 // InitVault creates a new vault with master password
 func (a *App) InitVault(password string) error {
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters")
-	}
-
 	if a.CheckVaultExists() {
 		return errors.New("vault already exists")
 	}
-
-	// Create vault instance
-	v := vault.New(a.vaultDir)
-	if err := v.Init(password); err != nil {
-		return err
-	}
-
-	// Unlock immediately after init
-	if err := v.Unlock(password); err != nil {
-		return err
-	}
-
-	a.vault = v
-	a.unlocked = true
-	a.lastActivity = time.Now()
-
-	return nil
+	return a.CreateVault("", password, KDFSettings{})
 }
 
 // Unlock unlocks the vault with master password
@@ -141,17 +115,25 @@ func (a *App) Unlock(password string) error {
 	}
 
 	v := vault.New(a.vaultDir)
+	v.SetAuditSource(audit.SourceUI)
 	if err := v.Unlock(password); err != nil {
-		return errors.New("invalid password")
+		return errors.New(i18n.T("invalid password"))
 	}
 
 	a.vault = v
 	a.unlocked = true
-	a.lastActivity = time.Now()
+	a.forwardVaultEvents(v)
+	a.startSharedSession(v)
 
 	return nil
 }
 
+// SetLocale selects the language of errors returned to the frontend
+// ("en" or "ja"). The frontend calls it when its language changes.
+func (a *App) SetLocale(locale string) {
+	i18n.SetLocale(locale)
+}
+
 // Lock locks the vault and clears clipboard
 func (a *App) Lock() error {
 	a.stateMu.Lock()
@@ -164,9 +146,13 @@ func (a *App) Lock() error {
 	// Clear clipboard before locking to prevent secret leakage
 	a.ClearClipboard()
 
+	a.stopSharedSession()
+	a.stopVaultEvents()
+	a.vault.SetAutoLock(0)
 	a.vault.Lock()
 	a.vault = nil
 	a.unlocked = false
+	a.clearEdits()
 
 	return nil
 }
@@ -221,7 +207,7 @@ func (a *App) ChangePassword(currentPassword, newPassword, confirmPassword strin
 	}
 
 	// Perform the password change
-	if err := a.vault.ChangePassword(currentPassword, newPassword); err != nil {
+	if err := a.vault.ChangeMasterPassword(currentPassword, newPassword); err != nil {
 		// Map specific errors to user-friendly messages
 		if errors.Is(err, vault.ErrInvalidPassword) {
 			return PasswordChangeResult{
@@ -235,20 +221,30 @@ func (a *App) ChangePassword(currentPassword, newPassword, confirmPassword strin
 				Message: "New password must be different from current password",
 			}
 		}
+		if errors.Is(err, vault.ErrCooldownActive) {
+			return PasswordChangeResult{
+				Success: false,
+				Message: "Too many incorrect attempts, please wait and try again",
+			}
+		}
 		return PasswordChangeResult{
 			Success: false,
 			Message: "Failed to change password: " + err.Error(),
 		}
 	}
 
-	// Update activity timestamp
-	a.lastActivity = time.Now()
+	// Recovery kits and emergency contacts hold the DEK, which a password
+	// change keeps
+	warnings := validation.Warnings
+	if artifacts, err := a.vault.RecoveryArtifacts(); err == nil && len(artifacts) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d recovery kit(s) or emergency contact(s) still open the vault without the new password; run 'secretctl rekey' to invalidate them", len(artifacts)))
+	}
 
 	return PasswordChangeResult{
 		Success:  true,
 		Message:  "Password changed successfully",
 		Strength: string(validation.Strength),
-		Warnings: validation.Warnings,
+		Warnings: warnings,
 	}
 }
 
@@ -275,6 +271,9 @@ type FieldDTO struct {
 	Kind      string   `json:"kind,omitempty"`
 	InputType string   `json:"inputType,omitempty"` // "text" (default) | "textarea" per ADR-005
 	Hint      string   `json:"hint,omitempty"`
+	Validator string   `json:"validator,omitempty"`
+	Masked    string   `json:"masked,omitempty"` // preview of sensitive values per the vault's mask policy
+	Ref       string   `json:"ref,omitempty"`    // ref:// reference Value was read from
 }
 
 // Secret represents a secret for frontend
@@ -289,6 +288,8 @@ type Secret struct {
 	Tags       []string            `json:"tags,omitempty"`
 	CreatedAt  string              `json:"createdAt"`
 	UpdatedAt  string              `json:"updatedAt"`
+	ExpiresAt  string              `json:"expiresAt,omitempty"` // in the configured time zone
+	ExpiresIn  string              `json:"expiresIn,omitempty"` // localized, e.g. "expires in 3 days"
 }
 
 // SecretListItem represents a secret in list view (no value)
@@ -312,10 +313,39 @@ func (a *App) ListSecrets() ([]SecretListItem, error) {
 	if err != nil {
 		return nil, err
 	}
+	return a.secretListItems(keys), nil
+}
 
+// SecretListPage is one page of the secret list
+type SecretListPage struct {
+	Items      []SecretListItem `json:"items"`
+	NextCursor string           `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// ListSecretsPage returns up to limit secrets after cursor, so that large
+// vaults can be listed a page at a time. Pass "" for the first page.
+func (a *App) ListSecretsPage(cursor string, limit int) (*SecretListPage, error) {
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+
+	page, err := a.vault.ListSecretsPage(cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(page.Secrets))
+	for _, entry := range page.Secrets {
+		keys = append(keys, entry.Key)
+	}
+	return &SecretListPage{Items: a.secretListItems(keys), NextCursor: page.NextCursor}, nil
+}
+
+// secretListItems builds the list view items of keys
+func (a *App) secretListItems(keys []string) []SecretListItem {
 	items := make([]SecretListItem, 0, len(keys))
 	for _, key := range keys {
-		entry, err := a.vault.GetSecret(key)
+		// Listing shows metadata only, so expired and deprecated secrets stay visible
+		entry, err := a.vault.InspectSecret(key)
 		if err != nil {
 			continue
 		}
@@ -343,8 +373,7 @@ func (a *App) ListSecrets() ([]SecretListItem, error) {
 			HasURL:       hasURL,
 		})
 	}
-
-	return items, nil
+	return items
 }
 
 // GetSecret returns a secret with its value
@@ -357,7 +386,11 @@ func (a *App) GetSecret(key string) (*Secret, error) {
 	if err != nil {
 		return nil, err
 	}
+	return a.secretDTO(entry), nil
+}
 
+// secretDTO converts a secret read from the vault for the frontend
+func (a *App) secretDTO(entry *vault.SecretEntry) *Secret {
 	notes := ""
 	url := ""
 	if entry.Metadata != nil {
@@ -369,6 +402,14 @@ func (a *App) GetSecret(key string) (*Secret, error) {
 	fields := make(map[string]FieldDTO)
 	var fieldOrder []string
 
+	policy := a.vault.MaskPolicyFor(entry.Key)
+	masked := func(value string, sensitive bool) string {
+		if !sensitive {
+			return ""
+		}
+		return policy.Mask([]byte(value))
+	}
+
 	if len(entry.Fields) > 0 {
 		// Multi-field secret
 		for name, field := range entry.Fields {
@@ -379,6 +420,9 @@ func (a *App) GetSecret(key string) (*Secret, error) {
 				Kind:      field.Kind,
 				InputType: field.InputType,
 				Hint:      field.Hint,
+				Validator: field.Validator,
+				Masked:    masked(field.Value, field.Sensitive),
+				Ref:       entry.References[name],
 			}
 			fieldOrder = append(fieldOrder, name)
 		}
@@ -389,6 +433,7 @@ func (a *App) GetSecret(key string) (*Secret, error) {
 		fields["value"] = FieldDTO{
 			Value:     string(entry.Value),
 			Sensitive: true, // Legacy values are treated as sensitive
+			Masked:    masked(string(entry.Value), true),
 		}
 		fieldOrder = []string{"value"}
 	}
@@ -399,7 +444,7 @@ func (a *App) GetSecret(key string) (*Secret, error) {
 		bindings[k] = v
 	}
 
-	return &Secret{
+	secret := &Secret{
 		Key:        entry.Key,
 		Value:      string(entry.Value), // Keep for backward compatibility
 		Fields:     fields,
@@ -410,7 +455,37 @@ func (a *App) GetSecret(key string) (*Secret, error) {
 		Tags:       entry.Tags,
 		CreatedAt:  entry.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:  entry.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	}
+	if expiry := entry.Expiry(); expiry != nil {
+		tf := a.timeFormatter()
+		secret.ExpiresAt = tf.Time(*expiry)
+		secret.ExpiresIn = tf.Expiry(*expiry, time.Now())
+	}
+	return secret
+}
+
+// FormatRelativeTime formats an RFC 3339 timestamp relative to now in the
+// configured locale ("3 days ago", "3日前"). Invalid input is returned as is.
+func (a *App) FormatRelativeTime(ts string) string {
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return ts
+	}
+	return a.timeFormatter().Relative(t, time.Now())
+}
+
+// timeFormatter returns a formatter for the vault's timezone and locale
+// settings; SECRETCTL_TIMEZONE and SECRETCTL_LOCALE take precedence.
+func (a *App) timeFormatter() *timefmt.Formatter {
+	opts := timefmt.OptionsFromEnv()
+	if settings, err := a.vault.Settings(); err == nil {
+		opts = opts.Merge(timefmt.Options{Timezone: settings.Timezone, Locale: settings.Locale})
+	}
+	tf, err := timefmt.New(opts)
+	if err != nil {
+		return timefmt.Default()
+	}
+	return tf
 }
 
 // CreateSecret creates a new secret
@@ -515,18 +590,8 @@ func validateSecretDTO(dto SecretUpdateDTO) error {
 	return nil
 }
 
-// UpdateSecretMultiField updates a secret with multi-field support
-func (a *App) UpdateSecretMultiField(dto SecretUpdateDTO) error {
-	if !a.unlocked {
-		return errors.New("vault locked")
-	}
-
-	// Validate input
-	if err := validateSecretDTO(dto); err != nil {
-		return err
-	}
-
-	// Convert FieldDTO to vault.Field
+// secretEntryFromDTO converts a secret edited in the frontend for the vault
+func secretEntryFromDTO(dto SecretUpdateDTO) *vault.SecretEntry {
 	fields := make(map[string]vault.Field)
 	for name, fieldDTO := range dto.Fields {
 		fields[name] = vault.Field{
@@ -536,6 +601,7 @@ func (a *App) UpdateSecretMultiField(dto SecretUpdateDTO) error {
 			Kind:      fieldDTO.Kind,
 			InputType: fieldDTO.InputType,
 			Hint:      fieldDTO.Hint,
+			Validator: fieldDTO.Validator,
 		}
 	}
 
@@ -552,6 +618,21 @@ func (a *App) UpdateSecretMultiField(dto SecretUpdateDTO) error {
 			URL:   dto.URL,
 		}
 	}
+	return entry
+}
+
+// UpdateSecretMultiField updates a secret with multi-field support
+func (a *App) UpdateSecretMultiField(dto SecretUpdateDTO) error {
+	if !a.unlocked {
+		return errors.New("vault locked")
+	}
+
+	// Validate input
+	if err := validateSecretDTO(dto); err != nil {
+		return err
+	}
+
+	entry := secretEntryFromDTO(dto)
 
 	// Persist first, then audit log (audit after success)
 	if err := a.vault.SetSecret(dto.Key, entry); err != nil {
@@ -577,7 +658,7 @@ func (a *App) UpdateSecretMultiField(dto SecretUpdateDTO) error {
 		dto.Key,
 		nil,
 		map[string]interface{}{
-			"field_count":   len(fields),
+			"field_count":   len(entry.Fields),
 			"binding_count": len(dto.Bindings),
 		},
 	); err != nil {
@@ -599,39 +680,14 @@ func (a *App) CreateSecretMultiField(dto SecretUpdateDTO) error {
 	}
 
 	// Check if secret already exists (only treat ErrSecretNotFound as expected)
-	_, err := a.vault.GetSecret(dto.Key)
+	_, err := a.vault.InspectSecret(dto.Key)
 	if err == nil {
 		return errors.New("secret already exists")
 	}
 	// Note: We proceed with creation even if GetSecret returns other errors
 	// because the vault may not have any secrets yet or the secret simply doesn't exist
 
-	// Convert FieldDTO to vault.Field
-	fields := make(map[string]vault.Field)
-	for name, fieldDTO := range dto.Fields {
-		fields[name] = vault.Field{
-			Value:     fieldDTO.Value,
-			Sensitive: fieldDTO.Sensitive,
-			Aliases:   fieldDTO.Aliases,
-			Kind:      fieldDTO.Kind,
-			InputType: fieldDTO.InputType,
-			Hint:      fieldDTO.Hint,
-		}
-	}
-
-	entry := &vault.SecretEntry{
-		Key:      dto.Key,
-		Fields:   fields,
-		Bindings: dto.Bindings,
-		Tags:     dto.Tags,
-	}
-
-	if dto.Notes != "" || dto.URL != "" {
-		entry.Metadata = &vault.SecretMetadata{
-			Notes: dto.Notes,
-			URL:   dto.URL,
-		}
-	}
+	entry := secretEntryFromDTO(dto)
 
 	// Persist first, then audit log (audit after success)
 	if err := a.vault.SetSecret(dto.Key, entry); err != nil {
@@ -657,7 +713,7 @@ func (a *App) CreateSecretMultiField(dto SecretUpdateDTO) error {
 		dto.Key,
 		nil,
 		map[string]interface{}{
-			"field_count":   len(fields),
+			"field_count":   len(entry.Fields),
 			"binding_count": len(dto.Bindings),
 		},
 	); err != nil {
@@ -965,6 +1021,7 @@ type TemplateFieldInfo struct {
 	Sensitive bool   `json:"sensitive"`
 	Hint      string `json:"hint"`
 	InputType string `json:"inputType,omitempty"` // "text" (default) | "textarea" per ADR-005
+	Validator string `json:"validator,omitempty"` // see vault.Validators
 }
 
 // TemplateInfo represents a secret template
@@ -1031,10 +1088,25 @@ func (a *App) GetTemplates() []TemplateInfo {
 			Description: "SSH private key and passphrase",
 			Icon:        "terminal",
 			Fields: []TemplateFieldInfo{
-				{Name: "private_key", Sensitive: true, Hint: "SSH private key", InputType: "textarea"},
+				{Name: "private_key", Sensitive: true, Hint: "SSH private key", InputType: "textarea", Validator: vault.ValidatorPEM},
 				{Name: "passphrase", Sensitive: true, Hint: "Key passphrase (optional)"},
 			},
 			Bindings: map[string]string{},
 		},
+		{
+			ID:          "aws",
+			Name:        "AWS",
+			Description: "AWS access key",
+			Icon:        "cloud",
+			Fields: []TemplateFieldInfo{
+				{Name: "access_key_id", Sensitive: false, Hint: "Access key ID (AKIA...)", Validator: vault.ValidatorAWSAccessKeyID},
+				{Name: "secret_access_key", Sensitive: true, Hint: "Secret access key"},
+				{Name: "region", Sensitive: false, Hint: "Default region (optional)"},
+			},
+			Bindings: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "access_key_id",
+				"AWS_SECRET_ACCESS_KEY": "secret_access_key",
+			},
+		},
 	}
 }
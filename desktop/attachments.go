@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// AttachmentInfo describes a file attached to a secret
+type AttachmentInfo struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"createdAt"`
+}
+
+func attachmentInfo(a *vault.Attachment) AttachmentInfo {
+	return AttachmentInfo{Name: a.Name, Size: a.Size, CreatedAt: a.CreatedAt.Format(time.RFC3339)}
+}
+
+// ListAttachments returns the attachments of a secret, sorted by name
+func (a *App) ListAttachments(key string) ([]AttachmentInfo, error) {
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	attachments, err := a.vault.ListAttachments(key)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]AttachmentInfo, 0, len(attachments))
+	for _, att := range attachments {
+		infos = append(infos, attachmentInfo(att))
+	}
+	return infos, nil
+}
+
+// UploadAttachment lets the user pick a file and attaches it to a secret
+// under its file name, replacing an attachment of the same name. It
+// returns nil if the user cancels.
+func (a *App) UploadAttachment(key string) (*AttachmentInfo, error) {
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: fmt.Sprintf("Attach a file to %s", key),
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	if err := a.vault.AddAttachment(key, name, f); err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &AttachmentInfo{Name: name, Size: info.Size(), CreatedAt: time.Now().Format(time.RFC3339)}, nil
+}
+
+// DownloadAttachment decrypts an attachment and saves it where the user
+// chooses, readable only by the user. It returns the path written, or ""
+// if the user cancels.
+func (a *App) DownloadAttachment(key, name string) (string, error) {
+	if !a.unlocked {
+		return "", errors.New("vault locked")
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save attachment",
+		DefaultFilename: name,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	r, err := a.vault.OpenAttachmentReader(key, name)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	// The dialog has already asked whether to replace an existing file
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// DeleteAttachment removes an attachment from a secret
+func (a *App) DeleteAttachment(key, name string) error {
+	if !a.unlocked {
+		return errors.New("vault locked")
+	}
+	return a.vault.RemoveAttachment(key, name)
+}
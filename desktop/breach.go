@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/forest6511/secretctl/pkg/breach"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// BreachResult is a password field found in known breaches
+type BreachResult struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+// BreachReport is the result of a breach check
+type BreachReport struct {
+	Checked     int            `json:"checked"`
+	Compromised []BreachResult `json:"compromised"`
+}
+
+// CheckBreaches looks up the password fields of all secrets in Have I Been
+// Pwned. It only runs when the user asks for it, and only the first five
+// characters of each SHA-1 hash are sent (see package breach).
+func (a *App) CheckBreaches() (*BreachReport, error) {
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	keys, err := a.vault.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*vault.SecretEntry, 0, len(keys))
+	for _, key := range keys {
+		entry, err := a.vault.InspectSecret(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret '%s': %w", key, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	report, err := breach.NewChecker().Scan(a.ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	result := &BreachReport{Checked: report.Checked, Compromised: []BreachResult{}}
+	for _, r := range report.Compromised {
+		result.Compromised = append(result.Compromised, BreachResult(r))
+	}
+	return result, nil
+}
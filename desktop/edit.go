@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Kinds of MergeItem
+const (
+	mergeField    = "field"
+	mergeNotes    = "notes"
+	mergeURL      = "url"
+	mergeTags     = "tags"
+	mergeBindings = "bindings"
+)
+
+// EditConflict is returned by CommitEdit when the secret was changed, by
+// another window or the CLI, after BeginEdit. The frontend shows a merge
+// dialog and commits the result with Token.
+type EditConflict struct {
+	Key     string      `json:"key"`
+	Token   string      `json:"token"`            // revision of the current version
+	Deleted bool        `json:"deleted"`          // the secret was deleted; committing recreates it
+	Theirs  *Secret     `json:"theirs,omitempty"` // the current version as stored
+	Changes []MergeItem `json:"changes"`          // parts that differ between the edit and the current version
+}
+
+// MergeItem is one part of a secret that differs between the edit (Mine)
+// and the current version (Theirs). Nil values mean the field does not
+// exist on that side. Base is the version the edit started from.
+type MergeItem struct {
+	Kind      string  `json:"kind"`           // field, notes, url, tags or bindings
+	Name      string  `json:"name,omitempty"` // field name, for kind "field"
+	Base      *string `json:"base,omitempty"`
+	Mine      *string `json:"mine,omitempty"`
+	Theirs    *string `json:"theirs,omitempty"`
+	Sensitive bool    `json:"sensitive"`
+	// Conflict is set when both sides changed the part; otherwise the
+	// changed side can be taken as is.
+	Conflict bool `json:"conflict"`
+}
+
+// BeginEdit starts editing a secret and returns its revision token for
+// CommitEdit. The version being edited is kept until the vault is locked,
+// to tell a merge which side changed what.
+func (a *App) BeginEdit(key string) (string, error) {
+	if !a.unlocked {
+		return "", errors.New("vault locked")
+	}
+	entry, err := a.vault.InspectSecret(key)
+	if err != nil {
+		return "", err
+	}
+	token := entry.Revision()
+	a.rememberEdit(token, entry)
+	return token, nil
+}
+
+// CommitEdit saves a secret edited since BeginEdit returned token. If the
+// secret was changed in the meantime nothing is saved and the conflict is
+// returned instead.
+func (a *App) CommitEdit(key string, dto SecretUpdateDTO, token string) (*EditConflict, error) {
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	if dto.Key != key {
+		return nil, fmt.Errorf("cannot rename secret '%s' while editing it", key)
+	}
+	if err := validateSecretDTO(dto); err != nil {
+		return nil, err
+	}
+
+	base := a.editBase(token)
+	entry := secretEntryFromDTO(dto)
+	if base != nil {
+		// Keep what the editor does not show
+		entry.FolderID, entry.Schema, entry.Immutable = base.FolderID, base.Schema, base.Immutable
+		entry.ExpiresAt, entry.NotBefore, entry.AccessWindow = base.ExpiresAt, base.NotBefore, base.AccessWindow
+		if m := base.Metadata; m != nil && (m.Owner != "" || m.Team != "") {
+			if entry.Metadata == nil {
+				entry.Metadata = &vault.SecretMetadata{}
+			}
+			entry.Metadata.Owner, entry.Metadata.Team = m.Owner, m.Team
+		}
+	}
+
+	err := a.vault.SetSecretIfRevision(key, entry, token)
+	var changed *vault.ChangedError
+	if errors.As(err, &changed) {
+		a.rememberEdit(changed.Revision, changed.Current)
+		return a.editConflict(key, base, entry, changed), nil
+	}
+	if err != nil {
+		_ = a.vault.AuditLogger().Log("secret.updated", "desktop", audit.ResultError, key, nil,
+			map[string]interface{}{"error": err.Error()})
+		return nil, err
+	}
+
+	a.forgetEdit(token)
+	return nil, a.vault.AuditLogger().Log("secret.updated", "desktop", audit.ResultSuccess, key, nil,
+		map[string]interface{}{
+			"field_count":   len(entry.Fields),
+			"binding_count": len(entry.Bindings),
+		})
+}
+
+// rememberEdit keeps the version of a secret with revision token.
+func (a *App) rememberEdit(token string, entry *vault.SecretEntry) {
+	if entry == nil {
+		return
+	}
+	a.editMu.Lock()
+	defer a.editMu.Unlock()
+	if a.edits == nil {
+		a.edits = make(map[string]*vault.SecretEntry)
+	}
+	a.edits[token] = entry
+}
+
+func (a *App) editBase(token string) *vault.SecretEntry {
+	a.editMu.Lock()
+	defer a.editMu.Unlock()
+	return a.edits[token]
+}
+
+func (a *App) forgetEdit(token string) {
+	a.editMu.Lock()
+	defer a.editMu.Unlock()
+	delete(a.edits, token)
+}
+
+// clearEdits forgets the versions kept for edits, which hold secret values.
+func (a *App) clearEdits() {
+	a.editMu.Lock()
+	defer a.editMu.Unlock()
+	a.edits = nil
+}
+
+// editConflict describes how mine, the rejected edit of base, differs from
+// the current version.
+func (a *App) editConflict(key string, base, mine *vault.SecretEntry, changed *vault.ChangedError) *EditConflict {
+	theirs := changed.Current
+	conflict := &EditConflict{Key: key, Token: changed.Revision, Deleted: theirs == nil, Changes: []MergeItem{}}
+	if theirs != nil {
+		conflict.Theirs = a.secretDTO(theirs)
+	}
+
+	add := func(kind, name string, sensitive bool, part func(*vault.SecretEntry) *string) {
+		item := MergeItem{Kind: kind, Name: name, Base: part(base), Mine: part(mine), Theirs: part(theirs), Sensitive: sensitive}
+		if equalPart(item.Mine, item.Theirs) {
+			return
+		}
+		// Without the base version every difference is a conflict
+		item.Conflict = base == nil || (!equalPart(item.Mine, item.Base) && !equalPart(item.Theirs, item.Base))
+		conflict.Changes = append(conflict.Changes, item)
+	}
+
+	names := slices.Collect(maps.Keys(mine.Fields))
+	if theirs != nil {
+		names = append(names, slices.Collect(maps.Keys(theirs.Fields))...)
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		sensitive := mine.Fields[name].Sensitive
+		if theirs != nil {
+			sensitive = sensitive || theirs.Fields[name].Sensitive
+		}
+		add(mergeField, name, sensitive, func(e *vault.SecretEntry) *string {
+			if e == nil {
+				return nil
+			}
+			if f, ok := e.Fields[name]; ok {
+				return &f.Value
+			}
+			return nil
+		})
+	}
+	add(mergeNotes, "", false, func(e *vault.SecretEntry) *string {
+		return metadataPart(e, func(m *vault.SecretMetadata) string { return m.Notes })
+	})
+	add(mergeURL, "", false, func(e *vault.SecretEntry) *string {
+		return metadataPart(e, func(m *vault.SecretMetadata) string { return m.URL })
+	})
+	add(mergeTags, "", false, func(e *vault.SecretEntry) *string {
+		if e == nil {
+			return nil
+		}
+		tags := strings.Join(e.Tags, ", ")
+		return &tags
+	})
+	add(mergeBindings, "", false, func(e *vault.SecretEntry) *string {
+		if e == nil {
+			return nil
+		}
+		lines := make([]string, 0, len(e.Bindings))
+		for _, env := range slices.Sorted(maps.Keys(e.Bindings)) {
+			lines = append(lines, env+"="+e.Bindings[env])
+		}
+		bindings := strings.Join(lines, "\n")
+		return &bindings
+	})
+	return conflict
+}
+
+func metadataPart(e *vault.SecretEntry, part func(*vault.SecretMetadata) string) *string {
+	if e == nil {
+		return nil
+	}
+	value := ""
+	if e.Metadata != nil {
+		value = part(e.Metadata)
+	}
+	return &value
+}
+
+func equalPart(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// idleLockTimeout is how long the vault may go unused before it locks.
+const idleLockTimeout = 15 * time.Minute
+
+// forwardVaultEvents re-emits vault events to the frontend as
+// "vault:<type>" (e.g. "vault:secret_changed"), watches the vault files
+// for tampering and arms the vault's auto-lock until stopVaultEvents is
+// called. Must be called with stateMu held.
+func (a *App) forwardVaultEvents(v *vault.Vault) {
+	events, unsubscribe := v.Events()
+	watchCtx, stopWatch := context.WithCancel(a.ctx)
+	a.stopEvents = func() {
+		stopWatch()
+		unsubscribe()
+	}
+	go v.WatchFiles(watchCtx, vault.DefaultTamperCheckInterval, audit.SourceUI)
+	v.SetAutoLock(idleLockTimeout)
+	go func() {
+		for e := range events {
+			runtime.EventsEmit(a.ctx, "vault:"+string(e.Type), e)
+			if e.Type == vault.EventTamperSuspected || e.Type == vault.EventLocked {
+				// The vault locked itself; release the session as well
+				_ = a.Lock()
+			}
+		}
+	}()
+}
+
+// stopVaultEvents stops forwarding. Must be called with stateMu held.
+func (a *App) stopVaultEvents() {
+	if a.stopEvents != nil {
+		a.stopEvents()
+		a.stopEvents = nil
+	}
+}
@@ -5,6 +5,7 @@ import { AuditPage } from '@/pages/AuditPage'
 import { SettingsPage } from '@/pages/SettingsPage'
 import { CommandPalette } from '@/components/CommandPalette'
 import { KeyboardShortcutsHelp } from '@/components/KeyboardShortcutsHelp'
+import { UpdateBanner } from '@/components/UpdateBanner'
 import { ToastProvider } from '@/hooks/useToast'
 import { useKeyboardShortcuts } from '@/hooks/useKeyboardShortcuts'
 import { GetAuthStatus, Lock } from '../wailsjs/go/main/App'
@@ -39,7 +40,7 @@ function App() {
     }
   }
 
-  const handleNavigate = (page: 'secrets' | 'settings' | 'new-secret') => {
+  const handleNavigate = (page: 'secrets' | 'settings' | 'audit' | 'new-secret') => {
     // 'new-secret' maps to 'secrets' page (user creates via UI)
     if (page === 'new-secret') {
       setCurrentPage('secrets')
@@ -106,50 +107,59 @@ function App() {
     )
   }
 
-  if (!isAuthenticated) {
-    return (
-      <ToastProvider>
-        <AuthPage onAuthenticated={() => setIsAuthenticated(true)} />
-      </ToastProvider>
-    )
-  }
+  const renderPage = () => {
+    if (!isAuthenticated) {
+      return (
+        <ToastProvider>
+          <AuthPage onAuthenticated={() => setIsAuthenticated(true)} />
+        </ToastProvider>
+      )
+    }
 
-  if (currentPage === 'audit') {
-    return (
-      <ToastProvider>
-        <AuditPage onNavigateBack={() => setCurrentPage('secrets')} />
-      </ToastProvider>
-    )
-  }
+    if (currentPage === 'audit') {
+      return (
+        <ToastProvider>
+          <AuditPage onNavigateBack={() => setCurrentPage('secrets')} />
+        </ToastProvider>
+      )
+    }
+
+    if (currentPage === 'settings') {
+      return (
+        <ToastProvider>
+          <SettingsPage onNavigateBack={() => setCurrentPage('secrets')} />
+        </ToastProvider>
+      )
+    }
 
-  if (currentPage === 'settings') {
     return (
       <ToastProvider>
-        <SettingsPage onNavigateBack={() => setCurrentPage('secrets')} />
+        <SecretsPage
+          onLocked={() => setIsAuthenticated(false)}
+          onNavigateToAudit={() => setCurrentPage('audit')}
+          onNavigateToSettings={() => setCurrentPage('settings')}
+        />
+        <CommandPalette
+          open={commandPaletteOpen}
+          onOpenChange={setCommandPaletteOpen}
+          onNavigate={handleNavigate}
+          onLock={handleLock}
+          onFocusSearch={handleFocusSearch}
+          onShowHelp={() => setShortcutsHelpOpen(true)}
+        />
+        <KeyboardShortcutsHelp
+          open={shortcutsHelpOpen}
+          onOpenChange={setShortcutsHelpOpen}
+        />
       </ToastProvider>
     )
   }
 
   return (
-    <ToastProvider>
-      <SecretsPage
-        onLocked={() => setIsAuthenticated(false)}
-        onNavigateToAudit={() => setCurrentPage('audit')}
-        onNavigateToSettings={() => setCurrentPage('settings')}
-      />
-      <CommandPalette
-        open={commandPaletteOpen}
-        onOpenChange={setCommandPaletteOpen}
-        onNavigate={handleNavigate}
-        onLock={handleLock}
-        onFocusSearch={handleFocusSearch}
-        onShowHelp={() => setShortcutsHelpOpen(true)}
-      />
-      <KeyboardShortcutsHelp
-        open={shortcutsHelpOpen}
-        onOpenChange={setShortcutsHelpOpen}
-      />
-    </ToastProvider>
+    <>
+      {renderPage()}
+      <UpdateBanner />
+    </>
   )
 }
 
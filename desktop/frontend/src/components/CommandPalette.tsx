@@ -1,7 +1,10 @@
 import { useState, useEffect, useRef } from 'react'
 import { useTranslation } from 'react-i18next'
 import { Input } from '@/components/ui/input'
-import { X, Plus, Settings, Lock, Search, HelpCircle } from 'lucide-react'
+import { X, Plus, Settings, Lock, Search, HelpCircle, Archive, ScrollText, Share2, Zap } from 'lucide-react'
+import { useToast } from '@/hooks/useToast'
+import { ListActions, ExecuteAction } from '../../wailsjs/go/main/App'
+import { main } from '../../wailsjs/go/models'
 
 interface Command {
   id: string
@@ -14,12 +17,18 @@ interface Command {
 interface CommandPaletteProps {
   open: boolean
   onOpenChange: (open: boolean) => void
-  onNavigate: (page: 'secrets' | 'settings' | 'new-secret') => void
+  onNavigate: (page: 'secrets' | 'settings' | 'audit' | 'new-secret') => void
   onLock: () => void
   onFocusSearch: () => void
   onShowHelp: () => void
 }
 
+const actionIcons: Record<string, React.ReactNode> = {
+  'backup.create': <Archive className="h-4 w-4" />,
+  'audit.open': <ScrollText className="h-4 w-4" />,
+  'mcp.start': <Share2 className="h-4 w-4" />,
+}
+
 export function CommandPalette({
   open,
   onOpenChange,
@@ -31,8 +40,21 @@ export function CommandPalette({
   const { t } = useTranslation()
   const [search, setSearch] = useState('')
   const inputRef = useRef<HTMLInputElement>(null)
+  const toast = useToast()
+  const [backendActions, setBackendActions] = useState<main.ActionInfo[]>([])
 
-  const commands: Command[] = [
+  const runAction = async (id: string) => {
+    try {
+      const result = await ExecuteAction(id, {})
+      if (result.navigate === 'audit') onNavigate('audit')
+      if (result.message) toast.success(result.message)
+    } catch (err) {
+      console.error(`Action ${id} failed:`, err)
+      toast.error(String(err))
+    }
+  }
+
+  const localCommands: Command[] = [
     {
       id: 'new',
       name: t('commandPalette.newSecret', 'New Secret'),
@@ -70,6 +92,20 @@ export function CommandPalette({
     },
   ]
 
+  // Backend actions that run without parameters; locking stays local
+  // because the app has to leave the unlocked pages
+  const commands: Command[] = [
+    ...localCommands,
+    ...backendActions
+      .filter(a => a.id !== 'vault.lock' && !a.params?.some(p => p.required))
+      .map(a => ({
+        id: a.id,
+        name: t(`commandPalette.actions.${a.id}`, a.title),
+        icon: actionIcons[a.id] ?? <Zap className="h-4 w-4" />,
+        action: () => runAction(a.id),
+      })),
+  ]
+
   const filteredCommands = commands.filter(cmd =>
     cmd.name.toLowerCase().includes(search.toLowerCase())
   )
@@ -79,6 +115,9 @@ export function CommandPalette({
     if (open) {
       setSearch('')
       setTimeout(() => inputRef.current?.focus(), 0)
+      ListActions()
+        .then(setBackendActions)
+        .catch(err => console.error('Failed to list actions:', err))
     }
   }, [open])
 
@@ -1,10 +1,10 @@
 import { useState } from 'react'
 import { useTranslation } from 'react-i18next'
-import { Copy, Eye, EyeOff, Lock, Unlock, Trash2 } from 'lucide-react'
+import { Copy, Eye, EyeOff, Lock, Unlock, Trash2, Wand2 } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Textarea } from '@/components/ui/textarea'
-import { ViewSensitiveField, CopyFieldValue } from '../../wailsjs/go/main/App'
+import { ViewSensitiveField, CopyFieldValue, GeneratePassword } from '../../wailsjs/go/main/App'
 import { useToast } from '@/hooks/useToast'
 
 // InputType for UI rendering per ADR-005
@@ -17,6 +17,9 @@ export interface FieldDTO {
   kind?: string
   inputType?: InputType // "text" (default) | "textarea" per ADR-005
   hint?: string
+  validator?: string // format checked by the vault on save
+  masked?: string // preview per the vault's mask policy (read mode)
+  ref?: string // ref:// reference the value was read from (read mode)
 }
 
 interface FieldEditorProps {
@@ -87,6 +90,19 @@ export function FieldEditor({
     }
   }
 
+  // Generated values are shown so the user can see what will be saved
+  const handleGenerate = async () => {
+    try {
+      const generated = await GeneratePassword({ passphrase: false, length: 0, noSymbols: false, noDigits: false, exclude: '' })
+      onChange?.(generated.value)
+      setIsVisible(true)
+      toast.success(t('fields.generated', { strength: t(`fields.strength.${generated.strength}`) }))
+    } catch (err) {
+      console.error('Failed to generate password:', err)
+      toast.error(t('fields.failedToGenerate'))
+    }
+  }
+
   const handleChange = (e: React.ChangeEvent<HTMLInputElement | HTMLTextAreaElement>) => {
     if (onChange) {
       onChange(e.target.value)
@@ -105,8 +121,9 @@ export function FieldEditor({
   const hasContent = (field.value?.length ?? 0) > 0
   const shouldMaskDisplay = field.sensitive && !isVisible && readOnly && hasContent
 
-  // Display value: masked in read mode when hidden, actual value otherwise
-  const displayValue = shouldMaskDisplay ? '••••••••' : field.value
+  // Display value: masked in read mode when hidden, actual value otherwise.
+  // The preview comes from the vault's mask policy; edited values have none.
+  const displayValue = shouldMaskDisplay ? (field.masked || '••••••••') : field.value
 
   // Visual styling for masked state (read mode only)
   const maskedStyles = shouldMaskDisplay ? 'cursor-not-allowed bg-muted' : ''
@@ -130,6 +147,11 @@ export function FieldEditor({
         {field.hint && (
           <span className="text-xs text-muted-foreground">({field.hint})</span>
         )}
+        {readOnly && field.ref && (
+          <span className="text-xs text-muted-foreground font-mono" data-testid={`field-ref-${fieldName}`}>
+            {t('fields.referencedFrom', { ref: field.ref })}
+          </span>
+        )}
         {!readOnly && onSensitiveToggle && (
           <Button
             variant="ghost"
@@ -165,6 +187,17 @@ export function FieldEditor({
             data-testid={`field-value-${fieldName}`}
           />
         )}
+        {!readOnly && onChange && field.sensitive && !isTextarea && (
+          <Button
+            variant="ghost"
+            size="icon"
+            onClick={handleGenerate}
+            title={t('fields.generate')}
+            data-testid={`generate-field-${fieldName}`}
+          >
+            <Wand2 className="w-4 h-4" />
+          </Button>
+        )}
         {field.sensitive && (
           <Button
             variant="ghost"
@@ -0,0 +1,144 @@
+import { useState, useEffect } from 'react'
+import { useTranslation } from 'react-i18next'
+import { GitMerge, Eye, EyeOff } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
+import { main } from '../../wailsjs/go/models'
+
+export type MergeChoice = 'mine' | 'theirs'
+
+interface MergeDialogProps {
+  conflict: main.EditConflict | null
+  onResolve: (choices: Record<string, MergeChoice>) => void
+  onCancel: () => void
+}
+
+// mergeItemId identifies a merge item in the choices passed to onResolve
+export function mergeItemId(item: main.MergeItem): string {
+  return item.kind === 'field' ? `field:${item.name}` : item.kind
+}
+
+// defaultChoice keeps the side that changed the item; both sides changed
+// conflicting items, and those default to the edit
+function defaultChoice(item: main.MergeItem): MergeChoice {
+  if (item.conflict) return 'mine'
+  return item.mine === item.base ? 'theirs' : 'mine'
+}
+
+export function MergeDialog({ conflict, onResolve, onCancel }: MergeDialogProps) {
+  const { t } = useTranslation()
+  const [choices, setChoices] = useState<Record<string, MergeChoice>>({})
+  const [showValues, setShowValues] = useState(false)
+
+  useEffect(() => {
+    if (!conflict) return
+    const initial: Record<string, MergeChoice> = {}
+    for (const item of conflict.changes) {
+      initial[mergeItemId(item)] = defaultChoice(item)
+    }
+    setChoices(initial)
+    setShowValues(false)
+  }, [conflict])
+
+  useEffect(() => {
+    const handleKeyDown = (e: KeyboardEvent) => {
+      if (conflict && e.key === 'Escape') {
+        e.preventDefault()
+        onCancel()
+      }
+    }
+    window.addEventListener('keydown', handleKeyDown)
+    return () => window.removeEventListener('keydown', handleKeyDown)
+  }, [conflict, onCancel])
+
+  if (!conflict) return null
+
+  const label = (item: main.MergeItem) =>
+    item.kind === 'field' ? item.name : t(`merge.kind.${item.kind}`)
+
+  const display = (item: main.MergeItem, value?: string) => {
+    if (value === undefined) return <span className="italic text-muted-foreground">{t('merge.absent')}</span>
+    if (value === '') return <span className="italic text-muted-foreground">{t('merge.empty')}</span>
+    if (item.sensitive && !showValues) return '••••••••'
+    return value
+  }
+
+  const option = (item: main.MergeItem, side: MergeChoice) => {
+    const id = mergeItemId(item)
+    const value = side === 'mine' ? item.mine : item.theirs
+    return (
+      <label
+        className={`flex-1 min-w-0 cursor-pointer rounded-md border p-2 text-sm ${choices[id] === side ? 'border-primary bg-primary/5' : ''}`}
+        data-testid={`merge-${id}-${side}`}
+      >
+        <input
+          type="radio"
+          name={id}
+          className="mr-2"
+          checked={choices[id] === side}
+          disabled={conflict.deleted && side === 'theirs'}
+          onChange={() => setChoices(prev => ({ ...prev, [id]: side }))}
+        />
+        <span className="text-xs text-muted-foreground">{t(`merge.${side}`)}</span>
+        <div className="mt-1 font-mono break-all whitespace-pre-wrap">{display(item, value)}</div>
+      </label>
+    )
+  }
+
+  return (
+    <div
+      className="fixed inset-0 bg-black/50 flex items-center justify-center z-50"
+      onClick={onCancel}
+      data-testid="merge-dialog"
+    >
+      <Card
+        className="w-full max-w-2xl mx-4 max-h-[85vh] flex flex-col"
+        onClick={e => e.stopPropagation()}
+      >
+        <CardHeader>
+          <CardTitle className="flex items-center gap-2">
+            <GitMerge className="w-5 h-5" />
+            {t('merge.title', { key: conflict.key })}
+          </CardTitle>
+          <p className="text-sm text-muted-foreground">
+            {conflict.deleted ? t('merge.deleted') : t('merge.description')}
+          </p>
+        </CardHeader>
+        <CardContent className="space-y-4 overflow-y-auto">
+          {conflict.changes.length === 0 && (
+            <p className="text-sm text-muted-foreground">{t('merge.noDifferences')}</p>
+          )}
+          {conflict.changes.map(item => (
+            <div key={mergeItemId(item)} className="space-y-1">
+              <div className="flex items-center gap-2 text-sm font-medium">
+                <span className={item.kind === 'field' ? 'font-mono' : ''}>{label(item)}</span>
+                {item.conflict && (
+                  <span className="text-xs text-destructive">{t('merge.bothChanged')}</span>
+                )}
+              </div>
+              <div className="flex gap-2">
+                {option(item, 'mine')}
+                {option(item, 'theirs')}
+              </div>
+            </div>
+          ))}
+
+          <div className="flex justify-between gap-2">
+            <Button variant="ghost" size="sm" onClick={() => setShowValues(v => !v)} data-testid="merge-toggle-values">
+              {showValues ? <EyeOff className="w-4 h-4 mr-1" /> : <Eye className="w-4 h-4 mr-1" />}
+              {showValues ? t('merge.hideValues') : t('merge.showValues')}
+            </Button>
+            <div className="flex gap-2">
+              <Button variant="outline" onClick={onCancel} data-testid="merge-cancel">
+                {t('common.cancel')}
+              </Button>
+              <Button onClick={() => onResolve(choices)} data-testid="merge-confirm">
+                {t('merge.save')}
+              </Button>
+            </div>
+          </div>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
@@ -1,6 +1,6 @@
 import { useTranslation } from 'react-i18next'
 
-import { Key, Database, Globe, Terminal, Lock, Unlock, Link } from 'lucide-react'
+import { Key, Database, Globe, Terminal, Cloud, Lock, Unlock, Link } from 'lucide-react'
 import { Card, CardContent } from '@/components/ui/card'
 import { main } from '../../wailsjs/go/models'
 
@@ -15,6 +15,7 @@ const iconMap: Record<string, JSX.Element> = {
   database: <Database className="w-8 h-8" />,
   globe: <Globe className="w-8 h-8" />,
   terminal: <Terminal className="w-8 h-8" />,
+  cloud: <Cloud className="w-8 h-8" />,
 }
 
 export function TemplateSelector({ templates, selectedTemplate, onSelect }: TemplateSelectorProps) {
@@ -0,0 +1,102 @@
+import { useEffect, useState } from 'react'
+import { useTranslation } from 'react-i18next'
+import { Download, Loader2, X } from 'lucide-react'
+import { Button } from '@/components/ui/button'
+import { InstallUpdate, PendingUpdate } from '../../wailsjs/go/main/App'
+import { BrowserOpenURL, EventsOn } from '../../wailsjs/runtime/runtime'
+import { main } from '../../wailsjs/go/models'
+
+type UpdateState = 'available' | 'downloading' | 'installed' | 'failed'
+
+// UpdateBanner announces a release found by the automatic update check
+// and installs it on request.
+export function UpdateBanner() {
+  const { t } = useTranslation()
+  const [info, setInfo] = useState<main.UpdateInfo | null>(null)
+  const [state, setState] = useState<UpdateState>('available')
+  const [error, setError] = useState('')
+
+  useEffect(() => {
+    const offs = [
+      EventsOn('update:available', (i: main.UpdateInfo) => {
+        setInfo(i)
+        setState('available')
+      }),
+      EventsOn('update:downloading', (i: main.UpdateInfo) => {
+        setInfo(i)
+        setState('downloading')
+      }),
+      EventsOn('update:installed', (i: main.UpdateInfo) => {
+        setInfo(i)
+        setState('installed')
+      }),
+    ]
+    // The check may have finished before we subscribed
+    PendingUpdate()
+      .then((i) => {
+        if (i) setInfo((cur) => cur ?? i)
+      })
+      .catch(() => {})
+    return () => offs.forEach((off) => off())
+  }, [])
+
+  if (!info) {
+    return null
+  }
+
+  const handleInstall = async () => {
+    setState('downloading')
+    setError('')
+    try {
+      // On success the app relaunches, or reports update:installed
+      await InstallUpdate()
+    } catch (err) {
+      setError(String(err))
+      setState('failed')
+    }
+  }
+
+  return (
+    <div className="fixed bottom-4 left-4 z-50 max-w-sm bg-card border border-border rounded-lg shadow-lg p-4 space-y-2">
+      <div className="flex items-start justify-between gap-2">
+        <p className="text-sm font-medium text-card-foreground">
+          {state === 'installed'
+            ? t('update.installed', 'secretctl {{version}} is installed and starts next time', { version: info.version })
+            : t('update.available', 'secretctl {{version}} is available (you have {{current}})', {
+                version: info.version,
+                current: info.currentVersion,
+              })}
+        </p>
+        <Button variant="ghost" size="icon" className="h-6 w-6" onClick={() => setInfo(null)}>
+          <X className="h-4 w-4" />
+        </Button>
+      </div>
+      {state === 'failed' && (
+        <p className="text-sm text-destructive">
+          {t('update.failed', 'Update failed')}: {error}
+        </p>
+      )}
+      {state !== 'installed' && (
+        <div className="flex gap-2">
+          {info.installable && (
+            <Button size="sm" onClick={handleInstall} disabled={state === 'downloading'}>
+              {state === 'downloading' ? (
+                <Loader2 className="h-4 w-4 mr-2 animate-spin" />
+              ) : (
+                <Download className="h-4 w-4 mr-2" />
+              )}
+              {state === 'downloading'
+                ? t('update.downloading', 'Downloading...')
+                : t('update.install', 'Install and Relaunch')}
+            </Button>
+          )}
+          {info.notesUrl && (
+            <Button size="sm" variant="outline" onClick={() => BrowserOpenURL(info.notesUrl!)}>
+              {t('update.releaseNotes', 'Release Notes')}
+            </Button>
+          )}
+        </div>
+      )}
+    </div>
+  )
+}
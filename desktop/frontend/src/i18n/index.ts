@@ -2,6 +2,7 @@ import i18n from 'i18next'
 import { initReactI18next } from 'react-i18next'
 import en from './locales/en.json'
 import ja from './locales/ja.json'
+import { SetLocale } from '../../wailsjs/go/main/App'
 
 // Get system language or default to English
 const getDefaultLanguage = () => {
@@ -25,4 +26,13 @@ i18n
     },
   })
 
+// Keep errors returned by the backend in the UI language. The Wails
+// bindings are absent outside the desktop runtime (e.g. in tests).
+const syncBackendLocale = (lng: string) => {
+  if (typeof window === 'undefined' || !('go' in window)) return
+  SetLocale(lng).catch(() => {})
+}
+syncBackendLocale(i18n.language)
+i18n.on('languageChanged', syncBackendLocale)
+
 export default i18n
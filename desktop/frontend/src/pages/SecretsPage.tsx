@@ -18,11 +18,14 @@ function normalizeInputType(value: string | undefined): InputType | undefined {
 }
 
 // Convert Wails FieldDTO to component FieldDTO with normalized inputType
-function normalizeFields(fields: Record<string, { value: string; sensitive: boolean; aliases?: string[]; kind?: string; inputType?: string; hint?: string }>): Record<string, FieldDTO> {
+function normalizeFields(fields: Record<string, { value: string; sensitive: boolean; aliases?: string[]; kind?: string; inputType?: string; hint?: string; validator?: string; masked?: string; ref?: string }>): Record<string, FieldDTO> {
   const result: Record<string, FieldDTO> = {}
   for (const [key, field] of Object.entries(fields)) {
+    // Edit a ref:// field as the reference, so saving keeps it
+    const { ref, ...rest } = field
     result[key] = {
-      ...field,
+      ...rest,
+      value: ref ?? field.value,
       inputType: normalizeInputType(field.inputType)
     }
   }
@@ -34,11 +37,12 @@ import { AddFieldDialog } from '@/components/AddFieldDialog'
 import { BindingsSection } from '@/components/BindingsSection'
 import { AddBindingDialog } from '@/components/AddBindingDialog'
 import { ChangePasswordDialog } from '@/components/ChangePasswordDialog'
+import { MergeDialog, mergeItemId, type MergeChoice } from '@/components/MergeDialog'
 import { useToast } from '@/hooks/useToast'
 import {
-  ListSecrets, GetSecret,
+  ListSecretsPage, GetSecret,
   DeleteSecret, CopyToClipboard, Lock as LockVault, ResetIdleTimer,
-CreateSecretMultiField, UpdateSecretMultiField, GetTemplates
+CreateSecretMultiField, GetTemplates, BeginEdit, CommitEdit
 } from '../../wailsjs/go/main/App'
 import { main } from '../../wailsjs/go/models'
 import { EventsOn } from '../../wailsjs/runtime/runtime'
@@ -52,6 +56,7 @@ interface SecretsPageProps {
 export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings }: SecretsPageProps) {
   const { t } = useTranslation()
   const [secrets, setSecrets] = useState<main.SecretListItem[]>([])
+  const [nextCursor, setNextCursor] = useState('')
   const [searchQuery, setSearchQuery] = useState('')
   const [selectedKey, setSelectedKey] = useState<string | null>(null)
   const [selectedSecret, setSelectedSecret] = useState<main.Secret | null>(null)
@@ -77,6 +82,9 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
   const [selectedTemplate, setSelectedTemplate] = useState<string | null>(null)
   const [templates, setTemplates] = useState<main.TemplateInfo[]>([])
   const [fieldToDelete, setFieldToDelete] = useState<string | null>(null)
+  // Concurrent edit state: the revision being edited and a rejected save
+  const [editToken, setEditToken] = useState('')
+  const [editConflict, setEditConflict] = useState<main.EditConflict | null>(null)
 
   // Refs
   const searchInputRef = useRef<HTMLInputElement>(null)
@@ -127,6 +135,9 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
     const unlisten = EventsOn('vault:locked', () => {
       onLocked()
     })
+    const unlistenTamper = EventsOn('vault:tamper_suspected', () => {
+      toast.error(t('secrets.tamperSuspected'))
+    })
 
     // Reset idle timer on activity
     const handleActivity = () => ResetIdleTimer()
@@ -136,16 +147,30 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
 
     return () => {
       unlisten()
+      unlistenTamper()
       window.removeEventListener('mousemove', handleActivity)
       window.removeEventListener('keydown', handleActivity)
       window.removeEventListener('keydown', handleKeyboardShortcuts)
     }
   }, [onLocked, handleKeyboardShortcuts])
 
+  // Large vaults are listed a page at a time
   const loadSecrets = async () => {
     try {
-      const list = await ListSecrets()
-      setSecrets(list || [])
+      const page = await ListSecretsPage('', 0)
+      setSecrets(page.items || [])
+      setNextCursor(page.nextCursor || '')
+    } catch (err) {
+      console.error('Failed to load secrets:', err)
+    }
+  }
+
+  const loadMoreSecrets = async () => {
+    if (!nextCursor) return
+    try {
+      const page = await ListSecretsPage(nextCursor, 0)
+      setSecrets(prev => [...prev, ...(page.items || [])])
+      setNextCursor(page.nextCursor || '')
     } catch (err) {
       console.error('Failed to load secrets:', err)
     }
@@ -259,6 +284,7 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
         sensitive: field.sensitive,
         inputType: normalizeInputType(field.inputType),
         hint: field.hint,
+        validator: field.validator,
       }
       newFieldOrder.push(field.name)
     }
@@ -266,23 +292,35 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
     setFormFieldOrder(newFieldOrder)
     setFormBindings(template.bindings || {})
   }
-  const handleStartEdit = () => {
+  const handleStartEdit = async () => {
     if (!selectedSecret) return
+    // Edit the version the revision token was taken from, so that saving
+    // detects any change made after this point
+    let secret: main.Secret
+    try {
+      setEditToken(await BeginEdit(selectedSecret.key))
+      secret = await GetSecret(selectedSecret.key)
+    } catch (err) {
+      console.error('Failed to begin edit:', err)
+      toast.error(t('merge.failedToBeginEdit'))
+      return
+    }
+    setSelectedSecret(secret)
     setIsEditing(true)
-    setFormKey(selectedSecret.key)
-    setFormNotes(selectedSecret.notes || '')
-    setFormUrl(selectedSecret.url || '')
-    setFormTags(selectedSecret.tags?.join(', ') || '')
+    setFormKey(secret.key)
+    setFormNotes(secret.notes || '')
+    setFormUrl(secret.url || '')
+    setFormTags(secret.tags?.join(', ') || '')
     // Populate multi-field state
-    if (selectedSecret.fields && Object.keys(selectedSecret.fields).length > 0) {
-      setFormFields(normalizeFields(selectedSecret.fields))
-      setFormFieldOrder(selectedSecret.fieldOrder || Object.keys(selectedSecret.fields))
+    if (secret.fields && Object.keys(secret.fields).length > 0) {
+      setFormFields(normalizeFields(secret.fields))
+      setFormFieldOrder(secret.fieldOrder || Object.keys(secret.fields))
     } else {
       // Legacy: single value -> value field
-      setFormFields({ value: { value: selectedSecret.value || '', sensitive: true } })
+      setFormFields({ value: { value: secret.value || '', sensitive: true } })
       setFormFieldOrder(['value'])
     }
-    setFormBindings(selectedSecret.bindings || {})
+    setFormBindings(secret.bindings || {})
     setShowValue(true)
     setSelectedTemplate(null)
   }
@@ -388,24 +426,107 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
         await CreateSecretMultiField(dto as main.SecretUpdateDTO)
         toast.success('Secret created')
       } else {
-        await UpdateSecretMultiField(dto as main.SecretUpdateDTO)
+        const conflict = await CommitEdit(formKey, dto as main.SecretUpdateDTO, editToken)
+        if (conflict) {
+          // Changed elsewhere since editing began: let the user merge
+          setEditConflict(conflict)
+          return
+        }
         toast.success('Changes saved')
       }
-      setIsCreating(false)
-      setIsEditing(false)
-      await loadSecrets()
-      if (formKey) {
-        await handleSelectSecret(formKey)
-      }
+      await finishSave()
     } catch (err) {
       console.error('Failed to save:', err)
       toast.error(isCreating ? 'Failed to create secret' : 'Failed to save changes')
     }
   }
 
+  const finishSave = async () => {
+    setIsCreating(false)
+    setIsEditing(false)
+    setEditToken('')
+    await loadSecrets()
+    if (formKey) {
+      await handleSelectSecret(formKey)
+    }
+  }
+
+  // Applies the merge choices to the form and saves against the current
+  // version; a further change in the meantime reopens the dialog
+  const handleResolveMerge = async (choices: Record<string, MergeChoice>) => {
+    const conflict = editConflict
+    if (!conflict) return
+    const fields = { ...formFields }
+    const fieldOrder = [...formFieldOrder]
+    let bindings = { ...formBindings }
+    let notes = formNotes
+    let url = formUrl
+    let tags = formTags ? formTags.split(',').map(t => t.trim()).filter(Boolean) : []
+
+    for (const item of conflict.changes) {
+      if (choices[mergeItemId(item)] !== 'theirs') continue
+      const theirs = item.theirs
+      switch (item.kind) {
+        case 'field': {
+          const name = item.name || ''
+          if (theirs === undefined) {
+            delete fields[name]
+            fieldOrder.splice(fieldOrder.indexOf(name), 1)
+            break
+          }
+          const stored = conflict.theirs?.fields?.[name]
+          fields[name] = stored
+            ? { ...normalizeFields({ [name]: stored })[name], value: theirs }
+            : { value: theirs, sensitive: item.sensitive }
+          if (!fieldOrder.includes(name)) fieldOrder.push(name)
+          break
+        }
+        case 'notes':
+          notes = theirs ?? ''
+          break
+        case 'url':
+          url = theirs ?? ''
+          break
+        case 'tags':
+          tags = (theirs ?? '').split(',').map(t => t.trim()).filter(Boolean)
+          break
+        case 'bindings':
+          bindings = Object.fromEntries((theirs ?? '').split('\n').filter(Boolean).map(line => {
+            const i = line.indexOf('=')
+            return [line.slice(0, i), line.slice(i + 1)]
+          }))
+          break
+      }
+    }
+
+    setFormFields(fields)
+    setFormFieldOrder(fieldOrder)
+    setFormBindings(bindings)
+    setFormNotes(notes)
+    setFormUrl(url)
+    setFormTags(tags.join(', '))
+    setEditConflict(null)
+    setEditToken(conflict.token)
+
+    try {
+      const dto = { key: formKey, fields, bindings, notes, url, tags }
+      const next = await CommitEdit(formKey, dto as main.SecretUpdateDTO, conflict.token)
+      if (next) {
+        setEditConflict(next)
+        return
+      }
+      toast.success(t('merge.saved'))
+      await finishSave()
+    } catch (err) {
+      console.error('Failed to save:', err)
+      toast.error('Failed to save changes')
+    }
+  }
+
   const handleCancel = () => {
     setIsCreating(false)
     setIsEditing(false)
+    setEditToken('')
     if (selectedKey) {
       handleSelectSecret(selectedKey)
     }
@@ -497,11 +618,18 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
               )}
             </button>
           ))}
-          {filteredSecrets.length === 0 && (
+          {filteredSecrets.length === 0 && !nextCursor && (
             <div className="p-4 text-center text-muted-foreground">
               {searchQuery ? t('secrets.noSecretsFound') : t('secrets.noSecretsYet')}
             </div>
           )}
+          {nextCursor && (
+            <div className="p-2">
+              <Button variant="ghost" className="w-full" onClick={loadMoreSecrets} data-testid="load-more-secrets">
+                {t('secrets.loadMore')}
+              </Button>
+            </div>
+          )}
         </div>
 
         {/* Add Button */}
@@ -733,6 +861,9 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
               <div className="pt-4 border-t border-border text-xs text-muted-foreground space-y-1">
                 <p>{t('secrets.created')}: {formatDate(selectedSecret.createdAt)}</p>
                 <p>{t('secrets.updated')}: {formatDate(selectedSecret.updatedAt)}</p>
+                {selectedSecret.expiresAt && (
+                  <p>{t('secrets.expires')}: {selectedSecret.expiresAt} ({selectedSecret.expiresIn})</p>
+                )}
               </div>
             </CardContent>
           </Card>
@@ -771,6 +902,12 @@ export function SecretsPage({ onLocked, onNavigateToAudit, onNavigateToSettings
         onCancel={() => setShowAddBindingDialog(false)}
       />
 
+      <MergeDialog
+        conflict={editConflict}
+        onResolve={handleResolveMerge}
+        onCancel={() => setEditConflict(null)}
+      />
+
       <ChangePasswordDialog
         open={showChangePasswordDialog}
         onClose={() => setShowChangePasswordDialog(false)}
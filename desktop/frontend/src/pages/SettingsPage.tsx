@@ -1,7 +1,17 @@
+import { useEffect, useState } from 'react'
 import { useTranslation } from 'react-i18next'
-import { ArrowLeft } from 'lucide-react'
+import { ArrowLeft, Download, Loader2, ShieldAlert, ShieldCheck } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { ThemeToggle } from '@/components/ThemeToggle'
+import { useToast } from '@/hooks/useToast'
+import {
+  CheckBreaches,
+  CheckForUpdate,
+  GetUpdateSettings,
+  InstallUpdate,
+  SetUpdateSettings,
+} from '../../wailsjs/go/main/App'
+import { main } from '../../wailsjs/go/models'
 
 interface SettingsPageProps {
   onNavigateBack: () => void
@@ -9,6 +19,19 @@ interface SettingsPageProps {
 
 export function SettingsPage({ onNavigateBack }: SettingsPageProps) {
   const { t, i18n } = useTranslation()
+  const toast = useToast()
+  const [breachReport, setBreachReport] = useState<main.BreachReport | null>(null)
+  const [checkingBreaches, setCheckingBreaches] = useState(false)
+  const [updateSettings, setUpdateSettings] = useState<main.UpdateSettings | null>(null)
+  const [updateInfo, setUpdateInfo] = useState<main.UpdateInfo | null>(null)
+  const [checkingUpdate, setCheckingUpdate] = useState(false)
+  const [installingUpdate, setInstallingUpdate] = useState(false)
+
+  useEffect(() => {
+    GetUpdateSettings()
+      .then(setUpdateSettings)
+      .catch((err) => toast.error(`${t('settings.updates.failedToLoad', 'Failed to load update settings')}: ${err}`))
+  }, [])
 
   const languages = [
     { code: 'en', name: 'English' },
@@ -20,6 +43,54 @@ export function SettingsPage({ onNavigateBack }: SettingsPageProps) {
     localStorage.setItem('secretctl-language', e.target.value)
   }
 
+  const handleCheckBreaches = async () => {
+    setCheckingBreaches(true)
+    try {
+      setBreachReport(await CheckBreaches())
+    } catch (err) {
+      toast.error(`${t('settings.breaches.failed', 'Breach check failed')}: ${err}`)
+    } finally {
+      setCheckingBreaches(false)
+    }
+  }
+
+  const handleUpdateSettingsChange = async (changes: Partial<main.UpdateSettings>) => {
+    if (!updateSettings) return
+    const next = main.UpdateSettings.createFrom({ ...updateSettings, ...changes })
+    try {
+      await SetUpdateSettings(next)
+      setUpdateSettings(next)
+      if (changes.channel !== undefined) {
+        setUpdateInfo(null)
+      }
+    } catch (err) {
+      toast.error(`${t('settings.updates.failedToSave', 'Failed to save update settings')}: ${err}`)
+    }
+  }
+
+  const handleCheckForUpdate = async () => {
+    setCheckingUpdate(true)
+    try {
+      setUpdateInfo(await CheckForUpdate())
+    } catch (err) {
+      toast.error(`${t('settings.updates.checkFailed', 'Update check failed')}: ${err}`)
+    } finally {
+      setCheckingUpdate(false)
+    }
+  }
+
+  const handleInstallUpdate = async () => {
+    setInstallingUpdate(true)
+    try {
+      // On success the app relaunches into the new version
+      await InstallUpdate()
+    } catch (err) {
+      toast.error(`${t('settings.updates.installFailed', 'Update failed')}: ${err}`)
+    } finally {
+      setInstallingUpdate(false)
+    }
+  }
+
   return (
     <div className="min-h-screen bg-background">
       {/* Header */}
@@ -86,6 +157,141 @@ export function SettingsPage({ onNavigateBack }: SettingsPageProps) {
             </div>
           </div>
         </section>
+
+        {/* Security Section */}
+        <section className="space-y-4">
+          <h2 className="text-lg font-semibold text-foreground">
+            {t('settings.security', 'Security')}
+          </h2>
+          <div className="bg-card rounded-lg border border-border p-4 space-y-4">
+            <div className="flex items-center justify-between gap-4">
+              <div>
+                <h3 className="font-medium text-card-foreground">
+                  {t('settings.breaches.title', 'Breached Passwords')}
+                </h3>
+                <p className="text-sm text-muted-foreground">
+                  {t('settings.breaches.description', 'Look up password fields in Have I Been Pwned. Only the first 5 characters of each SHA-1 hash are sent; passwords never leave this device.')}
+                </p>
+              </div>
+              <Button variant="outline" onClick={handleCheckBreaches} disabled={checkingBreaches}>
+                {checkingBreaches && <Loader2 className="h-4 w-4 mr-2 animate-spin" />}
+                {t('settings.breaches.check', 'Check Now')}
+              </Button>
+            </div>
+            {breachReport && breachReport.compromised.length === 0 && (
+              <p className="flex items-center gap-2 text-sm text-muted-foreground">
+                <ShieldCheck className="h-4 w-4 text-green-500" />
+                {t('settings.breaches.none', 'None of {{count}} passwords appear in known breaches', { count: breachReport.checked })}
+              </p>
+            )}
+            {breachReport && breachReport.compromised.length > 0 && (
+              <div className="space-y-2">
+                <p className="flex items-center gap-2 text-sm text-destructive">
+                  <ShieldAlert className="h-4 w-4" />
+                  {t('settings.breaches.found', '{{found}} of {{checked}} passwords appear in known breaches. Rotate them.', {
+                    found: breachReport.compromised.length,
+                    checked: breachReport.checked,
+                  })}
+                </p>
+                <ul className="text-sm divide-y divide-border">
+                  {breachReport.compromised.map((r) => (
+                    <li key={`${r.key}/${r.field}`} className="flex justify-between py-1">
+                      <span className="font-mono text-card-foreground">
+                        {r.key} <span className="text-muted-foreground">({r.field})</span>
+                      </span>
+                      <span className="text-muted-foreground">
+                        {t('settings.breaches.seen', 'seen {{count}} times', { count: r.count })}
+                      </span>
+                    </li>
+                  ))}
+                </ul>
+              </div>
+            )}
+          </div>
+        </section>
+
+        {/* Updates Section */}
+        <section className="space-y-4">
+          <h2 className="text-lg font-semibold text-foreground">
+            {t('settings.updates.title', 'Updates')}
+          </h2>
+          <div className="bg-card rounded-lg border border-border p-4 space-y-4">
+            <div className="flex items-center justify-between gap-4">
+              <div>
+                <h3 className="font-medium text-card-foreground">
+                  {t('settings.updates.autoCheck', 'Check for updates automatically')}
+                </h3>
+                <p className="text-sm text-muted-foreground">
+                  {t('settings.updates.autoCheckDescription', 'Look for a new release at startup, at most once a day. Releases are verified against the signed manifest before they are installed.')}
+                </p>
+              </div>
+              <input
+                type="checkbox"
+                className="w-4 h-4"
+                checked={updateSettings?.autoCheck ?? false}
+                disabled={!updateSettings}
+                onChange={(e) => handleUpdateSettingsChange({ autoCheck: e.target.checked })}
+              />
+            </div>
+            <div className="flex items-center justify-between gap-4">
+              <div>
+                <h3 className="font-medium text-card-foreground">
+                  {t('settings.updates.channel', 'Release Channel')}
+                </h3>
+                <p className="text-sm text-muted-foreground">
+                  {t('settings.updates.channelDescription', 'Beta releases arrive earlier but may be less stable')}
+                </p>
+              </div>
+              <select
+                value={updateSettings?.channel ?? 'stable'}
+                disabled={!updateSettings}
+                onChange={(e) => handleUpdateSettingsChange({ channel: e.target.value })}
+                className="bg-background border border-border rounded-md px-3 py-2 text-foreground focus:outline-none focus:ring-2 focus:ring-ring"
+              >
+                <option value="stable">{t('settings.updates.stable', 'Stable')}</option>
+                <option value="beta">{t('settings.updates.beta', 'Beta')}</option>
+              </select>
+            </div>
+            <div className="flex items-center justify-between gap-4">
+              <p className="text-sm text-muted-foreground">
+                {updateSettings?.lastCheck
+                  ? t('settings.updates.lastCheck', 'Last checked {{date}}', {
+                      date: new Date(updateSettings.lastCheck).toLocaleString(i18n.language),
+                    })
+                  : t('settings.updates.neverChecked', 'Not checked automatically yet')}
+              </p>
+              <Button variant="outline" onClick={handleCheckForUpdate} disabled={checkingUpdate}>
+                {checkingUpdate && <Loader2 className="h-4 w-4 mr-2 animate-spin" />}
+                {t('settings.updates.check', 'Check Now')}
+              </Button>
+            </div>
+            {updateInfo && !updateInfo.available && (
+              <p className="text-sm text-muted-foreground">
+                {t('settings.updates.upToDate', 'secretctl {{version}} is up to date', { version: updateInfo.currentVersion })}
+              </p>
+            )}
+            {updateInfo && updateInfo.available && (
+              <div className="flex items-center justify-between gap-4">
+                <p className="text-sm text-card-foreground">
+                  {t('update.available', 'secretctl {{version}} is available (you have {{current}})', {
+                    version: updateInfo.version,
+                    current: updateInfo.currentVersion,
+                  })}
+                </p>
+                {updateInfo.installable && (
+                  <Button onClick={handleInstallUpdate} disabled={installingUpdate}>
+                    {installingUpdate ? (
+                      <Loader2 className="h-4 w-4 mr-2 animate-spin" />
+                    ) : (
+                      <Download className="h-4 w-4 mr-2" />
+                    )}
+                    {t('update.install', 'Install and Relaunch')}
+                  </Button>
+                )}
+              </div>
+            )}
+          </div>
+        </section>
       </main>
     </div>
   )
@@ -2,48 +2,98 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function BeginEdit(arg1:string):Promise<string>;
+
+export function CalibrateKDF():Promise<main.KDFSettings>;
+
 export function ChangePassword(arg1:string,arg2:string,arg3:string):Promise<main.PasswordChangeResult>;
 
+export function CheckBreaches():Promise<main.BreachReport>;
+
+export function CheckForUpdate():Promise<main.UpdateInfo>;
+
 export function CheckVaultExists():Promise<boolean>;
 
 export function ClearClipboard():Promise<void>;
 
+export function CommitEdit(arg1:string,arg2:main.SecretUpdateDTO,arg3:string):Promise<main.EditConflict>;
+
+export function ConfigureMCPClient(arg1:string,arg2:string):Promise<main.MCPConfigResult>;
+
 export function CopyFieldValue(arg1:string,arg2:string):Promise<void>;
 
 export function CopyToClipboard(arg1:string):Promise<void>;
 
+export function CreateRecoveryKit(arg1:string):Promise<string>;
+
 export function CreateSecret(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Array<string>):Promise<void>;
 
 export function CreateSecretMultiField(arg1:main.SecretUpdateDTO):Promise<void>;
 
+export function CreateVault(arg1:string,arg2:string,arg3:main.KDFSettings):Promise<void>;
+
+export function DeleteAttachment(arg1:string,arg2:string):Promise<void>;
+
 export function DeleteSecret(arg1:string):Promise<void>;
 
+export function DownloadAttachment(arg1:string,arg2:string):Promise<string>;
+
+export function ExecuteAction(arg1:string,arg2:Record<string, string>):Promise<main.ActionResult>;
+
+export function FormatRelativeTime(arg1:string):Promise<string>;
+
+export function GeneratePassword(arg1:main.GenerateOptions):Promise<main.GeneratedValue>;
+
 export function GetAuditLogStats():Promise<Record<string, number>>;
 
 export function GetAuthStatus():Promise<main.AuthStatus>;
 
+export function GetOnboardingStatus():Promise<main.OnboardingStatus>;
+
 export function GetSecret(arg1:string):Promise<main.Secret>;
 
 export function GetTemplates():Promise<Array<main.TemplateInfo>>;
 
+export function GetUpdateSettings():Promise<main.UpdateSettings>;
+
 export function InitVault(arg1:string):Promise<void>;
 
+export function InstallUpdate():Promise<void>;
+
+export function ListActions():Promise<Array<main.ActionInfo>>;
+
+export function ListAttachments(arg1:string):Promise<Array<main.AttachmentInfo>>;
+
 export function ListAuditLogs(arg1:number):Promise<Array<main.AuditLogEntry>>;
 
 export function ListSecrets():Promise<Array<main.SecretListItem>>;
 
+export function ListSecretsPage(arg1:string,arg2:number):Promise<main.SecretListPage>;
+
 export function Lock():Promise<void>;
 
+export function PendingUpdate():Promise<main.UpdateInfo>;
+
+export function PreviewMCPConfig(arg1:string):Promise<main.MCPConfigPreview>;
+
+export function PreviewRestore(arg1:string,arg2:string,arg3:string,arg4:string):Promise<main.RestorePreview>;
+
 export function ResetIdleTimer():Promise<void>;
 
 export function SearchAuditLogs(arg1:main.AuditLogFilter,arg2:number,arg3:number):Promise<main.AuditLogSearchResult>;
 
+export function SetLocale(arg1:string):Promise<void>;
+
+export function SetUpdateSettings(arg1:main.UpdateSettings):Promise<void>;
+
 export function Unlock(arg1:string):Promise<void>;
 
 export function UpdateSecret(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Array<string>):Promise<void>;
 
 export function UpdateSecretMultiField(arg1:main.SecretUpdateDTO):Promise<void>;
 
+export function UploadAttachment(arg1:string):Promise<main.AttachmentInfo>;
+
 export function VerifyAuditLogs():Promise<boolean>;
 
 export function ViewSensitiveField(arg1:string,arg2:string):Promise<void>;
@@ -2,10 +2,26 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function BeginEdit(arg1) {
+  return window['go']['main']['App']['BeginEdit'](arg1);
+}
+
+export function CalibrateKDF() {
+  return window['go']['main']['App']['CalibrateKDF']();
+}
+
 export function ChangePassword(arg1, arg2, arg3) {
   return window['go']['main']['App']['ChangePassword'](arg1, arg2, arg3);
 }
 
+export function CheckBreaches() {
+  return window['go']['main']['App']['CheckBreaches']();
+}
+
+export function CheckForUpdate() {
+  return window['go']['main']['App']['CheckForUpdate']();
+}
+
 export function CheckVaultExists() {
   return window['go']['main']['App']['CheckVaultExists']();
 }
@@ -14,6 +30,14 @@ export function ClearClipboard() {
   return window['go']['main']['App']['ClearClipboard']();
 }
 
+export function CommitEdit(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CommitEdit'](arg1, arg2, arg3);
+}
+
+export function ConfigureMCPClient(arg1, arg2) {
+  return window['go']['main']['App']['ConfigureMCPClient'](arg1, arg2);
+}
+
 export function CopyFieldValue(arg1, arg2) {
   return window['go']['main']['App']['CopyFieldValue'](arg1, arg2);
 }
@@ -22,6 +46,10 @@ export function CopyToClipboard(arg1) {
   return window['go']['main']['App']['CopyToClipboard'](arg1);
 }
 
+export function CreateRecoveryKit(arg1) {
+  return window['go']['main']['App']['CreateRecoveryKit'](arg1);
+}
+
 export function CreateSecret(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['CreateSecret'](arg1, arg2, arg3, arg4, arg5);
 }
@@ -30,10 +58,34 @@ export function CreateSecretMultiField(arg1) {
   return window['go']['main']['App']['CreateSecretMultiField'](arg1);
 }
 
+export function CreateVault(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CreateVault'](arg1, arg2, arg3);
+}
+
+export function DeleteAttachment(arg1, arg2) {
+  return window['go']['main']['App']['DeleteAttachment'](arg1, arg2);
+}
+
 export function DeleteSecret(arg1) {
   return window['go']['main']['App']['DeleteSecret'](arg1);
 }
 
+export function DownloadAttachment(arg1, arg2) {
+  return window['go']['main']['App']['DownloadAttachment'](arg1, arg2);
+}
+
+export function ExecuteAction(arg1, arg2) {
+  return window['go']['main']['App']['ExecuteAction'](arg1, arg2);
+}
+
+export function FormatRelativeTime(arg1) {
+  return window['go']['main']['App']['FormatRelativeTime'](arg1);
+}
+
+export function GeneratePassword(arg1) {
+  return window['go']['main']['App']['GeneratePassword'](arg1);
+}
+
 export function GetAuditLogStats() {
   return window['go']['main']['App']['GetAuditLogStats']();
 }
@@ -42,6 +94,10 @@ export function GetAuthStatus() {
   return window['go']['main']['App']['GetAuthStatus']();
 }
 
+export function GetOnboardingStatus() {
+  return window['go']['main']['App']['GetOnboardingStatus']();
+}
+
 export function GetSecret(arg1) {
   return window['go']['main']['App']['GetSecret'](arg1);
 }
@@ -50,10 +106,26 @@ export function GetTemplates() {
   return window['go']['main']['App']['GetTemplates']();
 }
 
+export function GetUpdateSettings() {
+  return window['go']['main']['App']['GetUpdateSettings']();
+}
+
 export function InitVault(arg1) {
   return window['go']['main']['App']['InitVault'](arg1);
 }
 
+export function InstallUpdate() {
+  return window['go']['main']['App']['InstallUpdate']();
+}
+
+export function ListActions() {
+  return window['go']['main']['App']['ListActions']();
+}
+
+export function ListAttachments(arg1) {
+  return window['go']['main']['App']['ListAttachments'](arg1);
+}
+
 export function ListAuditLogs(arg1) {
   return window['go']['main']['App']['ListAuditLogs'](arg1);
 }
@@ -62,10 +134,26 @@ export function ListSecrets() {
   return window['go']['main']['App']['ListSecrets']();
 }
 
+export function ListSecretsPage(arg1, arg2) {
+  return window['go']['main']['App']['ListSecretsPage'](arg1, arg2);
+}
+
 export function Lock() {
   return window['go']['main']['App']['Lock']();
 }
 
+export function PendingUpdate() {
+  return window['go']['main']['App']['PendingUpdate']();
+}
+
+export function PreviewMCPConfig(arg1) {
+  return window['go']['main']['App']['PreviewMCPConfig'](arg1);
+}
+
+export function PreviewRestore(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['PreviewRestore'](arg1, arg2, arg3, arg4);
+}
+
 export function ResetIdleTimer() {
   return window['go']['main']['App']['ResetIdleTimer']();
 }
@@ -74,6 +162,14 @@ export function SearchAuditLogs(arg1, arg2, arg3) {
   return window['go']['main']['App']['SearchAuditLogs'](arg1, arg2, arg3);
 }
 
+export function SetLocale(arg1) {
+  return window['go']['main']['App']['SetLocale'](arg1);
+}
+
+export function SetUpdateSettings(arg1) {
+  return window['go']['main']['App']['SetUpdateSettings'](arg1);
+}
+
 export function Unlock(arg1) {
   return window['go']['main']['App']['Unlock'](arg1);
 }
@@ -86,6 +182,10 @@ export function UpdateSecretMultiField(arg1) {
   return window['go']['main']['App']['UpdateSecretMultiField'](arg1);
 }
 
+export function UploadAttachment(arg1) {
+  return window['go']['main']['App']['UploadAttachment'](arg1);
+}
+
 export function VerifyAuditLogs() {
   return window['go']['main']['App']['VerifyAuditLogs']();
 }
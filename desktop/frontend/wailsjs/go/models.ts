@@ -1,5 +1,91 @@
 export namespace main {
 	
+	export class ActionInfo {
+	    id: string;
+	    title: string;
+	    description: string;
+	    params: ActionParam[];
+	    requiresUnlock: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.description = source["description"];
+	        this.params = this.convertValues(source["params"], ActionParam);
+	        this.requiresUnlock = source["requiresUnlock"];
+	    }
+	
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
+	    }
+	    return a;
+	}
+	}
+	export class ActionParam {
+	    name: string;
+	    description: string;
+	    required: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionParam(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.required = source["required"];
+	    }
+	}
+	export class ActionResult {
+	    message?: string;
+	    navigate?: string;
+	    data?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.message = source["message"];
+	        this.navigate = source["navigate"];
+	        this.data = source["data"];
+	    }
+	}
+	export class AttachmentInfo {
+	    name: string;
+	    size: number;
+	    createdAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttachmentInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.size = source["size"];
+	        this.createdAt = source["createdAt"];
+	    }
+	}
 	export class AuditLogEntry {
 	    timestamp: string;
 	    action: string;
@@ -90,6 +176,92 @@ export namespace main {
 	        this.vaultDir = source["vaultDir"];
 	    }
 	}
+	export class BreachReport {
+	    checked: number;
+	    compromised: BreachResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BreachReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.checked = source["checked"];
+	        this.compromised = this.convertValues(source["compromised"], BreachResult);
+	    }
+	
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
+	    }
+	    return a;
+	}
+	}
+	export class BreachResult {
+	    key: string;
+	    field: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BreachResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.field = source["field"];
+	        this.count = source["count"];
+	    }
+	}
+	export class EditConflict {
+	    key: string;
+	    token: string;
+	    deleted: boolean;
+	    theirs?: Secret;
+	    changes: MergeItem[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EditConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.token = source["token"];
+	        this.deleted = source["deleted"];
+	        this.theirs = this.convertValues(source["theirs"], Secret);
+	        this.changes = this.convertValues(source["changes"], MergeItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class FieldDTO {
 	    value: string;
 	    sensitive: boolean;
@@ -97,6 +269,9 @@ export namespace main {
 	    kind?: string;
 	    inputType?: string;
 	    hint?: string;
+	    validator?: string;
+	    masked?: string;
+	    ref?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new FieldDTO(source);
@@ -110,8 +285,181 @@ export namespace main {
 	        this.kind = source["kind"];
 	        this.inputType = source["inputType"];
 	        this.hint = source["hint"];
+	        this.validator = source["validator"];
+	        this.masked = source["masked"];
+	        this.ref = source["ref"];
+	    }
+	}
+	export class GenerateOptions {
+	    passphrase: boolean;
+	    length: number;
+	    noSymbols: boolean;
+	    noDigits: boolean;
+	    exclude: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.passphrase = source["passphrase"];
+	        this.length = source["length"];
+	        this.noSymbols = source["noSymbols"];
+	        this.noDigits = source["noDigits"];
+	        this.exclude = source["exclude"];
 	    }
 	}
+	export class GeneratedValue {
+	    value: string;
+	    strength: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GeneratedValue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.value = source["value"];
+	        this.strength = source["strength"];
+	    }
+	}
+	export class KDFSettings {
+	    memory: number;
+	    iterations: number;
+	    threads: number;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KDFSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.memory = source["memory"];
+	        this.iterations = source["iterations"];
+	        this.threads = source["threads"];
+	        this.description = source["description"];
+	    }
+	}
+	export class MCPClientStatus {
+	    id: string;
+	    name: string;
+	    configPath: string;
+	    configured: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MCPClientStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.configPath = source["configPath"];
+	        this.configured = source["configured"];
+	    }
+	}
+	export class MCPConfigPreview {
+	    id: string;
+	    name: string;
+	    configPath: string;
+	    before?: string;
+	    after: string;
+	    changed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MCPConfigPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.configPath = source["configPath"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	        this.changed = source["changed"];
+	    }
+	}
+	export class MCPConfigResult {
+	    configPath: string;
+	    backup?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MCPConfigResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.configPath = source["configPath"];
+	        this.backup = source["backup"];
+	    }
+	}
+	export class MergeItem {
+	    kind: string;
+	    name?: string;
+	    base?: string;
+	    mine?: string;
+	    theirs?: string;
+	    sensitive: boolean;
+	    conflict: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.name = source["name"];
+	        this.base = source["base"];
+	        this.mine = source["mine"];
+	        this.theirs = source["theirs"];
+	        this.sensitive = source["sensitive"];
+	        this.conflict = source["conflict"];
+	    }
+	}
+	export class OnboardingStatus {
+	    vaultDir: string;
+	    defaultVaultDir: string;
+	    vaultExists: boolean;
+	    defaultKdf: KDFSettings;
+	    cliPath?: string;
+	    mcpClients: MCPClientStatus[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OnboardingStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.vaultDir = source["vaultDir"];
+	        this.defaultVaultDir = source["defaultVaultDir"];
+	        this.vaultExists = source["vaultExists"];
+	        this.defaultKdf = this.convertValues(source["defaultKdf"], KDFSettings);
+	        this.cliPath = source["cliPath"];
+	        this.mcpClients = this.convertValues(source["mcpClients"], MCPClientStatus);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class PasswordChangeResult {
 	    success: boolean;
 	    message: string;
@@ -130,6 +478,64 @@ export namespace main {
 	        this.warnings = source["warnings"];
 	    }
 	}
+	export class RestorePreview {
+	    createdAt: string;
+	    secretCount: number;
+	    items: RestorePreviewItem[];
+	    hostname?: string;
+	    toolVersion?: string;
+	    warnings?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RestorePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.createdAt = source["createdAt"];
+	        this.secretCount = source["secretCount"];
+	        this.items = this.convertValues(source["items"], RestorePreviewItem);
+	        this.hostname = source["hostname"];
+	        this.toolVersion = source["toolVersion"];
+	        this.warnings = source["warnings"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RestorePreviewItem {
+	    key: string;
+	    status: string;
+	    localUpdatedAt?: string;
+	    backupUpdatedAt?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RestorePreviewItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.status = source["status"];
+	        this.localUpdatedAt = source["localUpdatedAt"];
+	        this.backupUpdatedAt = source["backupUpdatedAt"];
+	    }
+	}
 	export class Secret {
 	    key: string;
 	    value?: string;
@@ -141,6 +547,8 @@ export namespace main {
 	    tags?: string[];
 	    createdAt: string;
 	    updatedAt: string;
+	    expiresAt?: string;
+	    expiresIn?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Secret(source);
@@ -158,6 +566,8 @@ export namespace main {
 	        this.tags = source["tags"];
 	        this.createdAt = source["createdAt"];
 	        this.updatedAt = source["updatedAt"];
+	        this.expiresAt = source["expiresAt"];
+	        this.expiresIn = source["expiresIn"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -202,6 +612,38 @@ export namespace main {
 	        this.hasUrl = source["hasUrl"];
 	    }
 	}
+	export class SecretListPage {
+	    items: SecretListItem[];
+	    nextCursor?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SecretListPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], SecretListItem);
+	        this.nextCursor = source["nextCursor"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class SecretUpdateDTO {
 	    key: string;
 	    fields: Record<string, FieldDTO>;
@@ -247,6 +689,7 @@ export namespace main {
 	    sensitive: boolean;
 	    hint: string;
 	    inputType?: string;
+	    validator?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new TemplateFieldInfo(source);
@@ -258,6 +701,7 @@ export namespace main {
 	        this.sensitive = source["sensitive"];
 	        this.hint = source["hint"];
 	        this.inputType = source["inputType"];
+	        this.validator = source["validator"];
 	    }
 	}
 	export class TemplateInfo {
@@ -300,6 +744,46 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class UpdateInfo {
+	    currentVersion: string;
+	    version: string;
+	    channel: string;
+	    releasedAt: string;
+	    notesUrl?: string;
+	    available: boolean;
+	    installable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currentVersion = source["currentVersion"];
+	        this.version = source["version"];
+	        this.channel = source["channel"];
+	        this.releasedAt = source["releasedAt"];
+	        this.notesUrl = source["notesUrl"];
+	        this.available = source["available"];
+	        this.installable = source["installable"];
+	    }
+	}
+	export class UpdateSettings {
+	    autoCheck: boolean;
+	    channel: string;
+	    lastCheck?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.autoCheck = source["autoCheck"];
+	        this.channel = source["channel"];
+	        this.lastCheck = source["lastCheck"];
+	    }
+	}
 
 }
 
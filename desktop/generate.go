@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/forest6511/secretctl/pkg/generate"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// GenerateOptions configures the password generator of the secret editor
+type GenerateOptions struct {
+	Passphrase bool `json:"passphrase"`
+	// Length is characters, or words of a passphrase; 0 for the default
+	Length    int    `json:"length"`
+	NoSymbols bool   `json:"noSymbols"`
+	NoDigits  bool   `json:"noDigits"`
+	Exclude   string `json:"exclude"`
+}
+
+// GeneratedValue is a generated password or passphrase with its strength
+type GeneratedValue struct {
+	Value    string `json:"value"`
+	Strength string `json:"strength"` // weak, fair, good or strong
+}
+
+// GeneratePassword generates a password or diceware passphrase for a
+// secret field. Nothing is stored until the secret is saved.
+func (a *App) GeneratePassword(opts GenerateOptions) (*GeneratedValue, error) {
+	var value string
+	var err error
+	if opts.Passphrase {
+		value, err = generate.Passphrase(generate.PassphraseOptions{Words: opts.Length})
+	} else {
+		value, err = generate.Password(generate.Options{
+			Length:    opts.Length,
+			NoSymbols: opts.NoSymbols,
+			NoDigits:  opts.NoDigits,
+			Exclude:   opts.Exclude,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &GeneratedValue{Value: value, Strength: vault.RatePassword(value).String()}, nil
+}
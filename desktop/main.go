@@ -3,7 +3,6 @@ package main
 import (
 	"embed"
 	"os"
-	"path/filepath"
 	goruntime "runtime"
 
 	"github.com/wailsapp/wails/v2"
@@ -23,11 +22,14 @@ var assets embed.FS
 var appIcon []byte
 
 func main() {
-	// Vault directory
+	// Vault directory: environment, then the one chosen during onboarding,
+	// then the CLI default
 	vaultDir := os.Getenv("SECRETCTL_VAULT_DIR")
 	if vaultDir == "" {
-		home, _ := os.UserHomeDir()
-		vaultDir = filepath.Join(home, ".secretctl")
+		vaultDir = loadDesktopPrefs().VaultDir
+	}
+	if vaultDir == "" {
+		vaultDir = defaultVaultDir()
 	}
 
 	app := NewApp(vaultDir)
@@ -46,6 +48,14 @@ func main() {
 		app.Lock()
 		runtime.EventsEmit(app.ctx, "vault:locked")
 	})
+	fileMenu.AddText("Check for Updates...", nil, func(_ *menu.CallbackData) {
+		info, err := app.CheckForUpdate()
+		if err != nil {
+			runtime.EventsEmit(app.ctx, "update:error", err.Error())
+			return
+		}
+		runtime.EventsEmit(app.ctx, "update:checked", info)
+	})
 	fileMenu.AddSeparator()
 	fileMenu.AddText("Quit", keys.CmdOrCtrl("q"), func(_ *menu.CallbackData) {
 		runtime.Quit(app.ctx)
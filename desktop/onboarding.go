@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/mcpconfig"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// kdfCalibrationTarget is how long one unlock should take with calibrated
+// KDF parameters, as in `secretctl init`.
+const kdfCalibrationTarget = time.Second
+
+// KDFSettings are Argon2id parameters offered by the first-run wizard
+type KDFSettings struct {
+	Memory      uint32 `json:"memory"` // KiB
+	Iterations  uint32 `json:"iterations"`
+	Threads     uint8  `json:"threads"`
+	Description string `json:"description"`
+}
+
+// MCPClientStatus is an AI client found on this machine
+type MCPClientStatus struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ConfigPath string `json:"configPath"`
+	Configured bool   `json:"configured"` // secretctl is already registered
+}
+
+// OnboardingStatus is what the first-run wizard needs to know
+type OnboardingStatus struct {
+	VaultDir        string            `json:"vaultDir"`
+	DefaultVaultDir string            `json:"defaultVaultDir"`
+	VaultExists     bool              `json:"vaultExists"` // e.g. created with the CLI; offer to reuse it
+	DefaultKDF      KDFSettings       `json:"defaultKdf"`
+	CLIPath         string            `json:"cliPath,omitempty"` // secretctl CLI that MCP clients start
+	MCPClients      []MCPClientStatus `json:"mcpClients"`
+}
+
+// MCPConfigPreview shows the user what ConfigureMCPClient would write
+type MCPConfigPreview struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ConfigPath string `json:"configPath"`
+	Before     string `json:"before,omitempty"`
+	After      string `json:"after"`
+	Changed    bool   `json:"changed"`
+}
+
+// MCPConfigResult reports a configured client
+type MCPConfigResult struct {
+	ConfigPath string `json:"configPath"`
+	Backup     string `json:"backup,omitempty"`
+}
+
+// defaultVaultDir is the vault the CLI uses
+func defaultVaultDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".secretctl")
+}
+
+// desktopPrefs are desktop preferences that must be known before a vault
+// is opened.
+type desktopPrefs struct {
+	VaultDir string `json:"vault_dir,omitempty"`
+}
+
+func desktopPrefsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "secretctl", "desktop.json"), nil
+}
+
+// loadDesktopPrefs returns the saved preferences, or none if they cannot
+// be read.
+func loadDesktopPrefs() desktopPrefs {
+	var p desktopPrefs
+	path, err := desktopPrefsPath()
+	if err != nil {
+		return p
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &p)
+	}
+	return p
+}
+
+func saveDesktopPrefs(p desktopPrefs) error {
+	path, err := desktopPrefsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data, 0o600)
+}
+
+func kdfSettings(p crypto.KDFParams) KDFSettings {
+	return KDFSettings{Memory: p.Memory, Iterations: p.Time, Threads: p.Threads, Description: p.String()}
+}
+
+// GetOnboardingStatus detects an existing vault, the secretctl CLI and
+// AI clients that can be configured for MCP
+func (a *App) GetOnboardingStatus() (*OnboardingStatus, error) {
+	status := &OnboardingStatus{
+		VaultDir:        a.vaultDir,
+		DefaultVaultDir: defaultVaultDir(),
+		VaultExists:     a.CheckVaultExists(),
+		DefaultKDF:      kdfSettings(crypto.DefaultKDFParams()),
+		MCPClients:      []MCPClientStatus{},
+	}
+	if path, err := exec.LookPath("secretctl"); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			status.CLIPath = abs
+		}
+	}
+	for _, c := range mcpconfig.Detect() {
+		path, err := mcpconfig.ConfigPath(c)
+		if err != nil {
+			continue
+		}
+		configured, _ := mcpconfig.Configured(c, path)
+		status.MCPClients = append(status.MCPClients, MCPClientStatus{
+			ID:         string(c),
+			Name:       c.Name(),
+			ConfigPath: path,
+			Configured: configured,
+		})
+	}
+	return status, nil
+}
+
+// CalibrateKDF measures this machine and returns Argon2id parameters that
+// take about a second per unlock. It never returns weaker than the defaults.
+func (a *App) CalibrateKDF() KDFSettings {
+	return kdfSettings(crypto.CalibrateKDF(kdfCalibrationTarget))
+}
+
+// CreateVault creates and unlocks a new vault in dir (the current vault
+// directory if empty) with the given KDF parameters (the defaults if
+// zero). A different dir is remembered for the next start.
+func (a *App) CreateVault(dir, password string, kdf KDFSettings) error {
+	if len(password) < 8 {
+		return errors.New("password must be at least 8 characters")
+	}
+	params := crypto.DefaultKDFParams()
+	if kdf.Memory != 0 || kdf.Iterations != 0 || kdf.Threads != 0 {
+		params = crypto.KDFParams{Memory: kdf.Memory, Time: kdf.Iterations, Threads: kdf.Threads}
+		if err := params.Validate(); err != nil {
+			return err
+		}
+	}
+	if dir == "" {
+		dir = a.vaultDir
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid vault directory: %w", err)
+	}
+
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+
+	if a.unlocked {
+		return errors.New("vault already unlocked")
+	}
+	v := vault.New(dir)
+	v.SetAuditSource(audit.SourceUI)
+	if err := v.InitWithKDF(password, params); err != nil {
+		return err
+	}
+	if err := v.Unlock(password); err != nil {
+		return err
+	}
+
+	if dir != a.vaultDir {
+		prefs := loadDesktopPrefs()
+		prefs.VaultDir = dir
+		if dir == defaultVaultDir() {
+			prefs.VaultDir = ""
+		}
+		if err := saveDesktopPrefs(prefs); err != nil {
+			v.Lock()
+			return fmt.Errorf("failed to remember vault directory: %w", err)
+		}
+		a.vaultDir = dir
+	}
+
+	a.vault = v
+	a.unlocked = true
+	a.forwardVaultEvents(v)
+	a.startSharedSession(v)
+	return nil
+}
+
+// CreateRecoveryKit writes the vault's data encryption key to a new file
+// at path (secretctl-recovery-kit.txt in the home directory if empty) and
+// returns the path. The kit must be stored offline.
+func (a *App) CreateRecoveryKit(path string) (string, error) {
+	if !a.unlocked {
+		return "", errors.New("vault locked")
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, vault.RecoveryKitFileName)
+	}
+	if err := a.vault.IssueRecoveryKit(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// planMCPConfig plans registering the secretctl CLI with client. Without
+// SECRETCTL_PASSWORD in the entry, the server joins this app's session.
+// The vault directory is only passed when it is not the CLI default.
+func (a *App) planMCPConfig(client string) (*mcpconfig.Change, error) {
+	c := mcpconfig.Client(client)
+	path, err := mcpconfig.ConfigPath(c)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := exec.LookPath("secretctl")
+	if err != nil {
+		return nil, errors.New("the secretctl CLI was not found in PATH; install it to use MCP")
+	}
+	if cli, err = filepath.Abs(cli); err != nil {
+		return nil, err
+	}
+	vaultDir := a.vaultDir
+	if vaultDir == defaultVaultDir() {
+		vaultDir = ""
+	}
+	server := mcpconfig.SecretctlServer(cli, vaultDir, "")
+	return mcpconfig.Plan(c, path, server)
+}
+
+// PreviewMCPConfig returns the change ConfigureMCPClient would make to the
+// client's config, for the user to review
+func (a *App) PreviewMCPConfig(client string) (*MCPConfigPreview, error) {
+	change, err := a.planMCPConfig(client)
+	if err != nil {
+		return nil, err
+	}
+	return &MCPConfigPreview{
+		ID:         string(change.Client),
+		Name:       change.Client.Name(),
+		ConfigPath: change.Path,
+		Before:     string(change.Before),
+		After:      string(change.After),
+		Changed:    change.Changed(),
+	}, nil
+}
+
+// ConfigureMCPClient registers secretctl in the client's config after the
+// user approved the preview. approved is the After text of that preview;
+// if the config changed since, nothing is written. The previous config is
+// backed up next to it.
+func (a *App) ConfigureMCPClient(client, approved string) (*MCPConfigResult, error) {
+	change, err := a.planMCPConfig(client)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(change.After, []byte(approved)) {
+		return nil, errors.New("the configuration changed since it was previewed; review it again")
+	}
+	if err := change.Apply(); err != nil {
+		return nil, err
+	}
+	return &MCPConfigResult{ConfigPath: change.Path, Backup: change.Backup}, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/backup"
+)
+
+// RestorePreviewItem is one key of a restore preview
+type RestorePreviewItem struct {
+	Key             string `json:"key"`
+	Status          string `json:"status"` // new, identical, local-newer, backup-newer, local-only
+	LocalUpdatedAt  string `json:"localUpdatedAt,omitempty"`
+	BackupUpdatedAt string `json:"backupUpdatedAt,omitempty"`
+}
+
+// RestorePreview is what restoring a backup would change, for the restore wizard
+type RestorePreview struct {
+	CreatedAt   string               `json:"createdAt"`
+	SecretCount int                  `json:"secretCount"`
+	Items       []RestorePreviewItem `json:"items"`
+	// Hostname and ToolVersion say where the backup was written, if known
+	Hostname    string   `json:"hostname,omitempty"`
+	ToolVersion string   `json:"toolVersion,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// PreviewRestore compares a backup with the unlocked vault key by key.
+// password (or keyFile) decrypts the backup; vaultPassword opens the
+// backed-up vault and may be empty when it equals password.
+func (a *App) PreviewRestore(backupPath, password, keyFile, vaultPassword string) (*RestorePreview, error) {
+	if !a.unlocked {
+		return nil, errors.New("vault locked")
+	}
+
+	verify, err := backup.Verify(backupPath, []byte(password), keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !verify.Valid {
+		return nil, errors.New(verify.Error)
+	}
+
+	opts := backup.RestoreOptions{
+		VaultPath: a.vaultDir,
+		DryRun:    true,
+		Password:  []byte(password),
+		KeyFile:   keyFile,
+		Current:   a.vault,
+
+		ToolVersion: version,
+	}
+	if vaultPassword != "" {
+		opts.VaultPassword = []byte(vaultPassword)
+	}
+	result, err := backup.Restore(backupPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	preview := &RestorePreview{
+		CreatedAt:   verify.CreatedAt.Format(time.RFC3339),
+		SecretCount: verify.SecretCount,
+		Items:       make([]RestorePreviewItem, 0, len(result.Diff)),
+		Hostname:    verify.Hostname,
+		ToolVersion: verify.ToolVersion,
+		Warnings:    result.Warnings,
+	}
+	for _, d := range result.Diff {
+		preview.Items = append(preview.Items, RestorePreviewItem{
+			Key:             d.Key,
+			Status:          string(d.Status),
+			LocalUpdatedAt:  formatTime(d.LocalUpdatedAt),
+			BackupUpdatedAt: formatTime(d.BackupUpdatedAt),
+		})
+	}
+	return preview, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// startSharedSession serves the unlocked vault on the agent socket so that
+// an MCP server started without SECRETCTL_PASSWORD can join this session
+// after the user approves it. Must be called with stateMu held.
+func (a *App) startSharedSession(v *vault.Vault) {
+	server := agent.NewServer(v, &agent.ServerOptions{
+		HealthInterval: -1,
+		TamperInterval: -1, // the app watches the vault files itself
+		ApproveAttach:  a.approveAttach,
+		OnLock: func() {
+			// An attached client locked the vault; lock the app too. The
+			// frontend already got "vault:locked" from the vault event.
+			// Runs asynchronously because Lock closes the agent connection.
+			go func() { _ = a.Lock() }()
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.sharedSession = server
+	a.stopShared = cancel
+	go func() {
+		if err := server.Serve(ctx); err != nil {
+			// Typically another agent already owns the socket
+			slog.Warn("shared session unavailable", "err", err)
+		}
+	}()
+}
+
+// stopSharedSession stops serving the vault. Attached MCP servers notice
+// the shutdown and lock themselves. Must be called with stateMu held.
+func (a *App) stopSharedSession() {
+	if a.sharedSession == nil {
+		return
+	}
+	a.stopShared()
+	_ = a.sharedSession.Close()
+	a.sharedSession = nil
+	a.stopShared = nil
+}
+
+// approveAttach asks the user whether a process may join the unlocked
+// session. The process is named by the PID and executable from its socket
+// credentials; the client name it sent is shown only as its claim.
+func (a *App) approveAttach(req *agent.AttachRequest) bool {
+	exe := req.Executable
+	if exe == "" {
+		exe = "An unknown program"
+	}
+	answer, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:  runtime.QuestionDialog,
+		Title: "Allow access to the vault?",
+		Message: fmt.Sprintf("%s (pid %d) wants to use the unlocked vault. It calls itself %q. It will be locked when you lock secretctl.",
+			exe, req.PeerPID, req.Client),
+		Buttons:       []string{"Allow", "Deny"},
+		DefaultButton: "Deny",
+		CancelButton:  "Deny",
+	})
+	if err != nil {
+		return false
+	}
+	// Platforms without custom buttons answer "Yes"/"No"
+	return answer == "Allow" || answer == "Yes"
+}
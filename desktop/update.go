@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/update"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// version is the running release, set at build time with
+// -ldflags "-X main.version=<version>". Development builds never update.
+var version = "dev"
+
+// UpdateSettings are the update preferences shown in settings
+type UpdateSettings struct {
+	AutoCheck bool   `json:"autoCheck"`
+	Channel   string `json:"channel"` // stable, beta
+	LastCheck string `json:"lastCheck,omitempty"`
+}
+
+// UpdateInfo is the result of an update check
+type UpdateInfo struct {
+	CurrentVersion string `json:"currentVersion"`
+	Version        string `json:"version"`
+	Channel        string `json:"channel"`
+	ReleasedAt     string `json:"releasedAt"`
+	NotesURL       string `json:"notesUrl,omitempty"`
+	Available      bool   `json:"available"`
+	Installable    bool   `json:"installable"` // a signed download exists for this platform
+}
+
+// updateSettingsPath is where update preferences live. They are kept out
+// of the vault so that checks can run before it is unlocked.
+func updateSettingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "secretctl", "desktop-update.json"), nil
+}
+
+func loadUpdateSettings() (string, update.Settings, error) {
+	path, err := updateSettingsPath()
+	if err != nil {
+		return "", update.DefaultSettings(), err
+	}
+	s, err := update.LoadSettings(path)
+	return path, s, err
+}
+
+// GetUpdateSettings returns the update preferences
+func (a *App) GetUpdateSettings() (*UpdateSettings, error) {
+	_, s, err := loadUpdateSettings()
+	if err != nil {
+		return nil, err
+	}
+	result := &UpdateSettings{AutoCheck: s.AutoCheck, Channel: string(s.Channel)}
+	if s.LastCheck != nil {
+		result.LastCheck = s.LastCheck.Format(time.RFC3339)
+	}
+	return result, nil
+}
+
+// SetUpdateSettings turns automatic checks on or off and selects the
+// release channel
+func (a *App) SetUpdateSettings(settings UpdateSettings) error {
+	path, s, err := loadUpdateSettings()
+	if path == "" {
+		return err
+	}
+	// An unreadable settings file is simply replaced
+	if s.Channel != update.Channel(settings.Channel) {
+		// Check the new channel at the next opportunity
+		s.LastCheck = nil
+	}
+	s.AutoCheck = settings.AutoCheck
+	s.Channel = update.Channel(settings.Channel)
+	return update.SaveSettings(path, s)
+}
+
+// CheckForUpdate fetches the signed manifest of the selected channel
+func (a *App) CheckForUpdate() (*UpdateInfo, error) {
+	_, s, err := loadUpdateSettings()
+	if err != nil {
+		return nil, err
+	}
+	rel, err := update.NewChecker(version).Check(a.ctx, s.Channel)
+	if err != nil {
+		return nil, err
+	}
+	return updateInfo(rel), nil
+}
+
+// InstallUpdate downloads the newest release of the selected channel,
+// verifies it against the signed manifest, replaces the installed app and
+// relaunches it. The vault is locked on the way out.
+func (a *App) InstallUpdate() error {
+	if version == "dev" {
+		return errors.New("development builds cannot be updated")
+	}
+	_, s, err := loadUpdateSettings()
+	if err != nil {
+		return err
+	}
+	target, err := update.Target()
+	if err != nil {
+		return err
+	}
+
+	checker := update.NewChecker(version)
+	rel, err := checker.Check(a.ctx, s.Channel)
+	if err != nil {
+		return err
+	}
+	if !rel.Available {
+		return fmt.Errorf("secretctl %s is already up to date", version)
+	}
+
+	runtime.EventsEmit(a.ctx, "update:downloading", updateInfo(rel))
+	tempDir, err := os.MkdirTemp("", "secretctl-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	artifact, err := checker.Download(a.ctx, rel, tempDir)
+	if err != nil {
+		return err
+	}
+	if err := update.Install(artifact, target); err != nil {
+		return err
+	}
+
+	if err := update.Relaunch(target); err != nil {
+		// The new version is installed and starts next time
+		runtime.EventsEmit(a.ctx, "update:installed", updateInfo(rel))
+		return nil
+	}
+	runtime.Quit(a.ctx)
+	return nil
+}
+
+// startUpdater removes leftovers of the previous update and runs the
+// automatic check if it is due.
+func (a *App) startUpdater(ctx context.Context) {
+	if version == "dev" {
+		return
+	}
+	if target, err := update.Target(); err == nil {
+		update.CleanupPrevious(target)
+	}
+
+	path, s, err := loadUpdateSettings()
+	if err != nil || !s.DueForCheck(time.Now()) {
+		return
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, update.DefaultTimeout)
+	defer cancel()
+	rel, err := update.NewChecker(version).Check(checkCtx, s.Channel)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	s.LastCheck = &now
+	_ = update.SaveSettings(path, s)
+	if rel.Available {
+		info := updateInfo(rel)
+		a.updateMu.Lock()
+		a.pendingUpdate = info
+		a.updateMu.Unlock()
+		runtime.EventsEmit(ctx, "update:available", info)
+	}
+}
+
+// PendingUpdate returns the release the automatic check found, or nil.
+// The check may finish before the frontend listens for
+// "update:available", so the frontend asks once it does.
+func (a *App) PendingUpdate() *UpdateInfo {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	return a.pendingUpdate
+}
+
+func updateInfo(rel *update.Release) *UpdateInfo {
+	return &UpdateInfo{
+		CurrentVersion: version,
+		Version:        rel.Version,
+		Channel:        string(rel.Channel),
+		ReleasedAt:     rel.ReleasedAt.Format(time.RFC3339),
+		NotesURL:       rel.NotesURL,
+		Available:      rel.Available,
+		Installable:    rel.Available && rel.Download != nil,
+	}
+}
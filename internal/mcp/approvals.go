@@ -0,0 +1,396 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// ApprovalsFileName is the sealed file holding first-use grants and
+// pending approval requests.
+const ApprovalsFileName = "mcp-approvals"
+
+// Grant lifetimes
+const (
+	DefaultGrantTTL = 30 * 24 * time.Hour
+	MaxGrantTTL     = 365 * 24 * time.Hour
+)
+
+// pendingRequestTTL is how long an unanswered approval request is kept.
+const pendingRequestTTL = 24 * time.Hour
+
+// maxPendingRequests bounds the pending requests an agent can create.
+const maxPendingRequests = 100
+
+// unknownClient names MCP clients that did not identify themselves.
+const unknownClient = "unknown"
+
+// ErrApprovalRequired is returned when a client uses a key for the first
+// time and the user has not approved it yet.
+var ErrApprovalRequired = errors.New("first use of this key by this client requires approval")
+
+// ErrApprovalNotFound is returned when approving or denying an unknown request.
+var ErrApprovalNotFound = errors.New("approval request not found")
+
+// ErrApprovalsCorrupt is returned when the approvals file exists but was
+// not sealed with this vault's key or cannot be decoded.
+var ErrApprovalsCorrupt = errors.New("approvals file is corrupt")
+
+// FirstUseApproval configures the first-use approval mode: the first time an
+// MCP client touches a matching key, the tool call fails until the user
+// approves it with `secretctl mcp approve`. Approvals are remembered per
+// client and key until they expire, also across server restarts.
+type FirstUseApproval struct {
+	// Keys lists key patterns (path.Match syntax); empty means every key
+	Keys []string `yaml:"keys"`
+	// GrantTTL is how long an approval lasts (e.g. "720h"); default 30 days
+	GrantTTL string `yaml:"grant_ttl"`
+}
+
+// Validate checks the key patterns and the grant TTL.
+func (f *FirstUseApproval) Validate() error {
+	for _, pattern := range f.Keys {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid first_use_approval key pattern %q: %w", pattern, err)
+		}
+	}
+	if f.GrantTTL != "" {
+		ttl, err := time.ParseDuration(f.GrantTTL)
+		if err != nil || ttl <= 0 || ttl > MaxGrantTTL {
+			return fmt.Errorf("invalid first_use_approval grant_ttl %q (must be between 1s and %s)", f.GrantTTL, MaxGrantTTL)
+		}
+	}
+	return nil
+}
+
+// ttl returns the grant lifetime, DefaultGrantTTL if unset or invalid.
+func (f *FirstUseApproval) ttl() time.Duration {
+	if ttl, err := time.ParseDuration(f.GrantTTL); err == nil && ttl > 0 && ttl <= MaxGrantTTL {
+		return ttl
+	}
+	return DefaultGrantTTL
+}
+
+// RequiresApproval reports whether key is subject to first-use approval.
+// Invalid patterns match every key, as with pin_required.
+func (p *Policy) RequiresApproval(key string) bool {
+	if p.FirstUseApproval == nil {
+		return false
+	}
+	if len(p.FirstUseApproval.Keys) == 0 {
+		return true
+	}
+	for _, pattern := range p.FirstUseApproval.Keys {
+		matched, err := path.Match(pattern, key)
+		if matched || err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalRequest is a pending first-use request, waiting for the user.
+type ApprovalRequest struct {
+	ID          string        `json:"id"`
+	Client      string        `json:"client"`
+	Key         string        `json:"key"`
+	RequestedAt time.Time     `json:"requested_at"`
+	GrantTTL    time.Duration `json:"grant_ttl"`
+}
+
+// Grant allows an MCP client to use a key until ExpiresAt.
+type Grant struct {
+	Client    string    `json:"client"`
+	Key       string    `json:"key"`
+	GrantedAt time.Time `json:"granted_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// approvalSet is the sealed content of the approvals file.
+type approvalSet struct {
+	Requests []ApprovalRequest `json:"requests"`
+	Grants   []Grant           `json:"grants"`
+}
+
+// prune drops expired grants and stale requests.
+func (a *approvalSet) prune(now time.Time) {
+	grants := a.Grants[:0]
+	for _, g := range a.Grants {
+		if g.ExpiresAt.After(now) {
+			grants = append(grants, g)
+		}
+	}
+	a.Grants = grants
+	requests := a.Requests[:0]
+	for _, r := range a.Requests {
+		if now.Sub(r.RequestedAt) < pendingRequestTTL {
+			requests = append(requests, r)
+		}
+	}
+	a.Requests = requests
+}
+
+// take removes request id and returns it, or nil if there is none.
+func (a *approvalSet) take(id string) *ApprovalRequest {
+	for i, r := range a.Requests {
+		if r.ID == id {
+			a.Requests = append(a.Requests[:i], a.Requests[i+1:]...)
+			return &r
+		}
+	}
+	return nil
+}
+
+// ListApprovals returns the pending requests and the unexpired grants.
+func ListApprovals(v *vault.Vault, vaultPath string) ([]ApprovalRequest, []Grant, error) {
+	set, err := loadApprovals(v, vaultPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	set.prune(time.Now())
+	return set.Requests, set.Grants, nil
+}
+
+// Approve grants the client of request id access to its key. A ttl of 0
+// uses the grant_ttl of the policy that created the request.
+func Approve(v *vault.Vault, vaultPath, id string, ttl time.Duration) (*Grant, error) {
+	if ttl < 0 || ttl > MaxGrantTTL {
+		return nil, fmt.Errorf("grant ttl must be between 1s and %s", MaxGrantTTL)
+	}
+	unlock, err := lockApprovals(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	set, err := loadApprovals(v, vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	set.prune(now)
+
+	request := set.take(id)
+	if request == nil {
+		return nil, ErrApprovalNotFound
+	}
+
+	if ttl == 0 {
+		ttl = request.GrantTTL
+	}
+	if ttl <= 0 {
+		ttl = DefaultGrantTTL
+	}
+	grant := Grant{Client: request.Client, Key: request.Key, GrantedAt: now, ExpiresAt: now.Add(ttl)}
+	set.Grants = append(removeGrant(set.Grants, grant.Client, grant.Key), grant)
+	if err := saveApprovals(v, vaultPath, set); err != nil {
+		return nil, err
+	}
+
+	_ = v.Audit().Log(audit.OpMCPApprove, audit.SourceCLI, audit.ResultSuccess, grant.Key, nil,
+		map[string]interface{}{"client": grant.Client, "expires_at": grant.ExpiresAt.Format(time.RFC3339)})
+	return &grant, nil
+}
+
+// Deny discards request id. The client's next use of the key asks again.
+func Deny(v *vault.Vault, vaultPath, id string) (*ApprovalRequest, error) {
+	unlock, err := lockApprovals(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	set, err := loadApprovals(v, vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	set.prune(time.Now())
+
+	request := set.take(id)
+	if request == nil {
+		return nil, ErrApprovalNotFound
+	}
+	if err := saveApprovals(v, vaultPath, set); err != nil {
+		return nil, err
+	}
+
+	_ = v.Audit().Log(audit.OpMCPDeny, audit.SourceCLI, audit.ResultSuccess, request.Key, nil,
+		map[string]interface{}{"client": request.Client})
+	return request, nil
+}
+
+// RevokeGrant removes the grant of client for key before it expires.
+func RevokeGrant(v *vault.Vault, vaultPath, client, key string) error {
+	unlock, err := lockApprovals(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	set, err := loadApprovals(v, vaultPath)
+	if err != nil {
+		return err
+	}
+	n := len(set.Grants)
+	set.Grants = removeGrant(set.Grants, client, key)
+	if len(set.Grants) == n {
+		return fmt.Errorf("no grant for client '%s' and key '%s'", client, key)
+	}
+	if err := saveApprovals(v, vaultPath, set); err != nil {
+		return err
+	}
+
+	_ = v.Audit().Log(audit.OpMCPRevoke, audit.SourceCLI, audit.ResultSuccess, key, nil,
+		map[string]interface{}{"client": client})
+	return nil
+}
+
+func removeGrant(grants []Grant, client, key string) []Grant {
+	kept := grants[:0]
+	for _, g := range grants {
+		if g.Client != client || g.Key != key {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+// lockApprovals takes the lock that serializes the load-modify-save cycles
+// of the approvals file across the CLI and every MCP server, and returns
+// the function that releases it.
+func lockApprovals(vaultPath string) (func(), error) {
+	unlock, err := atomicfile.Lock(filepath.Join(vaultPath, ApprovalsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock approvals file: %w", err)
+	}
+	return unlock, nil
+}
+
+// loadApprovals reads the approvals file. A missing file yields no grants.
+// A file that fails to unseal or decode is an error rather than empty, so
+// that a forged or damaged file grants nothing and is not overwritten,
+// discarding the real grants, by the next save.
+func loadApprovals(v *vault.Vault, vaultPath string) (*approvalSet, error) {
+	path := filepath.Join(vaultPath, ApprovalsFileName)
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &approvalSet{}, nil
+		}
+		return nil, fmt.Errorf("failed to read approvals file: %w", err)
+	}
+	data, err := v.Unseal(sealed)
+	if err != nil {
+		if errors.Is(err, vault.ErrVaultLocked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: approvals file cannot be unsealed (remove %s to reset approvals)", ErrApprovalsCorrupt, path)
+	}
+	var set approvalSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %v (remove %s to reset approvals)", ErrApprovalsCorrupt, err, path)
+	}
+	return &set, nil
+}
+
+func saveApprovals(v *vault.Vault, vaultPath string, set *approvalSet) error {
+	data, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to marshal approvals: %w", err)
+	}
+	sealed, err := v.Seal(data)
+	if err != nil {
+		return fmt.Errorf("failed to seal approvals: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(vaultPath, ApprovalsFileName), sealed); err != nil {
+		return fmt.Errorf("failed to write approvals file: %w", err)
+	}
+	return nil
+}
+
+// handleInitialized remembers the name the MCP client reported, which
+// identifies it in approval requests and grants.
+func (s *Server) handleInitialized(_ context.Context, req *mcp.InitializedRequest) {
+	params := req.Session.InitializeParams()
+	if params == nil || params.ClientInfo == nil || params.ClientInfo.Name == "" {
+		return
+	}
+	s.approvalsMu.Lock()
+	s.client = params.ClientInfo.Name
+	s.approvalsMu.Unlock()
+}
+
+// checkFirstUse returns ErrApprovalRequired if the policy requires
+// first-use approval for key and this client has no unexpired grant. It
+// then records a pending request for the user to approve; denials are
+// audited as op.
+func (s *Server) checkFirstUse(op, key string) error {
+	if s.policy == nil || !s.policy.RequiresApproval(key) {
+		return nil
+	}
+	s.approvalsMu.Lock()
+	defer s.approvalsMu.Unlock()
+
+	client := s.client
+	if client == "" {
+		client = unknownClient
+	}
+	unlock, err := lockApprovals(s.vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	set, err := loadApprovals(s.vault, s.vaultPath)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	set.prune(now)
+	for _, g := range set.Grants {
+		if g.Client == client && g.Key == key {
+			return nil
+		}
+	}
+
+	var request *ApprovalRequest
+	for i := range set.Requests {
+		if set.Requests[i].Client == client && set.Requests[i].Key == key {
+			request = &set.Requests[i]
+			break
+		}
+	}
+	if request == nil {
+		if len(set.Requests) >= maxPendingRequests {
+			_ = s.vault.Audit().LogDenied(op, audit.SourceMCP, key, "first-use approval required (too many pending requests)")
+			return fmt.Errorf("%w: too many pending requests, ask the user to review 'secretctl mcp approvals'", ErrApprovalRequired)
+		}
+		id := make([]byte, 4)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("failed to generate request id: %w", err)
+		}
+		set.Requests = append(set.Requests, ApprovalRequest{
+			ID:          hex.EncodeToString(id),
+			Client:      client,
+			Key:         key,
+			RequestedAt: now,
+			GrantTTL:    s.policy.FirstUseApproval.ttl(),
+		})
+		request = &set.Requests[len(set.Requests)-1]
+		if err := saveApprovals(s.vault, s.vaultPath, set); err != nil {
+			return err
+		}
+	}
+
+	_ = s.vault.Audit().LogDenied(op, audit.SourceMCP, key, fmt.Sprintf("first-use approval required (request %s, client %s)", request.ID, client))
+	return fmt.Errorf("%w: ask the user to run 'secretctl mcp approve %s', then retry", ErrApprovalRequired, request.ID)
+}
@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+)
+
+// testApprovalServer returns a server whose policy requires first-use
+// approval for prod/*.
+func testApprovalServer(t *testing.T) *Server {
+	t.Helper()
+	v, tmpDir := testVault(t)
+	addTestSecret(t, v, "prod/db", []byte("prod-secret"))
+	addTestSecret(t, v, "dev/db", []byte("dev-secret"))
+
+	return &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy: &Policy{Version: 1, DefaultAction: ActionDeny, FirstUseApproval: &FirstUseApproval{
+			Keys:     []string{"prod/*"},
+			GrantTTL: "1h",
+		}},
+		client: "claude-desktop",
+	}
+}
+
+func TestValidatePolicy_FirstUseApproval(t *testing.T) {
+	tests := []struct {
+		name string
+		f    FirstUseApproval
+		ok   bool
+	}{
+		{"defaults", FirstUseApproval{}, true},
+		{"patterns and ttl", FirstUseApproval{Keys: []string{"prod/*"}, GrantTTL: "8h"}, true},
+		{"bad pattern", FirstUseApproval{Keys: []string{"prod/["}}, false},
+		{"bad ttl", FirstUseApproval{GrantTTL: "30d"}, false},
+		{"ttl too long", FirstUseApproval{GrantTTL: "9000h"}, false},
+	}
+	for _, tt := range tests {
+		p := &Policy{Version: 1, DefaultAction: ActionDeny, FirstUseApproval: &tt.f}
+		if err := p.ValidatePolicy(); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidatePolicy() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestPolicy_RequiresApproval(t *testing.T) {
+	if (&Policy{}).RequiresApproval("prod/db") {
+		t.Error("policy without first_use_approval should not require approval")
+	}
+	all := &Policy{FirstUseApproval: &FirstUseApproval{}}
+	if !all.RequiresApproval("dev/db") {
+		t.Error("empty keys should require approval for every key")
+	}
+	prod := &Policy{FirstUseApproval: &FirstUseApproval{Keys: []string{"prod/*"}}}
+	if !prod.RequiresApproval("prod/db") || prod.RequiresApproval("dev/db") {
+		t.Error("keys pattern not applied")
+	}
+}
+
+func TestFirstUseApproval(t *testing.T) {
+	s := testApprovalServer(t)
+	ctx := context.Background()
+
+	// Keys outside the patterns are unaffected
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "dev/db"}); err != nil {
+		t.Fatalf("dev/db should not need approval: %v", err)
+	}
+
+	_, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"})
+	if !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("expected ErrApprovalRequired, got %v", err)
+	}
+	// Asking again reuses the pending request
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"}); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("expected ErrApprovalRequired, got %v", err)
+	}
+
+	requests, grants, err := ListApprovals(s.vault, s.vaultPath)
+	if err != nil {
+		t.Fatalf("ListApprovals failed: %v", err)
+	}
+	if len(requests) != 1 || len(grants) != 0 {
+		t.Fatalf("expected 1 request and no grants, got %+v %+v", requests, grants)
+	}
+	r := requests[0]
+	if r.Client != "claude-desktop" || r.Key != "prod/db" || r.GrantTTL != time.Hour {
+		t.Errorf("unexpected request %+v", r)
+	}
+
+	if _, err := Approve(s.vault, s.vaultPath, "nope", 0); !errors.Is(err, ErrApprovalNotFound) {
+		t.Errorf("expected ErrApprovalNotFound, got %v", err)
+	}
+	grant, err := Approve(s.vault, s.vaultPath, r.ID, 0)
+	if err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if d := grant.ExpiresAt.Sub(grant.GrantedAt); d != time.Hour {
+		t.Errorf("grant lasts %s, want the policy's 1h", d)
+	}
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"}); err != nil {
+		t.Fatalf("approved key should be usable: %v", err)
+	}
+
+	// Grants are per client
+	s.client = "other-agent"
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"}); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("expected ErrApprovalRequired for another client, got %v", err)
+	}
+	requests, _, _ = ListApprovals(s.vault, s.vaultPath)
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 pending request, got %+v", requests)
+	}
+	if _, err := Deny(s.vault, s.vaultPath, requests[0].ID); err != nil {
+		t.Fatalf("Deny failed: %v", err)
+	}
+	if requests, _, _ = ListApprovals(s.vault, s.vaultPath); len(requests) != 0 {
+		t.Errorf("denied request still pending: %+v", requests)
+	}
+
+	// Revoking ends the grant
+	s.client = "claude-desktop"
+	if err := RevokeGrant(s.vault, s.vaultPath, "claude-desktop", "prod/db"); err != nil {
+		t.Fatalf("RevokeGrant failed: %v", err)
+	}
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"}); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("expected ErrApprovalRequired after revoke, got %v", err)
+	}
+	if err := RevokeGrant(s.vault, s.vaultPath, "claude-desktop", "prod/db"); err == nil {
+		t.Error("expected error revoking a missing grant")
+	}
+}
+
+func TestFirstUseApproval_WaitsForFileLock(t *testing.T) {
+	s := testApprovalServer(t)
+
+	// Another process is updating the approvals file
+	unlock, err := atomicfile.Lock(filepath.Join(s.vaultPath, ApprovalsFileName))
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.checkFirstUse("test", "prod/db") }()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("checkFirstUse did not wait for the file lock: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	if err := <-done; !errors.Is(err, ErrApprovalRequired) {
+		t.Errorf("expected ErrApprovalRequired, got %v", err)
+	}
+}
+
+func TestFirstUseApproval_CorruptFile(t *testing.T) {
+	s := testApprovalServer(t)
+
+	path := filepath.Join(s.vaultPath, ApprovalsFileName)
+	forged := []byte(`{"grants":[{"client":"claude-desktop","key":"prod/db","expires_at":"2999-01-01T00:00:00Z"}]}`)
+	if err := os.WriteFile(path, forged, 0600); err != nil {
+		t.Fatalf("failed to write approvals file: %v", err)
+	}
+
+	if err := s.checkFirstUse("test", "prod/db"); !errors.Is(err, ErrApprovalsCorrupt) {
+		t.Errorf("expected ErrApprovalsCorrupt, got %v", err)
+	}
+	if _, _, err := ListApprovals(s.vault, s.vaultPath); !errors.Is(err, ErrApprovalsCorrupt) {
+		t.Errorf("expected ErrApprovalsCorrupt, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, forged) {
+		t.Error("corrupt approvals file was overwritten")
+	}
+}
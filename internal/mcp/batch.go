@@ -0,0 +1,218 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// maxBatchSteps is the maximum number of commands in one secret_run_batch.
+const maxBatchSteps = 5
+
+// maxBatchDuration caps the total run time of a secret_run_batch.
+const maxBatchDuration = time.Hour
+
+// SecretRunBatchInput represents input for secret_run_batch tool. The
+// secrets are read and the environment is built once, then every step runs
+// with it in order.
+type SecretRunBatchInput struct {
+	Keys       []string    `json:"keys"`
+	EnvPrefix  string      `json:"env_prefix,omitempty"`
+	Env        string      `json:"env,omitempty"`
+	ProjectDir string      `json:"project_dir,omitempty"`
+	Steps      []BatchStep `json:"steps"`
+	// ContinueOnError runs the remaining steps after one exits non-zero
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+}
+
+// BatchStep is one command of a secret_run_batch.
+type BatchStep struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Timeout string   `json:"timeout,omitempty"`
+	Output  string   `json:"output,omitempty"`
+}
+
+// SecretRunBatchOutput represents output for secret_run_batch tool.
+type SecretRunBatchOutput struct {
+	// Steps holds the result of every step that ran, in order
+	Steps []SecretRunOutput `json:"steps"`
+	// Stopped is true when a step exited non-zero and the rest were skipped
+	Stopped    bool  `json:"stopped"`
+	DurationMs int64 `json:"duration_ms"`
+	Sanitized  bool  `json:"sanitized"`
+}
+
+// handleSecretRunBatch handles the secret_run_batch tool call. Every step
+// must pass the same checks as secret_run before anything runs, and each
+// step counts as a run against the session budget.
+func (s *Server) handleSecretRunBatch(ctx context.Context, _ *mcp.CallToolRequest, input *SecretRunBatchInput) (*mcp.CallToolResult, SecretRunBatchOutput, error) {
+	// One concurrency slot for the whole batch
+	select {
+	case s.runSem <- struct{}{}:
+		defer func() { <-s.runSem }()
+	default:
+		_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, "", "RATE_LIMITED", "too many concurrent operations")
+		return nil, SecretRunBatchOutput{}, errors.New("too many concurrent secret_run operations (max 5)")
+	}
+
+	invalid := func(msg string) (*mcp.CallToolResult, SecretRunBatchOutput, error) {
+		_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, "", "INVALID_INPUT", msg)
+		return nil, SecretRunBatchOutput{}, errors.New(msg)
+	}
+	if len(input.Keys) == 0 && input.ProjectDir == "" {
+		return invalid("keys is required")
+	}
+	if len(input.Keys) > 0 && input.ProjectDir != "" {
+		return invalid("keys and project_dir are mutually exclusive")
+	}
+	if len(input.Keys) > 10 {
+		return invalid("too many keys (max 10)")
+	}
+	if len(input.Steps) == 0 {
+		return invalid("steps is required")
+	}
+	if len(input.Steps) > maxBatchSteps {
+		return invalid(fmt.Sprintf("too many steps (max %d)", maxBatchSteps))
+	}
+
+	if s.policy == nil {
+		_ = s.vault.Audit().LogDenied(audit.OpSecretRunDenied, audit.SourceMCP, "", "NO_POLICY")
+		return nil, SecretRunBatchOutput{}, errors.New("MCP policy not configured. Create ~/.secretctl/mcp-policy.yaml to enable secret_run")
+	}
+
+	// Validate every step before any secret is read or command is run
+	resolved := make([]string, len(input.Steps))
+	timeouts := make([]time.Duration, len(input.Steps))
+	for i, step := range input.Steps {
+		if step.Command == "" {
+			return invalid(fmt.Sprintf("step %d: command is required", i+1))
+		}
+		if len(step.Command) > 4096 {
+			return invalid(fmt.Sprintf("step %d: command too long (max 4096)", i+1))
+		}
+		if len(step.Args) > 100 {
+			return invalid(fmt.Sprintf("step %d: too many args (max 100)", i+1))
+		}
+		if err := validateOutputMode(step.Output); err != nil {
+			return invalid(fmt.Sprintf("step %d: %v", i+1, err))
+		}
+		timeout, err := parseRunTimeout(step.Timeout)
+		if err != nil {
+			_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, "", "INVALID_TIMEOUT", err.Error())
+			return nil, SecretRunBatchOutput{}, fmt.Errorf("step %d: invalid timeout format: %w", i+1, err)
+		}
+		timeouts[i] = timeout
+
+		resolvedCmd, err := ResolveAndValidateCommand(step.Command)
+		if err != nil {
+			return nil, SecretRunBatchOutput{}, fmt.Errorf("step %d: command validation failed: %w", i+1, err)
+		}
+		allowed, reason := s.policy.IsCommandAllowed(step.Command)
+		if !allowed {
+			allowed, reason = s.policy.IsCommandAllowed(resolvedCmd)
+		}
+		if !allowed {
+			_ = s.vault.Audit().LogDenied(audit.OpSecretRunDenied, audit.SourceMCP, step.Command, reason)
+			return nil, SecretRunBatchOutput{}, fmt.Errorf("step %d: command not allowed by policy: %s", i+1, reason)
+		}
+		resolved[i] = resolvedCmd
+	}
+
+	keys := input.Keys
+	if input.Env != "" {
+		resolvedKeys, err := s.policy.ResolveAliasKeys(input.Env, input.Keys)
+		if err != nil {
+			_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, "", "ALIAS_FAILED", err.Error())
+			return nil, SecretRunBatchOutput{}, fmt.Errorf("failed to resolve environment alias '%s': %w", input.Env, err)
+		}
+		keys = resolvedKeys
+	}
+
+	// Materialize the secrets once for all steps
+	var secrets []secretData
+	var err error
+	if input.ProjectDir != "" {
+		secrets, err = s.collectProjectSecrets(input.ProjectDir)
+	} else {
+		secrets, err = s.collectSecrets(keys)
+	}
+	if err != nil {
+		_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, "", "SECRET_COLLECT_FAILED", err.Error())
+		return nil, SecretRunBatchOutput{}, err
+	}
+	defer wipeSecrets(secrets)
+
+	injected := 0
+	for _, secret := range secrets {
+		injected += len(secret.value)
+	}
+	if err := s.useInjectedBytes(audit.OpSecretRunBatch, injected); err != nil {
+		return nil, SecretRunBatchOutput{}, err
+	}
+
+	env, err := s.buildEnvironment(secrets, input.EnvPrefix)
+	if err != nil {
+		_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, "", "ENV_BUILD_FAILED", err.Error())
+		return nil, SecretRunBatchOutput{}, err
+	}
+	defer wipeEnvSlice(env)
+	sanitizer := newOutputSanitizer(secrets)
+
+	ctx, cancel := context.WithTimeout(ctx, maxBatchDuration)
+	defer cancel()
+
+	var out SecretRunBatchOutput
+	commands := make([]string, 0, len(input.Steps))
+	startTime := time.Now()
+	for i, step := range input.Steps {
+		if err := s.useRun(audit.OpSecretRunBatch); err != nil {
+			return nil, SecretRunBatchOutput{}, err
+		}
+		for _, secret := range secrets {
+			s.recordUsage(secret.key, step.Command)
+		}
+
+		stepStart := time.Now()
+		result, err := s.runCommand(ctx, resolved[i], step.Args, env, sanitizer, timeouts[i], step.Output)
+		if err != nil {
+			_ = s.vault.Audit().LogError(audit.OpSecretRunBatch, audit.SourceMCP, step.Command, "EXEC_FAILED", err.Error())
+			return nil, SecretRunBatchOutput{}, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		result.DurationMs = time.Since(stepStart).Milliseconds()
+		result.Sanitized = true
+		out.Steps = append(out.Steps, *result)
+		commands = append(commands, step.Command)
+
+		if result.ExitCode != 0 && !input.ContinueOnError && i < len(input.Steps)-1 {
+			out.Stopped = true
+			break
+		}
+	}
+	out.DurationMs = time.Since(startTime).Milliseconds()
+	out.Sanitized = true
+
+	_ = s.vault.Audit().LogSuccess(audit.OpSecretRunBatch, audit.SourceMCP, strings.Join(commands, ","))
+	return nil, out, nil
+}
+
+// parseRunTimeout parses a secret_run timeout: 5 minutes if empty, capped
+// at 1 hour.
+func parseRunTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 5 * time.Minute, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		if timeout, err = parseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	return min(timeout, time.Hour), nil
+}
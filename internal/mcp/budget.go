@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// SessionBudget caps what a single MCP server session may do, limiting the
+// damage of a misbehaving agent. Zero values mean unlimited. Exceeding any
+// limit locks the session until the server is restarted.
+type SessionBudget struct {
+	// MaxSecrets is the number of distinct secret keys tools may touch
+	MaxSecrets int `yaml:"max_secrets"`
+	// MaxRuns is the number of secret_run and secret_run_with_bindings calls
+	// and secret_run_batch steps
+	MaxRuns int `yaml:"max_runs"`
+	// MaxInjectedBytes is the total size of secret values injected into commands
+	MaxInjectedBytes int64 `yaml:"max_injected_bytes"`
+}
+
+// ErrSessionLocked is returned by every tool once the session budget is exceeded.
+var ErrSessionLocked = errors.New("MCP session locked: budget exceeded, restart the MCP server to continue")
+
+// budgetUsage tracks consumption of the session budget.
+type budgetUsage struct {
+	mu       sync.Mutex
+	secrets  map[string]bool
+	runs     int
+	injected int64
+	locked   string // reason the session was locked, empty while usable
+}
+
+// Validate checks that no limit is negative.
+func (b *SessionBudget) Validate() error {
+	if b.MaxSecrets < 0 || b.MaxRuns < 0 || b.MaxInjectedBytes < 0 {
+		return errors.New("session_budget limits must not be negative")
+	}
+	return nil
+}
+
+func (s *Server) budget() *SessionBudget {
+	if s.policy == nil || s.policy.SessionBudget == nil {
+		return &SessionBudget{}
+	}
+	return s.policy.SessionBudget
+}
+
+// checkSessionLocked returns ErrSessionLocked once the budget was exceeded.
+func (s *Server) checkSessionLocked() error {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if s.usage.locked != "" {
+		return fmt.Errorf("%w (%s)", ErrSessionLocked, s.usage.locked)
+	}
+	return nil
+}
+
+// useSecret counts key against max_secrets. Keys already touched are free.
+func (s *Server) useSecret(op, key string) error {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if s.usage.locked != "" {
+		return fmt.Errorf("%w (%s)", ErrSessionLocked, s.usage.locked)
+	}
+	if s.usage.secrets[key] {
+		return nil
+	}
+	if limit := s.budget().MaxSecrets; limit > 0 && len(s.usage.secrets) >= limit {
+		return s.lockSessionLocked(op, key, fmt.Sprintf("more than %d distinct secrets", limit))
+	}
+	if s.usage.secrets == nil {
+		s.usage.secrets = make(map[string]bool)
+	}
+	s.usage.secrets[key] = true
+	return nil
+}
+
+// useRun counts one command execution against max_runs.
+func (s *Server) useRun(op string) error {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if s.usage.locked != "" {
+		return fmt.Errorf("%w (%s)", ErrSessionLocked, s.usage.locked)
+	}
+	if limit := s.budget().MaxRuns; limit > 0 && s.usage.runs >= limit {
+		return s.lockSessionLocked(op, "", fmt.Sprintf("more than %d runs", limit))
+	}
+	s.usage.runs++
+	return nil
+}
+
+// useInjectedBytes counts n secret bytes against max_injected_bytes.
+func (s *Server) useInjectedBytes(op string, n int) error {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if s.usage.locked != "" {
+		return fmt.Errorf("%w (%s)", ErrSessionLocked, s.usage.locked)
+	}
+	if limit := s.budget().MaxInjectedBytes; limit > 0 && s.usage.injected+int64(n) > limit {
+		return s.lockSessionLocked(op, "", fmt.Sprintf("more than %d injected bytes", limit))
+	}
+	s.usage.injected += int64(n)
+	return nil
+}
+
+// lockSessionLocked locks the session and the vault. s.usage.mu must be held.
+func (s *Server) lockSessionLocked(op, key, reason string) error {
+	s.usage.locked = reason
+	_ = s.vault.Audit().LogDenied(audit.OpMCPSessionLocked, audit.SourceMCP, key, fmt.Sprintf("%s: %s", op, reason))
+	s.vault.Lock()
+	s.lockSharedSession()
+	return fmt.Errorf("%w (%s)", ErrSessionLocked, reason)
+}
+
+// authorize checks that a tool may touch key: the session must not be
+// locked, the key must be pinned if required, and it must fit the budget.
+func (s *Server) authorize(op, key string) error {
+	if err := s.checkSessionLocked(); err != nil {
+		return err
+	}
+	if err := s.checkPin(op, key); err != nil {
+		return err
+	}
+	if err := s.checkFirstUse(op, key); err != nil {
+		return err
+	}
+	return s.useSecret(op, key)
+}
+
+// addTool registers a tool whose handler is refused once the session is locked.
+func addTool[In, Out any](s *Server, tool *mcp.Tool, h mcp.ToolHandlerFor[In, Out]) {
+	mcp.AddTool(s.server, tool, func(ctx context.Context, req *mcp.CallToolRequest, input In) (*mcp.CallToolResult, Out, error) {
+		if err := s.checkSessionLocked(); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		return h(ctx, req, input)
+	})
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func testBudgetServer(t *testing.T, budget *SessionBudget) *Server {
+	t.Helper()
+	v, tmpDir := testVault(t)
+	addTestSecret(t, v, "a", []byte("value-a"))
+	addTestSecret(t, v, "b", []byte("value-b"))
+	addTestSecret(t, v, "c", []byte("value-c"))
+	return &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy:    &Policy{Version: 1, DefaultAction: ActionDeny, SessionBudget: budget},
+	}
+}
+
+func TestSessionBudget_MaxSecrets(t *testing.T) {
+	s := testBudgetServer(t, &SessionBudget{MaxSecrets: 2})
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "a"} {
+		if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: key}); err != nil {
+			t.Fatalf("get_masked %s within budget failed: %v", key, err)
+		}
+	}
+
+	_, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "c"})
+	if !errors.Is(err, ErrSessionLocked) {
+		t.Fatalf("expected ErrSessionLocked for third secret, got %v", err)
+	}
+
+	// Once locked, even secrets already touched are refused and the vault is locked
+	if _, _, err := s.handleSecretExists(ctx, nil, SecretExistsInput{Key: "a"}); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("expected ErrSessionLocked after lock, got %v", err)
+	}
+	if !s.vault.IsLocked() {
+		t.Error("expected vault to be locked after budget was exceeded")
+	}
+}
+
+func TestSessionBudget_MaxRunsAndBytes(t *testing.T) {
+	s := testBudgetServer(t, &SessionBudget{MaxRuns: 1})
+	if err := s.useRun("test"); err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if err := s.useRun("test"); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("expected ErrSessionLocked for second run, got %v", err)
+	}
+
+	s = testBudgetServer(t, &SessionBudget{MaxInjectedBytes: 10})
+	if err := s.useInjectedBytes("test", 10); err != nil {
+		t.Fatalf("injecting 10 bytes failed: %v", err)
+	}
+	if err := s.useInjectedBytes("test", 1); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("expected ErrSessionLocked above max_injected_bytes, got %v", err)
+	}
+}
+
+func TestSessionBudget_Unlimited(t *testing.T) {
+	s := testBudgetServer(t, nil)
+	for i := 0; i < 100; i++ {
+		if err := s.useRun("test"); err != nil {
+			t.Fatalf("run %d failed without budget: %v", i, err)
+		}
+	}
+	if err := s.useInjectedBytes("test", 1<<30); err != nil {
+		t.Fatalf("injecting without budget failed: %v", err)
+	}
+}
+
+func TestValidatePolicy_NegativeBudget(t *testing.T) {
+	p := &Policy{Version: 1, DefaultAction: ActionDeny, SessionBudget: &SessionBudget{MaxRuns: -1}}
+	if err := p.ValidatePolicy(); err == nil {
+		t.Error("expected error for negative session_budget limit")
+	}
+}
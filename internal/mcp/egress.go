@@ -0,0 +1,328 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// EgressPolicy limits the destinations that commands run with secrets can
+// reach through HTTP(S) proxies. Each command gets HTTPS_PROXY and
+// HTTP_PROXY pointing at a proxy on 127.0.0.1 that connects only to allowed
+// destinations and audits every destination tried. Programs that ignore the
+// proxy variables are not restricted.
+type EgressPolicy struct {
+	// AllowedHosts are host names, either exact ("api.github.com") or
+	// wildcards that match any subdomain ("*.amazonaws.com")
+	AllowedHosts []string `yaml:"allowed_hosts" json:"allowed_hosts,omitempty"`
+	// AllowedCIDRs are networks that may be reached, by address or by a
+	// host name that resolves into them
+	AllowedCIDRs []string `yaml:"allowed_cidrs" json:"allowed_cidrs,omitempty"`
+}
+
+// EgressReport lists the destinations (host:port) a command tried to reach
+// through the egress proxy.
+type EgressReport struct {
+	Allowed []string `json:"allowed"`
+	Denied  []string `json:"denied"`
+}
+
+// egressHostPattern matches an AllowedHosts entry.
+var egressHostPattern = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// errEgressDenied is returned when the proxy refuses a destination.
+var errEgressDenied = errors.New("destination not allowed by egress policy")
+
+// proxyEnvVars are replaced in the command's environment, so that it
+// cannot bypass the egress proxy with NO_PROXY or a proxy of its own.
+var proxyEnvVars = map[string]bool{
+	"HTTPS_PROXY": true,
+	"HTTP_PROXY":  true,
+	"ALL_PROXY":   true,
+	"NO_PROXY":    true,
+}
+
+// Validate checks every host name and network.
+func (p *EgressPolicy) Validate() error {
+	for _, host := range p.AllowedHosts {
+		if !egressHostPattern.MatchString(host) {
+			return fmt.Errorf("invalid egress allowed_hosts entry %q (e.g. api.github.com or *.amazonaws.com, in lower case)", host)
+		}
+	}
+	for _, cidr := range p.AllowedCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid egress allowed_cidrs entry %q: %w", cidr, err)
+		}
+	}
+	return nil
+}
+
+// validateEgress checks the egress policy, which needs network access to
+// reach the proxy.
+func (p *Policy) validateEgress() error {
+	if p.Egress == nil {
+		return nil
+	}
+	if p.Sandbox != nil && !p.Sandbox.AllowNetwork {
+		return errors.New("egress requires sandbox allow_network: without network access commands cannot reach the egress proxy")
+	}
+	return p.Egress.Validate()
+}
+
+// allowsHost reports whether host matches AllowedHosts.
+func (p *EgressPolicy) allowsHost(host string) bool {
+	for _, pattern := range p.AllowedHosts {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAddr reports whether addr is in AllowedCIDRs.
+func (p *EgressPolicy) allowsAddr(addr netip.Addr) bool {
+	for _, cidr := range p.AllowedCIDRs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// egressProxy is an HTTP proxy for one command that enforces an
+// EgressPolicy. It tunnels CONNECT requests (HTTPS) and forwards plain
+// HTTP requests.
+type egressProxy struct {
+	policy    *EgressPolicy
+	audit     *audit.Logger
+	listener  net.Listener
+	server    *http.Server
+	transport *http.Transport
+
+	mu      sync.Mutex
+	report  EgressReport
+	tunnels map[net.Conn]struct{}
+}
+
+// startEgressProxy starts a proxy enforcing policy on a free loopback port.
+func startEgressProxy(policy *EgressPolicy, logger *audit.Logger) (*egressProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start egress proxy: %w", err)
+	}
+	p := &egressProxy{
+		policy:   policy,
+		audit:    logger,
+		listener: listener,
+		report:   EgressReport{Allowed: []string{}, Denied: []string{}},
+		tunnels:  make(map[net.Conn]struct{}),
+	}
+	p.transport = &http.Transport{DialContext: p.dial}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go func() { _ = p.server.Serve(listener) }()
+	return p, nil
+}
+
+// environ returns env with the proxy variables pointing at p.
+func (p *egressProxy) environ(env []string) []string {
+	url := "http://" + p.listener.Addr().String()
+	out := make([]string, 0, len(env)+4)
+	for _, e := range env {
+		name, _, _ := strings.Cut(e, "=")
+		if !proxyEnvVars[strings.ToUpper(name)] {
+			out = append(out, e)
+		}
+	}
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		out = append(out, name+"="+url)
+	}
+	return out
+}
+
+// Close stops the proxy and any open tunnels, and returns the report.
+func (p *egressProxy) Close() *EgressReport {
+	_ = p.server.Close()
+	p.transport.CloseIdleConnections()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for conn := range p.tunnels {
+		conn.Close()
+	}
+	p.tunnels = nil
+	report := EgressReport{Allowed: slices.Clone(p.report.Allowed), Denied: slices.Clone(p.report.Denied)}
+	return &report
+}
+
+// ServeHTTP handles one proxy request.
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if r.URL.Scheme != "http" || r.URL.Host == "" {
+		http.Error(w, "secretctl egress proxy: an absolute http:// URL is required", http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, h := range []string{"Proxy-Connection", "Proxy-Authorization", "Connection", "Keep-Alive", "Upgrade"} {
+		out.Header.Del(h)
+	}
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		p.fail(w, err)
+		return
+	}
+	defer resp.Body.Close()
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// tunnel connects a CONNECT request to its destination.
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		p.fail(w, err)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "secretctl egress proxy: tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if !p.track(client, upstream) {
+		return
+	}
+	defer p.untrack(client, upstream)
+
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+	// The tunnel ends when the destination is done; untrack then closes
+	// the client side too, ending the copy in the other direction
+	go func() {
+		_, _ = io.Copy(upstream, buffered)
+		if tcp, ok := upstream.(*net.TCPConn); ok {
+			_ = tcp.CloseWrite()
+		}
+	}()
+	_, _ = io.Copy(client, upstream)
+}
+
+// track registers the connections of a tunnel so that Close can end it.
+// It closes them and returns false if the proxy was closed already.
+func (p *egressProxy) track(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tunnels == nil {
+		for _, conn := range conns {
+			conn.Close()
+		}
+		return false
+	}
+	for _, conn := range conns {
+		p.tunnels[conn] = struct{}{}
+	}
+	return true
+}
+
+func (p *egressProxy) untrack(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+		delete(p.tunnels, conn)
+	}
+}
+
+// fail answers a request whose destination could not be reached.
+func (p *egressProxy) fail(w http.ResponseWriter, err error) {
+	if errors.Is(err, errEgressDenied) {
+		http.Error(w, "secretctl egress proxy: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, "secretctl egress proxy: "+err.Error(), http.StatusBadGateway)
+}
+
+// dial connects to dest (host:port) if the policy allows it. A host name
+// allowed only by AllowedCIDRs is connected to at the resolved address
+// that was checked, so it cannot resolve elsewhere in between.
+func (p *egressProxy) dial(ctx context.Context, network, dest string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(dest)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	dest = net.JoinHostPort(host, port)
+
+	target := ""
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if p.policy.allowsAddr(addr) || p.policy.allowsHost(host) {
+			target = host
+		}
+	} else if p.policy.allowsHost(host) {
+		target = host
+	} else if len(p.policy.AllowedCIDRs) > 0 {
+		addrs, _ := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		for _, addr := range addrs {
+			if p.policy.allowsAddr(addr) {
+				target = addr.Unmap().String()
+				break
+			}
+		}
+	}
+	p.record(dest, target != "")
+	if target == "" {
+		return nil, fmt.Errorf("%w: %s", errEgressDenied, dest)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, net.JoinHostPort(target, port))
+}
+
+// record adds dest to the report and audits it, once per destination.
+func (p *egressProxy) record(dest string, allowed bool) {
+	p.mu.Lock()
+	list := &p.report.Denied
+	if allowed {
+		list = &p.report.Allowed
+	}
+	seen := slices.Contains(*list, dest)
+	if !seen {
+		*list = append(*list, dest)
+	}
+	p.mu.Unlock()
+
+	if seen {
+		return
+	}
+	if allowed {
+		_ = p.audit.LogSuccess(audit.OpSecretRunEgress, audit.SourceMCP, dest)
+	} else {
+		_ = p.audit.LogDenied(audit.OpSecretRunEgressDenied, audit.SourceMCP, dest, "EGRESS_NOT_ALLOWED")
+	}
+}
@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEgressPolicy_Validate(t *testing.T) {
+	valid := &EgressPolicy{AllowedHosts: []string{"api.github.com", "*.amazonaws.com", "localhost"}, AllowedCIDRs: []string{"10.0.0.0/8", "::1/128"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+	for _, p := range []EgressPolicy{
+		{AllowedHosts: []string{"https://api.github.com"}},
+		{AllowedHosts: []string{"api.github.com:443"}},
+		{AllowedHosts: []string{"API.github.com"}},
+		{AllowedHosts: []string{"*"}},
+		{AllowedHosts: []string{"api.*.com"}},
+		{AllowedHosts: []string{""}},
+		{AllowedCIDRs: []string{"10.0.0.0"}},
+		{AllowedCIDRs: []string{"10.0.0.0/33"}},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", p)
+		}
+	}
+}
+
+func TestEgressPolicy_Allows(t *testing.T) {
+	p := &EgressPolicy{AllowedHosts: []string{"api.github.com", "*.amazonaws.com"}, AllowedCIDRs: []string{"10.1.0.0/16"}}
+	for host, want := range map[string]bool{
+		"api.github.com":                 true,
+		"github.com":                     false,
+		"evil-api.github.com":            false,
+		"s3.us-east-1.amazonaws.com":     true,
+		"amazonaws.com":                  false,
+		"amazonaws.com.attacker.example": false,
+	} {
+		if got := p.allowsHost(host); got != want {
+			t.Errorf("allowsHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+	for addr, want := range map[string]bool{
+		"10.1.2.3":        true,
+		"10.2.0.1":        false,
+		"::ffff:10.1.2.3": true,
+		"2001:db8::1":     false,
+		"169.254.169.254": false,
+	} {
+		if got := p.allowsAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("allowsAddr(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestValidatePolicy_EgressNeedsNetwork(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PolicyFileName)
+	content := "version: 1\nsandbox:\n  allow_network: false\negress:\n  allowed_hosts: [api.github.com]\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicyFile(path); err == nil || !strings.Contains(err.Error(), "allow_network") {
+		t.Errorf("expected an allow_network error, got %v", err)
+	}
+}
+
+// proxyClient returns an HTTP client that uses proxy for every request.
+func proxyClient(proxy *egressProxy, transport *http.Transport) *http.Client {
+	proxyURL := &url.URL{Scheme: "http", Host: proxy.listener.Addr().String()}
+	transport.Proxy = http.ProxyURL(proxyURL)
+	return &http.Client{Transport: transport, Timeout: 10 * time.Second}
+}
+
+func TestEgressProxy(t *testing.T) {
+	v, _ := testVault(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = io.WriteString(w, "ok") })
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	proxy, err := startEgressProxy(&EgressPolicy{AllowedCIDRs: []string{"127.0.0.0/8"}}, v.Audit())
+	if err != nil {
+		t.Fatalf("startEgressProxy failed: %v", err)
+	}
+	defer proxy.Close()
+
+	// Plain HTTP is forwarded, HTTPS is tunneled
+	client := proxyClient(proxy, &http.Transport{})
+	tlsClient := proxyClient(proxy, tlsServer.Client().Transport.(*http.Transport).Clone())
+	for _, c := range []struct {
+		client *http.Client
+		url    string
+	}{{client, plain.URL}, {tlsClient, tlsServer.URL}} {
+		resp, err := c.client.Get(c.url)
+		if err != nil {
+			t.Fatalf("GET %s through the proxy failed: %v", c.url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+			t.Errorf("GET %s = %d %q", c.url, resp.StatusCode, body)
+		}
+	}
+
+	// Destinations outside the policy are refused
+	denied, err := startEgressProxy(&EgressPolicy{AllowedHosts: []string{"api.github.com"}}, v.Audit())
+	if err != nil {
+		t.Fatalf("startEgressProxy failed: %v", err)
+	}
+	defer denied.Close()
+	resp, err := proxyClient(denied, &http.Transport{}).Get(plain.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+	if _, err := proxyClient(denied, tlsServer.Client().Transport.(*http.Transport).Clone()).Get(tlsServer.URL); err == nil {
+		t.Error("expected the tunnel to be refused")
+	}
+
+	report := proxy.Close()
+	if len(report.Allowed) != 2 || len(report.Denied) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	report = denied.Close()
+	if len(report.Allowed) != 0 || len(report.Denied) != 2 || !slices.Contains(report.Denied, strings.TrimPrefix(plain.URL, "http://")) {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestRunCommand_EgressEnvironment(t *testing.T) {
+	v, _ := testVault(t)
+	resolved, err := ResolveAndValidateCommand("env")
+	if err != nil {
+		t.Skipf("env not available: %v", err)
+	}
+	s := &Server{vault: v, policy: &Policy{Version: 1, DefaultAction: ActionAllow, Egress: &EgressPolicy{AllowedHosts: []string{"api.github.com"}}}}
+	env := []string{"PATH=/usr/bin:/bin", "NO_PROXY=*", "https_proxy=http://elsewhere:3128"}
+	result, err := s.runCommand(context.Background(), resolved, nil, env, newOutputSanitizer(nil), time.Minute, OutputText)
+	if err != nil {
+		t.Fatalf("runCommand failed: %v", err)
+	}
+	if strings.Contains(result.Stdout, "NO_PROXY") || strings.Contains(result.Stdout, "elsewhere") {
+		t.Errorf("proxy variables were not replaced:\n%s", result.Stdout)
+	}
+	if !strings.Contains(result.Stdout, "HTTPS_PROXY=http://127.0.0.1:") {
+		t.Errorf("HTTPS_PROXY not set:\n%s", result.Stdout)
+	}
+	if result.Egress == nil || len(result.Egress.Allowed)+len(result.Egress.Denied) != 0 {
+		t.Errorf("expected an empty egress report, got %+v", result.Egress)
+	}
+}
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fuzzPlaceholder is what the sanitizer writes for the key "K".
+var fuzzPlaceholder = []byte("[REDACTED:K]")
+
+// encodedForms returns the forms of secret the sanitizer promises to redact.
+func encodedForms(secret []byte) [][]byte {
+	s := string(secret)
+	hexLower := hex.EncodeToString(secret)
+	hexUpper := strings.ToUpper(hexLower)
+	return [][]byte{
+		secret,
+		[]byte(base64.StdEncoding.EncodeToString(secret)),
+		[]byte(base64.URLEncoding.EncodeToString(secret)),
+		[]byte(base64.RawStdEncoding.EncodeToString(secret)),
+		[]byte(base64.RawURLEncoding.EncodeToString(secret)),
+		[]byte(url.QueryEscape(s)),
+		[]byte(url.PathEscape(s)),
+		[]byte(strings.ToLower(url.QueryEscape(s))),
+		[]byte(hexLower),
+		[]byte(hexUpper),
+		[]byte("0x" + hexLower),
+		[]byte("0X" + hexUpper),
+	}
+}
+
+// redactable reports whether the leak property can be checked for secret.
+// A secret containing '[' or ']' can overlap the brackets of a placeholder,
+// and one that is part of the placeholder text is "leaked" by every
+// redaction, so neither says anything about the sanitizer.
+func redactable(secret []byte) bool {
+	if len(secret) == 0 || bytes.ContainsAny(secret, "[]") {
+		return false
+	}
+	for _, form := range encodedForms(secret) {
+		if bytes.Contains(fuzzPlaceholder, form) {
+			return false
+		}
+	}
+	return true
+}
+
+// assertRedacted fails if any encoded form of secret survives in out
+// outside of a placeholder.
+func assertRedacted(t *testing.T, secret, input, out []byte) {
+	t.Helper()
+	for _, segment := range bytes.Split(out, fuzzPlaceholder) {
+		for _, form := range encodedForms(secret) {
+			if bytes.Contains(segment, form) {
+				t.Fatalf("secret %q leaked as %q\ninput:  %q\noutput: %q", secret, form, input, out)
+			}
+		}
+	}
+}
+
+func FuzzOutputSanitizer(f *testing.F) {
+	f.Add([]byte("hunter2"), []byte("password="), []byte("\n"), uint8(0))
+	f.Add([]byte("sk-proj-abc123"), []byte("Authorization: Bearer "), []byte(""), uint8(1))
+	f.Add([]byte("p@ss w/rd?&"), []byte("https://example.com/?q="), []byte("&x=1"), uint8(5))
+	f.Add([]byte{0x00, 0xff, 0x10}, []byte("dump: "), []byte(" end"), uint8(10))
+	f.Add([]byte("a"), []byte("aaaa"), []byte("aaaa"), uint8(0))
+
+	f.Fuzz(func(t *testing.T, secret, prefix, suffix []byte, enc uint8) {
+		if !redactable(secret) {
+			t.Skip()
+		}
+		forms := encodedForms(secret)
+		form := forms[int(enc)%len(forms)]
+		input := bytes.Join([][]byte{prefix, form, suffix, form}, nil)
+
+		s := newOutputSanitizer([]secretData{{key: "K", value: secret}})
+		out := s.Sanitize(input)
+		assertRedacted(t, secret, input, out)
+	})
+}
+
+// TestOutputSanitizerRandomEmbedding runs the FuzzOutputSanitizer property
+// over random secrets embedded in random encodings and positions, so the
+// property is checked on every test run and not only under -fuzz.
+func TestOutputSanitizerRandomEmbedding(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randBytes := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+
+	for i := 0; i < 2000; i++ {
+		secret := randBytes(1 + rng.Intn(40))
+		if rng.Intn(2) == 0 {
+			// Printable secrets are the common case and exercise URL escaping
+			for j := range secret {
+				secret[j] = byte(' ' + rng.Intn(95))
+			}
+		}
+		if !redactable(secret) {
+			continue
+		}
+		forms := encodedForms(secret)
+
+		var input []byte
+		for n := rng.Intn(4); n >= 0; n-- {
+			input = append(input, randBytes(rng.Intn(20))...)
+			input = append(input, forms[rng.Intn(len(forms))]...)
+		}
+		input = append(input, randBytes(rng.Intn(20))...)
+
+		s := newOutputSanitizer([]secretData{{key: "K", value: secret}})
+		assertRedacted(t, secret, input, s.Sanitize(input))
+	}
+}
+
+func FuzzParseDuration(f *testing.F) {
+	for _, seed := range []string{"30s", "5h", "7d", "2w", "1m", "1y", "1h30m", "1.5h", "-5d", "+5d", "5dd", "99999999999999y", ""} {
+		f.Add(seed)
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour, 'm': 30 * 24 * time.Hour, 'y': 365 * 24 * time.Hour}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := parseDuration(s)
+		if err != nil {
+			return
+		}
+		if d < 0 {
+			t.Fatalf("parseDuration(%q) = %v, want a non-negative duration", s, d)
+		}
+		// A whole number with a custom unit means exactly that many units
+		if per, ok := units[s[len(s)-1]]; ok && isDigits(s[:len(s)-1]) {
+			n, _ := strconv.ParseInt(s[:len(s)-1], 10, 64)
+			if d != time.Duration(n)*per {
+				t.Fatalf("parseDuration(%q) = %v, want %d × %v", s, d, n, per)
+			}
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package mcp
+
+// Restrictions that exec.Cmd cannot express, resource limits and the Linux
+// sandbox, are applied by a launcher: the command is started as a copy of
+// this executable with launchEnvVar set, which applies them to itself
+// before main runs and then executes the command in its place. The command
+// inherits them (see launch_unix.go).
+
+// launchEnvVar carries the launchConfig to the launcher.
+const launchEnvVar = "SECRETCTL_LAUNCH"
+
+// launchConfig is the launcher's configuration, passed as JSON.
+type launchConfig struct {
+	// Path is the command to execute, with the launcher's arguments
+	Path string `json:"path,omitempty"`
+	// Rlimits are set before executing the command
+	Rlimits []rlimit `json:"rlimits,omitempty"`
+	// Sandbox is applied before executing the command (Linux only)
+	Sandbox *sandboxConfig `json:"sandbox,omitempty"`
+	// Probe exits at once, to test whether the launcher can be started
+	Probe bool `json:"probe,omitempty"`
+}
+
+// rlimit is a resource limit, with the platform's resource number.
+type rlimit struct {
+	Resource int    `json:"resource"`
+	Value    uint64 `json:"value"`
+}
+
+// sandboxConfig is the Linux sandbox applied by the launcher.
+type sandboxConfig struct {
+	Network  bool     `json:"network,omitempty"`
+	Landlock bool     `json:"landlock,omitempty"`
+	Writable []string `json:"writable,omitempty"`
+	Seccomp  bool     `json:"seccomp,omitempty"`
+}
+
+// needed reports whether the command must start through the launcher.
+func (c *launchConfig) needed() bool {
+	return len(c.Rlimits) > 0 || c.Sandbox != nil
+}
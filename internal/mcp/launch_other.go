@@ -0,0 +1,23 @@
+//go:build !unix
+
+package mcp
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errLaunchUnsupported is returned for restrictions this platform cannot
+// apply.
+var errLaunchUnsupported = errors.New("resource_limits are not supported on this platform")
+
+// launchThrough is not available on this platform.
+func launchThrough(*exec.Cmd, *launchConfig) error {
+	return errLaunchUnsupported
+}
+
+// rlimits fails on this platform, so that commands never run without the
+// limits the policy asks for.
+func (l *ResourceLimits) rlimits() ([]rlimit, error) {
+	return nil, errLaunchUnsupported
+}
@@ -0,0 +1,113 @@
+//go:build unix
+
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func init() {
+	if data, ok := os.LookupEnv(launchEnvVar); ok {
+		runLauncher(data)
+	}
+}
+
+// launchThrough makes cmd start through the launcher, which applies cfg
+// and then executes cmd's command with its arguments.
+func launchThrough(cmd *exec.Cmd, cfg *launchConfig) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the launcher: %w", err)
+	}
+	cfg.Path = cmd.Path
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	// cmd.Args[0] stays the command; the launcher passes it on
+	cmd.Path = self
+	cmd.Env = append(slices.Clip(cmd.Env), launchEnvVar+"="+string(data))
+	return nil
+}
+
+// runLauncher applies the configuration in data to this thread and
+// executes the command. It never returns.
+func runLauncher(data string) {
+	var cfg launchConfig
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		launchFail(fmt.Errorf("invalid configuration: %w", err))
+	}
+	if cfg.Probe {
+		os.Exit(0)
+	}
+
+	env := slices.DeleteFunc(os.Environ(), func(e string) bool {
+		return strings.HasPrefix(e, launchEnvVar+"=")
+	})
+
+	// The sandbox restricts the calling thread, which then executes the
+	// command
+	runtime.LockOSThread()
+	if cfg.Sandbox != nil {
+		if err := cfg.Sandbox.restrictSelf(); err != nil {
+			launchFail(err)
+		}
+	}
+	// Last, so that the limits (e.g. on processes) cannot starve the
+	// launcher itself
+	for _, limit := range cfg.Rlimits {
+		if err := unix.Setrlimit(limit.Resource, &unix.Rlimit{Cur: limit.Value, Max: limit.Value}); err != nil {
+			launchFail(fmt.Errorf("failed to set resource limit %d: %w", limit.Resource, err))
+		}
+	}
+	err := unix.Exec(cfg.Path, os.Args, env)
+	launchFail(fmt.Errorf("failed to execute %s: %w", cfg.Path, err))
+}
+
+// launchFail reports a launcher failure as the command's output and exit
+// code, like a shell that cannot execute a command.
+func launchFail(err error) {
+	fmt.Fprintf(os.Stderr, "secretctl: %v\n", err)
+	os.Exit(126)
+}
+
+// rlimits converts the limits to this platform's resource numbers.
+func (l *ResourceLimits) rlimits() ([]rlimit, error) {
+	var limits []rlimit
+	if l.CPUTime != "" {
+		d, err := l.cpuTime()
+		if err != nil {
+			return nil, err
+		}
+		// RLIMIT_CPU counts whole seconds
+		seconds := (d + time.Second - 1) / time.Second
+		limits = append(limits, rlimit{unix.RLIMIT_CPU, uint64(seconds)})
+	}
+	if l.Memory != "" {
+		n, err := parseByteSize(l.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource_limits memory: %w", err)
+		}
+		limits = append(limits, rlimit{unix.RLIMIT_AS, n})
+	}
+	if l.FileSize != "" {
+		n, err := parseByteSize(l.FileSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource_limits file_size: %w", err)
+		}
+		limits = append(limits, rlimit{unix.RLIMIT_FSIZE, n})
+	}
+	if l.Processes > 0 {
+		limits = append(limits, rlimit{unix.RLIMIT_NPROC, uint64(l.Processes)})
+	}
+	return limits, nil
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runLimited runs a command from the trusted directories under limits.
+func runLimited(t *testing.T, limits *ResourceLimits, command string, args ...string) *SecretRunOutput {
+	t.Helper()
+	resolved, err := ResolveAndValidateCommand(command)
+	if err != nil {
+		t.Skipf("%s not available: %v", command, err)
+	}
+	s := &Server{policy: &Policy{Version: 1, DefaultAction: ActionAllow, ResourceLimits: limits}}
+	result, err := s.runCommand(context.Background(), resolved, args, []string{"PATH=/usr/bin:/bin"}, newOutputSanitizer(nil), time.Minute, OutputText)
+	if err != nil {
+		t.Fatalf("runCommand(%s) failed: %v", command, err)
+	}
+	return result
+}
+
+func TestResourceLimits_Applied(t *testing.T) {
+	result := runLimited(t, &ResourceLimits{CPUTime: "90s", FileSize: "1M"}, "sh", "-c", "ulimit -t; ulimit -f")
+	if result.ExitCode != 0 {
+		t.Fatalf("command failed: %+v", result)
+	}
+	// ulimit -f counts 512-byte blocks
+	if got := strings.Fields(result.Stdout); len(got) != 2 || got[0] != "90" || got[1] != "2048" {
+		t.Errorf("unexpected limits %q", result.Stdout)
+	}
+}
+
+func TestResourceLimits_FileSize(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "big")
+	limits := &ResourceLimits{FileSize: "64K"}
+	if result := runLimited(t, limits, "dd", "if=/dev/zero", "of="+file, "bs=1024", "count=32"); result.ExitCode != 0 {
+		t.Errorf("write under the limit failed: %+v", result)
+	}
+	if result := runLimited(t, limits, "dd", "if=/dev/zero", "of="+file, "bs=1024", "count=128"); result.ExitCode == 0 {
+		t.Error("write over the limit succeeded")
+	}
+}
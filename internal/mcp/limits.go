@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResourceLimits caps the resources of each command run with secrets, so
+// that a runaway or malicious command cannot exhaust the host before its
+// timeout. Unset limits are not applied.
+type ResourceLimits struct {
+	// CPUTime is the processor time a command may use, e.g. "10m"
+	CPUTime string `yaml:"cpu_time" json:"cpu_time,omitempty"`
+	// Memory is the virtual memory a command may map, e.g. "2G"
+	Memory string `yaml:"memory" json:"memory,omitempty"`
+	// FileSize is the largest file a command may write, e.g. "100M"
+	FileSize string `yaml:"file_size" json:"file_size,omitempty"`
+	// Processes caps the number of processes of the user while the
+	// command runs, counting the user's other processes too
+	Processes int `yaml:"processes" json:"processes,omitempty"`
+}
+
+// Validate checks that every limit parses and is positive.
+func (l *ResourceLimits) Validate() error {
+	if l.CPUTime != "" {
+		if _, err := l.cpuTime(); err != nil {
+			return err
+		}
+	}
+	if l.Memory != "" {
+		if _, err := parseByteSize(l.Memory); err != nil {
+			return fmt.Errorf("invalid resource_limits memory: %w", err)
+		}
+	}
+	if l.FileSize != "" {
+		if _, err := parseByteSize(l.FileSize); err != nil {
+			return fmt.Errorf("invalid resource_limits file_size: %w", err)
+		}
+	}
+	if l.Processes < 0 {
+		return errors.New("invalid resource_limits processes: must not be negative")
+	}
+	return nil
+}
+
+// cpuTime parses CPUTime.
+func (l *ResourceLimits) cpuTime() (time.Duration, error) {
+	d, err := time.ParseDuration(l.CPUTime)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid resource_limits cpu_time %q (e.g. 30s or 10m)", l.CPUTime)
+	}
+	return d, nil
+}
+
+// byteUnits are the suffixes parseByteSize accepts, in powers of 1024.
+var byteUnits = map[string]uint64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// parseByteSize parses a positive size such as "512M", "2GiB" or "4096".
+// Units are powers of 1024, case-insensitive, and may be followed by "B"
+// or "iB".
+func parseByteSize(s string) (uint64, error) {
+	digits := strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	unit := strings.ToUpper(s[len(digits):])
+	if u, ok := strings.CutSuffix(unit, "B"); ok {
+		unit = u
+		if u, ok := strings.CutSuffix(unit, "I"); ok && u != "" {
+			unit = u
+		}
+	}
+	multiplier, ok := byteUnits[unit]
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if !ok || err != nil || n == 0 || n > (1<<63)/multiplier {
+		return 0, fmt.Errorf("invalid size %q (e.g. 512M or 2G)", s)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]uint64{
+		"4096":  4096,
+		"512K":  512 << 10,
+		"512kb": 512 << 10,
+		"100M":  100 << 20,
+		"2G":    2 << 30,
+		"2GiB":  2 << 30,
+		"1T":    1 << 40,
+	}
+	for in, want := range tests {
+		got, err := parseByteSize(in)
+		if err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "0M", "-1M", "1.5G", "1X", "5iB", "5I", "G", "M1", "99999999999T"} {
+		if _, err := parseByteSize(in); err == nil {
+			t.Errorf("expected an error for %q", in)
+		}
+	}
+}
+
+func TestResourceLimits_Validate(t *testing.T) {
+	valid := &ResourceLimits{CPUTime: "90s", Memory: "2G", FileSize: "100M", Processes: 64}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+	for _, l := range []ResourceLimits{
+		{CPUTime: "10"},
+		{CPUTime: "-1m"},
+		{Memory: "lots"},
+		{FileSize: "0"},
+		{Processes: -1},
+	} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", l)
+		}
+	}
+}
+
+func TestLoadPolicyFile_ResourceLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PolicyFileName)
+	content := "version: 1\nresource_limits:\n  memory: 2X\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicyFile(path); err == nil || !strings.Contains(err.Error(), "memory") {
+		t.Errorf("expected an invalid memory error, got %v", err)
+	}
+
+	content = "version: 1\nresource_limits:\n  cpu_time: 10m\n  file_size: 1G\n  processes: 128\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatalf("LoadPolicyFile failed: %v", err)
+	}
+	if l := policy.ResourceLimits; l == nil || l.CPUTime != "10m" || l.FileSize != "1G" || l.Processes != 128 {
+		t.Errorf("unexpected resource limits %+v", l)
+	}
+}
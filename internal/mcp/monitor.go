@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// monitorVault follows vault events while the server runs: once the vault
+// is locked, whoever locked it, the session refuses further tool calls, and
+// vault warnings and suspected tampering are logged for the MCP client.
+func (s *Server) monitorVault(ctx context.Context) {
+	events, unsubscribe := s.vault.Events()
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-events:
+			switch e.Type {
+			case vault.EventLocked:
+				s.usage.mu.Lock()
+				if s.usage.locked == "" {
+					s.usage.locked = "vault was locked"
+					if e.Message != "" {
+						s.usage.locked += " (" + e.Message + ")"
+					}
+				}
+				s.usage.mu.Unlock()
+			case vault.EventCooldownTriggered, vault.EventLowDisk, vault.EventWarning:
+				slog.Warn(e.Message, "event", e.Type)
+			case vault.EventTamperSuspected:
+				s.usage.mu.Lock()
+				s.usage.locked = "vault files were modified by another process"
+				s.usage.mu.Unlock()
+				slog.Error("vault locked, tampering suspected: "+e.Message, "event", e.Type)
+			case vault.EventCanaryTriggered:
+				slog.Error(e.Message, "event", e.Type, "key", e.Key)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMonitorVault_LockRefusesSession(t *testing.T) {
+	v, tmpDir := testVault(t)
+	s := &Server{vault: v, vaultPath: tmpDir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		s.monitorVault(ctx)
+		close(done)
+	}()
+	// Give the monitor time to subscribe
+	time.Sleep(50 * time.Millisecond)
+
+	v.Lock()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.checkSessionLocked() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("session was not locked after the vault was locked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
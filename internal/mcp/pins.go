@@ -0,0 +1,346 @@
+package mcp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Session and pin files in the vault directory
+const (
+	SessionFileName = "mcp-session.json"
+	PinsFileName    = "mcp-pins"
+)
+
+// MaxPinTTL is the longest a pin may last.
+const MaxPinTTL = 24 * time.Hour
+
+// ErrNoSession is returned when pinning while no MCP server is running.
+var ErrNoSession = errors.New("no MCP server session is running")
+
+// ErrPinRequired is returned when a tool touches a pin_required key that is not pinned.
+var ErrPinRequired = errors.New("key requires a pin")
+
+// Session identifies a running MCP server. Pins only apply to the session
+// they were created for, so they end when the server restarts. If several
+// servers run at once, the most recently started one owns the session.
+type Session struct {
+	ID        string    `json:"id"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Pin authorizes MCP tools to touch a pin_required key until ExpiresAt.
+type Pin struct {
+	Key       string    `json:"key"`
+	PinnedAt  time.Time `json:"pinned_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// pinSet is the sealed content of the pins file.
+type pinSet struct {
+	SessionID string `json:"session_id"`
+	Pins      []Pin  `json:"pins"`
+}
+
+// startSession records a new MCP server session.
+func startSession(vaultPath string) (*Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate session id: %w", err)
+	}
+	session := &Session{ID: hex.EncodeToString(id), PID: os.Getpid(), StartedAt: time.Now().UTC()}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(vaultPath, SessionFileName), data); err != nil {
+		return nil, fmt.Errorf("failed to write session file: %w", err)
+	}
+	return session, nil
+}
+
+// endSession removes the session file if it still belongs to id.
+func endSession(vaultPath, id string) {
+	if current, err := ReadSession(vaultPath); err == nil && current.ID == id {
+		os.Remove(filepath.Join(vaultPath, SessionFileName))
+		os.Remove(filepath.Join(vaultPath, PinsFileName))
+	}
+}
+
+// ReadSession returns the current MCP server session, or ErrNoSession.
+func ReadSession(vaultPath string) (*Session, error) {
+	data, err := os.ReadFile(filepath.Join(vaultPath, SessionFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoSession
+		}
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil || session.ID == "" {
+		return nil, ErrNoSession
+	}
+	return &session, nil
+}
+
+// PinKey pins key for the current MCP server session for ttl.
+// The vault must be unlocked: pins are sealed with the vault key so that an
+// agent with file access cannot create them.
+func PinKey(v *vault.Vault, vaultPath, key string, ttl time.Duration) (*Pin, error) {
+	if ttl <= 0 || ttl > MaxPinTTL {
+		return nil, fmt.Errorf("pin ttl must be between 1s and %s", MaxPinTTL)
+	}
+	session, err := ReadSession(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockPins(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	now := time.Now().UTC()
+	pin := Pin{Key: key, PinnedAt: now, ExpiresAt: now.Add(ttl)}
+
+	pins, err := loadPins(v, vaultPath, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	kept := []Pin{pin}
+	for _, p := range pins {
+		if p.Key != key && p.ExpiresAt.After(now) {
+			kept = append(kept, p)
+		}
+	}
+	if err := savePins(v, vaultPath, pinSet{SessionID: session.ID, Pins: kept}); err != nil {
+		return nil, err
+	}
+
+	_ = v.Audit().LogSuccess(audit.OpMCPPin, audit.SourceCLI, key)
+	return &pin, nil
+}
+
+// UnpinKey removes the pin for key from the current session.
+func UnpinKey(v *vault.Vault, vaultPath, key string) error {
+	session, err := ReadSession(vaultPath)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockPins(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	pins, err := loadPins(v, vaultPath, session.ID)
+	if err != nil {
+		return err
+	}
+	kept := pins[:0]
+	for _, p := range pins {
+		if p.Key != key {
+			kept = append(kept, p)
+		}
+	}
+	if err := savePins(v, vaultPath, pinSet{SessionID: session.ID, Pins: kept}); err != nil {
+		return err
+	}
+
+	_ = v.Audit().LogSuccess(audit.OpMCPUnpin, audit.SourceCLI, key)
+	return nil
+}
+
+// ListPins returns the unexpired pins of the current session.
+func ListPins(v *vault.Vault, vaultPath string) ([]Pin, error) {
+	session, err := ReadSession(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	pins, err := loadPins(v, vaultPath, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := pins[:0]
+	for _, p := range pins {
+		if p.ExpiresAt.After(now) {
+			active = append(active, p)
+		}
+	}
+	return active, nil
+}
+
+// lockPins takes the lock that serializes the load-modify-save cycles of
+// the pins file across CLI processes, and returns the function that
+// releases it.
+func lockPins(vaultPath string) (func(), error) {
+	unlock, err := atomicfile.Lock(filepath.Join(vaultPath, PinsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock pins file: %w", err)
+	}
+	return unlock, nil
+}
+
+// loadPins reads the pins recorded for sessionID. Pins of other sessions,
+// a missing file and a file that fails to unseal all yield no pins.
+func loadPins(v *vault.Vault, vaultPath, sessionID string) ([]Pin, error) {
+	sealed, err := os.ReadFile(filepath.Join(vaultPath, PinsFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pins file: %w", err)
+	}
+	data, err := v.Unseal(sealed)
+	if err != nil {
+		if errors.Is(err, vault.ErrVaultLocked) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	var set pinSet
+	if err := json.Unmarshal(data, &set); err != nil || set.SessionID != sessionID {
+		return nil, nil
+	}
+	return set.Pins, nil
+}
+
+func savePins(v *vault.Vault, vaultPath string, set pinSet) error {
+	data, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pins: %w", err)
+	}
+	sealed, err := v.Seal(data)
+	if err != nil {
+		return fmt.Errorf("failed to seal pins: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(vaultPath, PinsFileName), sealed); err != nil {
+		return fmt.Errorf("failed to write pins file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data (0600).
+func writeFileAtomic(path string, data []byte) error {
+	return atomicfile.Write(path, data, 0600)
+}
+
+// checkPin returns ErrPinRequired if the policy requires a pin for key and
+// the current session has no unexpired pin for it. Denials are audited as op.
+func (s *Server) checkPin(op, key string) error {
+	if s.policy == nil || !s.policy.RequiresPin(key) {
+		return nil
+	}
+	pinned, err := s.hasPin(key)
+	if err != nil {
+		return err
+	}
+	if pinned {
+		return nil
+	}
+	_ = s.vault.Audit().LogDenied(op, audit.SourceMCP, key, "pin required")
+	return fmt.Errorf("%w: ask the user to run 'secretctl mcp pin %s --ttl 30m'", ErrPinRequired, key)
+}
+
+// hasPin reports whether this session has an unexpired pin for key.
+func (s *Server) hasPin(key string) (bool, error) {
+	if s.sessionID == "" {
+		return false, nil
+	}
+	pins, err := loadPins(s.vault, s.vaultPath, s.sessionID)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for _, p := range pins {
+		if p.Key == key && p.ExpiresAt.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getSecret reads key for a tool, authorizing the requested key and, if the
+// read was redirected from a deprecated key, the replacement, and any
+// secrets its ref:// fields were read from.
+func (s *Server) getSecret(op, key string) (*vault.SecretEntry, error) {
+	if err := s.authorize(op, key); err != nil {
+		return nil, err
+	}
+	entry, err := s.vault.GetSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRead(op, key, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// getSecretMasked is getSecret for preview-only tools: the entry is
+// masked inside the vault and no plaintext reaches the server.
+func (s *Server) getSecretMasked(op, key string) (*vault.MaskedSecret, error) {
+	if err := s.authorize(op, key); err != nil {
+		return nil, err
+	}
+	masked, err := s.vault.GetSecretMasked(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRead(op, key, &vault.SecretEntry{Key: masked.Key, ReferencedKeys: masked.ReferencedKeys}); err != nil {
+		return nil, err
+	}
+	return masked, nil
+}
+
+// authorizeRead authorizes the secrets other than key that were read to
+// return entry: a redirect target and referenced secrets.
+func (s *Server) authorizeRead(op, key string, entry *vault.SecretEntry) error {
+	if entry.Key != key {
+		if err := s.authorize(op, entry.Key); err != nil {
+			return err
+		}
+	}
+	for _, ref := range entry.ReferencedKeys {
+		if ref == key || ref == entry.Key {
+			continue
+		}
+		if err := s.authorize(op, ref); err != nil {
+			return fmt.Errorf("secret '%s' references '%s': %w", key, ref, err)
+		}
+	}
+	return nil
+}
+
+// getSecrets reads keys for a tool in one vault read, authorizing each
+// requested key before the read and each redirect target and referenced
+// secret after it.
+func (s *Server) getSecrets(op string, keys []string) (map[string]*vault.SecretEntry, error) {
+	for _, key := range keys {
+		if err := s.authorize(op, key); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := s.vault.GetSecrets(keys)
+	if err != nil {
+		return nil, err
+	}
+	for key, entry := range entries {
+		if err := s.authorizeRead(op, key, entry); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
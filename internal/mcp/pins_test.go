@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+)
+
+// testPinServer returns a server with a running session whose policy
+// requires pins for prod/*.
+func testPinServer(t *testing.T) *Server {
+	t.Helper()
+	v, tmpDir := testVault(t)
+	addTestSecret(t, v, "prod/db", []byte("prod-secret"))
+	addTestSecret(t, v, "dev/db", []byte("dev-secret"))
+
+	session, err := startSession(tmpDir)
+	if err != nil {
+		t.Fatalf("startSession failed: %v", err)
+	}
+	return &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy:    &Policy{Version: 1, DefaultAction: ActionDeny, PinRequired: []string{"prod/*"}},
+		sessionID: session.ID,
+	}
+}
+
+func TestPolicy_RequiresPin(t *testing.T) {
+	p := &Policy{PinRequired: []string{"prod/*", "stripe-key"}}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"prod/db", true},
+		{"stripe-key", true},
+		{"dev/db", false},
+		{"prod/nested/db", false},
+	}
+	for _, tt := range tests {
+		if got := p.RequiresPin(tt.key); got != tt.want {
+			t.Errorf("RequiresPin(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	if (&Policy{}).RequiresPin("prod/db") {
+		t.Error("empty policy should not require pins")
+	}
+}
+
+func TestValidatePolicy_InvalidPinPattern(t *testing.T) {
+	p := &Policy{Version: 1, DefaultAction: ActionDeny, PinRequired: []string{"prod/["}}
+	if err := p.ValidatePolicy(); err == nil {
+		t.Error("expected error for invalid pin_required pattern")
+	}
+}
+
+func TestPin_RequiredBeforeUse(t *testing.T) {
+	s := testPinServer(t)
+	ctx := context.Background()
+
+	_, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"})
+	if !errors.Is(err, ErrPinRequired) {
+		t.Fatalf("expected ErrPinRequired, got %v", err)
+	}
+	_, _, err = s.handleSecretExists(ctx, nil, SecretExistsInput{Key: "prod/db"})
+	if !errors.Is(err, ErrPinRequired) {
+		t.Fatalf("expected ErrPinRequired for secret_exists, got %v", err)
+	}
+	if _, err := s.collectSecrets([]string{"prod/*"}); !errors.Is(err, ErrPinRequired) {
+		t.Fatalf("expected ErrPinRequired for secret_run, got %v", err)
+	}
+
+	// Keys outside pin_required are unaffected
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "dev/db"}); err != nil {
+		t.Fatalf("dev/db should not need a pin: %v", err)
+	}
+
+	if _, err := PinKey(s.vault, s.vaultPath, "prod/db", 30*time.Minute); err != nil {
+		t.Fatalf("PinKey failed: %v", err)
+	}
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"}); err != nil {
+		t.Fatalf("pinned key should be usable: %v", err)
+	}
+
+	_, list, err := s.handleSecretList(ctx, nil, SecretListInput{})
+	if err != nil {
+		t.Fatalf("secret_list failed: %v", err)
+	}
+	for _, info := range list.Secrets {
+		if info.Key == "prod/db" && (!info.PinRequired || !info.Pinned) {
+			t.Errorf("expected prod/db to be pin_required and pinned, got %+v", info)
+		}
+	}
+
+	if err := UnpinKey(s.vault, s.vaultPath, "prod/db"); err != nil {
+		t.Fatalf("UnpinKey failed: %v", err)
+	}
+	if _, _, err := s.handleSecretGetMasked(ctx, nil, SecretGetMaskedInput{Key: "prod/db"}); !errors.Is(err, ErrPinRequired) {
+		t.Fatalf("expected ErrPinRequired after unpin, got %v", err)
+	}
+}
+
+func TestPin_ExpiredAndOtherSession(t *testing.T) {
+	s := testPinServer(t)
+
+	// An expired pin does not count
+	now := time.Now().UTC()
+	expired := pinSet{SessionID: s.sessionID, Pins: []Pin{{Key: "prod/db", PinnedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)}}}
+	if err := savePins(s.vault, s.vaultPath, expired); err != nil {
+		t.Fatalf("savePins failed: %v", err)
+	}
+	if err := s.checkPin("test", "prod/db"); !errors.Is(err, ErrPinRequired) {
+		t.Errorf("expected expired pin to be ignored, got %v", err)
+	}
+
+	// Pins made for a previous server session do not carry over
+	if _, err := PinKey(s.vault, s.vaultPath, "prod/db", time.Hour); err != nil {
+		t.Fatalf("PinKey failed: %v", err)
+	}
+	next, err := startSession(s.vaultPath)
+	if err != nil {
+		t.Fatalf("startSession failed: %v", err)
+	}
+	s.sessionID = next.ID
+	if err := s.checkPin("test", "prod/db"); !errors.Is(err, ErrPinRequired) {
+		t.Errorf("expected pin of previous session to be ignored, got %v", err)
+	}
+}
+
+func TestPin_ForgedFileIgnored(t *testing.T) {
+	s := testPinServer(t)
+
+	forged := []byte(`{"session_id":"` + s.sessionID + `","pins":[{"key":"prod/db","expires_at":"2999-01-01T00:00:00Z"}]}`)
+	if err := os.WriteFile(filepath.Join(s.vaultPath, PinsFileName), forged, 0600); err != nil {
+		t.Fatalf("failed to write pins file: %v", err)
+	}
+	if err := s.checkPin("test", "prod/db"); !errors.Is(err, ErrPinRequired) {
+		t.Errorf("expected unsealed pins file to be ignored, got %v", err)
+	}
+}
+
+func TestPinKey_NoSession(t *testing.T) {
+	v, tmpDir := testVault(t)
+
+	if _, err := PinKey(v, tmpDir, "prod/db", time.Hour); !errors.Is(err, ErrNoSession) {
+		t.Errorf("expected ErrNoSession, got %v", err)
+	}
+	if _, err := PinKey(v, tmpDir, "prod/db", 48*time.Hour); err == nil {
+		t.Error("expected error for ttl above MaxPinTTL")
+	}
+}
+
+func TestServer_EndSessionRemovesPins(t *testing.T) {
+	s := testPinServer(t)
+	if _, err := PinKey(s.vault, s.vaultPath, "prod/db", time.Hour); err != nil {
+		t.Fatalf("PinKey failed: %v", err)
+	}
+
+	s.endSession()
+
+	if _, err := ReadSession(s.vaultPath); !errors.Is(err, ErrNoSession) {
+		t.Errorf("expected session to be gone, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.vaultPath, PinsFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected pins file to be removed, got %v", err)
+	}
+}
+
+func TestPinKey_WaitsForFileLock(t *testing.T) {
+	s := testPinServer(t)
+
+	// Another process is updating the pins file
+	unlock, err := atomicfile.Lock(filepath.Join(s.vaultPath, PinsFileName))
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := PinKey(s.vault, s.vaultPath, "prod/db", time.Hour)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("PinKey did not wait for the file lock: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("PinKey failed: %v", err)
+	}
+	if err := s.checkPin("test", "prod/db"); err != nil {
+		t.Errorf("expected pinned key to be allowed, got %v", err)
+	}
+}
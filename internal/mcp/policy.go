@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 
 	"gopkg.in/yaml.v3"
@@ -23,6 +24,32 @@ type Policy struct {
 	DeniedCommands  []string                     `yaml:"denied_commands"`
 	AllowedCommands []string                     `yaml:"allowed_commands"`
 	EnvAliases      map[string][]EnvAliasMapping `yaml:"env_aliases"`
+	// ProjectManifests allows secret_run to inject secrets declared in a
+	// project's .secretctl.yaml (opt-in, disabled by default)
+	ProjectManifests bool `yaml:"project_manifests"`
+	// PinRequired lists key patterns (path.Match syntax, e.g. "prod/*") that
+	// MCP tools may only touch after the user ran `secretctl mcp pin <key>`
+	PinRequired []string `yaml:"pin_required"`
+	// FirstUseApproval requires the user to approve each client's first use
+	// of a key with `secretctl mcp approve`
+	FirstUseApproval *FirstUseApproval `yaml:"first_use_approval"`
+	// SessionBudget limits secrets, runs and injected bytes per server session
+	SessionBudget *SessionBudget `yaml:"session_budget"`
+	// PolicyInfoRedact hides policy sections (e.g. "allowed_commands", or
+	// "all") from the policy_info tool
+	PolicyInfoRedact []string `yaml:"policy_info_redact"`
+	// InheritEnv adds environment variables (e.g. SSH_AUTH_SOCK) that
+	// secret_run passes through from the server, on top of PATH, HOME and
+	// the other built-in safe variables. Blocked variables are rejected.
+	InheritEnv []string `yaml:"inherit_env"`
+	// Sandbox restricts the network and filesystem access of the commands
+	// run with secrets
+	Sandbox *SandboxPolicy `yaml:"sandbox"`
+	// ResourceLimits caps the CPU time, memory, file size and processes of
+	// the commands run with secrets
+	ResourceLimits *ResourceLimits `yaml:"resource_limits"`
+	// Egress limits where commands can connect to through HTTP(S) proxies
+	Egress *EgressPolicy `yaml:"egress"`
 }
 
 // PolicyFileName is the name of the policy file
@@ -68,10 +95,13 @@ var TrustedDirectories = []string{
 }
 
 // LoadPolicy loads the MCP policy from the vault directory.
-// Implements TOCTOU-safe loading per mcp-design-ja.md §4.5.2
 func LoadPolicy(vaultPath string) (*Policy, error) {
-	policyPath := filepath.Join(vaultPath, PolicyFileName)
+	return LoadPolicyFile(filepath.Join(vaultPath, PolicyFileName))
+}
 
+// LoadPolicyFile loads the MCP policy at policyPath.
+// Implements TOCTOU-safe loading per mcp-design-ja.md §4.5.2
+func LoadPolicyFile(policyPath string) (*Policy, error) {
 	// 1. Open with platform-specific handling (O_NOFOLLOW on Unix)
 	f, err := openPolicyFile(policyPath)
 	if err != nil {
@@ -120,9 +150,99 @@ func LoadPolicy(vaultPath string) (*Policy, error) {
 		policy.DefaultAction = ActionDeny
 	}
 
+	// A blocked variable must not be let through by a policy edit
+	if err := validateInheritEnv(policy.InheritEnv); err != nil {
+		return nil, err
+	}
+	// Nor may a sandbox or limits that cannot be applied as written be
+	// ignored
+	if policy.Sandbox != nil {
+		if err := policy.Sandbox.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if policy.ResourceLimits != nil {
+		if err := policy.ResourceLimits.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := policy.validateEgress(); err != nil {
+		return nil, err
+	}
+
 	return &policy, nil
 }
 
+// policySkeleton is the starting policy written by WritePolicySkeleton.
+// It denies every command until the user lists the ones MCP clients need.
+const policySkeleton = `# secretctl MCP policy. See the configuration reference for all fields.
+version: 1
+default_action: deny
+
+# Commands secret_run may never execute (env, printenv, set and export
+# are always denied)
+denied_commands: []
+
+# Commands secret_run may execute, e.g. aws, kubectl, ./deploy.sh
+allowed_commands: []
+
+# Keys MCP tools may only use after 'secretctl mcp pin <key>'
+pin_required: []
+
+# Environment variables commands inherit besides PATH, HOME, USER,
+# LOGNAME, LANG, LC_ALL, TERM and TZ, e.g. SSH_AUTH_SOCK, KUBECONFIG
+inherit_env: []
+
+# Uncomment to require 'secretctl mcp approve' the first time each MCP
+# client uses a key (keys: patterns, empty for all keys)
+# first_use_approval:
+#   keys: []
+#   grant_ttl: 720h
+
+# Uncomment to run commands without network access, writing only
+# beneath writable_paths (required: refuse to run unsandboxed)
+# sandbox:
+#   allow_network: false
+#   writable_paths: []
+#   required: false
+
+# Uncomment to cap the resources of each command
+# resource_limits:
+#   cpu_time: 10m
+#   memory: 4G
+#   file_size: 1G
+#   processes: 512
+
+# Uncomment to let commands reach only these destinations through
+# HTTPS_PROXY (programs that ignore proxy variables are not restricted)
+# egress:
+#   allowed_hosts:
+#     - api.github.com
+#     - "*.amazonaws.com"
+#   allowed_cidrs:
+#     - 10.0.0.0/8
+`
+
+// WritePolicySkeleton creates a deny-by-default policy file in the vault
+// directory. It fails with an error wrapping os.ErrExist if a policy
+// already exists, so an existing policy is never replaced.
+func WritePolicySkeleton(vaultPath string) (string, error) {
+	policyPath := filepath.Join(vaultPath, PolicyFileName)
+	f, err := os.OpenFile(policyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create policy file: %w", err)
+	}
+	if _, err := f.WriteString(policySkeleton); err != nil {
+		f.Close()
+		os.Remove(policyPath)
+		return "", fmt.Errorf("failed to write policy file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write policy file: %w", err)
+	}
+	return policyPath, nil
+}
+
 // IsCommandAllowed checks if a command is allowed by the policy.
 // Evaluation order per mcp-design-ja.md §4.3:
 // 0. default_denied_commands → always deny (hardcoded security)
@@ -159,6 +279,24 @@ func (p *Policy) IsCommandAllowed(command string) (allowed bool, reason string)
 	return false, fmt.Sprintf("command '%s' not in allowed_commands list", command)
 }
 
+// AuthorizeCommand resolves command in the trusted directories and checks
+// its name and then its resolved path against the policy, as secret_run
+// does. It returns the absolute path to execute.
+func (p *Policy) AuthorizeCommand(command string) (string, error) {
+	resolved, err := ResolveAndValidateCommand(command)
+	if err != nil {
+		return "", fmt.Errorf("command validation failed: %w", err)
+	}
+	allowed, reason := p.IsCommandAllowed(command)
+	if !allowed {
+		allowed, reason = p.IsCommandAllowed(resolved)
+	}
+	if !allowed {
+		return "", fmt.Errorf("command not allowed by policy: %s", reason)
+	}
+	return resolved, nil
+}
+
 // matchCommand checks if a command matches a pattern.
 // Security behavior:
 // - If pattern is an absolute path (e.g., "/usr/bin/curl"), require exact match
@@ -310,9 +448,72 @@ func (p *Policy) ValidatePolicy() error {
 		return fmt.Errorf("invalid default_action: %s (must be '%s' or '%s')", p.DefaultAction, ActionDeny, ActionAllow)
 	}
 
+	for _, pattern := range p.PinRequired {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pin_required pattern %q: %w", pattern, err)
+		}
+	}
+
+	if p.FirstUseApproval != nil {
+		if err := p.FirstUseApproval.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.SessionBudget != nil {
+		if err := p.SessionBudget.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateRedactSections(p.PolicyInfoRedact); err != nil {
+		return err
+	}
+
+	if p.Sandbox != nil {
+		if err := p.Sandbox.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if p.ResourceLimits != nil {
+		if err := p.ResourceLimits.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.validateEgress(); err != nil {
+		return err
+	}
+
+	return validateInheritEnv(p.InheritEnv)
+}
+
+// validateInheritEnv checks inherit_env entries.
+func validateInheritEnv(names []string) error {
+	for _, name := range names {
+		if err := validateEnvName(name); err != nil {
+			return fmt.Errorf("invalid inherit_env variable %q: %w", name, err)
+		}
+		if blockedEnvVars[name] {
+			return fmt.Errorf("inherit_env variable %q is blocked", name)
+		}
+	}
 	return nil
 }
 
+// RequiresPin reports whether key matches a pin_required pattern.
+// Invalid patterns match every key so that a typo cannot disable pinning.
+func (p *Policy) RequiresPin(key string) bool {
+	for _, pattern := range p.PinRequired {
+		matched, err := path.Match(pattern, key)
+		if matched || err != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // DefaultDeniedCommands returns the default list of denied commands
 // that should always be blocked per mcp-design-ja.md §4.2
 func DefaultDeniedCommands() []string {
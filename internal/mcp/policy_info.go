@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Policy sections that policy_info_redact can hide from policy_info.
+const (
+	SectionAllowedCommands = "allowed_commands"
+	SectionDeniedCommands  = "denied_commands"
+	SectionEnvAliases      = "env_aliases"
+	SectionPinRequired     = "pin_required"
+	SectionSessionBudget   = "session_budget"
+	SectionEgress          = "egress"
+)
+
+var policySections = []string{
+	SectionAllowedCommands,
+	SectionDeniedCommands,
+	SectionEnvAliases,
+	SectionPinRequired,
+	SectionSessionBudget,
+	SectionEgress,
+}
+
+// Approval modes reported by policy_info.
+const (
+	ApprovalNone = "none" // policy alone decides
+	ApprovalPin  = "pin"  // some keys need `secretctl mcp pin`
+	// ApprovalFirstUse means each client's first use of some keys needs
+	// `secretctl mcp approve`; pin_required may apply as well
+	ApprovalFirstUse = "first_use"
+)
+
+// PolicyInfoInput represents input for policy_info tool.
+type PolicyInfoInput struct{}
+
+// PolicyInfoOutput describes the effective MCP policy.
+type PolicyInfoOutput struct {
+	// Configured is false when no valid mcp-policy.yaml was loaded, in which
+	// case secret_run and secret_run_with_bindings are disabled.
+	Configured      bool     `json:"configured"`
+	DefaultAction   string   `json:"default_action,omitempty"`
+	AllowedCommands []string `json:"allowed_commands,omitempty"`
+	// DeniedCommands includes the built-in commands that are always denied
+	DeniedCommands   []string     `json:"denied_commands,omitempty"`
+	EnvAliases       []string     `json:"env_aliases,omitempty"` // Names usable as secret_run env
+	ProjectManifests bool         `json:"project_manifests"`
+	PinRequired      []string     `json:"pin_required,omitempty"`
+	ApprovalMode     string       `json:"approval_mode"`
+	SessionBudget    *BudgetInfo  `json:"session_budget,omitempty"`
+	Limits           PolicyLimits `json:"limits"`
+	Redacted         []string     `json:"redacted,omitempty"` // Sections hidden by policy_info_redact
+	// TrustedDirs are the only directories commands may be executed from
+	TrustedDirs []string `json:"trusted_dirs"`
+	// InheritEnv are the server environment variables commands inherit
+	InheritEnv []string `json:"inherit_env"`
+	// Egress are the destinations commands may reach through the proxy
+	Egress *EgressPolicy `json:"egress,omitempty"`
+}
+
+// BudgetInfo reports the session budget and how much of it is used.
+type BudgetInfo struct {
+	MaxSecrets       int    `json:"max_secrets,omitempty"`
+	MaxRuns          int    `json:"max_runs,omitempty"`
+	MaxInjectedBytes int64  `json:"max_injected_bytes,omitempty"`
+	UsedSecrets      int    `json:"used_secrets"`
+	UsedRuns         int    `json:"used_runs"`
+	UsedBytes        int64  `json:"used_injected_bytes"`
+	Locked           bool   `json:"locked"`
+	LockReason       string `json:"lock_reason,omitempty"`
+}
+
+// PolicyLimits are the fixed limits of secret_run.
+type PolicyLimits struct {
+	MaxKeysPerRun     int    `json:"max_keys_per_run"`
+	MaxConcurrentRuns int    `json:"max_concurrent_runs"`
+	MaxBatchSteps     int    `json:"max_batch_steps"`
+	MaxTimeout        string `json:"max_timeout"`
+	DefaultTimeout    string `json:"default_timeout"`
+	// Resources are the policy's resource_limits on each command
+	Resources *ResourceLimits `json:"resources,omitempty"`
+}
+
+// validateRedactSections checks policy_info_redact entries.
+func validateRedactSections(sections []string) error {
+	for _, section := range sections {
+		if section == "all" {
+			continue
+		}
+		known := false
+		for _, s := range policySections {
+			if s == section {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid policy_info_redact section %q", section)
+		}
+	}
+	return nil
+}
+
+// handlePolicyInfo handles the policy_info tool call.
+func (s *Server) handlePolicyInfo(_ context.Context, _ *mcp.CallToolRequest, _ PolicyInfoInput) (*mcp.CallToolResult, PolicyInfoOutput, error) {
+	output := PolicyInfoOutput{
+		ApprovalMode: ApprovalNone,
+		Limits: PolicyLimits{
+			MaxKeysPerRun:     10,
+			MaxConcurrentRuns: maxConcurrentRuns,
+			MaxBatchSteps:     maxBatchSteps,
+			MaxTimeout:        "1h",
+			DefaultTimeout:    "5m",
+		},
+		TrustedDirs: TrustedDirectories,
+	}
+	for name := range safeEnvVars {
+		output.InheritEnv = append(output.InheritEnv, name)
+	}
+	if s.policy != nil {
+		for _, name := range s.policy.InheritEnv {
+			if s.inheritsEnv(name) && !slices.Contains(output.InheritEnv, name) {
+				output.InheritEnv = append(output.InheritEnv, name)
+			}
+		}
+	}
+	sort.Strings(output.InheritEnv)
+
+	p := s.policy
+	if p == nil {
+		_ = s.vault.Audit().LogSuccess(audit.OpMCPPolicyInfo, audit.SourceMCP, "")
+		return nil, output, nil
+	}
+
+	redacted := make(map[string]bool)
+	for _, section := range p.PolicyInfoRedact {
+		if section == "all" {
+			for _, s := range policySections {
+				redacted[s] = true
+			}
+			continue
+		}
+		redacted[section] = true
+	}
+	for _, section := range policySections {
+		if redacted[section] {
+			output.Redacted = append(output.Redacted, section)
+		}
+	}
+
+	output.Configured = true
+	output.DefaultAction = p.DefaultAction
+	output.ProjectManifests = p.ProjectManifests
+	output.Limits.Resources = p.ResourceLimits
+	if p.FirstUseApproval != nil {
+		output.ApprovalMode = ApprovalFirstUse
+	} else if len(p.PinRequired) > 0 {
+		output.ApprovalMode = ApprovalPin
+	}
+	if !redacted[SectionAllowedCommands] {
+		output.AllowedCommands = p.AllowedCommands
+	}
+	if !redacted[SectionDeniedCommands] {
+		output.DeniedCommands = append(DefaultDeniedCommands(), p.DeniedCommands...)
+	}
+	if !redacted[SectionEnvAliases] {
+		for name := range p.EnvAliases {
+			output.EnvAliases = append(output.EnvAliases, name)
+		}
+		sort.Strings(output.EnvAliases)
+	}
+	if !redacted[SectionPinRequired] {
+		output.PinRequired = p.PinRequired
+	}
+	if !redacted[SectionEgress] {
+		output.Egress = p.Egress
+	}
+	if !redacted[SectionSessionBudget] && p.SessionBudget != nil {
+		s.usage.mu.Lock()
+		output.SessionBudget = &BudgetInfo{
+			MaxSecrets:       p.SessionBudget.MaxSecrets,
+			MaxRuns:          p.SessionBudget.MaxRuns,
+			MaxInjectedBytes: p.SessionBudget.MaxInjectedBytes,
+			UsedSecrets:      len(s.usage.secrets),
+			UsedRuns:         s.usage.runs,
+			UsedBytes:        s.usage.injected,
+			Locked:           s.usage.locked != "",
+			LockReason:       s.usage.locked,
+		}
+		s.usage.mu.Unlock()
+	}
+
+	_ = s.vault.Audit().LogSuccess(audit.OpMCPPolicyInfo, audit.SourceMCP, "")
+	return nil, output, nil
+}
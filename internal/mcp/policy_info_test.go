@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyInfo(t *testing.T) {
+	v, tmpDir := testVault(t)
+	s := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy: &Policy{
+			Version:         1,
+			DefaultAction:   ActionDeny,
+			AllowedCommands: []string{"aws", "kubectl"},
+			DeniedCommands:  []string{"curl"},
+			EnvAliases: map[string][]EnvAliasMapping{
+				"prod": {{Pattern: "db/*", Target: "prod/db/*"}},
+				"dev":  {{Pattern: "db/*", Target: "dev/db/*"}},
+			},
+			PinRequired:   []string{"prod/*"},
+			SessionBudget: &SessionBudget{MaxRuns: 3},
+		},
+	}
+	if err := s.useRun("test"); err != nil {
+		t.Fatalf("useRun failed: %v", err)
+	}
+
+	_, out, err := s.handlePolicyInfo(context.Background(), nil, PolicyInfoInput{})
+	if err != nil {
+		t.Fatalf("policy_info failed: %v", err)
+	}
+	if !out.Configured || out.DefaultAction != ActionDeny {
+		t.Errorf("unexpected configured/default_action: %+v", out)
+	}
+	if !reflect.DeepEqual(out.AllowedCommands, []string{"aws", "kubectl"}) {
+		t.Errorf("allowed_commands = %v", out.AllowedCommands)
+	}
+	if len(out.DeniedCommands) != len(DefaultDeniedCommands())+1 || out.DeniedCommands[len(out.DeniedCommands)-1] != "curl" {
+		t.Errorf("denied_commands should include defaults and curl, got %v", out.DeniedCommands)
+	}
+	if !reflect.DeepEqual(out.EnvAliases, []string{"dev", "prod"}) {
+		t.Errorf("env_aliases = %v", out.EnvAliases)
+	}
+	if out.ApprovalMode != ApprovalPin {
+		t.Errorf("approval_mode = %q, want %q", out.ApprovalMode, ApprovalPin)
+	}
+	if out.SessionBudget == nil || out.SessionBudget.MaxRuns != 3 || out.SessionBudget.UsedRuns != 1 {
+		t.Errorf("session_budget = %+v", out.SessionBudget)
+	}
+	if out.Limits.MaxConcurrentRuns != maxConcurrentRuns || out.Limits.MaxKeysPerRun != 10 {
+		t.Errorf("limits = %+v", out.Limits)
+	}
+}
+
+func TestPolicyInfo_Redact(t *testing.T) {
+	v, tmpDir := testVault(t)
+	s := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy: &Policy{
+			Version:          1,
+			DefaultAction:    ActionAllow,
+			AllowedCommands:  []string{"aws"},
+			PinRequired:      []string{"prod/*"},
+			PolicyInfoRedact: []string{SectionAllowedCommands, SectionPinRequired},
+		},
+	}
+
+	_, out, err := s.handlePolicyInfo(context.Background(), nil, PolicyInfoInput{})
+	if err != nil {
+		t.Fatalf("policy_info failed: %v", err)
+	}
+	if out.AllowedCommands != nil || out.PinRequired != nil {
+		t.Errorf("redacted sections were returned: %+v", out)
+	}
+	if !reflect.DeepEqual(out.Redacted, []string{SectionAllowedCommands, SectionPinRequired}) {
+		t.Errorf("redacted = %v", out.Redacted)
+	}
+	// The approval mode is still reported so agents know pins may be needed
+	if out.ApprovalMode != ApprovalPin {
+		t.Errorf("approval_mode = %q, want %q", out.ApprovalMode, ApprovalPin)
+	}
+
+	s.policy.PolicyInfoRedact = []string{"all"}
+	_, out, _ = s.handlePolicyInfo(context.Background(), nil, PolicyInfoInput{})
+	if len(out.Redacted) != len(policySections) || out.DeniedCommands != nil {
+		t.Errorf("redact all: %+v", out)
+	}
+
+	s.policy.PolicyInfoRedact = []string{"secrets"}
+	if err := s.policy.ValidatePolicy(); err == nil {
+		t.Error("expected ValidatePolicy to reject unknown policy_info_redact section")
+	}
+}
+
+func TestPolicyInfo_NoPolicy(t *testing.T) {
+	v, tmpDir := testVault(t)
+	s := &Server{vault: v, vaultPath: tmpDir}
+
+	_, out, err := s.handlePolicyInfo(context.Background(), nil, PolicyInfoInput{})
+	if err != nil {
+		t.Fatalf("policy_info failed: %v", err)
+	}
+	if out.Configured || out.ApprovalMode != ApprovalNone {
+		t.Errorf("expected unconfigured policy, got %+v", out)
+	}
+}
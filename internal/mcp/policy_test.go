@@ -1,9 +1,12 @@
 package mcp
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
 )
 
 func TestLoadPolicy_NotFound(t *testing.T) {
@@ -50,6 +53,26 @@ denied_commands:
 	}
 }
 
+func TestNewServer_PolicyPath(t *testing.T) {
+	vaultDir := t.TempDir()
+	if err := vault.New(vaultDir).Init("testpassword123"); err != nil {
+		t.Fatalf("failed to init vault: %v", err)
+	}
+	policyPath := filepath.Join(t.TempDir(), "project-policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("version: 1\nallowed_commands: [make]\n"), 0600); err != nil {
+		t.Fatalf("failed to write policy file: %v", err)
+	}
+
+	server, err := NewServer(&ServerOptions{VaultPath: vaultDir, PolicyPath: policyPath, Password: "testpassword123"})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	defer server.Close()
+	if server.policy == nil || len(server.policy.AllowedCommands) != 1 || server.policy.AllowedCommands[0] != "make" {
+		t.Errorf("policy from --policy not loaded: %+v", server.policy)
+	}
+}
+
 func TestLoadPolicy_InsecurePermissions(t *testing.T) {
 	tmpDir := t.TempDir()
 	policyPath := filepath.Join(tmpDir, PolicyFileName)
@@ -100,6 +123,26 @@ default_action: deny
 	}
 }
 
+func TestLoadPolicy_BlockedInheritEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	policyPath := filepath.Join(tmpDir, PolicyFileName)
+
+	content := `version: 1
+default_action: deny
+inherit_env:
+  - SSH_AUTH_SOCK
+  - NODE_OPTIONS
+`
+	if err := os.WriteFile(policyPath, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write policy file: %v", err)
+	}
+
+	_, err := LoadPolicy(tmpDir)
+	if err == nil {
+		t.Error("expected error for blocked inherit_env variable")
+	}
+}
+
 func TestLoadPolicy_DefaultActionFallback(t *testing.T) {
 	tmpDir := t.TempDir()
 	policyPath := filepath.Join(tmpDir, PolicyFileName)
@@ -148,6 +191,26 @@ default_action: deny
 	}
 }
 
+func TestWritePolicySkeleton(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if _, err := WritePolicySkeleton(tmpDir); err != nil {
+		t.Fatalf("WritePolicySkeleton failed: %v", err)
+	}
+
+	policy, err := LoadPolicy(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed on skeleton: %v", err)
+	}
+	if policy.DefaultAction != ActionDeny || len(policy.AllowedCommands) != 0 {
+		t.Errorf("skeleton should deny everything, got %+v", policy)
+	}
+
+	if _, err := WritePolicySkeleton(tmpDir); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expected os.ErrExist for existing policy, got %v", err)
+	}
+}
+
 func TestIsCommandAllowed_DefaultDenied(t *testing.T) {
 	policy := &Policy{
 		Version:       1,
@@ -360,6 +423,22 @@ func TestValidatePolicy_Invalid(t *testing.T) {
 				DefaultAction: "invalid",
 			},
 		},
+		{
+			name: "blocked inherit_env",
+			policy: &Policy{
+				Version:       1,
+				DefaultAction: ActionDeny,
+				InheritEnv:    []string{"SSH_AUTH_SOCK", "LD_PRELOAD"},
+			},
+		},
+		{
+			name: "invalid inherit_env name",
+			policy: &Policy{
+				Version:       1,
+				DefaultAction: ActionDeny,
+				InheritEnv:    []string{"NOT-A-NAME"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/forest6511/secretctl/internal/project"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Requirement status values reported by project_secrets_manifest.
+const (
+	RequirementPresent      = "present"
+	RequirementMissing      = "missing"
+	RequirementExpired      = "expired"
+	RequirementMissingField = "missing_field"
+	RequirementDeprecated   = "deprecated"
+)
+
+// ProjectManifestInput represents input for project_secrets_manifest tool.
+type ProjectManifestInput struct {
+	// Directory is the absolute path of the project checkout containing .secretctl.yaml.
+	Directory string `json:"directory"`
+}
+
+// ProjectManifestOutput represents output for project_secrets_manifest tool.
+type ProjectManifestOutput struct {
+	ManifestPath string              `json:"manifest_path"`
+	Ready        bool                `json:"ready"` // true when every required secret is present
+	Requirements []RequirementStatus `json:"requirements"`
+	Present      int                 `json:"present"`
+	Missing      int                 `json:"missing"`
+	Expired      int                 `json:"expired"`
+}
+
+// RequirementStatus reports the state of one declared requirement (no values).
+type RequirementStatus struct {
+	Key         string `json:"key"`
+	Field       string `json:"field,omitempty"`
+	Env         string `json:"env"`
+	Optional    bool   `json:"optional"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	ReplacedBy  string `json:"replaced_by,omitempty"` // set when Status is deprecated
+}
+
+// handleProjectManifest handles the project_secrets_manifest tool call.
+// It reads the repository's .secretctl.yaml and reports which declared
+// secrets exist, are missing or are expired. Secret values are never returned.
+func (s *Server) handleProjectManifest(_ context.Context, _ *mcp.CallToolRequest, input ProjectManifestInput) (*mcp.CallToolResult, ProjectManifestOutput, error) {
+	if input.Directory == "" {
+		_ = s.vault.Audit().LogError(audit.OpProjectManifest, audit.SourceMCP, "", "INVALID_INPUT", "directory is required")
+		return nil, ProjectManifestOutput{}, errors.New("directory is required")
+	}
+	if !filepath.IsAbs(input.Directory) {
+		_ = s.vault.Audit().LogError(audit.OpProjectManifest, audit.SourceMCP, "", "INVALID_INPUT", "directory must be absolute")
+		return nil, ProjectManifestOutput{}, errors.New("directory must be an absolute path")
+	}
+
+	manifest, err := project.Load(filepath.Clean(input.Directory))
+	if err != nil {
+		_ = s.vault.Audit().LogError(audit.OpProjectManifest, audit.SourceMCP, "", "MANIFEST_LOAD_FAILED", err.Error())
+		return nil, ProjectManifestOutput{}, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	output := ProjectManifestOutput{
+		ManifestPath: manifest.Path,
+		Ready:        true,
+		Requirements: make([]RequirementStatus, 0, len(manifest.Secrets)),
+	}
+
+	now := time.Now()
+	for _, req := range manifest.Secrets {
+		status := RequirementStatus{
+			Key:         req.Key,
+			Field:       req.Field,
+			Env:         req.EnvName(),
+			Optional:    req.Optional,
+			Description: req.Description,
+		}
+
+		entry, err := s.vault.InspectSecret(req.Key)
+		switch {
+		case errors.Is(err, vault.ErrSecretNotFound):
+			status.Status = RequirementMissing
+		case err != nil:
+			_ = s.vault.Audit().LogError(audit.OpProjectManifest, audit.SourceMCP, req.Key, "GET_FAILED", err.Error())
+			return nil, ProjectManifestOutput{}, fmt.Errorf("failed to check secret '%s': %w", req.Key, err)
+		case entry.Deprecation != nil:
+			status.Status = RequirementDeprecated
+			status.ReplacedBy = entry.Deprecation.ReplacedBy
+		case entry.ExpiresAt != nil && entry.ExpiresAt.Before(now):
+			status.Status = RequirementExpired
+			status.ExpiresAt = entry.ExpiresAt.Format(time.RFC3339)
+		default:
+			field := req.Field
+			if field == "" {
+				field = vault.DefaultFieldName
+			}
+			if _, _, ferr := vault.ResolveFieldName(entry.Fields, field); ferr != nil {
+				status.Status = RequirementMissingField
+			} else {
+				status.Status = RequirementPresent
+			}
+		}
+		if entry != nil && entry.ExpiresAt != nil && status.ExpiresAt == "" {
+			status.ExpiresAt = entry.ExpiresAt.Format(time.RFC3339)
+		}
+
+		switch status.Status {
+		case RequirementPresent:
+			output.Present++
+		case RequirementExpired:
+			output.Expired++
+		default:
+			output.Missing++
+		}
+		if status.Status != RequirementPresent && !req.Optional {
+			output.Ready = false
+		}
+
+		output.Requirements = append(output.Requirements, status)
+	}
+
+	_ = s.vault.Audit().LogSuccess(audit.OpProjectManifest, audit.SourceMCP, "")
+
+	return nil, output, nil
+}
+
+// collectProjectSecrets resolves the secrets declared in a project's
+// .secretctl.yaml for secret_run. Requires policy opt-in.
+func (s *Server) collectProjectSecrets(dir string) ([]secretData, error) {
+	if s.policy == nil || !s.policy.ProjectManifests {
+		return nil, errors.New("project_dir requires 'project_manifests: true' in mcp-policy.yaml")
+	}
+	if !filepath.IsAbs(dir) {
+		return nil, errors.New("project_dir must be an absolute path")
+	}
+
+	manifest, err := project.Load(filepath.Clean(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+	resolved, err := manifest.Resolve(func(key string) (*vault.SecretEntry, error) {
+		return s.getSecret(audit.OpSecretRun, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resolved) == 0 {
+		return nil, errors.New("no secrets available from project manifest")
+	}
+	if len(resolved) > 10 {
+		return nil, errors.New("too many keys in project manifest (max 10)")
+	}
+
+	secrets := make([]secretData, 0, len(resolved))
+	for _, r := range resolved {
+		secrets = append(secrets, secretData{key: r.Key, value: r.Value, envName: r.EnvName()})
+	}
+	return secrets, nil
+}
@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestHandleProjectManifest(t *testing.T) {
+	v, tmpDir := testVault(t)
+
+	addTestSecret(t, v, "api/dev/token", []byte("tok123"))
+	addTestSecretMultiField(t, v, "db/dev", map[string]vault.Field{
+		"host":     {Value: "localhost"},
+		"password": {Value: "pw", Sensitive: true},
+	})
+	soon := time.Now().Add(time.Second)
+	addTestSecretWithExpiration(t, v, "old/cert", []byte("cert"), &soon)
+
+	projectDir := t.TempDir()
+	manifest := `version: 1
+secrets:
+  - key: api/dev/token
+  - key: db/dev
+    field: password
+    env: DB_PASSWORD
+  - key: db/dev
+    field: port
+    env: DB_PORT
+  - key: old/cert
+    env: CERT
+  - key: missing/key
+    env: MISSING
+    optional: true
+`
+	if err := os.WriteFile(filepath.Join(projectDir, ".secretctl.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	server := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+	}
+
+	// Wait for old/cert to expire
+	time.Sleep(time.Until(soon) + 50*time.Millisecond)
+
+	_, output, err := server.handleProjectManifest(context.Background(), nil, ProjectManifestInput{Directory: projectDir})
+	if err != nil {
+		t.Fatalf("handleProjectManifest failed: %v", err)
+	}
+
+	want := []struct{ env, status string }{
+		{"API_DEV_TOKEN", RequirementPresent},
+		{"DB_PASSWORD", RequirementPresent},
+		{"DB_PORT", RequirementMissingField},
+		{"CERT", RequirementExpired},
+		{"MISSING", RequirementMissing},
+	}
+	if len(output.Requirements) != len(want) {
+		t.Fatalf("expected %d requirements, got %d", len(want), len(output.Requirements))
+	}
+	for i, w := range want {
+		got := output.Requirements[i]
+		if got.Env != w.env || got.Status != w.status {
+			t.Errorf("requirement %d: got (%s, %s), want (%s, %s)", i, got.Env, got.Status, w.env, w.status)
+		}
+	}
+	if output.Ready {
+		t.Error("expected ready=false with missing and expired required secrets")
+	}
+	if output.Present != 2 || output.Missing != 2 || output.Expired != 1 {
+		t.Errorf("unexpected counts: present=%d missing=%d expired=%d", output.Present, output.Missing, output.Expired)
+	}
+}
+
+func TestHandleProjectManifest_InvalidInput(t *testing.T) {
+	v, tmpDir := testVault(t)
+	server := &Server{vault: v, vaultPath: tmpDir}
+	ctx := context.Background()
+
+	if _, _, err := server.handleProjectManifest(ctx, nil, ProjectManifestInput{}); err == nil {
+		t.Error("expected error for empty directory")
+	}
+	if _, _, err := server.handleProjectManifest(ctx, nil, ProjectManifestInput{Directory: "relative/dir"}); err == nil {
+		t.Error("expected error for relative directory")
+	}
+	if _, _, err := server.handleProjectManifest(ctx, nil, ProjectManifestInput{Directory: t.TempDir()}); err == nil {
+		t.Error("expected error when manifest is missing")
+	}
+}
+
+func TestHandleSecretRun_ProjectDirRequiresOptIn(t *testing.T) {
+	v, tmpDir := testVault(t)
+	addTestSecret(t, v, "api/token", []byte("tok123"))
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, ".secretctl.yaml"), []byte("version: 1\nsecrets:\n  - key: api/token\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy:    &Policy{Version: 1, DefaultAction: ActionDeny, AllowedCommands: []string{"env"}},
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+	}
+
+	if _, err := server.collectProjectSecrets(projectDir); err == nil {
+		t.Fatal("expected error without project_manifests opt-in")
+	}
+
+	server.policy.ProjectManifests = true
+	secrets, err := server.collectProjectSecrets(projectDir)
+	if err != nil {
+		t.Fatalf("collectProjectSecrets failed: %v", err)
+	}
+	env, err := server.buildEnvironment(secrets, "")
+	if err != nil {
+		t.Fatalf("buildEnvironment failed: %v", err)
+	}
+	found := false
+	for _, e := range env {
+		if e == "API_TOKEN=tok123" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected API_TOKEN in environment")
+	}
+
+	_, _, err = server.handleSecretRun(context.Background(), nil, &SecretRunInput{
+		Keys:       []string{"api/token"},
+		ProjectDir: projectDir,
+		Command:    "env",
+	})
+	if err == nil {
+		t.Error("expected error when keys and project_dir are both set")
+	}
+}
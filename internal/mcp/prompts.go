@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxPromptArgLength bounds prompt arguments, which are pasted into the
+// prompt text.
+const maxPromptArgLength = 512
+
+// promptTemplate is a curated workflow offered as an MCP prompt. render
+// receives the validated arguments and returns the user message text.
+type promptTemplate struct {
+	prompt *mcp.Prompt
+	render func(args map[string]string) (string, error)
+}
+
+// promptTemplates are the prompts offered to clients. They only refer to
+// tools registered in registerTools and never ask for secret values.
+var promptTemplates = []promptTemplate{
+	{
+		prompt: &mcp.Prompt{
+			Name:        "rotate_credential",
+			Title:       "Rotate a credential",
+			Description: "Guided rotation of one secret: check its metadata, have the user set the new value, then verify it without revealing it.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "key", Description: "Secret key to rotate, e.g. prod/db/password", Required: true},
+				{Name: "test_command", Description: "Optional command that proves the new credential works, e.g. psql -c 'select 1'"},
+			},
+		},
+		render: renderRotateCredential,
+	},
+	{
+		prompt: &mcp.Prompt{
+			Name:        "setup_project_env",
+			Title:       "Set up a project's environment",
+			Description: "Check a project's .secretctl.yaml against the vault and run the project with its declared secrets injected.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "directory", Description: "Absolute path of the project checkout", Required: true},
+			},
+		},
+		render: renderSetupProjectEnv,
+	},
+	{
+		prompt: &mcp.Prompt{
+			Name:        "audit_key_access",
+			Title:       "Audit access to a key",
+			Description: "Summarize who and what can use a secret: its metadata, the MCP policy that governs it, and the CLI commands that show its usage and history.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "key", Description: "Secret key to audit", Required: true},
+			},
+		},
+		render: renderAuditKeyAccess,
+	},
+}
+
+// registerPrompts registers the curated workflow prompts with the server.
+func (s *Server) registerPrompts() {
+	for _, t := range promptTemplates {
+		s.server.AddPrompt(t.prompt, t.handler())
+	}
+}
+
+// handler validates the request arguments and renders the template.
+func (t promptTemplate) handler() mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := make(map[string]string)
+		for _, a := range t.prompt.Arguments {
+			value, err := promptArg(req.Params.Arguments, a)
+			if err != nil {
+				return nil, err
+			}
+			args[a.Name] = value
+		}
+		text, err := t.render(args)
+		if err != nil {
+			return nil, err
+		}
+		return &mcp.GetPromptResult{
+			Description: t.prompt.Description,
+			Messages: []*mcp.PromptMessage{
+				{Role: "user", Content: &mcp.TextContent{Text: text}},
+			},
+		}, nil
+	}
+}
+
+// promptArg returns the trimmed value of argument a. Values must fit on one
+// line so that they cannot add instructions of their own to the prompt.
+func promptArg(args map[string]string, a *mcp.PromptArgument) (string, error) {
+	value := strings.TrimSpace(args[a.Name])
+	if value == "" {
+		if a.Required {
+			return "", fmt.Errorf("argument %q is required", a.Name)
+		}
+		return "", nil
+	}
+	if len(value) > maxPromptArgLength {
+		return "", fmt.Errorf("argument %q is too long", a.Name)
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("argument %q must be a single line", a.Name)
+	}
+	return value, nil
+}
+
+func renderRotateCredential(args map[string]string) (string, error) {
+	key := args["key"]
+	var b strings.Builder
+	fmt.Fprintf(&b, "Help me rotate the secret %q stored in secretctl. Never ask me to paste the old or new value into this conversation.\n\n", key)
+	fmt.Fprintf(&b, "1. Call secret_exists for %q. Report when it was last updated, when it expires, and whether it is deprecated (if so, suggest rotating the replacement instead).\n", key)
+	fmt.Fprintf(&b, "2. Call secret_list_fields for %q to see which fields and environment bindings depend on it.\n", key)
+	b.WriteString("3. Create the new credential at its provider. I will store it myself: tell me to run\n")
+	fmt.Fprintf(&b, "     secretctl set %s\n", key)
+	b.WriteString("   (or 'secretctl generate' first for a random password), and wait until I confirm.\n")
+	fmt.Fprintf(&b, "4. Call secret_get_masked for %q and check that the masked value changed.\n", key)
+	if cmd := args["test_command"]; cmd != "" {
+		fmt.Fprintf(&b, "5. Call policy_info, then secret_run with %q to run: %s\n", key, cmd)
+		b.WriteString("   Report only the exit code and whether it succeeded.\n")
+	} else {
+		b.WriteString("5. Suggest a harmless command that would prove the new credential works, and run it with secret_run only if policy_info shows it is allowed.\n")
+	}
+	b.WriteString("6. Remind me to revoke the old credential at the provider once everything works.\n")
+	return b.String(), nil
+}
+
+func renderSetupProjectEnv(args map[string]string) (string, error) {
+	dir := args["directory"]
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("argument %q must be an absolute path", "directory")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Set up the environment for the project in %s using secretctl. Never print secret values.\n\n", dir)
+	fmt.Fprintf(&b, "1. Call project_secrets_manifest with directory %q.\n", dir)
+	b.WriteString("2. List the declared secrets that are missing or expired. For each one, tell me the exact 'secretctl set <key>' command to run, and wait until I confirm.\n")
+	b.WriteString("3. Call policy_info and check that the commands this project needs are allowed and that the directory is trusted. If not, explain which mcp-policy.yaml change I would need; do not try to work around it.\n")
+	b.WriteString("4. Run the project's setup or test command with secret_run, injecting the secrets under the env var names from the manifest, with the project directory as the working directory.\n")
+	b.WriteString("5. Summarize what is configured and anything still missing.\n")
+	return b.String(), nil
+}
+
+func renderAuditKeyAccess(args map[string]string) (string, error) {
+	key := args["key"]
+	var b strings.Builder
+	fmt.Fprintf(&b, "Audit who and what can use the secret %q in secretctl. Do not read or reveal its value.\n\n", key)
+	fmt.Fprintf(&b, "1. Call secret_exists for %q and report its creation and update times, expiry, access rules (not_before, access_window) and deprecation.\n", key)
+	fmt.Fprintf(&b, "2. Call policy_info and explain which commands an AI agent could run with %q, and whether it needs a pin or first-use approval.\n", key)
+	b.WriteString("3. The audit log is not available over MCP. Ask me to run these and paste the output:\n")
+	fmt.Fprintf(&b, "     secretctl history %s                (when its value changed)\n", key)
+	b.WriteString("     secretctl audit list --since 720h   (recent access events)\n")
+	fmt.Fprintf(&b, "   and 'secretctl get %s --show-metadata', pasting only its \"Used by\" section (commands it was injected into), since that output also contains the value.\n", key)
+	b.WriteString("4. Summarize the findings and flag anything unusual: stale values, unexpected commands, access from unknown sources, or a missing expiry.\n")
+	return b.String(), nil
+}
@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SandboxPolicy restricts the commands run by secret_run and the other run
+// tools. Without allow_network they cannot reach the network, and they may
+// write only beneath writable_paths (and to /dev/null); reading stays
+// unrestricted.
+type SandboxPolicy struct {
+	AllowNetwork  bool     `yaml:"allow_network"`
+	WritablePaths []string `yaml:"writable_paths"`
+	// Required refuses to run commands when this platform cannot apply
+	// every restriction, instead of running them with what it can apply
+	Required bool `yaml:"required"`
+}
+
+// Restrictions reported in SandboxReport.Unavailable
+const (
+	restrictNetwork    = "network"
+	restrictFilesystem = "filesystem"
+)
+
+// SandboxReport describes how a command was sandboxed.
+type SandboxReport struct {
+	// Backend is "linux", "sandbox-exec" (macOS) or "none"
+	Backend string `json:"backend"`
+	// Capabilities lists the mechanisms applied, e.g. "network_namespace",
+	// "landlock" or "seccomp"
+	Capabilities []string `json:"capabilities"`
+	// Network is true if the command could reach the network
+	Network bool `json:"network"`
+	// WritablePaths are the only places the command could write to,
+	// unless "filesystem" is in Unavailable
+	WritablePaths []string `json:"writable_paths,omitempty"`
+	// Unavailable lists restrictions this platform could not apply
+	// ("network" or "filesystem")
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// ErrSandboxUnavailable is returned when sandbox.required is set and a
+// restriction cannot be applied on this platform.
+var ErrSandboxUnavailable = errors.New("sandbox required but not available")
+
+// unsandboxedReport reports a command that ran without a sandbox.
+func unsandboxedReport(allowNetwork bool) *SandboxReport {
+	report := &SandboxReport{Backend: "none", Capabilities: []string{}, Network: true}
+	if !allowNetwork {
+		report.Unavailable = append(report.Unavailable, restrictNetwork)
+	}
+	report.Unavailable = append(report.Unavailable, restrictFilesystem)
+	return report
+}
+
+// Validate checks that every writable path is absolute and clean.
+func (p *SandboxPolicy) Validate() error {
+	for _, path := range p.WritablePaths {
+		if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+			return fmt.Errorf("invalid sandbox writable_paths entry %q (must be an absolute, clean path)", path)
+		}
+	}
+	return nil
+}
+
+// apply rewrites cmd, or configures the launcher, to run cmd inside the
+// sandbox and reports the restrictions in effect.
+func (p *SandboxPolicy) apply(cmd *exec.Cmd, launch *launchConfig) (*SandboxReport, error) {
+	writable := make([]string, 0, len(p.WritablePaths))
+	for _, path := range p.WritablePaths {
+		// Sandboxes match resolved paths (e.g. /tmp is /private/tmp on macOS)
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		writable = append(writable, path)
+	}
+
+	report, err := sandboxCommand(cmd, p.AllowNetwork, writable, launch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sandbox command: %w", err)
+	}
+	if p.Required && len(report.Unavailable) > 0 {
+		return nil, fmt.Errorf("%w: %s cannot be restricted on this platform", ErrSandboxUnavailable, strings.Join(report.Unavailable, ", "))
+	}
+	return report, nil
+}
@@ -0,0 +1,51 @@
+//go:build darwin
+
+package mcp
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// sandboxExecPath is the macOS sandbox launcher.
+const sandboxExecPath = "/usr/bin/sandbox-exec"
+
+// sandboxCommand makes cmd start through sandbox-exec with a profile that
+// denies the network and writes outside the writable paths.
+func sandboxCommand(cmd *exec.Cmd, allowNetwork bool, writable []string, _ *launchConfig) (*SandboxReport, error) {
+	if _, err := os.Stat(sandboxExecPath); err != nil {
+		return unsandboxedReport(allowNetwork), nil
+	}
+
+	var profile strings.Builder
+	profile.WriteString("(version 1)\n(allow default)\n")
+	if !allowNetwork {
+		profile.WriteString("(deny network*)\n")
+	}
+	profile.WriteString("(deny file-write*)\n(allow file-write* (literal \"/dev/null\")")
+	for _, path := range writable {
+		profile.WriteString(" (subpath " + sbplString(path) + ")")
+	}
+	profile.WriteString(")\n")
+
+	cmd.Args = append([]string{sandboxExecPath, "-p", profile.String(), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = sandboxExecPath
+	return &SandboxReport{
+		Backend:       "sandbox-exec",
+		Capabilities:  []string{"sandbox_profile"},
+		Network:       allowNetwork,
+		WritablePaths: writable,
+	}, nil
+}
+
+// sbplString quotes s as a sandbox profile string.
+func sbplString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// restrictSelf is never called: sandbox-exec needs no launcher.
+func (c *sandboxConfig) restrictSelf() error {
+	return errors.New("the launcher cannot sandbox commands on macOS")
+}
@@ -0,0 +1,245 @@
+//go:build linux
+
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// On Linux the launcher (see launch.go) sandboxes commands: it restricts
+// itself with Landlock (writes) and a seccomp filter (system calls and,
+// without network access, sockets) before executing the command. Without
+// network access it also starts in new user and network namespaces, which
+// have no interfaces.
+
+// sandboxCommand configures the launcher to sandbox cmd.
+func sandboxCommand(cmd *exec.Cmd, allowNetwork bool, writable []string, launch *launchConfig) (*SandboxReport, error) {
+	cfg := &sandboxConfig{Network: allowNetwork}
+	report := &SandboxReport{Backend: "linux", Capabilities: []string{"no_new_privs"}, Network: allowNetwork}
+	if landlockABI() > 0 {
+		cfg.Landlock = true
+		cfg.Writable = writable
+		report.Capabilities = append(report.Capabilities, "landlock")
+		report.WritablePaths = writable
+	} else {
+		report.Unavailable = append(report.Unavailable, restrictFilesystem)
+	}
+	if seccompArch != 0 {
+		cfg.Seccomp = true
+		report.Capabilities = append(report.Capabilities, "seccomp")
+	}
+	if !allowNetwork {
+		if namespacesAvailable() {
+			cmd.SysProcAttr = namespaceAttr()
+			report.Capabilities = append(report.Capabilities, "user_namespace", "network_namespace")
+		} else if !cfg.Seccomp {
+			report.Network = true
+			report.Unavailable = append(report.Unavailable, restrictNetwork)
+		}
+	}
+	launch.Sandbox = cfg
+	return report, nil
+}
+
+// namespaceAttr starts a process in new user, network and IPC namespaces,
+// as the current user.
+func namespaceAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+}
+
+// namespacesAvailable reports whether this process may create the
+// namespaces of namespaceAttr, which distributions can forbid (e.g. with
+// kernel.apparmor_restrict_unprivileged_userns), by starting the launcher
+// in them once.
+var namespacesAvailable = sync.OnceValue(func() bool {
+	self, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	probe := exec.Command(self)
+	probe.Env = []string{launchEnvVar + `={"probe":true}`}
+	probe.SysProcAttr = namespaceAttr()
+	return probe.Run() == nil
+})
+
+// restrictSelf applies the sandbox to the calling thread, whose locked
+// OS thread then executes the command.
+func (c *sandboxConfig) restrictSelf() error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if c.Landlock {
+		if err := restrictWrites(c.Writable); err != nil {
+			return fmt.Errorf("failed to restrict writes: %w", err)
+		}
+	}
+	if c.Seccomp {
+		if err := loadSeccompFilter(!c.Network); err != nil {
+			return fmt.Errorf("failed to load seccomp filter: %w", err)
+		}
+	}
+	return nil
+}
+
+// landlockABI returns the Landlock ABI version of the kernel, 0 if
+// Landlock is unavailable.
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// restrictWrites allows this thread to write only beneath the writable
+// paths and to /dev/null. Paths that do not exist are skipped.
+func restrictWrites(writable []string) error {
+	abi := landlockABI()
+	if abi == 0 {
+		return errors.New("landlock is not available")
+	}
+	fileAccess := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE)
+	dirAccess := uint64(unix.LANDLOCK_ACCESS_FS_REMOVE_DIR | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR | unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK | unix.LANDLOCK_ACCESS_FS_MAKE_FIFO | unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	if abi >= 2 {
+		dirAccess |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		fileAccess |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: fileAccess | dirAccess}
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return errno
+	}
+	defer unix.Close(int(ruleset))
+
+	for _, path := range append([]string{"/dev/null"}, writable...) {
+		fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if errors.Is(err, unix.ENOENT) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		var st unix.Stat_t
+		err = unix.Fstat(fd, &st)
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: fileAccess, Parent_fd: int32(fd)}
+		if st.Mode&unix.S_IFMT == unix.S_IFDIR {
+			rule.Allowed_access |= dirAccess
+		}
+		if err == nil {
+			_, _, errno = unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, ruleset, unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+			if errno != 0 {
+				err = errno
+			}
+		}
+		unix.Close(fd)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// seccompArch is the audit architecture the seccomp filter is built for,
+// 0 on architectures it does not support.
+var seccompArch = map[string]uint32{
+	"amd64": unix.AUDIT_ARCH_X86_64,
+	"arm64": unix.AUDIT_ARCH_AARCH64,
+}[runtime.GOARCH]
+
+// deniedSyscalls fail with EPERM in the sandbox. They leave the sandbox's
+// namespaces or mounts, inspect other processes, or reach parts of the
+// kernel that commands given secrets have no need for.
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_UNSHARE, unix.SYS_SETNS,
+	unix.SYS_KEXEC_LOAD, unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD,
+	unix.SYS_KEYCTL, unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY,
+	// io_uring can open sockets without socket(2)
+	unix.SYS_IO_URING_SETUP,
+}
+
+// deniedSocketFamilies cannot be used without network access.
+var deniedSocketFamilies = []uint32{unix.AF_INET, unix.AF_INET6, unix.AF_PACKET}
+
+// seccompFilter returns the BPF program of the sandbox's seccomp filter.
+func seccompFilter(denyNetwork bool) []unix.SockFilter {
+	const (
+		offsetNr   = 0 // struct seccomp_data
+		offsetArch = 4
+		offsetArg0 = 16 // low 32 bits on little-endian architectures
+	)
+	load := func(offset uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offset}
+	}
+	jumpEq := func(k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: jt, Jf: jf, K: k}
+	}
+	ret := func(k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: k}
+	}
+	deny := func(errno unix.Errno) unix.SockFilter {
+		return ret(unix.SECCOMP_RET_ERRNO | uint32(errno))
+	}
+
+	filter := []unix.SockFilter{
+		// System calls of another ABI (e.g. 32-bit ones on amd64) would
+		// bypass the numbers below, so they kill the process
+		load(offsetArch),
+		jumpEq(seccompArch, 1, 0),
+		ret(unix.SECCOMP_RET_KILL_PROCESS),
+		load(offsetNr),
+	}
+	if runtime.GOARCH == "amd64" {
+		// x32 system call numbers
+		filter = append(filter,
+			unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, Jt: 0, Jf: 1, K: 0x40000000},
+			deny(unix.EPERM))
+	}
+	for _, nr := range deniedSyscalls {
+		filter = append(filter, jumpEq(uint32(nr), 0, 1), deny(unix.EPERM))
+	}
+	if denyNetwork {
+		n := len(deniedSocketFamilies)
+		filter = append(filter, jumpEq(unix.SYS_SOCKET, 0, uint8(n+3)), load(offsetArg0))
+		for i, family := range deniedSocketFamilies {
+			filter = append(filter, jumpEq(family, uint8(n-i), 0))
+		}
+		filter = append(filter, unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JA, K: 1}, deny(unix.EACCES))
+	}
+	return append(filter, ret(unix.SECCOMP_RET_ALLOW))
+}
+
+// loadSeccompFilter installs the sandbox's seccomp filter on this thread.
+// no_new_privs must be set.
+func loadSeccompFilter(denyNetwork bool) error {
+	filter := seccompFilter(denyNetwork)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if _, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, 0, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
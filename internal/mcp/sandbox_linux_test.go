@@ -0,0 +1,86 @@
+//go:build linux
+
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runSandboxed runs a command from the trusted directories under sandbox.
+func runSandboxed(t *testing.T, sandbox *SandboxPolicy, command string, args ...string) *SecretRunOutput {
+	t.Helper()
+	resolved, err := ResolveAndValidateCommand(command)
+	if err != nil {
+		t.Skipf("%s not available: %v", command, err)
+	}
+	s := &Server{policy: &Policy{Version: 1, DefaultAction: ActionAllow, Sandbox: sandbox}}
+	result, err := s.runCommand(context.Background(), resolved, args, []string{"PATH=/usr/bin:/bin"}, newOutputSanitizer(nil), time.Minute, OutputText)
+	if err != nil {
+		t.Fatalf("runCommand(%s) failed: %v", command, err)
+	}
+	if result.Sandbox == nil || result.Sandbox.Backend != "linux" {
+		t.Fatalf("expected a linux sandbox report, got %+v", result.Sandbox)
+	}
+	return result
+}
+
+func TestSandbox_Filesystem(t *testing.T) {
+	writable := t.TempDir()
+	other := t.TempDir()
+	sandbox := &SandboxPolicy{WritablePaths: []string{writable}}
+
+	result := runSandboxed(t, sandbox, "touch", filepath.Join(writable, "ok"))
+	if !slices.Contains(result.Sandbox.Capabilities, "landlock") {
+		t.Skipf("landlock not available: %+v", result.Sandbox)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("write to a writable path failed: %+v", result)
+	}
+	if result := runSandboxed(t, sandbox, "touch", filepath.Join(other, "denied")); result.ExitCode == 0 {
+		t.Error("write outside the writable paths succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(other, "denied")); err == nil {
+		t.Error("file created outside the writable paths")
+	}
+
+	// Reading stays unrestricted
+	if result := runSandboxed(t, sandbox, "cat", "/etc/hostname"); result.ExitCode != 0 {
+		t.Errorf("read failed: %+v", result)
+	}
+}
+
+func TestSandbox_Network(t *testing.T) {
+	result := runSandboxed(t, &SandboxPolicy{}, "cat", "/proc/net/dev")
+	if result.Sandbox.Network {
+		t.Fatalf("expected network to be denied: %+v", result.Sandbox)
+	}
+	if slices.Contains(result.Sandbox.Capabilities, "network_namespace") {
+		// A new network namespace has only a loopback interface
+		for _, line := range strings.Split(result.Stdout, "\n")[2:] {
+			if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && name != "lo" {
+				t.Errorf("interface %s visible in the sandbox", name)
+			}
+		}
+	}
+
+	result = runSandboxed(t, &SandboxPolicy{AllowNetwork: true}, "cat", "/proc/net/dev")
+	if !result.Sandbox.Network || slices.Contains(result.Sandbox.Capabilities, "network_namespace") {
+		t.Errorf("expected network to be allowed: %+v", result.Sandbox)
+	}
+}
+
+func TestSandbox_Syscalls(t *testing.T) {
+	result := runSandboxed(t, &SandboxPolicy{AllowNetwork: true}, "unshare", "--user", "true")
+	if !slices.Contains(result.Sandbox.Capabilities, "seccomp") {
+		t.Skipf("seccomp filter not supported: %+v", result.Sandbox)
+	}
+	if result.ExitCode == 0 {
+		t.Error("unshare succeeded in the sandbox")
+	}
+}
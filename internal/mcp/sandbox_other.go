@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package mcp
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// sandboxCommand cannot restrict commands on this platform; it leaves cmd
+// unchanged.
+func sandboxCommand(_ *exec.Cmd, allowNetwork bool, _ []string, _ *launchConfig) (*SandboxReport, error) {
+	return unsandboxedReport(allowNetwork), nil
+}
+
+// restrictSelf is never called: sandboxCommand does not use the launcher.
+func (c *sandboxConfig) restrictSelf() error {
+	return errors.New("the launcher cannot sandbox commands on this platform")
+}
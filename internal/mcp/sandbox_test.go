@@ -0,0 +1,44 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandboxPolicy_Validate(t *testing.T) {
+	valid := &SandboxPolicy{WritablePaths: []string{"/tmp/build", "/var/cache/app"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+	for _, path := range []string{"build", "/tmp/../etc", "/tmp/build/", ""} {
+		p := &SandboxPolicy{WritablePaths: []string{path}}
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected an error for writable path %q", path)
+		}
+	}
+}
+
+func TestLoadPolicyFile_Sandbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PolicyFileName)
+	content := "version: 1\nsandbox:\n  writable_paths: [build]\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicyFile(path); err == nil || !strings.Contains(err.Error(), "writable_paths") {
+		t.Errorf("expected an invalid writable_paths error, got %v", err)
+	}
+
+	content = "version: 1\nsandbox:\n  allow_network: true\n  writable_paths: [/tmp]\n  required: true\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicyFile(path)
+	if err != nil {
+		t.Fatalf("LoadPolicyFile failed: %v", err)
+	}
+	if s := policy.Sandbox; s == nil || !s.AllowNetwork || !s.Required || len(s.WritablePaths) != 1 {
+		t.Errorf("unexpected sandbox policy %+v", s)
+	}
+}
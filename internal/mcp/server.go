@@ -5,12 +5,16 @@ package mcp
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/audit"
 	"github.com/forest6511/secretctl/pkg/vault"
 )
 
@@ -24,17 +28,42 @@ type Server struct {
 	vaultPath string
 	policy    *Policy
 	runSem    chan struct{} // Semaphore for limiting concurrent secret_run operations
+	sessionID string        // Identifies this server for `secretctl mcp pin`
+	usage     budgetUsage   // Consumption of the policy's session_budget
+	shared    *agent.Client // Agent whose unlocked session this server joined, if any
+
+	approvalsMu sync.Mutex // Serializes first-use approval checks
+	client      string     // Name the MCP client reported, for first-use approvals
 }
 
 // ServerOptions contains configuration options for the MCP server.
 type ServerOptions struct {
 	// VaultPath is the path to the vault directory.
-	// If empty, defaults to ~/.secretctl
+	// If empty, defaults to $SECRETCTL_VAULT_DIR, then ~/.secretctl
 	VaultPath string
 
+	// PolicyPath is the MCP policy file.
+	// If empty, defaults to mcp-policy.yaml in the vault directory.
+	PolicyPath string
+
 	// Password is the master password for the vault.
-	// If empty, the server will attempt to read from SECRETCTL_PASSWORD environment variable.
+	// If empty, the server will attempt to read from SECRETCTL_PASSWORD environment variable,
+	// and then to join the unlocked session of a running agent (e.g. the desktop app).
 	Password string
+
+	// AgentSocket is the agent socket used to join a shared session.
+	// If empty, defaults to agent.DefaultSocketPath(VaultPath).
+	AgentSocket string
+
+	// Snapshot makes the server read a copy of the vault database taken at
+	// startup instead of vault.db, refreshed by the snapshot_refresh tool.
+	// Tools that change the vault fail in this mode.
+	Snapshot bool
+
+	// AutoLock locks the vault once no tool has used it for this long,
+	// ending the session. Zero keeps the vault unlocked until the server
+	// exits.
+	AutoLock time.Duration
 }
 
 // NewServer creates a new MCP server instance.
@@ -45,6 +74,9 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 
 	// Determine vault path
 	vaultPath := opts.VaultPath
+	if vaultPath == "" {
+		vaultPath = os.Getenv("SECRETCTL_VAULT_DIR")
+	}
 	if vaultPath == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -54,15 +86,20 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 	}
 
 	// Load policy
-	policy, err := LoadPolicy(vaultPath)
+	policyPath := opts.PolicyPath
+	if policyPath == "" {
+		policyPath = filepath.Join(vaultPath, PolicyFileName)
+	}
+	policy, err := LoadPolicyFile(policyPath)
 	if err != nil {
 		// Policy load failure is not fatal - we'll operate in restricted mode
-		log.Printf("warning: failed to load MCP policy: %v", err)
+		slog.Warn("failed to load MCP policy", "err", err)
 		policy = nil
 	}
 
 	// Create vault instance
 	v := vault.New(vaultPath)
+	v.SetAuditSource(audit.SourceMCP)
 
 	// Get password from options or environment
 	password := opts.Password
@@ -72,34 +109,69 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 		os.Unsetenv("SECRETCTL_PASSWORD")
 	}
 
+	// Without a password, join the session of an agent that has the vault
+	// unlocked; locking there locks this server too.
+	var shared *agent.Client
 	if password == "" {
-		return nil, fmt.Errorf("no password provided: set SECRETCTL_PASSWORD environment variable")
+		socketPath := opts.AgentSocket
+		if socketPath == "" {
+			socketPath = agent.DefaultSocketPath(vaultPath)
+		}
+		if _, err := os.Stat(socketPath); err != nil {
+			return nil, fmt.Errorf("no password provided: set SECRETCTL_PASSWORD environment variable")
+		}
+		shared, err = attachSharedSession(v, socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to join shared session (set SECRETCTL_PASSWORD instead): %w", err)
+		}
+	} else if err := v.Unlock(password); err != nil {
+		return nil, fmt.Errorf("failed to unlock vault: %w", err)
+	}
+	if opts.Snapshot {
+		if err := v.UseSnapshot(); err != nil {
+			if shared != nil {
+				shared.Close()
+			}
+			v.Lock()
+			return nil, fmt.Errorf("failed to take vault snapshot: %w", err)
+		}
 	}
 
-	// Unlock the vault
-	if err := v.Unlock(password); err != nil {
-		return nil, fmt.Errorf("failed to unlock vault: %w", err)
+	if opts.AutoLock > 0 {
+		v.SetAutoLock(opts.AutoLock)
 	}
 
+	s := &Server{
+		vault:     v,
+		vaultPath: vaultPath,
+		policy:    policy,
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+		shared:    shared,
+	}
 	// Create the MCP server
-	mcpServer := mcp.NewServer(
+	s.server = mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "secretctl",
 			Version: "0.8.8",
 		},
-		nil,
+		&mcp.ServerOptions{InitializedHandler: s.handleInitialized},
 	)
+	if shared != nil {
+		go s.watchSharedSession()
+	}
 
-	s := &Server{
-		server:    mcpServer,
-		vault:     v,
-		vaultPath: vaultPath,
-		policy:    policy,
-		runSem:    make(chan struct{}, maxConcurrentRuns),
+	// Start a session so the user can pin keys for this server
+	session, err := startSession(vaultPath)
+	if err != nil {
+		slog.Warn("failed to start MCP session, pinning is unavailable", "err", err)
+	} else {
+		s.sessionID = session.ID
+		_ = v.Audit().LogSuccess(audit.OpSessionStart, audit.SourceMCP, "")
 	}
 
-	// Register tools
+	// Register tools and prompts
 	s.registerTools()
+	s.registerPrompts()
 
 	return s, nil
 }
@@ -107,82 +179,135 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 // registerTools registers all MCP tools with the server.
 func (s *Server) registerTools() {
 	// secret_list - List secret keys with metadata (no values)
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_list",
-		Description: "List all secret keys with metadata. Returns key names, tags, expiration, and flags for notes/url presence. Does NOT return secret values.",
+		Description: "List all secret keys with metadata. Returns key names, tags, expiration, and flags for notes/url presence. Does NOT return secret values. Without a filter, results are paged: if next_cursor is set, call again with it as cursor to get more (limit sets the page size, default 100).",
 	}, s.handleSecretList)
 
 	// secret_exists - Check if a secret exists and return metadata
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_exists",
 		Description: "Check if a secret key exists and return its metadata. Does NOT return the secret value.",
 	}, s.handleSecretExists)
 
 	// secret_get_masked - Get masked secret value
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_get_masked",
 		Description: "Get a masked version of a secret value (e.g., '****WXYZ'). Useful for verifying secret format without exposing the actual value.",
 	}, s.handleSecretGetMasked)
 
 	// secret_run - Execute command with secrets as environment variables
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_run",
-		Description: "Execute a command with specified secrets injected as environment variables. Output is automatically sanitized to prevent secret leakage. Requires policy approval.",
+		Description: "Execute a command with specified secrets injected as environment variables. Output is automatically sanitized to prevent secret leakage. Set output to 'json' to get stdout parsed into result, or 'discard' to return only the exit code and duration. Requires policy approval.",
 	}, s.handleSecretRun)
 
+	// secret_run_batch - Execute a short pipeline sharing one environment
+	addTool(s, &mcp.Tool{
+		Name:        "secret_run_batch",
+		Description: "Execute up to 5 commands in order with the same secrets injected as environment variables, e.g. 'terraform plan' then 'terraform apply'. Secrets are read once for all steps and every output is sanitized. Stops at the first step that exits non-zero unless continue_on_error is set. Every command must be allowed by policy.",
+	}, s.handleSecretRunBatch)
+
 	// secret_list_fields - List field names for a multi-field secret
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_list_fields",
 		Description: "List all field names and metadata for a multi-field secret. Returns field names, sensitivity flags, hints, and aliases. Does NOT return field values.",
 	}, s.handleSecretListFields)
 
 	// secret_get_field - Get a non-sensitive field value
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_get_field",
 		Description: "Get a specific field value from a multi-field secret. Only non-sensitive fields can be retrieved (AI-Safe Access policy). Sensitive fields will be rejected.",
 	}, s.handleSecretGetField)
 
 	// secret_run_with_bindings - Execute command with binding-based environment variables
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "secret_run_with_bindings",
 		Description: "Execute a command with environment variables injected based on the secret's predefined bindings. Each binding maps an environment variable name to a field. Requires policy approval.",
 	}, s.handleSecretRunWithBindings)
 
 	// security_score - Get vault security score and issue summary
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "security_score",
 		Description: "Get the security health score of your vault including password strength, duplicate detection, and expiration status. Returns a score from 0-100 with issue details and suggestions.",
 	}, s.handleSecurityScore)
 
 	// Phase 2c-X2: Folder MCP tools
 	// folder_list - List folders with metadata
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "folder_list",
 		Description: "List all folders with metadata including secret count and subfolder count. Use parent_id to list children of a specific folder.",
 	}, s.handleFolderList)
 
 	// folder_create - Create a new folder
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "folder_create",
 		Description: "Create a new folder for organizing secrets. Folder names cannot contain '/'.",
 	}, s.handleFolderCreate)
 
 	// folder_move_secret - Move a secret to a folder
-	mcp.AddTool(s.server, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "folder_move_secret",
 		Description: "Move a secret to a different folder. Set folder_id to null to unfile the secret.",
 	}, s.handleFolderMoveSecret)
+
+	// project_secrets_manifest - Report declared project requirements
+	addTool(s, &mcp.Tool{
+		Name:        "project_secrets_manifest",
+		Description: "Read a project's .secretctl.yaml (given the absolute checkout directory) and report which declared secrets are present, missing, or expired, with their target env var names. Does NOT return secret values.",
+	}, s.handleProjectManifest)
+
+	// policy_info - Describe the effective policy
+	addTool(s, &mcp.Tool{
+		Name:        "policy_info",
+		Description: "Describe the effective MCP policy: default action, allowed and denied commands, env aliases, pin-required key patterns, session budget usage, and secret_run limits. Call this before secret_run to avoid denied requests. Sections may be redacted by the policy.",
+	}, s.handlePolicyInfo)
+
+	// snapshot_refresh - Only when reading a snapshot (--snapshot)
+	if _, ok := s.vault.SnapshotTime(); ok {
+		addTool(s, &mcp.Tool{
+			Name:        "snapshot_refresh",
+			Description: "Take a new snapshot of the vault. This server reads a point-in-time copy of the vault, so changes made elsewhere are not visible until it is refreshed. Returns when the new snapshot was taken.",
+		}, s.handleSnapshotRefresh)
+	}
 }
 
 // Run starts the MCP server using stdio transport.
 func (s *Server) Run(ctx context.Context) error {
 	defer s.vault.Lock()
+	defer s.endSession()
+	defer s.closeShared()
+
+	monitorCtx, stopMonitor := context.WithCancel(ctx)
+	defer stopMonitor()
+	go s.monitorVault(monitorCtx)
+	go s.vault.WatchFiles(monitorCtx, vault.DefaultTamperCheckInterval, audit.SourceMCP)
 
 	return s.server.Run(ctx, &mcp.StdioTransport{})
 }
 
 // Close closes the server and locks the vault.
 func (s *Server) Close() error {
+	s.endSession()
+	s.closeShared()
+	s.vault.SetAutoLock(0)
 	s.vault.Lock()
 	return nil
 }
+
+// closeShared disconnects from a shared session without locking its owner.
+func (s *Server) closeShared() {
+	if s.shared != nil {
+		s.shared.Close()
+	}
+}
+
+// endSession ends the pin session (pins do not outlive the server).
+func (s *Server) endSession() {
+	if s.sessionID == "" {
+		return
+	}
+	endSession(s.vaultPath, s.sessionID)
+	_ = s.vault.Audit().LogSuccess(audit.OpSessionEnd, audit.SourceMCP, "")
+	s.sessionID = ""
+}
@@ -5,10 +5,14 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/forest6511/secretctl/pkg/vault/vaulttest"
 )
 
 // testVault creates a temporary vault for testing
@@ -39,18 +43,6 @@ func addTestSecret(t *testing.T, v *vault.Vault, key string, value []byte) {
 	}
 }
 
-// addTestSecretWithTags adds a secret with tags to the vault for testing
-func addTestSecretWithTags(t *testing.T, v *vault.Vault, key string, value []byte, tags []string) {
-	t.Helper()
-	entry := &vault.SecretEntry{
-		Value: value,
-		Tags:  tags,
-	}
-	if err := v.SetSecret(key, entry); err != nil {
-		t.Fatalf("failed to add secret '%s': %v", key, err)
-	}
-}
-
 // addTestSecretWithExpiration adds a secret with expiration to the vault for testing
 func addTestSecretWithExpiration(t *testing.T, v *vault.Vault, key string, value []byte, expiresAt *time.Time) {
 	t.Helper()
@@ -266,16 +258,24 @@ func TestHandleSecretList_Empty(t *testing.T) {
 	}
 }
 
-func TestHandleSecretList_WithSecrets(t *testing.T) {
-	v, tmpDir := testVault(t)
+// fixtureVault builds a vault with n generated secrets and returns it
+// with the generated entries.
+func fixtureVault(t *testing.T, seed uint64, n int) (*vault.Vault, []string, map[string]*vault.SecretEntry) {
+	t.Helper()
+	builder := vaulttest.NewBuilder(seed).WithSecrets(n).WithAuditEvents(0)
+	keys, entries, err := builder.Entries()
+	if err != nil {
+		t.Fatalf("failed to generate secrets: %v", err)
+	}
+	return builder.MustBuild(t), keys, entries
+}
 
-	// Add some secrets
-	addTestSecret(t, v, "api_key", []byte("secret123"))
-	addTestSecret(t, v, "db_password", []byte("dbpass456"))
+func TestHandleSecretList_WithSecrets(t *testing.T) {
+	v, keys, _ := fixtureVault(t, 1, 12)
 
 	server := &Server{
 		vault:     v,
-		vaultPath: tmpDir,
+		vaultPath: v.Path(),
 		runSem:    make(chan struct{}, maxConcurrentRuns),
 	}
 
@@ -284,28 +284,58 @@ func TestHandleSecretList_WithSecrets(t *testing.T) {
 	if err != nil {
 		t.Fatalf("handleSecretList failed: %v", err)
 	}
-	if len(output.Secrets) != 2 {
-		t.Errorf("expected 2 secrets, got %d", len(output.Secrets))
+	if len(output.Secrets) != len(keys) {
+		t.Errorf("expected %d secrets, got %d", len(keys), len(output.Secrets))
 	}
 
 	// Verify no values are exposed
 	for _, secret := range output.Secrets {
-		if secret.Key == "" {
-			t.Error("secret key is empty")
+		if !slices.Contains(keys, secret.Key) {
+			t.Errorf("unexpected secret key %q", secret.Key)
 		}
 	}
 }
 
-func TestHandleSecretList_ByTag(t *testing.T) {
-	v, tmpDir := testVault(t)
+func TestHandleSecretList_Paged(t *testing.T) {
+	v, _, _ := fixtureVault(t, 2, 3)
+
+	server := &Server{
+		vault:     v,
+		vaultPath: v.Path(),
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+	}
 
-	// Add secrets with tags
-	addTestSecretWithTags(t, v, "api_key", []byte("secret123"), []string{"prod", "api"})
-	addTestSecretWithTags(t, v, "dev_key", []byte("devpass"), []string{"dev"})
+	ctx := context.Background()
+	_, first, err := server.handleSecretList(ctx, nil, SecretListInput{Limit: 2})
+	if err != nil {
+		t.Fatalf("handleSecretList failed: %v", err)
+	}
+	if len(first.Secrets) != 2 || first.NextCursor == "" {
+		t.Fatalf("expected 2 secrets and a cursor, got %d, %q", len(first.Secrets), first.NextCursor)
+	}
+	_, second, err := server.handleSecretList(ctx, nil, SecretListInput{Cursor: first.NextCursor, Limit: 2})
+	if err != nil {
+		t.Fatalf("handleSecretList failed: %v", err)
+	}
+	if len(second.Secrets) != 1 || second.NextCursor != "" {
+		t.Errorf("expected the last secret and no cursor, got %d, %q", len(second.Secrets), second.NextCursor)
+	}
+
+	if _, _, err := server.handleSecretList(ctx, nil, SecretListInput{Tag: "prod", Limit: 2}); err == nil {
+		t.Error("expected an error for a limit with a tag filter")
+	}
+}
+
+func TestHandleSecretList_ByTag(t *testing.T) {
+	// Generated secrets with the one the assertions name
+	v := vaulttest.NewBuilder(3).WithSecrets(10).WithAuditEvents(0).WithTagRatio(0).
+		WithEntry("api_key", &vault.SecretEntry{Value: []byte("secret123"), Tags: []string{"prod", "api"}}).
+		WithEntry("dev_key", &vault.SecretEntry{Value: []byte("devpass"), Tags: []string{"dev"}}).
+		MustBuild(t)
 
 	server := &Server{
 		vault:     v,
-		vaultPath: tmpDir,
+		vaultPath: v.Path(),
 		runSem:    make(chan struct{}, maxConcurrentRuns),
 	}
 
@@ -323,16 +353,29 @@ func TestHandleSecretList_ByTag(t *testing.T) {
 }
 
 func TestHandleSecretList_ExpiringWithin(t *testing.T) {
-	v, tmpDir := testVault(t)
-
-	// Add secrets with expiration
 	expireTime := time.Now().Add(12 * time.Hour)
-	addTestSecretWithExpiration(t, v, "expiring_key", []byte("secret123"), &expireTime)
-	addTestSecret(t, v, "long_lived_key", []byte("longlived"))
+	builder := vaulttest.NewBuilder(4).WithSecrets(10).WithAuditEvents(0).
+		WithEntry("expiring_key", &vault.SecretEntry{Value: []byte("secret123"), ExpiresAt: &expireTime}).
+		WithEntry("long_lived_key", &vault.SecretEntry{Value: []byte("longlived")})
+	keys, entries, err := builder.Entries()
+	if err != nil {
+		t.Fatalf("failed to generate secrets: %v", err)
+	}
+	v := builder.MustBuild(t)
+
+	// Generated secrets expire whole days from now, so none is near the
+	// one-day boundary
+	cutoff := time.Now().Add(36 * time.Hour)
+	var want []string
+	for _, key := range keys {
+		if e := entries[key]; e.ExpiresAt != nil && e.ExpiresAt.Before(cutoff) {
+			want = append(want, key)
+		}
+	}
 
 	server := &Server{
 		vault:     v,
-		vaultPath: tmpDir,
+		vaultPath: v.Path(),
 		runSem:    make(chan struct{}, maxConcurrentRuns),
 	}
 
@@ -341,8 +384,13 @@ func TestHandleSecretList_ExpiringWithin(t *testing.T) {
 	if err != nil {
 		t.Fatalf("handleSecretList failed: %v", err)
 	}
-	if len(output.Secrets) != 1 {
-		t.Errorf("expected 1 expiring secret, got %d", len(output.Secrets))
+	if len(output.Secrets) != len(want) {
+		t.Errorf("expected %d expiring secrets, got %d", len(want), len(output.Secrets))
+	}
+	for _, secret := range output.Secrets {
+		if !slices.Contains(want, secret.Key) {
+			t.Errorf("%s listed as expiring within a day", secret.Key)
+		}
 	}
 }
 
@@ -1074,6 +1122,32 @@ func TestBuildEnvironment_BlockedEnvVar(t *testing.T) {
 	}
 }
 
+func TestBuildEnvironment_InheritEnv(t *testing.T) {
+	v, tmpDir := testVault(t)
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/agent.sock")
+	t.Setenv("DOCKER_HOST", "unix:///var/run/docker.sock")
+
+	server := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy:    &Policy{Version: 1, DefaultAction: ActionDeny, InheritEnv: []string{"SSH_AUTH_SOCK"}},
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+	}
+
+	env, err := server.buildEnvironment(nil, "")
+	if err != nil {
+		t.Fatalf("buildEnvironment failed: %v", err)
+	}
+	if !slices.Contains(env, "SSH_AUTH_SOCK=/tmp/agent.sock") {
+		t.Error("inherit_env variable SSH_AUTH_SOCK not inherited")
+	}
+	for _, e := range env {
+		if strings.HasPrefix(e, "DOCKER_HOST=") {
+			t.Error("DOCKER_HOST inherited without inherit_env")
+		}
+	}
+}
+
 func TestBuildEnvironment_NullByte(t *testing.T) {
 	v, tmpDir := testVault(t)
 
@@ -1103,7 +1177,7 @@ func TestExecuteCommand_PathTraversal(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	_, err := server.executeCommand(ctx, "../../../bin/sh", nil, nil, nil, time.Second)
+	_, err := server.executeCommand(ctx, "../../../bin/sh", nil, nil, nil, time.Second, "")
 	if err == nil {
 		t.Error("expected error for path traversal")
 	}
@@ -1119,12 +1193,61 @@ func TestExecuteCommand_NotFound(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	_, err := server.executeCommand(ctx, "nonexistentcommand12345", nil, nil, nil, time.Second)
+	_, err := server.executeCommand(ctx, "nonexistentcommand12345", nil, nil, nil, time.Second, "")
 	if err == nil {
 		t.Error("expected error for nonexistent command")
 	}
 }
 
+func TestExecuteCommand_OutputModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires /bin/sh")
+	}
+	v, tmpDir := testVault(t)
+	server := &Server{vault: v, vaultPath: tmpDir}
+	ctx := context.Background()
+	secrets := []secretData{{key: "API_KEY", value: []byte("s3cr3t-value")}}
+
+	script := []string{"-c", `printf '{"token":"s3cr3t-value","ok":true}'; echo oops >&2`}
+	result, err := server.executeCommand(ctx, "/bin/sh", script, nil, secrets, 5*time.Second, OutputJSON)
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if result.Stdout != "" || result.OutputError != "" {
+		t.Errorf("json: expected parsed result only, got stdout=%q error=%q", result.Stdout, result.OutputError)
+	}
+	if got := string(result.Result); got != `{"token":"[REDACTED:API_KEY]","ok":true}` {
+		t.Errorf("json: result = %s", got)
+	}
+
+	result, err = server.executeCommand(ctx, "/bin/sh", []string{"-c", "echo not json"}, nil, nil, 5*time.Second, OutputJSON)
+	if err != nil {
+		t.Fatalf("json invalid: %v", err)
+	}
+	if result.Result != nil || result.OutputError == "" || result.Stdout != "not json\n" {
+		t.Errorf("json invalid: expected text fallback with output_error, got %+v", result)
+	}
+
+	result, err = server.executeCommand(ctx, "/bin/sh", []string{"-c", "echo s3cr3t-value; echo err >&2; exit 3"}, nil, secrets, 5*time.Second, OutputDiscard)
+	if err != nil {
+		t.Fatalf("discard: %v", err)
+	}
+	if result.ExitCode != 3 || result.Stdout != "" || result.Stderr != "" {
+		t.Errorf("discard: expected exit code only, got %+v", result)
+	}
+}
+
+func TestValidateOutputMode(t *testing.T) {
+	for _, mode := range []string{"", OutputText, OutputJSON, OutputDiscard} {
+		if err := validateOutputMode(mode); err != nil {
+			t.Errorf("validateOutputMode(%q) = %v", mode, err)
+		}
+	}
+	if err := validateOutputMode("yaml"); err == nil {
+		t.Error("expected error for unknown output mode")
+	}
+}
+
 // addTestMultiFieldSecret adds a multi-field secret to the vault for testing
 func addTestMultiFieldSecret(t *testing.T, v *vault.Vault, key string, fields map[string]vault.Field, bindings map[string]string) {
 	t.Helper()
@@ -1831,3 +1954,143 @@ func TestWipeBuffer(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleSnapshotRefresh(t *testing.T) {
+	v, tmpDir := testVault(t)
+	defer v.Lock()
+	addTestSecret(t, v, "api_key", []byte("secret123"))
+	if err := v.UseSnapshot(); err != nil {
+		t.Fatalf("UseSnapshot failed: %v", err)
+	}
+
+	writer := vault.New(tmpDir)
+	if err := writer.Unlock("testpassword123"); err != nil {
+		t.Fatalf("failed to unlock vault: %v", err)
+	}
+	addTestSecret(t, writer, "token", []byte("token789"))
+	writer.Lock()
+
+	server := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+	}
+	ctx := context.Background()
+	if _, out, _ := server.handleSecretExists(ctx, nil, SecretExistsInput{Key: "token"}); out.Exists {
+		t.Error("secret written after the snapshot is visible before refresh")
+	}
+	_, out, err := server.handleSnapshotRefresh(ctx, nil, SnapshotRefreshInput{})
+	if err != nil {
+		t.Fatalf("handleSnapshotRefresh failed: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, out.TakenAt); err != nil {
+		t.Errorf("taken_at %q: %v", out.TakenAt, err)
+	}
+	if _, exists, _ := server.handleSecretExists(ctx, nil, SecretExistsInput{Key: "token"}); !exists.Exists {
+		t.Error("secret not visible after refresh")
+	}
+	if _, _, err := server.handleFolderCreate(ctx, nil, FolderCreateInput{Name: "x"}); err == nil {
+		t.Error("folder_create succeeded on a snapshot")
+	}
+}
+
+func TestHandleSecretRunBatch(t *testing.T) {
+	v, tmpDir := testVault(t)
+
+	secretValue := "supersecret123"
+	addTestSecret(t, v, "api_key", []byte(secretValue))
+
+	policy := &Policy{
+		Version:         1,
+		DefaultAction:   ActionDeny,
+		AllowedCommands: []string{"echo", "false"},
+	}
+
+	server := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy:    policy,
+		runSem:    make(chan struct{}, maxConcurrentRuns),
+	}
+
+	ctx := context.Background()
+	_, output, err := server.handleSecretRunBatch(ctx, nil, &SecretRunBatchInput{
+		Keys: []string{"api_key"},
+		Steps: []BatchStep{
+			{Command: "echo", Args: []string{"plan", secretValue}},
+			{Command: "echo", Args: []string{"apply"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("handleSecretRunBatch failed: %v", err)
+	}
+	if len(output.Steps) != 2 || output.Stopped {
+		t.Fatalf("expected 2 steps to run, got %+v", output)
+	}
+	if strings.Contains(output.Steps[0].Stdout, secretValue) {
+		t.Errorf("secret not sanitized in step 1: %q", output.Steps[0].Stdout)
+	}
+	if output.Steps[1].Stdout != "apply\n" {
+		t.Errorf("expected stdout 'apply\\n', got %q", output.Steps[1].Stdout)
+	}
+
+	// A failing step stops the rest
+	_, output, err = server.handleSecretRunBatch(ctx, nil, &SecretRunBatchInput{
+		Keys:  []string{"api_key"},
+		Steps: []BatchStep{{Command: "false"}, {Command: "echo", Args: []string{"apply"}}},
+	})
+	if err != nil {
+		t.Fatalf("handleSecretRunBatch failed: %v", err)
+	}
+	if len(output.Steps) != 1 || !output.Stopped || output.Steps[0].ExitCode == 0 {
+		t.Errorf("expected to stop after step 1, got %+v", output)
+	}
+
+	// ...unless continue_on_error is set
+	_, output, err = server.handleSecretRunBatch(ctx, nil, &SecretRunBatchInput{
+		Keys:            []string{"api_key"},
+		Steps:           []BatchStep{{Command: "false"}, {Command: "echo", Args: []string{"apply"}}},
+		ContinueOnError: true,
+	})
+	if err != nil {
+		t.Fatalf("handleSecretRunBatch failed: %v", err)
+	}
+	if len(output.Steps) != 2 || output.Stopped {
+		t.Errorf("expected both steps to run, got %+v", output)
+	}
+}
+
+func TestHandleSecretRunBatch_DeniedStep(t *testing.T) {
+	v, tmpDir := testVault(t)
+	addTestSecret(t, v, "api_key", []byte("secret123"))
+
+	server := &Server{
+		vault:     v,
+		vaultPath: tmpDir,
+		policy: &Policy{
+			Version:         1,
+			DefaultAction:   ActionDeny,
+			AllowedCommands: []string{"echo"},
+		},
+		runSem: make(chan struct{}, maxConcurrentRuns),
+	}
+
+	// The allowed first step must not run when a later step is denied
+	marker := filepath.Join(tmpDir, "ran")
+	_, _, err := server.handleSecretRunBatch(context.Background(), nil, &SecretRunBatchInput{
+		Keys: []string{"api_key"},
+		Steps: []BatchStep{
+			{Command: "echo", Args: []string{"ok"}},
+			{Command: "touch", Args: []string{marker}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "step 2") {
+		t.Fatalf("expected step 2 to be denied, got %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("denied step ran")
+	}
+	if server.usage.runs != 0 {
+		t.Errorf("expected no runs counted, got %d", server.usage.runs)
+	}
+}
@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// attachTimeout bounds how long NewServer waits for the user to approve
+// joining a shared session.
+const attachTimeout = 2 * time.Minute
+
+// attachSharedSession unlocks v with the session key of an agent (such as the
+// desktop app) that already has the vault unlocked. The agent asks its user
+// to approve the request. The returned client must stay open for
+// watchSharedSession.
+func attachSharedSession(v *vault.Vault, socketPath string) (*agent.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+	defer cancel()
+
+	client, err := agent.Dial(ctx, socketPath)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("waiting for approval to join the unlocked session", "socket", socketPath)
+	resp, err := client.Attach(ctx, &agentv1.AttachRequest{Client: "mcp-server", Pid: int32(os.Getpid())})
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	defer crypto.SecureWipe(resp.Key)
+
+	if err := v.UnlockWithKey(resp.Key); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to unlock vault with shared session key: %w", err)
+	}
+	return client, nil
+}
+
+// watchSharedSession locks this server when the session owner locks the
+// vault or goes away.
+func (s *Server) watchSharedSession() {
+	_, err := s.shared.WaitLocked(context.Background(), &agentv1.WaitLockedRequest{})
+
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if s.usage.locked != "" {
+		return
+	}
+	reason := "shared session was locked"
+	if err != nil {
+		reason = "shared session ended"
+	}
+	s.usage.locked = reason
+	s.vault.Lock()
+}
+
+// lockSharedSession propagates a lock of this server to the session owner.
+func (s *Server) lockSharedSession() {
+	if s.shared == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.shared.Lock(ctx, &agentv1.LockRequest{}); err != nil {
+		slog.Warn("failed to lock shared session", "err", err)
+	}
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// startSharedAgent serves an unlocked vault on an agent socket that approves
+// every attach request, like the desktop app after the user clicks Allow.
+func startSharedAgent(t *testing.T) (*vault.Vault, string, string) {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peer credential checks not supported on this platform")
+	}
+
+	v, tmpDir := testVault(t)
+	sockDir, err := os.MkdirTemp("", "sctl")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(sockDir) })
+
+	srv := agent.NewServer(v, &agent.ServerOptions{
+		SocketPath:     filepath.Join(sockDir, agent.SocketFileName),
+		HealthInterval: -1,
+		ApproveAttach:  func(*agent.AttachRequest) bool { return true },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(srv.SocketPath()); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("agent socket did not appear")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return v, tmpDir, srv.SocketPath()
+}
+
+func waitLocked(t *testing.T, v *vault.Vault) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !v.IsLocked() {
+		if time.Now().After(deadline) {
+			t.Fatal("vault was not locked")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestSharedSession_OwnerLockLocksServer(t *testing.T) {
+	owner, tmpDir, socketPath := startSharedAgent(t)
+	addTestSecret(t, owner, "api/key", []byte("secret-value"))
+	os.Unsetenv("SECRETCTL_PASSWORD")
+
+	s, err := NewServer(&ServerOptions{VaultPath: tmpDir, AgentSocket: socketPath})
+	if err != nil {
+		t.Fatalf("NewServer via shared session failed: %v", err)
+	}
+	defer s.Close()
+
+	if _, _, err := s.handleSecretExists(context.Background(), nil, SecretExistsInput{Key: "api/key"}); err != nil {
+		t.Fatalf("secret_exists in shared session failed: %v", err)
+	}
+
+	owner.Lock()
+	waitLocked(t, s.vault)
+	if err := s.checkSessionLocked(); !errors.Is(err, ErrSessionLocked) {
+		t.Errorf("expected ErrSessionLocked after owner locked, got %v", err)
+	}
+}
+
+func TestSharedSession_ServerLockLocksOwner(t *testing.T) {
+	owner, tmpDir, socketPath := startSharedAgent(t)
+	addTestSecret(t, owner, "a", []byte("value-a"))
+	addTestSecret(t, owner, "b", []byte("value-b"))
+	os.Unsetenv("SECRETCTL_PASSWORD")
+
+	s, err := NewServer(&ServerOptions{VaultPath: tmpDir, AgentSocket: socketPath})
+	if err != nil {
+		t.Fatalf("NewServer via shared session failed: %v", err)
+	}
+	defer s.Close()
+	s.policy = &Policy{Version: 1, DefaultAction: ActionDeny, SessionBudget: &SessionBudget{MaxSecrets: 1}}
+
+	ctx := context.Background()
+	if _, _, err := s.handleSecretExists(ctx, nil, SecretExistsInput{Key: "a"}); err != nil {
+		t.Fatalf("first secret failed: %v", err)
+	}
+	if _, _, err := s.handleSecretExists(ctx, nil, SecretExistsInput{Key: "b"}); !errors.Is(err, ErrSessionLocked) {
+		t.Fatalf("expected budget lock, got %v", err)
+	}
+	waitLocked(t, owner)
+}
@@ -0,0 +1,26 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SnapshotRefreshInput represents input for snapshot_refresh tool.
+type SnapshotRefreshInput struct{}
+
+// SnapshotRefreshOutput represents output for snapshot_refresh tool.
+type SnapshotRefreshOutput struct {
+	TakenAt string `json:"taken_at"` // RFC 3339
+}
+
+// handleSnapshotRefresh handles the snapshot_refresh tool call.
+func (s *Server) handleSnapshotRefresh(_ context.Context, _ *mcp.CallToolRequest, _ SnapshotRefreshInput) (*mcp.CallToolResult, SnapshotRefreshOutput, error) {
+	if err := s.vault.UseSnapshot(); err != nil {
+		return nil, SnapshotRefreshOutput{}, fmt.Errorf("failed to refresh snapshot: %w", err)
+	}
+	takenAt, _ := s.vault.SnapshotTime()
+	return nil, SnapshotRefreshOutput{TakenAt: takenAt.UTC().Format(time.RFC3339)}, nil
+}
@@ -4,21 +4,22 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
-	"encoding/base64"
-	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net/url"
-	"os"
+	"math"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/forest6511/secretctl/internal/secretenv"
 	"github.com/forest6511/secretctl/pkg/audit"
 	"github.com/forest6511/secretctl/pkg/security"
 	"github.com/forest6511/secretctl/pkg/vault"
@@ -31,11 +32,16 @@ type SecretListInput struct {
 	Tag            string `json:"tag,omitempty"`
 	ExpiringWithin string `json:"expiring_within,omitempty"`
 	FolderID       string `json:"folder_id,omitempty"` // Phase 2c-X2: Filter by folder
+	// Cursor and Limit page through the unfiltered list
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
 }
 
 // SecretListOutput represents output for secret_list tool.
 type SecretListOutput struct {
 	Secrets []SecretInfo `json:"secrets"`
+	// NextCursor is set if there are more secrets; pass it as cursor
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // SecretInfo represents metadata for a secret (no value).
@@ -50,6 +56,16 @@ type SecretInfo struct {
 	UpdatedAt  string   `json:"updated_at"`
 	FolderID   string   `json:"folder_id,omitempty"`   // Phase 2c-X2: Folder UUID
 	FolderPath string   `json:"folder_path,omitempty"` // Phase 2c-X2: Computed path for display
+	Deprecated bool     `json:"deprecated,omitempty"`
+	ReplacedBy string   `json:"replaced_by,omitempty"` // Key to use instead of a deprecated key
+	NotBefore  string   `json:"not_before,omitempty"`
+	// AccessWindow is when the secret can be read, e.g. "mon-fri 09:00-17:00 UTC"
+	AccessWindow string `json:"access_window,omitempty"`
+	Immutable    bool   `json:"immutable,omitempty"` // Write-once: cannot be changed by agents
+	PinRequired  bool   `json:"pin_required,omitempty"`
+	Pinned       bool   `json:"pinned,omitempty"` // Pinned by the user for this session
+	// CertNotAfter is when the certificate stored in the secret expires
+	CertNotAfter string `json:"cert_not_after,omitempty"`
 }
 
 // SecretExistsInput represents input for secret_exists tool.
@@ -67,6 +83,12 @@ type SecretExistsOutput struct {
 	HasURL    bool     `json:"has_url"`
 	CreatedAt string   `json:"created_at,omitempty"`
 	UpdatedAt string   `json:"updated_at,omitempty"`
+	// Deprecated keys should not be used; ReplacedBy names the replacement.
+	Deprecated bool   `json:"deprecated,omitempty"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	// Access rules: the secret cannot be read before NotBefore or outside AccessWindow.
+	NotBefore    string `json:"not_before,omitempty"`
+	AccessWindow string `json:"access_window,omitempty"`
 }
 
 // SecretGetMaskedInput represents input for secret_get_masked tool.
@@ -98,15 +120,38 @@ type SecretRunInput struct {
 	Timeout   string   `json:"timeout,omitempty"`
 	EnvPrefix string   `json:"env_prefix,omitempty"`
 	Env       string   `json:"env,omitempty"` // Environment alias (e.g., "dev", "staging", "prod")
-}
+	// ProjectDir injects the secrets declared in <project_dir>/.secretctl.yaml
+	// instead of keys. Requires project_manifests: true in the policy.
+	ProjectDir string `json:"project_dir,omitempty"`
+	// Output selects how stdout is returned: "text" (default), "json" or "discard"
+	Output string `json:"output,omitempty"`
+}
+
+// secret_run output modes
+const (
+	OutputText    = "text"    // sanitized stdout and stderr as strings
+	OutputJSON    = "json"    // sanitized stdout parsed as JSON into result
+	OutputDiscard = "discard" // exit code and duration only; output is never captured
+)
 
 // SecretRunOutput represents output for secret_run tool.
 type SecretRunOutput struct {
-	ExitCode   int    `json:"exit_code"`
-	Stdout     string `json:"stdout"`
-	Stderr     string `json:"stderr"`
-	DurationMs int64  `json:"duration_ms"`
-	Sanitized  bool   `json:"sanitized"`
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	// Result holds the parsed stdout when output is "json"
+	Result json.RawMessage `json:"result,omitempty"`
+	// OutputError explains why stdout could not be parsed as JSON; stdout is
+	// then returned as text instead
+	OutputError string `json:"output_error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+	Sanitized   bool   `json:"sanitized"`
+	// Sandbox describes the restrictions the command ran under, if the
+	// policy configures a sandbox
+	Sandbox *SandboxReport `json:"sandbox,omitempty"`
+	// Egress lists the destinations tried through the egress proxy, if
+	// the policy configures egress
+	Egress *EgressReport `json:"egress,omitempty"`
 }
 
 // SecretListFieldsInput represents input for secret_list_fields tool.
@@ -126,6 +171,7 @@ type FieldInfo struct {
 	Sensitive bool     `json:"sensitive"`
 	Hint      string   `json:"hint,omitempty"`
 	Kind      string   `json:"kind,omitempty"`
+	Validator string   `json:"validator,omitempty"`
 	Aliases   []string `json:"aliases,omitempty"`
 }
 
@@ -194,8 +240,14 @@ type SecurityIssueInfo struct {
 // handleSecretList handles the secret_list tool call.
 func (s *Server) handleSecretList(_ context.Context, _ *mcp.CallToolRequest, input SecretListInput) (*mcp.CallToolResult, SecretListOutput, error) {
 	var entries []*vault.SecretEntry
+	var nextCursor string
 	var err error
 
+	filtered := input.FolderID != "" || input.Tag != "" || input.ExpiringWithin != ""
+	if filtered && (input.Cursor != "" || input.Limit != 0) {
+		return nil, SecretListOutput{}, fmt.Errorf("cursor and limit cannot be combined with tag, expiring_within or folder_id")
+	}
+
 	switch {
 	case input.FolderID != "":
 		// Filter by folder (Phase 2c-X2)
@@ -224,18 +276,20 @@ func (s *Server) handleSecretList(_ context.Context, _ *mcp.CallToolRequest, inp
 			return nil, SecretListOutput{}, fmt.Errorf("failed to list expiring secrets: %w", err)
 		}
 	default:
-		// List all secrets with metadata but WITHOUT decrypting values
+		// List one page of secrets with metadata but WITHOUT decrypting values
 		// This follows AI-Safe Access principle: minimize plaintext exposure
-		entries, err = s.vault.ListSecretsWithMetadata()
-		if err != nil {
-			_ = s.vault.Audit().LogError(audit.OpSecretList, audit.SourceMCP, "", "LIST_FAILED", err.Error())
-			return nil, SecretListOutput{}, fmt.Errorf("failed to list secrets: %w", err)
+		page, pageErr := s.vault.ListSecretsPage(input.Cursor, input.Limit)
+		if pageErr != nil {
+			_ = s.vault.Audit().LogError(audit.OpSecretList, audit.SourceMCP, "", "LIST_FAILED", pageErr.Error())
+			return nil, SecretListOutput{}, fmt.Errorf("failed to list secrets: %w", pageErr)
 		}
+		entries, nextCursor = page.Secrets, page.NextCursor
 	}
 
 	// Convert to output format (no values!)
 	output := SecretListOutput{
-		Secrets: make([]SecretInfo, 0, len(entries)),
+		Secrets:    make([]SecretInfo, 0, len(entries)),
+		NextCursor: nextCursor,
 	}
 
 	for _, entry := range entries {
@@ -251,6 +305,24 @@ func (s *Server) handleSecretList(_ context.Context, _ *mcp.CallToolRequest, inp
 		if entry.ExpiresAt != nil {
 			info.ExpiresAt = entry.ExpiresAt.Format(time.RFC3339)
 		}
+		if entry.Deprecation != nil {
+			info.Deprecated = true
+			info.ReplacedBy = entry.Deprecation.ReplacedBy
+		}
+		if entry.NotBefore != nil {
+			info.NotBefore = entry.NotBefore.Format(time.RFC3339)
+		}
+		if entry.AccessWindow != nil {
+			info.AccessWindow = entry.AccessWindow.String()
+		}
+		info.Immutable = entry.Immutable
+		if entry.Certificate != nil {
+			info.CertNotAfter = entry.Certificate.NotAfter.Format(time.RFC3339)
+		}
+		if s.policy != nil && s.policy.RequiresPin(entry.Key) {
+			info.PinRequired = true
+			info.Pinned, _ = s.hasPin(entry.Key)
+		}
 		// Phase 2c-X2: Include folder information
 		if entry.FolderID != nil {
 			info.FolderID = *entry.FolderID
@@ -274,8 +346,11 @@ func (s *Server) handleSecretExists(_ context.Context, _ *mcp.CallToolRequest, i
 		_ = s.vault.Audit().LogError(audit.OpSecretExists, audit.SourceMCP, "", "INVALID_INPUT", "key is required")
 		return nil, SecretExistsOutput{}, errors.New("key is required")
 	}
+	if err := s.authorize(audit.OpSecretExists, input.Key); err != nil {
+		return nil, SecretExistsOutput{}, err
+	}
 
-	entry, err := s.vault.GetSecret(input.Key)
+	entry, err := s.vault.InspectSecret(input.Key)
 	if err != nil {
 		if errors.Is(err, vault.ErrSecretNotFound) {
 			// Log successful check (key doesn't exist is a valid result)
@@ -301,6 +376,16 @@ func (s *Server) handleSecretExists(_ context.Context, _ *mcp.CallToolRequest, i
 	if entry.ExpiresAt != nil {
 		output.ExpiresAt = entry.ExpiresAt.Format(time.RFC3339)
 	}
+	if entry.Deprecation != nil {
+		output.Deprecated = true
+		output.ReplacedBy = entry.Deprecation.ReplacedBy
+	}
+	if entry.NotBefore != nil {
+		output.NotBefore = entry.NotBefore.Format(time.RFC3339)
+	}
+	if entry.AccessWindow != nil {
+		output.AccessWindow = entry.AccessWindow.String()
+	}
 
 	// Log successful exists check
 	_ = s.vault.Audit().LogSuccess(audit.OpSecretExists, audit.SourceMCP, input.Key)
@@ -317,7 +402,10 @@ func (s *Server) handleSecretGetMasked(_ context.Context, _ *mcp.CallToolRequest
 		return nil, SecretGetMaskedOutput{}, errors.New("key is required")
 	}
 
-	entry, err := s.vault.GetSecret(input.Key)
+	masked, err := s.getSecretMasked(audit.OpSecretGetMasked, input.Key)
+	if errors.Is(err, ErrPinRequired) {
+		return nil, SecretGetMaskedOutput{}, err
+	}
 	if err != nil {
 		_ = s.vault.Audit().LogError(audit.OpSecretGetMasked, audit.SourceMCP, input.Key, "GET_FAILED", err.Error())
 		return nil, SecretGetMaskedOutput{}, fmt.Errorf("failed to get secret: %w", err)
@@ -326,31 +414,22 @@ func (s *Server) handleSecretGetMasked(_ context.Context, _ *mcp.CallToolRequest
 	// Build output with backward compatibility
 	output := SecretGetMaskedOutput{
 		Key:         input.Key,
-		MaskedValue: maskValue(entry.Value),
-		ValueLength: len(entry.Value),
+		MaskedValue: masked.Masked,
+		ValueLength: masked.Length,
 		FieldCount:  1, // Default for legacy secrets
 	}
 
-	// For multi-field secrets, include all fields with appropriate masking
-	if len(entry.Fields) > 0 {
-		output.FieldCount = len(entry.Fields)
-		output.Fields = make(map[string]MaskedField, len(entry.Fields))
-
-		for name, field := range entry.Fields {
-			mf := MaskedField{
+	// For multi-field secrets, include all fields; the vault has already
+	// masked the sensitive ones
+	if len(masked.Fields) > 0 {
+		output.FieldCount = len(masked.Fields)
+		output.Fields = make(map[string]MaskedField, len(masked.Fields))
+		for name, field := range masked.Fields {
+			output.Fields[name] = MaskedField{
+				Value:       field.Value,
 				Sensitive:   field.Sensitive,
-				ValueLength: len(field.Value),
-			}
-
-			if field.Sensitive {
-				// Mask sensitive field values
-				mf.Value = maskValue([]byte(field.Value))
-			} else {
-				// Show non-sensitive field values in full
-				mf.Value = field.Value
+				ValueLength: field.Length,
 			}
-
-			output.Fields[name] = mf
 		}
 	}
 
@@ -360,28 +439,16 @@ func (s *Server) handleSecretGetMasked(_ context.Context, _ *mcp.CallToolRequest
 	return nil, output, nil
 }
 
-// maskValue masks a secret value per mcp-design-ja.md §3.3
+// maskValue masks a secret value per mcp-design-ja.md §3.3 with the
+// default policy. The vault's mask_policy setting can change this (see
+// vault.MaskPolicy).
 // | Length  | Format          | Example   |
 // |---------|-----------------|-----------|
 // | 1-4     | All *           | ****      |
 // | 5-8     | Show last 2     | ******XY  |
 // | 9+      | Show last 4     | ****WXYZ  |
 func maskValue(value []byte) string {
-	length := len(value)
-	if length == 0 {
-		return ""
-	}
-
-	switch {
-	case length <= 4:
-		return strings.Repeat("*", length)
-	case length <= 8:
-		suffix := string(value[length-2:])
-		return strings.Repeat("*", length-2) + suffix
-	default:
-		suffix := string(value[length-4:])
-		return strings.Repeat("*", length-4) + suffix
-	}
+	return vault.MaskPolicy{Mode: vault.MaskDefault}.Mask(value)
 }
 
 // handleSecretRun handles the secret_run tool call.
@@ -396,10 +463,14 @@ func (s *Server) handleSecretRun(ctx context.Context, _ *mcp.CallToolRequest, in
 	}
 
 	// Validate required fields
-	if len(input.Keys) == 0 {
+	if len(input.Keys) == 0 && input.ProjectDir == "" {
 		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, "", "INVALID_INPUT", "keys is required")
 		return nil, SecretRunOutput{}, errors.New("keys is required")
 	}
+	if len(input.Keys) > 0 && input.ProjectDir != "" {
+		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, "", "INVALID_INPUT", "keys and project_dir are mutually exclusive")
+		return nil, SecretRunOutput{}, errors.New("keys and project_dir are mutually exclusive")
+	}
 	if input.Command == "" {
 		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, "", "INVALID_INPUT", "command is required")
 		return nil, SecretRunOutput{}, errors.New("command is required")
@@ -418,6 +489,10 @@ func (s *Server) handleSecretRun(ctx context.Context, _ *mcp.CallToolRequest, in
 		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, "", "INVALID_INPUT", "too many args")
 		return nil, SecretRunOutput{}, errors.New("too many args (max 100)")
 	}
+	if err := validateOutputMode(input.Output); err != nil {
+		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, "", "INVALID_INPUT", err.Error())
+		return nil, SecretRunOutput{}, err
+	}
 
 	// Check policy
 	if s.policy == nil {
@@ -444,6 +519,9 @@ func (s *Server) handleSecretRun(ctx context.Context, _ *mcp.CallToolRequest, in
 		_ = s.vault.Audit().LogDenied(audit.OpSecretRunDenied, audit.SourceMCP, input.Command, reason)
 		return nil, SecretRunOutput{}, fmt.Errorf("command not allowed by policy: %s", reason)
 	}
+	if err := s.useRun(audit.OpSecretRun); err != nil {
+		return nil, SecretRunOutput{}, err
+	}
 
 	// Resolve environment aliases if env is specified
 	keys := input.Keys
@@ -476,13 +554,26 @@ func (s *Server) handleSecretRun(ctx context.Context, _ *mcp.CallToolRequest, in
 	}
 
 	// Collect secrets (using resolved keys if env alias was applied)
-	secrets, err := s.collectSecrets(keys)
+	var secrets []secretData
+	if input.ProjectDir != "" {
+		secrets, err = s.collectProjectSecrets(input.ProjectDir)
+	} else {
+		secrets, err = s.collectSecrets(keys)
+	}
 	if err != nil {
 		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, "", "SECRET_COLLECT_FAILED", err.Error())
 		return nil, SecretRunOutput{}, err
 	}
 	defer wipeSecrets(secrets)
 
+	injected := 0
+	for _, secret := range secrets {
+		injected += len(secret.value)
+	}
+	if err := s.useInjectedBytes(audit.OpSecretRun, injected); err != nil {
+		return nil, SecretRunOutput{}, err
+	}
+
 	// Build environment
 	env, err := s.buildEnvironment(secrets, input.EnvPrefix)
 	if err != nil {
@@ -490,9 +581,13 @@ func (s *Server) handleSecretRun(ctx context.Context, _ *mcp.CallToolRequest, in
 		return nil, SecretRunOutput{}, err
 	}
 
+	for _, secret := range secrets {
+		s.recordUsage(secret.key, input.Command)
+	}
+
 	// Execute command using the pre-resolved and validated path
 	startTime := time.Now()
-	result, err := s.executeCommand(ctx, resolvedCmd, input.Args, env, secrets, timeout)
+	result, err := s.executeCommand(ctx, resolvedCmd, input.Args, env, secrets, timeout, input.Output)
 	if err != nil {
 		_ = s.vault.Audit().LogError(audit.OpSecretRun, audit.SourceMCP, input.Command, "EXEC_FAILED", err.Error())
 		return nil, SecretRunOutput{}, err
@@ -513,8 +608,11 @@ func (s *Server) handleSecretListFields(_ context.Context, _ *mcp.CallToolReques
 		_ = s.vault.Audit().LogError(audit.OpSecretListFields, audit.SourceMCP, "", "INVALID_INPUT", "key is required")
 		return nil, SecretListFieldsOutput{}, errors.New("key is required")
 	}
+	if err := s.authorize(audit.OpSecretListFields, input.Key); err != nil {
+		return nil, SecretListFieldsOutput{}, err
+	}
 
-	entry, err := s.vault.GetSecret(input.Key)
+	entry, err := s.vault.InspectSecret(input.Key)
 	if err != nil {
 		_ = s.vault.Audit().LogError(audit.OpSecretListFields, audit.SourceMCP, input.Key, "GET_FAILED", err.Error())
 		return nil, SecretListFieldsOutput{}, fmt.Errorf("failed to get secret: %w", err)
@@ -532,6 +630,7 @@ func (s *Server) handleSecretListFields(_ context.Context, _ *mcp.CallToolReques
 			Sensitive: field.Sensitive,
 			Hint:      field.Hint,
 			Kind:      field.Kind,
+			Validator: field.Validator,
 			Aliases:   field.Aliases,
 		}
 		output.Fields = append(output.Fields, info)
@@ -560,7 +659,10 @@ func (s *Server) handleSecretGetField(_ context.Context, _ *mcp.CallToolRequest,
 		return nil, SecretGetFieldOutput{}, errors.New("field is required")
 	}
 
-	entry, err := s.vault.GetSecret(input.Key)
+	entry, err := s.getSecret(audit.OpSecretGetField, input.Key)
+	if errors.Is(err, ErrPinRequired) {
+		return nil, SecretGetFieldOutput{}, err
+	}
 	if err != nil {
 		_ = s.vault.Audit().LogError(audit.OpSecretGetField, audit.SourceMCP, input.Key, "GET_FAILED", err.Error())
 		return nil, SecretGetFieldOutput{}, fmt.Errorf("failed to get secret: %w", err)
@@ -642,9 +744,15 @@ func (s *Server) handleSecretRunWithBindings(ctx context.Context, _ *mcp.CallToo
 		_ = s.vault.Audit().LogDenied(audit.OpSecretRunWithBindings, audit.SourceMCP, input.Command, reason)
 		return nil, SecretRunOutput{}, fmt.Errorf("command not allowed by policy: %s", reason)
 	}
+	if err := s.useRun(audit.OpSecretRunWithBindings); err != nil {
+		return nil, SecretRunOutput{}, err
+	}
 
 	// Get the secret
-	entry, err := s.vault.GetSecret(input.Key)
+	entry, err := s.getSecret(audit.OpSecretRunWithBindings, input.Key)
+	if errors.Is(err, ErrPinRequired) {
+		return nil, SecretRunOutput{}, err
+	}
 	if err != nil {
 		_ = s.vault.Audit().LogError(audit.OpSecretRunWithBindings, audit.SourceMCP, input.Key, "GET_FAILED", err.Error())
 		return nil, SecretRunOutput{}, fmt.Errorf("failed to get secret: %w", err)
@@ -687,6 +795,16 @@ func (s *Server) handleSecretRunWithBindings(ctx context.Context, _ *mcp.CallToo
 	defer wipeBindingSecrets(secrets)
 	defer wipeEnvSlice(env)
 
+	injected := 0
+	for _, secret := range secrets {
+		injected += len(secret.value)
+	}
+	if err := s.useInjectedBytes(audit.OpSecretRunWithBindings, injected); err != nil {
+		return nil, SecretRunOutput{}, err
+	}
+
+	s.recordUsage(input.Key, input.Command)
+
 	// Execute command
 	startTime := time.Now()
 	result, err := s.executeCommandWithBindings(ctx, resolvedCmd, input.Args, env, secrets, timeout)
@@ -704,6 +822,12 @@ func (s *Server) handleSecretRunWithBindings(ctx context.Context, _ *mcp.CallToo
 	return nil, *result, nil
 }
 
+// recordUsage adds command to the "used by" list of key. Failures are
+// ignored: the record is informational.
+func (s *Server) recordUsage(key, command string) {
+	_ = s.vault.RecordUsage(key, command, audit.SourceMCP)
+}
+
 // bindingSecretData holds an environment variable name and its value for binding-based injection.
 type bindingSecretData struct {
 	envVar string
@@ -746,47 +870,14 @@ func wipeBuffer(buf *bytes.Buffer) {
 
 // buildEnvironmentFromBindings creates environment variables from secret bindings.
 func (s *Server) buildEnvironmentFromBindings(entry *vault.SecretEntry) ([]string, []bindingSecretData, error) {
-	// Start with minimal safe environment
-	var env []string
-	for _, e := range os.Environ() {
-		parts := strings.SplitN(e, "=", 2)
-		if len(parts) == 2 && safeEnvVars[parts[0]] && !blockedEnvVars[parts[0]] {
-			env = append(env, e)
-		}
+	env, shared, err := secretenv.FromBindings(s.inheritedEnv(), entry)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	var secrets []bindingSecretData
-
-	// Add secrets as environment variables based on bindings
-	for envVar, fieldName := range entry.Bindings {
-		// Resolve field name (supports aliases)
-		_, field, err := vault.ResolveFieldName(entry.Fields, fieldName)
-		if err != nil {
-			return nil, nil, fmt.Errorf("binding '%s' references non-existent field '%s'", envVar, fieldName)
-		}
-
-		// Validate environment variable name
-		if err := validateEnvName(envVar); err != nil {
-			return nil, nil, fmt.Errorf("invalid environment variable name '%s': %w", envVar, err)
-		}
-
-		// Check for blocked env vars
-		if blockedEnvVars[envVar] {
-			return nil, nil, fmt.Errorf("cannot use blocked environment variable name: %s", envVar)
-		}
-
-		// Check for NUL bytes
-		if strings.ContainsRune(envVar, '\x00') || strings.ContainsRune(field.Value, '\x00') {
-			return nil, nil, fmt.Errorf("NUL byte detected in binding '%s'", envVar)
-		}
-
-		env = append(env, fmt.Sprintf("%s=%s", envVar, field.Value))
-		secrets = append(secrets, bindingSecretData{
-			envVar: envVar,
-			value:  []byte(field.Value),
-		})
+	secrets := make([]bindingSecretData, len(shared))
+	for i, secret := range shared {
+		secrets[i] = bindingSecretData{envVar: secret.Key, value: secret.Value}
 	}
-
 	return env, secrets, nil
 }
 
@@ -815,6 +906,39 @@ func (s *Server) executeCommandWithBindings(ctx context.Context, command string,
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Env = env
 
+	var egress *egressProxy
+	if s.policy != nil && s.policy.Egress != nil {
+		proxy, err := startEgressProxy(s.policy.Egress, s.vault.Audit())
+		if err != nil {
+			return nil, err
+		}
+		defer proxy.Close()
+		cmd.Env = proxy.environ(env)
+		egress = proxy
+	}
+
+	var sandbox *SandboxReport
+	var launch launchConfig
+	if s.policy != nil && s.policy.Sandbox != nil {
+		report, err := s.policy.Sandbox.apply(cmd, &launch)
+		if err != nil {
+			return nil, err
+		}
+		sandbox = report
+	}
+	if s.policy != nil && s.policy.ResourceLimits != nil {
+		limits, err := s.policy.ResourceLimits.rlimits()
+		if err != nil {
+			return nil, err
+		}
+		launch.Rlimits = limits
+	}
+	if launch.needed() {
+		if err := launchThrough(cmd, &launch); err != nil {
+			return nil, err
+		}
+	}
+
 	// Capture output
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -822,6 +946,10 @@ func (s *Server) executeCommandWithBindings(ctx context.Context, command string,
 
 	// Execute
 	err := cmd.Run()
+	var egressReport *EgressReport
+	if egress != nil {
+		egressReport = egress.Close()
+	}
 
 	// Convert bindingSecretData to secretData for sanitization
 	secretDataList := make([]secretData, len(secrets))
@@ -834,8 +962,8 @@ func (s *Server) executeCommandWithBindings(ctx context.Context, command string,
 
 	// Sanitize output
 	sanitizer := newOutputSanitizer(secretDataList)
-	sanitizedStdout := sanitizer.sanitize(stdout.Bytes())
-	sanitizedStderr := sanitizer.sanitize(stderr.Bytes())
+	sanitizedStdout := sanitizer.Sanitize(stdout.Bytes())
+	sanitizedStderr := sanitizer.Sanitize(stderr.Bytes())
 
 	// Wipe original buffers that may contain secrets in raw output
 	wipeBuffer(&stdout)
@@ -854,6 +982,8 @@ func (s *Server) executeCommandWithBindings(ctx context.Context, command string,
 		ExitCode: 0,
 		Stdout:   string(sanitizedStdout),
 		Stderr:   string(sanitizedStderr),
+		Sandbox:  sandbox,
+		Egress:   egressReport,
 	}
 
 	if err != nil {
@@ -875,6 +1005,8 @@ func (s *Server) executeCommandWithBindings(ctx context.Context, command string,
 type secretData struct {
 	key   string
 	value []byte
+	// envName overrides the name derived from key (set from .secretctl.yaml)
+	envName string
 }
 
 // wipeSecrets zeroes out all secret values in memory.
@@ -916,14 +1048,23 @@ func (s *Server) collectSecrets(patterns []string) ([]secretData, error) {
 		return nil, errors.New("no secrets match the specified patterns")
 	}
 
+	entries, err := s.getSecrets(audit.OpSecretRun, matchedKeys)
+	if errors.Is(err, ErrPinRequired) {
+		return nil, err
+	}
+	var keyErr *vault.KeyError
+	if errors.As(err, &keyErr) {
+		return nil, fmt.Errorf("failed to get secret '%s': %w", keyErr.Key, keyErr.Err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secrets: %w", err)
+	}
+
 	now := time.Now()
 	var secrets []secretData
 
 	for _, key := range matchedKeys {
-		entry, err := s.vault.GetSecret(key)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get secret '%s': %w", key, err)
-		}
+		entry := entries[key]
 
 		// Check if secret is expired per design
 		if entry.ExpiresAt != nil && entry.ExpiresAt.Before(now) {
@@ -974,116 +1115,65 @@ func expandPattern(pattern string, availableKeys []string) ([]string, error) {
 }
 
 // blockedEnvVars per mcp-design-ja.md §6.3.3
-var blockedEnvVars = map[string]bool{
-	// secretctl related
-	"SECRETCTL_PASSWORD": true,
-
-	// Dynamic linker attacks
-	"LD_PRELOAD":            true,
-	"LD_LIBRARY_PATH":       true,
-	"DYLD_INSERT_LIBRARIES": true,
-	"DYLD_LIBRARY_PATH":     true,
-
-	// Shell startup script execution
-	"BASH_ENV":  true,
-	"ENV":       true,
-	"SHELLOPTS": true,
-	"BASHOPTS":  true,
-
-	// Script language auto-execution
-	"PERL5OPT":      true,
-	"PYTHONSTARTUP": true,
-	"PYTHONPATH":    true,
-	"RUBYOPT":       true,
-	"NODE_OPTIONS":  true,
-
-	// Other dangerous variables
-	"IFS":        true,
-	"CDPATH":     true,
-	"GLOBIGNORE": true,
-}
+var blockedEnvVars = secretenv.BlockedVars
 
 // safeEnvVars are the only environment variables we inherit
-var safeEnvVars = map[string]bool{
-	"PATH":    true,
-	"HOME":    true,
-	"USER":    true,
-	"LOGNAME": true,
-	"LANG":    true,
-	"LC_ALL":  true,
-	"TERM":    true,
-	"TZ":      true,
+var safeEnvVars = secretenv.SafeVars
+
+// inheritedEnv returns the variables of the server's environment that
+// commands inherit: safeEnvVars plus the policy's inherit_env, never a
+// blocked one.
+func (s *Server) inheritedEnv() []string {
+	return secretenv.Inherited(s.inheritsEnv)
+}
+
+// inheritsEnv reports whether commands inherit the variable name.
+func (s *Server) inheritsEnv(name string) bool {
+	if blockedEnvVars[name] {
+		return false
+	}
+	return safeEnvVars[name] || (s.policy != nil && slices.Contains(s.policy.InheritEnv, name))
 }
 
 // buildEnvironment creates environment variables from secrets
 func (s *Server) buildEnvironment(secrets []secretData, prefix string) ([]string, error) {
 	// Start with minimal safe environment per mcp-design-ja.md §6.3.2
-	var env []string
-	for _, e := range os.Environ() {
-		parts := strings.SplitN(e, "=", 2)
-		if len(parts) == 2 && safeEnvVars[parts[0]] && !blockedEnvVars[parts[0]] {
-			env = append(env, e)
-		}
-	}
-
-	// Add secrets as environment variables
-	for _, secret := range secrets {
-		envName := keyToEnvName(secret.key)
-		if prefix != "" {
-			envName = prefix + envName
-		}
-
-		// Validate per §6.3.5
-		if err := validateEnvName(envName); err != nil {
-			return nil, fmt.Errorf("invalid environment variable name for key '%s': %w", secret.key, err)
-		}
-
-		// Check for blocked env vars
-		if blockedEnvVars[envName] {
-			return nil, fmt.Errorf("cannot use blocked environment variable name: %s", envName)
-		}
-
-		// Check for NUL bytes per §6.3.5
-		if strings.ContainsRune(envName, '\x00') || bytes.ContainsRune(secret.value, '\x00') {
-			return nil, fmt.Errorf("NUL byte detected in key '%s'", secret.key)
-		}
+	return secretenv.Build(s.inheritedEnv(), sharedSecrets(secrets), prefix)
+}
 
-		env = append(env, fmt.Sprintf("%s=%s", envName, string(secret.value)))
+// sharedSecrets converts secrets for the secretenv package. The values are
+// shared, not copied, so wipeSecrets still clears them.
+func sharedSecrets(secrets []secretData) []secretenv.Secret {
+	shared := make([]secretenv.Secret, len(secrets))
+	for i, secret := range secrets {
+		shared[i] = secretenv.Secret{Key: secret.key, Value: secret.value, EnvName: secret.envName}
 	}
-
-	return env, nil
+	return shared
 }
 
 // keyToEnvName converts a secret key to an environment variable name
 func keyToEnvName(key string) string {
-	name := strings.ReplaceAll(key, "/", "_")
-	name = strings.ReplaceAll(name, "-", "_")
-	return strings.ToUpper(name)
+	return secretenv.KeyToEnvName(key)
 }
 
 // validateEnvName validates environment variable name
 func validateEnvName(name string) error {
-	if name == "" {
-		return errors.New("environment variable name cannot be empty")
-	}
-	first := name[0]
-	if !((first >= 'A' && first <= 'Z') || (first >= 'a' && first <= 'z') || first == '_') {
-		return errors.New("must start with a letter or underscore")
-	}
-	for i := 1; i < len(name); i++ {
-		c := name[i]
-		if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_') {
-			return fmt.Errorf("contains invalid character '%c'", c)
-		}
-	}
-	return nil
+	return secretenv.ValidateName(name)
 }
 
 // executeCommand runs the command with secrets in environment.
 // IMPORTANT: The command parameter MUST be an absolute path that has already been
 // resolved and validated by ResolveAndValidateCommand. This function does NOT
 // perform path lookup to prevent PATH manipulation attacks.
-func (s *Server) executeCommand(ctx context.Context, command string, args []string, env []string, secrets []secretData, timeout time.Duration) (*SecretRunOutput, error) {
+// Output is handled according to output (see OutputText, OutputJSON and
+// OutputDiscard); an empty output means OutputText.
+func (s *Server) executeCommand(ctx context.Context, command string, args []string, env []string, secrets []secretData, timeout time.Duration, output string) (*SecretRunOutput, error) {
+	return s.runCommand(ctx, command, args, env, newOutputSanitizer(secrets), timeout, output)
+}
+
+// runCommand is executeCommand with a sanitizer built by the caller, so
+// that the commands of a secret_run_batch share one.
+func (s *Server) runCommand(ctx context.Context, command string, args []string, env []string, sanitizer *outputSanitizer, timeout time.Duration, output string) (*SecretRunOutput, error) {
 	// Validate command path per §6.3.4
 	if err := validateCommand(command); err != nil {
 		return nil, err
@@ -1109,18 +1199,57 @@ func (s *Server) executeCommand(ctx context.Context, command string, args []stri
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Env = env
 
-	// Capture output
+	var egress *egressProxy
+	if s.policy != nil && s.policy.Egress != nil {
+		proxy, err := startEgressProxy(s.policy.Egress, s.vault.Audit())
+		if err != nil {
+			return nil, err
+		}
+		defer proxy.Close()
+		cmd.Env = proxy.environ(env)
+		egress = proxy
+	}
+
+	var sandbox *SandboxReport
+	var launch launchConfig
+	if s.policy != nil && s.policy.Sandbox != nil {
+		report, err := s.policy.Sandbox.apply(cmd, &launch)
+		if err != nil {
+			return nil, err
+		}
+		sandbox = report
+	}
+	if s.policy != nil && s.policy.ResourceLimits != nil {
+		limits, err := s.policy.ResourceLimits.rlimits()
+		if err != nil {
+			return nil, err
+		}
+		launch.Rlimits = limits
+	}
+	if launch.needed() {
+		if err := launchThrough(cmd, &launch); err != nil {
+			return nil, err
+		}
+	}
+
+	// Capture output. With discard, output goes to the null device and never
+	// reaches memory, so there is nothing to sanitize or leak.
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	if output != OutputDiscard {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+	}
 
 	// Execute
 	err := cmd.Run()
+	var egressReport *EgressReport
+	if egress != nil {
+		egressReport = egress.Close()
+	}
 
 	// Sanitize output
-	sanitizer := newOutputSanitizer(secrets)
-	sanitizedStdout := sanitizer.sanitize(stdout.Bytes())
-	sanitizedStderr := sanitizer.sanitize(stderr.Bytes())
+	sanitizedStdout := sanitizer.Sanitize(stdout.Bytes())
+	sanitizedStderr := sanitizer.Sanitize(stderr.Bytes())
 
 	// Wipe original buffers that may contain secrets in raw output
 	wipeBuffer(&stdout)
@@ -1139,6 +1268,20 @@ func (s *Server) executeCommand(ctx context.Context, command string, args []stri
 		ExitCode: 0,
 		Stdout:   string(sanitizedStdout),
 		Stderr:   string(sanitizedStderr),
+		Sandbox:  sandbox,
+		Egress:   egressReport,
+	}
+
+	// Parse after sanitizing so redaction placeholders are part of the result
+	// and a secret can never surface through the parsed JSON
+	if output == OutputJSON {
+		trimmed := bytes.TrimSpace(sanitizedStdout)
+		if json.Valid(trimmed) {
+			result.Result = json.RawMessage(trimmed)
+			result.Stdout = ""
+		} else {
+			result.OutputError = "stdout is not valid JSON"
+		}
 	}
 
 	if err != nil {
@@ -1156,137 +1299,39 @@ func (s *Server) executeCommand(ctx context.Context, command string, args []stri
 	return result, nil
 }
 
-// validateCommand validates command path per §6.3.4
-func validateCommand(cmd string) error {
-	// Check for path traversal
-	if strings.Contains(cmd, "..") || strings.Contains(cmd, "/./") {
-		return errors.New("path traversal detected in command")
-	}
-
-	// Check for NUL byte
-	if strings.ContainsRune(cmd, '\x00') {
-		return errors.New("null byte detected in command")
+// validateOutputMode checks the secret_run output option.
+func validateOutputMode(output string) error {
+	switch output {
+	case "", OutputText, OutputJSON, OutputDiscard:
+		return nil
+	default:
+		return fmt.Errorf("invalid output %q (must be text, json or discard)", output)
 	}
+}
 
-	return nil
+// validateCommand validates command path per §6.3.4
+func validateCommand(cmd string) error {
+	return secretenv.ValidateCommand(cmd)
 }
 
 // validateArgs validates command arguments per §6.3.5
 func validateArgs(args []string) error {
-	for i, arg := range args {
-		if strings.ContainsRune(arg, '\x00') {
-			return fmt.Errorf("null byte in argument %d", i)
-		}
-		if len(arg) > 32768 {
-			return fmt.Errorf("argument %d too long", i)
-		}
-	}
-	return nil
+	return secretenv.ValidateArgs(args)
 }
 
-// outputSanitizer sanitizes output by replacing secret values and their encoded forms.
-// This prevents secrets from leaking through MCP output in any form.
-type outputSanitizer struct {
-	replacements []secretReplacement
-}
-
-type secretReplacement struct {
-	secret      []byte
-	placeholder []byte
-}
+// outputSanitizer sanitizes output by replacing secret values and their
+// encoded forms (see secretenv.NewSanitizer), so that secrets cannot leak
+// through MCP output in any form.
+type outputSanitizer = secretenv.Sanitizer
 
-// newOutputSanitizer creates a sanitizer that will redact:
-// - The raw secret value (all lengths, not just >= 4 bytes)
-// - Base64-encoded forms (padded and raw/unpadded, standard and URL-safe)
-// - URL-encoded forms (QueryEscape and PathEscape styles)
-// - Hex-encoded forms (uppercase and lowercase, with and without 0x prefix)
 func newOutputSanitizer(secrets []secretData) *outputSanitizer {
-	// Use a map to deduplicate encoded forms
-	seen := make(map[string]bool)
-	var replacements []secretReplacement
-
-	addReplacement := func(secret []byte, placeholder []byte) {
-		key := string(secret)
-		if seen[key] || len(secret) == 0 {
-			return
-		}
-		seen[key] = true
-		replacements = append(replacements, secretReplacement{
-			secret:      secret,
-			placeholder: placeholder,
-		})
-	}
-
-	for _, secret := range secrets {
-		placeholder := []byte(fmt.Sprintf("[REDACTED:%s]", keyToEnvName(secret.key)))
-
-		// Always add the raw value replacement, regardless of length
-		// Short secrets are security-critical too (e.g., PIN codes, short API keys)
-		addReplacement(secret.value, placeholder)
-
-		// Only generate encoded forms for secrets with content
-		if len(secret.value) == 0 {
-			continue
-		}
-
-		// Base64-encoded forms (padded)
-		addReplacement([]byte(base64.StdEncoding.EncodeToString(secret.value)), placeholder)
-		addReplacement([]byte(base64.URLEncoding.EncodeToString(secret.value)), placeholder)
-
-		// Base64-encoded forms (raw/unpadded) - catches JWT segments, etc.
-		addReplacement([]byte(base64.RawStdEncoding.EncodeToString(secret.value)), placeholder)
-		addReplacement([]byte(base64.RawURLEncoding.EncodeToString(secret.value)), placeholder)
-
-		// URL-encoded forms
-		addReplacement([]byte(url.QueryEscape(string(secret.value))), placeholder)    // space as +
-		addReplacement([]byte(url.PathEscape(string(secret.value))), placeholder)     // space as %20
-		addReplacement([]byte(percentEncodeLower(string(secret.value))), placeholder) // lowercase %xx
-
-		// Hex-encoded forms (plain)
-		hexLower := hex.EncodeToString(secret.value)
-		hexUpper := strings.ToUpper(hexLower)
-		addReplacement([]byte(hexLower), placeholder)
-		addReplacement([]byte(hexUpper), placeholder)
-
-		// Hex-encoded forms with 0x prefix (common in debug output)
-		addReplacement([]byte("0x"+hexLower), placeholder)
-		addReplacement([]byte("0X"+hexUpper), placeholder)
-	}
-
-	// Sort replacements by length (longest first) to avoid partial replacements
-	// e.g., if we have "secret" and "secretkey", replace "secretkey" first
-	sortReplacementsByLength(replacements)
-
-	return &outputSanitizer{replacements: replacements}
+	return secretenv.NewSanitizer(sharedSecrets(secrets))
 }
 
-// percentEncodeLower generates URL encoding with lowercase hex digits
-// (some systems output %2f instead of %2F)
-func percentEncodeLower(s string) string {
-	return strings.ToLower(url.QueryEscape(s))
-}
-
-// sortReplacementsByLength sorts replacements by secret length in descending order.
-// This ensures longer matches are replaced first, preventing partial replacement issues.
-func sortReplacementsByLength(replacements []secretReplacement) {
-	sort.Slice(replacements, func(i, j int) bool {
-		return len(replacements[i].secret) > len(replacements[j].secret)
-	})
-}
-
-func (s *outputSanitizer) sanitize(data []byte) []byte {
-	// Always make a copy to avoid aliasing issues when the original buffer is wiped.
-	// This ensures wipeBuffer on the source doesn't affect the sanitized result.
-	result := make([]byte, len(data))
-	copy(result, data)
-
-	for _, r := range s.replacements {
-		result = bytes.ReplaceAll(result, r.secret, r.placeholder)
-	}
-	return result
-}
-
-// parseDuration parses a duration string like "30d", "1y", "24h"
+// parseDuration parses a duration string like "30d", "1y", "24h".
+// d, w, m (30 days) and y (365 days) take a whole number; anything else,
+// such as "90s", "1.5h" or "1h30m", is parsed by time.ParseDuration.
+// Negative and overflowing durations are rejected.
 func parseDuration(s string) (time.Duration, error) {
 	if len(s) < 2 {
 		return 0, fmt.Errorf("duration too short: %s", s)
@@ -1295,25 +1340,46 @@ func parseDuration(s string) (time.Duration, error) {
 	unit := s[len(s)-1]
 	valueStr := s[:len(s)-1]
 
-	var value int
-	if _, err := fmt.Sscanf(valueStr, "%d", &value); err != nil {
-		return 0, fmt.Errorf("invalid duration value: %s", valueStr)
-	}
-
+	var per time.Duration
 	switch unit {
-	case 'h':
-		return time.Duration(value) * time.Hour, nil
 	case 'd':
-		return time.Duration(value) * 24 * time.Hour, nil
+		per = 24 * time.Hour
 	case 'w':
-		return time.Duration(value) * 7 * 24 * time.Hour, nil
+		per = 7 * 24 * time.Hour
 	case 'm':
-		return time.Duration(value) * 30 * 24 * time.Hour, nil
+		per = 30 * 24 * time.Hour
 	case 'y':
-		return time.Duration(value) * 365 * 24 * time.Hour, nil
-	default:
-		return time.ParseDuration(s)
+		per = 365 * 24 * time.Hour
 	}
+	if per == 0 || !isDigits(valueStr) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("duration must not be negative: %s", s)
+		}
+		return d, nil
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil || value > int64(math.MaxInt64/per) {
+		return 0, fmt.Errorf("duration too long: %s", s)
+	}
+	return time.Duration(value) * per, nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // handleSecurityScore handles the security_score tool call.
@@ -1517,6 +1583,9 @@ func (s *Server) handleFolderMoveSecret(_ context.Context, _ *mcp.CallToolReques
 	if input.SecretKey == "" {
 		return nil, FolderMoveSecretOutput{}, errors.New("secret_key is required")
 	}
+	if err := s.authorize(audit.OpSecretUpdate, input.SecretKey); err != nil {
+		return nil, FolderMoveSecretOutput{}, err
+	}
 
 	if err := s.vault.MoveSecretToFolder(input.SecretKey, input.FolderID); err != nil {
 		return nil, FolderMoveSecretOutput{}, fmt.Errorf("failed to move secret: %w", err)
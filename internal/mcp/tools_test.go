@@ -215,7 +215,7 @@ func TestOutputSanitizer(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := string(sanitizer.sanitize([]byte(tt.input)))
+			result := string(sanitizer.Sanitize([]byte(tt.input)))
 			if result != tt.expected {
 				t.Errorf("sanitize(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
@@ -360,7 +360,7 @@ func TestOutputSanitizerMultipleOccurrences(t *testing.T) {
 
 	// Multiple occurrences should all be redacted
 	input := []byte("first: secret123, second: secret123")
-	result := sanitizer.sanitize(input)
+	result := sanitizer.Sanitize(input)
 	expected := "first: [REDACTED:API_KEY], second: [REDACTED:API_KEY]"
 
 	if string(result) != expected {
@@ -374,7 +374,7 @@ func TestOutputSanitizerEmpty(t *testing.T) {
 
 	// Empty secrets should return unchanged data
 	input := []byte("some data")
-	result := sanitizer.sanitize(input)
+	result := sanitizer.Sanitize(input)
 
 	if string(result) != string(input) {
 		t.Errorf("sanitize with no secrets = %q, want %q", result, input)
@@ -432,7 +432,7 @@ func TestOutputSanitizerEncodedForms(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := string(sanitizer.sanitize([]byte(tt.input)))
+			result := string(sanitizer.Sanitize([]byte(tt.input)))
 			if result != tt.expected {
 				t.Errorf("sanitize(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
@@ -476,7 +476,7 @@ func TestOutputSanitizerURLEncoded(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := string(sanitizer.sanitize([]byte(tt.input)))
+			result := string(sanitizer.Sanitize([]byte(tt.input)))
 			if result != tt.expected {
 				t.Errorf("sanitize(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
@@ -494,7 +494,7 @@ func TestOutputSanitizerLongestFirst(t *testing.T) {
 
 	// "secretkey" should be replaced as a whole, not "secret" + "key"
 	input := "value=secretkey"
-	result := string(sanitizer.sanitize([]byte(input)))
+	result := string(sanitizer.Sanitize([]byte(input)))
 	expected := "value=[REDACTED:LONG]"
 
 	if result != expected {
@@ -0,0 +1,238 @@
+// Package project reads the per-project .secretctl.yaml manifest.
+//
+// The manifest is committed to a repository and declares which vault keys a
+// project needs and which environment variables they map to. It never
+// contains secret values.
+//
+// Example:
+//
+//	version: 1
+//	secrets:
+//	  - key: db/dev
+//	    field: password
+//	    env: DATABASE_PASSWORD
+//	  - key: api/dev/stripe
+//	    env: STRIPE_KEY
+//	    optional: true
+//	    description: Only needed for payment tests
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// FileName is the manifest file name looked up in a project directory.
+const FileName = ".secretctl.yaml"
+
+// SupportedVersion is the only manifest version understood by this build.
+const SupportedVersion = 1
+
+// MaxManifestSize bounds the manifest file size (the file is untrusted input).
+const MaxManifestSize = 64 * 1024
+
+// Manifest errors
+var (
+	ErrManifestNotFound = errors.New("project: .secretctl.yaml not found")
+	ErrManifestInvalid  = errors.New("project: invalid .secretctl.yaml")
+	ErrManifestTooLarge = errors.New("project: .secretctl.yaml too large")
+)
+
+// Requirement declares one secret a project needs.
+type Requirement struct {
+	// Key is the vault key.
+	Key string `yaml:"key"`
+	// Field selects a field of a multi-field secret. Empty uses the default field.
+	Field string `yaml:"field,omitempty"`
+	// Env is the environment variable name. Empty derives it from Key.
+	Env string `yaml:"env,omitempty"`
+	// Optional requirements do not fail injection when missing.
+	Optional bool `yaml:"optional,omitempty"`
+	// Description is shown to humans and AI agents.
+	Description string `yaml:"description,omitempty"`
+}
+
+// Manifest is the parsed .secretctl.yaml file.
+type Manifest struct {
+	Version int           `yaml:"version"`
+	Secrets []Requirement `yaml:"secrets"`
+
+	// Path is the file the manifest was loaded from.
+	Path string `yaml:"-"`
+}
+
+// Load reads the manifest in dir.
+func Load(dir string) (*Manifest, error) {
+	return LoadFile(filepath.Join(dir, FileName))
+}
+
+// LoadFile reads and validates a manifest file.
+func LoadFile(path string) (*Manifest, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrManifestNotFound
+		}
+		return nil, fmt.Errorf("project: failed to stat manifest: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%w: %s is not a regular file", ErrManifestInvalid, path)
+	}
+	if info.Size() > MaxManifestSize {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrManifestTooLarge, info.Size(), MaxManifestSize)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("project: failed to open manifest: %w", err)
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, MaxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("project: failed to read manifest: %w", err)
+	}
+	if len(content) > MaxManifestSize {
+		return nil, ErrManifestTooLarge
+	}
+
+	m, err := Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	m.Path = path
+	return m, nil
+}
+
+// Parse parses and validates manifest content.
+func Parse(content []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrManifestInvalid, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Validate checks version, keys and environment variable names.
+func (m *Manifest) Validate() error {
+	if m.Version != SupportedVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrManifestInvalid, m.Version)
+	}
+
+	seen := make(map[string]string, len(m.Secrets))
+	for i, r := range m.Secrets {
+		if r.Key == "" {
+			return fmt.Errorf("%w: secrets[%d]: key is required", ErrManifestInvalid, i)
+		}
+		env := r.EnvName()
+		if !isValidEnvName(env) {
+			return fmt.Errorf("%w: secrets[%d]: invalid env name %q", ErrManifestInvalid, i, env)
+		}
+		if prev, ok := seen[env]; ok {
+			return fmt.Errorf("%w: env %s is mapped from both %s and %s", ErrManifestInvalid, env, prev, r.Key)
+		}
+		seen[env] = r.Key
+	}
+	return nil
+}
+
+// EnvName returns the environment variable name for the requirement.
+// Without an explicit env, the key is converted like `secretctl run` does
+// (/, - and . become _, uppercased).
+func (r Requirement) EnvName() string {
+	if r.Env != "" {
+		return r.Env
+	}
+	name := strings.ReplaceAll(r.Key, "/", "_")
+	name = strings.ReplaceAll(name, "-", "_")
+	name = strings.ReplaceAll(name, ".", "_")
+	return strings.ToUpper(name)
+}
+
+// Find searches dir and its parents for a manifest, stopping after the first
+// directory that contains .git (the repository root) or at the filesystem root.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("project: failed to resolve directory: %w", err)
+	}
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if _, err := os.Lstat(candidate); err == nil {
+			return candidate, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", ErrManifestNotFound
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrManifestNotFound
+		}
+		dir = parent
+	}
+}
+
+// Resolved is a requirement paired with its decrypted value.
+type Resolved struct {
+	Requirement
+	Value []byte
+}
+
+// Resolve fetches the value of every requirement using lookup. Missing
+// optional secrets are skipped; missing required secrets, expired secrets
+// and unknown fields are errors.
+func (m *Manifest) Resolve(lookup func(key string) (*vault.SecretEntry, error)) ([]Resolved, error) {
+	now := time.Now()
+	resolved := make([]Resolved, 0, len(m.Secrets))
+	for _, r := range m.Secrets {
+		entry, err := lookup(r.Key)
+		if err != nil {
+			if r.Optional && errors.Is(err, vault.ErrSecretNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get secret '%s': %w", r.Key, err)
+		}
+		if entry.ExpiresAt != nil && entry.ExpiresAt.Before(now) {
+			return nil, fmt.Errorf("secret '%s' has expired", r.Key)
+		}
+
+		field := r.Field
+		if field == "" {
+			field = vault.DefaultFieldName
+		}
+		_, f, err := vault.ResolveFieldName(entry.Fields, field)
+		if err != nil {
+			return nil, fmt.Errorf("secret '%s': %w (set 'field' in %s)", r.Key, err, FileName)
+		}
+		resolved = append(resolved, Resolved{Requirement: r, Value: []byte(f.Value)})
+	}
+	return resolved, nil
+}
+
+// isValidEnvName reports whether name matches ^[A-Za-z_][A-Za-z0-9_]*$.
+func isValidEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(`version: 1
+secrets:
+  - key: db/dev
+    field: password
+    env: DB_PASSWORD
+  - key: api/dev-key.v2
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(m.Secrets) != 2 {
+		t.Fatalf("expected 2 secrets, got %d", len(m.Secrets))
+	}
+	if got := m.Secrets[0].EnvName(); got != "DB_PASSWORD" {
+		t.Errorf("expected DB_PASSWORD, got %s", got)
+	}
+	if got := m.Secrets[1].EnvName(); got != "API_DEV_KEY_V2" {
+		t.Errorf("expected API_DEV_KEY_V2, got %s", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := map[string]string{
+		"bad version":   "version: 2\nsecrets: []\n",
+		"missing key":   "version: 1\nsecrets:\n  - env: A\n",
+		"bad env":       "version: 1\nsecrets:\n  - key: a\n    env: 1A\n",
+		"duplicate env": "version: 1\nsecrets:\n  - key: a\n    env: A\n  - key: b\n    env: A\n",
+		"not yaml":      "version: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(content)); !errors.Is(err, ErrManifestInvalid) {
+				t.Errorf("expected ErrManifestInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("expected ErrManifestNotFound, got %v", err)
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("version: 1\nsecrets:\n  - key: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if m.Path != path {
+		t.Errorf("expected path %s, got %s", path, m.Path)
+	}
+
+	big := make([]byte, MaxManifestSize+1)
+	if err := os.WriteFile(path, big, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); !errors.Is(err, ErrManifestTooLarge) {
+		t.Errorf("expected ErrManifestTooLarge, got %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Find(sub); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("expected ErrManifestNotFound, got %v", err)
+	}
+
+	path := filepath.Join(root, FileName)
+	if err := os.WriteFile(path, []byte("version: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Find(sub)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if got != path {
+		t.Errorf("expected %s, got %s", path, got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	entries := map[string]*vault.SecretEntry{
+		"db/dev": {Fields: map[string]vault.Field{
+			"password": {Value: "pw"},
+		}},
+		"api/token": {Fields: map[string]vault.Field{
+			vault.DefaultFieldName: {Value: "tok"},
+		}},
+	}
+	lookup := func(key string) (*vault.SecretEntry, error) {
+		if e, ok := entries[key]; ok {
+			return e, nil
+		}
+		return nil, vault.ErrSecretNotFound
+	}
+
+	m := &Manifest{Version: 1, Secrets: []Requirement{
+		{Key: "db/dev", Field: "password", Env: "DB_PASSWORD"},
+		{Key: "api/token"},
+		{Key: "missing", Optional: true},
+	}}
+	resolved, err := m.Resolve(lookup)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if len(resolved) != 2 {
+		t.Fatalf("expected 2 resolved, got %d", len(resolved))
+	}
+	if resolved[0].EnvName() != "DB_PASSWORD" || string(resolved[0].Value) != "pw" {
+		t.Errorf("unexpected first: %+v", resolved[0])
+	}
+	if resolved[1].EnvName() != "API_TOKEN" || string(resolved[1].Value) != "tok" {
+		t.Errorf("unexpected second: %+v", resolved[1])
+	}
+
+	m.Secrets = append(m.Secrets, Requirement{Key: "missing-required"})
+	if _, err := m.Resolve(lookup); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+
+	m.Secrets = []Requirement{{Key: "db/dev"}}
+	if _, err := m.Resolve(lookup); !errors.Is(err, vault.ErrFieldNotFound) {
+		t.Errorf("expected ErrFieldNotFound for missing default field, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package secretenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Sanitizer replaces secret values and their encoded forms in command
+// output, so that secrets cannot leak through it in any form.
+type Sanitizer struct {
+	replacements []replacement
+	maxLen       int
+}
+
+type replacement struct {
+	secret      []byte
+	placeholder []byte
+}
+
+// NewSanitizer creates a sanitizer that redacts, as [REDACTED:<name>]:
+//   - the raw secret value (all lengths, not just >= 4 bytes)
+//   - base64-encoded forms (padded and raw/unpadded, standard and URL-safe)
+//   - URL-encoded forms (QueryEscape and PathEscape styles)
+//   - hex-encoded forms (uppercase and lowercase, with and without 0x prefix)
+func NewSanitizer(secrets []Secret) *Sanitizer {
+	// Use a map to deduplicate encoded forms
+	seen := make(map[string]bool)
+	s := &Sanitizer{}
+
+	add := func(secret []byte, placeholder []byte) {
+		key := string(secret)
+		if seen[key] || len(secret) == 0 {
+			return
+		}
+		seen[key] = true
+		s.replacements = append(s.replacements, replacement{secret: secret, placeholder: placeholder})
+		s.maxLen = max(s.maxLen, len(secret))
+	}
+
+	for _, secret := range secrets {
+		placeholder := []byte(fmt.Sprintf("[REDACTED:%s]", KeyToEnvName(secret.Key)))
+
+		// Short secrets are security-critical too (e.g., PIN codes, short API keys)
+		add(secret.Value, placeholder)
+		if len(secret.Value) == 0 {
+			continue
+		}
+
+		// Base64-encoded forms (padded)
+		add([]byte(base64.StdEncoding.EncodeToString(secret.Value)), placeholder)
+		add([]byte(base64.URLEncoding.EncodeToString(secret.Value)), placeholder)
+
+		// Base64-encoded forms (raw/unpadded) - catches JWT segments, etc.
+		add([]byte(base64.RawStdEncoding.EncodeToString(secret.Value)), placeholder)
+		add([]byte(base64.RawURLEncoding.EncodeToString(secret.Value)), placeholder)
+
+		// URL-encoded forms
+		add([]byte(url.QueryEscape(string(secret.Value))), placeholder)    // space as +
+		add([]byte(url.PathEscape(string(secret.Value))), placeholder)     // space as %20
+		add([]byte(percentEncodeLower(string(secret.Value))), placeholder) // lowercase %xx
+
+		// Hex-encoded forms (plain)
+		hexLower := hex.EncodeToString(secret.Value)
+		hexUpper := strings.ToUpper(hexLower)
+		add([]byte(hexLower), placeholder)
+		add([]byte(hexUpper), placeholder)
+
+		// Hex-encoded forms with 0x prefix (common in debug output)
+		add([]byte("0x"+hexLower), placeholder)
+		add([]byte("0X"+hexUpper), placeholder)
+	}
+
+	// Longest first so that "secretkey" is replaced before "secret"
+	sort.Slice(s.replacements, func(i, j int) bool {
+		return len(s.replacements[i].secret) > len(s.replacements[j].secret)
+	})
+	return s
+}
+
+// percentEncodeLower generates URL encoding with lowercase hex digits
+// (some systems output %2f instead of %2F)
+func percentEncodeLower(s string) string {
+	return strings.ToLower(url.QueryEscape(s))
+}
+
+// Sanitize returns a redacted copy of data. The copy never aliases data,
+// so data can be wiped afterwards.
+func (s *Sanitizer) Sanitize(data []byte) []byte {
+	result := make([]byte, len(data))
+	copy(result, data)
+	for _, r := range s.replacements {
+		result = bytes.ReplaceAll(result, r.secret, r.placeholder)
+	}
+	return result
+}
+
+// MaxLen returns the length of the longest form Sanitize redacts.
+func (s *Sanitizer) MaxLen() int {
+	return s.maxLen
+}
+
+// LimitedBuffer collects command output up to Limit bytes and silently
+// drops the rest, so that a chatty command cannot exhaust memory. Writes
+// always succeed, so the command is not killed by a broken pipe.
+type LimitedBuffer struct {
+	Limit int
+	buf   bytes.Buffer
+}
+
+// Write implements io.Writer.
+func (b *LimitedBuffer) Write(p []byte) (int, error) {
+	if room := b.Limit - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// Bytes returns the collected output.
+func (b *LimitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// Wipe zeroes the collected output, which may contain secrets.
+func (b *LimitedBuffer) Wipe() {
+	data := b.buf.Bytes()
+	for i := range data {
+		data[i] = 0
+	}
+	runtime.KeepAlive(data)
+	b.buf.Reset()
+}
+
+// Capture returns a LimitedBuffer for output that is sanitized with s and
+// then cut to limit bytes. It keeps enough beyond limit that a secret
+// starting before the cut is still redacted whole.
+func (s *Sanitizer) Capture(limit int) *LimitedBuffer {
+	return &LimitedBuffer{Limit: limit + s.maxLen}
+}
@@ -0,0 +1,42 @@
+package secretenv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &LimitedBuffer{Limit: 5}
+	for _, p := range []string{"abc", "defg", "hij"} {
+		if n, err := b.Write([]byte(p)); n != len(p) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v; want %d, nil", p, n, err, len(p))
+		}
+	}
+	if got := string(b.Bytes()); got != "abcde" {
+		t.Errorf("Bytes() = %q, want %q", got, "abcde")
+	}
+
+	data := b.Bytes()
+	b.Wipe()
+	if len(b.Bytes()) != 0 || !bytes.Equal(data, make([]byte, len(data))) {
+		t.Error("Wipe did not clear the output")
+	}
+}
+
+func TestCaptureRedactsSecretAtLimit(t *testing.T) {
+	s := NewSanitizer([]Secret{{Key: "token", Value: []byte("s3cr3t-value")}})
+	const limit = 16
+
+	// The secret starts before the limit and ends after it
+	out := s.Capture(limit)
+	out.Write([]byte(strings.Repeat("x", limit-4) + "s3cr3t-value and more"))
+	got := string(s.Sanitize(out.Bytes()))[:limit]
+
+	if strings.Contains(got, "s3cr") {
+		t.Errorf("secret prefix leaked at the limit: %q", got)
+	}
+	if !strings.HasPrefix(got, strings.Repeat("x", limit-4)+"[RED") {
+		t.Errorf("unexpected output %q", got)
+	}
+}
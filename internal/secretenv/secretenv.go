@@ -0,0 +1,189 @@
+// Package secretenv builds the environment of commands run with secrets
+// injected and redacts those secrets from their output. The MCP server and
+// the agent share it so that both enforce the same rules.
+package secretenv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// BlockedVars can never be set from a secret or inherited per
+// mcp-design-ja.md §6.3.3.
+var BlockedVars = map[string]bool{
+	// secretctl related
+	"SECRETCTL_PASSWORD": true,
+	"SECRETCTL_LAUNCH":   true, // sandbox launcher handoff (internal/mcp)
+
+	// Dynamic linker attacks
+	"LD_PRELOAD":            true,
+	"LD_LIBRARY_PATH":       true,
+	"DYLD_INSERT_LIBRARIES": true,
+	"DYLD_LIBRARY_PATH":     true,
+
+	// Shell startup script execution
+	"BASH_ENV":  true,
+	"ENV":       true,
+	"SHELLOPTS": true,
+	"BASHOPTS":  true,
+
+	// Script language auto-execution
+	"PERL5OPT":      true,
+	"PYTHONSTARTUP": true,
+	"PYTHONPATH":    true,
+	"RUBYOPT":       true,
+	"NODE_OPTIONS":  true,
+
+	// Other dangerous variables
+	"IFS":        true,
+	"CDPATH":     true,
+	"GLOBIGNORE": true,
+}
+
+// SafeVars are the variables commands inherit by default.
+var SafeVars = map[string]bool{
+	"PATH":    true,
+	"HOME":    true,
+	"USER":    true,
+	"LOGNAME": true,
+	"LANG":    true,
+	"LC_ALL":  true,
+	"TERM":    true,
+	"TZ":      true,
+}
+
+// Secret is a secret value to inject into a command's environment.
+type Secret struct {
+	Key   string
+	Value []byte
+	// EnvName overrides the name derived from Key
+	EnvName string
+}
+
+// Inherited returns the variables of the current environment that commands
+// inherit: SafeVars plus those extra reports true for, never a blocked one.
+// extra may be nil.
+func Inherited(extra func(name string) bool) []string {
+	var env []string
+	for _, e := range os.Environ() {
+		name, _, ok := strings.Cut(e, "=")
+		if !ok || BlockedVars[name] {
+			continue
+		}
+		if SafeVars[name] || (extra != nil && extra(name)) {
+			env = append(env, e)
+		}
+	}
+	return env
+}
+
+// Build appends secrets to env as variables named after their keys (or
+// EnvName) with prefix prepended, per mcp-design-ja.md §6.3.5.
+func Build(env []string, secrets []Secret, prefix string) ([]string, error) {
+	for _, secret := range secrets {
+		envName := KeyToEnvName(secret.Key)
+		if secret.EnvName != "" {
+			envName = secret.EnvName
+		}
+		if prefix != "" {
+			envName = prefix + envName
+		}
+
+		if err := ValidateName(envName); err != nil {
+			return nil, fmt.Errorf("invalid environment variable name for key '%s': %w", secret.Key, err)
+		}
+		if BlockedVars[envName] {
+			return nil, fmt.Errorf("cannot use blocked environment variable name: %s", envName)
+		}
+		if strings.ContainsRune(envName, '\x00') || bytes.ContainsRune(secret.Value, '\x00') {
+			return nil, fmt.Errorf("NUL byte detected in key '%s'", secret.Key)
+		}
+
+		env = append(env, fmt.Sprintf("%s=%s", envName, string(secret.Value)))
+	}
+	return env, nil
+}
+
+// FromBindings appends the variables bound to fields of entry (see
+// SecretEntry.Bindings) to env. The returned secrets are keyed by variable
+// name, for NewSanitizer.
+func FromBindings(env []string, entry *vault.SecretEntry) ([]string, []Secret, error) {
+	var secrets []Secret
+	for envVar, fieldName := range entry.Bindings {
+		// Resolve field name (supports aliases)
+		_, field, err := vault.ResolveFieldName(entry.Fields, fieldName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("binding '%s' references non-existent field '%s'", envVar, fieldName)
+		}
+		if err := ValidateName(envVar); err != nil {
+			return nil, nil, fmt.Errorf("invalid environment variable name '%s': %w", envVar, err)
+		}
+		if BlockedVars[envVar] {
+			return nil, nil, fmt.Errorf("cannot use blocked environment variable name: %s", envVar)
+		}
+		if strings.ContainsRune(envVar, '\x00') || strings.ContainsRune(field.Value, '\x00') {
+			return nil, nil, fmt.Errorf("NUL byte detected in binding '%s'", envVar)
+		}
+
+		env = append(env, fmt.Sprintf("%s=%s", envVar, field.Value))
+		secrets = append(secrets, Secret{Key: envVar, Value: []byte(field.Value)})
+	}
+	return env, secrets, nil
+}
+
+// KeyToEnvName converts a secret key to an environment variable name.
+func KeyToEnvName(key string) string {
+	name := strings.ReplaceAll(key, "/", "_")
+	name = strings.ReplaceAll(name, "-", "_")
+	return strings.ToUpper(name)
+}
+
+// ValidateName checks that name is a POSIX environment variable name
+// (^[A-Za-z_][A-Za-z0-9_]*$).
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("environment variable name cannot be empty")
+	}
+	first := name[0]
+	if !((first >= 'A' && first <= 'Z') || (first >= 'a' && first <= 'z') || first == '_') {
+		return errors.New("must start with a letter or underscore")
+	}
+	for i := 1; i < len(name); i++ {
+		c := name[i]
+		if !((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_') {
+			return fmt.Errorf("contains invalid character '%c'", c)
+		}
+	}
+	return nil
+}
+
+// ValidateCommand rejects command paths with traversal or NUL bytes per
+// mcp-design-ja.md §6.3.4.
+func ValidateCommand(cmd string) error {
+	if strings.Contains(cmd, "..") || strings.Contains(cmd, "/./") {
+		return errors.New("path traversal detected in command")
+	}
+	if strings.ContainsRune(cmd, '\x00') {
+		return errors.New("null byte detected in command")
+	}
+	return nil
+}
+
+// ValidateArgs rejects arguments with NUL bytes or over 32 KiB per
+// mcp-design-ja.md §6.3.5.
+func ValidateArgs(args []string) error {
+	for i, arg := range args {
+		if strings.ContainsRune(arg, '\x00') {
+			return fmt.Errorf("null byte in argument %d", i)
+		}
+		if len(arg) > 32768 {
+			return fmt.Errorf("argument %d too long", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,663 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/forest6511/secretctl/pkg/vault/vaulttest"
+)
+
+// startTestAgent creates an unlocked vault and serves it on a temp socket.
+func startTestAgent(t *testing.T) (*vault.Vault, *Client, string) {
+	t.Helper()
+	return startTestAgentWithOptions(t, &ServerOptions{})
+}
+
+// startTestAgentWithOptions is startTestAgent with server options; the
+// socket path is always set to a temp socket.
+func startTestAgentWithOptions(t *testing.T, opts *ServerOptions) (*vault.Vault, *Client, string) {
+	t.Helper()
+	v, srv, client := startTestServer(t, opts)
+	return v, client, srv.SocketPath()
+}
+
+// startTestServer is startTestAgentWithOptions returning the server.
+func startTestServer(t *testing.T, opts *ServerOptions) (*vault.Vault, *Server, *Client) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	v := vault.New(tmpDir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	t.Cleanup(v.Lock)
+
+	srv, client := serveTestVault(t, v, opts)
+	return v, srv, client
+}
+
+// serveTestVault serves the unlocked vault v on a temp socket.
+func serveTestVault(t *testing.T, v *vault.Vault, opts *ServerOptions) (*Server, *Client) {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peer credential checks not supported on this platform")
+	}
+
+	// Unix socket paths are length-limited; keep it short.
+	sockDir, err := os.MkdirTemp("", "sctl")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(sockDir) })
+
+	opts.SocketPath = filepath.Join(sockDir, SocketFileName)
+	srv := NewServer(v, opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	var client *Client
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client, err = Dial(context.Background(), srv.SocketPath())
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return srv, client
+}
+
+func TestAgentSetGetList(t *testing.T) {
+	_, client, _ := startTestAgent(t)
+	ctx := context.Background()
+
+	if _, err := client.Set(ctx, &agentv1.SetRequest{Key: "api/token", Value: "s3cr3t", Tags: []string{"dev"}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	got, err := client.Get(ctx, &agentv1.GetRequest{Key: "api/token"})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Value != "s3cr3t" {
+		t.Errorf("expected value s3cr3t, got %q", got.Value)
+	}
+
+	list, err := client.List(ctx, &agentv1.ListRequest{Tag: "dev"})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Keys) != 1 || list.Keys[0] != "api/token" {
+		t.Errorf("unexpected keys: %v", list.Keys)
+	}
+}
+
+func TestAgentListGenerated(t *testing.T) {
+	builder := vaulttest.NewBuilder(9).WithSecrets(20).WithAuditEvents(0)
+	keys, entries, err := builder.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	_, client := serveTestVault(t, builder.MustBuild(t), &ServerOptions{})
+	ctx := context.Background()
+
+	for _, tag := range []string{"", "prod", "dev"} {
+		var want []string
+		for _, key := range keys {
+			if tag == "" || slices.Contains(entries[key].Tags, tag) {
+				want = append(want, key)
+			}
+		}
+		slices.Sort(want)
+
+		list, err := client.List(ctx, &agentv1.ListRequest{Tag: tag})
+		if err != nil {
+			t.Fatalf("List(%q) failed: %v", tag, err)
+		}
+		got := slices.Sorted(slices.Values(list.Keys))
+		if !slices.Equal(got, want) {
+			t.Errorf("List(%q) = %v, want %v", tag, got, want)
+		}
+	}
+
+	// Values come back as generated
+	key := keys[0]
+	got, err := client.Get(ctx, &agentv1.GetRequest{Key: key})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(got.Fields) != len(entries[key].Fields) {
+		t.Errorf("Get(%s) returned %d fields, want %d", key, len(got.Fields), len(entries[key].Fields))
+	}
+	for name, f := range entries[key].Fields {
+		if got.Fields[name] != f.Value {
+			t.Errorf("Get(%s) field %s = %q, want %q", key, name, got.Fields[name], f.Value)
+		}
+	}
+}
+
+func TestDialCloseWithoutCalls(t *testing.T) {
+	sockDir, err := os.MkdirTemp("", "sctl")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	defer os.RemoveAll(sockDir)
+	ln, err := net.Listen("unix", filepath.Join(sockDir, SocketFileName))
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	client, err := Dial(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+
+	// gRPC never used the connection Dial checked; Close must close it
+	client.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("connection still open after Close: %v", err)
+	}
+}
+
+func TestAgentTypedErrors(t *testing.T) {
+	_, client, _ := startTestAgent(t)
+	ctx := context.Background()
+
+	_, err := client.Get(ctx, &agentv1.GetRequest{Key: "missing"})
+	var agentErr *Error
+	if !errors.As(err, &agentErr) || agentErr.Code != CodeNotFound {
+		t.Errorf("expected not_found error, got %v", err)
+	}
+
+	_, err = client.Set(ctx, &agentv1.SetRequest{Key: "empty"})
+	if !errors.As(err, &agentErr) || agentErr.Code != CodeInvalidArgument {
+		t.Errorf("expected invalid_argument error, got %v", err)
+	}
+}
+
+func TestAgentLockAndStatus(t *testing.T) {
+	v, client, _ := startTestAgent(t)
+	ctx := context.Background()
+
+	status, err := client.Status(ctx, &agentv1.StatusRequest{})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.ApiVersion != APIVersion || status.Locked {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if status.VaultPath != v.Path() {
+		t.Errorf("expected vault path %s, got %s", v.Path(), status.VaultPath)
+	}
+
+	if _, err := client.Lock(ctx, &agentv1.LockRequest{}); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if !v.IsLocked() {
+		t.Error("expected vault to be locked")
+	}
+
+	_, err = client.List(ctx, &agentv1.ListRequest{})
+	var agentErr *Error
+	if !errors.As(err, &agentErr) || agentErr.Code != CodeLocked {
+		t.Errorf("expected locked error, got %v", err)
+	}
+}
+
+// testRunPolicy allows the commands in allowed, resolved through PATH.
+type testRunPolicy struct {
+	allowed []string
+}
+
+func (p testRunPolicy) AuthorizeCommand(command string) (string, error) {
+	if !slices.Contains(p.allowed, command) {
+		return "", fmt.Errorf("command not allowed by policy: %s", command)
+	}
+	return exec.LookPath(command)
+}
+
+func (testRunPolicy) InheritsEnv(string) bool { return false }
+
+func TestAgentRunSanitizesOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	_, client, _ := startTestAgentWithOptions(t, &ServerOptions{RunPolicy: testRunPolicy{allowed: []string{"sh"}}})
+	ctx := context.Background()
+
+	if _, err := client.Set(ctx, &agentv1.SetRequest{Key: "db/password", Value: "hunter2-value"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	resp, err := client.Run(ctx, &agentv1.RunRequest{
+		Keys:    []string{"db/password"},
+		Command: "sh",
+		Args:    []string{"-c", "echo $DB_PASSWORD; printf '%s\\n' aHVudGVyMi12YWx1ZQ== 68756e746572322d76616c7565 >&2; exit 3"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if resp.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", resp.ExitCode)
+	}
+	if resp.Stdout != "[REDACTED:DB_PASSWORD]\n" {
+		t.Errorf("expected redacted output, got %q", resp.Stdout)
+	}
+	// Encoded forms are redacted too
+	if resp.Stderr != "[REDACTED:DB_PASSWORD]\n[REDACTED:DB_PASSWORD]\n" {
+		t.Errorf("expected redacted base64 and hex, got %q", resp.Stderr)
+	}
+}
+
+func TestAgentRunPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx := context.Background()
+	var agentErr *Error
+
+	// Without a policy, Run is disabled
+	_, client, _ := startTestAgent(t)
+	_, err := client.Run(ctx, &agentv1.RunRequest{Keys: []string{"k"}, Command: "sh"})
+	if !errors.As(err, &agentErr) || agentErr.Code != CodeDenied {
+		t.Errorf("expected denied error without a policy, got %v", err)
+	}
+
+	v, client, _ := startTestAgentWithOptions(t, &ServerOptions{RunPolicy: testRunPolicy{allowed: []string{"sh"}}})
+	if err := v.SetSecret("db/pass.wd", &vault.SecretEntry{Value: []byte("s3cr3t")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("LD_PRELOAD", &vault.SecretEntry{Value: []byte("/tmp/evil.so")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  *agentv1.RunRequest
+		code string
+	}{
+		{"command not allowed", &agentv1.RunRequest{Keys: []string{"db/pass.wd"}, Command: "env"}, CodeDenied},
+		{"invalid env name", &agentv1.RunRequest{Keys: []string{"db/pass.wd"}, Command: "sh"}, CodeInvalidArgument},
+		{"blocked env name", &agentv1.RunRequest{Keys: []string{"LD_PRELOAD"}, Command: "sh"}, CodeInvalidArgument},
+		{"NUL in argument", &agentv1.RunRequest{Keys: []string{"LD_PRELOAD"}, Command: "sh", Args: []string{"a\x00b"}}, CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Run(ctx, tt.req)
+			if !errors.As(err, &agentErr) || agentErr.Code != tt.code {
+				t.Errorf("expected %s error, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestAgentRunBindings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	v, client, _ := startTestAgentWithOptions(t, &ServerOptions{RunPolicy: testRunPolicy{allowed: []string{"sh"}}})
+	entry := &vault.SecretEntry{
+		Fields: map[string]vault.Field{
+			"host":     {Value: "db.internal"},
+			"password": {Value: "p4ssw0rd", Sensitive: true},
+		},
+		Bindings: map[string]string{"PGHOST": "host", "PGPASSWORD": "password"},
+	}
+	if err := v.SetSecret("db/prod", entry); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	resp, err := client.Run(context.Background(), &agentv1.RunRequest{
+		Bindings: []string{"db/prod"},
+		Command:  "sh",
+		Args:     []string{"-c", "echo $PGHOST $PGPASSWORD"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if resp.Stdout != "[REDACTED:PGHOST] [REDACTED:PGPASSWORD]\n" {
+		t.Errorf("expected bound variables, redacted, got %q", resp.Stdout)
+	}
+}
+
+func TestAgentRunOutputLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	_, client, _ := startTestAgentWithOptions(t, &ServerOptions{RunPolicy: testRunPolicy{allowed: []string{"head"}}})
+	ctx := context.Background()
+	if _, err := client.Set(ctx, &agentv1.SetRequest{Key: "token", Value: "s3cr3t"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	resp, err := client.Run(ctx, &agentv1.RunRequest{
+		Keys:    []string{"token"},
+		Command: "head",
+		Args:    []string{"-c", strconv.Itoa(maxRunOutputSize + 4096), "/dev/zero"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if resp.ExitCode != 0 || len(resp.Stdout) != maxRunOutputSize {
+		t.Errorf("expected exit 0 and %d bytes, got exit %d and %d bytes", maxRunOutputSize, resp.ExitCode, len(resp.Stdout))
+	}
+}
+
+func TestServerRefusesLiveSocket(t *testing.T) {
+	v, _, socketPath := startTestAgent(t)
+
+	// A second server on the same socket must not steal it.
+	srv := NewServer(v, &ServerOptions{SocketPath: socketPath})
+	if err := srv.Serve(context.Background()); !errors.Is(err, ErrSocketInUse) {
+		t.Errorf("expected ErrSocketInUse, got %v", err)
+	}
+}
+
+func TestHealthWatchdog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission checks not applicable on Windows")
+	}
+	tmpDir := t.TempDir()
+	if err := os.Chmod(tmpDir, 0700); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	v := vault.New(tmpDir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	t.Cleanup(v.Lock)
+
+	var changes []*HealthStatus
+	srv := NewServer(v, &ServerOptions{
+		OnHealthChange: func(h *HealthStatus) { changes = append(changes, h) },
+	})
+
+	srv.checkHealth()
+	if h := srv.Health(); h == nil || !h.Healthy {
+		t.Fatalf("expected healthy status, got %+v", h)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no notification for initial healthy check, got %d", len(changes))
+	}
+
+	if err := os.Chmod(filepath.Join(tmpDir, vault.DBFileName), 0644); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	srv.checkHealth()
+	srv.checkHealth()
+	if len(changes) != 1 || changes[0].Healthy {
+		t.Fatalf("expected one degraded notification, got %+v", changes)
+	}
+
+	if err := os.Chmod(filepath.Join(tmpDir, vault.DBFileName), 0600); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	srv.checkHealth()
+	if len(changes) != 2 || !changes[1].Healthy {
+		t.Errorf("expected recovery notification, got %+v", changes)
+	}
+
+	// Locked vaults are skipped and keep the last result
+	v.Lock()
+	checkedAt := srv.Health().CheckedAt
+	srv.checkHealth()
+	if !srv.Health().CheckedAt.Equal(checkedAt) {
+		t.Error("expected check to be skipped while locked")
+	}
+}
+
+func TestAgentAttach(t *testing.T) {
+	approve := true
+	var asked *AttachRequest
+	v, client, _ := startTestAgentWithOptions(t, &ServerOptions{
+		ApproveAttach: func(req *AttachRequest) bool {
+			asked = req
+			return approve
+		},
+	})
+	ctx := context.Background()
+	if err := v.SetSecret("api/token", &vault.SecretEntry{Value: []byte("s3cr3t")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	resp, err := client.Attach(ctx, &agentv1.AttachRequest{Client: "mcp-server", Pid: 42})
+	if err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	if asked == nil || asked.Client != "mcp-server" || asked.PID != 42 {
+		t.Errorf("approval callback got %+v", asked)
+	}
+	// The peer is identified by its credentials, not by what it claims
+	if asked != nil && asked.PeerPID != os.Getpid() {
+		t.Errorf("PeerPID = %d, want %d", asked.PeerPID, os.Getpid())
+	}
+	if exe, err := os.Executable(); err == nil && asked != nil && asked.Executable != exe {
+		t.Errorf("Executable = %q, want %q", asked.Executable, exe)
+	}
+
+	// The key unlocks a second handle on the same vault
+	attached := vault.New(resp.VaultPath)
+	if err := attached.UnlockWithKey(resp.Key); err != nil {
+		t.Fatalf("UnlockWithKey failed: %v", err)
+	}
+	defer attached.Lock()
+	entry, err := attached.GetSecret("api/token")
+	if err != nil || string(entry.Value) != "s3cr3t" {
+		t.Fatalf("GetSecret via attached session = %v, %v", entry, err)
+	}
+
+	approve = false
+	_, err = client.Attach(ctx, &agentv1.AttachRequest{Client: "mcp-server"})
+	var agentErr *Error
+	if !errors.As(err, &agentErr) || agentErr.Code != CodeDenied {
+		t.Errorf("expected denied error, got %v", err)
+	}
+}
+
+func TestAgentAttachDisabled(t *testing.T) {
+	_, client, _ := startTestAgent(t)
+	_, err := client.Attach(context.Background(), &agentv1.AttachRequest{Client: "mcp-server"})
+	var agentErr *Error
+	if !errors.As(err, &agentErr) || agentErr.Code != CodeDenied {
+		t.Errorf("expected denied error without ApproveAttach, got %v", err)
+	}
+}
+
+func TestAgentWaitLocked(t *testing.T) {
+	v, client, _ := startTestAgent(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.WaitLocked(context.Background(), &agentv1.WaitLockedRequest{})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("WaitLocked returned before lock: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	v.Lock()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("WaitLocked failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitLocked did not return after lock")
+	}
+}
+
+func TestAgentTasks(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	startTestAgentWithOptions(t, &ServerOptions{
+		HealthInterval: -1,
+		Tasks: []Task{
+			{Name: "count", Interval: 20 * time.Millisecond, Run: func(context.Context) error {
+				runs <- struct{}{}
+				return nil
+			}},
+			{Name: "disabled", Run: func(context.Context) error {
+				t.Error("task without interval ran")
+				return nil
+			}},
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-runs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("task ran %d times, want at least 2", i)
+		}
+	}
+}
+
+func TestAgentMetrics(t *testing.T) {
+	_, srv, client := startTestServer(t, &ServerOptions{MetricsAddr: "127.0.0.1:0"})
+	ctx := context.Background()
+
+	if _, err := client.Set(ctx, &agentv1.SetRequest{Key: "api/token", Value: "s3cr3t"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := client.Get(ctx, &agentv1.GetRequest{Key: "missing"}); err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+
+	resp, err := http.Get("http://" + srv.MetricsAddr() + MetricsPath)
+	if err != nil {
+		t.Fatalf("GET metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading metrics failed: %v", err)
+	}
+	out := string(body)
+
+	for _, want := range []string{
+		`secretctl_agent_requests_total{method="Set",code="ok"} 1`,
+		`secretctl_agent_requests_total{method="Get",code="not_found"} 1`,
+		`secretctl_agent_request_duration_seconds_count{method="Get"} 1`,
+		"secretctl_vault_locked 0",
+		"secretctl_dynamic_cache_hits_total 0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "api/token") || strings.Contains(out, "s3cr3t") {
+		t.Errorf("metrics leak key names or values:\n%s", out)
+	}
+}
+
+func TestAgentDashboard(t *testing.T) {
+	v, srv, client := startTestServer(t, &ServerOptions{DashboardAddr: "127.0.0.1:0"})
+	ctx := context.Background()
+
+	expires := time.Now().Add(7 * 24 * time.Hour)
+	if err := v.SetSecret("certs/web", &vault.SecretEntry{Value: []byte("s3cr3t"), ExpiresAt: &expires}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := client.Get(ctx, &agentv1.GetRequest{Key: "certs/web"}); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	link := srv.DashboardURL()
+	base, _, _ := strings.Cut(link, "?")
+	get := func(c *http.Client, url string) (int, string) {
+		t.Helper()
+		resp, err := c.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", url, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, _ := get(http.DefaultClient, base); code != http.StatusUnauthorized {
+		t.Errorf("without a token: status %d, want 401", code)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	browser := &http.Client{Jar: jar}
+	code, page := get(browser, link)
+	if code != http.StatusOK {
+		t.Fatalf("with the token: status %d", code)
+	}
+	for _, want := range []string{"unlocked", "certs/web", "secret.get"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("dashboard missing %q", want)
+		}
+	}
+	if strings.Contains(page, "s3cr3t") {
+		t.Error("dashboard shows a secret value")
+	}
+	if code, _ := get(browser, base); code != http.StatusOK {
+		t.Errorf("session cookie rejected: status %d", code)
+	}
+
+	// The token works once
+	if code, _ := get(http.DefaultClient, link); code != http.StatusUnauthorized {
+		t.Errorf("reused token: status %d, want 401", code)
+	}
+
+	// Requests for other host names are refused (DNS rebinding)
+	req, _ := http.NewRequest(http.MethodGet, base, nil)
+	req.Host = "attacker.example"
+	resp, err := browser.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("foreign Host: status %d, want 403", resp.StatusCode)
+	}
+
+	if err := checkLoopback("0.0.0.0:9465"); !errors.Is(err, ErrDashboardNotLoopback) {
+		t.Errorf("expected ErrDashboardNotLoopback, got %v", err)
+	}
+	if err := checkLoopback("[::1]:9465"); err != nil {
+		t.Errorf("IPv6 loopback rejected: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package agentv1 contains the protobuf messages and the generated gRPC
+// client and server stubs of version 1 of the agent API (agent.proto).
+//
+// Go programs usually connect through agent.Dial, which checks the socket
+// and maps errors to *agent.Error; programs in other languages generate
+// their own stubs from agent.proto and dial the agent's Unix socket.
+package agentv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative agent.proto
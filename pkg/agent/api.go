@@ -0,0 +1,70 @@
+// Package agent implements the secretctl agent daemon and its local API.
+//
+// The agent keeps a vault unlocked in a long-running process and serves a
+// versioned gRPC API over a Unix domain socket. Only peers running as the
+// same user as the agent are accepted (checked via peer credentials).
+//
+// The API is defined in agentv1/agent.proto (package secretctl.agent.v1),
+// from which the messages and the client and server stubs in package
+// agentv1 are generated. Other languages generate their own stubs from the
+// same file. New fields may be added to v1 messages; removing or changing
+// fields requires a new package version.
+package agent
+
+import (
+	"time"
+)
+
+// APIVersion is the current agent API version.
+const APIVersion = "v1"
+
+// ServiceV1 is the gRPC service name for API version 1.
+const ServiceV1 = "secretctl.agent.v1.Agent"
+
+// SocketFileName is the default socket file name inside the vault directory.
+const SocketFileName = "agent.sock"
+
+// SocketEnv names the environment variable that overrides the default socket
+// path (set by `secretctl shell-init`).
+const SocketEnv = "SECRETCTL_AGENT_SOCK"
+
+// Error codes returned by the agent. Errors cross the wire as gRPC statuses
+// with a google.rpc.ErrorInfo detail in ErrorDomain whose reason is the
+// code, so clients can map them back to typed errors.
+const (
+	CodeNotFound        = "not_found"
+	CodeLocked          = "locked"
+	CodeInvalidArgument = "invalid_argument"
+	CodeDenied          = "denied"
+	CodeExpired         = "expired"
+	CodeDeprecated      = "deprecated"
+	CodeInternal        = "internal"
+)
+
+// ErrorDomain is the ErrorInfo domain of agent errors.
+const ErrorDomain = "secretctl.agent"
+
+// AttachRequest describes a process that asks to join the agent's unlocked
+// session. The agent owner must approve every request (see
+// ServerOptions.ApproveAttach). Client and PID are whatever the process
+// claims; PeerPID and Executable come from the socket's peer credentials
+// and are what the user should be shown to decide.
+type AttachRequest struct {
+	// Client names the requesting program as it claims (e.g. "mcp-server").
+	Client string `json:"client"`
+	// PID is the process ID the requesting program claims.
+	PID int `json:"pid"`
+	// PeerPID is the process ID of the connected peer (SO_PEERCRED or
+	// LOCAL_PEERPID).
+	PeerPID int `json:"peer_pid"`
+	// Executable is the path of the peer's executable, "" if it cannot
+	// be read.
+	Executable string `json:"executable,omitempty"`
+}
+
+// HealthStatus is the result of the agent's periodic vault health check.
+type HealthStatus struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Healthy   bool      `json:"healthy"`
+	Problems  []string  `json:"problems,omitempty"`
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+)
+
+// Error is an error returned by the agent, carrying a wire code.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("agent: %s: %s", e.Code, e.Message)
+}
+
+// maxResponseSize fits a Run response with both outputs at their limit.
+const maxResponseSize = 2*maxRunOutputSize + 1024*1024
+
+// Client is a typed client for the v1 agent API. Its methods are the
+// generated agentv1.AgentClient ones; errors from the agent are returned
+// as *Error.
+type Client struct {
+	agentv1.AgentClient
+	conn *grpc.ClientConn
+	// first is the connection Dial checked, until gRPC takes it
+	first *atomic.Pointer[net.Conn]
+}
+
+// Dial connects to an agent listening on socketPath. Unlike grpc.NewClient
+// it connects right away, so it fails if no agent is listening.
+func Dial(ctx context.Context, socketPath string) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("agent: failed to connect to %s: %w", socketPath, err)
+	}
+
+	// Hand the checked connection to gRPC and dial again on reconnects
+	first := new(atomic.Pointer[net.Conn])
+	first.Store(&conn)
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		if c := first.Swap(nil); c != nil {
+			return *c, nil
+		}
+		return d.DialContext(ctx, "unix", socketPath)
+	}
+
+	// The socket is owner-only and the agent checks peer credentials, so
+	// there is nothing to encrypt or authenticate at the gRPC level.
+	cc, err := grpc.NewClient("passthrough:///"+socketPath,
+		grpc.WithContextDialer(dialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(clientErrors),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxResponseSize)),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("agent: failed to connect to %s: %w", socketPath, err)
+	}
+	return &Client{AgentClient: agentv1.NewAgentClient(cc), conn: cc, first: first}, nil
+}
+
+// Close closes the connection to the agent.
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	// gRPC never dialed, so the checked connection is still ours
+	if conn := c.first.Swap(nil); conn != nil {
+		(*conn).Close()
+	}
+	return err
+}
+
+// clientErrors converts agent errors into *Error and honors ctx
+// cancellation.
+func clientErrors(ctx context.Context, method string, req, resp any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, resp, cc, opts...)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return parseError(err)
+}
+
+// parseError converts a wire error into *Error when it carries a known code.
+func parseError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("agent: rpc failed: %w", err)
+	}
+	code := statusCode(st)
+	if code == "" {
+		return fmt.Errorf("agent: rpc failed: %w", err)
+	}
+	return &Error{Code: code, Message: st.Message()}
+}
@@ -0,0 +1,357 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/backup"
+)
+
+// ErrDashboardNotLoopback is returned when the dashboard address is not on
+// a loopback interface.
+var ErrDashboardNotLoopback = errors.New("agent: dashboard must listen on a loopback address")
+
+const (
+	// dashboardCookie holds the session the one-time token was exchanged for.
+	dashboardCookie = "secretctl_dashboard"
+	// dashboardExpiryWindow is how far ahead expiring secrets are listed.
+	dashboardExpiryWindow = 30 * 24 * time.Hour
+	// dashboardAuditWindow is how far back audit and MCP activity go.
+	dashboardAuditWindow = 24 * time.Hour
+	// dashboardAuditTail is how many audit events are listed.
+	dashboardAuditTail = 25
+)
+
+// dashboardAuth holds the one-time token of the dashboard URL and the
+// sessions it was exchanged for.
+type dashboardAuth struct {
+	mu       sync.Mutex
+	token    string // empty once used
+	sessions map[string]bool
+}
+
+func newDashboardAuth() (*dashboardAuth, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	return &dashboardAuth{token: token, sessions: make(map[string]bool)}, nil
+}
+
+// redeem exchanges the token for a new session. It succeeds only once.
+func (a *dashboardAuth) redeem(token string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		return "", false
+	}
+	session, err := randomHex(32)
+	if err != nil {
+		return "", false
+	}
+	a.token = ""
+	a.sessions[session] = true
+	return session, true
+}
+
+func (a *dashboardAuth) valid(session string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return session != "" && a.sessions[session]
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("agent: failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// checkLoopback returns ErrDashboardNotLoopback unless addr is a loopback
+// host and port.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("agent: invalid dashboard address %q: %w", addr, err)
+	}
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("%w: %s", ErrDashboardNotLoopback, addr)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listenDashboard listens on the loopback address addr and creates the
+// one-time token for it.
+func listenDashboard(addr string) (net.Listener, *dashboardAuth, error) {
+	if err := checkLoopback(addr); err != nil {
+		return nil, nil, err
+	}
+	auth, err := newDashboardAuth()
+	if err != nil {
+		return nil, nil, err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("agent: failed to listen for the dashboard on %s: %w", addr, err)
+	}
+	return ln, auth, nil
+}
+
+// DashboardURL returns the address of the dashboard with its one-time
+// token once Serve has started, or "" if the dashboard is disabled.
+// Opening it signs the browser in; the URL does not work a second time.
+func (s *Server) DashboardURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dashboardListener == nil {
+		return ""
+	}
+	s.dashboardAuth.mu.Lock()
+	defer s.dashboardAuth.mu.Unlock()
+	return fmt.Sprintf("http://%s/?token=%s", s.dashboardListener.Addr(), s.dashboardAuth.token)
+}
+
+// serveDashboard serves the dashboard on ln until ctx is canceled.
+func (s *Server) serveDashboard(ctx context.Context, ln net.Listener) {
+	srv := &http.Server{Handler: s.dashboardHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("agent: dashboard server failed", "err", err)
+	}
+}
+
+// dashboardHandler serves the read-only dashboard page.
+func (s *Server) dashboardHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Cache-Control", "no-store")
+		h.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'none'; frame-ancestors 'none'")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+
+		// A page on another site could otherwise reach the dashboard
+		// through a DNS name rebound to 127.0.0.1
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil || !isLoopbackHost(host) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if token := r.URL.Query().Get("token"); token != "" {
+			session, ok := s.dashboardAuth.redeem(token)
+			if !ok {
+				http.Error(w, "this dashboard link was already used or is invalid; restart the agent for a new one", http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     dashboardCookie,
+				Value:    session,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			// Drop the token from the address bar and history
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		cookie, err := r.Cookie(dashboardCookie)
+		if err != nil || !s.dashboardAuth.valid(cookie.Value) {
+			http.Error(w, "open the dashboard URL printed by 'secretctl agent start'", http.StatusUnauthorized)
+			return
+		}
+
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, s.dashboardData()); err != nil {
+			slog.Debug("agent: failed to render dashboard", "err", err)
+		}
+	})
+	return mux
+}
+
+// dashboardData is what the dashboard shows. It never holds secret values.
+type dashboardData struct {
+	GeneratedAt time.Time
+	VaultPath   string
+	Locked      bool
+	StartedAt   time.Time
+	Health      *HealthStatus
+
+	Secrets  int
+	Expiring []dashboardSecret
+	Error    string
+
+	BackupDir string
+	BackupAt  *time.Time
+
+	Audit       []audit.AuditEvent // newest first
+	MCPRequests int
+	MCPDenied   int
+}
+
+type dashboardSecret struct {
+	Key     string
+	Expires time.Time
+	Expired bool
+}
+
+func (s *Server) dashboardData() *dashboardData {
+	now := time.Now()
+	d := &dashboardData{
+		GeneratedAt: now,
+		VaultPath:   s.vault.Path(),
+		Locked:      s.vault.IsLocked(),
+		StartedAt:   s.startedAt,
+		Health:      s.Health(),
+	}
+
+	if !d.Locked {
+		if secrets, err := s.vault.ListSecretsWithMetadata(); err == nil {
+			d.Secrets = len(secrets)
+		} else {
+			d.Error = err.Error()
+		}
+		if expiring, err := s.vault.ListExpiringSecrets(dashboardExpiryWindow); err == nil {
+			for _, e := range expiring {
+				if exp := e.Expiry(); exp != nil {
+					d.Expiring = append(d.Expiring, dashboardSecret{Key: e.Key, Expires: *exp, Expired: exp.Before(now)})
+				}
+			}
+		} else {
+			d.Error = err.Error()
+		}
+	}
+
+	if settings, err := s.vault.Settings(); err == nil && settings.BackupDir != "" {
+		d.BackupDir = settings.BackupDir
+		if t, ok := backup.Latest(settings.BackupDir); ok {
+			d.BackupAt = &t
+		}
+	}
+
+	if events, err := s.vault.AuditLogger().ListEvents(0, now.Add(-dashboardAuditWindow)); err == nil {
+		for _, e := range events {
+			if e.Actor.Source == audit.SourceMCP {
+				d.MCPRequests++
+				if e.Result == audit.ResultDenied {
+					d.MCPDenied++
+				}
+			}
+		}
+		for i := len(events) - 1; i >= 0 && len(d.Audit) < dashboardAuditTail; i-- {
+			d.Audit = append(d.Audit, events[i])
+		}
+	}
+	return d
+}
+
+// ago formats the time since t for the dashboard, e.g. "3h ago".
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ago": ago,
+	"date": func(t time.Time) string {
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"eventTime": func(ts string) string {
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return ts
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>secretctl dashboard</title>
+<style>
+body { font: 14px/1.5 system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #1f2328; }
+h1 { font-size: 1.4rem; } h2 { font-size: 1.1rem; margin-top: 2rem; }
+.cards { display: flex; flex-wrap: wrap; gap: 1rem; }
+.card { border: 1px solid #d0d7de; border-radius: 6px; padding: .75rem 1rem; min-width: 10rem; }
+.card b { display: block; font-size: 1.3rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3rem .5rem; border-bottom: 1px solid #eaeef2; }
+.bad { color: #cf222e; } .ok { color: #1a7f37; } .muted { color: #656d76; }
+</style>
+</head>
+<body>
+<h1>secretctl</h1>
+<p class="muted">{{.VaultPath}} · agent started {{ago .StartedAt}} · updated {{date .GeneratedAt}}</p>
+
+<div class="cards">
+<div class="card">Vault<b>{{if .Locked}}<span class="bad">locked</span>{{else}}<span class="ok">unlocked</span>{{end}}</b></div>
+<div class="card">Health<b>{{with .Health}}{{if .Healthy}}<span class="ok">ok</span>{{else}}<span class="bad">degraded</span>{{end}}{{else}}<span class="muted">not checked</span>{{end}}</b></div>
+<div class="card">Secrets<b>{{if .Locked}}–{{else}}{{.Secrets}}{{end}}</b></div>
+<div class="card">Last backup<b>{{with .BackupAt}}{{ago .}}{{else}}<span class="bad">none</span>{{end}}</b></div>
+<div class="card">MCP (24h)<b>{{.MCPRequests}}{{if .MCPDenied}} <span class="bad">({{.MCPDenied}} denied)</span>{{end}}</b></div>
+</div>
+{{with .Health}}{{range .Problems}}<p class="bad">{{.}}</p>{{end}}{{end}}
+{{if .Error}}<p class="bad">{{.Error}}</p>{{end}}
+{{if not .BackupDir}}<p class="muted">Set backup_dir with 'secretctl config set' to track backups.</p>{{end}}
+
+<h2>Expiring within 30 days</h2>
+{{if .Locked}}<p class="muted">Unavailable while the vault is locked.</p>
+{{else if .Expiring}}<table>
+<tr><th>Key</th><th>Expires</th></tr>
+{{range .Expiring}}<tr><td>{{.Key}}</td><td{{if .Expired}} class="bad"{{end}}>{{date .Expires}}{{if .Expired}} (expired){{end}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">Nothing expires soon.</p>{{end}}
+
+<h2>Audit log (last 24 hours)</h2>
+{{if .Audit}}<table>
+<tr><th>Time</th><th>Source</th><th>Operation</th><th>Key</th><th>Result</th></tr>
+{{range .Audit}}<tr><td>{{eventTime .Timestamp}}</td><td>{{.Actor.Source}}</td><td>{{.Operation}}</td><td>{{.Key}}</td><td{{if ne .Result "success"}} class="bad"{{end}}>{{.Result}}</td></tr>
+{{end}}</table>
+{{else}}<p class="muted">No activity.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/forest6511/secretctl/pkg/backup"
+)
+
+// MetricsPath is the HTTP path metrics are served on.
+const MetricsPath = "/metrics"
+
+// latencyBuckets are the upper bounds, in seconds, of the request duration
+// histogram. Run requests include the command's run time.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 30, 300}
+
+// requestLabels identify a request counter.
+type requestLabels struct {
+	method string
+	code   string
+}
+
+// histogram is a Prometheus histogram with latencyBuckets.
+type histogram struct {
+	buckets []uint64 // not cumulative; one per latencyBuckets entry
+	count   uint64
+	sum     float64
+}
+
+// metrics counts agent API requests. Vault state is read when scraped.
+type metrics struct {
+	mu       sync.Mutex
+	requests map[requestLabels]uint64
+	latency  map[string]*histogram
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests: make(map[requestLabels]uint64),
+		latency:  make(map[string]*histogram),
+	}
+}
+
+// observe records one request. code is "ok" or an agent error code.
+func (m *metrics) observe(method, code string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestLabels{method, code}]++
+	h, ok := m.latency[method]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+		m.latency[method] = h
+	}
+	seconds := d.Seconds()
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			h.buckets[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// observe is the gRPC interceptor that times the requests the server
+// serves.
+func (s *Server) observe(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.metrics.observe(methodLabel(info.FullMethod), errorCode(err), time.Since(start))
+	return resp, err
+}
+
+// methodLabel strips the service name: "/secretctl.agent.v1.Agent/Get"
+// becomes "Get".
+func methodLabel(fullMethod string) string {
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[i+1:]
+	}
+	return fullMethod
+}
+
+// errorCode returns the agent error code of a handler error, "ok" for none.
+func errorCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if st, ok := status.FromError(err); ok {
+		if code := statusCode(st); code != "" {
+			return code
+		}
+	}
+	return CodeInternal
+}
+
+// serveMetrics serves MetricsPath on ln until ctx is canceled.
+func (s *Server) serveMetrics(ctx context.Context, ln net.Listener) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(MetricsPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.WriteMetrics(w)
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("agent: metrics server failed", "err", err)
+	}
+}
+
+// WriteMetrics writes the agent's metrics in the Prometheus text format.
+func (s *Server) WriteMetrics(w io.Writer) {
+	s.metrics.mu.Lock()
+	requests := make([]requestLabels, 0, len(s.metrics.requests))
+	for l := range s.metrics.requests {
+		requests = append(requests, l)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].method != requests[j].method {
+			return requests[i].method < requests[j].method
+		}
+		return requests[i].code < requests[j].code
+	})
+	header(w, "secretctl_agent_requests_total", "counter", "Agent API requests by method and result code.")
+	for _, l := range requests {
+		fmt.Fprintf(w, "secretctl_agent_requests_total{method=%q,code=%q} %d\n", l.method, l.code, s.metrics.requests[l])
+	}
+
+	methods := make([]string, 0, len(s.metrics.latency))
+	for m := range s.metrics.latency {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	header(w, "secretctl_agent_request_duration_seconds", "histogram", "Agent API request latency.")
+	for _, m := range methods {
+		h := s.metrics.latency[m]
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += h.buckets[i]
+			fmt.Fprintf(w, "secretctl_agent_request_duration_seconds_bucket{method=%q,le=\"%g\"} %d\n", m, le, cumulative)
+		}
+		fmt.Fprintf(w, "secretctl_agent_request_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", m, h.count)
+		fmt.Fprintf(w, "secretctl_agent_request_duration_seconds_sum{method=%q} %g\n", m, h.sum)
+		fmt.Fprintf(w, "secretctl_agent_request_duration_seconds_count{method=%q} %d\n", m, h.count)
+	}
+	s.metrics.mu.Unlock()
+
+	gauge(w, "secretctl_agent_start_time_seconds", "Unix time the agent started.", float64(s.startedAt.Unix()))
+	locked := 0.0
+	if s.vault.IsLocked() {
+		locked = 1
+	}
+	gauge(w, "secretctl_vault_locked", "Whether the vault is locked (1) or unlocked (0).", locked)
+	if h := s.Health(); h != nil {
+		healthy := 0.0
+		if h.Healthy {
+			healthy = 1
+		}
+		gauge(w, "secretctl_vault_healthy", "Result of the last vault health check.", healthy)
+	}
+
+	if state, err := s.vault.GetLockState(); err == nil {
+		gauge(w, "secretctl_vault_failed_unlock_attempts", "Failed unlock attempts since the last successful unlock.", float64(state.FailedAttempts))
+		header(w, "secretctl_vault_cooldowns_total", "counter", "Times too many failed unlock attempts triggered a cooldown.")
+		fmt.Fprintf(w, "secretctl_vault_cooldowns_total %d\n", state.LockoutCount)
+	}
+	gauge(w, "secretctl_vault_cooldown_remaining_seconds", "Time until unlocking is allowed again.", s.vault.RemainingCooldown().Seconds())
+
+	hits, misses := s.vault.DynamicCacheStats()
+	header(w, "secretctl_dynamic_cache_hits_total", "counter", "dynamic:// reads served from the cache.")
+	fmt.Fprintf(w, "secretctl_dynamic_cache_hits_total %d\n", hits)
+	header(w, "secretctl_dynamic_cache_misses_total", "counter", "dynamic:// reads that ran a plugin.")
+	fmt.Fprintf(w, "secretctl_dynamic_cache_misses_total %d\n", misses)
+
+	if settings, err := s.vault.Settings(); err == nil && settings.BackupDir != "" {
+		if t, ok := backup.Latest(settings.BackupDir); ok {
+			gauge(w, "secretctl_backup_last_timestamp_seconds", "Unix time of the newest backup in backup_dir.", float64(t.Unix()))
+		}
+	}
+}
+
+func header(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func gauge(w io.Writer, name, help string, value float64) {
+	header(w, name, "gauge", help)
+	fmt.Fprintf(w, "%s %g\n", name, value)
+}
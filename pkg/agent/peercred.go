@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// peerCredentials are the server's gRPC transport credentials. They add no
+// encryption (the socket is owner-only) but reject every connection whose
+// peer fails checkPeer before gRPC reads anything from it.
+type peerCredentials struct{}
+
+// peerAuthInfo is the AuthInfo of connections that passed checkPeer. It
+// identifies the peer process as the kernel reported it when connecting,
+// which unlike the fields of a request the client cannot choose.
+type peerAuthInfo struct {
+	pid        int
+	executable string
+}
+
+func (peerAuthInfo) AuthType() string { return "peercred" }
+
+func (peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	pid, err := checkPeer(conn)
+	if err != nil {
+		slog.Warn("agent: rejected connection", "err", err)
+		return nil, nil, err
+	}
+	return conn, peerAuthInfo{pid: pid, executable: processExecutable(pid)}, nil
+}
+
+func (peerCredentials) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return conn, peerAuthInfo{}, nil
+}
+
+func (peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (c peerCredentials) Clone() credentials.TransportCredentials { return c }
+
+func (peerCredentials) OverrideServerName(string) error { return nil }
@@ -0,0 +1,58 @@
+//go:build darwin
+
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeer verifies that the peer of a Unix socket connection runs as
+// the same user as the agent, using LOCAL_PEERCRED, and returns its PID
+// (LOCAL_PEERPID).
+func checkPeer(conn net.Conn) (int, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("agent: not a unix socket connection")
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return 0, fmt.Errorf("agent: failed to access socket: %w", err)
+	}
+
+	var cred *unix.Xucred
+	var pid int
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if credErr == nil {
+			pid, credErr = unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+		}
+	}); err != nil {
+		return 0, fmt.Errorf("agent: failed to access socket: %w", err)
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("agent: failed to read peer credentials: %w", credErr)
+	}
+
+	if int(cred.Uid) != os.Getuid() {
+		return 0, fmt.Errorf("%w: peer uid %d", ErrPeerUIDMismatch, cred.Uid)
+	}
+	return pid, nil
+}
+
+// processExecutable returns the path of the executable pid runs, or "" if
+// it cannot be read. kern.procargs2 starts with argc (4 bytes) followed by
+// the NUL-terminated executable path.
+func processExecutable(pid int) string {
+	buf, err := unix.SysctlRaw("kern.procargs2", pid)
+	if err != nil || len(buf) < 4 {
+		return ""
+	}
+	path, _, _ := bytes.Cut(buf[4:], []byte{0})
+	return string(path)
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeer verifies that the peer of a Unix socket connection runs as
+// the same user as the agent, using SO_PEERCRED, and returns its PID.
+func checkPeer(conn net.Conn) (int, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("agent: not a unix socket connection")
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return 0, fmt.Errorf("agent: failed to access socket: %w", err)
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return 0, fmt.Errorf("agent: failed to access socket: %w", err)
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("agent: failed to read peer credentials: %w", credErr)
+	}
+
+	if int(cred.Uid) != os.Getuid() {
+		return 0, fmt.Errorf("%w: peer uid %d, pid %d", ErrPeerUIDMismatch, cred.Uid, cred.Pid)
+	}
+	return int(cred.Pid), nil
+}
+
+// processExecutable returns the path of the executable pid runs, or "" if
+// it cannot be read.
+func processExecutable(pid int) string {
+	path, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/exe")
+	if err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"net"
+)
+
+// checkPeer rejects all connections on platforms without a supported
+// peer credential mechanism. Serving secrets to unverified peers is unsafe.
+func checkPeer(_ net.Conn) (int, error) {
+	return 0, ErrPeerCredUnsupported
+}
+
+// processExecutable is never called since checkPeer rejects every peer.
+func processExecutable(int) string {
+	return ""
+}
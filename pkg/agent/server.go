@@ -0,0 +1,693 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/forest6511/secretctl/internal/secretenv"
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Run limits
+const (
+	defaultRunTimeout = 300 * time.Second
+	maxRunTimeout     = 3600 * time.Second
+	maxRunOutputSize  = 10 * 1024 * 1024
+)
+
+// Server errors
+var (
+	ErrSocketInUse          = errors.New("agent: socket already in use by a running agent")
+	ErrPeerCredUnsupported  = errors.New("agent: peer credential checks are not supported on this platform")
+	ErrPeerUIDMismatch      = errors.New("agent: peer uid does not match agent uid")
+	ErrServerAlreadyStarted = errors.New("agent: server already started")
+)
+
+// ServerOptions contains configuration options for the agent server.
+type ServerOptions struct {
+	// SocketPath is the Unix socket to listen on.
+	// If empty, defaults to <vault>/agent.sock.
+	SocketPath string
+
+	// HealthInterval is how often the vault health watchdog runs.
+	// Zero uses DefaultHealthInterval; a negative value disables the watchdog.
+	HealthInterval time.Duration
+
+	// TamperInterval is how often the vault files are polled for changes
+	// by other processes where file system notifications are unavailable
+	// (see vault.WatchFiles). Zero uses vault.DefaultTamperCheckInterval; a
+	// negative value disables the check.
+	TamperInterval time.Duration
+
+	// OnHealthChange is called when the vault becomes degraded or recovers.
+	// It runs on the watchdog goroutine and must not block for long.
+	OnHealthChange func(*HealthStatus)
+
+	// ApproveAttach decides whether another process may join the unlocked
+	// session (Attach). It should ask the user and may block while doing so.
+	// If nil, shared sessions are disabled.
+	ApproveAttach func(*AttachRequest) bool
+
+	// OnLock is called after a client locked the vault through the API, so
+	// the agent owner can update its own state.
+	OnLock func()
+
+	// RunPolicy authorizes the commands clients run with secrets (Run).
+	// If nil, Run is disabled.
+	RunPolicy RunPolicy
+
+	// Tasks are run periodically while the agent serves. Tasks with a
+	// non-positive Interval are ignored.
+	Tasks []Task
+
+	// MetricsAddr is a TCP address, such as 127.0.0.1:9464, to serve
+	// Prometheus metrics on (see MetricsPath). Empty disables metrics.
+	// Metrics contain no keys or values, but anyone who can reach the
+	// address can read them.
+	MetricsAddr string
+
+	// DashboardAddr is a loopback TCP address, such as 127.0.0.1:9465, to
+	// serve the read-only HTML dashboard on (see DashboardURL). Empty
+	// disables it.
+	DashboardAddr string
+}
+
+// RunPolicy decides which commands clients may run through the agent.
+// *mcp.Policy implements it, so that the agent enforces the same command
+// rules as secret_run.
+type RunPolicy interface {
+	// AuthorizeCommand resolves command and checks it against the policy,
+	// returning the absolute path to execute.
+	AuthorizeCommand(command string) (string, error)
+	// InheritsEnv reports whether commands inherit the agent's variable
+	// name on top of secretenv.SafeVars. Blocked variables never are.
+	InheritsEnv(name string) bool
+}
+
+// Server serves the agent API for an unlocked vault.
+type Server struct {
+	vault      *vault.Vault
+	socketPath string
+	startedAt  time.Time
+
+	healthInterval time.Duration
+	tamperInterval time.Duration
+	onHealthChange func(*HealthStatus)
+	approveAttach  func(*AttachRequest) bool
+	onLock         func()
+	runPolicy      RunPolicy
+	tasks          []Task
+	metricsAddr    string
+	metrics        *metrics
+	dashboardAddr  string
+
+	mu              sync.Mutex
+	listener        net.Listener
+	grpcServer      *grpc.Server
+	metricsListener net.Listener
+	health          *HealthStatus
+	done            chan struct{} // closed by Close
+	// dashboardListener and dashboardAuth are set while the dashboard is served
+	dashboardListener net.Listener
+	dashboardAuth     *dashboardAuth
+}
+
+// NewServer creates an agent server for v. The vault should already be unlocked.
+func NewServer(v *vault.Vault, opts *ServerOptions) *Server {
+	if opts == nil {
+		opts = &ServerOptions{}
+	}
+	socketPath := opts.SocketPath
+	if socketPath == "" {
+		socketPath = DefaultSocketPath(v.Path())
+	}
+	healthInterval := opts.HealthInterval
+	if healthInterval == 0 {
+		healthInterval = DefaultHealthInterval
+	}
+	tamperInterval := opts.TamperInterval
+	if tamperInterval == 0 {
+		tamperInterval = vault.DefaultTamperCheckInterval
+	}
+	return &Server{
+		vault:          v,
+		socketPath:     socketPath,
+		startedAt:      time.Now(),
+		healthInterval: healthInterval,
+		tamperInterval: tamperInterval,
+		onHealthChange: opts.OnHealthChange,
+		approveAttach:  opts.ApproveAttach,
+		onLock:         opts.OnLock,
+		runPolicy:      opts.RunPolicy,
+		tasks:          opts.Tasks,
+		metricsAddr:    opts.MetricsAddr,
+		metrics:        newMetrics(),
+		dashboardAddr:  opts.DashboardAddr,
+		done:           make(chan struct{}),
+	}
+}
+
+// DefaultSocketPath returns the default agent socket path for a vault
+// directory, or the path in SECRETCTL_AGENT_SOCK if that is set.
+func DefaultSocketPath(vaultPath string) string {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path
+	}
+	return filepath.Join(vaultPath, SocketFileName)
+}
+
+// SocketPath returns the socket the server listens on.
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
+// Serve listens on the socket and serves requests until ctx is canceled
+// or Close is called.
+func (s *Server) Serve(ctx context.Context) error {
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.Creds(peerCredentials{}),
+		grpc.UnaryInterceptor(s.observe),
+	)
+	agentv1.RegisterAgentServer(grpcServer, &serviceV1{server: s})
+	s.mu.Lock()
+	s.grpcServer = grpcServer
+	s.mu.Unlock()
+
+	watchCtx, stopWatchdog := context.WithCancel(ctx)
+	defer stopWatchdog()
+	if s.healthInterval > 0 {
+		go s.watchHealth(watchCtx)
+	}
+	if s.tamperInterval > 0 {
+		go s.vault.WatchFiles(watchCtx, s.tamperInterval, audit.SourceAPI)
+	}
+	go s.logEvents(watchCtx)
+	if s.metricsListener != nil {
+		go s.serveMetrics(watchCtx, s.metricsListener)
+	}
+	if s.dashboardListener != nil {
+		go s.serveDashboard(watchCtx, s.dashboardListener)
+	}
+	for _, t := range s.tasks {
+		if t.Interval > 0 {
+			go s.runTask(watchCtx, t)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	if err := grpcServer.Serve(ln); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("agent: serve failed: %w", err)
+	}
+	return nil
+}
+
+// logEvents logs vault warnings while the agent serves; they would otherwise
+// go to a stderr nobody watches.
+func (s *Server) logEvents(ctx context.Context) {
+	events, unsubscribe := s.vault.Events()
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-events:
+			switch e.Type {
+			case vault.EventCooldownTriggered, vault.EventLowDisk, vault.EventWarning:
+				slog.Warn("agent: "+e.Message, "event", e.Type)
+			case vault.EventTamperSuspected:
+				slog.Error("agent: vault locked, tampering suspected: "+e.Message, "event", e.Type)
+			case vault.EventCanaryTriggered:
+				slog.Error("agent: "+e.Message, "event", e.Type, "key", e.Key)
+			}
+		}
+	}
+}
+
+// listen creates the Unix socket with owner-only permissions.
+func (s *Server) listen() (net.Listener, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return nil, ErrServerAlreadyStarted
+	}
+
+	// Remove a stale socket left by a crashed agent, but never steal
+	// the socket of a live one.
+	if _, err := os.Lstat(s.socketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", s.socketPath, time.Second); err == nil {
+			conn.Close()
+			return nil, ErrSocketInUse
+		}
+		if err := os.Remove(s.socketPath); err != nil {
+			return nil, fmt.Errorf("agent: failed to remove stale socket: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0700); err != nil {
+		return nil, fmt.Errorf("agent: failed to create socket directory: %w", err)
+	}
+
+	ln, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("agent: failed to listen on %s: %w", s.socketPath, err)
+	}
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("agent: failed to set socket permissions: %w", err)
+	}
+
+	if s.metricsAddr != "" {
+		mln, err := net.Listen("tcp", s.metricsAddr)
+		if err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("agent: failed to listen for metrics on %s: %w", s.metricsAddr, err)
+		}
+		s.metricsListener = mln
+	}
+
+	if s.dashboardAddr != "" {
+		dln, auth, err := listenDashboard(s.dashboardAddr)
+		if err != nil {
+			ln.Close()
+			if s.metricsListener != nil {
+				s.metricsListener.Close()
+				s.metricsListener = nil
+			}
+			return nil, err
+		}
+		s.dashboardListener = dln
+		s.dashboardAuth = auth
+	}
+
+	s.listener = ln
+	return ln, nil
+}
+
+// MetricsAddr returns the address metrics are served on once Serve has
+// started, or "" if metrics are disabled.
+func (s *Server) MetricsAddr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metricsListener == nil {
+		return ""
+	}
+	return s.metricsListener.Addr().String()
+}
+
+// Close stops the server, closes open connections and removes the socket.
+// The vault is not locked; callers decide whether to lock it.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	err := s.listener.Close()
+	s.listener = nil
+	grpcServer := s.grpcServer
+	s.grpcServer = nil
+	if s.metricsListener != nil {
+		s.metricsListener.Close()
+		s.metricsListener = nil
+	}
+	if s.dashboardListener != nil {
+		s.dashboardListener.Close()
+		s.dashboardListener = nil
+	}
+	close(s.done)
+	_ = os.Remove(s.socketPath)
+	s.mu.Unlock()
+
+	// Outside s.mu: stopping cancels the running handlers, which may need it
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return err
+}
+
+// serviceV1 implements the v1 API (agentv1.AgentServer).
+type serviceV1 struct {
+	agentv1.UnimplementedAgentServer
+	server *Server
+}
+
+// Get returns a decrypted secret.
+func (svc *serviceV1) Get(_ context.Context, req *agentv1.GetRequest) (*agentv1.GetResponse, error) {
+	if req.Key == "" {
+		return nil, rpcError(CodeInvalidArgument, "key is required")
+	}
+
+	entry, err := svc.server.vault.GetSecret(req.Key)
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+
+	resp := &agentv1.GetResponse{
+		Key:       req.Key,
+		Tags:      entry.Tags,
+		UpdatedAt: timestamppb.New(entry.UpdatedAt),
+		Fields:    make(map[string]string, len(entry.Fields)),
+	}
+	if entry.ExpiresAt != nil {
+		resp.ExpiresAt = timestamppb.New(*entry.ExpiresAt)
+	}
+	for name, f := range entry.Fields {
+		resp.Fields[name] = f.Value
+	}
+
+	if req.Field != "" {
+		_, field, err := vault.ResolveFieldName(entry.Fields, req.Field)
+		if err != nil {
+			return nil, rpcError(CodeNotFound, err.Error())
+		}
+		resp.Value = field.Value
+	} else {
+		resp.Value = vault.GetDefaultFieldValue(entry.Fields)
+	}
+	return resp, nil
+}
+
+// Set stores a secret.
+func (svc *serviceV1) Set(_ context.Context, req *agentv1.SetRequest) (*agentv1.SetResponse, error) {
+	if req.Key == "" {
+		return nil, rpcError(CodeInvalidArgument, "key is required")
+	}
+	if req.Value == "" && len(req.Fields) == 0 {
+		return nil, rpcError(CodeInvalidArgument, "value or fields is required")
+	}
+
+	fields := make(map[string]vault.Field, len(req.Fields)+1)
+	if req.Value != "" {
+		fields[vault.DefaultFieldName] = vault.NewDefaultField(req.Value)
+	}
+	for name, value := range req.Fields {
+		fields[name] = vault.Field{Value: value, Sensitive: true}
+	}
+
+	entry := &vault.SecretEntry{
+		Key:    req.Key,
+		Fields: fields,
+		Tags:   req.Tags,
+	}
+	if req.Notes != "" || req.Url != "" {
+		entry.Metadata = &vault.SecretMetadata{Notes: req.Notes, URL: req.Url}
+	}
+
+	if err := svc.server.vault.SetSecret(req.Key, entry); err != nil {
+		return nil, toRPCError(err)
+	}
+	return &agentv1.SetResponse{Key: req.Key}, nil
+}
+
+// List returns secret keys.
+func (svc *serviceV1) List(_ context.Context, req *agentv1.ListRequest) (*agentv1.ListResponse, error) {
+	if req.Tag == "" {
+		keys, err := svc.server.vault.ListSecrets()
+		if err != nil {
+			return nil, toRPCError(err)
+		}
+		return &agentv1.ListResponse{Keys: keys}, nil
+	}
+
+	entries, err := svc.server.vault.ListSecretsByTag(req.Tag)
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	resp := &agentv1.ListResponse{Keys: make([]string, 0, len(entries))}
+	for _, e := range entries {
+		resp.Keys = append(resp.Keys, e.Key)
+	}
+	return resp, nil
+}
+
+// Run executes a command allowed by the run policy with the requested
+// secrets injected. Secrets are injected and redacted from the output by
+// the same rules as MCP secret_run.
+func (svc *serviceV1) Run(ctx context.Context, req *agentv1.RunRequest) (*agentv1.RunResponse, error) {
+	v := svc.server.vault
+	policy := svc.server.runPolicy
+	if policy == nil {
+		return nil, rpcError(CodeDenied, "running commands is not enabled on this agent")
+	}
+	if req.Command == "" {
+		return nil, rpcError(CodeInvalidArgument, "command is required")
+	}
+	if len(req.Keys) == 0 && len(req.Bindings) == 0 {
+		return nil, rpcError(CodeInvalidArgument, "at least one key or binding is required")
+	}
+	if err := secretenv.ValidateCommand(req.Command); err != nil {
+		return nil, rpcError(CodeInvalidArgument, err.Error())
+	}
+	if err := secretenv.ValidateArgs(req.Args); err != nil {
+		return nil, rpcError(CodeInvalidArgument, err.Error())
+	}
+	command, err := policy.AuthorizeCommand(req.Command)
+	if err != nil {
+		_ = v.Audit().LogDenied(audit.OpSecretRunDenied, audit.SourceAPI, req.Command, err.Error())
+		return nil, rpcError(CodeDenied, err.Error())
+	}
+
+	timeout := defaultRunTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(req.TimeoutSeconds)*time.Second, maxRunTimeout)
+	}
+
+	entries, err := v.GetSecrets(slices.Concat(req.Keys, req.Bindings))
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	secrets := make([]secretenv.Secret, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		secrets = append(secrets, secretenv.Secret{Key: key, Value: []byte(vault.GetDefaultFieldValue(entries[key].Fields))})
+	}
+	env, err := secretenv.Build(secretenv.Inherited(policy.InheritsEnv), secrets, "")
+	if err != nil {
+		return nil, rpcError(CodeInvalidArgument, err.Error())
+	}
+	for _, key := range req.Bindings {
+		if len(entries[key].Bindings) == 0 {
+			return nil, rpcError(CodeInvalidArgument, fmt.Sprintf("secret %s has no env bindings", key))
+		}
+		var bound []secretenv.Secret
+		env, bound, err = secretenv.FromBindings(env, entries[key])
+		if err != nil {
+			return nil, rpcError(CodeInvalidArgument, err.Error())
+		}
+		secrets = append(secrets, bound...)
+	}
+	sanitizer := secretenv.NewSanitizer(secrets)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command, req.Args...)
+	cmd.Env = env
+	stdout := sanitizer.Capture(maxRunOutputSize)
+	stderr := sanitizer.Capture(maxRunOutputSize)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+
+	resp := &agentv1.RunResponse{
+		Stdout: string(truncate(sanitizer.Sanitize(stdout.Bytes()), maxRunOutputSize)),
+		Stderr: string(truncate(sanitizer.Sanitize(stderr.Bytes()), maxRunOutputSize)),
+	}
+	stdout.Wipe()
+	stderr.Wipe()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, rpcError(CodeInternal, fmt.Sprintf("command timed out after %v", timeout))
+		case errors.As(err, &exitErr):
+			resp.ExitCode = int32(exitErr.ExitCode())
+		default:
+			return nil, rpcError(CodeInternal, fmt.Sprintf("command execution failed: %v", err))
+		}
+	}
+	return resp, nil
+}
+
+// truncate cuts data to at most n bytes.
+func truncate(data []byte, n int) []byte {
+	if len(data) > n {
+		return data[:n]
+	}
+	return data
+}
+
+// Lock locks the vault. Subsequent secret operations fail with CodeLocked.
+func (svc *serviceV1) Lock(_ context.Context, _ *agentv1.LockRequest) (*agentv1.LockResponse, error) {
+	svc.server.vault.Lock()
+	if svc.server.onLock != nil {
+		svc.server.onLock()
+	}
+	return &agentv1.LockResponse{Locked: true}, nil
+}
+
+// Attach hands the session key to a process that the user approved, so that
+// it can use the vault without the master password.
+func (svc *serviceV1) Attach(ctx context.Context, req *agentv1.AttachRequest) (*agentv1.AttachResponse, error) {
+	v := svc.server.vault
+	if svc.server.approveAttach == nil {
+		return nil, rpcError(CodeDenied, "shared sessions are not enabled on this agent")
+	}
+	if v.IsLocked() {
+		return nil, rpcError(CodeLocked, "vault is locked")
+	}
+	var info peerAuthInfo
+	p, ok := peer.FromContext(ctx)
+	if ok {
+		info, ok = p.AuthInfo.(peerAuthInfo)
+	}
+	if !ok {
+		return nil, rpcError(CodeDenied, "peer credentials are unavailable")
+	}
+
+	attach := &AttachRequest{Client: req.Client, PID: int(req.Pid), PeerPID: info.pid, Executable: info.executable}
+	details := map[string]interface{}{
+		"client":     attach.Client,
+		"pid":        attach.PeerPID,
+		"executable": attach.Executable,
+	}
+	if !svc.server.approveAttach(attach) {
+		_ = v.Audit().Log(audit.OpSessionAttach, audit.SourceAPI, audit.ResultDenied, "", nil, details)
+		return nil, rpcError(CodeDenied, "attach request was declined")
+	}
+
+	key, err := v.SessionKey()
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	_ = v.Audit().Log(audit.OpSessionAttach, audit.SourceAPI, audit.ResultSuccess, "", nil, details)
+	return &agentv1.AttachResponse{Key: key, VaultPath: v.Path()}, nil
+}
+
+// WaitLocked returns once the vault is locked, however that happened, so
+// that attached processes can lock themselves too. It fails if the agent
+// shuts down or the client goes away first.
+func (svc *serviceV1) WaitLocked(ctx context.Context, _ *agentv1.WaitLockedRequest) (*agentv1.WaitLockedResponse, error) {
+	// Subscribe before checking so that a lock in between is not missed
+	events, unsubscribe := svc.server.vault.Events()
+	defer unsubscribe()
+	for !svc.server.vault.IsLocked() {
+		select {
+		case <-events:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-svc.server.done:
+			return nil, rpcError(CodeInternal, "agent is shutting down")
+		}
+	}
+	return &agentv1.WaitLockedResponse{Locked: true}, nil
+}
+
+// Status reports agent state.
+func (svc *serviceV1) Status(_ context.Context, _ *agentv1.StatusRequest) (*agentv1.StatusResponse, error) {
+	resp := &agentv1.StatusResponse{
+		ApiVersion: APIVersion,
+		Pid:        int32(os.Getpid()),
+		VaultPath:  svc.server.vault.Path(),
+		Locked:     svc.server.vault.IsLocked(),
+		StartedAt:  timestamppb.New(svc.server.startedAt),
+	}
+	if h := svc.server.Health(); h != nil {
+		resp.Health = &agentv1.HealthStatus{
+			CheckedAt: timestamppb.New(h.CheckedAt),
+			Healthy:   h.Healthy,
+			Problems:  h.Problems,
+		}
+	}
+	return resp, nil
+}
+
+// grpcCodes are the gRPC status codes of the agent error codes.
+var grpcCodes = map[string]codes.Code{
+	CodeNotFound:        codes.NotFound,
+	CodeLocked:          codes.FailedPrecondition,
+	CodeInvalidArgument: codes.InvalidArgument,
+	CodeDenied:          codes.PermissionDenied,
+	CodeExpired:         codes.FailedPrecondition,
+	CodeDeprecated:      codes.FailedPrecondition,
+	CodeInternal:        codes.Internal,
+}
+
+// rpcError returns a gRPC status carrying an agent error code.
+func rpcError(code, msg string) error {
+	st := status.New(grpcCodes[code], msg)
+	if withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: code, Domain: ErrorDomain}); err == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
+
+// statusCode returns the agent error code carried by st, or "" if it has
+// none.
+func statusCode(st *status.Status) string {
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != ErrorDomain {
+			continue
+		}
+		if _, known := grpcCodes[info.Reason]; known {
+			return info.Reason
+		}
+		return CodeInternal
+	}
+	return ""
+}
+
+// toRPCError maps vault errors to wire codes.
+func toRPCError(err error) error {
+	switch {
+	case errors.Is(err, vault.ErrSecretNotFound):
+		return rpcError(CodeNotFound, err.Error())
+	case errors.Is(err, vault.ErrVaultLocked):
+		return rpcError(CodeLocked, err.Error())
+	case errors.Is(err, vault.ErrSecretExpired):
+		return rpcError(CodeExpired, err.Error())
+	case errors.Is(err, vault.ErrSecretDeprecated):
+		return rpcError(CodeDeprecated, err.Error())
+	case errors.Is(err, vault.ErrSecretNotYetValid), errors.Is(err, vault.ErrOutsideAccessWindow),
+		errors.Is(err, vault.ErrSecretImmutable), errors.Is(err, vault.ErrSecretFrozen):
+		return rpcError(CodeDenied, err.Error())
+	case errors.Is(err, vault.ErrKeyInvalid), errors.Is(err, vault.ErrKeyTooLong),
+		errors.Is(err, vault.ErrKeyTooShort), errors.Is(err, vault.ErrValueTooLarge):
+		return rpcError(CodeInvalidArgument, err.Error())
+	default:
+		return rpcError(CodeInternal, err.Error())
+	}
+}
@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Task is periodic work the agent runs while it serves, such as renewing
+// certificates. Run is called once when the agent starts and then every
+// Interval, but only while the vault is unlocked.
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// runTask runs t until ctx is canceled.
+func (s *Server) runTask(ctx context.Context, t Task) {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	for {
+		if !s.vault.IsLocked() {
+			if err := t.Run(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("agent: task failed", "task", t.Name, "err", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
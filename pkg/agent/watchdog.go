@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultHealthInterval is how often the agent checks vault health.
+const DefaultHealthInterval = 5 * time.Minute
+
+// watchHealth periodically runs vault.QuickCheck while the agent is serving,
+// so corruption or permission drift is reported while the agent runs rather
+// than at the next unlock. Checks are skipped while the vault is locked.
+func (s *Server) watchHealth(ctx context.Context) {
+	s.checkHealth()
+
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkHealth()
+		}
+	}
+}
+
+// checkHealth runs one health check and records the result.
+func (s *Server) checkHealth() {
+	if s.vault.IsLocked() {
+		return
+	}
+	report, err := s.vault.QuickCheck()
+	if err != nil {
+		// Locked between the check above and QuickCheck; try again next tick.
+		return
+	}
+
+	status := &HealthStatus{
+		CheckedAt: report.CheckedAt,
+		Healthy:   report.Healthy,
+		Problems:  report.Problems,
+	}
+
+	s.mu.Lock()
+	prev := s.health
+	s.health = status
+	s.mu.Unlock()
+
+	// Only notify on transitions: first degraded result, or recovery.
+	changed := (prev == nil && !status.Healthy) || (prev != nil && prev.Healthy != status.Healthy)
+	if !changed {
+		return
+	}
+	if status.Healthy {
+		slog.Info("agent: vault health recovered")
+	} else {
+		for _, p := range status.Problems {
+			slog.Warn("agent: vault health degraded", "problem", p)
+		}
+	}
+	if s.onHealthChange != nil {
+		s.onHealthChange(status)
+	}
+}
+
+// Health returns the latest watchdog result, or nil if no check has run yet.
+func (s *Server) Health() *HealthStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.health == nil {
+		return nil
+	}
+	h := *s.health
+	h.Problems = append([]string(nil), s.health.Problems...)
+	return &h
+}
@@ -0,0 +1,73 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.meta")
+
+	if err := Write(path, []byte("one"), 0600); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := Write(path, []byte("two"), 0600); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "two" {
+		t.Fatalf("content = %q, %v; want two", data, err)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestWriteMissingDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "vault.salt")
+	if err := Write(path, []byte("x"), 0600); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp-approvals")
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	acquired := make(chan func())
+	go func() {
+		unlock, err := Lock(path)
+		if err != nil {
+			t.Errorf("second Lock failed: %v", err)
+			unlock = func() {}
+		}
+		acquired <- unlock
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second Lock acquired while the first was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case unlock := <-acquired:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Lock not acquired after unlock")
+	}
+}
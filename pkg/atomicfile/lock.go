@@ -0,0 +1,27 @@
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+)
+
+// Lock takes an exclusive lock on path, waiting for other processes to
+// release it, and returns the function that releases it. Callers that read
+// a file, change it and Write it back hold the lock around all three, so
+// that concurrent updates from other processes are not lost. The lock is
+// kept in path + ".lock", which is created if needed and never removed;
+// path itself need not exist.
+func Lock(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("atomicfile: failed to open lock file: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("atomicfile: failed to lock %s: %w", path, err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
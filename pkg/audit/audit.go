@@ -42,6 +42,9 @@ import (
 	"time"
 
 	"golang.org/x/crypto/hkdf"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/crypto"
 )
 
 // Disk space constants
@@ -56,41 +59,104 @@ const (
 	OpVaultUnlock       = "vault.unlock"
 	OpVaultUnlockFailed = "vault.unlock_failed"
 	OpVaultLock         = "vault.lock"
+	OpVaultRebuild      = "vault.rebuild"
+	OpVaultDEKExport    = "vault.dek_export"
+	OpVaultTamper       = "vault.tamper_suspected"
+	OpVaultReencrypt    = "vault.reencrypt"
+	OpVaultFreeze       = "vault.freeze"
+	OpVaultEmergency    = "vault.emergency_access"
+	OpVaultShard        = "vault.shard"
+	OpVaultSnapshot     = "vault.snapshot"
+	OpVaultRekey        = "vault.rekey"
+	OpVaultKDF          = "vault.kdf_changed"
+	OpVaultPermissions  = "vault.permissions_fixed"
 
 	// Secret operations
-	OpSecretGet    = "secret.get"
-	OpSecretSet    = "secret.set"
-	OpSecretUpdate = "secret.update"
-	OpSecretDelete = "secret.delete"
-	OpSecretList   = "secret.list"
+	OpSecretGet        = "secret.get"
+	OpSecretGetBatch   = "secret.get_batch" // one event for a GetSecrets call
+	OpSecretSet        = "secret.set"
+	OpSecretSetBatch   = "secret.set_batch" // one event for a SetSecrets transaction
+	OpSecretUpdate     = "secret.update"
+	OpSecretDelete     = "secret.delete"
+	OpSecretList       = "secret.list"
+	OpSecretDeprecate  = "secret.deprecate"
+	OpSecretBreakGlass = "secret.break_glass"
+	OpSecretGrep       = "secret.grep"
+	OpSecretSearch     = "secret.search"     // query not recorded
+	OpSecretDuplicates = "secret.duplicates" // duplicate value scan, no keys recorded
+	OpSecretDynamic    = "secret.dynamic"    // dynamic:// secret resolved by a plugin
+	OpSecretCanary     = "secret.canary_triggered"
+	OpSecretRestore    = "secret.restore" // moved back out of the trash
+	OpSecretPurge      = "secret.purge"   // removed from the trash for good
+
+	// Attachment operations; the attachment name is in the context
+	OpSecretAttach           = "secret.attachment_add"
+	OpSecretAttachmentGet    = "secret.attachment_get"
+	OpSecretAttachmentRemove = "secret.attachment_remove"
 
 	// MCP operations (Phase 2)
 	OpSecretExists    = "secret.exists"
 	OpSecretGetMasked = "secret.get_masked"
 	OpSecretRun       = "secret.run"
 	OpSecretRunDenied = "secret.run_denied"
-	OpSecretExport    = "secret.export"
+	// OpSecretRunEgress records a destination a command reached through
+	// the egress proxy; OpSecretRunEgressDenied one it was refused
+	OpSecretRunEgress       = "secret.run_egress"
+	OpSecretRunEgressDenied = "secret.run_egress_denied"
+	OpSecretExport          = "secret.export"
 
 	// MCP multi-field operations (Phase 2.5)
 	OpSecretListFields      = "secret.list_fields"
 	OpSecretGetField        = "secret.get_field"
 	OpSecretGetFieldDenied  = "secret.get_field_denied"
 	OpSecretRunWithBindings = "secret.run_with_bindings"
+	OpSecretRunBatch        = "secret.run_batch"
+
+	// Project manifest operations
+	OpProjectManifest = "project.manifest"
+
+	// MCP session pin operations
+	OpMCPPin           = "mcp.pin"
+	OpMCPUnpin         = "mcp.unpin"
+	OpMCPSessionLocked = "mcp.session_locked"
+	OpMCPPolicyInfo    = "mcp.policy_info"
+	OpMCPApprove       = "mcp.approve"
+	OpMCPDeny          = "mcp.deny"
+	OpMCPRevoke        = "mcp.revoke"
+
+	// Audit log operations
+	OpAuditExportProof = "audit.export_proof"
 
 	// Session operations (Phase 3+)
 	OpSessionStart = "session.start"
 	OpSessionEnd   = "session.end"
+	// OpSessionAttach records a shared-session request through the agent
+	OpSessionAttach = "session.attach"
 
 	// Password management operations (Phase 2c-P)
 	OpPasswordChanged = "password.changed"
+
+	// Database credential broker operations
+	OpDBIssue  = "db.issue"
+	OpDBRevoke = "db.revoke"
+
+	// Certificate operations
+	OpCertRenew = "cert.renew"
+
+	// CI bundle operations
+	OpCITokenCreate = "ci.token_create"
+
+	// GitHub Actions sync operations
+	OpGHActionsPush   = "ghactions.push"
+	OpGHActionsDelete = "ghactions.delete"
 )
 
 // Source identifies where the operation originated
 const (
 	SourceCLI = "cli"
 	SourceMCP = "mcp"
-	SourceUI  = "ui"
-	SourceAPI = "api"
+	SourceUI  = "ui"  // the desktop app
+	SourceAPI = "api" // the agent and the Go SDK
 )
 
 // Result indicates the outcome of an operation
@@ -114,6 +180,7 @@ type AuditEvent struct {
 
 	// Actor information
 	Actor Actor `json:"actor"`
+	Host  *Host `json:"host,omitempty"` // Machine and caller (configurable)
 
 	// Organization information (Phase 3+)
 	Org *Org `json:"org,omitempty"`
@@ -163,13 +230,19 @@ type Chain struct {
 
 // Logger handles audit log writing with HMAC chain
 type Logger struct {
-	path       string     // Audit log directory path
-	hmacKey    []byte     // HMAC key derived from master key
-	mu         sync.Mutex // Protects concurrent writes
-	sequence   int64      // Current sequence number
-	prevHash   string     // Previous record hash
-	sessionID  string     // Current session ID
-	hmacKeySet bool       // Whether HMAC key has been set
+	path       string               // Audit log directory path
+	hmacKey    []byte               // HMAC key derived from master key
+	hmacBuf    *crypto.LockedBuffer // Non-swappable memory holding hmacKey
+	mu         sync.Mutex           // Protects concurrent writes
+	sequence   int64                // Current sequence number
+	prevHash   string               // Previous record hash
+	sessionID  string               // Current session ID
+	hmacKeySet bool                 // Whether HMAC key has been set
+	host       *Host                // Host identity added to events (nil = omitted)
+	vaultID    string               // Vault UUID added to events ("" = omitted)
+	// retiredKeys are the HMAC keys used before the vault's DEK was rotated,
+	// oldest first
+	retiredKeys [][]byte
 }
 
 // Config holds audit logger configuration
@@ -186,6 +259,7 @@ func NewLogger(path string) *Logger {
 		path:      path,
 		prevHash:  "genesis", // Initial chain value
 		sessionID: generateSessionID(),
+		host:      CurrentHost(),
 	}
 }
 
@@ -194,9 +268,12 @@ func (l *Logger) SetHMACKey(masterKey []byte) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	// Derive HMAC key using HKDF-SHA256
+	// Derive HMAC key using HKDF-SHA256, into locked memory where possible
+	// (the vault warns when its DEK cannot be locked)
+	l.hmacBuf.Destroy()
+	l.hmacBuf, _ = crypto.NewLockedBuffer(32)
+	l.hmacKey = l.hmacBuf.Bytes()
 	hkdfReader := hkdf.New(sha256.New, masterKey, nil, []byte("audit-log-v1"))
-	l.hmacKey = make([]byte, 32)
 	if _, err := hkdfReader.Read(l.hmacKey); err != nil {
 		return fmt.Errorf("audit: failed to derive HMAC key: %w", err)
 	}
@@ -212,20 +289,59 @@ func (l *Logger) SetHMACKey(masterKey []byte) error {
 	return nil
 }
 
+// SetVaultID sets the vault UUID recorded in subsequent events, so that
+// logs collected from several vaults can be told apart.
+func (l *Logger) SetVaultID(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.vaultID = id
+}
+
+// org returns the organization context of new events.
+func (l *Logger) org() *Org {
+	if l.vaultID == "" {
+		return nil
+	}
+	return &Org{VaultID: l.vaultID}
+}
+
+// SetRetiredKeys sets the HMAC keys, as returned by ExportKey, that signed
+// records before the current key, oldest first. Verify accepts a record
+// signed with a retired key as long as no later key has signed an earlier
+// record, so records written before a key rotation still verify while
+// records forged with an exposed old key cannot be appended afterwards.
+func (l *Logger) SetRetiredKeys(keys [][]byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	wipeKeys(l.retiredKeys)
+	l.retiredKeys = make([][]byte, len(keys))
+	for i, key := range keys {
+		l.retiredKeys[i] = append([]byte(nil), key...)
+	}
+}
+
+func wipeKeys(keys [][]byte) {
+	for _, key := range keys {
+		for i := range key {
+			key[i] = 0
+		}
+		runtime.KeepAlive(key)
+	}
+}
+
 // ClearHMACKey securely wipes the HMAC key from memory.
 // This should be called when locking the vault to minimize sensitive material lifetime.
 func (l *Logger) ClearHMACKey() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.hmacKey != nil {
-		// Securely wipe the key bytes
-		for i := range l.hmacKey {
-			l.hmacKey[i] = 0
-		}
-		runtime.KeepAlive(l.hmacKey)
-		l.hmacKey = nil
-	}
+	// Destroy wipes the key bytes
+	l.hmacBuf.Destroy()
+	l.hmacBuf = nil
+	l.hmacKey = nil
+	wipeKeys(l.retiredKeys)
+	l.retiredKeys = nil
 	l.hmacKeySet = false
 }
 
@@ -259,6 +375,8 @@ func (l *Logger) Log(op, source, result string, keyName string, errInfo *ErrorIn
 			Source:    source,
 			SessionID: l.sessionID,
 		},
+		Host:    l.host,
+		Org:     l.org(),
 		Result:  result,
 		Error:   errInfo,
 		Context: ctx,
@@ -359,6 +477,21 @@ func (l *Logger) buildRecordData(event *AuditEvent) []byte {
 		event.Chain.Sequence,
 		event.Chain.PrevHash,
 	)
+
+	// Host identity is appended only when present so that records written
+	// before it existed keep verifying
+	if event.Host != nil {
+		data += fmt.Sprintf("|host=%s|%s|%s|%s",
+			event.Host.Hostname,
+			event.Host.User,
+			event.Host.Process,
+			event.Host.ParentProcess,
+		)
+	}
+	// Likewise the organization context (the vault ID)
+	if event.Org != nil {
+		data += fmt.Sprintf("|org=%s|%s|%s", event.Org.ID, event.Org.VaultID, event.Org.TeamID)
+	}
 	return []byte(data)
 }
 
@@ -434,7 +567,7 @@ func (l *Logger) saveChainState() error {
 	}
 
 	metaPath := filepath.Join(l.path, "audit.meta")
-	if err := os.WriteFile(metaPath, data, 0600); err != nil {
+	if err := atomicfile.Write(metaPath, data, 0600); err != nil {
 		return fmt.Errorf("audit: failed to save chain state: %w", err)
 	}
 
@@ -499,6 +632,9 @@ func (l *Logger) Verify() (*VerifyResult, error) {
 
 	expectedPrevHash := "genesis"
 	var expectedSeq int64 = 1
+	// Retired keys first; epoch only moves forward
+	keys := append(append([][]byte(nil), l.retiredKeys...), l.hmacKey)
+	epoch := 0
 
 	for _, file := range files {
 		events, err := l.readLogFile(file)
@@ -527,11 +663,17 @@ func (l *Logger) Verify() (*VerifyResult, error) {
 
 			// Verify HMAC
 			recordData := l.buildRecordData(&event)
-			mac := hmac.New(sha256.New, l.hmacKey)
-			mac.Write(recordData)
-			expectedHMAC := hex.EncodeToString(mac.Sum(nil))
+			matched := false
+			for i := epoch; i < len(keys); i++ {
+				mac := hmac.New(sha256.New, keys[i])
+				mac.Write(recordData)
+				if event.Chain.HMAC == hex.EncodeToString(mac.Sum(nil)) {
+					epoch, matched = i, true
+					break
+				}
+			}
 
-			if event.Chain.HMAC != expectedHMAC {
+			if !matched {
 				result.Valid = false
 				result.Errors = append(result.Errors, fmt.Sprintf(
 					"HMAC mismatch at record %s: possible tampering",
@@ -547,6 +689,59 @@ func (l *Logger) Verify() (*VerifyResult, error) {
 	return result, nil
 }
 
+// VerifyHead performs a lightweight check of the newest audit record.
+// It verifies the record's HMAC and that it matches the chain head recorded
+// in audit.meta, detecting truncation or tampering of the latest log file
+// without re-reading the whole history. Use Verify for a full chain check.
+func (l *Logger) VerifyHead() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.hmacKeySet {
+		return fmt.Errorf("audit: HMAC key not set")
+	}
+
+	// Other processes (CLI, MCP server) append to the same log, so compare
+	// against the persisted head rather than this logger's in-memory state.
+	head := ChainState{Sequence: l.sequence, PrevHash: l.prevHash}
+	if data, err := os.ReadFile(filepath.Join(l.path, "audit.meta")); err == nil {
+		if err := json.Unmarshal(data, &head); err != nil {
+			return fmt.Errorf("audit: corrupt chain state: %w", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(l.path, "*.jsonl"))
+	if err != nil {
+		return fmt.Errorf("audit: failed to list log files: %w", err)
+	}
+	if len(files) == 0 {
+		if head.Sequence != 0 {
+			return fmt.Errorf("audit: log files missing (expected sequence %d)", head.Sequence)
+		}
+		return nil
+	}
+	sortStrings(files)
+
+	events, err := l.readLogFile(files[len(files)-1])
+	if err != nil {
+		return fmt.Errorf("audit: failed to read %s: %w", files[len(files)-1], err)
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("audit: newest log file is empty")
+	}
+	last := events[len(events)-1]
+
+	mac := hmac.New(sha256.New, l.hmacKey)
+	mac.Write(l.buildRecordData(&last))
+	if last.Chain.HMAC != hex.EncodeToString(mac.Sum(nil)) {
+		return fmt.Errorf("audit: HMAC mismatch at head record %s: possible tampering", last.ID)
+	}
+	if last.Chain.Sequence != head.Sequence || last.Chain.HMAC != head.PrevHash {
+		return fmt.Errorf("audit: chain head mismatch: log at sequence %d, expected %d", last.Chain.Sequence, head.Sequence)
+	}
+	return nil
+}
+
 // VerifyResult contains the results of chain verification
 type VerifyResult struct {
 	Valid           bool     `json:"valid"`
@@ -711,12 +906,18 @@ func (l *Logger) formatJSON(events []AuditEvent) ([]byte, error) {
 	return json.MarshalIndent(events, "", "  ")
 }
 
+// contextString returns a string context value, or "" if it is missing.
+func contextString(ctx map[string]interface{}, name string) string {
+	value, _ := ctx[name].(string)
+	return value
+}
+
 // formatCSV formats events as CSV with proper escaping
 func (l *Logger) formatCSV(events []AuditEvent) []byte {
 	var result []byte
 
 	// Header
-	result = append(result, []byte("timestamp,operation,result,key_hash\n")...)
+	result = append(result, []byte("timestamp,operation,result,key_hash,hostname,user,process,parent_process,owner,team\n")...)
 
 	// Data rows
 	for _, event := range events {
@@ -724,12 +925,22 @@ func (l *Logger) formatCSV(events []AuditEvent) []byte {
 		if len(keyHash) > 16 {
 			keyHash = keyHash[:16] + "..."
 		}
+		host := event.Host
+		if host == nil {
+			host = &Host{}
+		}
 		// Escape fields to prevent CSV injection
-		line := fmt.Sprintf("%s,%s,%s,%s\n",
+		line := fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
 			csvEscape(event.Timestamp),
 			csvEscape(event.Operation),
 			csvEscape(event.Result),
 			csvEscape(keyHash),
+			csvEscape(host.Hostname),
+			csvEscape(host.User),
+			csvEscape(host.Process),
+			csvEscape(host.ParentProcess),
+			csvEscape(contextString(event.Context, "owner")),
+			csvEscape(contextString(event.Context, "team")),
 		)
 		result = append(result, []byte(line)...)
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -529,6 +530,59 @@ func TestVerifyEmptyLog(t *testing.T) {
 	}
 }
 
+// TestVerifyRetiredKeys tests verification across a key rotation
+func TestVerifyRetiredKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldMaster := make([]byte, 32)
+	newMaster := make([]byte, 32)
+	newMaster[0] = 1
+
+	logger := NewLogger(tmpDir)
+	if err := logger.SetHMACKey(oldMaster); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+	_ = logger.LogSuccess(OpSecretSet, SourceCLI, "key1")
+	_ = logger.LogSuccess(OpSecretGet, SourceCLI, "key1")
+	oldKey, err := logger.ExportKey()
+	if err != nil {
+		t.Fatalf("ExportKey failed: %v", err)
+	}
+
+	// Rotate: the chain continues under the new key
+	if err := logger.SetHMACKey(newMaster); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+	logger.SetRetiredKeys([][]byte{oldKey})
+	_ = logger.LogSuccess(OpVaultRekey, SourceCLI, "")
+
+	result, err := logger.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !result.Valid || result.RecordsTotal != 3 {
+		t.Errorf("expected 3 valid records, got %+v", result)
+	}
+
+	// Without the retired key the old records do not verify
+	fresh := NewLogger(tmpDir)
+	if err := fresh.SetHMACKey(newMaster); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+	if result, _ := fresh.Verify(); result.Valid {
+		t.Error("expected old records to fail without the retired key")
+	}
+
+	// A record signed with the old key after the rotation is rejected
+	stale := NewLogger(tmpDir)
+	if err := stale.SetHMACKey(oldMaster); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+	_ = stale.LogSuccess(OpSecretGet, SourceCLI, "key1")
+	if result, _ := logger.Verify(); result.Valid {
+		t.Error("expected a record signed with a retired key after the rotation to fail")
+	}
+}
+
 // TestListEvents tests the audit log list functionality
 func TestListEvents(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -670,6 +724,45 @@ func TestExport(t *testing.T) {
 	})
 }
 
+// TestExportOwnerTeam tests the owner and team columns of CSV exports
+func TestExportOwnerTeam(t *testing.T) {
+	tests := []struct {
+		name    string
+		context map[string]interface{}
+		want    string
+	}{
+		{"owner and team", map[string]interface{}{"owner": "alice", "team": "platform"}, ",alice,platform"},
+		{"owner only", map[string]interface{}{"owner": "alice"}, ",alice,"},
+		{"team only", map[string]interface{}{"team": "platform"}, ",,platform"},
+		{"neither", nil, ",,"},
+		{"not strings", map[string]interface{}{"owner": 1, "team": true}, ",,"},
+		{"escaped", map[string]interface{}{"owner": "=cmd", "team": "a,b"}, `,"=cmd","a,b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := NewLogger(t.TempDir())
+			if err := logger.SetHMACKey(make([]byte, 32)); err != nil {
+				t.Fatalf("SetHMACKey failed: %v", err)
+			}
+			if err := logger.Log(OpSecretSet, SourceCLI, ResultSuccess, "key1", nil, tt.context); err != nil {
+				t.Fatalf("Log failed: %v", err)
+			}
+
+			data, err := logger.Export("csv", time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatalf("Export CSV failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 lines in CSV export, got %d", len(lines))
+			}
+			if !strings.HasSuffix(lines[1], tt.want) {
+				t.Errorf("CSV row %q does not end with %q", lines[1], tt.want)
+			}
+		})
+	}
+}
+
 // containsSubstring checks if a string contains a substring
 func containsSubstring(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
@@ -809,3 +902,85 @@ func TestPrunePreview(t *testing.T) {
 		t.Errorf("expected 3 events after preview (no deletion), got %d", len(events))
 	}
 }
+
+func TestVerifyHead(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLogger(tmpDir)
+	if err := logger.SetHMACKey(make([]byte, 32)); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+
+	// Empty log is healthy
+	if err := logger.VerifyHead(); err != nil {
+		t.Errorf("VerifyHead on empty log failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := logger.LogSuccess(OpSecretGet, SourceCLI, "test-key"); err != nil {
+			t.Fatalf("LogSuccess failed: %v", err)
+		}
+	}
+	if err := logger.VerifyHead(); err != nil {
+		t.Fatalf("VerifyHead failed: %v", err)
+	}
+
+	// Truncating the newest record leaves the log behind the in-memory head
+	files, _ := filepath.Glob(filepath.Join(tmpDir, "*.jsonl"))
+	if len(files) == 0 {
+		t.Fatal("no log files found")
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	lines := splitLines(data)
+	truncated := []byte{}
+	for _, line := range lines[:2] {
+		truncated = append(truncated, line...)
+		truncated = append(truncated, '\n')
+	}
+	if err := os.WriteFile(files[0], truncated, 0600); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+	if err := logger.VerifyHead(); err == nil {
+		t.Error("expected VerifyHead to detect a truncated log")
+	}
+}
+
+func TestLogVaultID(t *testing.T) {
+	logger := NewLogger(t.TempDir())
+	if err := logger.SetHMACKey([]byte("test-master-key-32-bytes-long!!!")); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+
+	// Records written before the vault had an ID verify alongside later ones
+	if err := logger.LogSuccess(OpSecretGet, SourceCLI, "a"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	logger.SetVaultID("7d1c5a2e-3b4f-4c1d-9e8a-0f6b2d4c8a10")
+	if err := logger.LogSuccess(OpSecretGet, SourceCLI, "b"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	events, err := logger.ListEvents(0, time.Time{})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Org != nil {
+		t.Errorf("expected no org on first event, got %+v", events[0].Org)
+	}
+	if events[1].Org == nil || events[1].Org.VaultID != "7d1c5a2e-3b4f-4c1d-9e8a-0f6b2d4c8a10" {
+		t.Errorf("unexpected org on second event: %+v", events[1].Org)
+	}
+
+	result, err := logger.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !result.Valid {
+		t.Errorf("expected chain to verify, got errors %v", result.Errors)
+	}
+}
@@ -4,7 +4,7 @@ package audit
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
 	"path/filepath"
 	"syscall"
 )
@@ -17,7 +17,7 @@ func (l *Logger) checkDiskSpace() error {
 		parentDir := filepath.Dir(l.path)
 		if err := syscall.Statfs(parentDir, &stat); err != nil {
 			// Log warning but don't block audit operation
-			fmt.Fprintf(os.Stderr, "warning: failed to check disk space for audit: %v\n", err)
+			slog.Warn("audit: failed to check disk space", "err", err)
 			return nil
 		}
 	}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+)
+
+// Host identifies the machine and caller that produced an event, so events
+// written to a shared vault directory (network home, synced folder) can be
+// attributed. Fields that cannot be determined are left empty.
+type Host struct {
+	Hostname      string `json:"hostname,omitempty"`
+	User          string `json:"user,omitempty"`           // OS user name
+	Process       string `json:"process,omitempty"`        // Executable name
+	ParentProcess string `json:"parent_process,omitempty"` // e.g. the shell or AI tool that started secretctl
+}
+
+// CurrentHost returns the identity of the current process.
+func CurrentHost() *Host {
+	h := &Host{}
+	h.Hostname, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		h.User = u.Username
+	}
+	if exe, err := os.Executable(); err == nil {
+		h.Process = filepath.Base(exe)
+	} else if len(os.Args) > 0 {
+		h.Process = filepath.Base(os.Args[0])
+	}
+	h.ParentProcess = processName(os.Getppid())
+	return h
+}
+
+// SetHost sets the host identity recorded in subsequent events. nil stops
+// recording it.
+func (l *Logger) SetHost(h *Host) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.host = h
+}
@@ -0,0 +1,16 @@
+//go:build darwin
+
+package audit
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// processName returns the command name of pid via sysctl.
+func processName(pid int) string {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(kp.Proc.P_comm[:])
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// processName returns the command name of pid from /proc.
+func processName(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package audit
+
+// processName is not implemented on this platform.
+func processName(pid int) string {
+	return ""
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCurrentHost(t *testing.T) {
+	h := CurrentHost()
+	if h.Process == "" {
+		t.Error("expected process name to be set")
+	}
+	if h.Hostname == "" {
+		t.Error("expected hostname to be set")
+	}
+}
+
+func TestLogHostIdentity(t *testing.T) {
+	logger := NewLogger(t.TempDir())
+	if err := logger.SetHMACKey([]byte("test-master-key-32-bytes-long!!!")); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+
+	// Mix records without and with host identity in one chain
+	logger.SetHost(nil)
+	if err := logger.LogSuccess(OpSecretGet, SourceCLI, "a"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	logger.SetHost(&Host{Hostname: "build-01", User: "ci", Process: "secretctl", ParentProcess: "bash"})
+	if err := logger.LogSuccess(OpSecretGet, SourceCLI, "b"); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	events, err := logger.ListEvents(0, time.Time{})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Host != nil {
+		t.Errorf("expected no host on first event, got %+v", events[0].Host)
+	}
+	if events[1].Host == nil || events[1].Host.Hostname != "build-01" || events[1].Host.ParentProcess != "bash" {
+		t.Errorf("unexpected host on second event: %+v", events[1].Host)
+	}
+
+	result, err := logger.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !result.Valid {
+		t.Errorf("expected chain to verify, got errors %v", result.Errors)
+	}
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ProofVersion is the current proof bundle format version.
+const ProofVersion = 1
+
+// ErrEmptyProofRange is returned when no records fall in the requested range.
+var ErrEmptyProofRange = errors.New("audit: no records in range")
+
+// ProofBundle is a self-contained segment of the audit chain that anyone
+// holding the audit HMAC key can verify without access to the vault.
+type ProofBundle struct {
+	Version   int    `json:"v"`
+	CreatedAt string `json:"created_at"`
+	From      int64  `json:"from"` // First sequence number (inclusive)
+	To        int64  `json:"to"`   // Last sequence number (inclusive)
+	// Head is the chain head when the bundle was created, so a verifier can
+	// tell whether the segment ends at the newest record.
+	Head ChainState `json:"head"`
+	// KeyID identifies the HMAC key without revealing it.
+	KeyID  string       `json:"key_id"`
+	Events []AuditEvent `json:"events"`
+	// HMAC binds the metadata above to the segment.
+	HMAC string `json:"hmac"`
+}
+
+// ExportProof builds a proof bundle for sequence numbers from..to (inclusive).
+// A to of 0 means up to the newest record.
+func (l *Logger) ExportProof(from, to int64) (*ProofBundle, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.hmacKeySet {
+		return nil, fmt.Errorf("audit: HMAC key not set")
+	}
+	if from < 1 || (to != 0 && to < from) {
+		return nil, fmt.Errorf("audit: invalid range %d:%d", from, to)
+	}
+
+	head := ChainState{Sequence: l.sequence, PrevHash: l.prevHash}
+	if data, err := os.ReadFile(filepath.Join(l.path, "audit.meta")); err == nil {
+		if err := json.Unmarshal(data, &head); err != nil {
+			return nil, fmt.Errorf("audit: corrupt chain state: %w", err)
+		}
+	}
+	if to == 0 {
+		to = head.Sequence
+	}
+
+	files, err := filepath.Glob(filepath.Join(l.path, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to list log files: %w", err)
+	}
+	sortStrings(files)
+
+	var events []AuditEvent
+	for _, file := range files {
+		fileEvents, err := l.readLogFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("audit: failed to read %s: %w", file, err)
+		}
+		for _, event := range fileEvents {
+			if event.Chain.Sequence >= from && event.Chain.Sequence <= to {
+				events = append(events, event)
+			}
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w %d:%d", ErrEmptyProofRange, from, to)
+	}
+
+	bundle := &ProofBundle{
+		Version:   ProofVersion,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		From:      from,
+		To:        to,
+		Head:      head,
+		KeyID:     keyID(l.hmacKey),
+		Events:    events,
+	}
+	bundle.HMAC = proofHMAC(l.hmacKey, bundle)
+	return bundle, nil
+}
+
+// ExportKey returns a copy of the audit HMAC key, to hand to whoever will
+// verify exported proofs.
+func (l *Logger) ExportKey() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.hmacKeySet {
+		return nil, fmt.Errorf("audit: HMAC key not set")
+	}
+	return append([]byte(nil), l.hmacKey...), nil
+}
+
+// VerifyProof checks a proof bundle with the audit HMAC key: every record's
+// HMAC, the sequence and prev-hash links inside the segment, and the bundle
+// HMAC over the metadata.
+func VerifyProof(bundle *ProofBundle, key []byte) (*VerifyResult, error) {
+	if bundle.Version != ProofVersion {
+		return nil, fmt.Errorf("audit: unsupported proof version %d", bundle.Version)
+	}
+	if bundle.KeyID != keyID(key) {
+		return nil, fmt.Errorf("audit: proof was created with a different key")
+	}
+
+	result := &VerifyResult{Valid: true}
+	fail := func(format string, args ...interface{}) {
+		result.Valid = false
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	if !hmac.Equal([]byte(bundle.HMAC), []byte(proofHMAC(key, bundle))) {
+		fail("bundle HMAC mismatch: metadata or records were modified")
+	}
+
+	verifier := &Logger{hmacKey: key}
+	expectedSeq := bundle.From
+	prevHash := ""
+	for i := range bundle.Events {
+		event := &bundle.Events[i]
+		result.RecordsTotal++
+
+		if event.Chain.Sequence != expectedSeq {
+			fail("sequence gap at record %s: expected %d, got %d", event.ID, expectedSeq, event.Chain.Sequence)
+		}
+		if prevHash != "" && event.Chain.PrevHash != prevHash {
+			fail("chain broken at record %s", event.ID)
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(verifier.buildRecordData(event))
+		if event.Chain.HMAC != hex.EncodeToString(mac.Sum(nil)) {
+			fail("HMAC mismatch at record %s: possible tampering", event.ID)
+		} else {
+			result.RecordsVerified++
+		}
+
+		prevHash = event.Chain.HMAC
+		expectedSeq = event.Chain.Sequence + 1
+	}
+	if expectedSeq != bundle.To+1 {
+		fail("segment ends at sequence %d, expected %d", expectedSeq-1, bundle.To)
+	}
+	if bundle.To == bundle.Head.Sequence && prevHash != bundle.Head.PrevHash {
+		fail("last record does not match the chain head")
+	}
+
+	return result, nil
+}
+
+// keyID returns a short fingerprint of an HMAC key.
+func keyID(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("secretctl-audit-key-id"))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// proofHMAC authenticates the bundle metadata and the record HMACs, which in
+// turn cover each record's content.
+func proofHMAC(key []byte, b *ProofBundle) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "proof|%d|%s|%d|%d|%d|%s|%s|%d",
+		b.Version, b.CreatedAt, b.From, b.To, b.Head.Sequence, b.Head.PrevHash, b.KeyID, len(b.Events))
+	for _, event := range b.Events {
+		fmt.Fprintf(mac, "|%s", event.Chain.HMAC)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
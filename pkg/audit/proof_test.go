@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func newProofTestLogger(t *testing.T, records int) *Logger {
+	t.Helper()
+	logger := NewLogger(t.TempDir())
+	if err := logger.SetHMACKey([]byte("test-master-key-32-bytes-long!!!")); err != nil {
+		t.Fatalf("SetHMACKey failed: %v", err)
+	}
+	for i := 0; i < records; i++ {
+		if err := logger.Log(OpSecretGet, SourceCLI, ResultSuccess, "key", nil, map[string]interface{}{"n": i}); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	return logger
+}
+
+func TestExportProof_Verify(t *testing.T) {
+	logger := newProofTestLogger(t, 10)
+	key, err := logger.ExportKey()
+	if err != nil {
+		t.Fatalf("ExportKey failed: %v", err)
+	}
+
+	bundle, err := logger.ExportProof(3, 7)
+	if err != nil {
+		t.Fatalf("ExportProof failed: %v", err)
+	}
+	if len(bundle.Events) != 5 || bundle.Events[0].Chain.Sequence != 3 {
+		t.Fatalf("unexpected segment: %d events", len(bundle.Events))
+	}
+
+	// Round-trip through JSON as a third party would receive it
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received ProofBundle
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := VerifyProof(&received, key)
+	if err != nil {
+		t.Fatalf("VerifyProof failed: %v", err)
+	}
+	if !result.Valid || result.RecordsVerified != 5 {
+		t.Errorf("expected valid proof of 5 records, got %+v", result)
+	}
+
+	// Open-ended range runs to the chain head
+	bundle, err = logger.ExportProof(8, 0)
+	if err != nil {
+		t.Fatalf("ExportProof failed: %v", err)
+	}
+	if bundle.To != 10 || bundle.Head.Sequence != 10 {
+		t.Errorf("expected segment to end at head 10, got to=%d head=%d", bundle.To, bundle.Head.Sequence)
+	}
+	if result, err := VerifyProof(bundle, key); err != nil || !result.Valid {
+		t.Errorf("expected valid head segment, got %+v, %v", result, err)
+	}
+}
+
+func TestVerifyProof_Tampering(t *testing.T) {
+	logger := newProofTestLogger(t, 5)
+	key, _ := logger.ExportKey()
+
+	tests := []struct {
+		name   string
+		tamper func(b *ProofBundle)
+	}{
+		{"record modified", func(b *ProofBundle) { b.Events[1].Result = ResultDenied }},
+		{"record removed", func(b *ProofBundle) { b.Events = append(b.Events[:1], b.Events[2:]...) }},
+		{"range widened", func(b *ProofBundle) { b.To = 5 }},
+		{"head rewritten", func(b *ProofBundle) { b.Head.Sequence = 99 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := logger.ExportProof(1, 4)
+			if err != nil {
+				t.Fatalf("ExportProof failed: %v", err)
+			}
+			tt.tamper(bundle)
+			result, err := VerifyProof(bundle, key)
+			if err != nil {
+				t.Fatalf("VerifyProof failed: %v", err)
+			}
+			if result.Valid {
+				t.Error("expected tampered proof to fail verification")
+			}
+		})
+	}
+
+	bundle, _ := logger.ExportProof(1, 4)
+	if _, err := VerifyProof(bundle, []byte("wrong-key")); err == nil {
+		t.Error("expected error for wrong key")
+	}
+}
+
+func TestExportProof_InvalidRange(t *testing.T) {
+	logger := newProofTestLogger(t, 3)
+
+	if _, err := logger.ExportProof(10, 20); !errors.Is(err, ErrEmptyProofRange) {
+		t.Errorf("expected ErrEmptyProofRange, got %v", err)
+	}
+	if _, err := logger.ExportProof(3, 2); err == nil {
+		t.Error("expected error for reversed range")
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !wasm
+
 // Package backup provides vault backup and restore functionality.
 //
 // Features:
@@ -12,14 +14,19 @@
 //   - Outer HMAC covers header + ciphertext for tamper detection
 //   - File permissions: 0600 for files, 0700 for directories
 //   - Sensitive data cleared from memory with SecureWipe
+//
+// Everything that needs the vault (and so SQLite) or the file system is
+// excluded from GOARCH=wasm builds. What remains, the file format, key
+// derivation and Open/VerifyData, can run in a browser to verify or
+// decrypt a backup.
 package backup
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/forest6511/secretctl/pkg/crypto"
@@ -48,6 +55,8 @@ type BackupOptions struct {
 	Password []byte
 	// KeyFile path for encryption key (overrides Password).
 	KeyFile string
+	// ToolVersion is the secretctl version recorded in the header.
+	ToolVersion string
 }
 
 // RestoreOptions configures the restore operation.
@@ -66,6 +75,15 @@ type RestoreOptions struct {
 	Password []byte
 	// KeyFile path for decryption key (overrides Password).
 	KeyFile string
+	// Current is the unlocked local vault. When set, DryRun compares it
+	// with the backup key by key and fills RestoreResult.Diff.
+	Current *vault.Vault
+	// VaultPassword is the master password of the backed-up vault, used to
+	// open it for the comparison (defaults to Password).
+	VaultPassword []byte
+	// ToolVersion is the running secretctl version, compared with the one
+	// that wrote the backup (see CheckOrigin).
+	ToolVersion string
 }
 
 // RestoreResult contains the result of a restore operation.
@@ -78,22 +96,11 @@ type RestoreResult struct {
 	AuditRestored bool
 	// DryRun indicates this was a dry run.
 	DryRun bool
-}
-
-// VerifyResult contains the result of a verify operation.
-type VerifyResult struct {
-	// Valid indicates the backup passed all integrity checks.
-	Valid bool
-	// Version is the backup format version.
-	Version int
-	// CreatedAt is when the backup was created.
-	CreatedAt time.Time
-	// SecretCount is the number of secrets in the backup.
-	SecretCount int
-	// IncludesAudit indicates if audit logs are included.
-	IncludesAudit bool
-	// Error is set if verification failed.
-	Error string
+	// Diff lists every key of the backup and the local vault with how they
+	// compare, sorted by key. It is only set by a dry run with Current.
+	Diff []KeyDiff
+	// Warnings are the results of CheckOrigin for the backup.
+	Warnings []string
 }
 
 // Backup creates an encrypted backup of the vault.
@@ -102,56 +109,12 @@ func Backup(v *vault.Vault, opts BackupOptions) error {
 		return fmt.Errorf("output writer is required")
 	}
 
-	// Determine encryption key
-	var encKey, macKey []byte
-	var kdfParams *KDFParams
-	var encMode EncryptionMode
-	var err error
-
-	if opts.KeyFile != "" {
-		// Use key file
-		encKey, err = ReadKeyFile(opts.KeyFile)
-		if err != nil {
-			return err
-		}
-		defer crypto.SecureWipe(encKey)
-
-		// Derive MAC key from encryption key
-		macKey, err = deriveHKDF(encKey, []byte(hkdfInfoMAC))
-		if err != nil {
-			return fmt.Errorf("failed to derive MAC key: %w", err)
-		}
-		defer crypto.SecureWipe(macKey)
-
-		encMode = EncryptionModeKey
-	} else {
-		// Use password (master or custom)
-		password := opts.Password
-		if password == nil {
-			return fmt.Errorf("password or key file is required")
-		}
-
-		// Generate fresh salt for backup
-		salt, err := GenerateSalt()
-		if err != nil {
-			return err
-		}
-
-		encKey, macKey, err = DeriveBackupKeys(password, salt)
-		if err != nil {
-			return err
-		}
-		defer crypto.SecureWipe(encKey)
-		defer crypto.SecureWipe(macKey)
-
-		kdfParams = &KDFParams{
-			Salt:        salt,
-			Memory:      crypto.Argon2Memory,
-			Iterations:  crypto.Argon2Time,
-			Parallelism: crypto.Argon2Threads,
-		}
-		encMode = EncryptionModeMaster
+	encKey, macKey, kdfParams, encMode, err := backupKeys(opts.Password, opts.KeyFile)
+	if err != nil {
+		return err
 	}
+	defer crypto.SecureWipe(encKey)
+	defer crypto.SecureWipe(macKey)
 
 	// Collect vault data
 	payload, secretCount, err := collectVaultData(v, opts.IncludeAudit)
@@ -159,60 +122,23 @@ func Backup(v *vault.Vault, opts BackupOptions) error {
 		return fmt.Errorf("failed to collect vault data: %w", err)
 	}
 
-	// Encode payload
-	payloadBytes, err := EncodePayload(payload)
-	if err != nil {
-		return err
-	}
-	defer crypto.SecureWipe(payloadBytes)
-
-	// Encrypt payload
-	ciphertext, err := EncryptPayload(payloadBytes, encKey)
-	if err != nil {
-		return fmt.Errorf("failed to encrypt payload: %w", err)
-	}
-
-	// Create header
 	header := &Header{
-		Version:        FormatVersion,
 		CreatedAt:      time.Now().UTC(),
 		VaultVersion:   1, // TODO: get from vault metadata
 		EncryptionMode: encMode,
 		KDFParams:      kdfParams,
 		IncludesAudit:  opts.IncludeAudit,
 		SecretCount:    secretCount,
-		ChecksumAlgo:   "sha256",
+		ToolVersion:    opts.ToolVersion,
 	}
-
-	// Write to buffer first (for HMAC calculation)
-	var buf bytes.Buffer
-
-	// Write header
-	if err := WriteHeader(&buf, header); err != nil {
-		return err
+	header.Hostname, _ = os.Hostname()
+	if header.VaultID, err = v.ID(); err != nil {
+		return fmt.Errorf("failed to read vault ID: %w", err)
 	}
-
-	// Write ciphertext length and data
-	ciphertextLen := uint32(len(ciphertext))
-	if err := writeUint32(&buf, ciphertextLen); err != nil {
-		return err
-	}
-	if _, err := buf.Write(ciphertext); err != nil {
-		return fmt.Errorf("failed to write ciphertext: %w", err)
-	}
-
-	// Compute HMAC over header + ciphertext
-	hmacValue := ComputeHMAC(buf.Bytes(), macKey)
-
-	// Write everything to output
-	if _, err := opts.Output.Write(buf.Bytes()); err != nil {
-		return fmt.Errorf("failed to write backup: %w", err)
-	}
-	if _, err := opts.Output.Write(hmacValue); err != nil {
-		return fmt.Errorf("failed to write HMAC: %w", err)
+	if header.Features, err = v.Features(); err != nil {
+		return fmt.Errorf("failed to read vault features: %w", err)
 	}
-
-	return nil
+	return writeBackup(opts.Output, header, payload, encKey, macKey)
 }
 
 // Restore restores a vault from an encrypted backup.
@@ -238,13 +164,26 @@ func Restore(backupPath string, opts RestoreOptions) (*RestoreResult, error) {
 		}, nil
 	}
 
+	vaultPath := opts.VaultPath
+	if vaultPath == "" {
+		vaultPath = DefaultVaultPath()
+	}
+	warnings := CheckOrigin(header, opts.ToolVersion, vaultPath)
+
 	if opts.DryRun {
-		return &RestoreResult{
+		result := &RestoreResult{
 			SecretsRestored: header.SecretCount,
 			SecretsSkipped:  0,
 			AuditRestored:   header.IncludesAudit && opts.WithAudit,
 			DryRun:          true,
-		}, nil
+			Warnings:        warnings,
+		}
+		if opts.Current != nil {
+			if result.Diff, err = previewRestore(opts, payload); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
 	}
 
 	// Perform actual restore
@@ -252,6 +191,7 @@ func Restore(backupPath string, opts RestoreOptions) (*RestoreResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	result.Warnings = warnings
 
 	return result, nil
 }
@@ -262,19 +202,12 @@ func Verify(backupPath string, password []byte, keyFile string) (*VerifyResult,
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: err.Error()}, nil
 	}
-
-	header, _, err := verifyAndDecrypt(data, password, keyFile)
+	key, err := readOptionalKeyFile(keyFile)
 	if err != nil {
 		return &VerifyResult{Valid: false, Error: err.Error()}, nil
 	}
-
-	return &VerifyResult{
-		Valid:         true,
-		Version:       header.Version,
-		CreatedAt:     header.CreatedAt,
-		SecretCount:   header.SecretCount,
-		IncludesAudit: header.IncludesAudit,
-	}, nil
+	defer crypto.SecureWipe(key)
+	return VerifyData(data, password, key), nil
 }
 
 // collectVaultData collects all vault data for backup.
@@ -302,6 +235,23 @@ func collectVaultData(v *vault.Vault, includeAudit bool) (*Payload, int, error)
 		return nil, 0, fmt.Errorf("failed to read vault.db: %w", err)
 	}
 
+	// Read namespace shards
+	shardPaths, err := filepath.Glob(filepath.Join(vaultPath, vault.ShardsDirName, "*.db"))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list shards: %w", err)
+	}
+	var shards map[string][]byte
+	for _, path := range shardPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read shard: %w", err)
+		}
+		if shards == nil {
+			shards = make(map[string][]byte, len(shardPaths))
+		}
+		shards[strings.TrimSuffix(filepath.Base(path), ".db")] = data
+	}
+
 	// Get secret count
 	secrets, err := v.ListSecrets()
 	if err != nil {
@@ -312,6 +262,7 @@ func collectVaultData(v *vault.Vault, includeAudit bool) (*Payload, int, error)
 		VaultSalt: vaultSalt,
 		VaultMeta: vaultMeta,
 		VaultDB:   vaultDB,
+		Shards:    shards,
 	}
 
 	// Read audit log if requested
@@ -327,96 +278,14 @@ func collectVaultData(v *vault.Vault, includeAudit bool) (*Payload, int, error)
 	return payload, len(secrets), nil
 }
 
-// verifyAndDecrypt verifies the backup integrity and decrypts the payload.
+// verifyAndDecrypt reads keyFile, if given, and opens the backup in data.
 func verifyAndDecrypt(data []byte, password []byte, keyFile string) (*Header, *Payload, error) {
-	if len(data) < 8+4+HMACLength {
-		return nil, nil, ErrInvalidMagic
-	}
-
-	// Read header
-	reader := bytes.NewReader(data)
-	header, err := ReadHeader(reader)
+	key, err := readOptionalKeyFile(keyFile)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	// Get current position (after header)
-	headerEnd := len(data) - reader.Len()
-
-	// Read ciphertext length
-	var ciphertextLen uint32
-	if err := readUint32(reader, &ciphertextLen); err != nil {
-		return nil, nil, fmt.Errorf("failed to read ciphertext length: %w", err)
-	}
-
-	// Verify we have enough data
-	remaining := reader.Len()
-	if remaining < int(ciphertextLen)+HMACLength {
-		return nil, nil, fmt.Errorf("backup file truncated")
-	}
-
-	// Read ciphertext
-	ciphertext := make([]byte, ciphertextLen)
-	if _, err := io.ReadFull(reader, ciphertext); err != nil {
-		return nil, nil, fmt.Errorf("failed to read ciphertext: %w", err)
-	}
-
-	// Read HMAC
-	storedHMAC := make([]byte, HMACLength)
-	if _, err := io.ReadFull(reader, storedHMAC); err != nil {
-		return nil, nil, fmt.Errorf("failed to read HMAC: %w", err)
-	}
-
-	// Derive keys
-	var encKey, macKey []byte
-
-	switch {
-	case keyFile != "":
-		encKey, err = ReadKeyFile(keyFile)
-		if err != nil {
-			return nil, nil, err
-		}
-		defer crypto.SecureWipe(encKey)
-
-		macKey, err = deriveHKDF(encKey, []byte(hkdfInfoMAC))
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to derive MAC key: %w", err)
-		}
-		defer crypto.SecureWipe(macKey)
-	case header.EncryptionMode == EncryptionModeMaster && header.KDFParams != nil:
-		if password == nil {
-			return nil, nil, ErrEmptyPassword
-		}
-		encKey, macKey, err = DeriveBackupKeys(password, header.KDFParams.Salt)
-		if err != nil {
-			return nil, nil, err
-		}
-		defer crypto.SecureWipe(encKey)
-		defer crypto.SecureWipe(macKey)
-	default:
-		return nil, nil, fmt.Errorf("cannot determine decryption key")
-	}
-
-	// Verify HMAC (header + ciphertext length + ciphertext)
-	dataToVerify := data[:headerEnd+4+int(ciphertextLen)]
-	if !VerifyHMAC(dataToVerify, storedHMAC, macKey) {
-		return nil, nil, ErrIntegrityFailed
-	}
-
-	// Decrypt payload
-	plaintext, err := DecryptPayload(ciphertext, encKey)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer crypto.SecureWipe(plaintext)
-
-	// Decode payload
-	payload, err := DecodePayload(plaintext)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return header, payload, nil
+	defer crypto.SecureWipe(key)
+	return Open(data, password, key)
 }
 
 // DefaultVaultPath returns the default vault path (~/.secretctl).
@@ -448,24 +317,10 @@ func performRestore(opts RestoreOptions, header *Header, payload *Payload) (*Res
 	}
 
 	// Write vault files to temp directory
-	if err := os.WriteFile(filepath.Join(tempDir, "vault.salt"), payload.VaultSalt, 0600); err != nil {
-		return nil, fmt.Errorf("failed to write vault.salt: %w", err)
-	}
-	if err := os.WriteFile(filepath.Join(tempDir, "vault.meta"), payload.VaultMeta, 0600); err != nil {
-		return nil, fmt.Errorf("failed to write vault.meta: %w", err)
-	}
-	if err := os.WriteFile(filepath.Join(tempDir, "vault.db"), payload.VaultDB, 0600); err != nil {
-		return nil, fmt.Errorf("failed to write vault.db: %w", err)
-	}
-
-	// Write audit log if included and requested
-	auditRestored := false
-	if opts.WithAudit && len(payload.AuditLog) > 0 {
-		if err := os.WriteFile(filepath.Join(tempDir, "audit.jsonl"), payload.AuditLog, 0600); err != nil {
-			return nil, fmt.Errorf("failed to write audit.jsonl: %w", err)
-		}
-		auditRestored = true
+	if err := writeVaultFiles(tempDir, payload, opts.WithAudit); err != nil {
+		return nil, err
 	}
+	auditRestored := opts.WithAudit && len(payload.AuditLog) > 0
 
 	// Check if vault already exists
 	if _, err := os.Stat(vaultPath); err == nil {
@@ -510,6 +365,42 @@ func performRestore(opts RestoreOptions, header *Header, payload *Payload) (*Res
 	}, nil
 }
 
+// writeVaultFiles writes the vault files of payload into dir, including the
+// audit log if withAudit is set and the backup has one.
+func writeVaultFiles(dir string, payload *Payload, withAudit bool) error {
+	if err := os.WriteFile(filepath.Join(dir, "vault.salt"), payload.VaultSalt, 0600); err != nil {
+		return fmt.Errorf("failed to write vault.salt: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vault.meta"), payload.VaultMeta, 0600); err != nil {
+		return fmt.Errorf("failed to write vault.meta: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vault.db"), payload.VaultDB, 0600); err != nil {
+		return fmt.Errorf("failed to write vault.db: %w", err)
+	}
+	if len(payload.Shards) > 0 {
+		if err := os.MkdirAll(filepath.Join(dir, vault.ShardsDirName), 0700); err != nil {
+			return fmt.Errorf("failed to create shards directory: %w", err)
+		}
+	}
+	for ns, data := range payload.Shards {
+		if ns == "" || strings.ContainsAny(ns, `/\.`) {
+			return fmt.Errorf("invalid shard name in backup: %q", ns)
+		}
+		if err := os.WriteFile(filepath.Join(dir, vault.ShardsDirName, ns+".db"), data, 0600); err != nil {
+			return fmt.Errorf("failed to write shard %s: %w", ns, err)
+		}
+	}
+
+	// Write audit log if included and requested
+	if withAudit && len(payload.AuditLog) > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "audit.jsonl"), payload.AuditLog, 0600); err != nil {
+			return fmt.Errorf("failed to write audit.jsonl: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // copyDir copies a directory recursively.
 func copyDir(src, dst string) error {
 	if err := os.MkdirAll(dst, 0700); err != nil {
@@ -542,24 +433,3 @@ func copyDir(src, dst string) error {
 
 	return nil
 }
-
-// writeUint32 writes a uint32 in big-endian format.
-func writeUint32(w io.Writer, v uint32) error {
-	buf := make([]byte, 4)
-	buf[0] = byte(v >> 24)
-	buf[1] = byte(v >> 16)
-	buf[2] = byte(v >> 8)
-	buf[3] = byte(v)
-	_, err := w.Write(buf)
-	return err
-}
-
-// readUint32 reads a uint32 in big-endian format.
-func readUint32(r io.Reader, v *uint32) error {
-	buf := make([]byte, 4)
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return err
-	}
-	*v = uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
-	return nil
-}
@@ -1,11 +1,15 @@
+//go:build !wasm
+
 package backup
 
 import (
 	"bytes"
 	cryptorand "crypto/rand"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -824,6 +828,51 @@ func TestBackup_NoPasswordOrKeyFile(t *testing.T) {
 	}
 }
 
+func TestBackup_WeakPassword(t *testing.T) {
+	tempDir := t.TempDir()
+	vaultDir := filepath.Join(tempDir, "vault")
+
+	password := "test-password"
+	v := vault.New(vaultDir)
+	v.Init(password)
+	v.Unlock(password)
+	defer v.Lock()
+
+	var buf bytes.Buffer
+	err := Backup(v, BackupOptions{Output: &buf, Password: []byte("short")})
+	if !errors.Is(err, ErrWeakPassword) || !errors.Is(err, vault.ErrPasswordTooShort) {
+		t.Errorf("expected ErrWeakPassword wrapping ErrPasswordTooShort, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("nothing should be written for a rejected password")
+	}
+
+	err = ValidatePassword(bytes.Repeat([]byte("a"), vault.MaxPasswordLength+1))
+	if !errors.Is(err, vault.ErrPasswordTooLong) {
+		t.Errorf("expected ErrPasswordTooLong, got %v", err)
+	}
+	if err := ValidatePassword([]byte(password)); err != nil {
+		t.Errorf("ValidatePassword(%q) = %v", password, err)
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	p1, err := GeneratePassphrase()
+	if err != nil {
+		t.Fatalf("GeneratePassphrase failed: %v", err)
+	}
+	p2, _ := GeneratePassphrase()
+	if p1 == p2 {
+		t.Error("two generated passphrases are equal")
+	}
+	if words := strings.Split(p1, "-"); len(words) < PassphraseWords {
+		t.Errorf("passphrase %q has %d words, want %d", p1, len(words), PassphraseWords)
+	}
+	if err := ValidatePassword([]byte(p1)); err != nil {
+		t.Errorf("generated passphrase fails validation: %v", err)
+	}
+}
+
 func TestWriteReadUint32(t *testing.T) {
 	tests := []uint32{0, 1, 255, 65535, 4294967295}
 
@@ -1245,3 +1294,130 @@ func TestReadHeader_ValidMagicButTruncated(t *testing.T) {
 		t.Error("Expected error for truncated header content")
 	}
 }
+
+func TestBackupRestore_KeepsCipher(t *testing.T) {
+	tempDir := t.TempDir()
+	vaultDir := filepath.Join(tempDir, "vault")
+	restoreDir := filepath.Join(tempDir, "restored")
+	backupFile := filepath.Join(tempDir, "backup.enc")
+
+	password := "test-password-123"
+	v := vault.New(vaultDir)
+	if err := v.InitWithOptions(password, vault.InitOptions{Cipher: vault.CipherXChaCha20Poly1305}); err != nil {
+		t.Fatalf("Failed to init vault: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Failed to unlock vault: %v", err)
+	}
+	if err := v.SetSecret("api/key", &vault.SecretEntry{Value: []byte("value1")}); err != nil {
+		t.Fatalf("Failed to set secret: %v", err)
+	}
+
+	out, err := os.Create(backupFile)
+	if err != nil {
+		t.Fatalf("Failed to create backup file: %v", err)
+	}
+	err = Backup(v, BackupOptions{Output: out, Password: []byte(password)})
+	out.Close()
+	v.Lock()
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	if _, err := Restore(backupFile, RestoreOptions{VaultPath: restoreDir, OnConflict: ConflictError, Password: []byte(password)}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	restored := vault.New(restoreDir)
+	if features, err := restored.Features(); err != nil || features[vault.FeatureCipher] != vault.CipherXChaCha20Poly1305 {
+		t.Errorf("restored features = %v, %v", features, err)
+	}
+	if err := restored.Unlock(password); err != nil {
+		t.Fatalf("Failed to unlock restored vault: %v", err)
+	}
+	defer restored.Lock()
+	if entry, err := restored.GetSecret("api/key"); err != nil || string(entry.Value) != "value1" {
+		t.Errorf("GetSecret = %v, %v", entry, err)
+	}
+}
+
+func TestBackupOrigin(t *testing.T) {
+	tempDir := t.TempDir()
+	vaultDir := filepath.Join(tempDir, "vault")
+	otherDir := filepath.Join(tempDir, "other")
+	backupFile := filepath.Join(tempDir, "backup.enc")
+
+	password := "test-password"
+	v := vault.New(vaultDir)
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	id, err := v.ID()
+	if err != nil || id == "" {
+		t.Fatalf("ID = %q, %v", id, err)
+	}
+	output, _ := os.Create(backupFile)
+	err = Backup(v, BackupOptions{Output: output, Password: []byte(password), ToolVersion: "1.5.0"})
+	output.Close()
+	v.Lock()
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	result, err := Verify(backupFile, []byte(password), "")
+	if err != nil || !result.Valid {
+		t.Fatalf("Verify failed: %v %+v", err, result)
+	}
+	hostname, _ := os.Hostname()
+	if result.ToolVersion != "1.5.0" || result.Hostname != hostname || result.VaultID != id {
+		t.Errorf("origin = %q %q %q", result.ToolVersion, result.Hostname, result.VaultID)
+	}
+
+	// Restoring over the same vault with the same version is quiet
+	restored, err := Restore(backupFile, RestoreOptions{
+		VaultPath: vaultDir, DryRun: true, Password: []byte(password), ToolVersion: "1.5.0",
+	})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(restored.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", restored.Warnings)
+	}
+
+	// An older binary is warned of the downgrade
+	restored, err = Restore(backupFile, RestoreOptions{
+		VaultPath: vaultDir, DryRun: true, Password: []byte(password), ToolVersion: "1.4.2",
+	})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(restored.Warnings) != 1 || !strings.Contains(restored.Warnings[0], "newer") {
+		t.Errorf("downgrade warnings = %v", restored.Warnings)
+	}
+
+	// And so is restoring over another vault, or one using unknown features
+	other := vault.New(otherDir)
+	if err := other.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	f, _ := os.Open(backupFile)
+	header, err := ReadHeader(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("ReadHeader failed: %v", err)
+	}
+	if warnings := CheckOrigin(header, "dev", otherDir); len(warnings) != 1 || !strings.Contains(warnings[0], id) {
+		t.Errorf("foreign vault warnings = %v", warnings)
+	}
+	header.Features["teleport"] = "on"
+	if warnings := CheckOrigin(header, "dev", vaultDir); len(warnings) != 1 || !strings.Contains(warnings[0], "teleport") {
+		t.Errorf("feature warnings = %v", warnings)
+	}
+
+	// Backups that do not record their origin produce no warnings
+	if warnings := CheckOrigin(&Header{}, "1.0.0", otherDir); len(warnings) != 0 {
+		t.Errorf("warnings for a header without origin: %v", warnings)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// ConvertOptions configures the convert operation.
+type ConvertOptions struct {
+	// Output is the destination writer for the converted backup.
+	Output io.Writer
+	// Password for decrypting the source backup.
+	Password []byte
+	// KeyFile path for decrypting the source backup (overrides Password).
+	KeyFile string
+	// NewPassword for encrypting the converted backup. If both NewPassword
+	// and NewKeyFile are empty, the source credentials are reused.
+	NewPassword []byte
+	// NewKeyFile path for encrypting the converted backup (overrides NewPassword).
+	NewKeyFile string
+}
+
+// Convert reads a backup in any supported format version and rewrites it
+// in the current one. The vault contents, creation time and secret count
+// are carried over unchanged; the encryption is redone with a fresh salt
+// and nonce. It returns the header of the converted backup.
+func Convert(backupPath string, opts ConvertOptions) (*Header, error) {
+	if opts.Output == nil {
+		return nil, fmt.Errorf("output writer is required")
+	}
+
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	src, payload, err := verifyAndDecrypt(data, opts.Password, opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	password, keyFile := opts.NewPassword, opts.NewKeyFile
+	if password == nil && keyFile == "" {
+		password, keyFile = opts.Password, opts.KeyFile
+	}
+	encKey, macKey, kdfParams, encMode, err := backupKeys(password, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(encKey)
+	defer crypto.SecureWipe(macKey)
+
+	header := &Header{
+		CreatedAt:      src.CreatedAt,
+		VaultVersion:   src.VaultVersion,
+		EncryptionMode: encMode,
+		KDFParams:      kdfParams,
+		IncludesAudit:  src.IncludesAudit,
+		SecretCount:    src.SecretCount,
+	}
+	if err := writeBackup(opts.Output, header, payload, encKey, macKey); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
@@ -0,0 +1,147 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// v1 fixture written by the version 1 writer with testdata/v1.key. It must
+// never be regenerated: it pins the format that released versions wrote.
+const (
+	v1Fixture    = "testdata/v1-keyfile.enc"
+	v1FixtureKey = "testdata/v1.key"
+)
+
+func TestFormatV1_ByteLayout(t *testing.T) {
+	data, err := os.ReadFile(v1Fixture)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	if !bytes.Equal(data[:8], []byte("SCTL_BKP")) {
+		t.Fatalf("magic = %q", data[:8])
+	}
+	headerLen := int(binary.BigEndian.Uint32(data[8:12]))
+	headerJSON := data[12 : 12+headerLen]
+	want := `{"version":1,"created_at":"2025-01-02T03:04:05Z","vault_version":1,"encryption_mode":"key",` +
+		`"includes_audit":true,"secret_count":2,"checksum_algorithm":"sha256"}`
+	if string(headerJSON) != want {
+		t.Errorf("header JSON =\n%s\nwant\n%s", headerJSON, want)
+	}
+
+	rest := data[12+headerLen:]
+	ciphertextLen := int(binary.BigEndian.Uint32(rest[:4]))
+	if got := 4 + ciphertextLen + HMACLength; got != len(rest) {
+		t.Fatalf("body is %d bytes, layout accounts for %d", len(rest), got)
+	}
+
+	key, err := ReadKeyFile(v1FixtureKey)
+	if err != nil {
+		t.Fatalf("ReadKeyFile failed: %v", err)
+	}
+	macKey, err := deriveHKDF(key, []byte(hkdfInfoMAC))
+	if err != nil {
+		t.Fatalf("deriveHKDF failed: %v", err)
+	}
+	macStart := len(data) - HMACLength
+	if !VerifyHMAC(data[:macStart], data[macStart:], macKey) {
+		t.Error("trailing HMAC does not cover header and ciphertext")
+	}
+
+	plaintext, err := DecryptPayload(rest[4:4+ciphertextLen], key)
+	if err != nil {
+		t.Fatalf("DecryptPayload failed: %v", err)
+	}
+	var fields map[string][]byte
+	if err := json.Unmarshal(plaintext, &fields); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	for name, value := range map[string]string{
+		"vault_salt": "v1-vault-salt",
+		"vault_meta": `{"version":1}`,
+		"vault_db":   "v1-vault-db",
+		"audit_log":  "{\"op\":\"secret.set\"}\n",
+	} {
+		if string(fields[name]) != value {
+			t.Errorf("payload %s = %q, want %q", name, fields[name], value)
+		}
+	}
+}
+
+func TestFormatV1_Readable(t *testing.T) {
+	result, err := Verify(v1Fixture, nil, v1FixtureKey)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("v1 backup no longer verifies: %s", result.Error)
+	}
+	if result.Version != 1 || result.SecretCount != 2 || !result.IncludesAudit {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tempDir := t.TempDir()
+	newKey := filepath.Join(tempDir, "new.key")
+	if err := GenerateKeyFile(newKey); err != nil {
+		t.Fatalf("GenerateKeyFile failed: %v", err)
+	}
+
+	// Same credentials
+	var out bytes.Buffer
+	header, err := Convert(v1Fixture, ConvertOptions{Output: &out, KeyFile: v1FixtureKey})
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if header.Version != FormatVersion || header.EncryptionMode != EncryptionModeKey {
+		t.Errorf("unexpected header: %+v", header)
+	}
+	if !header.CreatedAt.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) || header.SecretCount != 2 {
+		t.Errorf("source metadata not carried over: %+v", header)
+	}
+	_, payload, err := verifyAndDecrypt(out.Bytes(), nil, v1FixtureKey)
+	if err != nil {
+		t.Fatalf("converted backup does not verify: %v", err)
+	}
+	if string(payload.VaultDB) != "v1-vault-db" || string(payload.VaultSalt) != "v1-vault-salt" {
+		t.Errorf("payload changed: %+v", payload)
+	}
+
+	// New key file
+	out.Reset()
+	if _, err := Convert(v1Fixture, ConvertOptions{Output: &out, KeyFile: v1FixtureKey, NewKeyFile: newKey}); err != nil {
+		t.Fatalf("Convert with new key failed: %v", err)
+	}
+	if _, _, err := verifyAndDecrypt(out.Bytes(), nil, v1FixtureKey); err == nil {
+		t.Error("old key should not open the converted backup")
+	}
+	if _, _, err := verifyAndDecrypt(out.Bytes(), nil, newKey); err != nil {
+		t.Errorf("new key should open the converted backup: %v", err)
+	}
+
+	// Wrong source key
+	if _, err := Convert(v1Fixture, ConvertOptions{Output: &out, KeyFile: newKey}); !errors.Is(err, ErrIntegrityFailed) {
+		t.Errorf("Convert with wrong key = %v, want ErrIntegrityFailed", err)
+	}
+}
+
+func TestVerifyAndDecrypt_UnknownVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHeader(&buf, &Header{Version: 0}); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	buf.Write(make([]byte, 4+HMACLength))
+
+	if _, _, err := verifyAndDecrypt(buf.Bytes(), nil, v1FixtureKey); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
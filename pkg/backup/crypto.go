@@ -6,11 +6,11 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-	"os"
 
 	"golang.org/x/crypto/hkdf"
 
 	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/generate"
 )
 
 const (
@@ -22,6 +22,9 @@ const (
 
 	// KeyLength is the length of encryption keys in bytes (256 bits).
 	KeyLength = 32
+
+	// PassphraseWords is the number of words in a generated passphrase.
+	PassphraseWords = 6
 )
 
 // HKDF info strings for key derivation.
@@ -39,6 +42,12 @@ func GenerateSalt() ([]byte, error) {
 	return salt, nil
 }
 
+// GeneratePassphrase returns a random passphrase of PassphraseWords words
+// from the EFF large diceware list, separated by hyphens (about 77 bits).
+func GeneratePassphrase() (string, error) {
+	return generate.Passphrase(generate.PassphraseOptions{Words: PassphraseWords})
+}
+
 // DeriveBackupKeys derives encryption and MAC keys from a password and salt.
 func DeriveBackupKeys(password, salt []byte) (encKey, macKey []byte, err error) {
 	if len(password) == 0 {
@@ -115,34 +124,3 @@ func VerifyHMAC(data, expectedMAC, key []byte) bool {
 	actualMAC := ComputeHMAC(data, key)
 	return hmac.Equal(actualMAC, expectedMAC)
 }
-
-// ReadKeyFile reads a 32-byte encryption key from a file.
-func ReadKeyFile(path string) ([]byte, error) {
-	key, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read key file: %w", err)
-	}
-
-	if len(key) != KeyLength {
-		crypto.SecureWipe(key)
-		return nil, ErrInvalidKeyFile
-	}
-
-	return key, nil
-}
-
-// GenerateKeyFile generates a random 32-byte key and writes it to a file.
-func GenerateKeyFile(path string) error {
-	key := make([]byte, KeyLength)
-	if _, err := rand.Read(key); err != nil {
-		return fmt.Errorf("failed to generate key: %w", err)
-	}
-	defer crypto.SecureWipe(key)
-
-	// Write with secure permissions (0600)
-	if err := os.WriteFile(path, key, 0600); err != nil {
-		return fmt.Errorf("failed to write key file: %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,161 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// KeyStatus classifies a key in a restore preview.
+type KeyStatus string
+
+const (
+	// KeyNew is only in the backup.
+	KeyNew KeyStatus = "new"
+	// KeyIdentical has the same value in the backup and the local vault.
+	KeyIdentical KeyStatus = "identical"
+	// KeyLocalNewer differs, and the local copy was updated more recently.
+	KeyLocalNewer KeyStatus = "local-newer"
+	// KeyBackupNewer differs, and the backup copy was updated more recently.
+	KeyBackupNewer KeyStatus = "backup-newer"
+	// KeyLocalOnly is only in the local vault and is lost on overwrite.
+	KeyLocalOnly KeyStatus = "local-only"
+)
+
+// IsConflict reports whether the key differs between backup and vault.
+func (s KeyStatus) IsConflict() bool {
+	return s == KeyLocalNewer || s == KeyBackupNewer
+}
+
+// KeyDiff describes one key in a restore preview.
+type KeyDiff struct {
+	Key             string     `json:"key"`
+	Status          KeyStatus  `json:"status"`
+	LocalUpdatedAt  *time.Time `json:"local_updated_at,omitempty"`
+	BackupUpdatedAt *time.Time `json:"backup_updated_at,omitempty"`
+}
+
+// previewRestore unlocks the vault inside payload in a temporary directory
+// and compares it with the local vault key by key.
+func previewRestore(opts RestoreOptions, payload *Payload) ([]KeyDiff, error) {
+	password := opts.VaultPassword
+	if password == nil {
+		password = opts.Password
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("the master password of the backed-up vault is required to compare keys")
+	}
+
+	tempDir, err := os.MkdirTemp("", "secretctl-preview-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if err := writeVaultFiles(tempDir, payload, false); err != nil {
+		return nil, err
+	}
+
+	restored := vault.New(tempDir)
+	if err := restored.Unlock(string(password)); err != nil {
+		return nil, fmt.Errorf("failed to open backed-up vault: %w", err)
+	}
+	defer restored.Lock()
+
+	return diffVaults(opts.Current, restored)
+}
+
+// diffVaults compares the secrets of local and restored. Values are
+// compared by checksum and never leave this function.
+func diffVaults(local, restored *vault.Vault) ([]KeyDiff, error) {
+	localSums, err := secretChecksums(local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local vault: %w", err)
+	}
+	backupSums, err := secretChecksums(restored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backed-up vault: %w", err)
+	}
+	return diffChecksums(localSums, backupSums), nil
+}
+
+// diffChecksums classifies every key of localSums and backupSums. A key
+// whose content differs counts as backup-newer unless the local copy was
+// updated strictly later.
+func diffChecksums(localSums, backupSums map[string]secretChecksum) []KeyDiff {
+	var diffs []KeyDiff
+	for key, b := range backupSums {
+		d := KeyDiff{Key: key, BackupUpdatedAt: &b.updatedAt}
+		l, ok := localSums[key]
+		switch {
+		case !ok:
+			d.Status = KeyNew
+		case bytes.Equal(l.sum, b.sum):
+			d.Status = KeyIdentical
+			d.LocalUpdatedAt = &l.updatedAt
+		case l.updatedAt.After(b.updatedAt):
+			d.Status = KeyLocalNewer
+			d.LocalUpdatedAt = &l.updatedAt
+		default:
+			d.Status = KeyBackupNewer
+			d.LocalUpdatedAt = &l.updatedAt
+		}
+		diffs = append(diffs, d)
+	}
+	for key, l := range localSums {
+		if _, ok := backupSums[key]; !ok {
+			diffs = append(diffs, KeyDiff{Key: key, Status: KeyLocalOnly, LocalUpdatedAt: &l.updatedAt})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+// secretChecksum is the content checksum and update time of a secret.
+type secretChecksum struct {
+	sum       []byte
+	updatedAt time.Time
+}
+
+// secretChecksums returns the checksum of every secret in v, covering its
+// value, fields, bindings and metadata.
+func secretChecksums(v *vault.Vault) (map[string]secretChecksum, error) {
+	keys, err := v.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]secretChecksum, len(keys))
+	for _, key := range keys {
+		entry, err := v.InspectSecret(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		var meta vault.SecretMetadata
+		if entry.Metadata != nil {
+			meta = *entry.Metadata
+		}
+		// json.Marshal sorts map keys, so equal content gives equal bytes
+		content, err := json.Marshal(struct {
+			Value    []byte
+			Fields   map[string]vault.Field
+			Bindings map[string]string
+			Notes    string
+			URL      string
+			Owner    string
+			Team     string
+		}{entry.Value, entry.Fields, entry.Bindings, meta.Notes, meta.URL, meta.Owner, meta.Team})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		sum := sha256.Sum256(content)
+		sums[key] = secretChecksum{sum: sum[:], updatedAt: entry.UpdatedAt}
+	}
+	return sums, nil
+}
@@ -0,0 +1,133 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestDiffChecksums(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	local := map[string]secretChecksum{
+		"same":       {sum: []byte("a"), updatedAt: t1},
+		"edited":     {sum: []byte("b2"), updatedAt: t1},
+		"stale":      {sum: []byte("c1"), updatedAt: t0},
+		"local/only": {sum: []byte("d"), updatedAt: t0},
+	}
+	backup := map[string]secretChecksum{
+		"same":   {sum: []byte("a"), updatedAt: t0},
+		"edited": {sum: []byte("b1"), updatedAt: t0},
+		"stale":  {sum: []byte("c2"), updatedAt: t1},
+		"added":  {sum: []byte("e"), updatedAt: t0},
+	}
+
+	want := map[string]KeyStatus{
+		"added":      KeyNew,
+		"edited":     KeyLocalNewer,
+		"local/only": KeyLocalOnly,
+		"same":       KeyIdentical,
+		"stale":      KeyBackupNewer,
+	}
+	diffs := diffChecksums(local, backup)
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs, want %d: %+v", len(diffs), len(want), diffs)
+	}
+	for i, d := range diffs {
+		if i > 0 && diffs[i-1].Key >= d.Key {
+			t.Errorf("diffs not sorted: %q before %q", diffs[i-1].Key, d.Key)
+		}
+		if d.Status != want[d.Key] {
+			t.Errorf("%s: status %s, want %s", d.Key, d.Status, want[d.Key])
+		}
+	}
+	if diffs[0].LocalUpdatedAt != nil || diffs[2].BackupUpdatedAt != nil {
+		t.Error("timestamps set for a side that lacks the key")
+	}
+}
+
+func TestRestore_DryRunDiff(t *testing.T) {
+	tempDir := t.TempDir()
+	vaultDir := filepath.Join(tempDir, "vault")
+	backupFile := filepath.Join(tempDir, "backup.enc")
+
+	password := "test-password"
+	v := vault.New(vaultDir)
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Failed to init vault: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Failed to unlock vault: %v", err)
+	}
+	defer v.Lock()
+	for _, key := range []string{"same", "changed", "deleted"} {
+		if err := v.SetSecret(key, &vault.SecretEntry{Value: []byte(key)}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+
+	out, _ := os.Create(backupFile)
+	if err := Backup(v, BackupOptions{Output: out, Password: []byte(password)}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	out.Close()
+
+	if err := v.SetSecret("changed", &vault.SecretEntry{Value: []byte("edited")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.DeleteSecret("deleted"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if err := v.SetSecret("added", &vault.SecretEntry{Value: []byte("added")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	result, err := Restore(backupFile, RestoreOptions{
+		VaultPath: vaultDir,
+		DryRun:    true,
+		Password:  []byte(password),
+		Current:   v,
+	})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+
+	got := make(map[string]KeyStatus)
+	for _, d := range result.Diff {
+		got[d.Key] = d.Status
+	}
+	if got["same"] != KeyIdentical || got["deleted"] != KeyNew || got["added"] != KeyLocalOnly {
+		t.Errorf("unexpected diff: %v", got)
+	}
+	if !got["changed"].IsConflict() {
+		t.Errorf("changed: status %s, want a conflict", got["changed"])
+	}
+
+	// A key-file backup cannot be opened without the vault's password
+	keyFile := filepath.Join(tempDir, "backup.key")
+	if err := GenerateKeyFile(keyFile); err != nil {
+		t.Fatalf("GenerateKeyFile failed: %v", err)
+	}
+	out, _ = os.Create(backupFile)
+	if err := Backup(v, BackupOptions{Output: out, KeyFile: keyFile}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	out.Close()
+	if _, err := Restore(backupFile, RestoreOptions{DryRun: true, KeyFile: keyFile, Current: v}); err == nil {
+		t.Error("expected an error without VaultPassword")
+	}
+	result, err = Restore(backupFile, RestoreOptions{DryRun: true, KeyFile: keyFile, Current: v, VaultPassword: []byte(password)})
+	if err != nil {
+		t.Fatalf("Dry run with VaultPassword failed: %v", err)
+	}
+	for _, d := range result.Diff {
+		if d.Status != KeyIdentical {
+			t.Errorf("%s: status %s, want identical", d.Key, d.Status)
+		}
+	}
+}
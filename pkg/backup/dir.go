@@ -0,0 +1,31 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName returns the name of a backup created at now in a backup
+// directory, e.g. secretctl-backup-20260102-150405.enc.
+func FileName(now time.Time) string {
+	return "secretctl-backup-" + now.UTC().Format("20060102-150405") + ".enc"
+}
+
+// Latest returns the modification time of the newest backup named by
+// FileName in dir. ok is false if there is none.
+func Latest(dir string) (modTime time.Time, ok bool) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "secretctl-backup-*.enc"))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.ModTime().After(modTime) {
+			modTime, ok = info.ModTime(), true
+		}
+	}
+	return modTime, ok
+}
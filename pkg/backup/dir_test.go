@@ -0,0 +1,38 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatest(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := Latest(dir); ok {
+		t.Fatal("Latest reported a backup in an empty directory")
+	}
+
+	older := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	newer := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for name, mtime := range map[string]time.Time{
+		FileName(older): older,
+		FileName(newer): newer,
+		"notes.enc":     time.Now(),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+
+	got, ok := Latest(dir)
+	if !ok || !got.Equal(newer) {
+		t.Errorf("Latest = %v, %v; want %v", got, ok, newer)
+	}
+}
@@ -37,4 +37,7 @@ var (
 
 	// ErrEmptyPassword indicates an empty password was provided.
 	ErrEmptyPassword = errors.New("password cannot be empty")
+
+	// ErrWeakPassword indicates a backup password that fails the master password rules.
+	ErrWeakPassword = errors.New("backup password does not meet the master password requirements")
 )
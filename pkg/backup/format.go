@@ -42,6 +42,13 @@ type Header struct {
 	IncludesAudit  bool           `json:"includes_audit"`
 	SecretCount    int            `json:"secret_count"`
 	ChecksumAlgo   string         `json:"checksum_algorithm"`
+
+	// Where the backup came from, to tell backups apart during recovery.
+	// Backups written before these were recorded leave them empty.
+	ToolVersion string            `json:"tool_version,omitempty"` // secretctl version
+	Hostname    string            `json:"hostname,omitempty"`
+	VaultID     string            `json:"vault_id,omitempty"`
+	Features    map[string]string `json:"features,omitempty"` // as in vault.meta
 }
 
 // Payload contains the encrypted backup data.
@@ -50,6 +57,9 @@ type Payload struct {
 	VaultMeta []byte `json:"vault_meta"` // vault.meta JSON
 	VaultDB   []byte `json:"vault_db"`   // vault.db SQLite
 	AuditLog  []byte `json:"audit_log"`  // Optional audit.jsonl
+
+	// Shards are the namespace databases under shards/, by namespace
+	Shards map[string][]byte `json:"shards,omitempty"`
 }
 
 // WriteHeader writes the magic number and header to the writer.
@@ -144,3 +154,24 @@ func DecodePayload(data []byte) (*Payload, error) {
 func HeaderBytes(header *Header) ([]byte, error) {
 	return json.Marshal(header)
 }
+
+// writeUint32 writes a uint32 in big-endian format.
+func writeUint32(w io.Writer, v uint32) error {
+	buf := make([]byte, 4)
+	buf[0] = byte(v >> 24)
+	buf[1] = byte(v >> 16)
+	buf[2] = byte(v >> 8)
+	buf[3] = byte(v)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readUint32 reads a uint32 in big-endian format.
+func readUint32(r io.Reader, v *uint32) error {
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	*v = uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// ValidatePassword checks a password for encrypting a backup against the
+// master password rules, so that a backup is never protected by a weaker
+// password than the vault it contains.
+func ValidatePassword(password []byte) error {
+	if vault.ValidateMasterPassword(string(password)).Valid {
+		return nil
+	}
+	if len(password) > vault.MaxPasswordLength {
+		return fmt.Errorf("%w: %w", ErrWeakPassword, vault.ErrPasswordTooLong)
+	}
+	return fmt.Errorf("%w: %w", ErrWeakPassword, vault.ErrPasswordTooShort)
+}
+
+// ReadKeyFile reads a 32-byte encryption key from a file.
+func ReadKeyFile(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	if len(key) != KeyLength {
+		crypto.SecureWipe(key)
+		return nil, ErrInvalidKeyFile
+	}
+
+	return key, nil
+}
+
+// GenerateKeyFile generates a random 32-byte key and writes it to a file.
+func GenerateKeyFile(path string) error {
+	key := make([]byte, KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	defer crypto.SecureWipe(key)
+
+	// Write with secure permissions (0600)
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	return nil
+}
+
+// readOptionalKeyFile reads keyFile, or returns nil if it is empty.
+func readOptionalKeyFile(keyFile string) ([]byte, error) {
+	if keyFile == "" {
+		return nil, nil
+	}
+	return ReadKeyFile(keyFile)
+}
+
+// backupKeys returns the keys and header fields for writing a backup
+// encrypted with keyFile, or with password under a fresh salt. Passwords
+// must pass ValidatePassword. The caller wipes the keys.
+func backupKeys(password []byte, keyFile string) (encKey, macKey []byte, kdfParams *KDFParams, mode EncryptionMode, err error) {
+	if keyFile != "" {
+		encKey, err = ReadKeyFile(keyFile)
+		if err != nil {
+			return nil, nil, nil, "", err
+		}
+
+		// Derive MAC key from encryption key
+		macKey, err = deriveHKDF(encKey, []byte(hkdfInfoMAC))
+		if err != nil {
+			crypto.SecureWipe(encKey)
+			return nil, nil, nil, "", fmt.Errorf("failed to derive MAC key: %w", err)
+		}
+		return encKey, macKey, nil, EncryptionModeKey, nil
+	}
+
+	// Use password (master or custom)
+	if password == nil {
+		return nil, nil, nil, "", fmt.Errorf("password or key file is required")
+	}
+	if err := ValidatePassword(password); err != nil {
+		return nil, nil, nil, "", err
+	}
+
+	// Generate fresh salt for backup
+	salt, err := GenerateSalt()
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+
+	encKey, macKey, err = DeriveBackupKeys(password, salt)
+	if err != nil {
+		return nil, nil, nil, "", err
+	}
+
+	kdfParams = &KDFParams{
+		Salt:        salt,
+		Memory:      crypto.Argon2Memory,
+		Iterations:  crypto.Argon2Time,
+		Parallelism: crypto.Argon2Threads,
+	}
+	return encKey, macKey, kdfParams, EncryptionModeMaster, nil
+}
@@ -0,0 +1,304 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// OpLogicalRestore is the operation name of LogicalRestore in progress reports.
+const OpLogicalRestore = "restore"
+
+// defaultRestoreBatchSize is how many secrets LogicalRestore writes per batch.
+const defaultRestoreBatchSize = 100
+
+// LogicalRestoreOptions configures a logical restore.
+type LogicalRestoreOptions struct {
+	// Password for decryption.
+	Password []byte
+	// KeyFile path for decryption key (overrides Password).
+	KeyFile string
+	// VaultPassword is the master password of the backed-up vault
+	// (defaults to Password).
+	VaultPassword []byte
+	// OnConflict specifies how to handle keys that exist in the target.
+	OnConflict ConflictMode
+	// Keys restricts the restore to keys matching any of these path.Match
+	// patterns (e.g. "db/*"). Empty restores every key.
+	Keys []string
+	// BatchSize is the number of secrets written per batch (default 100).
+	BatchSize int
+	// Progress receives an update after each batch. It may be nil.
+	Progress vault.ProgressFunc
+}
+
+// LogicalRestore restores secrets from a backup into an existing, unlocked
+// target vault. Unlike Restore, which swaps in the backed-up files, it opens
+// the backed-up vault in a temporary directory and writes each secret into
+// target through the vault API. The target keeps its own master password
+// and DEK, and keys that are not in the backup are left alone.
+//
+// Values, fields, bindings, notes, tags, expiry and access rules are
+// carried over, and folders are matched or created by path. History,
+// deprecations and usage records stay with the source vault.
+func LogicalRestore(backupPath string, target *vault.Vault, opts LogicalRestoreOptions) (*RestoreResult, error) {
+	for _, pattern := range opts.Keys {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %w", pattern, err)
+		}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRestoreBatchSize
+	}
+
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	_, payload, err := verifyAndDecrypt(data, opts.Password, opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	password := opts.VaultPassword
+	if password == nil {
+		password = opts.Password
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("the master password of the backed-up vault is required for a logical restore")
+	}
+
+	tempDir, err := os.MkdirTemp("", "secretctl-restore-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if err := writeVaultFiles(tempDir, payload, false); err != nil {
+		return nil, err
+	}
+
+	source := vault.New(tempDir)
+	if err := source.Unlock(string(password)); err != nil {
+		return nil, fmt.Errorf("failed to open backed-up vault: %w", err)
+	}
+	defer source.Lock()
+
+	keys, err := selectRestoreKeys(source, opts.Keys)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := target.ListSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list target secrets: %w", err)
+	}
+	inTarget := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		inTarget[key] = true
+	}
+
+	// Settle conflicts before the first write so a refused restore changes nothing
+	result := &RestoreResult{}
+	var todo, conflicts []string
+	for _, key := range keys {
+		if !inTarget[key] {
+			todo = append(todo, key)
+			continue
+		}
+		switch opts.OnConflict {
+		case ConflictSkip:
+			result.SecretsSkipped++
+		case ConflictOverwrite:
+			entry, err := target.InspectSecret(key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", key, err)
+			}
+			if entry.Immutable {
+				return nil, fmt.Errorf("cannot overwrite %s: %w", key, vault.ErrSecretImmutable)
+			}
+			if w := target.ActiveFreeze(key); w != nil {
+				return nil, fmt.Errorf("cannot overwrite %s: %w by %s", key, vault.ErrSecretFrozen, w.Pattern)
+			}
+			todo = append(todo, key)
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrConflict, strings.Join(conflicts, ", "))
+	}
+
+	// Decrypt from the source while the previous batch is written
+	entries := make(chan *vault.SecretEntry, batchSize)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(entries)
+		for _, key := range todo {
+			entry, err := source.InspectSecret(key)
+			if err != nil {
+				readErr <- fmt.Errorf("failed to read %s from backup: %w", key, err)
+				return
+			}
+			select {
+			case entries <- entry:
+			case <-done:
+				return
+			}
+		}
+		readErr <- nil
+	}()
+
+	folders := newFolderMapper(source, target)
+	progress := vault.Progress{Op: OpLogicalRestore, Total: len(todo)}
+	if opts.Progress != nil {
+		opts.Progress(progress)
+	}
+	batch := make([]*vault.SecretEntry, 0, batchSize)
+	flush := func() error {
+		if err := writeRestoreBatch(target, folders, batch); err != nil {
+			return err
+		}
+		result.SecretsRestored += len(batch)
+		progress.Done += len(batch)
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for entry := range entries {
+		batch = append(batch, entry)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := <-readErr; err != nil {
+		return result, err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// selectRestoreKeys returns the sorted keys of source matching any pattern.
+func selectRestoreKeys(source *vault.Vault, patterns []string) ([]string, error) {
+	all, err := source.ListSecrets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backed-up secrets: %w", err)
+	}
+	if len(patterns) == 0 {
+		sort.Strings(all)
+		return all, nil
+	}
+	var keys []string
+	for _, key := range all {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// writeRestoreBatch writes a batch of source entries into target in one
+// transaction.
+func writeRestoreBatch(target *vault.Vault, folders *folderMapper, batch []*vault.SecretEntry) error {
+	entries := make(map[string]*vault.SecretEntry, len(batch))
+	for _, src := range batch {
+		folderID, err := folders.targetID(src.FolderID)
+		if err != nil {
+			return err
+		}
+		entry := &vault.SecretEntry{
+			Value:        src.Value,
+			Fields:       src.Fields,
+			Bindings:     src.Bindings,
+			Schema:       src.Schema,
+			FolderID:     folderID,
+			Tags:         src.Tags,
+			ExpiresAt:    src.ExpiresAt,
+			NotBefore:    src.NotBefore,
+			AccessWindow: src.AccessWindow,
+			Immutable:    src.Immutable,
+		}
+		if src.Metadata != nil {
+			entry.Metadata = &vault.SecretMetadata{
+				Notes: src.Metadata.Notes,
+				URL:   src.Metadata.URL,
+				Owner: src.Metadata.Owner,
+				Team:  src.Metadata.Team,
+			}
+		}
+		entries[src.Key] = entry
+	}
+	if err := target.SetSecrets(entries); err != nil {
+		return fmt.Errorf("failed to restore %w", err)
+	}
+	return nil
+}
+
+// folderMapper translates folder IDs of the source vault to folders with
+// the same path in the target, creating them as needed.
+type folderMapper struct {
+	source, target *vault.Vault
+	ids            map[string]*string // source folder ID -> target folder ID
+}
+
+func newFolderMapper(source, target *vault.Vault) *folderMapper {
+	return &folderMapper{source: source, target: target, ids: make(map[string]*string)}
+}
+
+// targetID returns the target folder for the source folder id.
+func (m *folderMapper) targetID(id *string) (*string, error) {
+	if id == nil {
+		return nil, nil
+	}
+	if mapped, ok := m.ids[*id]; ok {
+		return mapped, nil
+	}
+	folder, err := m.source.GetFolder(*id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder from backup: %w", err)
+	}
+
+	var parentID *string
+	var prefix string
+	for _, name := range strings.Split(folder.Path, "/") {
+		prefix = path.Join(prefix, name)
+		existing, err := m.target.GetFolderByPath(prefix)
+		if err == nil {
+			parentID = &existing.ID
+			continue
+		}
+		if !errors.Is(err, vault.ErrFolderPathNotFound) {
+			return nil, fmt.Errorf("failed to look up folder %s: %w", prefix, err)
+		}
+		created := &vault.Folder{Name: name, ParentID: parentID}
+		if prefix == folder.Path {
+			created.Icon, created.Color = folder.Icon, folder.Color
+		}
+		if err := m.target.CreateFolder(created); err != nil {
+			return nil, fmt.Errorf("failed to create folder %s: %w", prefix, err)
+		}
+		parentID = &created.ID
+	}
+	m.ids[*id] = parentID
+	return parentID, nil
+}
@@ -0,0 +1,159 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// newLogicalRestoreFixture backs up a vault with five secrets, one of them
+// in a folder, and returns the backup, its key file and a separate target
+// vault with a different master password that already holds "app/shared".
+func newLogicalRestoreFixture(t *testing.T) (backupFile, keyFile string, target *vault.Vault) {
+	t.Helper()
+	tempDir := t.TempDir()
+	backupFile = filepath.Join(tempDir, "backup.enc")
+	keyFile = filepath.Join(tempDir, "backup.key")
+	if err := GenerateKeyFile(keyFile); err != nil {
+		t.Fatalf("GenerateKeyFile failed: %v", err)
+	}
+
+	source := vault.New(filepath.Join(tempDir, "source"))
+	if err := source.Init("source-password"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := source.Unlock("source-password"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	folder := &vault.Folder{Name: "Work"}
+	if err := source.CreateFolder(folder); err != nil {
+		t.Fatalf("CreateFolder failed: %v", err)
+	}
+	for _, key := range []string{"app/one", "app/two", "app/shared", "db/password"} {
+		entry := &vault.SecretEntry{Value: []byte("backup-" + key), Tags: []string{"restored"}}
+		if err := source.SetSecret(key, entry); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+	filed := &vault.SecretEntry{
+		Value:    []byte("filed"),
+		FolderID: &folder.ID,
+		Metadata: &vault.SecretMetadata{Notes: "in a folder"},
+	}
+	if err := source.SetSecret("work/token", filed); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	out, _ := os.Create(backupFile)
+	if err := Backup(source, BackupOptions{Output: out, KeyFile: keyFile}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	out.Close()
+	source.Lock()
+
+	target = vault.New(filepath.Join(tempDir, "target"))
+	if err := target.Init("target-password"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := target.Unlock("target-password"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	t.Cleanup(target.Lock)
+	if err := target.SetSecret("app/shared", &vault.SecretEntry{Value: []byte("local")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	return backupFile, keyFile, target
+}
+
+func TestLogicalRestore(t *testing.T) {
+	backupFile, keyFile, target := newLogicalRestoreFixture(t)
+	opts := LogicalRestoreOptions{KeyFile: keyFile, VaultPassword: []byte("source-password")}
+
+	// Conflicts are refused before anything is written
+	if _, err := LogicalRestore(backupFile, target, opts); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	if keys, _ := target.ListSecrets(); len(keys) != 1 {
+		t.Fatalf("refused restore wrote secrets: %v", keys)
+	}
+
+	var updates []vault.Progress
+	opts.OnConflict = ConflictSkip
+	opts.BatchSize = 2
+	opts.Progress = func(p vault.Progress) { updates = append(updates, p) }
+	result, err := LogicalRestore(backupFile, target, opts)
+	if err != nil {
+		t.Fatalf("LogicalRestore failed: %v", err)
+	}
+	if result.SecretsRestored != 4 || result.SecretsSkipped != 1 {
+		t.Errorf("restored %d, skipped %d; want 4 and 1", result.SecretsRestored, result.SecretsSkipped)
+	}
+	if len(updates) != 3 || updates[2].Done != 4 || updates[2].Total != 4 {
+		t.Errorf("unexpected progress: %+v", updates)
+	}
+
+	shared, _ := target.GetSecret("app/shared")
+	if string(shared.Value) != "local" {
+		t.Errorf("skipped key was overwritten: %q", shared.Value)
+	}
+	one, err := target.GetSecret("app/one")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if string(one.Value) != "backup-app/one" || len(one.Tags) != 1 {
+		t.Errorf("app/one not restored: %+v", one)
+	}
+	filed, err := target.GetSecret("work/token")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if filed.FolderID == nil || filed.Metadata == nil || filed.Metadata.Notes != "in a folder" {
+		t.Fatalf("folder or notes lost: %+v", filed)
+	}
+	if folder, err := target.GetFolder(*filed.FolderID); err != nil || folder.Path != "Work" {
+		t.Errorf("folder = %+v, %v; want Work", folder, err)
+	}
+
+	// The target keeps its own master password
+	target.Lock()
+	if err := target.Unlock("target-password"); err != nil {
+		t.Fatalf("target password changed: %v", err)
+	}
+}
+
+func TestLogicalRestore_OverwriteAndFilter(t *testing.T) {
+	backupFile, keyFile, target := newLogicalRestoreFixture(t)
+
+	result, err := LogicalRestore(backupFile, target, LogicalRestoreOptions{
+		KeyFile:       keyFile,
+		VaultPassword: []byte("source-password"),
+		OnConflict:    ConflictOverwrite,
+		Keys:          []string{"app/*"},
+	})
+	if err != nil {
+		t.Fatalf("LogicalRestore failed: %v", err)
+	}
+	if result.SecretsRestored != 3 {
+		t.Errorf("restored %d, want 3", result.SecretsRestored)
+	}
+	shared, _ := target.GetSecret("app/shared")
+	if string(shared.Value) != "backup-app/shared" {
+		t.Errorf("app/shared = %q, want the backup value", shared.Value)
+	}
+	if _, err := target.GetSecret("db/password"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("filtered key restored: %v", err)
+	}
+
+	if _, err := LogicalRestore(backupFile, target, LogicalRestoreOptions{KeyFile: keyFile}); err == nil {
+		t.Error("expected an error without the backed-up vault's password")
+	}
+	if _, err := LogicalRestore(backupFile, target, LogicalRestoreOptions{
+		KeyFile: keyFile, VaultPassword: []byte("source-password"), Keys: []string{"["},
+	}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,75 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// VerifyResult contains the result of a verify operation.
+type VerifyResult struct {
+	// Valid indicates the backup passed all integrity checks.
+	Valid bool
+	// Version is the backup format version.
+	Version int
+	// CreatedAt is when the backup was created.
+	CreatedAt time.Time
+	// SecretCount is the number of secrets in the backup.
+	SecretCount int
+	// IncludesAudit indicates if audit logs are included.
+	IncludesAudit bool
+	// ToolVersion, Hostname and VaultID identify the secretctl version,
+	// machine and vault that wrote the backup, if it records them.
+	ToolVersion string
+	Hostname    string
+	VaultID     string
+	// Error is set if verification failed.
+	Error string
+}
+
+// Open verifies a backup held in memory and decrypts its payload with the
+// reader for the backup's format version. key is the content of the key
+// file for backups encrypted with one; otherwise password is used.
+//
+// Open touches no files, database or other system facilities, so it is
+// available in GOARCH=wasm builds.
+func Open(data, password, key []byte) (*Header, *Payload, error) {
+	if len(data) < 8+4+HMACLength {
+		return nil, nil, ErrInvalidMagic
+	}
+
+	// Read header
+	reader := bytes.NewReader(data)
+	header, err := ReadHeader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	read, ok := formatReaders[header.Version]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, header.Version)
+	}
+	payload, err := read(data, len(data)-reader.Len(), header, password, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, payload, nil
+}
+
+// VerifyData is Verify for a backup held in memory, with key as in Open.
+func VerifyData(data, password, key []byte) *VerifyResult {
+	header, _, err := Open(data, password, key)
+	if err != nil {
+		return &VerifyResult{Valid: false, Error: err.Error()}
+	}
+	return &VerifyResult{
+		Valid:         true,
+		Version:       header.Version,
+		CreatedAt:     header.CreatedAt,
+		SecretCount:   header.SecretCount,
+		IncludesAudit: header.IncludesAudit,
+		ToolVersion:   header.ToolVersion,
+		Hostname:      header.Hostname,
+		VaultID:       header.VaultID,
+	}
+}
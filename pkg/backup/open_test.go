@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOpen_KeyBytes(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, KeyLength)
+	macKey, err := deriveHKDF(key, []byte(hkdfInfoMAC))
+	if err != nil {
+		t.Fatalf("deriveHKDF failed: %v", err)
+	}
+	header := &Header{CreatedAt: time.Now().UTC(), EncryptionMode: EncryptionModeKey, SecretCount: 3}
+	payload := &Payload{VaultSalt: []byte("salt"), VaultMeta: []byte("{}"), VaultDB: []byte("db")}
+	var buf bytes.Buffer
+	if err := writeBackup(&buf, header, payload, key, macKey); err != nil {
+		t.Fatalf("writeBackup failed: %v", err)
+	}
+
+	gotHeader, gotPayload, err := Open(buf.Bytes(), nil, key)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if gotHeader.SecretCount != 3 || string(gotPayload.VaultDB) != "db" {
+		t.Errorf("unexpected backup: %+v %+v", gotHeader, gotPayload)
+	}
+	if key[0] != 0x42 {
+		t.Error("Open wiped the caller's key")
+	}
+
+	if result := VerifyData(buf.Bytes(), nil, key); !result.Valid || result.SecretCount != 3 {
+		t.Errorf("VerifyData = %+v", result)
+	}
+	if _, _, err := Open(buf.Bytes(), nil, key[:16]); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Errorf("expected ErrInvalidKeyFile for a short key, got %v", err)
+	}
+	wrong := bytes.Repeat([]byte{0x24}, KeyLength)
+	if result := VerifyData(buf.Bytes(), nil, wrong); result.Valid {
+		t.Error("VerifyData accepted the wrong key")
+	}
+}
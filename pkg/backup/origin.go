@@ -0,0 +1,42 @@
+//go:build !wasm
+
+package backup
+
+import (
+	"fmt"
+
+	"github.com/forest6511/secretctl/pkg/update"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// CheckOrigin compares where a backup came from, as recorded in its header,
+// with the running secretctl version and the vault at vaultPath. It returns
+// a warning for a backup written by a newer version, one of a vault using
+// features this version does not support, and one of another vault than
+// the one at vaultPath. Backups that do not record their origin, and
+// development builds, produce no warnings.
+//
+// The header may be read with ReadHeader before the backup is decrypted,
+// so that the warnings can be confirmed before anything is overwritten.
+func CheckOrigin(header *Header, toolVersion, vaultPath string) []string {
+	var warnings []string
+	if update.IsNewer(header.ToolVersion, toolVersion) {
+		warnings = append(warnings, fmt.Sprintf("backup was written by secretctl %s, newer than this version (%s)",
+			header.ToolVersion, toolVersion))
+	}
+	if err := vault.CheckFeatures(header.Features); err != nil {
+		warnings = append(warnings, fmt.Sprintf("restored vault will not open with this version: %v", err))
+	}
+	if header.VaultID != "" && vaultPath != "" {
+		id, err := vault.New(vaultPath).ID()
+		if err == nil && id != "" && id != header.VaultID {
+			from := header.VaultID
+			if header.Hostname != "" {
+				from += " on " + header.Hostname
+			}
+			warnings = append(warnings, fmt.Sprintf("backup is of another vault (%s) than the one at %s (%s)",
+				from, vaultPath, id))
+		}
+	}
+	return warnings
+}
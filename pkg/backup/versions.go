@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// formatReader verifies and decrypts the body of a backup whose header
+// (magic, length and JSON) ends at headerEnd in data.
+type formatReader func(data []byte, headerEnd int, header *Header, password, key []byte) (*Payload, error)
+
+// formatReaders holds a reader for every format version that can be read.
+// A new format version adds an entry here and changes writeBackup; the old
+// readers stay so that existing backups remain restorable and convertible.
+var formatReaders = map[int]formatReader{
+	1: readV1,
+}
+
+// readV1 reads the version 1 body:
+//
+//	uint32 ciphertext length (big-endian)
+//	ciphertext: AES-256-GCM nonce || sealed JSON payload
+//	HMAC-SHA256 over everything before it, header included
+func readV1(data []byte, headerEnd int, header *Header, password, key []byte) (*Payload, error) {
+	reader := bytes.NewReader(data[headerEnd:])
+
+	// Read ciphertext length
+	var ciphertextLen uint32
+	if err := readUint32(reader, &ciphertextLen); err != nil {
+		return nil, fmt.Errorf("failed to read ciphertext length: %w", err)
+	}
+
+	// Verify we have enough data
+	if reader.Len() < int(ciphertextLen)+HMACLength {
+		return nil, fmt.Errorf("backup file truncated")
+	}
+
+	// Read ciphertext
+	ciphertext := make([]byte, ciphertextLen)
+	if _, err := io.ReadFull(reader, ciphertext); err != nil {
+		return nil, fmt.Errorf("failed to read ciphertext: %w", err)
+	}
+
+	// Read HMAC
+	storedHMAC := make([]byte, HMACLength)
+	if _, err := io.ReadFull(reader, storedHMAC); err != nil {
+		return nil, fmt.Errorf("failed to read HMAC: %w", err)
+	}
+
+	encKey, macKey, err := restoreKeys(header, password, key)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(encKey)
+	defer crypto.SecureWipe(macKey)
+
+	// Verify HMAC (header + ciphertext length + ciphertext)
+	if !VerifyHMAC(data[:headerEnd+4+int(ciphertextLen)], storedHMAC, macKey) {
+		return nil, ErrIntegrityFailed
+	}
+
+	// Decrypt payload
+	plaintext, err := DecryptPayload(ciphertext, encKey)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(plaintext)
+
+	return DecodePayload(plaintext)
+}
+
+// writeBackup writes header and payload in the current format version,
+// filling in the header's Version and ChecksumAlgo.
+func writeBackup(w io.Writer, header *Header, payload *Payload, encKey, macKey []byte) error {
+	// Encode payload
+	payloadBytes, err := EncodePayload(payload)
+	if err != nil {
+		return err
+	}
+	defer crypto.SecureWipe(payloadBytes)
+
+	// Encrypt payload
+	ciphertext, err := EncryptPayload(payloadBytes, encKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	header.Version = FormatVersion
+	header.ChecksumAlgo = "sha256"
+
+	// Write to buffer first (for HMAC calculation)
+	var buf bytes.Buffer
+
+	// Write header
+	if err := WriteHeader(&buf, header); err != nil {
+		return err
+	}
+
+	// Write ciphertext length and data
+	if err := writeUint32(&buf, uint32(len(ciphertext))); err != nil {
+		return err
+	}
+	if _, err := buf.Write(ciphertext); err != nil {
+		return fmt.Errorf("failed to write ciphertext: %w", err)
+	}
+
+	// Compute HMAC over header + ciphertext
+	hmacValue := ComputeHMAC(buf.Bytes(), macKey)
+
+	// Write everything to output
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if _, err := w.Write(hmacValue); err != nil {
+		return fmt.Errorf("failed to write HMAC: %w", err)
+	}
+
+	return nil
+}
+
+// restoreKeys returns the keys for reading a backup with header, from the
+// key file content key or from password. The caller wipes the keys.
+func restoreKeys(header *Header, password, key []byte) (encKey, macKey []byte, err error) {
+	switch {
+	case key != nil:
+		if len(key) != KeyLength {
+			return nil, nil, ErrInvalidKeyFile
+		}
+		encKey = bytes.Clone(key)
+
+		macKey, err = deriveHKDF(encKey, []byte(hkdfInfoMAC))
+		if err != nil {
+			crypto.SecureWipe(encKey)
+			return nil, nil, fmt.Errorf("failed to derive MAC key: %w", err)
+		}
+		return encKey, macKey, nil
+	case header.EncryptionMode == EncryptionModeMaster && header.KDFParams != nil:
+		if password == nil {
+			return nil, nil, ErrEmptyPassword
+		}
+		return DeriveBackupKeys(password, header.KDFParams.Salt)
+	default:
+		return nil, nil, fmt.Errorf("cannot determine decryption key")
+	}
+}
@@ -0,0 +1,180 @@
+// Package breach checks passwords against the Have I Been Pwned "Pwned
+// Passwords" corpus without revealing them.
+//
+// It uses the k-anonymity range API: a password is hashed with SHA-1 and
+// only the first five hex characters of the hash are sent. The service
+// answers with every hash suffix under that prefix, padded with decoys,
+// and the match is made locally. Neither the password nor its full hash
+// leaves the machine.
+package breach
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" // #nosec G505 -- SHA-1 is what the range API indexes by, not a security boundary
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/security"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// DefaultBaseURL is the Pwned Passwords API.
+const DefaultBaseURL = "https://api.pwnedpasswords.com"
+
+// DefaultTimeout bounds a single range request.
+const DefaultTimeout = 30 * time.Second
+
+const (
+	prefixLen       = 5
+	maxResponseSize = 4 << 20 // 4 MiB; padded ranges are well under 100 KiB
+	userAgent       = "secretctl-breach-check"
+)
+
+// ErrUnexpectedResponse is returned when the range API answers with
+// something other than a hash list.
+var ErrUnexpectedResponse = errors.New("breach: unexpected response from range API")
+
+// Checker queries the range API. Ranges are cached for the lifetime of
+// the checker, so passwords sharing a prefix cost one request.
+type Checker struct {
+	// BaseURL is the API location (DefaultBaseURL).
+	BaseURL string
+	// Client performs the requests.
+	Client *http.Client
+
+	ranges map[string]map[string]int
+}
+
+// NewChecker returns a checker for the public API.
+func NewChecker() *Checker {
+	return &Checker{
+		BaseURL: DefaultBaseURL,
+		Client:  &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Count returns how often password appears in known breaches; 0 means it
+// was not found.
+func (c *Checker) Count(ctx context.Context, password string) (int, error) {
+	// #nosec G401 -- only the 5-character prefix is sent; see package doc
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := c.fetchRange(ctx, hash[:prefixLen])
+	if err != nil {
+		return 0, err
+	}
+	return suffixes[hash[prefixLen:]], nil
+}
+
+func (c *Checker) fetchRange(ctx context.Context, prefix string) (map[string]int, error) {
+	if r, ok := c.ranges[prefix]; ok {
+		return r, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+"/range/"+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Padding hides the true size of the range from anyone watching
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("breach: range request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedResponse, resp.Status)
+	}
+	r, err := parseRange(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if c.ranges == nil {
+		c.ranges = make(map[string]map[string]int)
+	}
+	c.ranges[prefix] = r
+	return r, nil
+}
+
+// parseRange parses "SUFFIX:COUNT" lines. Padding entries have a count of
+// zero and are dropped.
+func parseRange(body io.Reader) (map[string]int, error) {
+	suffixes := make(map[string]int)
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		suffix, count, ok := strings.Cut(line, ":")
+		n, err := strconv.Atoi(count)
+		if !ok || err != nil || len(suffix) != sha1.Size*2-prefixLen {
+			return nil, fmt.Errorf("%w: malformed line", ErrUnexpectedResponse)
+		}
+		if n > 0 {
+			suffixes[strings.ToUpper(suffix)] = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("breach: failed to read range: %w", err)
+	}
+	return suffixes, nil
+}
+
+// Result is a password field found in known breaches.
+type Result struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+// Report is the outcome of Scan.
+type Report struct {
+	// Checked is the number of password fields looked up.
+	Checked int `json:"checked"`
+	// Compromised lists the breached fields, most often seen first.
+	Compromised []Result `json:"compromised"`
+}
+
+// Scan looks up the password fields of entries, as classified by
+// security.IsPasswordField. Empty values and ref:// or dynamic://
+// references are skipped.
+func (c *Checker) Scan(ctx context.Context, entries []*vault.SecretEntry) (*Report, error) {
+	report := &Report{Compromised: []Result{}}
+	for _, entry := range entries {
+		for name, field := range entry.Fields {
+			if !security.IsPasswordField(name, field.Kind) || field.Value == "" ||
+				vault.IsSecretRef(field.Value) || vault.IsDynamicRef([]byte(field.Value)) {
+				continue
+			}
+			count, err := c.Count(ctx, field.Value)
+			if err != nil {
+				return nil, err
+			}
+			report.Checked++
+			if count > 0 {
+				report.Compromised = append(report.Compromised, Result{Key: entry.Key, Field: name, Count: count})
+			}
+		}
+	}
+	sort.Slice(report.Compromised, func(i, j int) bool {
+		a, b := report.Compromised[i], report.Compromised[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Field < b.Field
+	})
+	return report, nil
+}
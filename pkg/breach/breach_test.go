@@ -0,0 +1,101 @@
+package breach
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// rangeServer serves ranges for the given passwords and records every
+// requested path.
+func rangeServer(t *testing.T, breached map[string]int) (*httptest.Server, *[]string) {
+	t.Helper()
+	byPrefix := make(map[string][]string)
+	for password, count := range breached {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		byPrefix[hash[:5]] = append(byPrefix[hash[:5]], fmt.Sprintf("%s:%d", hash[5:], count))
+	}
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Error("request without Add-Padding")
+		}
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		lines := append(byPrefix[prefix], strings.Repeat("0", 35)+":0") // padding
+		fmt.Fprint(w, strings.Join(lines, "\r\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &paths
+}
+
+func TestScan(t *testing.T) {
+	srv, paths := rangeServer(t, map[string]int{"password123": 250000, "hunter2": 17})
+	c := NewChecker()
+	c.BaseURL = srv.URL
+
+	entries := []*vault.SecretEntry{
+		{Key: "github", Fields: map[string]vault.Field{"password": {Value: "password123"}, "username": {Value: "password123"}}},
+		{Key: "irc", Fields: map[string]vault.Field{"pass": {Value: "hunter2"}}},
+		{Key: "db", Fields: map[string]vault.Field{"password": {Value: "c0rrect-h0rse-battery"}}},
+		{Key: "alias", Fields: map[string]vault.Field{"password": {Value: "ref://github"}}},
+		{Key: "copy", Fields: map[string]vault.Field{"secret": {Value: "password123"}}},
+	}
+	report, err := c.Scan(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if report.Checked != 4 {
+		t.Errorf("Checked = %d, want 4", report.Checked)
+	}
+	want := []Result{{"copy", "secret", 250000}, {"github", "password", 250000}, {"irc", "pass", 17}}
+	if fmt.Sprint(report.Compromised) != fmt.Sprint(want) {
+		t.Errorf("Compromised = %v, want %v", report.Compromised, want)
+	}
+
+	// Only prefixes are sent, and each only once
+	if len(*paths) != 3 {
+		t.Errorf("expected 3 range requests, got %v", *paths)
+	}
+	for _, p := range *paths {
+		if len(strings.TrimPrefix(p, "/range/")) != 5 {
+			t.Errorf("request leaked more than a prefix: %s", p)
+		}
+		for _, e := range entries {
+			for _, f := range e.Fields {
+				if strings.Contains(p, f.Value) {
+					t.Errorf("request contains a value: %s", p)
+				}
+			}
+		}
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/range/5BAA6") {
+			fmt.Fprint(w, "<html>not a range</html>")
+			return
+		}
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	c := NewChecker()
+	c.BaseURL = srv.URL
+
+	// SHA-1("password") starts with 5BAA6
+	if _, err := c.Count(context.Background(), "password"); err == nil || !strings.Contains(err.Error(), "malformed") {
+		t.Errorf("expected a malformed response error, got %v", err)
+	}
+	if _, err := c.Count(context.Background(), "other"); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected a status error, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package certrenew
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+// hookTimeout bounds each run of a dns-01 hook.
+const hookTimeout = 5 * time.Minute
+
+// issueACME runs the ACME order flow and returns the certificate chain.
+func issueACME(ctx context.Context, cfg *Config, accountKey, certKey crypto.Signer) ([][]byte, error) {
+	client := &acme.Client{Key: accountKey, DirectoryURL: cfg.Directory}
+
+	acct := &acme.Account{}
+	if cfg.Email != "" {
+		acct.Contact = []string{"mailto:" + cfg.Email}
+	}
+	if _, err := client.Register(ctx, acct, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("certrenew: account registration failed: %w", err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(cfg.Domains...))
+	if err != nil {
+		return nil, fmt.Errorf("certrenew: failed to create order: %w", err)
+	}
+	for _, u := range order.AuthzURLs {
+		if err := authorize(ctx, client, cfg, u); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, fmt.Errorf("certrenew: order failed: %w", err)
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: strings.TrimPrefix(cfg.Domains[0], "*.")},
+		DNSNames: cfg.Domains,
+	}, certKey)
+	if err != nil {
+		return nil, err
+	}
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("certrenew: failed to finalize order: %w", err)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("certrenew: CA returned no certificate")
+	}
+	return chain, nil
+}
+
+// authorize completes one authorization with the configured challenge.
+func authorize(ctx context.Context, client *acme.Client, cfg *Config, url string) error {
+	z, err := client.GetAuthorization(ctx, url)
+	if err != nil {
+		return fmt.Errorf("certrenew: failed to get authorization: %w", err)
+	}
+	if z.Status == acme.StatusValid {
+		return nil
+	}
+
+	var chal *acme.Challenge
+	for _, c := range z.Challenges {
+		if c.Type == cfg.Challenge {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("certrenew: CA offers no %s challenge for %s", cfg.Challenge, z.Identifier.Value)
+	}
+
+	var cleanup func()
+	switch cfg.Challenge {
+	case ChallengeDNS01:
+		cleanup, err = presentDNS(ctx, client, cfg, z.Identifier.Value, chal.Token)
+	default:
+		cleanup, err = presentHTTP(client, cfg, chal.Token)
+	}
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if _, err := client.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("certrenew: failed to accept challenge for %s: %w", z.Identifier.Value, err)
+	}
+	if _, err := client.WaitAuthorization(ctx, z.URI); err != nil {
+		return fmt.Errorf("certrenew: %s challenge for %s failed: %w", cfg.Challenge, z.Identifier.Value, err)
+	}
+	return nil
+}
+
+// presentHTTP makes the http-01 response available, through the webroot or
+// a temporary HTTP server.
+func presentHTTP(client *acme.Client, cfg *Config, token string) (func(), error) {
+	body, err := client.HTTP01ChallengeResponse(token)
+	if err != nil {
+		return nil, err
+	}
+	path := client.HTTP01ChallengePath(token)
+
+	if cfg.Webroot != "" {
+		file := filepath.Join(cfg.Webroot, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return nil, fmt.Errorf("certrenew: failed to create challenge directory: %w", err)
+		}
+		// World-readable: the web server must serve it
+		if err := os.WriteFile(file, []byte(body), 0644); err != nil {
+			return nil, fmt.Errorf("certrenew: failed to write challenge: %w", err)
+		}
+		return func() { os.Remove(file) }, nil
+	}
+
+	ln, err := net.Listen("tcp", cfg.HTTPAddr)
+	if err != nil {
+		return nil, fmt.Errorf("certrenew: failed to listen on %s for http-01: %w", cfg.HTTPAddr, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return func() { srv.Close() }, nil
+}
+
+// presentDNS runs the dns-01 hook to publish the TXT record.
+func presentDNS(ctx context.Context, client *acme.Client, cfg *Config, domain, token string) (func(), error) {
+	value, err := client.DNS01ChallengeRecord(token)
+	if err != nil {
+		return nil, err
+	}
+	fqdn := "_acme-challenge." + strings.TrimPrefix(domain, "*.")
+	if err := runHook(ctx, cfg.DNSHook, "present", fqdn, value); err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = runHook(context.Background(), cfg.DNSHook, "cleanup", fqdn, value)
+	}, nil
+}
+
+func runHook(ctx context.Context, hook, action, fqdn, value string) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, hook, action, fqdn, value).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("certrenew: dns hook %s failed: %w: %s", action, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
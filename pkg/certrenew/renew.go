@@ -0,0 +1,288 @@
+// Package certrenew renews certificates stored in the vault through ACME
+// (e.g. Let's Encrypt).
+//
+// A renewable secret has an "acme" field holding a JSON Config. Renew
+// obtains a new certificate for Config.Domains and stores it in the "cert"
+// and "key" fields in a single write, so the previous certificate is kept
+// in the secret's version history. The ACME account key is kept in the
+// "acme_account_key" field.
+package certrenew
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Field names used by renewable secrets
+const (
+	ConfigField     = "acme"
+	CertField       = "cert"
+	KeyField        = "key"
+	AccountKeyField = "acme_account_key"
+)
+
+// Challenge types
+const (
+	ChallengeHTTP01 = "http-01"
+	ChallengeDNS01  = "dns-01"
+)
+
+// DefaultRenewBeforeDays is how long before expiry RenewDue renews.
+const DefaultRenewBeforeDays = 30
+
+// Errors
+var (
+	ErrNoConfig      = errors.New("certrenew: secret has no acme field")
+	ErrInvalidConfig = errors.New("certrenew: invalid acme configuration")
+)
+
+// Config is the ACME configuration stored in a secret's "acme" field.
+type Config struct {
+	Domains   []string `json:"domains"`
+	Email     string   `json:"email,omitempty"`
+	Directory string   `json:"directory,omitempty"` // default: Let's Encrypt production
+	Challenge string   `json:"challenge,omitempty"` // http-01 (default) or dns-01
+
+	// http-01: serve the challenge on HTTPAddr (default ":80"), or write it
+	// to <Webroot>/.well-known/acme-challenge/ for an existing web server.
+	HTTPAddr string `json:"http_addr,omitempty"`
+	Webroot  string `json:"webroot,omitempty"`
+
+	// dns-01: DNSHook is run as "<hook> present <fqdn> <value>" before the
+	// challenge and "<hook> cleanup <fqdn> <value>" after it. It must not
+	// return until the TXT record is visible.
+	DNSHook string `json:"dns_hook,omitempty"`
+
+	// RenewBeforeDays is how long before expiry RenewDue renews (default 30).
+	RenewBeforeDays int `json:"renew_before_days,omitempty"`
+}
+
+// ParseConfig parses and validates an "acme" field value, filling in defaults.
+func ParseConfig(data string) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if len(cfg.Domains) == 0 {
+		return nil, fmt.Errorf("%w: domains is required", ErrInvalidConfig)
+	}
+	if cfg.Directory == "" {
+		cfg.Directory = acme.LetsEncryptURL
+	}
+	if cfg.RenewBeforeDays == 0 {
+		cfg.RenewBeforeDays = DefaultRenewBeforeDays
+	}
+	switch cfg.Challenge {
+	case "", ChallengeHTTP01:
+		cfg.Challenge = ChallengeHTTP01
+		if cfg.HTTPAddr == "" && cfg.Webroot == "" {
+			cfg.HTTPAddr = ":80"
+		}
+	case ChallengeDNS01:
+		if cfg.DNSHook == "" {
+			return nil, fmt.Errorf("%w: dns-01 requires dns_hook", ErrInvalidConfig)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported challenge %q (use %s or %s)", ErrInvalidConfig, cfg.Challenge, ChallengeHTTP01, ChallengeDNS01)
+	}
+	if cfg.Challenge == ChallengeHTTP01 {
+		for _, d := range cfg.Domains {
+			if strings.HasPrefix(d, "*.") {
+				return nil, fmt.Errorf("%w: wildcard domain %s requires dns-01", ErrInvalidConfig, d)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// Result describes the outcome of renewing one secret.
+type Result struct {
+	Key      string
+	Renewed  bool
+	NotAfter time.Time // of the stored certificate after the call
+	Err      error
+}
+
+// issueFunc obtains a certificate chain (DER, leaf first) for certKey.
+type issueFunc func(ctx context.Context, cfg *Config, accountKey, certKey crypto.Signer) ([][]byte, error)
+
+// Renewer renews certificates in an unlocked vault.
+type Renewer struct {
+	vault  *vault.Vault
+	source string
+	issue  issueFunc
+}
+
+// New returns a Renewer. source is the audit source (audit.SourceCLI,
+// audit.SourceAPI for the agent).
+func New(v *vault.Vault, source string) *Renewer {
+	return &Renewer{vault: v, source: source, issue: issueACME}
+}
+
+// Renew obtains a new certificate for key and stores it.
+func (r *Renewer) Renew(ctx context.Context, key string) (*Result, error) {
+	entry, err := r.vault.GetSecret(key)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := configOf(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	accountKey, err := accountKeyOf(entry)
+	if err != nil {
+		return nil, err
+	}
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := r.issue(ctx, cfg, accountKey, certKey)
+	if err != nil {
+		r.log(audit.ResultError, key, cfg, err)
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("certrenew: CA returned an invalid certificate: %w", err)
+	}
+
+	var certPEM strings.Builder
+	for _, der := range chain {
+		_ = pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	keyPEM, err := encodeKey(certKey)
+	if err != nil {
+		return nil, err
+	}
+	accountPEM, err := encodeKey(accountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// One write keeps cert and key consistent and records history
+	fields := make(map[string]vault.Field, len(entry.Fields)+3)
+	for name, f := range entry.Fields {
+		fields[name] = f
+	}
+	fields[CertField] = vault.Field{Value: certPEM.String(), Kind: vault.KindCertificate}
+	fields[KeyField] = vault.NewDefaultField(keyPEM)
+	fields[AccountKeyField] = vault.NewDefaultField(accountPEM)
+	entry.Fields = fields
+	entry.Value = nil
+	if err := r.vault.SetSecret(key, entry); err != nil {
+		r.log(audit.ResultError, key, cfg, err)
+		return nil, fmt.Errorf("certrenew: failed to store certificate: %w", err)
+	}
+
+	r.log(audit.ResultSuccess, key, cfg, nil)
+	return &Result{Key: key, Renewed: true, NotAfter: leaf.NotAfter}, nil
+}
+
+// RenewDue renews every certificate with an acme field that expires within
+// its renew_before_days. Failures are reported per secret in the results.
+func (r *Renewer) RenewDue(ctx context.Context) ([]Result, error) {
+	certs, err := r.vault.ListCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, c := range certs {
+		if c.Certificate == nil {
+			continue
+		}
+		entry, err := r.vault.InspectSecret(c.Key)
+		if err != nil {
+			results = append(results, Result{Key: c.Key, Err: err})
+			continue
+		}
+		cfg, err := configOf(entry)
+		if errors.Is(err, ErrNoConfig) {
+			continue // tracked but not managed by ACME
+		}
+		if err != nil {
+			results = append(results, Result{Key: c.Key, Err: err})
+			continue
+		}
+		notAfter := c.Certificate.NotAfter
+		if time.Until(notAfter) > time.Duration(cfg.RenewBeforeDays)*24*time.Hour {
+			results = append(results, Result{Key: c.Key, NotAfter: notAfter})
+			continue
+		}
+
+		res, err := r.Renew(ctx, c.Key)
+		if err != nil {
+			results = append(results, Result{Key: c.Key, NotAfter: notAfter, Err: err})
+			continue
+		}
+		results = append(results, *res)
+	}
+	return results, nil
+}
+
+func (r *Renewer) log(result, key string, cfg *Config, err error) {
+	var errInfo *audit.ErrorInfo
+	if err != nil {
+		errInfo = &audit.ErrorInfo{Code: "renew_failed", Message: err.Error()}
+	}
+	_ = r.vault.Audit().Log(audit.OpCertRenew, r.source, result, key, errInfo, map[string]interface{}{
+		"domains":   strings.Join(cfg.Domains, ","),
+		"challenge": cfg.Challenge,
+		"directory": cfg.Directory,
+	})
+}
+
+func configOf(entry *vault.SecretEntry) (*Config, error) {
+	f, ok := entry.Fields[ConfigField]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrNoConfig, entry.Key)
+	}
+	return ParseConfig(f.Value)
+}
+
+// accountKeyOf returns the stored ACME account key or a new one.
+func accountKeyOf(entry *vault.SecretEntry) (crypto.Signer, error) {
+	f, ok := entry.Fields[AccountKeyField]
+	if !ok || f.Value == "" {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	block, _ := pem.Decode([]byte(f.Value))
+	if block == nil {
+		return nil, fmt.Errorf("certrenew: %s is not PEM", AccountKeyField)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("certrenew: invalid %s: %w", AccountKeyField, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("certrenew: unsupported %s type", AccountKeyField)
+	}
+	return signer, nil
+}
+
+func encodeKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
@@ -0,0 +1,211 @@
+package certrenew
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// selfSign issues certificates valid for validity instead of talking to a CA.
+func selfSign(validity time.Duration, calls *int) issueFunc {
+	return func(_ context.Context, cfg *Config, _, certKey crypto.Signer) ([][]byte, error) {
+		*calls++
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(*calls)),
+			Subject:      pkix.Name{CommonName: cfg.Domains[0]},
+			DNSNames:     cfg.Domains,
+			NotBefore:    time.Now().Add(-time.Minute),
+			NotAfter:     time.Now().Add(validity),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, certKey.Public(), certKey)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{der}, nil
+	}
+}
+
+func testVault(t *testing.T) *vault.Vault {
+	t.Helper()
+	v := vault.New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	t.Cleanup(v.Lock)
+	return v
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(`{"domains":["example.com"]}`)
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if cfg.Challenge != ChallengeHTTP01 || cfg.HTTPAddr != ":80" || cfg.Directory != acme.LetsEncryptURL || cfg.RenewBeforeDays != DefaultRenewBeforeDays {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		`{}`,
+		`not json`,
+		`{"domains":["example.com"],"challenge":"tls-alpn-01"}`,
+		`{"domains":["example.com"],"challenge":"dns-01"}`,
+		`{"domains":["*.example.com"]}`,
+	} {
+		if _, err := ParseConfig(bad); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("ParseConfig(%s): expected ErrInvalidConfig, got %v", bad, err)
+		}
+	}
+}
+
+func TestRenew(t *testing.T) {
+	v := testVault(t)
+	err := v.SetSecret("tls/example", &vault.SecretEntry{
+		Fields: map[string]vault.Field{
+			ConfigField: {Value: `{"domains":["example.com","www.example.com"],"webroot":"/srv/www"}`},
+		},
+		Tags: []string{"prod"},
+	})
+	if err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	var calls int
+	r := New(v, audit.SourceCLI)
+	r.issue = selfSign(90*24*time.Hour, &calls)
+
+	res, err := r.Renew(context.Background(), "tls/example")
+	if err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+	if !res.Renewed || time.Until(res.NotAfter) < 89*24*time.Hour {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	entry, err := v.GetSecret("tls/example")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if entry.Certificate == nil || entry.Certificate.Field != CertField || len(entry.Certificate.SANs) != 2 {
+		t.Errorf("certificate not tracked: %+v", entry.Certificate)
+	}
+	if !strings.Contains(entry.Fields[KeyField].Value, "PRIVATE KEY") || !entry.Fields[KeyField].Sensitive {
+		t.Error("private key not stored as a sensitive field")
+	}
+	if len(entry.Tags) != 1 || entry.Fields[ConfigField].Value == "" {
+		t.Errorf("existing fields or tags lost: %+v", entry)
+	}
+	account := entry.Fields[AccountKeyField].Value
+
+	// The account key is reused and the old certificate kept in history
+	if _, err := r.Renew(context.Background(), "tls/example"); err != nil {
+		t.Fatalf("second Renew failed: %v", err)
+	}
+	entry, _ = v.GetSecret("tls/example")
+	if entry.Fields[AccountKeyField].Value != account {
+		t.Error("account key was replaced")
+	}
+	history, err := v.GetSecretHistory("tls/example")
+	if err != nil || len(history) != 3 {
+		t.Errorf("expected 3 history entries, got %d (%v)", len(history), err)
+	}
+
+	if _, err := r.Renew(context.Background(), "missing"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestRenewDue(t *testing.T) {
+	v := testVault(t)
+	var calls int
+	r := New(v, audit.SourceAPI)
+
+	for key, validity := range map[string]time.Duration{"tls/soon": 10 * 24 * time.Hour, "tls/later": 60 * 24 * time.Hour} {
+		if err := v.SetSecret(key, &vault.SecretEntry{Fields: map[string]vault.Field{
+			ConfigField: {Value: `{"domains":["` + strings.TrimPrefix(key, "tls/") + `.example.com"]}`},
+		}}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+		r.issue = selfSign(validity, &calls)
+		if _, err := r.Renew(context.Background(), key); err != nil {
+			t.Fatalf("Renew failed: %v", err)
+		}
+	}
+
+	calls = 0
+	r.issue = selfSign(90*24*time.Hour, &calls)
+	results, err := r.RenewDue(context.Background())
+	if err != nil {
+		t.Fatalf("RenewDue failed: %v", err)
+	}
+	if calls != 1 || len(results) != 2 {
+		t.Fatalf("expected 1 renewal of 2 certificates, got %d calls, results %+v", calls, results)
+	}
+	for _, res := range results {
+		if res.Renewed != (res.Key == "tls/soon") || res.Err != nil {
+			t.Errorf("unexpected result %+v", res)
+		}
+	}
+}
+
+func TestPresentChallenges(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	client := &acme.Client{Key: key}
+	ctx := context.Background()
+
+	webroot := t.TempDir()
+	cleanup, err := presentHTTP(client, &Config{Webroot: webroot}, "tok123")
+	if err != nil {
+		t.Fatalf("presentHTTP failed: %v", err)
+	}
+	file := filepath.Join(webroot, ".well-known", "acme-challenge", "tok123")
+	if data, err := os.ReadFile(file); err != nil || !strings.HasPrefix(string(data), "tok123.") {
+		t.Errorf("challenge file = %q, %v", data, err)
+	}
+	cleanup()
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("challenge file not removed")
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	dir := t.TempDir()
+	hook := filepath.Join(dir, "hook")
+	script := "#!/bin/sh\necho \"$1 $2 $3\" >> " + filepath.Join(dir, "log") + "\n"
+	if err := os.WriteFile(hook, []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	cleanup, err = presentDNS(ctx, client, &Config{DNSHook: hook}, "*.example.com", "tok456")
+	if err != nil {
+		t.Fatalf("presentDNS failed: %v", err)
+	}
+	cleanup()
+	log, _ := os.ReadFile(filepath.Join(dir, "log"))
+	lines := strings.Split(strings.TrimSpace(string(log)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "present _acme-challenge.example.com ") || !strings.HasPrefix(lines[1], "cleanup _acme-challenge.example.com ") {
+		t.Errorf("unexpected hook calls %q", log)
+	}
+}
@@ -0,0 +1,371 @@
+// Package client is the Go SDK for reading and writing secretctl secrets.
+//
+// A Client talks either to a running agent over its Unix socket (agent mode)
+// or to a vault opened directly in the current process (embedded mode).
+// Both modes expose the same methods and return the same typed errors, so
+// applications can fetch secrets at startup without shelling out:
+//
+//	c, err := client.New(ctx, client.Options{})
+//	if err != nil { ... }
+//	defer c.Close()
+//	dsn, err := c.Get(ctx, "db/prod/dsn")
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/agent/agentv1"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Typed errors. Use errors.Is to test for them.
+var (
+	ErrNotFound        = errors.New("client: secret not found")
+	ErrLocked          = errors.New("client: vault is locked")
+	ErrInvalidArgument = errors.New("client: invalid argument")
+	ErrDenied          = errors.New("client: access denied")
+	ErrExpired         = errors.New("client: secret has expired")
+	ErrDeprecated      = errors.New("client: key is deprecated")
+	ErrUnavailable     = errors.New("client: secretctl agent is not available")
+	ErrClosed          = errors.New("client: client is closed")
+)
+
+// Mode selects how a Client reaches the vault.
+type Mode int
+
+const (
+	// ModeAuto uses the agent if its socket is reachable, otherwise opens
+	// the vault directly when a password is supplied.
+	ModeAuto Mode = iota
+	// ModeAgent requires a running agent.
+	ModeAgent
+	// ModeEmbedded opens the vault in this process.
+	ModeEmbedded
+)
+
+// Options configures a Client.
+type Options struct {
+	Mode Mode
+
+	// VaultPath is the vault directory. Defaults to $SECRETCTL_VAULT_DIR,
+	// then ~/.secretctl.
+	VaultPath string
+
+	// SocketPath overrides the agent socket. Defaults to $SECRETCTL_AGENT_SOCK,
+	// then <vault>/agent.sock.
+	SocketPath string
+
+	// Password unlocks the vault in embedded mode.
+	Password string
+}
+
+// Secret is a decrypted secret.
+type Secret struct {
+	Key    string
+	Value  string
+	Fields map[string]string
+	Tags   []string
+}
+
+// backend is implemented by the agent and embedded transports.
+type backend interface {
+	get(ctx context.Context, key, field string) (*Secret, error)
+	list(ctx context.Context, tag string) ([]string, error)
+	set(ctx context.Context, key, value string, tags []string) error
+	close() error
+}
+
+// Client fetches secrets from secretctl. It is safe for concurrent use;
+// once Close has been called every method returns ErrClosed.
+type Client struct {
+	mu sync.RWMutex // read-held for each call, write-held by Close
+	b  backend      // nil once closed
+}
+
+// New creates a Client according to opts.
+func New(ctx context.Context, opts Options) (*Client, error) {
+	vaultPath, err := resolveVaultPath(opts.VaultPath)
+	if err != nil {
+		return nil, err
+	}
+	socketPath := opts.SocketPath
+	if socketPath == "" {
+		socketPath = agent.DefaultSocketPath(vaultPath)
+	}
+
+	switch opts.Mode {
+	case ModeAgent:
+		return newAgentClient(ctx, socketPath)
+	case ModeEmbedded:
+		return newEmbeddedClient(vaultPath, opts.Password)
+	case ModeAuto:
+		c, err := newAgentClient(ctx, socketPath)
+		if err == nil {
+			return c, nil
+		}
+		if opts.Password == "" {
+			return nil, err
+		}
+		return newEmbeddedClient(vaultPath, opts.Password)
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %d", ErrInvalidArgument, opts.Mode)
+	}
+}
+
+// Get returns the default field value of a secret.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	s, err := c.GetSecret(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	return s.Value, nil
+}
+
+// GetField returns a single field value of a secret.
+func (c *Client) GetField(ctx context.Context, key, field string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.b == nil {
+		return "", ErrClosed
+	}
+	s, err := c.b.get(ctx, key, field)
+	if err != nil {
+		return "", err
+	}
+	return s.Value, nil
+}
+
+// GetSecret returns a secret with all of its fields.
+func (c *Client) GetSecret(ctx context.Context, key string) (*Secret, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.b == nil {
+		return nil, ErrClosed
+	}
+	return c.b.get(ctx, key, "")
+}
+
+// List returns secret keys, optionally filtered by tag.
+func (c *Client) List(ctx context.Context, tag string) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.b == nil {
+		return nil, ErrClosed
+	}
+	return c.b.list(ctx, tag)
+}
+
+// Set stores a single-value secret.
+func (c *Client) Set(ctx context.Context, key, value string, tags ...string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.b == nil {
+		return ErrClosed
+	}
+	return c.b.set(ctx, key, value, tags)
+}
+
+// Close releases the connection (agent mode) or locks the vault (embedded mode).
+// It waits for calls already in progress and is a no-op on a closed Client.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.b == nil {
+		return nil
+	}
+	err := c.b.close()
+	c.b = nil
+	return err
+}
+
+func resolveVaultPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("SECRETCTL_VAULT_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("client: failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".secretctl"), nil
+}
+
+// agentBackend talks to a running agent.
+type agentBackend struct {
+	c *agent.Client
+}
+
+func newAgentClient(ctx context.Context, socketPath string) (*Client, error) {
+	c, err := agent.Dial(ctx, socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return &Client{b: &agentBackend{c: c}}, nil
+}
+
+func (a *agentBackend) get(ctx context.Context, key, field string) (*Secret, error) {
+	resp, err := a.c.Get(ctx, &agentv1.GetRequest{Key: key, Field: field})
+	if err != nil {
+		return nil, fromAgentError(err)
+	}
+	return &Secret{Key: resp.Key, Value: resp.Value, Fields: resp.Fields, Tags: resp.Tags}, nil
+}
+
+func (a *agentBackend) list(ctx context.Context, tag string) ([]string, error) {
+	resp, err := a.c.List(ctx, &agentv1.ListRequest{Tag: tag})
+	if err != nil {
+		return nil, fromAgentError(err)
+	}
+	return resp.Keys, nil
+}
+
+func (a *agentBackend) set(ctx context.Context, key, value string, tags []string) error {
+	_, err := a.c.Set(ctx, &agentv1.SetRequest{Key: key, Value: value, Tags: tags})
+	return fromAgentError(err)
+}
+
+func (a *agentBackend) close() error {
+	return a.c.Close()
+}
+
+// fromAgentError maps agent wire codes to SDK errors.
+func fromAgentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var agentErr *agent.Error
+	if !errors.As(err, &agentErr) {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	switch agentErr.Code {
+	case agent.CodeNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, agentErr.Message)
+	case agent.CodeLocked:
+		return fmt.Errorf("%w: %s", ErrLocked, agentErr.Message)
+	case agent.CodeInvalidArgument:
+		return fmt.Errorf("%w: %s", ErrInvalidArgument, agentErr.Message)
+	case agent.CodeDenied:
+		return fmt.Errorf("%w: %s", ErrDenied, agentErr.Message)
+	case agent.CodeExpired:
+		return fmt.Errorf("%w: %s", ErrExpired, agentErr.Message)
+	case agent.CodeDeprecated:
+		return fmt.Errorf("%w: %s", ErrDeprecated, agentErr.Message)
+	default:
+		return err
+	}
+}
+
+// embeddedBackend uses a vault opened in this process.
+type embeddedBackend struct {
+	v *vault.Vault
+}
+
+func newEmbeddedClient(vaultPath, password string) (*Client, error) {
+	if password == "" {
+		return nil, fmt.Errorf("%w: password is required in embedded mode", ErrInvalidArgument)
+	}
+	v := vault.New(vaultPath)
+	v.SetAuditSource(audit.SourceAPI)
+	if err := v.Unlock(password); err != nil {
+		return nil, fromVaultError(err)
+	}
+	return &Client{b: &embeddedBackend{v: v}}, nil
+}
+
+func (e *embeddedBackend) get(ctx context.Context, key, field string) (*Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	entry, err := e.v.GetSecret(key)
+	if err != nil {
+		return nil, fromVaultError(err)
+	}
+
+	s := &Secret{Key: key, Tags: entry.Tags, Fields: make(map[string]string, len(entry.Fields))}
+	for name, f := range entry.Fields {
+		s.Fields[name] = f.Value
+	}
+	if field == "" {
+		s.Value = vault.GetDefaultFieldValue(entry.Fields)
+		return s, nil
+	}
+	_, f, err := vault.ResolveFieldName(entry.Fields, field)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	s.Value = f.Value
+	return s, nil
+}
+
+func (e *embeddedBackend) list(ctx context.Context, tag string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		keys, err := e.v.ListSecrets()
+		return keys, fromVaultError(err)
+	}
+	entries, err := e.v.ListSecretsByTag(tag)
+	if err != nil {
+		return nil, fromVaultError(err)
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	return keys, nil
+}
+
+func (e *embeddedBackend) set(ctx context.Context, key, value string, tags []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entry := &vault.SecretEntry{
+		Key:    key,
+		Fields: map[string]vault.Field{vault.DefaultFieldName: vault.NewDefaultField(value)},
+		Tags:   tags,
+	}
+	return fromVaultError(e.v.SetSecret(key, entry))
+}
+
+func (e *embeddedBackend) close() error {
+	e.v.Lock()
+	return nil
+}
+
+// fromVaultError maps vault errors to SDK errors.
+func fromVaultError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, vault.ErrSecretNotFound):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	case errors.Is(err, vault.ErrVaultLocked):
+		return fmt.Errorf("%w: %v", ErrLocked, err)
+	case errors.Is(err, vault.ErrInvalidPassword), errors.Is(err, vault.ErrSecretNotYetValid),
+		errors.Is(err, vault.ErrOutsideAccessWindow), errors.Is(err, vault.ErrSecretImmutable),
+		errors.Is(err, vault.ErrSecretFrozen):
+		return fmt.Errorf("%w: %v", ErrDenied, err)
+	case errors.Is(err, vault.ErrSecretExpired):
+		return fmt.Errorf("%w: %v", ErrExpired, err)
+	case errors.Is(err, vault.ErrSecretDeprecated):
+		return fmt.Errorf("%w: %v", ErrDeprecated, err)
+	case errors.Is(err, vault.ErrKeyInvalid), errors.Is(err, vault.ErrKeyTooLong),
+		errors.Is(err, vault.ErrKeyTooShort), errors.Is(err, vault.ErrValueTooLarge):
+		return fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	default:
+		return err
+	}
+}
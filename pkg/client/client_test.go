@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/agent"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+const testPassword = "testpassword123"
+
+func newTestVault(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	v := vault.New(tmpDir)
+	if err := v.Init(testPassword); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	return tmpDir
+}
+
+func TestEmbeddedClient(t *testing.T) {
+	dir := newTestVault(t)
+	ctx := context.Background()
+
+	c, err := New(ctx, Options{Mode: ModeEmbedded, VaultPath: dir, Password: testPassword})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Set(ctx, "api/key", "abc123", "dev"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	value, err := c.Get(ctx, "api/key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value != "abc123" {
+		t.Errorf("expected abc123, got %q", value)
+	}
+
+	keys, err := c.List(ctx, "dev")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "api/key" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := c.Get(canceled, "api/key"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	c.Close()
+	if _, err := c.Get(ctx, "api/key"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestConcurrentClose(t *testing.T) {
+	dir := newTestVault(t)
+	ctx := context.Background()
+
+	c, err := New(ctx, Options{Mode: ModeEmbedded, VaultPath: dir, Password: testPassword})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := c.Set(ctx, "api/key", "abc123"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := c.Get(ctx, "api/key"); err != nil && !errors.Is(err, ErrClosed) {
+					t.Errorf("Get: expected nil or ErrClosed, got %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := c.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := c.Get(ctx, "api/key"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestEmbeddedClientWrongPassword(t *testing.T) {
+	dir := newTestVault(t)
+	_, err := New(context.Background(), Options{Mode: ModeEmbedded, VaultPath: dir, Password: "wrongpassword1"})
+	if !errors.Is(err, ErrDenied) {
+		t.Errorf("expected ErrDenied, got %v", err)
+	}
+}
+
+func TestAutoModeWithoutAgent(t *testing.T) {
+	dir := newTestVault(t)
+	ctx := context.Background()
+
+	if _, err := New(ctx, Options{VaultPath: dir}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable without agent or password, got %v", err)
+	}
+
+	c, err := New(ctx, Options{VaultPath: dir, Password: testPassword})
+	if err != nil {
+		t.Fatalf("expected embedded fallback, got %v", err)
+	}
+	c.Close()
+}
+
+func TestAgentClient(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("agent requires peer credential support")
+	}
+	dir := newTestVault(t)
+	v := vault.New(dir)
+	if err := v.Unlock(testPassword); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	sockDir, err := os.MkdirTemp("", "sctl")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	defer os.RemoveAll(sockDir)
+	socketPath := filepath.Join(sockDir, agent.SocketFileName)
+
+	srv := agent.NewServer(v, &agent.ServerOptions{SocketPath: socketPath})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var c *Client
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c, err = New(ctx, Options{Mode: ModeAgent, VaultPath: dir, SocketPath: socketPath})
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("New failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer c.Close()
+
+	if err := c.Set(ctx, "db/password", "pw-value"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	value, err := c.Get(ctx, "db/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value != "pw-value" {
+		t.Errorf("expected pw-value, got %q", value)
+	}
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
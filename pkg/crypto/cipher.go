@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Cipher suites accepted by EncryptWith and DecryptWith.
+const (
+	// CipherAES256GCM is AES-256-GCM with 12-byte nonces, the default.
+	CipherAES256GCM = "aes-256-gcm"
+	// CipherXChaCha20Poly1305 is XChaCha20-Poly1305 with 24-byte nonces.
+	// It is faster than AES-GCM on processors without AES instructions.
+	CipherXChaCha20Poly1305 = "xchacha20-poly1305"
+)
+
+// ErrUnsupportedCipher indicates an unknown cipher suite.
+var ErrUnsupportedCipher = errors.New("crypto: unsupported cipher suite")
+
+// Ciphers returns the supported cipher suites, the default first.
+func Ciphers() []string {
+	return []string{CipherAES256GCM, CipherXChaCha20Poly1305}
+}
+
+// NonceSize returns the nonce length of suite in bytes.
+func NonceSize(suite string) (int, error) {
+	switch suite {
+	case CipherAES256GCM:
+		return NonceLength, nil
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NonceSizeX, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnsupportedCipher, suite)
+}
+
+// newAEAD returns the AEAD of suite keyed with key.
+func newAEAD(suite string, key []byte) (cipher.AEAD, error) {
+	switch suite {
+	case CipherAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: failed to create cipher: %w", err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: failed to create GCM: %w", err)
+		}
+		return gcm, nil
+	case CipherXChaCha20Poly1305:
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return nil, fmt.Errorf("crypto: failed to create XChaCha20-Poly1305: %w", err)
+		}
+		return aead, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedCipher, suite)
+}
+
+// EncryptWith is Encrypt with the given cipher suite. The nonce is
+// NonceSize(suite) bytes long.
+func EncryptWith(suite string, key, plaintext []byte) (ciphertext []byte, nonce []byte, err error) {
+	if len(key) != KeyLength {
+		return nil, nil, ErrInvalidKeyLength
+	}
+	aead, err := newAEAD(suite, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("crypto: failed to generate nonce: %w", err)
+	}
+
+	// #nosec G407 -- nonce is randomly generated above using crypto/rand.Read()
+	ciphertext = aead.Seal(nil, nonce, plaintext, nil)
+	return ciphertext, nonce, nil
+}
+
+// DecryptWith is Decrypt with the given cipher suite.
+func DecryptWith(suite string, key, ciphertext, nonce []byte) (plaintext []byte, err error) {
+	if len(key) != KeyLength {
+		return nil, ErrInvalidKeyLength
+	}
+	size, err := NonceSize(suite)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != size {
+		return nil, ErrInvalidNonceLength
+	}
+	aead, err := newAEAD(suite, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	plaintext, err = aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
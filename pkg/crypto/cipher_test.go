@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestEncryptWithCiphers round-trips every cipher suite
+func TestEncryptWithCiphers(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeyLength)
+	plaintext := []byte("secret value")
+
+	for _, suite := range Ciphers() {
+		t.Run(suite, func(t *testing.T) {
+			ciphertext, nonce, err := EncryptWith(suite, key, plaintext)
+			if err != nil {
+				t.Fatalf("EncryptWith() error = %v", err)
+			}
+			size, _ := NonceSize(suite)
+			if len(nonce) != size {
+				t.Errorf("nonce length = %d, want %d", len(nonce), size)
+			}
+			got, err := DecryptWith(suite, key, ciphertext, nonce)
+			if err != nil {
+				t.Fatalf("DecryptWith() error = %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("DecryptWith() = %q, want %q", got, plaintext)
+			}
+
+			ciphertext[0] ^= 1
+			if _, err := DecryptWith(suite, key, ciphertext, nonce); err != ErrDecryptionFailed {
+				t.Errorf("tampered ciphertext: error = %v, want %v", err, ErrDecryptionFailed)
+			}
+		})
+	}
+}
+
+// TestDecryptWithWrongCipher checks that the suites cannot read each other
+func TestDecryptWithWrongCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeyLength)
+	ciphertext, nonce, err := EncryptWith(CipherXChaCha20Poly1305, key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(key, ciphertext, nonce); err != ErrInvalidNonceLength {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrInvalidNonceLength)
+	}
+	if _, err := DecryptWith(CipherXChaCha20Poly1305, key, ciphertext, nonce[:NonceLength]); err != ErrInvalidNonceLength {
+		t.Errorf("DecryptWith() error = %v, want %v", err, ErrInvalidNonceLength)
+	}
+}
+
+// TestUnsupportedCipher checks unknown suites are rejected
+func TestUnsupportedCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeyLength)
+	if _, _, err := EncryptWith("rot13", key, []byte("x")); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("EncryptWith() error = %v, want %v", err, ErrUnsupportedCipher)
+	}
+	if _, err := DecryptWith("rot13", key, []byte("x"), nil); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("DecryptWith() error = %v, want %v", err, ErrUnsupportedCipher)
+	}
+	if _, err := NonceSize(""); !errors.Is(err, ErrUnsupportedCipher) {
+		t.Errorf("NonceSize() error = %v, want %v", err, ErrUnsupportedCipher)
+	}
+}
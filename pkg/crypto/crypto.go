@@ -1,14 +1,16 @@
 // Package crypto provides cryptographic primitives for secretctl.
 //
-// This package implements AES-256-GCM authenticated encryption and Argon2id
-// key derivation following OWASP recommendations.
+// This package implements AES-256-GCM and XChaCha20-Poly1305 authenticated
+// encryption and Argon2id key derivation following OWASP recommendations.
 //
 // # Security Features
 //
-//   - AES-256-GCM authenticated encryption
+//   - AES-256-GCM authenticated encryption (EncryptWith also supports
+//     XChaCha20-Poly1305)
 //   - Argon2id key derivation (64MB memory, 3 iterations, 4 threads)
 //   - Cryptographically secure random nonce generation
 //   - Secure memory wiping for sensitive data
+//   - Non-swappable buffers (LockedBuffer) for keys held in memory
 //
 // # Example Usage
 //
@@ -28,11 +30,7 @@
 package crypto
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"errors"
-	"fmt"
 	"runtime"
 
 	"golang.org/x/crypto/argon2"
@@ -61,8 +59,9 @@ var (
 	// ErrInvalidKeyLength indicates the key is not 32 bytes.
 	ErrInvalidKeyLength = errors.New("crypto: invalid key length, must be 32 bytes")
 
-	// ErrInvalidNonceLength indicates the nonce is not 12 bytes.
-	ErrInvalidNonceLength = errors.New("crypto: invalid nonce length, must be 12 bytes")
+	// ErrInvalidNonceLength indicates the nonce is not NonceSize bytes
+	// (12 for AES-256-GCM).
+	ErrInvalidNonceLength = errors.New("crypto: invalid nonce length")
 
 	// ErrDecryptionFailed indicates decryption or authentication tag verification failed.
 	ErrDecryptionFailed = errors.New("crypto: decryption failed, authentication tag verification failed")
@@ -98,31 +97,7 @@ func DeriveKey(password, salt []byte) []byte {
 //   - nonce: 12-byte nonce (must be stored with ciphertext for decryption)
 //   - err: ErrInvalidKeyLength if key is not 32 bytes
 func Encrypt(key, plaintext []byte) (ciphertext []byte, nonce []byte, err error) {
-	if len(key) != KeyLength {
-		return nil, nil, ErrInvalidKeyLength
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, nil, fmt.Errorf("crypto: failed to create cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, nil, fmt.Errorf("crypto: failed to create GCM: %w", err)
-	}
-
-	// Generate cryptographically secure random nonce
-	nonce = make([]byte, NonceLength)
-	if _, err := rand.Read(nonce); err != nil {
-		return nil, nil, fmt.Errorf("crypto: failed to generate nonce: %w", err)
-	}
-
-	// Encrypt with GCM (authentication tag is appended to ciphertext)
-	// #nosec G407 -- nonce is randomly generated above using crypto/rand.Read()
-	ciphertext = gcm.Seal(nil, nonce, plaintext, nil)
-
-	return ciphertext, nonce, nil
+	return EncryptWith(CipherAES256GCM, key, plaintext)
 }
 
 // Decrypt decrypts ciphertext using AES-256-GCM authenticated encryption.
@@ -141,36 +116,7 @@ func Encrypt(key, plaintext []byte) (ciphertext []byte, nonce []byte, err error)
 //   - err: ErrInvalidKeyLength, ErrInvalidNonceLength, ErrCiphertextTooShort,
 //     or ErrDecryptionFailed
 func Decrypt(key, ciphertext, nonce []byte) (plaintext []byte, err error) {
-	if len(key) != KeyLength {
-		return nil, ErrInvalidKeyLength
-	}
-
-	if len(nonce) != NonceLength {
-		return nil, ErrInvalidNonceLength
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("crypto: failed to create cipher: %w", err)
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, fmt.Errorf("crypto: failed to create GCM: %w", err)
-	}
-
-	// Verify ciphertext has minimum length (GCM tag is 16 bytes)
-	if len(ciphertext) < gcm.Overhead() {
-		return nil, ErrCiphertextTooShort
-	}
-
-	// Decrypt with GCM (includes authentication tag verification)
-	plaintext, err = gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, ErrDecryptionFailed
-	}
-
-	return plaintext, nil
+	return DecryptWith(CipherAES256GCM, key, ciphertext, nonce)
 }
 
 // SecureWipe overwrites a byte slice with zeros in a way that prevents
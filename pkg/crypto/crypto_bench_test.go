@@ -166,3 +166,25 @@ func benchmarkDecrypt(b *testing.B, size int) {
 		}
 	}
 }
+
+// BenchmarkEncryptXChaCha20 measures XChaCha20-Poly1305 encryption performance with 1KB payload.
+func BenchmarkEncryptXChaCha20(b *testing.B) {
+	key := make([]byte, crypto.KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		b.Fatal(err)
+	}
+	data := make([]byte, 1024) // 1KB
+	if _, err := rand.Read(data); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := crypto.EncryptWith(crypto.CipherXChaCha20Poly1305, key, data)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
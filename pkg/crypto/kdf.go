@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Bounds accepted by KDFParams.Validate. The minimum memory is the OWASP
+// floor for Argon2id; the maxima keep a tampered vault.meta from making
+// unlock exhaust memory or hang.
+const (
+	MinKDFMemory = 19 * 1024       // KiB
+	MaxKDFMemory = 4 * 1024 * 1024 // KiB (4 GiB)
+	MaxKDFTime   = 64
+)
+
+// ErrInvalidKDFParams indicates Argon2id parameters outside the accepted bounds.
+var ErrInvalidKDFParams = errors.New("crypto: invalid KDF parameters")
+
+// KDFParams are Argon2id cost parameters.
+type KDFParams struct {
+	Memory  uint32 `json:"memory"`      // KiB
+	Time    uint32 `json:"iterations"`  // passes over memory
+	Threads uint8  `json:"parallelism"` // lanes
+	// Version is the Argon2 version; 0 (recorded before versions were)
+	// means argon2.Version, the only one supported
+	Version uint32 `json:"version,omitempty"`
+}
+
+// DefaultKDFParams returns the parameters used by DeriveKey.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{Memory: Argon2Memory, Time: Argon2Time, Threads: Argon2Threads, Version: argon2.Version}
+}
+
+// Validate checks that p is within the accepted bounds.
+func (p KDFParams) Validate() error {
+	switch {
+	case p.Memory < MinKDFMemory || p.Memory > MaxKDFMemory:
+		return fmt.Errorf("%w: memory %d KiB (must be %d-%d)", ErrInvalidKDFParams, p.Memory, MinKDFMemory, MaxKDFMemory)
+	case p.Time < 1 || p.Time > MaxKDFTime:
+		return fmt.Errorf("%w: iterations %d (must be 1-%d)", ErrInvalidKDFParams, p.Time, MaxKDFTime)
+	case p.Threads < 1:
+		return fmt.Errorf("%w: parallelism must be at least 1", ErrInvalidKDFParams)
+	case p.Version != 0 && p.Version != argon2.Version:
+		return fmt.Errorf("%w: unsupported Argon2 version %#x", ErrInvalidKDFParams, p.Version)
+	}
+	return nil
+}
+
+// WeakerThan reports whether p costs an attacker less memory or fewer
+// iterations per guess than q. Parallelism does not change the cost.
+func (p KDFParams) WeakerThan(q KDFParams) bool {
+	return p.Memory < q.Memory || p.Time < q.Time
+}
+
+// Raise returns p with each parameter raised to at least q's, so that the
+// result is weaker than neither.
+func (p KDFParams) Raise(q KDFParams) KDFParams {
+	return KDFParams{
+		Memory:  max(p.Memory, q.Memory),
+		Time:    max(p.Time, q.Time),
+		Threads: max(p.Threads, q.Threads),
+		Version: argon2.Version,
+	}
+}
+
+// String describes p for people, e.g. "64 MiB, 3 iterations, 4 threads".
+func (p KDFParams) String() string {
+	return fmt.Sprintf("%d MiB, %d iterations, %d threads", p.Memory/1024, p.Time, p.Threads)
+}
+
+// DeriveKeyWithParams is DeriveKey with explicit Argon2id parameters.
+// Callers must Validate p first.
+func DeriveKeyWithParams(password, salt []byte, p KDFParams) []byte {
+	return argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, KeyLength)
+}
+
+// CalibrateKDF returns parameters that take about target to derive a key on
+// this machine. Memory and parallelism stay at the defaults; iterations are
+// raised until the target is met, so the result is never weaker than
+// DefaultKDFParams.
+func CalibrateKDF(target time.Duration) KDFParams {
+	return calibrateKDF(target, BenchmarkKDF)
+}
+
+// BenchmarkKDF returns how long deriving one key with p takes on this
+// machine.
+func BenchmarkKDF(p KDFParams) time.Duration {
+	salt := make([]byte, 16)
+	start := time.Now()
+	key := DeriveKeyWithParams([]byte("calibration"), salt, p)
+	elapsed := time.Since(start)
+	SecureWipe(key)
+	return elapsed
+}
+
+func calibrateKDF(target time.Duration, measure func(KDFParams) time.Duration) KDFParams {
+	p := DefaultKDFParams()
+	elapsed := measure(p)
+	if elapsed <= 0 || elapsed >= target {
+		return p
+	}
+	// Argon2 cost is linear in iterations
+	perPass := elapsed / time.Duration(p.Time)
+	if perPass <= 0 {
+		return p
+	}
+	p.Time = uint32(min(int64(target/perPass), MaxKDFTime))
+	p.Time = max(p.Time, Argon2Time)
+	return p
+}
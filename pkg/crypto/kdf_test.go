@@ -0,0 +1,85 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestDeriveKeyWithDefaultParams checks DeriveKeyWithParams matches DeriveKey
+func TestDeriveKeyWithDefaultParams(t *testing.T) {
+	password := []byte("test-password-123")
+	salt := bytes.Repeat([]byte{7}, 16)
+
+	if !bytes.Equal(DeriveKey(password, salt), DeriveKeyWithParams(password, salt, DefaultKDFParams())) {
+		t.Error("DeriveKeyWithParams(DefaultKDFParams()) differs from DeriveKey")
+	}
+}
+
+// TestKDFParamsValidate tests the accepted parameter bounds
+func TestKDFParamsValidate(t *testing.T) {
+	if err := DefaultKDFParams().Validate(); err != nil {
+		t.Errorf("defaults rejected: %v", err)
+	}
+	invalid := []KDFParams{
+		{Memory: 1024, Time: 3, Threads: 4},
+		{Memory: MaxKDFMemory + 1, Time: 3, Threads: 4},
+		{Memory: Argon2Memory, Time: 0, Threads: 4},
+		{Memory: Argon2Memory, Time: MaxKDFTime + 1, Threads: 4},
+		{Memory: Argon2Memory, Time: 3, Threads: 0},
+		{Memory: Argon2Memory, Time: 3, Threads: 4, Version: 0x10},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidKDFParams) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidKDFParams", p, err)
+		}
+	}
+}
+
+// TestKDFParamsWeakerThan tests comparing and raising parameters
+func TestKDFParamsWeakerThan(t *testing.T) {
+	defaults := DefaultKDFParams()
+	weak := KDFParams{Memory: MinKDFMemory, Time: 8, Threads: 1}
+	if !weak.WeakerThan(defaults) {
+		t.Error("less memory should be weaker")
+	}
+	if !defaults.WeakerThan(weak) {
+		t.Error("fewer iterations should be weaker")
+	}
+	if defaults.WeakerThan(defaults) {
+		t.Error("parameters should not be weaker than themselves")
+	}
+	fewerThreads := defaults
+	fewerThreads.Threads = 1
+	if fewerThreads.WeakerThan(defaults) {
+		t.Error("parallelism should not make parameters weaker")
+	}
+
+	raised := weak.Raise(defaults)
+	want := KDFParams{Memory: Argon2Memory, Time: 8, Threads: Argon2Threads, Version: defaults.Version}
+	if raised != want {
+		t.Errorf("Raise = %+v, want %+v", raised, want)
+	}
+	if raised.WeakerThan(weak) || raised.WeakerThan(defaults) {
+		t.Error("raised parameters are weaker than an input")
+	}
+}
+
+// TestCalibrateKDF tests that calibration scales iterations and never weakens
+func TestCalibrateKDF(t *testing.T) {
+	perPass := 100 * time.Millisecond
+	measure := func(p KDFParams) time.Duration { return time.Duration(p.Time) * perPass }
+
+	p := calibrateKDF(time.Second, measure)
+	if p.Time != 10 || p.Memory != Argon2Memory || p.Threads != Argon2Threads {
+		t.Errorf("calibrateKDF(1s) = %+v, want 10 iterations at default memory", p)
+	}
+
+	if p := calibrateKDF(100*time.Millisecond, measure); p != DefaultKDFParams() {
+		t.Errorf("calibrateKDF below default cost = %+v, want defaults", p)
+	}
+	if p := calibrateKDF(time.Hour, measure); p.Time != MaxKDFTime {
+		t.Errorf("calibrateKDF(1h) iterations = %d, want cap %d", p.Time, MaxKDFTime)
+	}
+}
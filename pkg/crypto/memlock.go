@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMemoryNotLocked is returned, together with a usable buffer, by
+// NewLockedBuffer when the buffer's memory could not be locked into RAM
+// (e.g. because RLIMIT_MEMLOCK is exhausted). Its contents may then be
+// written to swap.
+var ErrMemoryNotLocked = errors.New("crypto: memory could not be locked")
+
+// LockedBuffer holds key material outside the Go heap, in memory locked
+// into RAM (mlock on Unix, VirtualLock on Windows) so that it is never
+// swapped to disk. The garbage collector does not copy it, and Destroy
+// wipes it. A LockedBuffer must be destroyed once it is no longer needed.
+type LockedBuffer struct {
+	data   []byte
+	mapped bool // allocated with allocPages
+	locked bool
+}
+
+// NewLockedBuffer returns a zeroed buffer of size bytes. If its memory
+// cannot be locked, the buffer is returned along with ErrMemoryNotLocked.
+func NewLockedBuffer(size int) (*LockedBuffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("crypto: invalid buffer size %d", size)
+	}
+	b := &LockedBuffer{}
+	data, err := allocPages(size)
+	if err != nil {
+		// Heap memory shares pages with other objects and cannot be locked
+		b.data = make([]byte, size)
+		return b, fmt.Errorf("%w: %v", ErrMemoryNotLocked, err)
+	}
+	b.data, b.mapped = data, true
+	if err := lockPages(data); err != nil {
+		return b, fmt.Errorf("%w: %v", ErrMemoryNotLocked, err)
+	}
+	b.locked = true
+	return b, nil
+}
+
+// NewLockedBufferFrom returns a LockedBuffer holding a copy of src, which
+// the caller remains responsible for wiping. Errors are as for
+// NewLockedBuffer.
+func NewLockedBufferFrom(src []byte) (*LockedBuffer, error) {
+	b, err := NewLockedBuffer(len(src))
+	if b != nil {
+		copy(b.data, src)
+	}
+	return b, err
+}
+
+// Bytes returns the buffer's memory. It must not be used after Destroy.
+func (b *LockedBuffer) Bytes() []byte {
+	return b.data
+}
+
+// Locked reports whether the buffer is locked into RAM.
+func (b *LockedBuffer) Locked() bool {
+	return b.locked
+}
+
+// Destroy wipes the buffer and releases its memory. It is safe to call on
+// a nil or already destroyed buffer.
+func (b *LockedBuffer) Destroy() {
+	if b == nil || b.data == nil {
+		return
+	}
+	SecureWipe(b.data)
+	if b.locked {
+		unlockPages(b.data)
+	}
+	if b.mapped {
+		freePages(b.data)
+	}
+	b.data, b.mapped, b.locked = nil, false, false
+}
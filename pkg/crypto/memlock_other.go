@@ -0,0 +1,17 @@
+//go:build !unix && !windows
+
+package crypto
+
+import "errors"
+
+func allocPages(int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func lockPages([]byte) error {
+	return errors.ErrUnsupported
+}
+
+func unlockPages([]byte) {}
+
+func freePages([]byte) {}
@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestLockedBuffer tests copying into, using and destroying a locked buffer
+func TestLockedBuffer(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5a}, KeyLength)
+	buf, err := NewLockedBufferFrom(key)
+	if err != nil && !errors.Is(err, ErrMemoryNotLocked) {
+		t.Fatalf("NewLockedBufferFrom failed: %v", err)
+	}
+	if err != nil {
+		t.Logf("memory not locked: %v", err)
+	}
+	if buf.Locked() != (err == nil) {
+		t.Errorf("Locked() = %v with error %v", buf.Locked(), err)
+	}
+	if !bytes.Equal(buf.Bytes(), key) {
+		t.Fatal("buffer does not hold the key")
+	}
+
+	// The buffer works as a key
+	ciphertext, nonce, err := Encrypt(buf.Bytes(), []byte("secret"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if plaintext, err := Decrypt(key, ciphertext, nonce); err != nil || string(plaintext) != "secret" {
+		t.Errorf("Decrypt = %q, %v", plaintext, err)
+	}
+
+	buf.Destroy()
+	if buf.Bytes() != nil || buf.Locked() {
+		t.Error("buffer still usable after Destroy")
+	}
+	buf.Destroy()
+	var nilBuf *LockedBuffer
+	nilBuf.Destroy()
+}
+
+// TestNewLockedBufferSize tests that invalid sizes are rejected
+func TestNewLockedBufferSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if buf, err := NewLockedBuffer(size); err == nil || buf != nil {
+			t.Errorf("NewLockedBuffer(%d) = %v, %v; want an error", size, buf, err)
+		}
+	}
+	buf, _ := NewLockedBuffer(100)
+	defer buf.Destroy()
+	if len(buf.Bytes()) != 100 || !bytes.Equal(buf.Bytes(), make([]byte, 100)) {
+		t.Error("expected 100 zero bytes")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package crypto
+
+import "golang.org/x/sys/unix"
+
+// allocPages maps size bytes of anonymous memory, rounded up to whole pages.
+func allocPages(size int) ([]byte, error) {
+	data, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func lockPages(b []byte) error {
+	return unix.Mlock(b)
+}
+
+func unlockPages(b []byte) {
+	_ = unix.Munlock(b)
+}
+
+func freePages(b []byte) {
+	_ = unix.Munmap(b)
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package crypto
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// allocPages commits size bytes of memory, rounded up to whole pages.
+func allocPages(size int) ([]byte, error) {
+	addr, err := windows.VirtualAlloc(0, uintptr(size), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil {
+		return nil, err
+	}
+	// The memory is not managed by Go, so the address stays valid
+	return unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), size), nil
+}
+
+func lockPages(b []byte) error {
+	return windows.VirtualLock(uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)))
+}
+
+func unlockPages(b []byte) {
+	_ = windows.VirtualUnlock(uintptr(unsafe.Pointer(&b[0])), uintptr(len(b)))
+}
+
+func freePages(b []byte) {
+	_ = windows.VirtualFree(uintptr(unsafe.Pointer(&b[0])), 0, windows.MEM_RELEASE)
+}
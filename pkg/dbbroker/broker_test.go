@@ -0,0 +1,197 @@
+package dbbroker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// recorder is a database/sql driver that records executed statements.
+type recorder struct {
+	mu    sync.Mutex
+	stmts []string
+	fail  string // statements with this prefix fail
+}
+
+func (r *recorder) Open(string) (driver.Conn, error) { return &recorderConn{r}, nil }
+
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stmts := r.stmts
+	r.stmts = nil
+	return stmts
+}
+
+type recorderConn struct{ r *recorder }
+
+func (c *recorderConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *recorderConn) Close() error                        { return nil }
+func (c *recorderConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *recorderConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.r.stmts = append(c.r.stmts, query)
+	if c.r.fail != "" && strings.HasPrefix(query, c.r.fail) {
+		return nil, errors.New("permission denied")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+var (
+	registerOnce sync.Once
+	rec          = &recorder{}
+)
+
+func testBroker(t *testing.T, adminURL string) (*Broker, *vault.Vault) {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("dbbroker-test", rec) })
+	rec.take()
+	rec.fail = ""
+
+	v := vault.New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	t.Cleanup(v.Lock)
+	if err := v.SetSecret("db/admin", &vault.SecretEntry{Value: []byte(adminURL)}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	b := New(v)
+	b.open = func(_, _ string) (*sql.DB, error) { return sql.Open("dbbroker-test", "") }
+	return b, v
+}
+
+func TestIssueAndRevokePostgres(t *testing.T) {
+	b, _ := testBroker(t, "postgres://admin:pw@localhost:5432/app?sslmode=disable")
+	ctx := context.Background()
+
+	cred, err := b.Issue(ctx, IssueRequest{Key: "db/admin", TTL: time.Hour, Grants: []string{"readonly"}})
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if !strings.HasPrefix(cred.Username, usernamePrefix) || cred.Password == "" {
+		t.Errorf("unexpected credential %+v", cred)
+	}
+	u, err := url.Parse(cred.URL)
+	if err != nil || u.User.Username() != cred.Username || u.Host != "localhost:5432" || u.Query().Get("sslmode") != "disable" {
+		t.Errorf("unexpected URL %q", cred.URL)
+	}
+
+	stmts := rec.take()
+	if len(stmts) != 2 ||
+		!strings.HasPrefix(stmts[0], `CREATE ROLE "`+cred.Username+`" WITH LOGIN PASSWORD '`+cred.Password+`' VALID UNTIL '`) ||
+		stmts[1] != `GRANT "readonly" TO "`+cred.Username+`"` {
+		t.Errorf("unexpected statements %q", stmts)
+	}
+
+	leases, err := b.Leases()
+	if err != nil || len(leases) != 1 || leases[0].Username != cred.Username || leases[0].Engine != EnginePostgres {
+		t.Fatalf("unexpected leases %+v (err %v)", leases, err)
+	}
+
+	if err := b.Revoke(ctx, cred.Lease.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	stmts = rec.take()
+	if len(stmts) != 2 || stmts[1] != `DROP ROLE IF EXISTS "`+cred.Username+`"` {
+		t.Errorf("unexpected revoke statements %q", stmts)
+	}
+	if leases, _ := b.Leases(); len(leases) != 0 {
+		t.Errorf("lease not removed: %+v", leases)
+	}
+	if err := b.Revoke(ctx, cred.Lease.ID); !errors.Is(err, ErrLeaseNotFound) {
+		t.Errorf("expected ErrLeaseNotFound, got %v", err)
+	}
+}
+
+func TestIssueMySQL(t *testing.T) {
+	b, _ := testBroker(t, "mysql://admin:pw@localhost/app")
+
+	cred, err := b.Issue(context.Background(), IssueRequest{Key: "db/admin", Grants: []string{"SELECT ON app.*"}})
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	stmts := rec.take()
+	if len(stmts) != 2 || stmts[1] != "GRANT SELECT ON app.* TO '"+cred.Username+"'@'%'" {
+		t.Errorf("unexpected statements %q", stmts)
+	}
+	if cred.Lease.ExpiresAt.Sub(cred.Lease.CreatedAt) != DefaultTTL {
+		t.Errorf("expected default TTL, got lease %+v", cred.Lease)
+	}
+
+	if _, err := b.Issue(context.Background(), IssueRequest{Key: "db/admin", Grants: []string{"ALL ON *.* ; DROP USER root"}}); !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("expected ErrInvalidGrant, got %v", err)
+	}
+}
+
+func TestIssueFailureRevokesUser(t *testing.T) {
+	b, _ := testBroker(t, "postgres://admin:pw@localhost/app")
+	rec.fail = "GRANT"
+
+	_, err := b.Issue(context.Background(), IssueRequest{Key: "db/admin", Grants: []string{"missing_role"}})
+	if err == nil || strings.Contains(err.Error(), "PASSWORD") {
+		t.Fatalf("expected error without the statement, got %v", err)
+	}
+	stmts := rec.take()
+	if len(stmts) != 4 || !strings.HasPrefix(stmts[3], "DROP ROLE IF EXISTS") {
+		t.Errorf("expected cleanup after failed grant, got %q", stmts)
+	}
+	if leases, _ := b.Leases(); len(leases) != 0 {
+		t.Errorf("lease kept after cleanup: %+v", leases)
+	}
+}
+
+func TestRevokeExpired(t *testing.T) {
+	b, _ := testBroker(t, "postgres://admin:pw@localhost/app")
+	now := time.Now().UTC()
+	for _, l := range []Lease{
+		{ID: "old", Key: "db/admin", Engine: EnginePostgres, Username: "sctl_old", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "new", Key: "db/admin", Engine: EnginePostgres, Username: "sctl_new", ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := b.leases.add(l); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	n, err := b.RevokeExpired(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("RevokeExpired = %d, %v", n, err)
+	}
+	leases, _ := b.Leases()
+	if len(leases) != 1 || leases[0].ID != "new" {
+		t.Errorf("unexpected remaining leases %+v", leases)
+	}
+}
+
+func TestIssueValidation(t *testing.T) {
+	b, v := testBroker(t, "redis://admin:pw@localhost")
+	ctx := context.Background()
+
+	if _, err := b.Issue(ctx, IssueRequest{Key: "db/admin"}); !errors.Is(err, ErrUnsupportedEngine) {
+		t.Errorf("expected ErrUnsupportedEngine, got %v", err)
+	}
+	if _, err := b.Issue(ctx, IssueRequest{Key: "db/admin", TTL: 48 * time.Hour}); err == nil {
+		t.Error("expected TTL error")
+	}
+
+	if err := v.SetSecret("plain", &vault.SecretEntry{Value: []byte("hunter2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := b.Issue(ctx, IssueRequest{Key: "plain"}); !errors.Is(err, ErrInvalidAdminURL) {
+		t.Errorf("expected ErrInvalidAdminURL, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package dbbroker
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Supported engines
+const (
+	EnginePostgres = "postgres"
+	EngineMySQL    = "mysql"
+)
+
+// engine builds the SQL that creates and drops short-lived users.
+type engine interface {
+	driver() string
+	dsn(u *url.URL) (string, error)
+	create(user, password string, expires time.Time, grants []string) ([]string, error)
+	revoke(user string) []string
+}
+
+// engineFor returns the engine for a connection URL scheme.
+func engineFor(scheme string) (string, engine, error) {
+	switch scheme {
+	case "postgres", "postgresql":
+		return EnginePostgres, postgresEngine{}, nil
+	case "mysql":
+		return EngineMySQL, mysqlEngine{}, nil
+	default:
+		return "", nil, fmt.Errorf("%w: %q (use postgres:// or mysql://)", ErrUnsupportedEngine, scheme)
+	}
+}
+
+// postgresEngine creates LOGIN roles with VALID UNTIL, so the server refuses
+// logins after expiry even if revocation never runs.
+type postgresEngine struct{}
+
+func (postgresEngine) driver() string { return "postgres" }
+
+func (postgresEngine) dsn(u *url.URL) (string, error) {
+	return u.String(), nil
+}
+
+func (postgresEngine) create(user, password string, expires time.Time, grants []string) ([]string, error) {
+	stmts := []string{fmt.Sprintf("CREATE ROLE %s WITH LOGIN PASSWORD %s VALID UNTIL %s",
+		pq.QuoteIdentifier(user), pq.QuoteLiteral(password), pq.QuoteLiteral(expires.UTC().Format(time.RFC3339)))}
+	for _, role := range grants {
+		if role == "" {
+			return nil, fmt.Errorf("%w: empty role name", ErrInvalidGrant)
+		}
+		stmts = append(stmts, fmt.Sprintf("GRANT %s TO %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(user)))
+	}
+	return stmts, nil
+}
+
+func (postgresEngine) revoke(user string) []string {
+	return []string{
+		fmt.Sprintf("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = %s", pq.QuoteLiteral(user)),
+		fmt.Sprintf("DROP ROLE IF EXISTS %s", pq.QuoteIdentifier(user)),
+	}
+}
+
+// mysqlEngine creates users that may connect from any host. Grants are
+// privilege clauses such as "SELECT, INSERT ON app.*".
+type mysqlEngine struct{}
+
+func (mysqlEngine) driver() string { return "mysql" }
+
+func (mysqlEngine) dsn(u *url.URL) (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = u.Host
+	if u.Port() == "" {
+		cfg.Addr += ":3306"
+	}
+	cfg.User = u.User.Username()
+	cfg.Passwd, _ = u.User.Password()
+	cfg.DBName = strings.TrimPrefix(u.Path, "/")
+	if tls := u.Query().Get("tls"); tls != "" {
+		cfg.TLSConfig = tls
+	}
+	return cfg.FormatDSN(), nil
+}
+
+func (mysqlEngine) create(user, password string, _ time.Time, grants []string) ([]string, error) {
+	account := mysqlAccount(user)
+	stmts := []string{fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s", account, quoteMySQL(password))}
+	for _, grant := range grants {
+		if grant == "" || !strings.Contains(strings.ToUpper(grant), " ON ") || strings.ContainsAny(grant, ";'\"`\\") || strings.Contains(grant, "--") {
+			return nil, fmt.Errorf("%w: %q (expected e.g. \"SELECT ON app.*\")", ErrInvalidGrant, grant)
+		}
+		stmts = append(stmts, fmt.Sprintf("GRANT %s TO %s", grant, account))
+	}
+	return stmts, nil
+}
+
+func (mysqlEngine) revoke(user string) []string {
+	return []string{fmt.Sprintf("DROP USER IF EXISTS %s", mysqlAccount(user))}
+}
+
+func mysqlAccount(user string) string {
+	return quoteMySQL(user) + "@'%'"
+}
+
+// quoteMySQL quotes a MySQL string literal.
+func quoteMySQL(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,102 @@
+package dbbroker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// LeaseFileName is the file in the vault directory that tracks issued users.
+// It holds no passwords: revocation uses the admin credential from the vault.
+const LeaseFileName = "db_leases.json"
+
+// Lease records a database user issued by the broker.
+type Lease struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"` // vault key of the admin credential
+	Engine    string    `json:"engine"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the lease has passed its expiry at now.
+func (l *Lease) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// leaseStore persists leases as JSON next to the vault.
+type leaseStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *leaseStore) load() ([]Lease, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("dbbroker: failed to read leases: %w", err)
+	}
+	var leases []Lease
+	if err := json.Unmarshal(data, &leases); err != nil {
+		return nil, fmt.Errorf("dbbroker: failed to parse %s: %w", filepath.Base(s.path), err)
+	}
+	return leases, nil
+}
+
+func (s *leaseStore) save(leases []Lease) error {
+	sort.Slice(leases, func(i, j int) bool { return leases[i].ExpiresAt.Before(leases[j].ExpiresAt) })
+	data, err := json.MarshalIndent(leases, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(s.path, data, vault.FileMode); err != nil {
+		return fmt.Errorf("dbbroker: failed to write leases: %w", err)
+	}
+	return nil
+}
+
+// update loads the leases, applies fn and saves the result.
+func (s *leaseStore) update(fn func([]Lease) []Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leases, err := s.load()
+	if err != nil {
+		return err
+	}
+	return s.save(fn(leases))
+}
+
+func (s *leaseStore) list() ([]Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *leaseStore) add(l Lease) error {
+	return s.update(func(leases []Lease) []Lease {
+		return append(leases, l)
+	})
+}
+
+func (s *leaseStore) remove(id string) error {
+	return s.update(func(leases []Lease) []Lease {
+		kept := leases[:0]
+		for _, l := range leases {
+			if l.ID != id {
+				kept = append(kept, l)
+			}
+		}
+		return kept
+	})
+}
@@ -0,0 +1,220 @@
+// Package emergency implements the cryptography for emergency access: a
+// trusted contact's key pair, recovery bundles sealed to the contact, and
+// signed access requests.
+//
+// A contact key pair holds an X25519 key, to which the owner's vault seals
+// its recovery bundle, and an Ed25519 key with which the contact signs
+// access requests. Both travel together as one text line:
+//
+//	secretctl-emergency-pub-v1:<base64>   public key, given to the owner
+//	secretctl-emergency-key-v1:<base64>   private key, kept by the contact
+package emergency
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// Key encoding prefixes
+const (
+	PublicKeyPrefix  = "secretctl-emergency-pub-v1:"
+	PrivateKeyPrefix = "secretctl-emergency-key-v1:"
+)
+
+// Errors
+var (
+	ErrInvalidKey       = errors.New("emergency: invalid key")
+	ErrInvalidBundle    = errors.New("emergency: bundle cannot be opened with this key")
+	ErrInvalidSignature = errors.New("emergency: invalid request signature")
+)
+
+// sealInfo binds sealed bundles to this package and version.
+const sealInfo = "secretctl-emergency-seal-v1"
+
+// requestContext prefixes signed request messages.
+const requestContext = "secretctl-emergency-request-v1"
+
+// PublicKey is the public half of a contact key pair.
+type PublicKey struct {
+	box  *ecdh.PublicKey
+	sign ed25519.PublicKey
+}
+
+// PrivateKey is a contact key pair.
+type PrivateKey struct {
+	box  *ecdh.PrivateKey
+	sign ed25519.PrivateKey
+}
+
+// GenerateKey creates a new contact key pair.
+func GenerateKey() (*PrivateKey, error) {
+	box, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("emergency: failed to generate key: %w", err)
+	}
+	_, sign, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("emergency: failed to generate key: %w", err)
+	}
+	return &PrivateKey{box: box, sign: sign}, nil
+}
+
+// Public returns the public half of k.
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{box: k.box.PublicKey(), sign: k.sign.Public().(ed25519.PublicKey)}
+}
+
+// String encodes k with PrivateKeyPrefix.
+func (k *PrivateKey) String() string {
+	raw := append(k.box.Bytes(), k.sign.Seed()...)
+	return PrivateKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// String encodes p with PublicKeyPrefix.
+func (p *PublicKey) String() string {
+	raw := append(p.box.Bytes(), p.sign...)
+	return PublicKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Equal reports whether p and other are the same key.
+func (p *PublicKey) Equal(other *PublicKey) bool {
+	return other != nil && p.box.Equal(other.box) && p.sign.Equal(other.sign)
+}
+
+// ParsePublicKey decodes a key encoded by PublicKey.String.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	raw, err := decode(s, PublicKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	box, err := ecdh.X25519().NewPublicKey(raw[:32])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return &PublicKey{box: box, sign: ed25519.PublicKey(raw[32:])}, nil
+}
+
+// ParsePrivateKey decodes a key encoded by PrivateKey.String.
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+	raw, err := decode(s, PrivateKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	box, err := ecdh.X25519().NewPrivateKey(raw[:32])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return &PrivateKey{box: box, sign: ed25519.NewKeyFromSeed(raw[32:])}, nil
+}
+
+// decode strips prefix and decodes the 64 bytes of a key.
+func decode(s, prefix string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, prefix) {
+		return nil, fmt.Errorf("%w: expected %s...", ErrInvalidKey, prefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, prefix))
+	if err != nil || len(raw) != 64 {
+		return nil, fmt.Errorf("%w: malformed key", ErrInvalidKey)
+	}
+	return raw, nil
+}
+
+// Seal encrypts plaintext so that only the holder of to's private key can
+// open it: an ephemeral X25519 key agreement, HKDF-SHA256 and AES-256-GCM.
+// The result is ephemeral public key || nonce || ciphertext.
+func Seal(to *PublicKey, plaintext []byte) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("emergency: failed to generate key: %w", err)
+	}
+	key, err := sealKey(ephemeral, to.box, ephemeral.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(key)
+
+	ciphertext, nonce, err := crypto.Encrypt(key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	sealed := append(ephemeral.PublicKey().Bytes(), nonce...)
+	return append(sealed, ciphertext...), nil
+}
+
+// Open decrypts a bundle sealed to k's public key.
+func Open(k *PrivateKey, sealed []byte) ([]byte, error) {
+	if len(sealed) < 32+crypto.NonceLength {
+		return nil, ErrInvalidBundle
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(sealed[:32])
+	if err != nil {
+		return nil, ErrInvalidBundle
+	}
+	key, err := sealKey(k.box, ephemeral, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(key)
+
+	nonce := sealed[32 : 32+crypto.NonceLength]
+	plaintext, err := crypto.Decrypt(key, sealed[32+crypto.NonceLength:], nonce)
+	if err != nil {
+		return nil, ErrInvalidBundle
+	}
+	return plaintext, nil
+}
+
+// sealKey derives the AES key from an X25519 agreement between priv and
+// peer, bound to the ephemeral public key.
+func sealKey(priv *ecdh.PrivateKey, peer, ephemeral *ecdh.PublicKey) ([]byte, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, ErrInvalidBundle
+	}
+	defer crypto.SecureWipe(shared)
+
+	key := make([]byte, crypto.KeyLength)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, ephemeral.Bytes(), []byte(sealInfo)), key); err != nil {
+		return nil, fmt.Errorf("emergency: failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// Request is a contact's signed request for emergency access.
+type Request struct {
+	Contact     string    `json:"contact"`
+	RequestedAt time.Time `json:"requested_at"`
+	Signature   []byte    `json:"signature"`
+}
+
+// SignRequest creates a request by contact at t, signed with k.
+func SignRequest(k *PrivateKey, contact string, t time.Time) *Request {
+	r := &Request{Contact: contact, RequestedAt: t.UTC().Truncate(time.Second)}
+	r.Signature = ed25519.Sign(k.sign, r.message())
+	return r
+}
+
+// Verify checks that r was signed by the holder of p.
+func (r *Request) Verify(p *PublicKey) error {
+	if !ed25519.Verify(p.sign, r.message(), r.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (r *Request) message() []byte {
+	return []byte(requestContext + "\n" + r.Contact + "\n" + r.RequestedAt.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,88 @@
+package emergency
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyEncoding(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	parsed, err := ParsePrivateKey(key.String() + "\n")
+	if err != nil {
+		t.Fatalf("ParsePrivateKey failed: %v", err)
+	}
+	if parsed.String() != key.String() {
+		t.Error("private key did not round-trip")
+	}
+	pub, err := ParsePublicKey(key.Public().String())
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	if !pub.Equal(key.Public()) {
+		t.Error("public key did not round-trip")
+	}
+
+	for _, s := range []string{"", key.String(), PublicKeyPrefix + "!!", PublicKeyPrefix + "AAAA"} {
+		if _, err := ParsePublicKey(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParsePublicKey(%q): expected ErrInvalidKey, got %v", s, err)
+		}
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key, _ := GenerateKey()
+	other, _ := GenerateKey()
+	plaintext := []byte("0123456789abcdef0123456789abcdef")
+
+	sealed, err := Seal(key.Public(), plaintext)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Fatal("sealed bundle contains the plaintext")
+	}
+	got, err := Open(key, sealed)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Open = %q, want %q", got, plaintext)
+	}
+
+	if _, err := Open(other, sealed); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("expected ErrInvalidBundle with another key, got %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := Open(key, sealed); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("expected ErrInvalidBundle for a modified bundle, got %v", err)
+	}
+	if _, err := Open(key, sealed[:10]); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("expected ErrInvalidBundle for a short bundle, got %v", err)
+	}
+}
+
+func TestRequestSignature(t *testing.T) {
+	key, _ := GenerateKey()
+	other, _ := GenerateKey()
+	req := SignRequest(key, "alice", time.Now())
+
+	if err := req.Verify(key.Public()); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if err := req.Verify(other.Public()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for another key, got %v", err)
+	}
+
+	// Backdating a request invalidates it
+	backdated := *req
+	backdated.RequestedAt = req.RequestedAt.Add(-30 * 24 * time.Hour)
+	if err := backdated.Verify(key.Public()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for a backdated request, got %v", err)
+	}
+}
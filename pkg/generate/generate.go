@@ -0,0 +1,186 @@
+// Package generate creates random passwords and diceware passphrases for
+// secret values.
+//
+// Randomness comes from crypto/rand. Passwords are drawn uniformly from a
+// character set built from the enabled classes; passphrases are words of
+// the EFF large diceware list, about 12.9 bits each.
+package generate
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/sethvargo/go-diceware/diceware"
+)
+
+// Character classes
+const (
+	Lowercase = "abcdefghijklmnopqrstuvwxyz"
+	Uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits    = "0123456789"
+	Symbols   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+)
+
+// Password length and passphrase word count limits
+const (
+	MinLength     = 8
+	MaxLength     = 256
+	DefaultLength = 24
+
+	MinWords     = 4
+	MaxWords     = 20
+	DefaultWords = 6
+)
+
+// ErrEmptyCharset is returned when the options leave no characters to use.
+var ErrEmptyCharset = errors.New("generate: character set is empty: include at least one character type")
+
+// maxAttempts bounds the retries of Password for a value that contains
+// every class; the chance of needing more is negligible.
+const maxAttempts = 1000
+
+// Options configures Password. The zero value generates DefaultLength
+// characters from all four classes.
+type Options struct {
+	Length      int // DefaultLength if 0
+	NoLowercase bool
+	NoUppercase bool
+	NoDigits    bool
+	NoSymbols   bool
+	// Exclude lists characters never to use, e.g. the ambiguous "0O1lI"
+	Exclude string
+}
+
+// classes returns the enabled character classes with Exclude removed,
+// dropping classes that become empty.
+func (o Options) classes() []string {
+	var classes []string
+	for _, c := range []struct {
+		chars string
+		off   bool
+	}{
+		{Lowercase, o.NoLowercase},
+		{Uppercase, o.NoUppercase},
+		{Digits, o.NoDigits},
+		{Symbols, o.NoSymbols},
+	} {
+		if c.off {
+			continue
+		}
+		if chars := RemoveChars(c.chars, o.Exclude); chars != "" {
+			classes = append(classes, chars)
+		}
+	}
+	return classes
+}
+
+// Charset returns the characters Password draws from.
+func (o Options) Charset() (string, error) {
+	charset := strings.Join(o.classes(), "")
+	if charset == "" {
+		return "", ErrEmptyCharset
+	}
+	return charset, nil
+}
+
+// Password returns a random password for opts. When the length allows it,
+// the password contains at least one character of every enabled class, as
+// many sites require; passwords that do not are drawn again, which keeps
+// the result uniform among those that do.
+func Password(opts Options) (string, error) {
+	length := opts.Length
+	if length == 0 {
+		length = DefaultLength
+	}
+	if length < MinLength || length > MaxLength {
+		return "", fmt.Errorf("generate: password length must be between %d and %d", MinLength, MaxLength)
+	}
+	charset, err := opts.Charset()
+	if err != nil {
+		return "", err
+	}
+	classes := opts.classes()
+
+	for range maxAttempts {
+		password, err := FromCharset(charset, length)
+		if err != nil {
+			return "", err
+		}
+		if length < len(classes) || hasEvery(password, classes) {
+			return password, nil
+		}
+	}
+	return "", errors.New("generate: failed to generate a password containing every character class")
+}
+
+func hasEvery(password string, classes []string) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(password, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// FromCharset returns length characters drawn uniformly from charset, which
+// must consist of single-byte characters.
+func FromCharset(charset string, length int) (string, error) {
+	if charset == "" {
+		return "", ErrEmptyCharset
+	}
+	charsetLen := big.NewInt(int64(len(charset)))
+	password := make([]byte, length)
+	for i := range password {
+		idx, err := rand.Int(rand.Reader, charsetLen)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random number: %w", err)
+		}
+		password[i] = charset[idx.Int64()]
+	}
+	return string(password), nil
+}
+
+// RemoveChars returns s without the characters in chars.
+func RemoveChars(s, chars string) string {
+	exclude := make(map[rune]bool)
+	for _, c := range chars {
+		exclude[c] = true
+	}
+	var result strings.Builder
+	for _, c := range s {
+		if !exclude[c] {
+			result.WriteRune(c)
+		}
+	}
+	return result.String()
+}
+
+// PassphraseOptions configures Passphrase. The zero value generates
+// DefaultWords words separated by hyphens (about 77 bits).
+type PassphraseOptions struct {
+	Words     int    // DefaultWords if 0
+	Separator string // "-" if empty
+}
+
+// Passphrase returns random words from the EFF large diceware list.
+func Passphrase(opts PassphraseOptions) (string, error) {
+	n := opts.Words
+	if n == 0 {
+		n = DefaultWords
+	}
+	if n < MinWords || n > MaxWords {
+		return "", fmt.Errorf("generate: passphrase must have between %d and %d words", MinWords, MaxWords)
+	}
+	sep := opts.Separator
+	if sep == "" {
+		sep = "-"
+	}
+	words, err := diceware.Generate(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate passphrase: %w", err)
+	}
+	return strings.Join(words, sep), nil
+}
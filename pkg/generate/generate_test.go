@@ -0,0 +1,71 @@
+package generate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPassword(t *testing.T) {
+	password, err := Password(Options{})
+	if err != nil {
+		t.Fatalf("Password failed: %v", err)
+	}
+	if len(password) != DefaultLength {
+		t.Errorf("len = %d, want %d", len(password), DefaultLength)
+	}
+
+	// Every enabled class is present, even at the minimum length
+	for range 200 {
+		password, err := Password(Options{Length: MinLength})
+		if err != nil {
+			t.Fatalf("Password failed: %v", err)
+		}
+		for _, class := range []string{Lowercase, Uppercase, Digits, Symbols} {
+			if !strings.ContainsAny(password, class) {
+				t.Fatalf("%q has no character of %q", password, class)
+			}
+		}
+	}
+
+	password, err = Password(Options{Length: 64, NoSymbols: true, NoUppercase: true, Exclude: "0O1lI"})
+	if err != nil {
+		t.Fatalf("Password failed: %v", err)
+	}
+	if strings.ContainsAny(password, Symbols+Uppercase+"01l") {
+		t.Errorf("%q contains excluded characters", password)
+	}
+
+	if _, err := Password(Options{NoLowercase: true, NoUppercase: true, NoSymbols: true, Exclude: Digits}); !errors.Is(err, ErrEmptyCharset) {
+		t.Errorf("expected ErrEmptyCharset, got %v", err)
+	}
+	for _, length := range []int{MinLength - 1, MaxLength + 1} {
+		if _, err := Password(Options{Length: length}); err == nil {
+			t.Errorf("Password accepted length %d", length)
+		}
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	phrase, err := Passphrase(PassphraseOptions{})
+	if err != nil {
+		t.Fatalf("Passphrase failed: %v", err)
+	}
+	if words := strings.Split(phrase, "-"); len(words) != DefaultWords {
+		t.Errorf("%q has %d words, want %d", phrase, len(words), DefaultWords)
+	}
+
+	phrase, err = Passphrase(PassphraseOptions{Words: MinWords, Separator: " "})
+	if err != nil {
+		t.Fatalf("Passphrase failed: %v", err)
+	}
+	if words := strings.Fields(phrase); len(words) != MinWords {
+		t.Errorf("%q has %d words, want %d", phrase, len(words), MinWords)
+	}
+
+	for _, n := range []int{MinWords - 1, MaxWords + 1} {
+		if _, err := Passphrase(PassphraseOptions{Words: n}); err == nil {
+			t.Errorf("Passphrase accepted %d words", n)
+		}
+	}
+}
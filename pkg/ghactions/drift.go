@@ -0,0 +1,59 @@
+package ghactions
+
+import (
+	"sort"
+	"time"
+)
+
+// Local is a vault secret as it would be named in GitHub.
+type Local struct {
+	Name      string
+	UpdatedAt time.Time
+}
+
+// Drift compares local secrets with a repository's secrets by name.
+type Drift struct {
+	Missing []string // local only
+	Stale   []string // changed locally after the last upload
+	InSync  []string // uploaded after the last local change
+	Extra   []string // repository only
+}
+
+// IsClean reports whether nothing needs to be pushed or removed.
+func (d *Drift) IsClean() bool {
+	return len(d.Missing) == 0 && len(d.Stale) == 0 && len(d.Extra) == 0
+}
+
+// Diff computes the drift between local and remote. All name lists are sorted.
+func Diff(local []Local, remote []Secret) *Drift {
+	remoteByName := make(map[string]Secret, len(remote))
+	for _, s := range remote {
+		remoteByName[s.Name] = s
+	}
+
+	d := &Drift{}
+	seen := make(map[string]bool, len(local))
+	for _, l := range local {
+		seen[l.Name] = true
+		r, ok := remoteByName[l.Name]
+		switch {
+		case !ok:
+			d.Missing = append(d.Missing, l.Name)
+		case r.UpdatedAt.Before(l.UpdatedAt):
+			d.Stale = append(d.Stale, l.Name)
+		default:
+			d.InSync = append(d.InSync, l.Name)
+		}
+	}
+	for _, s := range remote {
+		if !seen[s.Name] {
+			d.Extra = append(d.Extra, s.Name)
+		}
+	}
+
+	sort.Strings(d.Missing)
+	sort.Strings(d.Stale)
+	sort.Strings(d.InSync)
+	sort.Strings(d.Extra)
+	return d
+}
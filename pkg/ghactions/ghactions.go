@@ -0,0 +1,222 @@
+// Package ghactions manages GitHub Actions repository secrets through the
+// GitHub REST API.
+//
+// Secret values are sealed client-side with the repository's public key
+// (libsodium sealed box), so GitHub only ever receives ciphertext. The API
+// never returns values, so drift is judged by name and update time.
+package ghactions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// DefaultBaseURL is the API endpoint of github.com.
+const DefaultBaseURL = "https://api.github.com"
+
+// DefaultTimeout bounds a single API request.
+const DefaultTimeout = 30 * time.Second
+
+// apiVersion is the REST API version requested from GitHub.
+const apiVersion = "2022-11-28"
+
+// Errors
+var (
+	ErrInvalidRepo       = errors.New("ghactions: repository must be in owner/name form")
+	ErrInvalidSecretName = errors.New("ghactions: invalid secret name")
+	ErrInvalidPublicKey  = errors.New("ghactions: invalid repository public key")
+)
+
+// Client talks to the GitHub Actions secrets API of one repository.
+type Client struct {
+	// BaseURL is the API endpoint; empty means DefaultBaseURL.
+	BaseURL string
+	// Token is a token allowed to manage the repository's Actions secrets.
+	Token string
+	// Repo is the repository in owner/name form.
+	Repo string
+	// HTTPClient is the client to use; nil means a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+// PublicKey is a repository's secret encryption key.
+type PublicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"` // base64 Curve25519 public key
+}
+
+// Secret describes a repository secret. GitHub never returns values.
+type Secret struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidateRepo checks that repo is in owner/name form.
+func ValidateRepo(repo string) error {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return ErrInvalidRepo
+	}
+	return nil
+}
+
+// ValidateSecretName checks a name against GitHub's secret naming rules:
+// alphanumerics and underscores, not starting with a digit or GITHUB_.
+func ValidateSecretName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidSecretName)
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return fmt.Errorf("%w: '%s' starts with a digit", ErrInvalidSecretName, name)
+	}
+	if strings.HasPrefix(strings.ToUpper(name), "GITHUB_") {
+		return fmt.Errorf("%w: '%s' uses the reserved GITHUB_ prefix", ErrInvalidSecretName, name)
+	}
+	for _, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return fmt.Errorf("%w: '%s' contains '%c'", ErrInvalidSecretName, name, c)
+		}
+	}
+	return nil
+}
+
+// Seal encrypts value for the repository public key.
+func Seal(value []byte, key *PublicKey) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(key.Key)
+	if err != nil || len(raw) != 32 {
+		return "", ErrInvalidPublicKey
+	}
+	var pub [32]byte
+	copy(pub[:], raw)
+	sealed, err := box.SealAnonymous(nil, value, &pub, rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("ghactions: failed to seal value: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// PublicKey fetches the repository's secret encryption key.
+func (c *Client) PublicKey(ctx context.Context) (*PublicKey, error) {
+	var key PublicKey
+	if err := c.do(ctx, http.MethodGet, "actions/secrets/public-key", nil, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListSecrets returns all repository secrets sorted by name.
+func (c *Client) ListSecrets(ctx context.Context) ([]Secret, error) {
+	var all []Secret
+	for page := 1; ; page++ {
+		var resp struct {
+			TotalCount int      `json:"total_count"`
+			Secrets    []Secret `json:"secrets"`
+		}
+		path := fmt.Sprintf("actions/secrets?per_page=100&page=%d", page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Secrets...)
+		if len(resp.Secrets) == 0 || len(all) >= resp.TotalCount {
+			break
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all, nil
+}
+
+// PutSecret creates or updates a secret with a value sealed for key.
+func (c *Client) PutSecret(ctx context.Context, name string, value []byte, key *PublicKey) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+	sealed, err := Seal(value, key)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{"encrypted_value": sealed, "key_id": key.KeyID}
+	return c.do(ctx, http.MethodPut, "actions/secrets/"+url.PathEscape(name), body, nil)
+}
+
+// DeleteSecret removes a secret.
+func (c *Client) DeleteSecret(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "actions/secrets/"+url.PathEscape(name), nil, nil)
+}
+
+// do sends a request for path below the repository and decodes the JSON
+// response into out, if non-nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	if err := ValidateRepo(c.Repo); err != nil {
+		return err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	endpoint := strings.TrimRight(base, "/") + "/repos/" + c.Repo + "/" + path
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("ghactions: failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("ghactions: failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	req.Header.Set("User-Agent", "secretctl")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ghactions: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("ghactions: failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("ghactions: %s %s: %s (%s)", method, path, resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("ghactions: %s %s: %s", method, path, resp.Status)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("ghactions: failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package ghactions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+func TestValidateSecretName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"NPM_TOKEN", true},
+		{"ci_token2", true},
+		{"", false},
+		{"2FA_SEED", false},
+		{"GITHUB_PAT", false},
+		{"github_pat", false},
+		{"NPM-TOKEN", false},
+	}
+	for _, tt := range tests {
+		err := ValidateSecretName(tt.name)
+		if tt.ok && err != nil {
+			t.Errorf("ValidateSecretName(%q) = %v, want nil", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidSecretName) {
+			t.Errorf("ValidateSecretName(%q) = %v, want ErrInvalidSecretName", tt.name, err)
+		}
+	}
+}
+
+func TestValidateRepo(t *testing.T) {
+	for repo, ok := range map[string]bool{"org/repo": true, "org": false, "/repo": false, "org/": false, "a/b/c": false} {
+		if err := ValidateRepo(repo); (err == nil) != ok {
+			t.Errorf("ValidateRepo(%q) = %v, want ok=%v", repo, err, ok)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := map[string][]byte{}
+	now := time.Now().UTC().Truncate(time.Second)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/org/repo/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(PublicKey{KeyID: "k1", Key: base64.StdEncoding.EncodeToString(pub[:])})
+	})
+	mux.HandleFunc("GET /repos/org/repo/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		// One secret per page to exercise pagination
+		names := []string{"A", "B", "C"}
+		var page int
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		resp := map[string]interface{}{"total_count": len(names), "secrets": []Secret{}}
+		if page >= 1 && page <= len(names) {
+			resp["secrets"] = []Secret{{Name: names[page-1], UpdatedAt: now}}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("PUT /repos/org/repo/actions/secrets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			EncryptedValue string `json:"encrypted_value"`
+			KeyID          string `json:"key_id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sealed, _ := base64.StdEncoding.DecodeString(body.EncryptedValue)
+		value, ok := box.OpenAnonymous(nil, sealed, pub, priv)
+		if !ok || body.KeyID != "k1" {
+			http.Error(w, `{"message":"bad value"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		stored[r.PathValue("name")] = value
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("DELETE /repos/org/repo/actions/secrets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		delete(stored, r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &Client{BaseURL: srv.URL, Token: "tok", Repo: "org/repo"}
+
+	key, err := c.PublicKey(ctx)
+	if err != nil {
+		t.Fatalf("PublicKey failed: %v", err)
+	}
+	if err := c.PutSecret(ctx, "NPM_TOKEN", []byte("s3cret"), key); err != nil {
+		t.Fatalf("PutSecret failed: %v", err)
+	}
+	if string(stored["NPM_TOKEN"]) != "s3cret" {
+		t.Errorf("stored value = %q, want s3cret", stored["NPM_TOKEN"])
+	}
+	if err := c.PutSecret(ctx, "GITHUB_X", []byte("x"), key); !errors.Is(err, ErrInvalidSecretName) {
+		t.Errorf("PutSecret with reserved name = %v, want ErrInvalidSecretName", err)
+	}
+	if err := c.DeleteSecret(ctx, "NPM_TOKEN"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if _, ok := stored["NPM_TOKEN"]; ok {
+		t.Error("secret not deleted")
+	}
+
+	secrets, err := c.ListSecrets(ctx)
+	if err != nil {
+		t.Fatalf("ListSecrets failed: %v", err)
+	}
+	if len(secrets) != 3 {
+		t.Errorf("ListSecrets returned %d secrets, want 3", len(secrets))
+	}
+
+	bad := &Client{BaseURL: srv.URL, Token: "wrong", Repo: "org/repo"}
+	if _, err := bad.PublicKey(ctx); err == nil {
+		t.Error("expected error with bad credentials")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	local := []Local{
+		{Name: "NEW", UpdatedAt: t0},
+		{Name: "CHANGED", UpdatedAt: t0.Add(time.Hour)},
+		{Name: "SAME", UpdatedAt: t0},
+	}
+	remote := []Secret{
+		{Name: "CHANGED", UpdatedAt: t0},
+		{Name: "SAME", UpdatedAt: t0.Add(time.Minute)},
+		{Name: "OLD", UpdatedAt: t0},
+	}
+	d := Diff(local, remote)
+	want := &Drift{Missing: []string{"NEW"}, Stale: []string{"CHANGED"}, InSync: []string{"SAME"}, Extra: []string{"OLD"}}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Diff = %+v, want %+v", d, want)
+	}
+	if d.IsClean() {
+		t.Error("drift should not be clean")
+	}
+	if !Diff(local[2:], remote[1:2]).IsClean() {
+		t.Error("matching sets should be clean")
+	}
+}
@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"errors"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// errorMessages describes well-known sentinel errors for people. The most
+// specific sentinels come first; the first one err wraps is used.
+var errorMessages = []struct {
+	err error
+	msg string
+}{
+	{vault.ErrVaultNotFound, "No vault found. Run 'secretctl init' to create one."},
+	{vault.ErrVaultAlreadyExists, "A vault already exists here."},
+	{vault.ErrVaultLocked, "The vault is locked."},
+	{vault.ErrVaultReadOnly, "The vault is read-only."},
+	{vault.ErrVaultExpired, "The vault has expired."},
+	{vault.ErrInvalidPassword, "Incorrect master password."},
+	{vault.ErrTooManyAttempts, "Too many failed unlock attempts."},
+	{vault.ErrCooldownActive, "Too many failed attempts; wait before trying again."},
+	{vault.ErrSecretNotFound, "Secret not found."},
+	{vault.ErrSecretExpired, "The secret has expired."},
+	{vault.ErrSecretNotYetValid, "The secret cannot be read yet."},
+	{vault.ErrOutsideAccessWindow, "The secret cannot be read outside its access window."},
+	{vault.ErrSecretDeprecated, "The key is deprecated."},
+	{vault.ErrSecretImmutable, "The secret is immutable; changing it requires break-glass."},
+	{vault.ErrSecretFrozen, "The secret is in a freeze window; changing it requires break-glass."},
+	{vault.ErrExpirationRequired, "Secrets with this key need an expiration date (--expires)."},
+	{vault.ErrValidationFailed, "A field value has the wrong format."},
+	{vault.ErrKeyTooLong, "The key name is too long."},
+	{vault.ErrKeyTooShort, "The key name is too short."},
+	{vault.ErrKeyInvalid, "The key name contains characters that are not allowed."},
+	{vault.ErrPasswordTooShort, "The password must be at least 8 characters."},
+	{vault.ErrPasswordTooLong, "The password must be at most 128 characters."},
+	{vault.ErrSamePassword, "The new password must differ from the current one."},
+	{vault.ErrInsufficientDisk, "Not enough disk space."},
+	{vault.ErrVaultCorrupted, "The vault is corrupted. Restore it from a backup."},
+	{vault.ErrPluginNotAllowed, "The plugin is not enabled (see 'secretctl config get dynamic_plugins')."},
+}
+
+// Error returns a message for err in the current locale. In English it is
+// err.Error() unchanged. In other locales, errors wrapping a known sentinel
+// get a translated description followed by the original text in
+// parentheses, so the detail and the searchable English message survive.
+func Error(err error) string {
+	if err == nil {
+		return ""
+	}
+	if Locale() == English {
+		return err.Error()
+	}
+	for _, m := range errorMessages {
+		if errors.Is(err, m.err) {
+			return T(m.msg) + " (" + err.Error() + ")"
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,92 @@
+// Package i18n translates user-facing CLI and desktop strings.
+//
+// Messages are identified by their English text, gettext style:
+// T("Enter master password: ") returns the Japanese prompt when the locale
+// is ja and the argument itself otherwise, so untranslated strings degrade
+// to English. Error translates errors by the sentinel they wrap; the
+// sentinels and their English text stay unchanged so callers can keep
+// matching them with errors.Is.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Supported locales
+const (
+	English  = "en"
+	Japanese = "ja"
+)
+
+// catalogs maps a locale to translations keyed by English text.
+var catalogs = map[string]map[string]string{
+	Japanese: ja,
+}
+
+var current atomic.Value // string
+
+// ParseLocale normalizes a locale such as "ja_JP.UTF-8" to a supported
+// one. Empty means the system locale.
+func ParseLocale(s string) (string, error) {
+	if s == "" {
+		return SystemLocale(), nil
+	}
+	lang := strings.ToLower(s)
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	switch lang {
+	case English, Japanese:
+		return lang, nil
+	case "c", "posix":
+		return English, nil
+	}
+	return "", fmt.Errorf("unsupported locale %q (use %s or %s)", s, English, Japanese)
+}
+
+// SystemLocale derives the locale from LC_ALL, LC_MESSAGES or LANG
+// (the first that is set), defaulting to English.
+func SystemLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			if locale, err := ParseLocale(value); err == nil {
+				return locale
+			}
+			break
+		}
+	}
+	return English
+}
+
+// SetLocale selects the locale used by T and Error. Unsupported values
+// select English.
+func SetLocale(locale string) {
+	if _, ok := catalogs[locale]; !ok {
+		locale = English
+	}
+	current.Store(locale)
+}
+
+// Locale returns the selected locale. Until SetLocale is called it is the
+// system locale.
+func Locale() string {
+	if locale, ok := current.Load().(string); ok {
+		return locale
+	}
+	return SystemLocale()
+}
+
+// T translates msg and formats it with args like fmt.Sprintf. Without
+// args, msg is returned translated but not formatted.
+func T(msg string, args ...any) string {
+	if translated, ok := catalogs[Locale()][msg]; ok {
+		msg = translated
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
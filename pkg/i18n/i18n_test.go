@@ -0,0 +1,99 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { SetLocale(English) })
+
+	SetLocale(English)
+	if got := T("Enter master password: "); got != "Enter master password: " {
+		t.Errorf("en T = %q", got)
+	}
+
+	SetLocale(Japanese)
+	if got := T("Enter master password: "); got != "マスターパスワードを入力: " {
+		t.Errorf("ja T = %q", got)
+	}
+	if got := T("Error: %s", "boom"); got != "エラー: boom" {
+		t.Errorf("ja T with args = %q", got)
+	}
+	if got := T("untranslated %d", 3); got != "untranslated 3" {
+		t.Errorf("untranslated T = %q, want English fallback", got)
+	}
+
+	SetLocale("fr")
+	if Locale() != English {
+		t.Errorf("unsupported locale selected %q, want en", Locale())
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	for in, want := range map[string]string{"ja_JP.UTF-8": Japanese, "en-US": English, "C": English} {
+		if got, err := ParseLocale(in); err != nil || got != want {
+			t.Errorf("ParseLocale(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseLocale("de_DE"); err == nil {
+		t.Error("expected error for unsupported locale")
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "ja_JP.UTF-8")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := SystemLocale(); got != Japanese {
+		t.Errorf("SystemLocale = %q, want LC_MESSAGES to win over LANG", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	t.Cleanup(func() { SetLocale(English) })
+	err := fmt.Errorf("failed to get secret: %w", vault.ErrSecretNotFound)
+
+	SetLocale(English)
+	if got := Error(err); got != err.Error() {
+		t.Errorf("en Error = %q", got)
+	}
+
+	SetLocale(Japanese)
+	got := Error(err)
+	if !strings.HasPrefix(got, "シークレットが見つかりません。") || !strings.Contains(got, err.Error()) {
+		t.Errorf("ja Error = %q", got)
+	}
+	if !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Error("sentinel no longer matches")
+	}
+
+	plain := errors.New("something else")
+	if got := Error(plain); got != "something else" {
+		t.Errorf("ja Error for unknown error = %q", got)
+	}
+	if Error(nil) != "" {
+		t.Error("Error(nil) should be empty")
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+func TestCatalogs(t *testing.T) {
+	for _, m := range errorMessages {
+		if _, ok := ja[m.msg]; !ok {
+			t.Errorf("no ja translation for %q", m.msg)
+		}
+	}
+	for msg, translated := range ja {
+		want := verbPattern.FindAllString(msg, -1)
+		got := verbPattern.FindAllString(translated, -1)
+		if !slices.Equal(want, got) {
+			t.Errorf("ja %q has verbs %v, want %v", msg, got, want)
+		}
+	}
+}
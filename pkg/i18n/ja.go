@@ -0,0 +1,76 @@
+package i18n
+
+// ja holds the Japanese translations. Keys are the exact English strings
+// passed to T; format verbs must match.
+var ja = map[string]string{
+	// Prompts
+	"Enter master password: ":                        "マスターパスワードを入力: ",
+	"Confirm master password: ":                      "マスターパスワードを再入力: ",
+	"Enter secret value (Ctrl+D to finish): ":        "シークレットの値を入力 (Ctrl+D で終了): ",
+	"Enter backup password: ":                        "バックアップのパスワードを入力: ",
+	"Confirm backup password: ":                      "バックアップのパスワードを再入力: ",
+	"Enter backup password (or master password): ":   "バックアップのパスワード (またはマスターパスワード) を入力: ",
+	"Enter master password of the backed-up vault: ": "バックアップ内の保管庫のマスターパスワードを入力: ",
+	"Enter current password: ":                       "現在のパスワードを入力: ",
+	"Enter new password: ":                           "新しいパスワードを入力: ",
+	"Confirm new password: ":                         "新しいパスワードを再入力: ",
+	"Type %q to confirm: ":                           "確認のため %q と入力してください: ",
+	"Are you sure? [y/N]: ":                          "よろしいですか? [y/N]: ",
+	"This will merge secrets from the backup into the vault. Continue? [y/N]: ": "バックアップのシークレットを保管庫に統合します。続行しますか? [y/N]: ",
+	"This will restore the vault from backup. Continue? [y/N]: ":                "バックアップから保管庫を復元します。続行しますか? [y/N]: ",
+
+	// Init wizard
+	"Key derivation (Argon2id):": "鍵導出 (Argon2id):",
+	"Standard":                   "標準",
+	"Calibrate for this machine (about 1s per unlock)": "このマシンに合わせて調整 (ロック解除1回あたり約1秒)",
+	"Choose":         "選択",
+	"Calibrating...": "調整中...",
+	"Using":          "使用する設定",
+	"Create a recovery kit? It contains the data encryption key and must be stored offline. [y/N]: ": "リカバリーキットを作成しますか? データ暗号化鍵を含むため、オフラインで保管してください。 [y/N]: ",
+	"Recovery kit path":                           "リカバリーキットの保存先",
+	"Add starter secrets from templates? [y/N]: ": "テンプレートから初期シークレットを追加しますか? [y/N]: ",
+	"Template":        "テンプレート",
+	"empty to finish": "空欄で終了",
+	"Key":             "キー",
+	"Set a backup directory for 'secretctl backup'? [y/N]: ": "'secretctl backup' のバックアップ先ディレクトリを設定しますか? [y/N]: ",
+	"Backup directory": "バックアップ先ディレクトリ",
+	"Create an MCP policy for AI agents (deny all commands by default)? [y/N]: ": "AI エージェント用の MCP ポリシーを作成しますか (既定ですべてのコマンドを拒否)? [y/N]: ",
+	"Setup complete.": "セットアップが完了しました。",
+	"Setup complete:": "セットアップが完了しました:",
+
+	// Messages
+	"Error: %s":                 "エラー: %s",
+	"passwords do not match":    "パスワードが一致しません",
+	"passphrase does not match": "パスフレーズが一致しません",
+	"Backup passphrase (shown only once, write it down):": "バックアップのパスフレーズ (一度だけ表示されます。書き留めてください):",
+	"Type the passphrase to confirm: ":                    "確認のためパスフレーズを入力: ",
+	"invalid password":                                    "パスワードが正しくありません",
+
+	// Errors (see errorMessages)
+	"No vault found. Run 'secretctl init' to create one.":                     "保管庫が見つかりません。'secretctl init' で作成してください。",
+	"A vault already exists here.":                                            "この場所には既に保管庫があります。",
+	"The vault is locked.":                                                    "保管庫はロックされています。",
+	"The vault is read-only.":                                                 "保管庫は読み取り専用です。",
+	"The vault has expired.":                                                  "保管庫の有効期限が切れています。",
+	"Incorrect master password.":                                              "マスターパスワードが正しくありません。",
+	"Too many failed unlock attempts.":                                        "ロック解除の失敗回数が多すぎます。",
+	"Too many failed attempts; wait before trying again.":                     "失敗回数が多すぎます。しばらく待ってから再試行してください。",
+	"Secret not found.":                                                       "シークレットが見つかりません。",
+	"The secret has expired.":                                                 "シークレットの有効期限が切れています。",
+	"The secret cannot be read yet.":                                          "シークレットはまだ読み取れません。",
+	"The secret cannot be read outside its access window.":                    "アクセス可能な時間帯の外ではシークレットを読み取れません。",
+	"The key is deprecated.":                                                  "このキーは非推奨です。",
+	"The secret is immutable; changing it requires break-glass.":              "このシークレットは変更不可です。変更には break-glass が必要です。",
+	"The secret is in a freeze window; changing it requires break-glass.":     "このシークレットは凍結期間中です。変更には break-glass が必要です。",
+	"Secrets with this key need an expiration date (--expires).":              "このキーのシークレットには有効期限 (--expires) が必要です。",
+	"A field value has the wrong format.":                                     "フィールドの値の形式が正しくありません。",
+	"The key name is too long.":                                               "キー名が長すぎます。",
+	"The key name is too short.":                                              "キー名が短すぎます。",
+	"The key name contains characters that are not allowed.":                  "キー名に使用できない文字が含まれています。",
+	"The password must be at least 8 characters.":                             "パスワードは8文字以上にしてください。",
+	"The password must be at most 128 characters.":                            "パスワードは128文字以下にしてください。",
+	"The new password must differ from the current one.":                      "新しいパスワードは現在のパスワードと異なるものにしてください。",
+	"Not enough disk space.":                                                  "ディスクの空き容量が不足しています。",
+	"The vault is corrupted. Restore it from a backup.":                       "保管庫が破損しています。バックアップから復元してください。",
+	"The plugin is not enabled (see 'secretctl config get dynamic_plugins').": "プラグインが有効になっていません ('secretctl config get dynamic_plugins' を参照)。",
+}
@@ -0,0 +1,247 @@
+// Package jwt decodes JSON Web Tokens stored in the vault for inspection.
+//
+// Decode does not verify the signature; tokens are treated as opaque data
+// that happens to carry readable claims. Verify checks the signature
+// against an HMAC secret or a PEM public key (or certificate) when the
+// caller has one.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrMalformed         = errors.New("jwt: malformed token")
+	ErrUnsupportedAlg    = errors.New("jwt: unsupported algorithm")
+	ErrInvalidKey        = errors.New("jwt: invalid verification key")
+	ErrSignatureMismatch = errors.New("jwt: signature verification failed")
+)
+
+// Token is a decoded, unverified JWT.
+type Token struct {
+	Header map[string]any `json:"header"`
+	Claims map[string]any `json:"claims"`
+
+	// Registered time claims, nil when absent.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+
+	signingInput string
+	signature    []byte
+}
+
+// Alg returns the "alg" header.
+func (t *Token) Alg() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// Expired reports whether the token's exp is at or before now.
+func (t *Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// NotYetValid reports whether the token's nbf is after now.
+func (t *Token) NotYetValid(now time.Time) bool {
+	return t.NotBefore != nil && now.Before(*t.NotBefore)
+}
+
+// Decode parses a compact-serialized JWT (header.payload.signature)
+// without verifying it. Surrounding whitespace and a "Bearer " prefix are
+// ignored.
+func Decode(raw string) (*Token, error) {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "Bearer ")
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrMalformed, len(parts))
+	}
+
+	t := &Token{signingInput: parts[0] + "." + parts[1]}
+	if err := decodeSegment(parts[0], &t.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	if err := decodeSegment(parts[1], &t.Claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrMalformed, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+	t.signature = sig
+
+	for name, dst := range map[string]**time.Time{"exp": &t.ExpiresAt, "nbf": &t.NotBefore, "iat": &t.IssuedAt} {
+		ts, err := numericDate(t.Claims, name)
+		if err != nil {
+			return nil, err
+		}
+		*dst = ts
+	}
+	return t, nil
+}
+
+func decodeSegment(seg string, dst *map[string]any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	return dec.Decode(dst)
+}
+
+// numericDate reads a NumericDate claim (seconds since the epoch).
+func numericDate(claims map[string]any, name string) (*time.Time, error) {
+	v, ok := claims[name]
+	if !ok {
+		return nil, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a number", ErrMalformed, name)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	sec := int64(f)
+	ts := time.Unix(sec, int64((f-float64(sec))*1e9)).UTC()
+	return &ts, nil
+}
+
+// Verify checks the token's signature. For HS* algorithms key is the
+// shared secret; for RS*, PS*, ES* and EdDSA it is a PEM public key or
+// certificate. "none" is never accepted.
+func (t *Token) Verify(key []byte) error {
+	alg := t.Alg()
+	if alg == "" || strings.EqualFold(alg, "none") {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlg, alg)
+	}
+	if strings.HasPrefix(alg, "HS") {
+		h, err := hashFor(alg[2:])
+		if err != nil {
+			return err
+		}
+		mac := hmac.New(h.New, key)
+		mac.Write([]byte(t.signingInput))
+		if !hmac.Equal(mac.Sum(nil), t.signature) {
+			return ErrSignatureMismatch
+		}
+		return nil
+	}
+
+	pub, err := parsePublicKey(key)
+	if err != nil {
+		return err
+	}
+
+	if alg == "EdDSA" {
+		edKey, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: EdDSA requires an Ed25519 key", ErrInvalidKey)
+		}
+		if !ed25519.Verify(edKey, []byte(t.signingInput), t.signature) {
+			return ErrSignatureMismatch
+		}
+		return nil
+	}
+
+	if len(alg) != 5 {
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlg, alg)
+	}
+	h, err := hashFor(alg[2:])
+	if err != nil {
+		return err
+	}
+	hasher := h.New()
+	hasher.Write([]byte(t.signingInput))
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an RSA key", ErrInvalidKey, alg)
+		}
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(rsaKey, h, digest, t.signature)
+		} else {
+			err = rsa.VerifyPSS(rsaKey, h, digest, t.signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrSignatureMismatch
+		}
+		return nil
+	case "ES":
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an ECDSA key", ErrInvalidKey, alg)
+		}
+		// JWS encodes ECDSA signatures as fixed-size r||s
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return ErrSignatureMismatch
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return ErrSignatureMismatch
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnsupportedAlg, alg)
+}
+
+func hashFor(bits string) (crypto.Hash, error) {
+	switch bits {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("%w: hash size %q", ErrUnsupportedAlg, bits)
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(string(data))))
+	if block == nil {
+		return nil, fmt.Errorf("%w: expected a PEM public key or certificate", ErrInvalidKey)
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return key, nil
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return key, nil
+	}
+}
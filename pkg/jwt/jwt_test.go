@@ -0,0 +1,123 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+)
+
+func segment(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func signHS256(t *testing.T, header, claims string, secret []byte) string {
+	t.Helper()
+	input := segment(header) + "." + segment(claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestDecode(t *testing.T) {
+	token := signHS256(t, `{"alg":"HS256","typ":"JWT"}`, `{"sub":"user-1","exp":1700000000,"nbf":1600000000,"iat":1600000000}`, []byte("secret"))
+
+	tok, err := Decode("Bearer " + token + "\n")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if tok.Alg() != "HS256" || tok.Claims["sub"] != "user-1" {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	if tok.ExpiresAt == nil || !tok.ExpiresAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected exp %v", tok.ExpiresAt)
+	}
+	if !tok.Expired(time.Unix(1700000000, 0)) || tok.Expired(time.Unix(1699999999, 0)) {
+		t.Error("Expired boundary is wrong")
+	}
+	if !tok.NotYetValid(time.Unix(1599999999, 0)) || tok.NotYetValid(time.Unix(1600000000, 0)) {
+		t.Error("NotYetValid boundary is wrong")
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"a.b",
+		"!!!.e30.",
+		segment(`{"alg":"none"}`) + "." + segment(`{"exp":"tomorrow"}`) + ".",
+	} {
+		if _, err := Decode(raw); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decode(%q): expected ErrMalformed, got %v", raw, err)
+		}
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	token := signHS256(t, `{"alg":"HS256"}`, `{"sub":"x"}`, []byte("secret"))
+	tok, err := Decode(token)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if err := tok.Verify([]byte("secret")); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	if err := tok.Verify([]byte("wrong")); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("expected ErrSignatureMismatch, got %v", err)
+	}
+}
+
+func TestVerifyECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := segment(`{"alg":"ES256"}`) + "." + segment(`{"sub":"x"}`)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	tok, err := Decode(input + "." + base64.RawURLEncoding.EncodeToString(sig))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := tok.Verify(pub); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ = x509.MarshalPKIXPublicKey(other.Public())
+	if err := tok.Verify(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("expected ErrSignatureMismatch, got %v", err)
+	}
+	if err := tok.Verify([]byte("not pem")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestVerifyRejectsNone(t *testing.T) {
+	tok, err := Decode(segment(`{"alg":"none"}`) + "." + segment(`{}`) + ".")
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if err := tok.Verify([]byte("anything")); !errors.Is(err, ErrUnsupportedAlg) {
+		t.Errorf("expected ErrUnsupportedAlg, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package logging configures secretctl's leveled, structured logging.
+//
+// All packages log through log/slog. Setup installs the default handler,
+// which writes to stderr or a file but never to stdout: stdout carries MCP
+// protocol traffic and the output of commands run by secretctl.
+//
+// Attributes whose key names a secret (see SensitiveKeys) are always
+// replaced with [REDACTED], so a careless slog.Warn("...", "value", v)
+// cannot leak a secret into a log file.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output formats
+const (
+	FormatText = "text" // "warning: message key=value", for terminals
+	FormatJSON = "json" // one JSON object per line, for log collectors
+)
+
+// Environment variables read by OptionsFromEnv.
+const (
+	EnvLevel  = "SECRETCTL_LOG_LEVEL"
+	EnvFormat = "SECRETCTL_LOG_FORMAT"
+	EnvFile   = "SECRETCTL_LOG_FILE"
+)
+
+// redacted replaces the value of sensitive attributes.
+const redacted = "[REDACTED]"
+
+// SensitiveKeys are attribute keys whose values are never logged.
+var SensitiveKeys = []string{"value", "password", "passphrase", "secret", "token", "dek", "kek", "master_password"}
+
+// Options configures Setup. Empty fields use the defaults: info level, text
+// format, stderr.
+type Options struct {
+	Level  string // debug, info, warn or error
+	Format string // FormatText or FormatJSON
+	File   string // append to this file instead of stderr
+}
+
+// OptionsFromEnv returns options set through SECRETCTL_LOG_* variables.
+func OptionsFromEnv() Options {
+	return Options{
+		Level:  os.Getenv(EnvLevel),
+		Format: os.Getenv(EnvFormat),
+		File:   os.Getenv(EnvFile),
+	}
+}
+
+// Merge returns o with empty fields taken from fallback.
+func (o Options) Merge(fallback Options) Options {
+	if o.Level == "" {
+		o.Level = fallback.Level
+	}
+	if o.Format == "" {
+		o.Format = fallback.Format
+	}
+	if o.File == "" {
+		o.File = fallback.File
+	}
+	return o
+}
+
+// ParseLevel parses a level name. "warning" is accepted for warn.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	switch strings.ToLower(s) {
+	case "":
+		return slog.LevelInfo, nil
+	case "warning":
+		return slog.LevelWarn, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (use debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// ValidateFormat checks a format name.
+func ValidateFormat(s string) error {
+	switch s {
+	case "", FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid log format %q (use %s or %s)", s, FormatText, FormatJSON)
+	}
+}
+
+// Setup installs the default slog logger (which the standard log package
+// also writes through) and returns a function that closes the log file.
+func Setup(opts Options) (func() error, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateFormat(opts.Format); err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = os.Stderr
+	closeFn := func() error { return nil }
+	toFile := opts.File != ""
+	if toFile {
+		if isStdout(opts.File) {
+			return nil, fmt.Errorf("log file must not be stdout: it carries MCP traffic and command output")
+		}
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closeFn = f, f.Close
+	}
+
+	slog.SetDefault(slog.New(NewHandler(w, level, opts.Format, toFile)))
+	return closeFn, nil
+}
+
+// NewHandler returns a handler for format that redacts sensitive
+// attributes. withTime adds timestamps to text output (JSON always has them).
+func NewHandler(w io.Writer, level slog.Leveler, format string, withTime bool) slog.Handler {
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				return redact(a)
+			},
+		})
+	}
+	return &textHandler{w: w, mu: &sync.Mutex{}, level: level, withTime: withTime}
+}
+
+func isStdout(path string) bool {
+	switch filepath.Clean(path) {
+	case "-", "/dev/stdout", "/dev/fd/1", "/proc/self/fd/1":
+		return true
+	}
+	return false
+}
+
+// redact hides the values of sensitive attributes.
+func redact(a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range SensitiveKeys {
+		if key == sensitive {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
+
+// textHandler writes "level: message key=value ..." lines.
+type textHandler struct {
+	w        io.Writer
+	mu       *sync.Mutex
+	level    slog.Leveler
+	withTime bool
+	prefix   string // group prefix for attribute keys
+	attrs    string // preformatted attributes from WithAttrs
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if h.withTime && !r.Time.IsZero() {
+		b.WriteString(r.Time.Format(time.RFC3339))
+		b.WriteByte(' ')
+	}
+	b.WriteString(levelName(r.Level))
+	b.WriteString(": ")
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		writeAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+	a = redact(a)
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
+
+func levelName(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return "error"
+	case l >= slog.LevelWarn:
+		return "warning"
+	case l >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
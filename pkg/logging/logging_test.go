@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, slog.LevelInfo, FormatText, false))
+
+	logger.Debug("hidden")
+	logger.With("component", "vault").Warn("disk is almost full", "used_pct", 93)
+	logger.Info("stored secret", "key", "api/token", "value", "s3cr3t")
+
+	want := "warning: disk is almost full component=vault used_pct=93\n" +
+		"info: stored secret key=api/token value=[REDACTED]\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestJSONHandlerRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, slog.LevelDebug, FormatJSON, false))
+	logger.Warn("oops", "Password", "hunter2", "key", "db/prod")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec["Password"] != redacted || rec["key"] != "db/prod" || rec["level"] != "WARN" {
+		t.Errorf("unexpected record %v", rec)
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Error("secret value was logged")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	path := filepath.Join(t.TempDir(), "secretctl.log")
+	closeLog, err := Setup(Options{Level: "warning", Format: FormatJSON, File: path})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	slog.Info("not logged")
+	slog.Warn("logged")
+	if err := closeLog(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.Count(string(data), "\n") != 1 || !strings.Contains(string(data), `"msg":"logged"`) {
+		t.Errorf("unexpected log file contents %q", data)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("log file mode = %o, want 600", info.Mode().Perm())
+	}
+
+	for _, opts := range []Options{{Level: "loud"}, {Format: "xml"}, {File: "/dev/stdout"}, {File: "-"}} {
+		if _, err := Setup(opts); err == nil {
+			t.Errorf("Setup(%+v): expected error", opts)
+		}
+	}
+}
+
+func TestOptionsMerge(t *testing.T) {
+	got := Options{Level: "debug"}.Merge(Options{Level: "error", Format: FormatJSON})
+	if got != (Options{Level: "debug", Format: FormatJSON}) {
+		t.Errorf("Merge = %+v", got)
+	}
+}
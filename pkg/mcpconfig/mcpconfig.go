@@ -0,0 +1,264 @@
+// Package mcpconfig registers secretctl as an MCP server in the
+// configuration files of AI clients: Claude Desktop, Cursor and VS Code.
+//
+// Changes are planned first so that callers can show the user exactly what
+// will be written before anything is touched, and the previous file is
+// kept as a backup when a plan is applied.
+package mcpconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+)
+
+// ServerName is the name secretctl is registered under.
+const ServerName = "secretctl"
+
+// Client is an MCP client whose configuration can be edited.
+type Client string
+
+// Supported clients.
+const (
+	ClientClaude Client = "claude" // Claude Desktop
+	ClientCursor Client = "cursor" // Cursor (global ~/.cursor/mcp.json)
+	ClientVSCode Client = "vscode" // VS Code (user-level mcp.json)
+)
+
+// ErrUnknownClient is returned for clients this package cannot configure.
+var ErrUnknownClient = errors.New("mcpconfig: unknown client")
+
+// clientSpec describes where a client keeps its MCP servers.
+type clientSpec struct {
+	name string
+	// path returns the config file for the OS, given the home and
+	// user config directories.
+	path func(goos, home, configDir string) string
+	// serversKey is the top-level object that maps server names to servers.
+	serversKey string
+	// serverType is the "type" of stdio server entries, if the client
+	// requires one.
+	serverType string
+}
+
+var clients = map[Client]clientSpec{
+	ClientClaude: {
+		name: "Claude Desktop",
+		path: func(goos, home, configDir string) string {
+			if goos == "darwin" {
+				return filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json")
+			}
+			return filepath.Join(configDir, "Claude", "claude_desktop_config.json")
+		},
+		serversKey: "mcpServers",
+	},
+	ClientCursor: {
+		name: "Cursor",
+		path: func(goos, home, configDir string) string {
+			return filepath.Join(home, ".cursor", "mcp.json")
+		},
+		serversKey: "mcpServers",
+	},
+	ClientVSCode: {
+		name: "VS Code",
+		path: func(goos, home, configDir string) string {
+			if goos == "darwin" {
+				return filepath.Join(home, "Library", "Application Support", "Code", "User", "mcp.json")
+			}
+			return filepath.Join(configDir, "Code", "User", "mcp.json")
+		},
+		serversKey: "servers",
+		serverType: "stdio",
+	},
+}
+
+// Clients returns the clients this package can configure.
+func Clients() []Client {
+	return []Client{ClientClaude, ClientCursor, ClientVSCode}
+}
+
+// Name returns the display name of c.
+func (c Client) Name() string {
+	if spec, ok := clients[c]; ok {
+		return spec.name
+	}
+	return string(c)
+}
+
+// ConfigPath returns the config file of client for the current user.
+func ConfigPath(client Client) (string, error) {
+	spec, ok := clients[client]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownClient, client)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("mcpconfig: cannot locate home directory: %w", err)
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("mcpconfig: cannot locate config directory: %w", err)
+	}
+	return spec.path(runtime.GOOS, home, configDir), nil
+}
+
+// Detect returns the clients that appear to be installed, i.e. whose
+// config directory exists.
+func Detect() []Client {
+	var found []Client
+	for _, c := range Clients() {
+		path, err := ConfigPath(c)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(filepath.Dir(path)); err == nil && info.IsDir() {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// Server is an MCP server entry.
+type Server struct {
+	Type    string            `json:"type,omitempty"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// SecretctlServer returns the entry that starts `secretctl mcp-server` at
+// command. Empty vaultDir and policyPath leave them to the server's
+// defaults.
+func SecretctlServer(command, vaultDir, policyPath string) Server {
+	s := Server{Command: command, Args: []string{"mcp-server"}}
+	if vaultDir != "" {
+		s.Args = append(s.Args, "--vault-dir", vaultDir)
+	}
+	if policyPath != "" {
+		s.Args = append(s.Args, "--policy", policyPath)
+	}
+	return s
+}
+
+// Change is a planned edit of a client's config file.
+type Change struct {
+	Client Client
+	Path   string
+	// Before is the current content, nil if the file does not exist.
+	Before []byte
+	// After is the content Apply writes.
+	After []byte
+	// Backup is where Apply saves Before.
+	Backup string
+}
+
+// Changed reports whether applying c would modify the file.
+func (c *Change) Changed() bool {
+	return c.Before == nil || !bytes.Equal(c.Before, c.After)
+}
+
+// Configured reports whether the config at path already registers secretctl.
+func Configured(client Client, path string) (bool, error) {
+	spec, ok := clients[client]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrUnknownClient, client)
+	}
+	_, servers, err := readConfig(path, spec)
+	if err != nil {
+		return false, err
+	}
+	_, found := servers[ServerName]
+	return found, nil
+}
+
+// Plan returns the edit that registers server under ServerName in the
+// config at path. Other servers and settings are kept.
+func Plan(client Client, path string, server Server) (*Change, error) {
+	spec, ok := clients[client]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownClient, client)
+	}
+	config, servers, err := readConfig(path, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if server.Type == "" {
+		server.Type = spec.serverType
+	}
+	entry, err := json.Marshal(server)
+	if err != nil {
+		return nil, fmt.Errorf("mcpconfig: failed to encode server: %w", err)
+	}
+	servers[ServerName] = entry
+	if config[spec.serversKey], err = json.Marshal(servers); err != nil {
+		return nil, fmt.Errorf("mcpconfig: failed to encode servers: %w", err)
+	}
+	after, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("mcpconfig: failed to encode config: %w", err)
+	}
+
+	change := &Change{Client: client, Path: path, After: append(after, '\n')}
+	if before, err := os.ReadFile(path); err == nil {
+		change.Before = before
+		change.Backup = fmt.Sprintf("%s.%s.bak", path, time.Now().UTC().Format("20060102-150405"))
+	}
+	return change, nil
+}
+
+// Apply writes the change, saving the previous file to Backup first.
+func (c *Change) Apply() error {
+	if !c.Changed() {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o700); err != nil {
+		return fmt.Errorf("mcpconfig: failed to create config directory: %w", err)
+	}
+	if c.Before != nil {
+		if err := os.WriteFile(c.Backup, c.Before, 0o600); err != nil {
+			return fmt.Errorf("mcpconfig: failed to back up config: %w", err)
+		}
+	}
+	perm := os.FileMode(0o600)
+	if info, err := os.Stat(c.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := atomicfile.Write(c.Path, c.After, perm); err != nil {
+		return fmt.Errorf("mcpconfig: failed to write config: %w", err)
+	}
+	return nil
+}
+
+// readConfig decodes the config at path, keeping unknown settings as raw
+// JSON. A missing or empty file yields an empty config.
+func readConfig(path string, spec clientSpec) (config, servers map[string]json.RawMessage, err error) {
+	config = make(map[string]json.RawMessage)
+	servers = make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, servers, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("mcpconfig: failed to read %s: %w", path, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return config, servers, nil
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("mcpconfig: %s is not valid JSON: %w", path, err)
+	}
+	if raw, ok := config[spec.serversKey]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return nil, nil, fmt.Errorf("mcpconfig: %q in %s is not an object: %w", spec.serversKey, path, err)
+		}
+	}
+	return config, servers, nil
+}
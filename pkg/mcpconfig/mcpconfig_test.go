@@ -0,0 +1,132 @@
+package mcpconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanAndApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Claude", "claude_desktop_config.json")
+	server := Server{Command: "/usr/local/bin/secretctl", Args: []string{"mcp-server"}}
+
+	// New file
+	change, err := Plan(ClientClaude, path, server)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if change.Before != nil || change.Backup != "" || !change.Changed() {
+		t.Fatalf("unexpected plan for a new file: %+v", change)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("Plan wrote the config")
+	}
+	if err := change.Apply(); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if ok, err := Configured(ClientClaude, path); err != nil || !ok {
+		t.Fatalf("Configured = %v, %v", ok, err)
+	}
+
+	// Existing servers and settings are kept, the old file is backed up
+	existing := `{"globalShortcut": "Ctrl+Space", "mcpServers": {"other": {"command": "other-server", "args": []}}}`
+	os.WriteFile(path, []byte(existing), 0o600)
+	change, err = Plan(ClientClaude, path, server)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if err := change.Apply(); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	backup, err := os.ReadFile(change.Backup)
+	if err != nil || string(backup) != existing {
+		t.Errorf("backup = %q, %v", backup, err)
+	}
+
+	var written struct {
+		GlobalShortcut string            `json:"globalShortcut"`
+		MCPServers     map[string]Server `json:"mcpServers"`
+	}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("written config is invalid: %v", err)
+	}
+	if written.GlobalShortcut != "Ctrl+Space" || written.MCPServers["other"].Command != "other-server" {
+		t.Errorf("existing settings lost: %s", data)
+	}
+	if got := written.MCPServers[ServerName]; got.Command != server.Command || len(got.Args) != 1 {
+		t.Errorf("secretctl entry = %+v", got)
+	}
+
+	// Planning again is a no-op
+	change, _ = Plan(ClientClaude, path, server)
+	if change.Changed() {
+		t.Error("second plan would change the config")
+	}
+}
+
+func TestPlan_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	server := Server{Command: "secretctl", Args: []string{"mcp-server"}}
+
+	broken := filepath.Join(dir, "broken.json")
+	os.WriteFile(broken, []byte(`{"mcpServers": `), 0o600)
+	if _, err := Plan(ClientClaude, broken, server); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+
+	wrongType := filepath.Join(dir, "wrong.json")
+	os.WriteFile(wrongType, []byte(`{"mcpServers": []}`), 0o600)
+	if _, err := Plan(ClientClaude, wrongType, server); err == nil || !strings.Contains(err.Error(), "not an object") {
+		t.Errorf("expected an error for a non-object mcpServers, got %v", err)
+	}
+
+	if _, err := Plan("emacs", filepath.Join(dir, "x.json"), server); !errors.Is(err, ErrUnknownClient) {
+		t.Errorf("expected ErrUnknownClient, got %v", err)
+	}
+}
+
+func TestPlan_VSCode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.json")
+	os.WriteFile(path, []byte(`{"inputs": [], "servers": {}}`), 0o600)
+	server := SecretctlServer("/usr/local/bin/secretctl", "/home/me/.secretctl", "/home/me/.secretctl/mcp-policy.yaml")
+
+	change, err := Plan(ClientVSCode, path, server)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	var written struct {
+		Inputs  []any             `json:"inputs"`
+		Servers map[string]Server `json:"servers"`
+	}
+	if err := json.Unmarshal(change.After, &written); err != nil {
+		t.Fatalf("planned config is invalid: %v", err)
+	}
+	got := written.Servers[ServerName]
+	if got.Type != "stdio" || written.Inputs == nil {
+		t.Errorf("unexpected VS Code config: %s", change.After)
+	}
+	want := []string{"mcp-server", "--vault-dir", "/home/me/.secretctl", "--policy", "/home/me/.secretctl/mcp-policy.yaml"}
+	if strings.Join(got.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("args = %q, want %q", got.Args, want)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	spec := clients[ClientClaude]
+	if got := spec.path("darwin", "/Users/me", "/Users/me/Library/Application Support"); got != filepath.Join("/Users/me", "Library", "Application Support", "Claude", "claude_desktop_config.json") {
+		t.Errorf("darwin path = %s", got)
+	}
+	if got := spec.path("windows", `C:\Users\me`, `C:\Users\me\AppData\Roaming`); got != filepath.Join(`C:\Users\me\AppData\Roaming`, "Claude", "claude_desktop_config.json") {
+		t.Errorf("windows path = %s", got)
+	}
+	if got := clients[ClientCursor].path("linux", "/home/me", "/home/me/.config"); got != filepath.Join("/home/me", ".cursor", "mcp.json") {
+		t.Errorf("cursor path = %s", got)
+	}
+	if got := clients[ClientVSCode].path("linux", "/home/me", "/home/me/.config"); got != filepath.Join("/home/me/.config", "Code", "User", "mcp.json") {
+		t.Errorf("vscode path = %s", got)
+	}
+}
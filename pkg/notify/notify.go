@@ -0,0 +1,93 @@
+// Package notify delivers security alerts, such as triggered canary
+// secrets, to external receivers.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultTimeout bounds a single webhook delivery.
+const DefaultTimeout = 5 * time.Second
+
+// ErrInvalidWebhookURL is returned for webhook URLs that are not absolute
+// https URLs (plain http is accepted for loopback receivers only).
+var ErrInvalidWebhookURL = errors.New("notify: webhook URL must be https (http only for localhost)")
+
+// Alert is the JSON document posted to a webhook.
+type Alert struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Vault   string    `json:"vault,omitempty"`
+	Key     string    `json:"key,omitempty"`
+	Message string    `json:"message"`
+	Host    string    `json:"host,omitempty"`
+}
+
+// ValidateWebhookURL checks that raw is usable as a webhook URL.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+	}
+	return ErrInvalidWebhookURL
+}
+
+// Webhook posts alerts as JSON to a URL.
+type Webhook struct {
+	URL string
+	// Client is the HTTP client to use; nil means a client with DefaultTimeout.
+	Client *http.Client
+}
+
+// Send posts alert to the webhook. Any 2xx response is success.
+func (w *Webhook) Send(ctx context.Context, alert Alert) error {
+	if err := ValidateWebhookURL(w.URL); err != nil {
+		return err
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("notify: failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "secretctl-notify")
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notify: webhook returned %s", resp.Status)
+	}
+	return nil
+}
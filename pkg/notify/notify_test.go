@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hooks.example.com/alert", true},
+		{"http://localhost:8080/alert", true},
+		{"http://127.0.0.1/alert", true},
+		{"http://[::1]:9000/", true},
+		{"http://hooks.example.com/alert", false},
+		{"ftp://example.com", false},
+		{"hooks.example.com/alert", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		err := ValidateWebhookURL(tt.url)
+		if tt.ok && err != nil {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want nil", tt.url, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidWebhookURL) {
+			t.Errorf("ValidateWebhookURL(%q) = %v, want ErrInvalidWebhookURL", tt.url, err)
+		}
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	var got Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	alert := Alert{Type: "canary_triggered", Time: time.Now().UTC().Truncate(time.Second), Key: "aws/prod", Message: "canary read"}
+	w := &Webhook{URL: srv.URL}
+	if err := w.Send(context.Background(), alert); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if got.Type != alert.Type || got.Key != alert.Key || !got.Time.Equal(alert.Time) {
+		t.Errorf("received %+v, want %+v", got, alert)
+	}
+}
+
+func TestWebhookSendStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	w := &Webhook{URL: srv.URL}
+	if err := w.Send(context.Background(), Alert{Type: "test"}); err == nil {
+		t.Error("expected error for 500 response")
+	}
+}
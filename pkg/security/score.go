@@ -131,7 +131,7 @@ func (c *Calculator) CalculateScore(includeKeys bool) (*SecurityScore, error) {
 	// Load all secrets with full details
 	var secretEntries []*vault.SecretEntry
 	for _, key := range secrets {
-		entry, err := c.vault.GetSecret(key)
+		entry, err := c.vault.InspectSecret(key)
 		if err != nil {
 			continue // Skip inaccessible secrets
 		}
@@ -291,12 +291,13 @@ func (c *Calculator) calculateExpirationScore(secrets []*vault.SecretEntry, incl
 	nonExpiredCount := 0
 
 	for _, entry := range secrets {
-		if entry.ExpiresAt == nil {
+		expiry := entry.Expiry() // expires_at or certificate notAfter
+		if expiry == nil {
 			continue // No expiration set
 		}
 
 		secretsWithExpiration++
-		expiresAt := *entry.ExpiresAt
+		expiresAt := *expiry
 
 		if expiresAt.Before(now) {
 			// Already expired
@@ -0,0 +1,188 @@
+// Package timefmt formats timestamps for people reading CLI and desktop
+// output.
+//
+// Times are printed in ISO 8601 extended format with the UTC offset
+// (2026-10-16T09:30:00+09:00), which is also the JIS X 0301 notation, in
+// a configurable time zone. Relative phrases ("expires in 3 days") are
+// localized; English and Japanese are supported.
+//
+// Machine-readable output (--json, audit logs, exports) keeps UTC RFC 3339
+// and does not go through this package.
+package timefmt
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/i18n"
+)
+
+// Supported locales
+const (
+	LocaleEnglish  = i18n.English
+	LocaleJapanese = i18n.Japanese
+)
+
+// Environment variables read by OptionsFromEnv.
+const (
+	EnvTimezone = "SECRETCTL_TIMEZONE"
+	EnvLocale   = "SECRETCTL_LOCALE"
+)
+
+// Options configures New. Empty fields use the defaults: the system time
+// zone and a locale derived from LC_ALL, LC_MESSAGES or LANG.
+type Options struct {
+	Timezone string // IANA name such as Asia/Tokyo, "UTC" or "Local"
+	Locale   string // LocaleEnglish or LocaleJapanese
+}
+
+// OptionsFromEnv returns options set through SECRETCTL_TIMEZONE and
+// SECRETCTL_LOCALE.
+func OptionsFromEnv() Options {
+	return Options{
+		Timezone: os.Getenv(EnvTimezone),
+		Locale:   os.Getenv(EnvLocale),
+	}
+}
+
+// Merge returns o with empty fields taken from fallback.
+func (o Options) Merge(fallback Options) Options {
+	if o.Timezone == "" {
+		o.Timezone = fallback.Timezone
+	}
+	if o.Locale == "" {
+		o.Locale = fallback.Locale
+	}
+	return o
+}
+
+// LoadLocation resolves a time zone name. Empty and "Local" mean the
+// system time zone.
+func LoadLocation(name string) (*time.Location, error) {
+	switch name {
+	case "", "Local", "local":
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q (use an IANA name like Asia/Tokyo, UTC or Local)", name)
+	}
+	return loc, nil
+}
+
+// ParseLocale normalizes a locale such as "ja_JP.UTF-8" to a supported
+// one. Empty means the system locale.
+func ParseLocale(s string) (string, error) {
+	return i18n.ParseLocale(s)
+}
+
+// Formatter formats times in one time zone and locale. The zero value is
+// not usable; use New or Default.
+type Formatter struct {
+	loc    *time.Location
+	locale string
+}
+
+// New returns a Formatter for opts.
+func New(opts Options) (*Formatter, error) {
+	loc, err := LoadLocation(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	locale, err := ParseLocale(opts.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return &Formatter{loc: loc, locale: locale}, nil
+}
+
+// Default returns a Formatter for the system time zone and locale.
+func Default() *Formatter {
+	return &Formatter{loc: time.Local, locale: i18n.SystemLocale()}
+}
+
+// Location returns the formatter's time zone.
+func (f *Formatter) Location() *time.Location { return f.loc }
+
+// Locale returns the formatter's locale.
+func (f *Formatter) Locale() string { return f.locale }
+
+// Time formats t as 2026-10-16T09:30:00+09:00 in the formatter's zone.
+func (f *Formatter) Time(t time.Time) string {
+	return t.In(f.loc).Format(time.RFC3339)
+}
+
+// Date formats t as 2026-10-16 in the formatter's zone.
+func (f *Formatter) Date(t time.Time) string {
+	return t.In(f.loc).Format(time.DateOnly)
+}
+
+// Relative describes t relative to now: "in 3 days", "2 hours ago",
+// "3日後", "2時間前".
+func (f *Formatter) Relative(t, now time.Time) string {
+	d := t.Sub(now)
+	amount, unit := span(d)
+	if amount == 0 {
+		if f.locale == LocaleJapanese {
+			return "たった今"
+		}
+		return "just now"
+	}
+	if f.locale == LocaleJapanese {
+		if d > 0 {
+			return fmt.Sprintf("%d%s後", amount, jaUnits[unit])
+		}
+		return fmt.Sprintf("%d%s前", amount, jaUnits[unit])
+	}
+	label := unit
+	if amount != 1 {
+		label += "s"
+	}
+	if d > 0 {
+		return fmt.Sprintf("in %d %s", amount, label)
+	}
+	return fmt.Sprintf("%d %s ago", amount, label)
+}
+
+// Expiry describes an expiration time: "expires in 3 days", "expired 2
+// days ago", "3日後に期限切れ", "2日前に期限切れ".
+func (f *Formatter) Expiry(t, now time.Time) string {
+	rel := f.Relative(t, now)
+	if f.locale == LocaleJapanese {
+		return rel + "に期限切れ"
+	}
+	if t.After(now) {
+		return "expires " + rel
+	}
+	return "expired " + rel
+}
+
+var jaUnits = map[string]string{
+	"year":   "年",
+	"month":  "か月",
+	"day":    "日",
+	"hour":   "時間",
+	"minute": "分",
+}
+
+// span rounds d down to the largest whole unit, so 47 hours is "1 day".
+func span(d time.Duration) (int, string) {
+	if d < 0 {
+		d = -d
+	}
+	const day = 24 * time.Hour
+	switch {
+	case d >= 365*day:
+		return int(d / (365 * day)), "year"
+	case d >= 30*day:
+		return int(d / (30 * day)), "month"
+	case d >= day:
+		return int(d / day), "day"
+	case d >= time.Hour:
+		return int(d / time.Hour), "hour"
+	case d >= time.Minute:
+		return int(d / time.Minute), "minute"
+	}
+	return 0, ""
+}
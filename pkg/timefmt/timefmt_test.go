@@ -0,0 +1,77 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatterTime(t *testing.T) {
+	f, err := New(Options{Timezone: "Asia/Tokyo", Locale: "ja_JP.UTF-8"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := time.Date(2026, 10, 16, 0, 30, 0, 0, time.UTC)
+	if got := f.Time(ts); got != "2026-10-16T09:30:00+09:00" {
+		t.Errorf("Time = %q", got)
+	}
+	if got := f.Date(time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)); got != "2026-10-17" {
+		t.Errorf("Date = %q, want the Tokyo date", got)
+	}
+	if f.Locale() != LocaleJapanese {
+		t.Errorf("Locale = %q", f.Locale())
+	}
+}
+
+func TestFormatterRelative(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	en, _ := New(Options{Timezone: "UTC", Locale: "en"})
+	ja, _ := New(Options{Timezone: "UTC", Locale: "ja"})
+
+	tests := []struct {
+		offset time.Duration
+		en, ja string
+	}{
+		{3*24*time.Hour + time.Hour, "in 3 days", "3日後"},
+		{-47 * time.Hour, "1 day ago", "1日前"},
+		{time.Hour, "in 1 hour", "1時間後"},
+		{-90 * time.Second, "1 minute ago", "1分前"},
+		{10 * time.Second, "just now", "たった今"},
+		{400 * 24 * time.Hour, "in 1 year", "1年後"},
+	}
+	for _, tt := range tests {
+		if got := en.Relative(now.Add(tt.offset), now); got != tt.en {
+			t.Errorf("en Relative(%v) = %q, want %q", tt.offset, got, tt.en)
+		}
+		if got := ja.Relative(now.Add(tt.offset), now); got != tt.ja {
+			t.Errorf("ja Relative(%v) = %q, want %q", tt.offset, got, tt.ja)
+		}
+	}
+
+	if got := en.Expiry(now.Add(-48*time.Hour), now); got != "expired 2 days ago" {
+		t.Errorf("en Expiry = %q", got)
+	}
+	if got := ja.Expiry(now.Add(72*time.Hour), now); got != "3日後に期限切れ" {
+		t.Errorf("ja Expiry = %q", got)
+	}
+}
+
+func TestOptionsValidation(t *testing.T) {
+	if _, err := New(Options{Timezone: "Mars/Olympus"}); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+	if _, err := New(Options{Locale: "fr"}); err == nil {
+		t.Error("expected error for unsupported locale")
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ja_JP.UTF-8")
+	if got := Default().Locale(); got != LocaleJapanese {
+		t.Errorf("system locale = %q, want ja", got)
+	}
+
+	merged := Options{Locale: "en"}.Merge(Options{Timezone: "UTC", Locale: "ja"})
+	if merged.Timezone != "UTC" || merged.Locale != "en" {
+		t.Errorf("Merge = %+v", merged)
+	}
+}
@@ -0,0 +1,281 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Artifact formats, recognized by file extension.
+const (
+	formatTarGz = ".tar.gz" // Linux: tarball containing the executable
+	formatZip   = ".zip"    // macOS: zip of the .app bundle
+	formatExe   = ".exe"    // Windows: the executable itself
+)
+
+// assetExt returns the artifact format of a file name or URL.
+func assetExt(name string) string {
+	if u, err := url.Parse(name); err == nil && u.Scheme != "" {
+		name = u.Path
+	}
+	for _, ext := range []string{formatTarGz, formatZip, formatExe} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// Target returns the installed app of the running process: the .app
+// bundle on macOS and the executable elsewhere.
+func Target() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("update: cannot locate executable: %w", err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("update: cannot locate executable: %w", err)
+	}
+	if runtime.GOOS != "darwin" {
+		return exe, nil
+	}
+	// <name>.app/Contents/MacOS/<executable>
+	bundle := filepath.Dir(filepath.Dir(filepath.Dir(exe)))
+	if filepath.Ext(bundle) != ".app" {
+		return "", fmt.Errorf("update: %s is not inside an app bundle", exe)
+	}
+	return bundle, nil
+}
+
+// Install replaces target, as returned by Target, with the downloaded
+// artifact. The new app is unpacked next to target first and then renamed
+// into place, keeping the old one until the rename has succeeded.
+func Install(artifact, target string) error {
+	staged := target + ".new"
+	if err := os.RemoveAll(staged); err != nil {
+		return fmt.Errorf("update: failed to clear %s: %w", staged, err)
+	}
+
+	var err error
+	switch ext := assetExt(artifact); ext {
+	case formatZip:
+		err = extractBundle(artifact, staged)
+	case formatTarGz:
+		err = extractExecutable(artifact, filepath.Base(target), staged)
+	case formatExe:
+		err = copyExecutable(artifact, staged)
+	default:
+		err = fmt.Errorf("update: unsupported artifact %s", filepath.Base(artifact))
+	}
+	if err != nil {
+		os.RemoveAll(staged)
+		return err
+	}
+	return swap(staged, target)
+}
+
+// swap renames staged to target. The previous target is moved aside and
+// removed afterwards; a running Windows executable cannot be removed, so
+// CleanupPrevious deletes it on the next start.
+func swap(staged, target string) error {
+	old := target + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		os.RemoveAll(staged)
+		return fmt.Errorf("update: failed to clear %s: %w", old, err)
+	}
+	if err := os.Rename(target, old); err != nil {
+		os.RemoveAll(staged)
+		return fmt.Errorf("update: cannot replace %s: %w", target, err)
+	}
+	if err := os.Rename(staged, target); err != nil {
+		_ = os.Rename(old, target)
+		os.RemoveAll(staged)
+		return fmt.Errorf("update: cannot replace %s: %w", target, err)
+	}
+	_ = os.RemoveAll(old)
+	return nil
+}
+
+// CleanupPrevious removes files a previous Install left next to target.
+func CleanupPrevious(target string) {
+	_ = os.RemoveAll(target + ".old")
+	_ = os.RemoveAll(target + ".new")
+}
+
+// Relaunch starts the app at target in a new process. The caller quits
+// afterwards.
+func Relaunch(target string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("open", "-n", target)
+	} else {
+		cmd = exec.Command(target, os.Args[1:]...)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("update: failed to relaunch: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// extractBundle unpacks the single .app bundle in a zip archive to dest.
+func extractBundle(archive, dest string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("update: failed to open artifact: %w", err)
+	}
+	defer r.Close()
+
+	var bundle string
+	for _, f := range r.File {
+		name, err := archivePath(f.Name)
+		if err != nil {
+			return err
+		}
+		top, rest, _ := strings.Cut(name, "/")
+		if path.Ext(top) != ".app" || (bundle != "" && top != bundle) {
+			return fmt.Errorf("update: artifact must contain exactly one .app bundle")
+		}
+		bundle = top
+		if rest == "" && !f.Mode().IsDir() {
+			return fmt.Errorf("update: artifact must contain exactly one .app bundle")
+		}
+		out := filepath.Join(dest, filepath.FromSlash(rest))
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(out, 0o755); err != nil {
+				return fmt.Errorf("update: failed to extract %s: %w", name, err)
+			}
+		case mode&os.ModeSymlink != 0:
+			if err := extractSymlink(f, bundle, name, out); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if err := extractFile(f, out, mode.Perm()); err != nil {
+				return fmt.Errorf("update: failed to extract %s: %w", name, err)
+			}
+		default:
+			return fmt.Errorf("update: unsupported entry %s", name)
+		}
+	}
+	if bundle == "" {
+		return fmt.Errorf("update: artifact must contain exactly one .app bundle")
+	}
+	return nil
+}
+
+// extractSymlink recreates a symlink of a bundle, which may only point
+// inside the bundle.
+func extractSymlink(f *zip.File, bundle, name, out string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("update: failed to extract %s: %w", name, err)
+	}
+	link, err := io.ReadAll(io.LimitReader(rc, 4096))
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("update: failed to extract %s: %w", name, err)
+	}
+	resolved := path.Join(path.Dir(name), string(link))
+	if path.IsAbs(string(link)) || (resolved != bundle && !strings.HasPrefix(resolved, bundle+"/")) {
+		return fmt.Errorf("update: symlink %s points outside the bundle", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return fmt.Errorf("update: failed to extract %s: %w", name, err)
+	}
+	return os.Symlink(string(link), out)
+}
+
+func extractFile(f *zip.File, out string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeFile(out, io.LimitReader(rc, maxArtifactSize), perm)
+}
+
+// extractExecutable unpacks the regular file called name from a gzipped
+// tarball to dest.
+func extractExecutable(archive, name, dest string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("update: failed to open artifact: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("update: failed to open artifact: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("update: artifact does not contain %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("update: failed to read artifact: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == name {
+			if err := writeFile(dest, io.LimitReader(tr, maxArtifactSize), 0o755); err != nil {
+				return fmt.Errorf("update: failed to extract %s: %w", name, err)
+			}
+			return nil
+		}
+	}
+}
+
+func copyExecutable(artifact, dest string) error {
+	f, err := os.Open(artifact)
+	if err != nil {
+		return fmt.Errorf("update: failed to open artifact: %w", err)
+	}
+	defer f.Close()
+	if err := writeFile(dest, f, 0o755); err != nil {
+		return fmt.Errorf("update: failed to copy artifact: %w", err)
+	}
+	return nil
+}
+
+func writeFile(name string, r io.Reader, perm os.FileMode) error {
+	out, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// archivePath cleans an archive entry name and rejects names that would
+// escape the extraction directory.
+func archivePath(name string) (string, error) {
+	clean := path.Clean(strings.TrimSuffix(name, "/"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("update: unsafe path %q in artifact", name)
+	}
+	return clean, nil
+}
@@ -0,0 +1,132 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+// zipEntry is a file, or a symlink if link is set.
+type zipEntry struct {
+	name, body, link string
+}
+
+func writeZip(t *testing.T, path string, entries []zipEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name}
+		body := e.body
+		if e.link != "" {
+			hdr.SetMode(os.ModeSymlink | 0o777)
+			body = e.link
+		} else {
+			hdr.SetMode(0o755)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatalf("CreateHeader failed: %v", err)
+		}
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestInstall_Executable(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "secretctl-desktop")
+	os.WriteFile(target, []byte("old"), 0o755)
+	artifact := filepath.Join(t.TempDir(), "download.tar.gz")
+	writeTarGz(t, artifact, map[string]string{"README": "docs", "secretctl-desktop": "new"})
+
+	if err := Install(artifact, target); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "new" {
+		t.Errorf("target = %q, want new", data)
+	}
+	if info, _ := os.Stat(target); runtime.GOOS != "windows" && info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("installed file is not executable: %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Install left %d files next to the target", len(entries))
+	}
+
+	// An archive without the executable leaves the installed one alone
+	writeTarGz(t, artifact, map[string]string{"other": "x"})
+	if err := Install(artifact, target); err == nil {
+		t.Error("expected an error for an archive without the executable")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Errorf("failed install changed the target: %q", data)
+	}
+}
+
+func TestInstall_Bundle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bundles contain symlinks")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "secretctl.app")
+	os.MkdirAll(filepath.Join(target, "Contents", "MacOS"), 0o755)
+	os.WriteFile(filepath.Join(target, "Contents", "MacOS", "secretctl"), []byte("old"), 0o755)
+
+	artifact := filepath.Join(t.TempDir(), "download.zip")
+	writeZip(t, artifact, []zipEntry{
+		{name: "secretctl.app/Contents/MacOS/secretctl", body: "new"},
+		{name: "secretctl.app/Contents/Info.plist", body: "<plist/>"},
+		{name: "secretctl.app/Contents/Current", link: "MacOS"},
+	})
+	if err := Install(artifact, target); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(target, "Contents", "Current", "secretctl")); string(data) != "new" {
+		t.Errorf("bundle executable = %q, want new", data)
+	}
+
+	unsafe := [][]zipEntry{
+		{{name: "../escape.app/x", body: "x"}},
+		{{name: "secretctl.app/x", body: "x"}, {name: "other.app/y", body: "y"}},
+		{{name: "secretctl.app/Contents/link", link: "../../.."}},
+		{{name: "secretctl.app/Contents/link", link: "/etc"}},
+		{{name: "not-a-bundle/x", body: "x"}},
+	}
+	for i, entries := range unsafe {
+		writeZip(t, artifact, entries)
+		if err := Install(artifact, target); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+		if data, _ := os.ReadFile(filepath.Join(target, "Contents", "MacOS", "secretctl")); string(data) != "new" {
+			t.Fatalf("case %d: failed install changed the bundle", i)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.app")); err == nil {
+		t.Error("archive entry escaped the extraction directory")
+	}
+}
@@ -0,0 +1,70 @@
+package update
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+)
+
+// CheckInterval is how often automatic checks run.
+const CheckInterval = 24 * time.Hour
+
+// Settings are the user's update preferences.
+type Settings struct {
+	// AutoCheck enables a check at startup, at most once per CheckInterval.
+	AutoCheck bool `json:"auto_check"`
+	// Channel is the release channel to follow.
+	Channel Channel `json:"channel"`
+	// LastCheck is when the last automatic check ran.
+	LastCheck *time.Time `json:"last_check,omitempty"`
+}
+
+// DefaultSettings follows the stable channel with automatic checks on.
+func DefaultSettings() Settings {
+	return Settings{AutoCheck: true, Channel: ChannelStable}
+}
+
+// DueForCheck reports whether an automatic check should run at now.
+func (s Settings) DueForCheck(now time.Time) bool {
+	return s.AutoCheck && (s.LastCheck == nil || now.Sub(*s.LastCheck) >= CheckInterval)
+}
+
+// LoadSettings reads settings from path, returning DefaultSettings if the
+// file does not exist.
+func LoadSettings(path string) (Settings, error) {
+	s := DefaultSettings()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("update: failed to read settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return DefaultSettings(), fmt.Errorf("update: invalid settings file %s: %w", path, err)
+	}
+	if ValidateChannel(s.Channel) != nil {
+		s.Channel = ChannelStable
+	}
+	return s, nil
+}
+
+// SaveSettings writes s to path, creating its directory.
+func SaveSettings(path string, s Settings) error {
+	if err := ValidateChannel(s.Channel); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("update: failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("update: failed to create settings directory: %w", err)
+	}
+	return atomicfile.Write(path, data, 0o600)
+}
@@ -0,0 +1,375 @@
+// Package update checks for and installs new releases of the desktop app.
+//
+// Each release channel is described by a manifest that the release
+// workflow signs with the release key. An artifact is only installed when
+// the manifest signature verifies against one of ReleasePublicKeys and the
+// downloaded file matches the SHA-256 listed in the manifest.
+package update
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Channel is a release channel.
+type Channel string
+
+const (
+	// ChannelStable receives tagged releases only.
+	ChannelStable Channel = "stable"
+	// ChannelBeta also receives pre-releases (tags such as v1.2.0-beta.1).
+	ChannelBeta Channel = "beta"
+)
+
+// DefaultBaseURL is where the release workflow publishes the channel
+// manifests, as <channel>.json and <channel>.json.sig.
+const DefaultBaseURL = "https://github.com/forest6511/secretctl/releases/download/update-manifests"
+
+// DefaultTimeout bounds fetching a manifest.
+const DefaultTimeout = 30 * time.Second
+
+const (
+	maxManifestSize = 1 << 20   // 1 MiB
+	maxArtifactSize = 512 << 20 // 512 MiB
+)
+
+// ReleasePublicKeys verify manifests signed by the release workflow with
+// the UPDATE_SIGNING_KEY secret. They change only through the key ceremony
+// in website/docs/contributing/release-signing.md; during a rotation both
+// the old and the new key are listed.
+var ReleasePublicKeys = []ed25519.PublicKey{
+	mustDecodeKey("LhqvmoUf+JzSljdK/ReQuLW0hKxQJY9BrXufP6uR5l4="),
+}
+
+var (
+	// ErrInvalidChannel is returned for channels other than stable and beta.
+	ErrInvalidChannel = errors.New("update: channel must be stable or beta")
+	// ErrInvalidSignature is returned when a manifest is not signed by the release key.
+	ErrInvalidSignature = errors.New("update: manifest signature is invalid")
+	// ErrChecksumMismatch is returned when a download does not match the signed manifest.
+	ErrChecksumMismatch = errors.New("update: download does not match the signed checksum")
+	// ErrNoAsset is returned when a release has no artifact for this platform.
+	ErrNoAsset = errors.New("update: release has no download for this platform")
+)
+
+// ValidateChannel checks that c is a known channel.
+func ValidateChannel(c Channel) error {
+	if c != ChannelStable && c != ChannelBeta {
+		return ErrInvalidChannel
+	}
+	return nil
+}
+
+// Manifest describes the newest release of a channel.
+type Manifest struct {
+	Channel    Channel   `json:"channel"`
+	Version    string    `json:"version"`
+	ReleasedAt time.Time `json:"released_at"`
+	NotesURL   string    `json:"notes_url,omitempty"`
+	Assets     []Asset   `json:"assets"`
+}
+
+// Asset is a downloadable artifact of a release. Arch is "universal" for
+// artifacts that run on every architecture of OS.
+type Asset struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Asset returns the artifact for goos/goarch, or nil if there is none.
+func (m *Manifest) Asset(goos, goarch string) *Asset {
+	for i := range m.Assets {
+		a := &m.Assets[i]
+		if a.OS == goos && (a.Arch == goarch || a.Arch == "universal") {
+			return a
+		}
+	}
+	return nil
+}
+
+// SignManifest returns the base64 signature of manifest data, as published
+// in <channel>.json.sig.
+func SignManifest(key ed25519.PrivateKey, data []byte) []byte {
+	sig := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(sig))
+}
+
+// ParseManifest verifies that sig over data was made with one of keys and
+// decodes the manifest.
+func ParseManifest(data, sig []byte, keys ...ed25519.PublicKey) (*Manifest, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !slices.ContainsFunc(keys, func(key ed25519.PublicKey) bool {
+		return ed25519.Verify(key, data, raw)
+	}) {
+		return nil, ErrInvalidSignature
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("update: invalid manifest: %w", err)
+	}
+	if _, ok := parseVersion(m.Version); !ok {
+		return nil, fmt.Errorf("update: invalid manifest version %q", m.Version)
+	}
+	return &m, nil
+}
+
+// Release is the result of a check.
+type Release struct {
+	Manifest
+	// Available reports whether Version is newer than the running version.
+	Available bool
+	// Download is the artifact for this platform (nil if there is none).
+	Download *Asset
+}
+
+// Checker fetches channel manifests.
+type Checker struct {
+	// BaseURL is the manifest location (DefaultBaseURL).
+	BaseURL string
+	// PublicKeys verify manifests (ReleasePublicKeys).
+	PublicKeys []ed25519.PublicKey
+	// Client performs the requests.
+	Client *http.Client
+	// CurrentVersion is the running version, e.g. "0.9.0".
+	CurrentVersion string
+	// GOOS and GOARCH select the artifact (runtime.GOOS and runtime.GOARCH).
+	GOOS, GOARCH string
+}
+
+// NewChecker returns a checker for the official releases.
+func NewChecker(currentVersion string) *Checker {
+	return &Checker{
+		BaseURL:        DefaultBaseURL,
+		PublicKeys:     ReleasePublicKeys,
+		Client:         &http.Client{Timeout: DefaultTimeout},
+		CurrentVersion: currentVersion,
+		GOOS:           runtime.GOOS,
+		GOARCH:         runtime.GOARCH,
+	}
+}
+
+// Check fetches and verifies the manifest of channel. A manifest signed for
+// another channel is rejected, so a beta release cannot be served to the
+// stable channel.
+func (c *Checker) Check(ctx context.Context, channel Channel) (*Release, error) {
+	if err := ValidateChannel(channel); err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(c.BaseURL, "/") + "/" + string(channel) + ".json"
+	data, err := c.fetch(ctx, base, maxManifestSize)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := c.fetch(ctx, base+".sig", 1024)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(data, sig, c.PublicKeys...)
+	if err != nil {
+		return nil, err
+	}
+	if m.Channel != channel {
+		return nil, fmt.Errorf("update: manifest is for channel %q, not %q", m.Channel, channel)
+	}
+	return &Release{
+		Manifest:  *m,
+		Available: IsNewer(m.Version, c.CurrentVersion),
+		Download:  m.Asset(c.GOOS, c.GOARCH),
+	}, nil
+}
+
+// Download saves the artifact of rel to a new file in dir and returns its
+// path. The file is removed again unless its size and SHA-256 match the
+// signed manifest.
+func (c *Checker) Download(ctx context.Context, rel *Release, dir string) (path string, err error) {
+	asset := rel.Download
+	if asset == nil {
+		return "", ErrNoAsset
+	}
+	if asset.Size <= 0 || asset.Size > maxArtifactSize {
+		return "", fmt.Errorf("update: invalid artifact size %d", asset.Size)
+	}
+	want, err := hex.DecodeString(asset.SHA256)
+	if err != nil || len(want) != sha256.Size {
+		return "", fmt.Errorf("update: invalid artifact checksum")
+	}
+
+	body, err := c.open(ctx, asset.URL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	f, err := os.CreateTemp(dir, ".secretctl-update-*"+assetExt(asset.URL))
+	if err != nil {
+		return "", fmt.Errorf("update: failed to create download file: %w", err)
+	}
+	defer func() {
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(body, asset.Size+1))
+	if err != nil {
+		return "", fmt.Errorf("update: download failed: %w", err)
+	}
+	if n != asset.Size || !bytes.Equal(h.Sum(nil), want) {
+		return "", ErrChecksumMismatch
+	}
+	if err := f.Sync(); err != nil {
+		return "", fmt.Errorf("update: failed to write download: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func (c *Checker) fetch(ctx context.Context, url string, limit int64) ([]byte, error) {
+	body, err := c.open(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("update: failed to read %s: %w", url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("update: %s is too large", url)
+	}
+	return data, nil
+}
+
+func (c *Checker) open(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("update: invalid URL: %w", err)
+	}
+	req.Header.Set("User-Agent", "secretctl-desktop/"+c.CurrentVersion)
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update: request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("update: %s returned %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// IsNewer reports whether version is newer than current. Versions are
+// semantic versions with an optional "v" prefix; a current version that is
+// not one (such as "dev") is never updated.
+func IsNewer(version, current string) bool {
+	v, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	return compareVersions(v, cur) > 0
+}
+
+// semver holds the parts of a semantic version that affect precedence.
+type semver struct {
+	core [3]int
+	pre  []string
+}
+
+func parseVersion(s string) (semver, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	core, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	if hasPre {
+		if pre == "" {
+			return v, false
+		}
+		v.pre = strings.Split(pre, ".")
+	}
+	return v, true
+}
+
+// compareVersions orders versions by semver precedence: a pre-release sorts
+// before its release, and numeric identifiers compare as numbers.
+func compareVersions(a, b semver) int {
+	for i := range a.core {
+		if a.core[i] != b.core[i] {
+			return cmp.Compare(a.core[i], b.core[i])
+		}
+	}
+	switch {
+	case len(a.pre) == 0 && len(b.pre) == 0:
+		return 0
+	case len(a.pre) == 0:
+		return 1
+	case len(b.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		x, xErr := strconv.Atoi(a.pre[i])
+		y, yErr := strconv.Atoi(b.pre[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return cmp.Compare(x, y)
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a.pre[i], b.pre[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(a.pre), len(b.pre))
+}
+
+func mustDecodeKey(s string) ed25519.PublicKey {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		panic("update: invalid release public key")
+	}
+	return ed25519.PublicKey(key)
+}
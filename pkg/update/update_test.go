@@ -0,0 +1,234 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// releaseServer serves signed manifests for both channels and one artifact.
+type releaseServer struct {
+	*httptest.Server
+	key       ed25519.PrivateKey
+	manifests map[string][]byte
+	sigs      map[string][]byte
+	artifact  []byte
+}
+
+func newReleaseServer(t *testing.T) *releaseServer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	rs := &releaseServer{
+		key:       key,
+		manifests: make(map[string][]byte),
+		sigs:      make(map[string][]byte),
+		artifact:  []byte("new desktop build"),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifests/{file}", func(w http.ResponseWriter, r *http.Request) {
+		file := r.PathValue("file")
+		if data, ok := rs.manifests[file]; ok {
+			w.Write(data)
+			return
+		}
+		if sig, ok := rs.sigs[file]; ok {
+			w.Write(sig)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/download/secretctl-desktop.exe", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(rs.artifact)
+	})
+	rs.Server = httptest.NewServer(mux)
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+// publish signs and serves a manifest for channel.
+func (rs *releaseServer) publish(t *testing.T, channel Channel, version string) {
+	t.Helper()
+	sum := sha256.Sum256(rs.artifact)
+	m := Manifest{
+		Channel:    channel,
+		Version:    version,
+		ReleasedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Assets: []Asset{{
+			OS:     "windows",
+			Arch:   "amd64",
+			URL:    rs.URL + "/download/secretctl-desktop.exe",
+			SHA256: hex.EncodeToString(sum[:]),
+			Size:   int64(len(rs.artifact)),
+		}},
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	rs.manifests[string(channel)+".json"] = data
+	rs.sigs[string(channel)+".json.sig"] = SignManifest(rs.key, data)
+}
+
+func (rs *releaseServer) checker(current string) *Checker {
+	c := NewChecker(current)
+	c.BaseURL = rs.URL + "/manifests"
+	c.PublicKeys = []ed25519.PublicKey{rs.key.Public().(ed25519.PublicKey)}
+	c.Client = rs.Client()
+	c.GOOS, c.GOARCH = "windows", "amd64"
+	return c
+}
+
+func TestCheckAndDownload(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.publish(t, ChannelStable, "1.2.0")
+	rs.publish(t, ChannelBeta, "1.3.0-beta.1")
+	ctx := context.Background()
+
+	rel, err := rs.checker("1.1.0").Check(ctx, ChannelStable)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if !rel.Available || rel.Version != "1.2.0" || rel.Download == nil {
+		t.Fatalf("unexpected release: %+v", rel)
+	}
+	beta, err := rs.checker("1.2.0").Check(ctx, ChannelBeta)
+	if err != nil || !beta.Available {
+		t.Fatalf("beta check = %+v, %v", beta, err)
+	}
+	if current, _ := rs.checker("1.2.0").Check(ctx, ChannelStable); current.Available {
+		t.Error("same version reported as available")
+	}
+
+	path, err := rs.checker("1.1.0").Download(ctx, rel, t.TempDir())
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(rs.artifact) {
+		t.Errorf("downloaded %q", data)
+	}
+	if filepath.Ext(path) != ".exe" {
+		t.Errorf("download %s lost its extension", path)
+	}
+
+	// The artifact changed after the manifest was signed
+	rs.artifact = []byte("tampered desktop build")
+	dir := t.TempDir()
+	if _, err := rs.checker("1.1.0").Download(ctx, rel, dir); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("rejected download left %d files", len(entries))
+	}
+}
+
+func TestCheck_Rejected(t *testing.T) {
+	rs := newReleaseServer(t)
+	rs.publish(t, ChannelStable, "1.2.0")
+	rs.publish(t, ChannelBeta, "1.3.0-beta.1")
+	ctx := context.Background()
+
+	// Signed by a different key
+	other := rs.checker("1.1.0")
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+	other.PublicKeys = []ed25519.PublicKey{otherKey}
+	if _, err := other.Check(ctx, ChannelStable); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+
+	// During a key rotation either key verifies
+	rotating := rs.checker("1.1.0")
+	rotating.PublicKeys = []ed25519.PublicKey{otherKey, rs.key.Public().(ed25519.PublicKey)}
+	if _, err := rotating.Check(ctx, ChannelStable); err != nil {
+		t.Errorf("Check with the signing key listed second failed: %v", err)
+	}
+
+	// Modified after signing
+	rs.manifests["stable.json"] = append(rs.manifests["stable.json"], ' ')
+	if _, err := rs.checker("1.1.0").Check(ctx, ChannelStable); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+
+	// A correctly signed beta manifest served as stable
+	rs.manifests["stable.json"] = rs.manifests["beta.json"]
+	rs.sigs["stable.json.sig"] = rs.sigs["beta.json.sig"]
+	if _, err := rs.checker("1.1.0").Check(ctx, ChannelStable); err == nil {
+		t.Error("expected an error for a manifest of another channel")
+	}
+
+	if _, err := rs.checker("1.1.0").Check(ctx, "nightly"); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("expected ErrInvalidChannel, got %v", err)
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		version, current string
+		want             bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.10.0", "1.9.0", true},
+		{"1.2.0", "1.2.0", false},
+		{"1.2.0", "1.2.0-beta.2", true},
+		{"1.2.0-beta.2", "1.2.0", false},
+		{"1.2.0-beta.10", "1.2.0-beta.9", true},
+		{"1.2.0-rc.1", "1.2.0-beta.9", true},
+		{"1.2.0-beta.1.1", "1.2.0-beta.1", true},
+		{"1.1.0", "1.2.0", false},
+		{"1.2.0", "dev", false},
+		{"latest", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewer(tt.version, tt.current); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.version, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secretctl", "update.json")
+
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	now := time.Now()
+	if s != DefaultSettings() || !s.DueForCheck(now) {
+		t.Errorf("unexpected defaults: %+v", s)
+	}
+
+	s.Channel = ChannelBeta
+	s.LastCheck = &now
+	if err := SaveSettings(path, s); err != nil {
+		t.Fatalf("SaveSettings failed: %v", err)
+	}
+	loaded, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	if loaded.Channel != ChannelBeta || loaded.LastCheck == nil || !loaded.AutoCheck {
+		t.Errorf("settings not saved: %+v", loaded)
+	}
+	if loaded.DueForCheck(now.Add(time.Hour)) || !loaded.DueForCheck(now.Add(CheckInterval)) {
+		t.Error("DueForCheck ignores LastCheck")
+	}
+	loaded.AutoCheck = false
+	if loaded.DueForCheck(now.Add(CheckInterval)) {
+		t.Error("DueForCheck with AutoCheck off")
+	}
+
+	if err := SaveSettings(path, Settings{Channel: "nightly"}); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("expected ErrInvalidChannel, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Access rule errors
+var (
+	ErrSecretNotYetValid    = errors.New("vault: secret is not valid yet")
+	ErrOutsideAccessWindow  = errors.New("vault: secret is outside its access window")
+	ErrInvalidAccessWindow  = errors.New("vault: invalid access window")
+	ErrNotBeforeAfterExpiry = errors.New("vault: not_before must be before expires_at")
+)
+
+// AccessWindow restricts when a secret can be read, e.g. to deploy windows.
+// A read is allowed when the current time in Timezone falls on one of Days
+// between Start (inclusive) and End (exclusive). A window whose End is not
+// after its Start spans midnight and belongs to the day it starts on.
+type AccessWindow struct {
+	// Days lists the allowed weekdays. Empty means every day.
+	Days []time.Weekday `json:"days,omitempty"`
+	// Start is the opening time as "HH:MM". Empty means 00:00.
+	Start string `json:"start,omitempty"`
+	// End is the closing time as "HH:MM" (up to "24:00"). Empty means 24:00.
+	End string `json:"end,omitempty"`
+	// Timezone is an IANA time zone name. Empty means the local time zone.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseAccessWindow parses a window such as "mon-fri 09:00-17:00 Europe/Berlin".
+// All three parts are optional and may appear in any order: a day list
+// ("mon-fri", "sat,sun"), a time range ("22:00-02:00") and a time zone.
+func ParseAccessWindow(s string) (*AccessWindow, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: empty window", ErrInvalidAccessWindow)
+	}
+
+	w := &AccessWindow{}
+	for _, part := range parts {
+		if days, err := parseWeekdays(strings.ToLower(part)); err == nil {
+			if w.Days != nil {
+				return nil, fmt.Errorf("%w: days given twice", ErrInvalidAccessWindow)
+			}
+			w.Days = days
+			continue
+		}
+		if start, end, ok := strings.Cut(part, "-"); ok && strings.Contains(part, ":") {
+			if w.Start != "" || w.End != "" {
+				return nil, fmt.Errorf("%w: time range given twice", ErrInvalidAccessWindow)
+			}
+			w.Start, w.End = start, end
+			continue
+		}
+		if w.Timezone != "" {
+			return nil, fmt.Errorf("%w: unrecognized %q", ErrInvalidAccessWindow, part)
+		}
+		w.Timezone = part
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// parseWeekdays parses "mon-fri", "sat,sun" or "mon,wed-fri".
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	seen := make(map[time.Weekday]bool)
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return nil, fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight (0-1440).
+func parseClock(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, fmt.Errorf("%w: time %q must be HH:MM", ErrInvalidAccessWindow, s)
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("%w: time %q out of range", ErrInvalidAccessWindow, s)
+	}
+	return h*60 + m, nil
+}
+
+// Validate checks the window's times and time zone.
+func (w *AccessWindow) Validate() error {
+	start, err := parseClock(w.Start, 0)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(w.End, 24*60)
+	if err != nil {
+		return err
+	}
+	if start == 24*60 {
+		return fmt.Errorf("%w: start must be before 24:00", ErrInvalidAccessWindow)
+	}
+	if start == end {
+		return fmt.Errorf("%w: start and end are equal", ErrInvalidAccessWindow)
+	}
+	for _, d := range w.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("%w: invalid weekday %d", ErrInvalidAccessWindow, d)
+		}
+	}
+	if _, err := w.location(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAccessWindow, err)
+	}
+	return nil
+}
+
+func (w *AccessWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// Allows reports whether t falls inside the window. Invalid windows allow nothing.
+func (w *AccessWindow) Allows(t time.Time) bool {
+	start, err1 := parseClock(w.Start, 0)
+	end, err2 := parseClock(w.End, 24*60)
+	loc, err3 := w.location()
+	if err1 != nil || err2 != nil || err3 != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return w.allowsDay(t.Weekday()) && now >= start && now < end
+	}
+	// Spans midnight: the early-morning part belongs to the previous day
+	if now >= start {
+		return w.allowsDay(t.Weekday())
+	}
+	return now < end && w.allowsDay((t.Weekday()+6)%7)
+}
+
+func (w *AccessWindow) allowsDay(d time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the window in the form accepted by ParseAccessWindow.
+func (w *AccessWindow) String() string {
+	var parts []string
+	if len(w.Days) > 0 {
+		names := make([]string, len(w.Days))
+		for i, d := range w.Days {
+			names[i] = strings.ToLower(d.String()[:3])
+		}
+		parts = append(parts, strings.Join(names, ","))
+	}
+	if w.Start != "" || w.End != "" {
+		start, end := w.Start, w.End
+		if start == "" {
+			start = "00:00"
+		}
+		if end == "" {
+			end = "24:00"
+		}
+		parts = append(parts, start+"-"+end)
+	}
+	if w.Timezone != "" {
+		parts = append(parts, w.Timezone)
+	}
+	if len(parts) == 0 {
+		return "always"
+	}
+	return strings.Join(parts, " ")
+}
+
+// validateAccessRules checks NotBefore and AccessWindow of an entry.
+func validateAccessRules(entry *SecretEntry) error {
+	if entry.NotBefore != nil && entry.ExpiresAt != nil && !entry.NotBefore.Before(*entry.ExpiresAt) {
+		return ErrNotBeforeAfterExpiry
+	}
+	if entry.AccessWindow != nil {
+		return entry.AccessWindow.Validate()
+	}
+	return nil
+}
+
+// checkAccessRules returns an error if a secret may not be read at now.
+func checkAccessRules(notBefore *time.Time, window *AccessWindow, now time.Time) error {
+	if notBefore != nil && now.Before(*notBefore) {
+		return fmt.Errorf("%w: usable from %s", ErrSecretNotYetValid, notBefore.Format(time.RFC3339))
+	}
+	if window != nil && !window.Allows(now) {
+		return fmt.Errorf("%w (%s)", ErrOutsideAccessWindow, window)
+	}
+	return nil
+}
+
+// parseAccessWindowColumn parses the access_window column. A window that
+// cannot be parsed denies all reads rather than silently allowing them.
+func parseAccessWindowColumn(s sql.NullString) *AccessWindow {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	var w AccessWindow
+	if err := json.Unmarshal([]byte(s.String), &w); err != nil {
+		return &AccessWindow{Start: "invalid"}
+	}
+	return &w
+}
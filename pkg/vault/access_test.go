@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseAccessWindow(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"mon-fri 09:00-17:00", "mon,tue,wed,thu,fri 09:00-17:00", false},
+		{"sat,sun", "sat,sun", false},
+		{"fri-mon 22:00-02:00 UTC", "fri,sat,sun,mon 22:00-02:00 UTC", false},
+		{"09:00-24:00 Europe/Berlin", "09:00-24:00 Europe/Berlin", false},
+		{"", "", true},
+		{"mon-fri 9:00-17:00", "", true},
+		{"mon 17:00-17:00", "", true},
+		{"mon 08:00-25:00", "", true},
+		{"mon Not/AZone", "", true},
+		{"mon tue", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			w, err := ParseAccessWindow(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAccessWindow) {
+					t.Errorf("expected ErrInvalidAccessWindow, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAccessWindow failed: %v", err)
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessWindowAllows(t *testing.T) {
+	// 2026-10-16 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+	office := &AccessWindow{Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start: "09:00", End: "17:00", Timezone: "UTC"}
+	overnight := &AccessWindow{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "02:00", Timezone: "UTC"}
+
+	tests := []struct {
+		name   string
+		window *AccessWindow
+		t      time.Time
+		want   bool
+	}{
+		{"weekday inside", office, at(16, 9, 0), true},
+		{"weekday end exclusive", office, at(16, 17, 0), false},
+		{"weekday before start", office, at(16, 8, 59), false},
+		{"weekend", office, at(17, 12, 0), false},
+		{"overnight start day", overnight, at(16, 23, 30), true},
+		{"overnight after midnight", overnight, at(17, 1, 59), true},
+		{"overnight closed", overnight, at(17, 2, 0), false},
+		{"overnight wrong day", overnight, at(15, 23, 30), false},
+		{"invalid window", &AccessWindow{Start: "invalid"}, at(16, 12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Allows(tt.t); got != tt.want {
+				t.Errorf("Allows(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessRulesEnforced(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	future := time.Now().Add(24 * time.Hour)
+	if err := v.SetSecret("deploy/later", &SecretEntry{Value: []byte("v"), NotBefore: &future}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("deploy/later"); !errors.Is(err, ErrSecretNotYetValid) {
+		t.Errorf("expected ErrSecretNotYetValid, got %v", err)
+	}
+	if _, err := v.GetSecretAllowExpired("deploy/later"); !errors.Is(err, ErrSecretNotYetValid) {
+		t.Errorf("--allow-expired must not lift not_before, got %v", err)
+	}
+
+	// A window that excludes today
+	today := time.Now().Weekday()
+	closed := &AccessWindow{Days: []time.Weekday{(today + 3) % 7}}
+	if err := v.SetSecret("deploy/window", &SecretEntry{Value: []byte("v"), AccessWindow: closed}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("deploy/window"); !errors.Is(err, ErrOutsideAccessWindow) {
+		t.Errorf("expected ErrOutsideAccessWindow, got %v", err)
+	}
+
+	open := &AccessWindow{Days: []time.Weekday{today, (today + 1) % 7, (today + 6) % 7}}
+	if err := v.SetSecret("deploy/open", &SecretEntry{Value: []byte("v"), AccessWindow: open}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("deploy/open"); err != nil {
+		t.Errorf("expected read inside window to succeed, got %v", err)
+	}
+
+	// Metadata views still see restricted secrets
+	entry, err := v.InspectSecret("deploy/window")
+	if err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	if entry.AccessWindow == nil || entry.AccessWindow.String() != closed.String() {
+		t.Errorf("expected access window to round-trip, got %v", entry.AccessWindow)
+	}
+	entries, err := v.ListSecretsWithMetadata()
+	if err != nil {
+		t.Fatalf("ListSecretsWithMetadata failed: %v", err)
+	}
+	for _, e := range entries {
+		if e.Key == "deploy/later" && (e.NotBefore == nil || !e.NotBefore.Equal(future)) {
+			t.Errorf("expected not_before in listing, got %v", e.NotBefore)
+		}
+	}
+
+	// Validation
+	soon := time.Now().Add(time.Hour)
+	err = v.SetSecret("deploy/bad", &SecretEntry{Value: []byte("v"), NotBefore: &future, ExpiresAt: &soon})
+	if !errors.Is(err, ErrNotBeforeAfterExpiry) {
+		t.Errorf("expected ErrNotBeforeAfterExpiry, got %v", err)
+	}
+	err = v.SetSecret("deploy/bad", &SecretEntry{Value: []byte("v"), AccessWindow: &AccessWindow{Start: "25:00"}})
+	if !errors.Is(err, ErrInvalidAccessWindow) {
+		t.Errorf("expected ErrInvalidAccessWindow, got %v", err)
+	}
+}
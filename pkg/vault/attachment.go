@@ -0,0 +1,381 @@
+package vault
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// MaxAttachmentSize is the largest file that can be attached to a secret.
+// Attachments are meant for certificates, key pairs and similar small
+// files; each is held in memory while it is encrypted.
+const MaxAttachmentSize = 1 << 20
+
+// maxAttachmentNameLength is the longest attachment name, in bytes.
+const maxAttachmentNameLength = 255
+
+// Attachment errors
+var (
+	ErrAttachmentNotFound    = errors.New("vault: attachment not found")
+	ErrAttachmentTooLarge    = fmt.Errorf("vault: attachment is larger than %d bytes", MaxAttachmentSize)
+	ErrInvalidAttachmentName = errors.New("vault: invalid attachment name")
+)
+
+// attachmentsTableSQL creates the table of files attached to secrets. Like
+// secret_history it lives in vault.db for sharded secrets too. The name and
+// contents are encrypted; the size is kept in the clear for listings, as
+// the ciphertext reveals it anyway.
+const attachmentsTableSQL = `
+	CREATE TABLE IF NOT EXISTS attachments (
+		id INTEGER PRIMARY KEY,
+		key_hash TEXT NOT NULL,
+		encrypted_name BLOB NOT NULL,
+		encrypted_data BLOB NOT NULL,
+		size INTEGER NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+const attachmentsIndexSQL = "CREATE INDEX IF NOT EXISTS idx_attachments_key ON attachments(key_hash)"
+
+// Attachment describes a file attached to a secret. The contents are read
+// with GetAttachment.
+type Attachment struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidateAttachmentName checks that name can be used as a file name on
+// every platform: not empty, at most 255 bytes, without path separators
+// or control characters, and not "." or "..".
+func ValidateAttachmentName(name string) error {
+	if name == "" || len(name) > maxAttachmentNameLength || name == "." || name == ".." {
+		return fmt.Errorf("%w: %q", ErrInvalidAttachmentName, name)
+	}
+	if strings.ContainsAny(name, `/\`) || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidAttachmentName, name)
+	}
+	return nil
+}
+
+// AddAttachment encrypts the contents of r and attaches them to the secret
+// key as name, replacing an attachment of the same name. Attaching counts
+// as a change of the secret, so immutable and frozen secrets refuse it.
+// Attachments follow the secret into the trash and are removed when it is
+// purged.
+func (v *Vault) AddAttachment(key, name string, r io.Reader) error {
+	return v.run(Op{Name: OpAttachmentAdd, Key: key}, func() error {
+		return v.addAttachment(key, name, r)
+	})
+}
+
+func (v *Vault) addAttachment(key, name string, r io.Reader) error {
+	if err := ValidateAttachmentName(name); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxAttachmentSize+1))
+	if err != nil {
+		return fmt.Errorf("vault: failed to read attachment: %w", err)
+	}
+	if len(data) > MaxAttachmentSize {
+		return ErrAttachmentTooLarge
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	keyHash := v.hashKey(key)
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := v.checkMutableTx(tx, keyHash, key, audit.OpSecretAttach, writeOptions{}); err != nil {
+		return err
+	}
+	if err := v.checkSecretExistsTx(tx, keyHash, key); err != nil {
+		return err
+	}
+	id, err := v.findAttachmentTx(tx, keyHash, name)
+	if err != nil && !errors.Is(err, ErrAttachmentNotFound) {
+		return err
+	}
+	if id != 0 {
+		if _, err := tx.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+			return fmt.Errorf("vault: failed to replace attachment: %w", err)
+		}
+	}
+
+	encryptedName, err := v.encryptWithNonce([]byte(name))
+	if err != nil {
+		return fmt.Errorf("vault: failed to encrypt attachment name: %w", err)
+	}
+	encryptedData, err := v.encryptWithNonce(data)
+	if err != nil {
+		return fmt.Errorf("vault: failed to encrypt attachment: %w", err)
+	}
+	_, err = tx.Exec("INSERT INTO attachments (key_hash, encrypted_name, encrypted_data, size) VALUES (?, ?, ?, ?)",
+		keyHash, encryptedName, encryptedData, len(data))
+	if err != nil {
+		return fmt.Errorf("vault: failed to save attachment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	if err := v.recordAttachmentFeature(); err != nil {
+		v.warn(EventWarning, "failed to record attachments in vault.meta: %v", err)
+	}
+	_ = v.audit.Log(audit.OpSecretAttach, v.auditSource(), audit.ResultSuccess, key, nil,
+		map[string]interface{}{"name": name, "size": len(data)})
+	v.emit(EventSecretChanged, key, "attachment added")
+	return nil
+}
+
+// ListAttachments returns the attachments of the secret key, sorted by
+// name. It does not decrypt their contents.
+func (v *Vault) ListAttachments(key string) ([]*Attachment, error) {
+	return runValue(v, Op{Name: OpList, Key: key}, func() ([]*Attachment, error) {
+		return v.listAttachments(key)
+	})
+}
+
+func (v *Vault) listAttachments(key string) ([]*Attachment, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	keyHash := v.hashKey(key)
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := v.checkSecretExistsTx(tx, keyHash, key); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("SELECT encrypted_name, size, created_at FROM attachments WHERE key_hash = ?", keyHash)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []*Attachment{}
+	for rows.Next() {
+		var encryptedName []byte
+		var a Attachment
+		if err := rows.Scan(&encryptedName, &a.Size, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		name, err := v.decryptWithNonce(encryptedName)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to decrypt attachment name: %w", err)
+		}
+		a.Name = string(name)
+		attachments = append(attachments, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].Name < attachments[j].Name })
+	return attachments, nil
+}
+
+// GetAttachment decrypts and returns the attachment name of the secret
+// key. The secret's access rules apply as they do to its value, and the
+// read is audited.
+func (v *Vault) GetAttachment(key, name string) ([]byte, error) {
+	return runValue(v, Op{Name: OpAttachmentGet, Key: key}, func() ([]byte, error) {
+		return v.getAttachment(key, name)
+	})
+}
+
+func (v *Vault) getAttachment(key, name string) ([]byte, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	keyHash := v.hashKey(key)
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var notBefore sql.NullTime
+	var accessWindow sql.NullString
+	err = tx.QueryRow("SELECT not_before, access_window FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).
+		Scan(&notBefore, &accessWindow)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, v.secretNotFound(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to read secret: %w", err)
+	}
+	var notBeforePtr *time.Time
+	if notBefore.Valid {
+		notBeforePtr = &notBefore.Time
+	}
+	if err := checkAccessRules(notBeforePtr, parseAccessWindowColumn(accessWindow), time.Now()); err != nil {
+		_ = v.audit.LogDenied(audit.OpSecretAttachmentGet, v.auditSource(), key, err.Error())
+		return nil, err
+	}
+
+	id, err := v.findAttachmentTx(tx, keyHash, name)
+	if err != nil {
+		return nil, err
+	}
+	var encryptedData []byte
+	if err := tx.QueryRow("SELECT encrypted_data FROM attachments WHERE id = ?", id).Scan(&encryptedData); err != nil {
+		return nil, fmt.Errorf("vault: failed to read attachment: %w", err)
+	}
+	data, err := v.decryptWithNonce(encryptedData)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to decrypt attachment: %w", err)
+	}
+
+	_ = v.audit.Log(audit.OpSecretAttachmentGet, v.auditSource(), audit.ResultSuccess, key, nil,
+		map[string]interface{}{"name": name})
+	return data, nil
+}
+
+// RemoveAttachment removes the attachment name from the secret key.
+func (v *Vault) RemoveAttachment(key, name string) error {
+	return v.run(Op{Name: OpAttachmentRemove, Key: key}, func() error {
+		return v.removeAttachment(key, name)
+	})
+}
+
+func (v *Vault) removeAttachment(key, name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	keyHash := v.hashKey(key)
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := v.checkMutableTx(tx, keyHash, key, audit.OpSecretAttachmentRemove, writeOptions{}); err != nil {
+		return err
+	}
+	if err := v.checkSecretExistsTx(tx, keyHash, key); err != nil {
+		return err
+	}
+	id, err := v.findAttachmentTx(tx, keyHash, name)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("vault: failed to remove attachment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	_ = v.audit.Log(audit.OpSecretAttachmentRemove, v.auditSource(), audit.ResultSuccess, key, nil,
+		map[string]interface{}{"name": name})
+	v.emit(EventSecretChanged, key, "attachment removed")
+	return nil
+}
+
+// checkSecretExistsTx returns ErrSecretNotFound unless key is stored and
+// not in the trash.
+func (v *Vault) checkSecretExistsTx(tx *sql.Tx, keyHash, key string) error {
+	var one int
+	err := tx.QueryRow("SELECT 1 FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return v.secretNotFound(key)
+	}
+	if err != nil {
+		return fmt.Errorf("vault: failed to read secret: %w", err)
+	}
+	return nil
+}
+
+// secretNotFound returns ErrSecretNotFound, or the reason the shard of key
+// cannot be read.
+func (v *Vault) secretNotFound(key string) error {
+	if err := v.checkShard(key); err != nil {
+		return err
+	}
+	return ErrSecretNotFound
+}
+
+// findAttachmentTx returns the row id of the attachment name of the
+// secret keyHash. Names are encrypted, so the secret's attachments are
+// decrypted in turn; there are few per secret.
+func (v *Vault) findAttachmentTx(tx *sql.Tx, keyHash, name string) (int64, error) {
+	rows, err := tx.Query("SELECT id, encrypted_name FROM attachments WHERE key_hash = ?", keyHash)
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var encryptedName []byte
+		if err := rows.Scan(&id, &encryptedName); err != nil {
+			return 0, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		stored, err := v.decryptWithNonce(encryptedName)
+		if err != nil {
+			return 0, fmt.Errorf("vault: failed to decrypt attachment name: %w", err)
+		}
+		if string(stored) == name {
+			return id, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	return 0, ErrAttachmentNotFound
+}
+
+// recordAttachmentFeature records FeatureAttachments in vault.meta once a
+// secret has an attachment. Caller must hold v.mu.
+func (v *Vault) recordAttachmentFeature() error {
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	if meta.Features[FeatureAttachments] == FeatureOn {
+		return nil
+	}
+	if meta.Features == nil {
+		meta.Features = make(map[string]string)
+	}
+	meta.Features[FeatureAttachments] = FeatureOn
+	return v.writeMeta(meta)
+}
@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+func TestAttachments(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("tls/api", &SecretEntry{Value: []byte("passphrase")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	cert := []byte("-----BEGIN CERTIFICATE-----\nMIIB...\n-----END CERTIFICATE-----\n")
+	if err := v.AddAttachment("tls/api", "api.crt", bytes.NewReader(cert)); err != nil {
+		t.Fatalf("AddAttachment failed: %v", err)
+	}
+	if err := v.AddAttachment("tls/api", "api.key", strings.NewReader("old key")); err != nil {
+		t.Fatalf("AddAttachment failed: %v", err)
+	}
+	// Same name replaces
+	if err := v.AddAttachment("tls/api", "api.key", strings.NewReader("new key")); err != nil {
+		t.Fatalf("AddAttachment failed: %v", err)
+	}
+
+	list, err := v.ListAttachments("tls/api")
+	if err != nil {
+		t.Fatalf("ListAttachments failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "api.crt" || list[0].Size != int64(len(cert)) || list[1].Name != "api.key" {
+		t.Fatalf("ListAttachments = %+v", list)
+	}
+	data, err := v.GetAttachment("tls/api", "api.key")
+	if err != nil || string(data) != "new key" {
+		t.Fatalf("GetAttachment = %q, %v", data, err)
+	}
+	if features, _ := v.Features(); features[FeatureAttachments] != FeatureOn {
+		t.Errorf("attachments feature not recorded: %v", features)
+	}
+
+	// Errors
+	for _, name := range []string{"", "..", "a/b", `a\b`, "a\nb", strings.Repeat("x", 256)} {
+		if err := v.AddAttachment("tls/api", name, strings.NewReader("x")); !errors.Is(err, ErrInvalidAttachmentName) {
+			t.Errorf("AddAttachment(%q) = %v, want ErrInvalidAttachmentName", name, err)
+		}
+	}
+	if err := v.AddAttachment("tls/api", "big.bin", bytes.NewReader(make([]byte, MaxAttachmentSize+1))); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("expected ErrAttachmentTooLarge, got %v", err)
+	}
+	if err := v.AddAttachment("missing", "a.txt", strings.NewReader("x")); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := v.GetAttachment("tls/api", "other.txt"); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("expected ErrAttachmentNotFound, got %v", err)
+	}
+
+	// Audited with the attachment name, never the contents
+	events, _ := v.Audit().ListEvents(0, time.Time{})
+	var gets int
+	for _, e := range events {
+		if e.Operation == audit.OpSecretAttachmentGet && e.Context["name"] == "api.key" {
+			gets++
+		}
+	}
+	if gets != 1 {
+		t.Errorf("attachment reads audited %d times, want 1", gets)
+	}
+
+	// Attachments survive a key rotation and follow the secret into the
+	// trash and back
+	if err := v.RotateDEK(password, nil); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+	if data, err := v.GetAttachment("tls/api", "api.crt"); err != nil || !bytes.Equal(data, cert) {
+		t.Fatalf("GetAttachment after RotateDEK = %q, %v", data, err)
+	}
+	if err := v.Reencrypt(nil); err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+	if err := v.DeleteSecret("tls/api"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if _, err := v.ListAttachments("tls/api"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("attachments of a deleted secret listed: %v", err)
+	}
+	if err := v.RestoreSecret("tls/api"); err != nil {
+		t.Fatalf("RestoreSecret failed: %v", err)
+	}
+	if data, err := v.GetAttachment("tls/api", "api.crt"); err != nil || !bytes.Equal(data, cert) {
+		t.Fatalf("GetAttachment after restore = %q, %v", data, err)
+	}
+
+	if err := v.RemoveAttachment("tls/api", "api.crt"); err != nil {
+		t.Fatalf("RemoveAttachment failed: %v", err)
+	}
+	if err := v.RemoveAttachment("tls/api", "api.crt"); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("expected ErrAttachmentNotFound, got %v", err)
+	}
+
+	// Purging the secret removes its attachments
+	if err := v.DeleteSecret("tls/api"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if _, err := v.PurgeDeleted(0); err != nil {
+		t.Fatalf("PurgeDeleted failed: %v", err)
+	}
+	var n int
+	if err := v.db.QueryRow("SELECT COUNT(*) FROM attachments").Scan(&n); err != nil || n != 0 {
+		t.Errorf("%d attachments left after purge (%v)", n, err)
+	}
+}
+
+func TestAttachmentsImmutable(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("ca/root", &SecretEntry{Value: []byte("v1"), Immutable: true}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.AddAttachment("ca/root", "root.crt", strings.NewReader("cert")); !errors.Is(err, ErrSecretImmutable) {
+		t.Errorf("expected ErrSecretImmutable, got %v", err)
+	}
+}
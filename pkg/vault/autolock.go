@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// autoLock locks the vault after a period without activity.
+type autoLock struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	timer   *time.Timer
+	// last is the time of the last activity in Unix nanoseconds
+	last atomic.Int64
+}
+
+// SetAutoLock locks the vault once it has gone unused for d, as Lock does,
+// publishing EventLocked. Every use of the data key, such as reading,
+// writing or looking up a secret, counts as activity, as does Touch. The
+// timer keeps running across Lock and Unlock; zero or negative d turns it
+// off.
+func (v *Vault) SetAutoLock(d time.Duration) {
+	a := &v.autoLock
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.timeout = d
+	if d <= 0 {
+		return
+	}
+	v.Touch()
+	a.timer = time.AfterFunc(d, v.checkAutoLock)
+}
+
+// AutoLock returns the auto-lock timeout set by SetAutoLock, zero if off.
+func (v *Vault) AutoLock() time.Duration {
+	v.autoLock.mu.Lock()
+	defer v.autoLock.mu.Unlock()
+	return v.autoLock.timeout
+}
+
+// Touch records activity, postponing the auto-lock. Callers use it for
+// activity the vault does not see, such as a user working in a UI.
+func (v *Vault) Touch() {
+	v.autoLock.last.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long the vault has gone without activity.
+func (v *Vault) idleFor() time.Duration {
+	return time.Since(time.Unix(0, v.autoLock.last.Load()))
+}
+
+// checkAutoLock runs when the auto-lock timer fires: it locks the vault if
+// it has been idle for the timeout, then rearms the timer for the rest.
+func (v *Vault) checkAutoLock() {
+	a := &v.autoLock
+	a.mu.Lock()
+	timeout, timer := a.timeout, a.timer
+	a.mu.Unlock()
+	if timer == nil {
+		return
+	}
+
+	next := timeout - v.idleFor()
+	if next <= 0 {
+		v.mu.Lock()
+		// Operations that held the lock meanwhile count as activity
+		if v.dek != nil && v.idleFor() >= timeout {
+			v.lock(fmt.Sprintf("auto-locked after %s of inactivity", timeout))
+		}
+		v.mu.Unlock()
+		next = timeout
+	}
+
+	a.mu.Lock()
+	if a.timer == timer {
+		timer.Reset(next)
+	}
+	a.mu.Unlock()
+}
@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutoLock(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if err := v.SetSecret("API_KEY", &SecretEntry{Value: []byte("v1")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+	const timeout = 200 * time.Millisecond
+	v.SetAutoLock(timeout)
+	defer v.SetAutoLock(0)
+
+	// Reads keep the vault unlocked past the timeout
+	for range 6 {
+		time.Sleep(timeout / 4)
+		if _, err := v.GetSecret("API_KEY"); err != nil {
+			t.Fatalf("GetSecret while active failed: %v", err)
+		}
+	}
+
+	select {
+	case e := <-events:
+		if e.Type != EventLocked || !strings.Contains(e.Message, "inactivity") {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("vault was not auto-locked")
+	}
+	if !v.IsLocked() {
+		t.Fatal("expected the vault to be locked")
+	}
+
+	// The timer keeps running across unlocking again
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !v.IsLocked() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !v.IsLocked() {
+		t.Fatal("vault was not auto-locked after unlocking again")
+	}
+
+	// Turning the timer off keeps the vault unlocked
+	v.SetAutoLock(0)
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	time.Sleep(2 * timeout)
+	if v.IsLocked() {
+		t.Error("vault locked with auto-lock off")
+	}
+}
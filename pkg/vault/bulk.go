@@ -0,0 +1,276 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Bulk update errors
+var (
+	ErrEmptyBulkFilter = errors.New("vault: bulk filter must have at least one criterion")
+	ErrEmptyBulkPatch  = errors.New("vault: bulk patch has no changes")
+)
+
+// BulkFilter selects secrets for BulkUpdate. All set criteria must match.
+type BulkFilter struct {
+	// Tag matches secrets that have this tag.
+	Tag string
+	// KeyPattern matches key names using path.Match syntax (e.g. "db/*").
+	KeyPattern string
+}
+
+// BulkPatch describes metadata changes applied by BulkUpdate.
+// Secret values are never touched.
+type BulkPatch struct {
+	AddTags    []string
+	RemoveTags []string
+	// RenameTags replaces tags in place, keeping their position.
+	RenameTags map[string]string
+	// ExpiresAt sets the expiration date.
+	ExpiresAt *time.Time
+	// ClearExpiration removes the expiration date. Ignored if ExpiresAt is set.
+	ClearExpiration bool
+}
+
+// BulkChange describes the change to one secret.
+type BulkChange struct {
+	Key          string
+	OldTags      []string
+	NewTags      []string
+	OldExpiresAt *time.Time
+	NewExpiresAt *time.Time
+}
+
+// BulkResult is the result of BulkUpdate or BulkUpdatePreview.
+type BulkResult struct {
+	// Matched is the number of secrets selected by the filter.
+	Matched int
+	// Changes lists the secrets whose metadata changes (unchanged matches are omitted).
+	Changes []BulkChange
+}
+
+// BulkUpdate applies patch to every secret matching filter in a single
+// transaction. Either all matching secrets are updated or none are.
+func (v *Vault) BulkUpdate(filter BulkFilter, patch BulkPatch) (*BulkResult, error) {
+	return runValue(v, Op{Name: OpUpdate}, func() (*BulkResult, error) {
+		return v.bulkUpdate(filter, patch, true)
+	})
+}
+
+// BulkUpdatePreview returns the changes BulkUpdate would make without applying them.
+func (v *Vault) BulkUpdatePreview(filter BulkFilter, patch BulkPatch) (*BulkResult, error) {
+	return runValue(v, Op{Name: OpList}, func() (*BulkResult, error) {
+		return v.bulkUpdate(filter, patch, false)
+	})
+}
+
+func (v *Vault) bulkUpdate(filter BulkFilter, patch BulkPatch, apply bool) (*BulkResult, error) {
+	if filter.Tag == "" && filter.KeyPattern == "" {
+		return nil, ErrEmptyBulkFilter
+	}
+	if filter.KeyPattern != "" {
+		if _, err := path.Match(filter.KeyPattern, ""); err != nil {
+			return nil, fmt.Errorf("vault: invalid key pattern %q: %w", filter.KeyPattern, err)
+		}
+	}
+	if len(patch.AddTags) == 0 && len(patch.RemoveTags) == 0 && len(patch.RenameTags) == 0 &&
+		patch.ExpiresAt == nil && !patch.ClearExpiration {
+		return nil, ErrEmptyBulkPatch
+	}
+	if err := validateMetadata(nil, patch.AddTags, patch.ExpiresAt); err != nil {
+		return nil, err
+	}
+	for _, tag := range patch.RenameTags {
+		if err := validateMetadata(nil, []string{tag}, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if apply {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+	} else {
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+	}
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	if apply {
+		if err := v.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT key_hash, encrypted_key, tags, expires_at, immutable FROM secrets WHERE deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+
+	type pending struct {
+		keyHash string
+		change  BulkChange
+	}
+	var updates []pending
+	result := &BulkResult{}
+	for rows.Next() {
+		var keyHash string
+		var encryptedKey []byte
+		var tagsStr sql.NullString
+		var expiresAt sql.NullTime
+		var immutable bool
+		if err := rows.Scan(&keyHash, &encryptedKey, &tagsStr, &expiresAt, &immutable); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		keyBytes, err := v.decryptWithNonce(encryptedKey)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("vault: failed to decrypt key: %w", err)
+		}
+		key := string(keyBytes)
+
+		var tags []string
+		if tagsStr.Valid && tagsStr.String != "" {
+			_ = json.Unmarshal([]byte(tagsStr.String), &tags)
+		}
+
+		if filter.Tag != "" && !slices.Contains(tags, filter.Tag) {
+			continue
+		}
+		if filter.KeyPattern != "" {
+			if ok, _ := path.Match(filter.KeyPattern, key); !ok {
+				continue
+			}
+		}
+		result.Matched++
+
+		change := BulkChange{Key: key, OldTags: tags, NewTags: patchTags(tags, patch)}
+		if expiresAt.Valid {
+			t := expiresAt.Time
+			change.OldExpiresAt = &t
+			change.NewExpiresAt = &t
+		}
+		if patch.ExpiresAt != nil {
+			change.NewExpiresAt = patch.ExpiresAt
+		} else if patch.ClearExpiration {
+			change.NewExpiresAt = nil
+		}
+
+		if slices.Equal(change.OldTags, change.NewTags) && timePtrEqual(change.OldExpiresAt, change.NewExpiresAt) {
+			continue
+		}
+		if immutable {
+			rows.Close()
+			return nil, fmt.Errorf("secret '%s': %w", key, ErrSecretImmutable)
+		}
+		if w := v.ActiveFreeze(key); w != nil {
+			rows.Close()
+			return nil, fmt.Errorf("secret '%s': %w by %s", key, ErrSecretFrozen, w.Pattern)
+		}
+		if err := validateMetadata(nil, change.NewTags, nil); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("secret '%s': %w", key, err)
+		}
+		if change.NewExpiresAt == nil && change.OldExpiresAt != nil {
+			if rule := v.ExpiryRuleFor(key); rule != nil && rule.Expires == ExpiryRequired {
+				rows.Close()
+				return nil, fmt.Errorf("secret '%s': %w by %s", key, ErrExpirationRequired, rule.Pattern)
+			}
+		}
+		updates = append(updates, pending{keyHash: keyHash, change: change})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	rows.Close()
+
+	sort.Slice(updates, func(i, j int) bool { return updates[i].change.Key < updates[j].change.Key })
+	for _, u := range updates {
+		result.Changes = append(result.Changes, u.change)
+	}
+	if !apply || len(updates) == 0 {
+		return result, nil
+	}
+
+	for _, u := range updates {
+		var tagsStr sql.NullString
+		if len(u.change.NewTags) > 0 {
+			tagsJSON, err := json.Marshal(u.change.NewTags)
+			if err != nil {
+				return nil, fmt.Errorf("vault: failed to marshal tags: %w", err)
+			}
+			tagsStr = sql.NullString{String: string(tagsJSON), Valid: true}
+		}
+		var expiresAt sql.NullTime
+		if u.change.NewExpiresAt != nil {
+			expiresAt = sql.NullTime{Time: *u.change.NewExpiresAt, Valid: true}
+		}
+
+		_, err := v.execSecrets(tx, "UPDATE %s SET tags = ?, expires_at = ?, updated_at = CURRENT_TIMESTAMP WHERE key_hash = ?",
+			tagsStr, expiresAt, u.keyHash)
+		if err != nil {
+			_ = v.audit.LogError(audit.OpSecretUpdate, v.auditSource(), u.change.Key, "DB_ERROR", err.Error())
+			return nil, fmt.Errorf("vault: failed to update secret '%s': %w", u.change.Key, err)
+		}
+		if err := v.recordHistoryTx(tx, u.keyHash, ChangeSummary{Similarity: SimilarityIdentical}); err != nil {
+			return nil, fmt.Errorf("vault: failed to record history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	for _, u := range updates {
+		_ = v.audit.LogSuccess(audit.OpSecretUpdate, v.auditSource(), u.change.Key)
+	}
+	return result, nil
+}
+
+// patchTags returns tags with patch.RenameTags applied, patch.RemoveTags
+// removed and patch.AddTags appended. A rename onto a tag the secret
+// already has leaves one copy.
+func patchTags(tags []string, patch BulkPatch) []string {
+	out := make([]string, 0, len(tags)+len(patch.AddTags))
+	for _, t := range tags {
+		if renamed, ok := patch.RenameTags[t]; ok {
+			t = renamed
+		}
+		if !slices.Contains(patch.RemoveTags, t) && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	for _, t := range patch.AddTags {
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func timePtrEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
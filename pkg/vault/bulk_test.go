@@ -0,0 +1,128 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBulkUpdate(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	secrets := map[string][]string{
+		"db/old":     {"legacy"},
+		"db/new":     nil,
+		"api/old":    {"legacy", "api"},
+		"api/legacy": {"legacy", "deprecated"},
+	}
+	for key, tags := range secrets {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("v"), Tags: tags}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+
+	expires := time.Now().Add(90 * 24 * time.Hour)
+	filter := BulkFilter{Tag: "legacy"}
+	patch := BulkPatch{AddTags: []string{"deprecated"}, ExpiresAt: &expires}
+
+	// Preview does not modify anything
+	preview, err := v.BulkUpdatePreview(filter, patch)
+	if err != nil {
+		t.Fatalf("BulkUpdatePreview failed: %v", err)
+	}
+	if preview.Matched != 3 || len(preview.Changes) != 3 {
+		t.Fatalf("expected 3 matched/changed, got %d/%d", preview.Matched, len(preview.Changes))
+	}
+	entry, _ := v.GetSecret("db/old")
+	if entry.ExpiresAt != nil || len(entry.Tags) != 1 {
+		t.Error("preview must not modify secrets")
+	}
+
+	result, err := v.BulkUpdate(filter, patch)
+	if err != nil {
+		t.Fatalf("BulkUpdate failed: %v", err)
+	}
+	if len(result.Changes) != 3 || result.Changes[0].Key != "api/legacy" {
+		t.Errorf("expected sorted changes, got %+v", result.Changes)
+	}
+
+	entry, _ = v.GetSecret("api/old")
+	if !reflect.DeepEqual(entry.Tags, []string{"legacy", "api", "deprecated"}) {
+		t.Errorf("unexpected tags %v", entry.Tags)
+	}
+	if entry.ExpiresAt == nil {
+		t.Error("expected expiration to be set")
+	}
+	entry, _ = v.GetSecret("db/new")
+	if entry.ExpiresAt != nil || len(entry.Tags) != 0 {
+		t.Error("non-matching secret was modified")
+	}
+
+	// Key pattern plus removal; clearing
+	result, err = v.BulkUpdate(BulkFilter{KeyPattern: "api/*"}, BulkPatch{RemoveTags: []string{"legacy"}, ClearExpiration: true})
+	if err != nil {
+		t.Fatalf("BulkUpdate failed: %v", err)
+	}
+	if result.Matched != 2 {
+		t.Errorf("expected 2 matched, got %d", result.Matched)
+	}
+	entry, _ = v.GetSecret("api/legacy")
+	if !reflect.DeepEqual(entry.Tags, []string{"deprecated"}) || entry.ExpiresAt != nil {
+		t.Errorf("unexpected entry after removal: tags=%v expires=%v", entry.Tags, entry.ExpiresAt)
+	}
+}
+
+func TestBulkUpdateValidation(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	patch := BulkPatch{AddTags: []string{"x"}}
+	if _, err := v.BulkUpdate(BulkFilter{}, patch); !errors.Is(err, ErrEmptyBulkFilter) {
+		t.Errorf("expected ErrEmptyBulkFilter, got %v", err)
+	}
+	if _, err := v.BulkUpdate(BulkFilter{Tag: "a"}, BulkPatch{}); !errors.Is(err, ErrEmptyBulkPatch) {
+		t.Errorf("expected ErrEmptyBulkPatch, got %v", err)
+	}
+	if _, err := v.BulkUpdate(BulkFilter{Tag: "a"}, BulkPatch{AddTags: []string{"bad tag"}}); !errors.Is(err, ErrTagInvalid) {
+		t.Errorf("expected ErrTagInvalid, got %v", err)
+	}
+	if _, err := v.BulkUpdate(BulkFilter{Tag: "a"}, patch); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	// Exceeding the tag limit on one secret rolls back the whole batch
+	full := make([]string, MaxTagCount)
+	for i := range full {
+		full[i] = "t" + string(rune('a'+i))
+	}
+	if err := v.SetSecret("full", &SecretEntry{Value: []byte("v"), Tags: append([]string{"grp"}, full[1:]...)}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("small", &SecretEntry{Value: []byte("v"), Tags: []string{"grp"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.BulkUpdate(BulkFilter{Tag: "grp"}, BulkPatch{AddTags: []string{"extra"}}); !errors.Is(err, ErrTooManyTags) {
+		t.Fatalf("expected ErrTooManyTags, got %v", err)
+	}
+	entry, _ := v.GetSecret("small")
+	if len(entry.Tags) != 1 {
+		t.Errorf("expected no partial update, got tags %v", entry.Tags)
+	}
+}
@@ -0,0 +1,120 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/notify"
+)
+
+// canaryTableSQL creates the table marking canary secrets. Only key hashes
+// are stored, so the database does not reveal which keys are decoys.
+const canaryTableSQL = `
+	CREATE TABLE IF NOT EXISTS canaries (
+		key_hash TEXT PRIMARY KEY,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// CreateCanary stores entry like SetSecret and marks it as a canary: a
+// decoy that nothing legitimate reads. Every read through GetSecret or
+// GetSecretAllowExpired (get, run, MCP tools, the agent) then raises an
+// alert: a secret.canary_triggered audit event, EventCanaryTriggered and,
+// if the alert_webhook setting is set, a webhook request. Metadata views
+// (InspectSecret, list) do not trigger it.
+//
+// Canaries are listed like any other secret; deleting the secret removes
+// the marker.
+func (v *Vault) CreateCanary(key string, entry *SecretEntry) error {
+	return v.setSecret(key, entry, writeOptions{canary: true})
+}
+
+// ListCanaries returns the keys of all canary secrets, sorted.
+func (v *Vault) ListCanaries() ([]string, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]string, error) {
+		return v.listCanaries()
+	})
+}
+
+func (v *Vault) listCanaries() ([]string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	rows, err := v.db.Query("SELECT s.encrypted_key FROM canaries c JOIN secrets s ON s.key_hash = c.key_hash WHERE s.deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query canaries: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var encryptedKey []byte
+		if err := rows.Scan(&encryptedKey); err != nil {
+			return nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		keyBytes, err := v.decryptWithNonce(encryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to decrypt key name: %w", err)
+		}
+		keys = append(keys, string(keyBytes))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// isCanary reports whether key is marked as a canary.
+func (v *Vault) isCanary(key string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return false
+	}
+	var one int
+	err := v.db.QueryRow("SELECT 1 FROM canaries WHERE key_hash = ?", v.hashKey(key)).Scan(&one)
+	return err == nil
+}
+
+// canaryTriggered raises the alerts for a read of canary key. The webhook
+// is sent synchronously so that short-lived processes such as `secretctl
+// get` deliver it before exiting.
+func (v *Vault) canaryTriggered(key string) {
+	msg := fmt.Sprintf("canary secret '%s' was read", key)
+	_ = v.audit.LogError(audit.OpSecretCanary, v.auditSource(), key, "CANARY_TRIGGERED", msg)
+	if !v.emit(EventCanaryTriggered, key, msg) {
+		slog.Warn(msg, "event", EventCanaryTriggered)
+	}
+
+	settings, err := v.Settings()
+	if err != nil || settings.AlertWebhook == "" {
+		return
+	}
+	alert := notify.Alert{
+		Type:    string(EventCanaryTriggered),
+		Time:    time.Now().UTC(),
+		Vault:   v.path,
+		Key:     key,
+		Message: msg,
+	}
+	if !settings.OmitAuditHost {
+		alert.Host, _ = os.Hostname()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notify.DefaultTimeout)
+	defer cancel()
+	webhook := &notify.Webhook{URL: settings.AlertWebhook}
+	if err := webhook.Send(ctx, alert); err != nil {
+		slog.Error("failed to deliver canary alert", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/notify"
+)
+
+func TestCanary(t *testing.T) {
+	alerts := make(chan notify.Alert, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a notify.Alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		alerts <- a
+	}))
+	defer srv.Close()
+
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.AlertWebhook = srv.URL
+		s.OmitAuditHost = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	if err := v.SetSecret("real/key", &SecretEntry{Value: []byte("real")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.CreateCanary("aws/prod-admin", &SecretEntry{Value: []byte("AKIADECOY")}); err != nil {
+		t.Fatalf("CreateCanary failed: %v", err)
+	}
+
+	keys, err := v.ListCanaries()
+	if err != nil {
+		t.Fatalf("ListCanaries failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "aws/prod-admin" {
+		t.Errorf("ListCanaries = %v, want [aws/prod-admin]", keys)
+	}
+
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+
+	// Ordinary reads and metadata views of the canary raise nothing
+	if _, err := v.GetSecret("real/key"); err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if _, err := v.InspectSecret("aws/prod-admin"); err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %+v", e)
+	case a := <-alerts:
+		t.Fatalf("unexpected alert %+v", a)
+	default:
+	}
+
+	entry, err := v.GetSecret("aws/prod-admin")
+	if err != nil {
+		t.Fatalf("GetSecret of canary failed: %v", err)
+	}
+	if string(entry.Value) != "AKIADECOY" {
+		t.Errorf("canary value = %q, want the decoy", entry.Value)
+	}
+	if e := nextEvent(t, events); e.Type != EventCanaryTriggered || e.Key != "aws/prod-admin" {
+		t.Errorf("expected canary_triggered for aws/prod-admin, got %+v", e)
+	}
+	select {
+	case a := <-alerts:
+		if a.Type != string(EventCanaryTriggered) || a.Key != "aws/prod-admin" || a.Host != "" {
+			t.Errorf("unexpected alert %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no webhook alert received")
+	}
+
+	// Deleting the canary removes the marker
+	if err := v.DeleteSecret("aws/prod-admin"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if keys, err := v.ListCanaries(); err != nil || len(keys) != 0 {
+		t.Errorf("ListCanaries after delete = %v, %v; want none", keys, err)
+	}
+	if err := v.SetSecret("aws/prod-admin", &SecretEntry{Value: []byte("real now")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if v.isCanary("aws/prod-admin") {
+		t.Error("re-created secret is still marked as a canary")
+	}
+}
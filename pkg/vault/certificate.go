@@ -0,0 +1,166 @@
+package vault
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KindCertificate marks a field holding a PEM certificate (or chain, leaf
+// first). Fields of other kinds are also recognized when their value
+// starts with a PEM certificate block.
+const KindCertificate = "certificate"
+
+const pemCertificateHeader = "-----BEGIN CERTIFICATE-----"
+
+// ErrInvalidCertificate is returned when a certificate-kind field does not
+// contain a parsable PEM certificate.
+var ErrInvalidCertificate = errors.New("vault: invalid certificate")
+
+// CertificateInfo describes the certificate stored in a secret. It is
+// extracted on write: not_after is stored in plaintext (like expires_at) so
+// expiring certificates can be queried; the rest is encrypted.
+type CertificateInfo struct {
+	Field     string    `json:"field"` // field holding the certificate
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans,omitempty"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// Expiry returns the earlier of the secret's expiration and its
+// certificate's notAfter, or nil if it has neither.
+func (e *SecretEntry) Expiry() *time.Time {
+	expiry := e.ExpiresAt
+	if e.Certificate != nil && (expiry == nil || e.Certificate.NotAfter.Before(*expiry)) {
+		notAfter := e.Certificate.NotAfter
+		expiry = &notAfter
+	}
+	return expiry
+}
+
+// extractCertificate finds and parses the certificate in fields. It returns
+// nil if there is none. Fields are checked in name order, certificate-kind
+// fields first.
+func extractCertificate(fields map[string]Field) (*CertificateInfo, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ki, kj := fields[names[i]].Kind == KindCertificate, fields[names[j]].Kind == KindCertificate
+		if ki != kj {
+			return ki
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		field := fields[name]
+		explicit := field.Kind == KindCertificate
+		if !explicit && !strings.HasPrefix(strings.TrimSpace(field.Value), pemCertificateHeader) {
+			continue
+		}
+		info, err := ParseCertificate(field.Value)
+		if err != nil {
+			if explicit {
+				return nil, fmt.Errorf("field %q: %w", name, err)
+			}
+			continue
+		}
+		info.Field = name
+		return info, nil
+	}
+	return nil, nil
+}
+
+// ParseCertificate parses the first certificate of a PEM block sequence.
+func ParseCertificate(pemData string) (*CertificateInfo, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemData)))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w: no PEM CERTIFICATE block", ErrInvalidCertificate)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	return &CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		SANs:      sans,
+		Serial:    hex.EncodeToString(cert.SerialNumber.Bytes()),
+		NotBefore: cert.NotBefore.UTC(),
+		NotAfter:  cert.NotAfter.UTC(),
+	}, nil
+}
+
+// decryptCertificate decrypts an encrypted_certificate value.
+func (v *Vault) decryptCertificate(encrypted []byte) (*CertificateInfo, error) {
+	data, err := v.decryptWithNonce(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	var info CertificateInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ListCertificates returns the secrets that hold a certificate, soonest
+// notAfter first (includes metadata, NOT values).
+func (v *Vault) ListCertificates() ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		return v.listCertificates()
+	})
+}
+
+func (v *Vault) listCertificates() ([]*SecretEntry, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	rows, err := v.db.Query(`
+		SELECT ` + secretMetadataColumns + `
+		FROM secrets
+		WHERE cert_not_after IS NOT NULL AND deleted_at IS NULL
+		ORDER BY cert_not_after`)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	var secrets []*SecretEntry
+	for rows.Next() {
+		entry, err := v.scanSecretEntryRowWithMetadata(rows)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	return secrets, nil
+}
@@ -0,0 +1,260 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Values for the deprecated_reads setting.
+const (
+	// DeprecatedReadsError makes reads of deprecated keys fail with *DeprecatedKeyError (default).
+	DeprecatedReadsError = "error"
+	// DeprecatedReadsRedirect makes reads of deprecated keys return the replacement secret.
+	DeprecatedReadsRedirect = "redirect"
+)
+
+// maxRedirectHops bounds how many deprecated keys a read will follow.
+const maxRedirectHops = 5
+
+// Deprecation errors
+var (
+	ErrSecretDeprecated = errors.New("vault: key is deprecated")
+	ErrRedirectCycle    = errors.New("vault: deprecation would create a redirect cycle")
+)
+
+// Deprecation records that a key was replaced by another key.
+type Deprecation struct {
+	ReplacedBy   string    `json:"replaced_by"`
+	DeprecatedAt time.Time `json:"deprecated_at"`
+}
+
+// DeprecatedKeyError is returned when reading a deprecated key.
+// It matches ErrSecretDeprecated with errors.Is.
+type DeprecatedKeyError struct {
+	Key        string
+	ReplacedBy string
+}
+
+func (e *DeprecatedKeyError) Error() string {
+	return fmt.Sprintf("vault: key '%s' is deprecated, use '%s' instead", e.Key, e.ReplacedBy)
+}
+
+// Unwrap returns ErrSecretDeprecated.
+func (e *DeprecatedKeyError) Unwrap() error {
+	return ErrSecretDeprecated
+}
+
+// readSecret reads a secret, following deprecation redirects when the
+// deprecated_reads setting is "redirect".
+func (v *Vault) readSecret(key string, opts readOptions) (*SecretEntry, error) {
+	settings, err := v.Settings()
+	opts.followRedirects = err == nil && settings.DeprecatedReads == DeprecatedReadsRedirect
+
+	var entry *SecretEntry
+	err = v.run(Op{Name: OpGet, Key: key}, func() error {
+		var err error
+		entry, err = v.readSecretChain(key, opts, nil)
+		return err
+	})
+	return entry, err
+}
+
+// readSecretChain reads key for readSecret and resolves its ref://
+// references; chain holds the keys whose references led to key.
+func (v *Vault) readSecretChain(key string, opts readOptions, chain []string) (*SecretEntry, error) {
+	current := key
+	for hops := 0; ; hops++ {
+		entry, err := v.getSecret(current, opts)
+		if !opts.inspect && v.isCanary(current) {
+			v.canaryTriggered(current)
+		}
+		var depErr *DeprecatedKeyError
+		if !opts.followRedirects || !errors.As(err, &depErr) {
+			if entry != nil && current != key {
+				entry.RedirectedFrom = key
+			}
+			if err == nil && !opts.inspect {
+				v.recordAccess(entry.Key)
+			}
+			if err == nil && !opts.inspect && IsDynamicRef(entry.Value) {
+				if err := v.resolveDynamic(entry); err != nil {
+					return nil, err
+				}
+			}
+			if err == nil && !opts.inspect && entry.hasRefs() {
+				if err := v.resolveRefs(entry, opts, append(slices.Clip(chain), entry.Key)); err != nil {
+					return nil, err
+				}
+			}
+			return entry, err
+		}
+		if hops >= maxRedirectHops {
+			return nil, fmt.Errorf("%w: more than %d redirects from '%s'", ErrSecretDeprecated, maxRedirectHops, key)
+		}
+		current = depErr.ReplacedBy
+	}
+}
+
+// DeprecateSecret marks key as replaced by replacedBy. Both keys must exist.
+// The deprecated secret keeps its value until deleted; reads of it fail or
+// redirect depending on the deprecated_reads setting.
+func (v *Vault) DeprecateSecret(key, replacedBy string) error {
+	return v.run(Op{Name: OpDeprecate, Key: key}, func() error {
+		return v.deprecateSecret(key, replacedBy)
+	})
+}
+
+func (v *Vault) deprecateSecret(key, replacedBy string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	if err := validateKeyName(replacedBy); err != nil {
+		return err
+	}
+	if key == replacedBy {
+		return fmt.Errorf("%w: key cannot replace itself", ErrRedirectCycle)
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := v.loadDeprecationTx(tx, key); err != nil {
+		return err
+	}
+
+	// Walk the replacement chain to reject cycles (a -> b -> a)
+	next := replacedBy
+	for hops := 0; ; hops++ {
+		dep, err := v.loadDeprecationTx(tx, next)
+		if err != nil {
+			if errors.Is(err, ErrSecretNotFound) {
+				return fmt.Errorf("replacement '%s': %w", next, err)
+			}
+			return err
+		}
+		if dep == nil {
+			break
+		}
+		if dep.ReplacedBy == key || hops >= maxRedirectHops {
+			return ErrRedirectCycle
+		}
+		next = dep.ReplacedBy
+	}
+
+	depJSON, err := json.Marshal(Deprecation{ReplacedBy: replacedBy, DeprecatedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("vault: failed to marshal deprecation: %w", err)
+	}
+	encrypted, err := v.encryptWithNonce(depJSON)
+	if err != nil {
+		return fmt.Errorf("vault: failed to encrypt deprecation: %w", err)
+	}
+
+	if err := v.setRedirectTx(tx, key, encrypted); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	_ = v.audit.LogSuccess(audit.OpSecretDeprecate, v.auditSource(), key)
+	return nil
+}
+
+// UndeprecateSecret removes the deprecation from key.
+func (v *Vault) UndeprecateSecret(key string) error {
+	return v.run(Op{Name: OpDeprecate, Key: key}, func() error {
+		return v.undeprecateSecret(key)
+	})
+}
+
+func (v *Vault) undeprecateSecret(key string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := v.setRedirectTx(tx, key, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	_ = v.audit.LogSuccess(audit.OpSecretDeprecate, v.auditSource(), key)
+	return nil
+}
+
+// setRedirectTx stores (or clears, if nil) the encrypted deprecation of key.
+// Immutable secrets cannot be (un)deprecated.
+func (v *Vault) setRedirectTx(tx *sql.Tx, key string, encrypted []byte) error {
+	keyHash := v.hashKey(key)
+	if err := v.checkMutableTx(tx, keyHash, key, audit.OpSecretDeprecate, writeOptions{}); err != nil {
+		return err
+	}
+	n, err := v.execSecrets(tx, "UPDATE %s SET encrypted_redirect = ?, updated_at = CURRENT_TIMESTAMP WHERE key_hash = ? AND deleted_at IS NULL",
+		encrypted, keyHash)
+	if err != nil {
+		return fmt.Errorf("vault: failed to update secret: %w", err)
+	}
+	if n == 0 {
+		return ErrSecretNotFound
+	}
+	return nil
+}
+
+// loadDeprecationTx returns the deprecation of key, nil if it is not
+// deprecated, or ErrSecretNotFound.
+func (v *Vault) loadDeprecationTx(tx *sql.Tx, key string) (*Deprecation, error) {
+	var encrypted []byte
+	err := tx.QueryRow("SELECT encrypted_redirect FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", v.hashKey(key)).Scan(&encrypted)
+	if err == sql.ErrNoRows {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to read secret: %w", err)
+	}
+	if len(encrypted) == 0 {
+		return nil, nil
+	}
+	return v.decryptDeprecation(encrypted)
+}
+
+// decryptDeprecation decrypts an encrypted_redirect value.
+func (v *Vault) decryptDeprecation(encrypted []byte) (*Deprecation, error) {
+	depJSON, err := v.decryptWithNonce(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	var dep Deprecation
+	if err := json.Unmarshal(depJSON, &dep); err != nil {
+		return nil, err
+	}
+	return &dep, nil
+}
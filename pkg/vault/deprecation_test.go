@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeprecateSecret(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for key, value := range map[string]string{"old/key": "old-value", "new/key": "new-value"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte(value)}); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+
+	if err := v.DeprecateSecret("old/key", "missing/key"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound for missing replacement, got %v", err)
+	}
+	if err := v.DeprecateSecret("old/key", "old/key"); !errors.Is(err, ErrRedirectCycle) {
+		t.Errorf("expected ErrRedirectCycle for self-replacement, got %v", err)
+	}
+	if err := v.DeprecateSecret("old/key", "new/key"); err != nil {
+		t.Fatalf("DeprecateSecret failed: %v", err)
+	}
+	if err := v.DeprecateSecret("new/key", "old/key"); !errors.Is(err, ErrRedirectCycle) {
+		t.Errorf("expected ErrRedirectCycle, got %v", err)
+	}
+
+	t.Run("error mode", func(t *testing.T) {
+		_, err := v.GetSecret("old/key")
+		var depErr *DeprecatedKeyError
+		if !errors.As(err, &depErr) {
+			t.Fatalf("expected DeprecatedKeyError, got %v", err)
+		}
+		if depErr.ReplacedBy != "new/key" {
+			t.Errorf("ReplacedBy = %q, want new/key", depErr.ReplacedBy)
+		}
+		if !errors.Is(err, ErrSecretDeprecated) {
+			t.Error("expected error to match ErrSecretDeprecated")
+		}
+	})
+
+	t.Run("inspect bypasses deprecation", func(t *testing.T) {
+		entry, err := v.InspectSecret("old/key")
+		if err != nil {
+			t.Fatalf("InspectSecret failed: %v", err)
+		}
+		if entry.Deprecation == nil || entry.Deprecation.ReplacedBy != "new/key" {
+			t.Errorf("expected deprecation metadata, got %+v", entry.Deprecation)
+		}
+	})
+
+	t.Run("list marks deprecated", func(t *testing.T) {
+		entries, err := v.ListSecretsWithMetadata()
+		if err != nil {
+			t.Fatalf("ListSecretsWithMetadata failed: %v", err)
+		}
+		for _, e := range entries {
+			if deprecated := e.Deprecation != nil; deprecated != (e.Key == "old/key") {
+				t.Errorf("%s: deprecated = %v", e.Key, deprecated)
+			}
+		}
+	})
+
+	t.Run("redirect mode", func(t *testing.T) {
+		err := v.UpdateSettings(func(s *VaultSettings) error {
+			s.DeprecatedReads = DeprecatedReadsRedirect
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("UpdateSettings failed: %v", err)
+		}
+		defer v.UpdateSettings(func(s *VaultSettings) error {
+			s.DeprecatedReads = ""
+			return nil
+		})
+
+		entry, err := v.GetSecret("old/key")
+		if err != nil {
+			t.Fatalf("GetSecret failed: %v", err)
+		}
+		if string(entry.Value) != "new-value" || entry.Key != "new/key" {
+			t.Errorf("expected redirect to new/key, got %s=%s", entry.Key, entry.Value)
+		}
+		if entry.RedirectedFrom != "old/key" {
+			t.Errorf("RedirectedFrom = %q, want old/key", entry.RedirectedFrom)
+		}
+	})
+
+	t.Run("survives update", func(t *testing.T) {
+		if err := v.SetSecret("old/key", &SecretEntry{Value: []byte("updated")}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+		if _, err := v.GetSecret("old/key"); !errors.Is(err, ErrSecretDeprecated) {
+			t.Errorf("expected deprecation to survive update, got %v", err)
+		}
+	})
+
+	t.Run("undeprecate", func(t *testing.T) {
+		if err := v.UndeprecateSecret("old/key"); err != nil {
+			t.Fatalf("UndeprecateSecret failed: %v", err)
+		}
+		entry, err := v.GetSecret("old/key")
+		if err != nil {
+			t.Fatalf("GetSecret failed: %v", err)
+		}
+		if entry.Deprecation != nil {
+			t.Error("expected deprecation to be cleared")
+		}
+		if err := v.UndeprecateSecret("missing/key"); !errors.Is(err, ErrSecretNotFound) {
+			t.Errorf("expected ErrSecretNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrDestroyUnsafePath is returned by Destroy when the vault path is the
+// filesystem root or the user's home directory.
+var ErrDestroyUnsafePath = errors.New("vault: refusing to destroy this directory")
+
+// Destroy overwrites every file in the vault directory with random data,
+// removes the directory and locks the vault. The vault must be unlocked,
+// so the caller has proven knowledge of the master password.
+//
+// Overwriting defeats recovery from the same file on conventional disks.
+// On SSDs and copy-on-write or journaling filesystems old blocks may
+// survive; the data stays encrypted, but only full-disk encryption makes
+// them unrecoverable. It returns the number of files wiped.
+func (v *Vault) Destroy() (int, error) {
+	if v.IsLocked() {
+		return 0, ErrVaultLocked
+	}
+	if err := checkDestroyPath(v.path); err != nil {
+		return 0, err
+	}
+	// Close the database and wipe the DEK before touching the files
+	v.Lock()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	wiped := 0
+	err := filepath.WalkDir(v.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Sockets and symlinks hold no data; never follow links out of the vault
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if err := wipeFile(path); err != nil {
+			return err
+		}
+		wiped++
+		return nil
+	})
+	if err != nil {
+		return wiped, fmt.Errorf("vault: failed to wipe vault files: %w", err)
+	}
+	if err := os.RemoveAll(v.path); err != nil {
+		return wiped, fmt.Errorf("vault: failed to remove vault directory: %w", err)
+	}
+	return wiped, nil
+}
+
+// checkDestroyPath guards against wiping a directory that obviously is not
+// a dedicated vault directory.
+func checkDestroyPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("vault: failed to resolve vault path: %w", err)
+	}
+	if abs == filepath.Dir(abs) {
+		return ErrDestroyUnsafePath
+	}
+	if home, err := os.UserHomeDir(); err == nil && filepath.Clean(home) == abs {
+		return ErrDestroyUnsafePath
+	}
+	return nil
+}
+
+// wipeFile overwrites a regular file with random data, syncs it and
+// removes it.
+func wipeFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDestroy(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "vault")
+	v := New(dir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := v.Destroy(); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("db/password", &SecretEntry{Value: []byte("hunter2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	// A symlink out of the vault must not be followed
+	outside := filepath.Join(t.TempDir(), "keep.txt")
+	if err := os.WriteFile(outside, []byte("keep me"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	wiped, err := v.Destroy()
+	if err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if wiped < 3 {
+		t.Errorf("wiped %d files, want at least salt, meta and db", wiped)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("vault directory still exists: %v", err)
+	}
+	if !v.IsLocked() {
+		t.Error("vault should be locked after Destroy")
+	}
+	if data, err := os.ReadFile(outside); err != nil || string(data) != "keep me" {
+		t.Errorf("file behind symlink was modified: %q, %v", data, err)
+	}
+}
+
+func TestCheckDestroyPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	for _, path := range []string{"/", home} {
+		if err := checkDestroyPath(path); !errors.Is(err, ErrDestroyUnsafePath) {
+			t.Errorf("checkDestroyPath(%q) = %v, want ErrDestroyUnsafePath", path, err)
+		}
+	}
+	if err := checkDestroyPath(filepath.Join(home, ".secretctl")); err != nil {
+		t.Errorf("checkDestroyPath(~/.secretctl) = %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// ValueLocation names a field of a secret.
+type ValueLocation struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+}
+
+// DuplicateGroup lists the fields that hold the same value, sorted by key
+// and field.
+type DuplicateGroup struct {
+	Locations []ValueLocation `json:"locations"`
+}
+
+// FindDuplicateValues reports the sensitive field values that are stored
+// more than once, such as a password reused across services. Values are
+// compared by their HMAC-SHA256 under a random key that only lives for
+// the call, so neither plaintext nor digests are kept or returned.
+// ref:// and dynamic:// values point at other secrets and are skipped.
+//
+// Groups are sorted by size, largest first, then by their first location.
+func (v *Vault) FindDuplicateValues() ([]DuplicateGroup, error) {
+	return runValue(v, Op{Name: OpDuplicates}, func() ([]DuplicateGroup, error) {
+		return v.findDuplicateValues()
+	})
+}
+
+func (v *Vault) findDuplicateValues() ([]DuplicateGroup, error) {
+	keys, err := v.listSecrets()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	macKey := make([]byte, 32)
+	if _, err := rand.Read(macKey); err != nil {
+		return nil, fmt.Errorf("vault: failed to generate comparison key: %w", err)
+	}
+	defer crypto.SecureWipe(macKey)
+
+	byDigest := make(map[[sha256.Size]byte][]ValueLocation)
+	add := func(key, field string, value []byte) {
+		if len(value) == 0 || IsSecretRef(string(value)) || IsDynamicRef(value) {
+			return
+		}
+		mac := hmac.New(sha256.New, macKey)
+		mac.Write(value)
+		var digest [sha256.Size]byte
+		copy(digest[:], mac.Sum(nil))
+		byDigest[digest] = append(byDigest[digest], ValueLocation{Key: key, Field: field})
+	}
+	for _, key := range keys {
+		entry, err := v.getSecret(key, readOptions{inspect: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret '%s': %w", key, err)
+		}
+		if len(entry.Fields) == 0 {
+			add(key, DefaultFieldName, entry.Value)
+		}
+		for name, field := range entry.Fields {
+			if field.Sensitive {
+				add(key, name, []byte(field.Value))
+			}
+		}
+		crypto.SecureWipe(entry.Value)
+	}
+
+	var groups []DuplicateGroup
+	for _, locations := range byDigest {
+		if len(locations) < 2 {
+			continue
+		}
+		slices.SortFunc(locations, func(a, b ValueLocation) int {
+			return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Field, b.Field))
+		})
+		groups = append(groups, DuplicateGroup{Locations: locations})
+	}
+	slices.SortFunc(groups, func(a, b DuplicateGroup) int {
+		if n := cmp.Compare(len(b.Locations), len(a.Locations)); n != 0 {
+			return n
+		}
+		return cmp.Or(cmp.Compare(a.Locations[0].Key, b.Locations[0].Key), cmp.Compare(a.Locations[0].Field, b.Locations[0].Field))
+	})
+
+	_ = v.audit.Log(audit.OpSecretDuplicates, v.auditSource(), audit.ResultSuccess, "", nil, map[string]interface{}{
+		"groups": len(groups),
+	})
+	return groups, nil
+}
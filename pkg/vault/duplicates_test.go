@@ -0,0 +1,56 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateValues(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	secrets := map[string]*SecretEntry{
+		"github":  {Value: []byte("hunter2-reused")},
+		"gitlab":  {Fields: map[string]Field{"password": {Value: "hunter2-reused", Sensitive: true}, "username": {Value: "alice"}}},
+		"jira":    {Fields: map[string]Field{"password": {Value: "hunter2-reused", Sensitive: true}, "username": {Value: "alice"}}},
+		"db/prod": {Fields: map[string]Field{"password": {Value: "pw-prod", Sensitive: true}, "replica": {Value: "pw-prod", Sensitive: true}}},
+		"unique":  {Value: []byte("only-once")},
+		"alias":   {Fields: map[string]Field{"password": {Value: "ref://github", Sensitive: true}}},
+		"alias2":  {Fields: map[string]Field{"password": {Value: "ref://github", Sensitive: true}}},
+	}
+	for key, entry := range secrets {
+		if err := v.SetSecret(key, entry); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+
+	groups, err := v.FindDuplicateValues()
+	if err != nil {
+		t.Fatalf("FindDuplicateValues failed: %v", err)
+	}
+	// The shared non-sensitive username and the references are not reported
+	want := []DuplicateGroup{
+		{Locations: []ValueLocation{{"github", "value"}, {"gitlab", "password"}, {"jira", "password"}}},
+		{Locations: []ValueLocation{{"db/prod", "password"}, {"db/prod", "replica"}}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("groups = %+v, want %+v", groups, want)
+	}
+
+	// Inspecting values for duplicates is not an access
+	stale, err := v.ListStaleSecrets(0)
+	if err != nil {
+		t.Fatalf("ListStaleSecrets failed: %v", err)
+	}
+	for _, e := range stale {
+		if e.AccessCount != 0 {
+			t.Errorf("%s: access count %d after duplicate scan", e.Key, e.AccessCount)
+		}
+	}
+}
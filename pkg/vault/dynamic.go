@@ -0,0 +1,410 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// Dynamic secrets
+//
+// A secret whose value is a dynamic:// URI is resolved on every read by an
+// external plugin executable, e.g. "dynamic://bastion/db/prod?role=readonly"
+// runs the "bastion" plugin with path "db/prod" and params {role: readonly}.
+// Plugins live in <vault>/plugins/secretctl-plugin-<name> and must be
+// enabled with the dynamic_plugins setting before they are run.
+//
+// Protocol: the plugin receives one PluginRequest as JSON on stdin and
+// writes one PluginResponse as JSON to stdout, then exits. Results are cached
+// in memory for the TTL the plugin returns until the vault is locked.
+const (
+	DynamicScheme         = "dynamic://"
+	PluginDirName         = "plugins"
+	PluginPrefix          = "secretctl-plugin-"
+	PluginProtocolVersion = 1
+)
+
+const (
+	defaultDynamicTTL = 5 * time.Minute
+	maxDynamicTTL     = time.Hour
+	pluginTimeout     = 30 * time.Second
+	maxPluginOutput   = 1 << 20 // 1MB
+)
+
+// Dynamic secret errors
+var (
+	ErrInvalidDynamicRef = errors.New("vault: invalid dynamic:// reference")
+	ErrPluginNotAllowed  = errors.New("vault: plugin is not enabled (see the dynamic_plugins setting)")
+	ErrPluginNotFound    = errors.New("vault: plugin not found")
+	ErrPluginFailed      = errors.New("vault: plugin failed")
+)
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// DynamicRef is a parsed dynamic:// URI.
+type DynamicRef struct {
+	Plugin string
+	Path   string
+	Params map[string]string
+}
+
+// IsDynamicRef reports whether value is a dynamic:// URI.
+func IsDynamicRef(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(DynamicScheme))
+}
+
+// ParseDynamicRef parses a dynamic://<plugin>/<path>?<params> URI.
+func ParseDynamicRef(s string) (*DynamicRef, error) {
+	if !strings.HasPrefix(s, DynamicScheme) {
+		return nil, fmt.Errorf("%w: missing %s prefix", ErrInvalidDynamicRef, DynamicScheme)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDynamicRef, err)
+	}
+	if !pluginNamePattern.MatchString(u.Host) {
+		return nil, fmt.Errorf("%w: invalid plugin name %q", ErrInvalidDynamicRef, u.Host)
+	}
+	ref := &DynamicRef{
+		Plugin: u.Host,
+		Path:   strings.TrimPrefix(u.Path, "/"),
+		Params: make(map[string]string),
+	}
+	for name, values := range u.Query() {
+		ref.Params[name] = values[len(values)-1]
+	}
+	return ref, nil
+}
+
+// ValidatePluginName checks a plugin name as used in the dynamic_plugins setting.
+func ValidatePluginName(name string) error {
+	if !pluginNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid plugin name %q (use lowercase letters, digits, '-' and '_')", name)
+	}
+	return nil
+}
+
+// PluginRequest is sent to a plugin on stdin.
+type PluginRequest struct {
+	Version   int               `json:"version"`
+	Operation string            `json:"operation"` // always "get" for now
+	Key       string            `json:"key"`       // vault key holding the reference
+	Path      string            `json:"path"`
+	Params    map[string]string `json:"params,omitempty"`
+}
+
+// PluginResponse is read from a plugin's stdout. Either Error or Value must
+// be set. Fields are added to the secret as sensitive fields.
+type PluginResponse struct {
+	Value      string            `json:"value"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	TTLSeconds int               `json:"ttl_seconds,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// PluginInfo describes an installed plugin.
+type PluginInfo struct {
+	Name    string
+	Path    string
+	Enabled bool
+}
+
+// PluginDir returns the directory plugins are loaded from.
+func (v *Vault) PluginDir() string {
+	return filepath.Join(v.path, PluginDirName)
+}
+
+// ListPlugins returns the plugins installed in PluginDir, sorted by name.
+func (v *Vault) ListPlugins() ([]PluginInfo, error) {
+	entries, err := os.ReadDir(v.PluginDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("vault: failed to read plugin directory: %w", err)
+	}
+	settings, err := v.Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []PluginInfo
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), PluginPrefix)
+		if runtime.GOOS == "windows" {
+			name = strings.TrimSuffix(name, ".exe")
+		}
+		if !ok || e.IsDir() || !pluginNamePattern.MatchString(name) {
+			continue
+		}
+		plugins = append(plugins, PluginInfo{
+			Name:    name,
+			Path:    filepath.Join(v.PluginDir(), e.Name()),
+			Enabled: slices.Contains(settings.DynamicPlugins, name),
+		})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// dynamicCacheEntry is a cached plugin result.
+type dynamicCacheEntry struct {
+	value   []byte
+	fields  map[string]string
+	expires time.Time
+}
+
+// dynamicCache holds plugin results until they expire or the vault is
+// locked. The zero value is ready to use.
+type dynamicCache struct {
+	mu      sync.Mutex
+	entries map[string]*dynamicCacheEntry
+	hits    uint64
+	misses  uint64
+}
+
+// get returns a copy of a cached result, so that Lock can wipe the cache
+// while the copy is in use.
+func (c *dynamicCache) get(id string) (*dynamicCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		crypto.SecureWipe(e.value)
+		delete(c.entries, id)
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return &dynamicCacheEntry{value: bytes.Clone(e.value), fields: e.fields, expires: e.expires}, true
+}
+
+func (c *dynamicCache) put(id string, e *dynamicCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*dynamicCacheEntry)
+	}
+	if old, ok := c.entries[id]; ok {
+		crypto.SecureWipe(old.value)
+	}
+	c.entries[id] = e
+}
+
+// clear wipes all cached values.
+func (c *dynamicCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, e := range c.entries {
+		crypto.SecureWipe(e.value)
+		delete(c.entries, id)
+	}
+}
+
+// DynamicCacheStats returns how many dynamic:// reads were served from the
+// cache and how many ran a plugin, since the vault was opened.
+func (v *Vault) DynamicCacheStats() (hits, misses uint64) {
+	v.dynamic.mu.Lock()
+	defer v.dynamic.mu.Unlock()
+	return v.dynamic.hits, v.dynamic.misses
+}
+
+// resolveDynamic replaces a dynamic:// reference in entry with the value the
+// plugin returns. It is called without v.mu held, since plugins may be slow.
+func (v *Vault) resolveDynamic(entry *SecretEntry) error {
+	uri := string(entry.Value)
+	ref, err := ParseDynamicRef(uri)
+	if err != nil {
+		return err
+	}
+	auditCtx := map[string]any{"plugin": ref.Plugin}
+
+	settings, err := v.Settings()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(settings.DynamicPlugins, ref.Plugin) {
+		_ = v.audit.Log(audit.OpSecretDynamic, v.auditSource(), audit.ResultDenied, entry.Key,
+			&audit.ErrorInfo{Code: "plugin_not_enabled", Message: ErrPluginNotAllowed.Error()}, auditCtx)
+		return fmt.Errorf("%w: %s", ErrPluginNotAllowed, ref.Plugin)
+	}
+
+	id := entry.Key + "\x00" + uri
+	cached, ok := v.dynamic.get(id)
+	if !ok {
+		resp, ttl, err := v.runPlugin(ref, entry.Key)
+		if err != nil {
+			_ = v.audit.Log(audit.OpSecretDynamic, v.auditSource(), audit.ResultError, entry.Key,
+				&audit.ErrorInfo{Code: "plugin_failed", Message: err.Error()}, auditCtx)
+			return err
+		}
+		cached = &dynamicCacheEntry{
+			value:   []byte(resp.Value),
+			fields:  resp.Fields,
+			expires: time.Now().Add(ttl),
+		}
+		v.dynamic.put(id, &dynamicCacheEntry{value: bytes.Clone(cached.value), fields: cached.fields, expires: cached.expires})
+		auditCtx["ttl_seconds"] = int(ttl.Seconds())
+		_ = v.audit.Log(audit.OpSecretDynamic, v.auditSource(), audit.ResultSuccess, entry.Key, nil, auditCtx)
+	}
+
+	entry.Value = cached.value
+	if entry.Fields == nil {
+		entry.Fields = make(map[string]Field)
+	}
+	for name, value := range cached.fields {
+		entry.Fields[name] = NewDefaultField(value)
+	}
+	if f, ok := entry.Fields[DefaultFieldName]; ok {
+		f.Value = string(cached.value)
+		entry.Fields[DefaultFieldName] = f
+	} else {
+		entry.Fields[DefaultFieldName] = NewDefaultField(string(cached.value))
+	}
+	entry.FieldCount = len(entry.Fields)
+	return nil
+}
+
+// runPlugin executes a plugin and returns its response and the cache TTL.
+func (v *Vault) runPlugin(ref *DynamicRef, key string) (*PluginResponse, time.Duration, error) {
+	path, err := v.pluginPath(ref.Plugin)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := json.Marshal(PluginRequest{
+		Version:   PluginProtocolVersion,
+		Operation: "get",
+		Key:       key,
+		Path:      ref.Path,
+		Params:    ref.Params,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = v.PluginDir()
+	cmd.Env = pluginEnv()
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &limitedWriter{w: &stdout, n: maxPluginOutput}
+	cmd.Stderr = &limitedWriter{w: &stderr, n: 4096}
+	defer func() { crypto.SecureWipe(stdout.Bytes()) }()
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, fmt.Errorf("%w: %s: timed out after %s", ErrPluginFailed, ref.Plugin, pluginTimeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, 0, fmt.Errorf("%w: %s: %s", ErrPluginFailed, ref.Plugin, msg)
+	}
+
+	var resp PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, 0, fmt.Errorf("%w: %s: invalid response: %v", ErrPluginFailed, ref.Plugin, err)
+	}
+	if resp.Error != "" {
+		return nil, 0, fmt.Errorf("%w: %s: %s", ErrPluginFailed, ref.Plugin, resp.Error)
+	}
+	if resp.Value == "" {
+		return nil, 0, fmt.Errorf("%w: %s: empty value", ErrPluginFailed, ref.Plugin)
+	}
+	for name := range resp.Fields {
+		if name == DefaultFieldName || ValidateFieldName(name) != nil {
+			return nil, 0, fmt.Errorf("%w: %s: invalid field name %q", ErrPluginFailed, ref.Plugin, name)
+		}
+	}
+
+	ttl := defaultDynamicTTL
+	if resp.TTLSeconds > 0 {
+		ttl = min(time.Duration(resp.TTLSeconds)*time.Second, maxDynamicTTL)
+	}
+	return &resp, ttl, nil
+}
+
+// pluginPath locates a plugin executable and checks that only its owner can
+// modify it.
+func (v *Vault) pluginPath(name string) (string, error) {
+	file := PluginPrefix + name
+	if runtime.GOOS == "windows" {
+		file += ".exe"
+	}
+	path := filepath.Join(v.PluginDir(), file)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s (expected %s)", ErrPluginNotFound, name, path)
+		}
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%w: %s is not a regular file", ErrPluginNotFound, path)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0022 != 0 {
+		return "", fmt.Errorf("vault: refusing to run %s: writable by group or others (chmod go-w)", path)
+	}
+	return path, nil
+}
+
+// pluginEnv returns the environment for plugins: the caller's environment
+// without secretctl's own variables, so the master password is never passed on.
+func pluginEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "SECRETCTL_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// limitedWriter discards writes beyond n bytes.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	if l.n <= 0 {
+		return total, nil
+	}
+	if len(p) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.w.Write(p)
+	l.n -= n
+	if err != nil {
+		return n, err
+	}
+	return total, nil
+}
@@ -0,0 +1,166 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// installTestPlugin writes a shell script plugin that answers with a fixed
+// response and counts its runs in <dir>/runs.
+func installTestPlugin(t *testing.T, v *Vault, name, response string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+	if err := os.MkdirAll(v.PluginDir(), DirMode); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	runs := filepath.Join(v.PluginDir(), "runs")
+	script := "#!/bin/sh\ncat > " + filepath.Join(v.PluginDir(), "request") + "\necho x >> " + runs + "\necho '" + response + "'\n"
+	if err := os.WriteFile(filepath.Join(v.PluginDir(), PluginPrefix+name), []byte(script), 0700); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return runs
+}
+
+func countRuns(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(data), "x")
+}
+
+func TestParseDynamicRef(t *testing.T) {
+	ref, err := ParseDynamicRef("dynamic://bastion/db/prod?role=readonly")
+	if err != nil {
+		t.Fatalf("ParseDynamicRef failed: %v", err)
+	}
+	if ref.Plugin != "bastion" || ref.Path != "db/prod" || ref.Params["role"] != "readonly" {
+		t.Errorf("unexpected ref %+v", ref)
+	}
+
+	for _, bad := range []string{"bastion/db", "dynamic://", "dynamic://../x/y", "dynamic://Bastion/db"} {
+		if _, err := ParseDynamicRef(bad); !errors.Is(err, ErrInvalidDynamicRef) {
+			t.Errorf("ParseDynamicRef(%q): expected ErrInvalidDynamicRef, got %v", bad, err)
+		}
+	}
+}
+
+func TestDynamicSecret(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	runs := installTestPlugin(t, v, "bastion", `{"value":"s3cr3t","fields":{"username":"ro_user"},"ttl_seconds":60}`)
+	uri := "dynamic://bastion/db/prod?role=readonly"
+	if err := v.SetSecret("db/prod", &SecretEntry{Value: []byte(uri)}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	// Not enabled yet
+	if _, err := v.GetSecret("db/prod"); !errors.Is(err, ErrPluginNotAllowed) {
+		t.Fatalf("expected ErrPluginNotAllowed, got %v", err)
+	}
+	if n := countRuns(t, runs); n != 0 {
+		t.Fatalf("disabled plugin ran %d times", n)
+	}
+
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.DynamicPlugins = []string{"bastion"}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	entry, err := v.GetSecret("db/prod")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if string(entry.Value) != "s3cr3t" || entry.Fields["username"].Value != "ro_user" {
+		t.Errorf("unexpected resolved entry: value=%q fields=%v", entry.Value, entry.Fields)
+	}
+	req, _ := os.ReadFile(filepath.Join(v.PluginDir(), "request"))
+	if !strings.Contains(string(req), `"path":"db/prod"`) || !strings.Contains(string(req), `"role":"readonly"`) {
+		t.Errorf("unexpected plugin request %s", req)
+	}
+
+	// Cached until the vault is locked
+	if _, err := v.GetSecret("db/prod"); err != nil {
+		t.Fatalf("second GetSecret failed: %v", err)
+	}
+	if n := countRuns(t, runs); n != 1 {
+		t.Errorf("plugin ran %d times, want 1", n)
+	}
+
+	// Inspect returns the reference itself
+	entry, err = v.InspectSecret("db/prod")
+	if err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	if string(entry.Value) != uri {
+		t.Errorf("InspectSecret value = %q, want %q", entry.Value, uri)
+	}
+
+	v.Lock()
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if _, err := v.GetSecret("db/prod"); err != nil {
+		t.Fatalf("GetSecret after relock failed: %v", err)
+	}
+	if n := countRuns(t, runs); n != 2 {
+		t.Errorf("plugin ran %d times after relock, want 2", n)
+	}
+}
+
+func TestDynamicSecretPluginErrors(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	installTestPlugin(t, v, "broken", `{"error":"bastion unreachable"}`)
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.DynamicPlugins = []string{"broken", "missing"}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	if err := v.SetSecret("a", &SecretEntry{Value: []byte("dynamic://broken/x")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("a"); !errors.Is(err, ErrPluginFailed) || !strings.Contains(err.Error(), "bastion unreachable") {
+		t.Errorf("expected plugin error, got %v", err)
+	}
+
+	if err := v.SetSecret("b", &SecretEntry{Value: []byte("dynamic://missing/x")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("b"); !errors.Is(err, ErrPluginNotFound) {
+		t.Errorf("expected ErrPluginNotFound, got %v", err)
+	}
+
+	// Group-writable plugins are refused
+	if err := os.Chmod(filepath.Join(v.PluginDir(), PluginPrefix+"broken"), 0770); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	if _, err := v.GetSecret("a"); err == nil || !strings.Contains(err.Error(), "writable by group or others") {
+		t.Errorf("expected permission error, got %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/emergency"
+	"golang.org/x/crypto/hkdf"
+)
+
+// EmergencyDirName is the directory, inside the vault, that holds pending
+// emergency access requests.
+const EmergencyDirName = "emergency"
+
+// MinEmergencyWait is the shortest waiting period a contact can be given.
+const MinEmergencyWait = time.Hour
+
+// maxRequestSkew is how far a request's signed time may differ from when
+// the vault first saw it, to allow for clock differences between the
+// contact's and owner's machines.
+const maxRequestSkew = 5 * time.Minute
+
+// receiptContext is the HKDF info of the key that authenticates emergency
+// receipts, keeping it apart from other keys derived from the DEK.
+const receiptContext = "secretctl emergency receipt v2"
+
+// emergencyNow is the clock of the waiting period. Tests replace it.
+var emergencyNow = time.Now
+
+// Emergency access errors
+var (
+	ErrInvalidEmergencyContact = errors.New("vault: invalid emergency contact")
+	ErrEmergencyContactExists  = errors.New("vault: emergency contact already exists")
+	ErrEmergencyContactUnknown = errors.New("vault: no such emergency contact")
+	ErrNoEmergencyRequest      = errors.New("vault: no pending emergency access request")
+	ErrEmergencyWaiting        = errors.New("vault: emergency access waiting period has not elapsed")
+)
+
+// EmergencyContact is someone who can request access to the vault in an
+// emergency. Bundle is the DEK sealed to PublicKey; it is released to the
+// contact once a request has gone undenied for Wait.
+type EmergencyContact struct {
+	Name      string        `json:"name"`
+	PublicKey string        `json:"public_key"`
+	Wait      time.Duration `json:"wait"`
+	Bundle    []byte        `json:"bundle"`
+	AddedAt   time.Time     `json:"added_at"`
+}
+
+// EmergencyRequest is a pending request for emergency access. The
+// waiting period runs from when the vault first saw the request, not from
+// RequestedAt, which the contact signs.
+type EmergencyRequest struct {
+	Contact     string
+	RequestedAt time.Time
+	ReleaseAt   time.Time
+}
+
+// AddEmergencyContact seals the DEK to pub and saves name as an emergency
+// contact with the given waiting period.
+func (v *Vault) AddEmergencyContact(name string, pub *emergency.PublicKey, wait time.Duration) error {
+	if name == "" || !tagRegex.MatchString(name) {
+		return fmt.Errorf("%w: name must contain only letters, numbers, '-' and '_'", ErrInvalidEmergencyContact)
+	}
+	if wait < MinEmergencyWait {
+		return fmt.Errorf("%w: waiting period must be at least %s", ErrInvalidEmergencyContact, MinEmergencyWait)
+	}
+
+	dek, err := v.ExportDEK()
+	if err != nil {
+		return err
+	}
+	bundle, err := emergency.Seal(pub, dek)
+	crypto.SecureWipe(dek)
+	if err != nil {
+		return err
+	}
+
+	contact := EmergencyContact{
+		Name:      name,
+		PublicKey: pub.String(),
+		Wait:      wait,
+		Bundle:    bundle,
+		AddedAt:   time.Now().UTC(),
+	}
+	err = v.UpdateSettings(func(s *VaultSettings) error {
+		for _, c := range s.EmergencyContacts {
+			if c.Name == name {
+				return fmt.Errorf("%w: %s", ErrEmergencyContactExists, name)
+			}
+		}
+		s.EmergencyContacts = append(s.EmergencyContacts, contact)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_ = v.audit.Log(audit.OpVaultEmergency, v.auditSource(), audit.ResultSuccess, name, nil,
+		map[string]interface{}{"action": "add", "wait": wait.String()})
+	return nil
+}
+
+// RemoveEmergencyContact removes a contact and any pending request from it.
+// The contact's bundle is deleted, but a copy taken earlier still opens
+// with the DEK it holds; rotate the DEK if that matters.
+func (v *Vault) RemoveEmergencyContact(name string) error {
+	err := v.UpdateSettings(func(s *VaultSettings) error {
+		n := len(s.EmergencyContacts)
+		s.EmergencyContacts = slices.DeleteFunc(s.EmergencyContacts, func(c EmergencyContact) bool {
+			return c.Name == name
+		})
+		if len(s.EmergencyContacts) == n {
+			return fmt.Errorf("%w: %s", ErrEmergencyContactUnknown, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := v.removeEmergencyRequest(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	_ = v.audit.Log(audit.OpVaultEmergency, v.auditSource(), audit.ResultSuccess, name, nil,
+		map[string]interface{}{"action": "remove"})
+	return nil
+}
+
+// EmergencyContacts returns the configured emergency contacts. The vault
+// does not need to be unlocked.
+func (v *Vault) EmergencyContacts() ([]EmergencyContact, error) {
+	settings, err := v.Settings()
+	if err != nil {
+		return nil, err
+	}
+	return settings.EmergencyContacts, nil
+}
+
+// emergencyContact returns the contact called name and its public key.
+func (v *Vault) emergencyContact(name string) (*EmergencyContact, *emergency.PublicKey, error) {
+	contacts, err := v.EmergencyContacts()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, c := range contacts {
+		if c.Name == name {
+			pub, err := emergency.ParsePublicKey(c.PublicKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &contacts[i], pub, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrEmergencyContactUnknown, name)
+}
+
+// RequestEmergencyAccess records a request by the contact holding key. The
+// vault does not need to be unlocked; the request file itself is the record,
+// since the audit log cannot be written without the DEK. The request's time is signed with
+// key, and a receipt of when the vault first saw it is written next to it;
+// the waiting period runs from the receipt. An existing request is kept,
+// so repeating a request does not restart the waiting period. It returns
+// the time from which ReleaseEmergencyAccess succeeds.
+func (v *Vault) RequestEmergencyAccess(name string, key *emergency.PrivateKey) (time.Time, error) {
+	contact, pub, err := v.emergencyContact(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !pub.Equal(key.Public()) {
+		return time.Time{}, fmt.Errorf("%w: key does not belong to %s", emergency.ErrInvalidKey, name)
+	}
+
+	dek, err := openEmergencyBundle(contact, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer crypto.SecureWipe(dek)
+
+	if seen, err := v.readEmergencyRequest(contact, pub, dek); err == nil {
+		return seen.Add(contact.Wait), nil
+	} else if !errors.Is(err, ErrNoEmergencyRequest) {
+		return time.Time{}, err
+	}
+
+	now := emergencyNow()
+	req := emergency.SignRequest(key, name, now)
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("vault: failed to marshal emergency request: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(v.path, EmergencyDirName), DirMode); err != nil {
+		return time.Time{}, fmt.Errorf("vault: failed to create emergency directory: %w", err)
+	}
+	if err := atomicfile.Write(v.emergencyRequestPath(name), data, FileMode); err != nil {
+		return time.Time{}, fmt.Errorf("vault: failed to write emergency request: %w", err)
+	}
+	seen, err := v.emergencyReceipt(contact, req, dek, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return seen.Add(contact.Wait), nil
+}
+
+// EmergencyRequests returns the pending emergency access requests with a
+// valid signature. The vault does not need to be unlocked, but while it is
+// locked the receipts cannot be verified, so the times are as the receipts
+// state them.
+func (v *Vault) EmergencyRequests() ([]EmergencyRequest, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.emergencyRequests()
+}
+
+// emergencyRequests is EmergencyRequests for callers that hold v.mu.
+func (v *Vault) emergencyRequests() ([]EmergencyRequest, error) {
+	contacts, err := v.EmergencyContacts()
+	if err != nil {
+		return nil, err
+	}
+	var requests []EmergencyRequest
+	for _, c := range contacts {
+		pub, err := emergency.ParsePublicKey(c.PublicKey)
+		if err != nil {
+			continue
+		}
+		seen, err := v.readEmergencyRequest(&c, pub, v.dek)
+		if err != nil {
+			continue
+		}
+		requests = append(requests, EmergencyRequest{
+			Contact:     c.Name,
+			RequestedAt: seen,
+			ReleaseAt:   seen.Add(c.Wait),
+		})
+	}
+	return requests, nil
+}
+
+// DenyEmergencyAccess deletes the pending request from name. Only the
+// owner can deny, so the vault must be unlocked.
+func (v *Vault) DenyEmergencyAccess(name string) error {
+	if v.IsLocked() {
+		return ErrVaultLocked
+	}
+	if _, _, err := v.emergencyContact(name); err != nil {
+		return err
+	}
+	if err := v.removeEmergencyRequest(name); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w from %s", ErrNoEmergencyRequest, name)
+		}
+		return err
+	}
+	_ = v.audit.Log(audit.OpVaultEmergency, v.auditSource(), audit.ResultSuccess, name, nil,
+		map[string]interface{}{"action": "deny"})
+	return nil
+}
+
+// ReleaseEmergencyAccess opens the contact's bundle once its request has
+// gone undenied for the waiting period, and returns the DEK. The vault
+// does not need to be unlocked. Callers must wipe the returned key.
+//
+// The waiting period is enforced by secretctl only: the bundle in
+// vault.meta opens with the contact's key alone, so a contact with a copy
+// of vault.meta does not have to wait.
+func (v *Vault) ReleaseEmergencyAccess(name string, key *emergency.PrivateKey) ([]byte, error) {
+	contact, pub, err := v.emergencyContact(name)
+	if err != nil {
+		return nil, err
+	}
+	if !pub.Equal(key.Public()) {
+		return nil, fmt.Errorf("%w: key does not belong to %s", emergency.ErrInvalidKey, name)
+	}
+	dek, err := openEmergencyBundle(contact, key)
+	if err != nil {
+		return nil, err
+	}
+	seen, err := v.readEmergencyRequest(contact, pub, dek)
+	if err != nil {
+		crypto.SecureWipe(dek)
+		return nil, err
+	}
+	if releaseAt := seen.Add(contact.Wait); emergencyNow().Before(releaseAt) {
+		crypto.SecureWipe(dek)
+		return nil, fmt.Errorf("%w: available from %s", ErrEmergencyWaiting, releaseAt.Format(time.RFC3339))
+	}
+	return dek, nil
+}
+
+// openEmergencyBundle opens the contact's bundle with key and returns the
+// DEK. Callers must wipe it.
+func openEmergencyBundle(contact *EmergencyContact, key *emergency.PrivateKey) ([]byte, error) {
+	dek, err := emergency.Open(key, contact.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	if len(dek) != DEKLength {
+		crypto.SecureWipe(dek)
+		return nil, ErrInvalidDEK
+	}
+	return dek, nil
+}
+
+// readEmergencyRequest reads and verifies the pending request from the
+// contact and returns when the vault first saw it. A request whose
+// signature does not verify, or whose time differs too much from its
+// receipt, is treated as tampered with. The receipt is verified and, if
+// missing, written with dek; with a nil dek it is taken as it is.
+func (v *Vault) readEmergencyRequest(contact *EmergencyContact, pub *emergency.PublicKey, dek []byte) (time.Time, error) {
+	name := contact.Name
+	data, err := os.ReadFile(v.emergencyRequestPath(name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return time.Time{}, fmt.Errorf("%w from %s", ErrNoEmergencyRequest, name)
+		}
+		return time.Time{}, fmt.Errorf("vault: failed to read emergency request: %w", err)
+	}
+	var req emergency.Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return time.Time{}, fmt.Errorf("vault: malformed emergency request from %s: %w", name, err)
+	}
+	if req.Contact != name {
+		return time.Time{}, fmt.Errorf("%w: request names %q", emergency.ErrInvalidSignature, req.Contact)
+	}
+	if err := req.Verify(pub); err != nil {
+		return time.Time{}, err
+	}
+	seen, err := v.emergencyReceipt(contact, &req, dek, emergencyNow())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if req.RequestedAt.After(seen.Add(maxRequestSkew)) {
+		return time.Time{}, fmt.Errorf("%w: request is dated in the future", emergency.ErrInvalidSignature)
+	}
+	if req.RequestedAt.Before(seen.Add(-maxRequestSkew)) {
+		return time.Time{}, fmt.Errorf("%w: request is dated before the vault first saw it", emergency.ErrInvalidSignature)
+	}
+	return seen, nil
+}
+
+// emergencyReceipt records when the vault first saw a request.
+type emergencyReceipt struct {
+	FirstSeen time.Time `json:"first_seen"`
+	MAC       []byte    `json:"mac"`
+}
+
+// emergencyReceipt returns when the vault first saw req, writing a receipt
+// dated now if there is none yet. The receipt is bound to the request and
+// the contact with an HMAC keyed from dek, which the contact only gets by
+// opening their bundle, so it can neither be forged from vault.meta nor
+// reused for another request or contact. A receipt that does not match is
+// an error rather than being replaced, since replacing it would restart the
+// clock at the contact's choosing. With a nil dek the receipt can only be
+// read, unverified.
+func (v *Vault) emergencyReceipt(contact *EmergencyContact, req *emergency.Request, dek []byte, now time.Time) (time.Time, error) {
+	path := v.emergencyReceiptPath(contact.Name)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var r emergencyReceipt
+		if err := json.Unmarshal(data, &r); err != nil {
+			return time.Time{}, fmt.Errorf("vault: malformed emergency receipt for %s: %w", contact.Name, err)
+		}
+		if dek == nil {
+			return r.FirstSeen, nil
+		}
+		mac, err := receiptMAC(dek, contact, req, r.FirstSeen)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !hmac.Equal(r.MAC, mac) {
+			return time.Time{}, fmt.Errorf("%w: receipt does not match the request from %s", emergency.ErrInvalidSignature, contact.Name)
+		}
+		return r.FirstSeen, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, fmt.Errorf("vault: failed to read emergency receipt: %w", err)
+	}
+
+	seen := now.UTC().Truncate(time.Second)
+	if dek == nil {
+		// Not seen by anyone who can write a receipt yet
+		return seen, nil
+	}
+	mac, err := receiptMAC(dek, contact, req, seen)
+	if err != nil {
+		return time.Time{}, err
+	}
+	data, err = json.MarshalIndent(emergencyReceipt{FirstSeen: seen, MAC: mac}, "", "  ")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("vault: failed to marshal emergency receipt: %w", err)
+	}
+	if err := atomicfile.Write(path, data, FileMode); err != nil {
+		return time.Time{}, fmt.Errorf("vault: failed to write emergency receipt: %w", err)
+	}
+	return seen, nil
+}
+
+// receiptMAC authenticates a receipt with a key derived from dek. The
+// contact's bundle is covered too, since it changes whenever the contact is
+// added again.
+func receiptMAC(dek []byte, contact *EmergencyContact, req *emergency.Request, seen time.Time) ([]byte, error) {
+	key := make([]byte, 32)
+	defer crypto.SecureWipe(key)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, dek, nil, []byte(receiptContext)), key); err != nil {
+		return nil, fmt.Errorf("vault: failed to derive receipt key: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(contact.Bundle)
+	mac.Write(req.Signature)
+	mac.Write([]byte("\n" + seen.UTC().Format(time.RFC3339)))
+	return mac.Sum(nil), nil
+}
+
+// removeEmergencyRequest deletes the request from name and its receipt.
+// It returns an error wrapping os.ErrNotExist if there was no request.
+func (v *Vault) removeEmergencyRequest(name string) error {
+	if err := os.Remove(v.emergencyReceiptPath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("vault: failed to remove emergency receipt: %w", err)
+	}
+	if err := os.Remove(v.emergencyRequestPath(name)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return fmt.Errorf("vault: failed to remove emergency request: %w", err)
+	}
+	return nil
+}
+
+func (v *Vault) emergencyRequestPath(name string) string {
+	return filepath.Join(v.path, EmergencyDirName, name+".json")
+}
+
+func (v *Vault) emergencyReceiptPath(name string) string {
+	return filepath.Join(v.path, EmergencyDirName, name+".seen")
+}
+
+// warnEmergencyRequests warns the owner about pending emergency access
+// requests so that they can deny them in time. Caller must hold v.mu.
+func (v *Vault) warnEmergencyRequests() {
+	requests, err := v.emergencyRequests()
+	if err != nil {
+		return
+	}
+	for _, r := range requests {
+		v.warn(EventWarning, "emergency access requested by %s; it will be granted at %s unless denied (secretctl emergency deny %s)",
+			r.Contact, r.ReleaseAt.Format(time.RFC3339), r.Contact)
+	}
+}
@@ -0,0 +1,267 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/emergency"
+)
+
+// setEmergencyClock makes the waiting period see *now as the current time.
+func setEmergencyClock(t *testing.T, now *time.Time) {
+	t.Helper()
+	old := emergencyNow
+	emergencyNow = func() time.Time { return *now }
+	t.Cleanup(func() { emergencyNow = old })
+}
+
+func TestEmergencyAccess(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	dek, err := v.ExportDEK()
+	if err != nil {
+		t.Fatalf("ExportDEK failed: %v", err)
+	}
+
+	key, _ := emergency.GenerateKey()
+	other, _ := emergency.GenerateKey()
+	wait := 72 * time.Hour
+
+	if err := v.AddEmergencyContact("bad name", key.Public(), wait); !errors.Is(err, ErrInvalidEmergencyContact) {
+		t.Errorf("expected ErrInvalidEmergencyContact for a bad name, got %v", err)
+	}
+	if err := v.AddEmergencyContact("alice", key.Public(), time.Minute); !errors.Is(err, ErrInvalidEmergencyContact) {
+		t.Errorf("expected ErrInvalidEmergencyContact for a short wait, got %v", err)
+	}
+	if err := v.AddEmergencyContact("alice", key.Public(), wait); err != nil {
+		t.Fatalf("AddEmergencyContact failed: %v", err)
+	}
+	if err := v.AddEmergencyContact("alice", other.Public(), wait); !errors.Is(err, ErrEmergencyContactExists) {
+		t.Errorf("expected ErrEmergencyContactExists, got %v", err)
+	}
+	v.Lock()
+
+	// The contact works against the locked vault
+	now := time.Now()
+	setEmergencyClock(t, &now)
+	if _, err := v.RequestEmergencyAccess("alice", other); !errors.Is(err, emergency.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for another contact's key, got %v", err)
+	}
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, ErrNoEmergencyRequest) {
+		t.Errorf("expected ErrNoEmergencyRequest before requesting, got %v", err)
+	}
+	releaseAt, err := v.RequestEmergencyAccess("alice", key)
+	if err != nil {
+		t.Fatalf("RequestEmergencyAccess failed: %v", err)
+	}
+	if want := now.UTC().Truncate(time.Second).Add(wait); !releaseAt.Equal(want) {
+		t.Errorf("releaseAt = %v, want %v", releaseAt, want)
+	}
+	// Requesting again keeps the original request
+	now = now.Add(time.Hour)
+	if again, err := v.RequestEmergencyAccess("alice", key); err != nil || !again.Equal(releaseAt) {
+		t.Errorf("repeated request = %v, %v; want %v", again, err, releaseAt)
+	}
+	now = releaseAt.Add(-time.Second)
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, ErrEmergencyWaiting) {
+		t.Errorf("expected ErrEmergencyWaiting, got %v", err)
+	}
+	now = releaseAt
+	got, err := v.ReleaseEmergencyAccess("alice", key)
+	if err != nil {
+		t.Fatalf("ReleaseEmergencyAccess failed: %v", err)
+	}
+	if !bytes.Equal(got, dek) {
+		t.Error("released key is not the DEK")
+	}
+
+	requests, err := v.EmergencyRequests()
+	if err != nil || len(requests) != 1 || requests[0].Contact != "alice" || !requests[0].ReleaseAt.Equal(releaseAt) {
+		t.Fatalf("EmergencyRequests = %+v, %v", requests, err)
+	}
+
+	// Only the unlocked owner can deny
+	if err := v.DenyEmergencyAccess("alice"); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if err := v.DenyEmergencyAccess("alice"); err != nil {
+		t.Fatalf("DenyEmergencyAccess failed: %v", err)
+	}
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, ErrNoEmergencyRequest) {
+		t.Errorf("expected ErrNoEmergencyRequest after deny, got %v", err)
+	}
+	if err := v.DenyEmergencyAccess("alice"); !errors.Is(err, ErrNoEmergencyRequest) {
+		t.Errorf("expected ErrNoEmergencyRequest for a second deny, got %v", err)
+	}
+
+	if err := v.RemoveEmergencyContact("alice"); err != nil {
+		t.Fatalf("RemoveEmergencyContact failed: %v", err)
+	}
+	if _, err := v.RequestEmergencyAccess("alice", key); !errors.Is(err, ErrEmergencyContactUnknown) {
+		t.Errorf("expected ErrEmergencyContactUnknown after removal, got %v", err)
+	}
+	if err := v.RemoveEmergencyContact("alice"); !errors.Is(err, ErrEmergencyContactUnknown) {
+		t.Errorf("expected ErrEmergencyContactUnknown, got %v", err)
+	}
+}
+
+func TestEmergencyRequestTampering(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	key, _ := emergency.GenerateKey()
+	if err := v.AddEmergencyContact("alice", key.Public(), 24*time.Hour); err != nil {
+		t.Fatalf("AddEmergencyContact failed: %v", err)
+	}
+	v.Lock()
+
+	now := time.Now()
+	setEmergencyClock(t, &now)
+	writeRequest := func(at time.Time) {
+		t.Helper()
+		data, _ := json.Marshal(emergency.SignRequest(key, "alice", at))
+		if err := os.MkdirAll(filepath.Join(v.Path(), EmergencyDirName), DirMode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(v.emergencyRequestPath("alice"), data, FileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Requests dated in the future are not trusted
+	writeRequest(now.Add(48 * time.Hour))
+	now = now.Add(72 * time.Hour)
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, emergency.ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for a future request, got %v", err)
+	}
+	if requests, _ := v.EmergencyRequests(); len(requests) != 0 {
+		t.Errorf("expected no valid requests, got %+v", requests)
+	}
+	if err := v.removeEmergencyRequest("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A backdated request does not skip the waiting period: the vault
+	// counts from when it first saw the request
+	writeRequest(now.Add(-48 * time.Hour))
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, emergency.ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for a backdated request, got %v", err)
+	}
+	now = now.Add(48 * time.Hour)
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, emergency.ErrInvalidSignature) {
+		t.Errorf("backdated request released after the wait: %v", err)
+	}
+	if err := v.removeEmergencyRequest("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replacing a seen request with a backdated one does not reuse its receipt
+	releaseAt, err := v.RequestEmergencyAccess("alice", key)
+	if err != nil {
+		t.Fatalf("RequestEmergencyAccess failed: %v", err)
+	}
+	writeRequest(now.Add(-24 * time.Hour))
+	now = releaseAt
+	if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, emergency.ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for a replaced request, got %v", err)
+	}
+}
+
+func TestEmergencyReceiptForgery(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	key, _ := emergency.GenerateKey()
+	wait := 72 * time.Hour
+	if err := v.AddEmergencyContact("alice", key.Public(), wait); err != nil {
+		t.Fatalf("AddEmergencyContact failed: %v", err)
+	}
+	v.Lock()
+
+	now := time.Now()
+	setEmergencyClock(t, &now)
+	contact, _, err := v.emergencyContact("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A backdated request with a receipt keyed from what vault.meta holds,
+	// as the contact could write it
+	then := now.Add(-wait - time.Hour).UTC().Truncate(time.Second)
+	req := emergency.SignRequest(key, "alice", then)
+	data, _ := json.Marshal(req)
+	if err := os.MkdirAll(filepath.Join(v.Path(), EmergencyDirName), DirMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(v.emergencyRequestPath("alice"), data, FileMode); err != nil {
+		t.Fatal(err)
+	}
+	for _, context := range []string{"secretctl emergency receipt v1", receiptContext} {
+		forgeKey := sha256.Sum256(append([]byte(context+"\n"), contact.Bundle...))
+		mac := hmac.New(sha256.New, forgeKey[:])
+		mac.Write(req.Signature)
+		mac.Write([]byte("\n" + then.Format(time.RFC3339)))
+		receipt, _ := json.Marshal(emergencyReceipt{FirstSeen: then, MAC: mac.Sum(nil)})
+		if err := os.WriteFile(v.emergencyReceiptPath("alice"), receipt, FileMode); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v.ReleaseEmergencyAccess("alice", key); !errors.Is(err, emergency.ErrInvalidSignature) {
+			t.Errorf("expected ErrInvalidSignature for a receipt forged from the bundle, got %v", err)
+		}
+	}
+
+	// The receipt written by the vault is accepted
+	if err := v.removeEmergencyRequest("alice"); err != nil {
+		t.Fatal(err)
+	}
+	releaseAt, err := v.RequestEmergencyAccess("alice", key)
+	if err != nil {
+		t.Fatalf("RequestEmergencyAccess failed: %v", err)
+	}
+	now = releaseAt
+	dek, err := v.ReleaseEmergencyAccess("alice", key)
+	if err != nil {
+		t.Fatalf("ReleaseEmergencyAccess failed: %v", err)
+	}
+
+	// The owner verifies receipts with the unlocked DEK
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	owner, _ := v.ExportDEK()
+	if !bytes.Equal(dek, owner) {
+		t.Error("released key is not the DEK")
+	}
+	if requests, err := v.EmergencyRequests(); err != nil || len(requests) != 1 || !requests[0].ReleaseAt.Equal(releaseAt) {
+		t.Errorf("EmergencyRequests = %+v, %v", requests, err)
+	}
+}
@@ -0,0 +1,100 @@
+package vault
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EventType identifies a vault event.
+type EventType string
+
+// Vault event types
+const (
+	EventUnlocked          EventType = "unlocked"
+	EventLocked            EventType = "locked"
+	EventSecretChanged     EventType = "secret_changed"     // Key is set; the secret was stored or deleted
+	EventCooldownTriggered EventType = "cooldown_triggered" // too many failed unlock attempts
+	EventLowDisk           EventType = "low_disk"
+	EventWarning           EventType = "warning"          // advisory problems such as insecure permissions
+	EventTamperSuspected   EventType = "tamper_suspected" // vault files changed by another process; the vault was locked
+	EventCanaryTriggered   EventType = "canary_triggered" // Key is set; a decoy secret was read
+	EventProgress          EventType = "progress"         // Progress is set; a vault-wide operation advanced
+)
+
+// eventBufferSize is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const eventBufferSize = 32
+
+// Event is a notification published by the vault.
+type Event struct {
+	Type    EventType `json:"type"`
+	Time    time.Time `json:"time"`
+	Key     string    `json:"key,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// Progress is set for EventProgress.
+	Progress *Progress `json:"progress,omitempty"`
+}
+
+// eventBus fans events out to subscribers. The zero value is ready to use.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// Events subscribes to vault events. Delivery never blocks the vault: a
+// subscriber that does not keep up misses events. Call the returned function
+// to unsubscribe, which also closes the channel.
+func (v *Vault) Events() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	b := &v.events
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[chan Event]struct{})
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// emit publishes an event and reports whether anyone is subscribed.
+func (v *Vault) emit(typ EventType, key, message string) bool {
+	return v.emitEvent(Event{Type: typ, Key: key, Message: message})
+}
+
+// emitEvent publishes e, stamping its time, and reports whether anyone is
+// subscribed.
+func (v *Vault) emitEvent(e Event) bool {
+	b := &v.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.Time = time.Now()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	return len(b.subs) > 0
+}
+
+// warn publishes an advisory event. Without subscribers (e.g. in the CLI)
+// it is logged instead.
+func (v *Vault) warn(typ EventType, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if !v.emit(typ, "", message) {
+		slog.Warn(message)
+	}
+}
@@ -0,0 +1,88 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	// Unlock may warn about permissions of the temp dir first
+	e := nextEvent(t, events)
+	for e.Type == EventWarning {
+		e = nextEvent(t, events)
+	}
+	if e.Type != EventUnlocked {
+		t.Errorf("expected %s, got %+v", EventUnlocked, e)
+	}
+
+	if err := v.SetSecret("api/key", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if e := nextEvent(t, events); e.Type != EventSecretChanged || e.Key != "api/key" {
+		t.Errorf("expected secret_changed for api/key, got %+v", e)
+	}
+	if err := v.DeleteSecret("api/key"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if e := nextEvent(t, events); e.Type != EventSecretChanged || e.Message != "deleted" {
+		t.Errorf("expected deleted secret_changed, got %+v", e)
+	}
+
+	v.Lock()
+	if e := nextEvent(t, events); e.Type != EventLocked {
+		t.Errorf("expected %s, got %+v", EventLocked, e)
+	}
+	// Locking a locked vault is not an event
+	v.Lock()
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %+v", e)
+	default:
+	}
+}
+
+func TestEvents_CooldownAndUnsubscribe(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	events, unsubscribe := v.Events()
+
+	for i := 0; i < CooldownThreshold1; i++ {
+		_ = v.Unlock("wrong-password")
+	}
+	if e := nextEvent(t, events); e.Type != EventCooldownTriggered {
+		t.Errorf("expected %s, got %+v", EventCooldownTriggered, e)
+	}
+
+	unsubscribe()
+	unsubscribe() // idempotent
+	if _, ok := <-events; ok {
+		t.Error("expected channel to be closed after unsubscribe")
+	}
+	if v.emit(EventWarning, "", "nobody listens") {
+		t.Error("emit reported subscribers after unsubscribe")
+	}
+}
@@ -0,0 +1,136 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expiry rule errors
+var (
+	ErrExpirationRequired = errors.New("vault: an expiration date is required for this key")
+	ErrInvalidExpiryRule  = errors.New("vault: invalid expiry rule")
+)
+
+// ExpiryRequired is the ExpiryRule.Expires value that rejects secrets
+// set without an expiration date.
+const ExpiryRequired = "required"
+
+// ExpiryRule applies to secrets whose key matches Pattern (path.Match
+// syntax, e.g. "tmp/*") when they are set without an expiration date.
+// Expires is either a duration such as "7d", which becomes the expiration
+// counted from the time of the write, or ExpiryRequired, which rejects
+// the write with ErrExpirationRequired.
+type ExpiryRule struct {
+	Pattern string `json:"pattern"`
+	Expires string `json:"expires"`
+}
+
+// ParseExpiryRules parses "pattern=expires" pairs separated by commas,
+// e.g. "tmp/*=7d,prod/*=required". Durations take d, w, m (30 days) or y
+// with a whole number, or anything time.ParseDuration accepts.
+func ParseExpiryRules(s string) ([]ExpiryRule, error) {
+	var rules []ExpiryRule
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, expires, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%w: expected pattern=duration or pattern=%s, got %q", ErrInvalidExpiryRule, ExpiryRequired, item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: pattern %q: %v", ErrInvalidExpiryRule, pattern, err)
+		}
+		rule := ExpiryRule{Pattern: pattern, Expires: expires}
+		if _, err := rule.duration(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// duration returns the default lifetime of r, or 0 if it only requires
+// an expiration date.
+func (r ExpiryRule) duration() (time.Duration, error) {
+	if r.Expires == ExpiryRequired {
+		return 0, nil
+	}
+	s := r.Expires
+	invalid := fmt.Errorf("%w: expected a duration such as 7d or %s, got %q", ErrInvalidExpiryRule, ExpiryRequired, s)
+	if len(s) < 2 {
+		return 0, invalid
+	}
+	var per time.Duration
+	switch s[len(s)-1] {
+	case 'd':
+		per = 24 * time.Hour
+	case 'w':
+		per = 7 * 24 * time.Hour
+	case 'm':
+		per = 30 * 24 * time.Hour
+	case 'y':
+		per = 365 * 24 * time.Hour
+	}
+	if n, err := strconv.ParseInt(s[:len(s)-1], 10, 64); per != 0 && err == nil {
+		if n <= 0 || n > int64(math.MaxInt64/per) {
+			return 0, invalid
+		}
+		return time.Duration(n) * per, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, invalid
+	}
+	return d, nil
+}
+
+// ExpiryRuleFor returns the rule that applies to key, or nil if there is
+// none. The first matching rule wins. The vault does not need to be
+// unlocked.
+func (v *Vault) ExpiryRuleFor(key string) *ExpiryRule {
+	settings, err := v.Settings()
+	if err != nil {
+		return nil
+	}
+	return settings.expiryRuleFor(key)
+}
+
+func (s *VaultSettings) expiryRuleFor(key string) *ExpiryRule {
+	for i, r := range s.ExpiryRules {
+		if ok, _ := path.Match(r.Pattern, key); ok {
+			return &s.ExpiryRules[i]
+		}
+	}
+	return nil
+}
+
+// applyExpiryRule returns the expiration date of key written at now with
+// expiresAt: expiresAt itself if set, otherwise the default of the rule
+// for key, if any. It returns ErrExpirationRequired if the rule requires
+// an expiration date and there is none.
+func (v *Vault) applyExpiryRule(key string, expiresAt *time.Time, now time.Time) (*time.Time, error) {
+	if expiresAt != nil {
+		return expiresAt, nil
+	}
+	rule := v.ExpiryRuleFor(key)
+	if rule == nil {
+		return nil, nil
+	}
+	d, err := rule.duration()
+	if err != nil {
+		// Rules are validated when saved, so vault.meta was edited by hand
+		return nil, err
+	}
+	if d == 0 {
+		return nil, fmt.Errorf("%w: %s matches expiry rule %s", ErrExpirationRequired, key, rule.Pattern)
+	}
+	t := now.Add(d)
+	return &t, nil
+}
@@ -0,0 +1,104 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExpiryRules(t *testing.T) {
+	rules, err := ParseExpiryRules("tmp/*=7d, prod/*=required,ci/*=12h")
+	if err != nil {
+		t.Fatalf("ParseExpiryRules failed: %v", err)
+	}
+	if len(rules) != 3 || rules[1] != (ExpiryRule{Pattern: "prod/*", Expires: ExpiryRequired}) {
+		t.Errorf("unexpected rules %v", rules)
+	}
+	for _, want := range []time.Duration{7 * 24 * time.Hour, 0, 12 * time.Hour} {
+		d, _ := rules[0].duration()
+		rules = rules[1:]
+		if d != want {
+			t.Errorf("duration = %v, want %v", d, want)
+		}
+	}
+
+	for _, bad := range []string{"tmp/*", "=7d", "tmp/*=soon", "tmp/*=0d", "tmp/*=-1h", "[=7d", "tmp/*=99999999999y"} {
+		if _, err := ParseExpiryRules(bad); !errors.Is(err, ErrInvalidExpiryRule) {
+			t.Errorf("ParseExpiryRules(%q) = %v, want ErrInvalidExpiryRule", bad, err)
+		}
+	}
+}
+
+func TestExpiryRules(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("prod/db", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	rules, _ := ParseExpiryRules("tmp/*=7d,prod/*=required")
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.ExpiryRules = rules
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	// A default is filled in without changing the caller's entry
+	before := time.Now()
+	entry := &SecretEntry{Value: []byte("v")}
+	if err := v.SetSecret("tmp/token", entry); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if entry.ExpiresAt != nil {
+		t.Error("SetSecret modified the entry")
+	}
+	got, _ := v.GetSecret("tmp/token")
+	if got.ExpiresAt == nil || got.ExpiresAt.Before(before.Add(7*24*time.Hour)) || got.ExpiresAt.After(time.Now().Add(7*24*time.Hour)) {
+		t.Errorf("ExpiresAt = %v, want 7 days from now", got.ExpiresAt)
+	}
+
+	// An explicit expiration wins over the default
+	explicit := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := v.SetSecret("tmp/other", &SecretEntry{Value: []byte("v"), ExpiresAt: &explicit}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	got, _ = v.GetSecret("tmp/other")
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(explicit) {
+		t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, explicit)
+	}
+
+	// Required rules reject secrets without one, also in batches
+	if err := v.SetSecret("prod/api", &SecretEntry{Value: []byte("v")}); !errors.Is(err, ErrExpirationRequired) {
+		t.Errorf("expected ErrExpirationRequired, got %v", err)
+	}
+	err := v.SetSecrets(map[string]*SecretEntry{
+		"dev/key":  {Value: []byte("v")},
+		"prod/api": {Value: []byte("v")},
+	})
+	if !errors.Is(err, ErrExpirationRequired) {
+		t.Errorf("expected ErrExpirationRequired, got %v", err)
+	}
+	if _, err := v.GetSecret("dev/key"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("batch must not be partially applied: %v", err)
+	}
+	if err := v.SetSecret("prod/api", &SecretEntry{Value: []byte("v"), ExpiresAt: &explicit}); err != nil {
+		t.Errorf("SetSecret with expiration failed: %v", err)
+	}
+
+	// Existing secrets stay readable; clearing the expiration is refused
+	if _, err := v.GetSecret("prod/db"); err != nil {
+		t.Errorf("GetSecret failed: %v", err)
+	}
+	_, err = v.BulkUpdate(BulkFilter{KeyPattern: "prod/*"}, BulkPatch{ClearExpiration: true})
+	if !errors.Is(err, ErrExpirationRequired) {
+		t.Errorf("expected ErrExpirationRequired, got %v", err)
+	}
+}
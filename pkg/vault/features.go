@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// Features recorded in vault.meta. A vault that uses a feature, or a value
+// of it, that this binary does not know is refused with
+// ErrUnsupportedFeature instead of being read or written incorrectly.
+const (
+	// FeatureCipher is the cipher suite that encrypts key names, values and
+	// metadata.
+	FeatureCipher = "cipher"
+	// FeatureVersions means previous versions of secrets are kept in
+	// secret_history.
+	FeatureVersions = "versions"
+	// FeatureShards means some namespaces are stored under shards/; a
+	// binary that ignored them would write those secrets to vault.db.
+	FeatureShards = "shards"
+	// FeatureStrictExpiry means expired secrets must not be returned
+	// (VaultSettings.BlockExpiredReads).
+	FeatureStrictExpiry = "strict_expiry"
+	// FeatureAttachments means secrets have files attached; a binary that
+	// ignored them would leave them under the old key when rotating it.
+	FeatureAttachments = "attachments"
+)
+
+// FeatureOn is the value of features that are either used or absent.
+const FeatureOn = "on"
+
+// FeatureCipher values. The DEK itself is always wrapped with AES-256-GCM.
+const (
+	CipherAES256GCM         = crypto.CipherAES256GCM
+	CipherXChaCha20Poly1305 = crypto.CipherXChaCha20Poly1305
+)
+
+// ErrUnsupportedFeature is returned for vaults that use a feature this
+// binary does not support, typically because a newer version created it.
+var ErrUnsupportedFeature = errors.New("vault: vault uses a feature this version of secretctl does not support")
+
+// supportedFeatures lists the features and values this binary handles.
+var supportedFeatures = map[string][]string{
+	FeatureCipher:       {CipherAES256GCM, CipherXChaCha20Poly1305},
+	FeatureVersions:     {FeatureOn},
+	FeatureShards:       {FeatureOn},
+	FeatureStrictExpiry: {FeatureOn},
+	FeatureAttachments:  {FeatureOn},
+}
+
+// Features returns the features the vault uses, as recorded in vault.meta.
+// Vaults written before features were recorded have none until they are
+// next unlocked. The vault does not need to be unlocked.
+func (v *Vault) Features() (map[string]string, error) {
+	meta, err := v.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	return maps.Clone(meta.Features), nil
+}
+
+// checkFeatures returns ErrUnsupportedFeature if vault.meta records a
+// feature this binary does not support. A missing or unreadable meta file
+// is left to the callers that need it.
+func (v *Vault) checkFeatures() error {
+	meta, err := v.readMeta()
+	if err != nil {
+		return nil
+	}
+	return meta.checkFeatures()
+}
+
+// cipher returns the cipher suite of the vault's data. Vaults written
+// before features were recorded use AES-256-GCM.
+func (m *VaultMeta) cipher() string {
+	if suite := m.Features[FeatureCipher]; suite != "" {
+		return suite
+	}
+	return CipherAES256GCM
+}
+
+// storedCipher is VaultMeta.cipher of vault.meta; AES-256-GCM if it
+// cannot be read.
+func (v *Vault) storedCipher() string {
+	meta, err := v.readMeta()
+	if err != nil {
+		return CipherAES256GCM
+	}
+	return meta.cipher()
+}
+
+func (m *VaultMeta) checkFeatures() error {
+	return CheckFeatures(m.Features)
+}
+
+// CheckFeatures returns ErrUnsupportedFeature if features, as returned by
+// Features, include one this binary does not support.
+func CheckFeatures(features map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(features)) {
+		value := features[name]
+		if !slices.Contains(supportedFeatures[name], value) {
+			return fmt.Errorf("%w: %s=%s", ErrUnsupportedFeature, name, value)
+		}
+	}
+	return nil
+}
+
+// updateFeatures records the features that follow from the settings.
+// writeMeta calls it, so the features stay in step with every change.
+func (m *VaultMeta) updateFeatures() {
+	if m.Features == nil {
+		m.Features = make(map[string]string)
+	}
+	if m.Features[FeatureCipher] == "" {
+		m.Features[FeatureCipher] = CipherAES256GCM
+	}
+	m.Features[FeatureVersions] = FeatureOn
+
+	s := m.Settings
+	setFeature(m.Features, FeatureShards, s != nil && len(s.Shards) > 0)
+	setFeature(m.Features, FeatureStrictExpiry, s != nil && s.BlockExpiredReads)
+}
+
+func setFeature(features map[string]string, name string, on bool) {
+	if on {
+		features[name] = FeatureOn
+	} else {
+		delete(features, name)
+	}
+}
+
+// recordFeatures writes the features and ID of a vault created before they
+// were recorded. Caller must hold v.mu.
+func (v *Vault) recordFeatures() error {
+	meta, err := v.readMeta()
+	if err != nil || (meta.Features != nil && meta.ID != "") {
+		return nil
+	}
+	if meta.Settings != nil && meta.Settings.ReadOnly {
+		return nil
+	}
+	return v.writeMeta(meta)
+}
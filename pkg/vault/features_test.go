@@ -0,0 +1,165 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+func TestFeatures(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	features, err := v.Features()
+	if err != nil {
+		t.Fatalf("Features failed: %v", err)
+	}
+	if features[FeatureCipher] != CipherAES256GCM || features[FeatureVersions] != FeatureOn {
+		t.Errorf("features after Init = %v", features)
+	}
+	if _, ok := features[FeatureStrictExpiry]; ok {
+		t.Errorf("strict_expiry recorded without block_expired_reads: %v", features)
+	}
+
+	// Features follow the settings
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.BlockExpiredReads = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if features, _ := v.Features(); features[FeatureStrictExpiry] != FeatureOn {
+		t.Errorf("strict_expiry not recorded: %v", features)
+	}
+	v.Lock()
+
+	// Vaults from before features were recorded get them on unlock
+	meta, err := v.readMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Features = nil
+	writeRawMeta(t, v, meta)
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock of a vault without features failed: %v", err)
+	}
+	v.Lock()
+	if features, _ := v.Features(); features[FeatureCipher] != CipherAES256GCM {
+		t.Errorf("features not recorded on unlock: %v", features)
+	}
+}
+
+func TestUnsupportedFeature(t *testing.T) {
+	for name, features := range map[string]map[string]string{
+		"unknown feature": {FeatureCipher: CipherAES256GCM, "compression": "zstd"},
+		"unknown value":   {FeatureCipher: "rot13"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			v := New(t.TempDir())
+			password := "testpassword123"
+			if err := v.Init(password); err != nil {
+				t.Fatalf("Init failed: %v", err)
+			}
+			meta, err := v.readMeta()
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta.Features = features
+			writeRawMeta(t, v, meta)
+
+			if err := v.Unlock(password); !errors.Is(err, ErrUnsupportedFeature) {
+				t.Errorf("Unlock: expected ErrUnsupportedFeature, got %v", err)
+			}
+			if err := v.UnlockWithKey(make([]byte, DEKLength)); !errors.Is(err, ErrUnsupportedFeature) {
+				t.Errorf("UnlockWithKey: expected ErrUnsupportedFeature, got %v", err)
+			}
+		})
+	}
+}
+
+// writeRawMeta writes meta without updating its features.
+func writeRawMeta(t *testing.T, v *Vault, meta *VaultMeta) {
+	t.Helper()
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(v.path, MetaFileName), data, FileMode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCipherXChaCha20Poly1305(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.InitWithOptions(password, InitOptions{Cipher: "rot13"}); !errors.Is(err, crypto.ErrUnsupportedCipher) {
+		t.Errorf("expected ErrUnsupportedCipher, got %v", err)
+	}
+	if err := v.InitWithOptions(password, InitOptions{Cipher: CipherXChaCha20Poly1305}); err != nil {
+		t.Fatalf("InitWithOptions failed: %v", err)
+	}
+	if features, _ := v.Features(); features[FeatureCipher] != CipherXChaCha20Poly1305 {
+		t.Errorf("features after Init = %v", features)
+	}
+
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("db/password", &SecretEntry{Value: []byte("hunter2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	var blob []byte
+	if err := v.db.QueryRow("SELECT encrypted_value FROM secrets").Scan(&blob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptBlob(CipherXChaCha20Poly1305, v.dek, blob); err != nil {
+		t.Errorf("value is not encrypted with XChaCha20-Poly1305: %v", err)
+	}
+	if _, err := decryptBlob(CipherAES256GCM, v.dek, blob); err == nil {
+		t.Error("value decrypts with AES-256-GCM")
+	}
+
+	// Rotating the DEK keeps the cipher suite
+	if err := v.RotateDEK(password, nil); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+	if entry, err := v.GetSecret("db/password"); err != nil || string(entry.Value) != "hunter2" {
+		t.Fatalf("GetSecret after RotateDEK = %v, %v", entry, err)
+	}
+	dek := bytes.Clone(v.dek)
+	v.Lock()
+
+	// A rebuild without vault.meta detects the cipher suite
+	for _, name := range []string{SaltFileName, MetaFileName} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v = New(dir)
+	if err := v.InitWithDEK(dek, "newpassword123"); err != nil {
+		t.Fatalf("InitWithDEK failed: %v", err)
+	}
+	if features, _ := v.Features(); features[FeatureCipher] != CipherXChaCha20Poly1305 {
+		t.Errorf("features after InitWithDEK = %v", features)
+	}
+	if err := v.Unlock("newpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if entry, err := v.GetSecret("db/password"); err != nil || string(entry.Value) != "hunter2" {
+		t.Errorf("GetSecret after InitWithDEK = %v, %v", entry, err)
+	}
+}
@@ -74,6 +74,10 @@ type Field struct {
 	// Hint provides UI/AI description for this field.
 	// Not encrypted, visible to AI agents.
 	Hint string `json:"hint,omitempty"`
+
+	// Validator names a format check applied to Value on write
+	// (see Validators), e.g. "uuid" or "pem". Empty means no check.
+	Validator string `json:"validator,omitempty"`
 }
 
 // fieldNameRegex validates field names: lowercase letters, numbers, underscores only (snake_case)
@@ -138,7 +142,8 @@ func ValidateField(name string, field *Field) error {
 		return fmt.Errorf("%w: field %q has invalid inputType %q", ErrInputTypeInvalid, name, field.InputType)
 	}
 
-	return nil
+	// Validate value format
+	return validateFieldValue(name, field)
 }
 
 // ValidateFields validates all fields in a multi-field secret.
@@ -101,6 +101,9 @@ func (v *Vault) CreateFolder(folder *Folder) error {
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
 
 	// Validate folder name
 	if err := validateFolderName(folder.Name); err != nil {
@@ -303,7 +306,7 @@ func (v *Vault) ListFolders(parentID *string) ([]*FolderWithStats, error) {
 		// All folders
 		query = `
 			SELECT f.id, f.name, f.parent_id, f.icon, f.color, f.sort_order, f.created_at, f.updated_at,
-			       (SELECT COUNT(*) FROM secrets s WHERE s.folder_id = f.id) as secret_count,
+			       (SELECT COUNT(*) FROM secrets s WHERE s.folder_id = f.id AND s.deleted_at IS NULL) as secret_count,
 			       (SELECT COUNT(*) FROM folders c WHERE c.parent_id = f.id) as subfolder_count
 			FROM folders f
 			ORDER BY f.parent_id NULLS FIRST, f.sort_order, f.name COLLATE NOCASE
@@ -312,7 +315,7 @@ func (v *Vault) ListFolders(parentID *string) ([]*FolderWithStats, error) {
 		// Root folders only
 		query = `
 			SELECT f.id, f.name, f.parent_id, f.icon, f.color, f.sort_order, f.created_at, f.updated_at,
-			       (SELECT COUNT(*) FROM secrets s WHERE s.folder_id = f.id) as secret_count,
+			       (SELECT COUNT(*) FROM secrets s WHERE s.folder_id = f.id AND s.deleted_at IS NULL) as secret_count,
 			       (SELECT COUNT(*) FROM folders c WHERE c.parent_id = f.id) as subfolder_count
 			FROM folders f
 			WHERE f.parent_id IS NULL
@@ -322,7 +325,7 @@ func (v *Vault) ListFolders(parentID *string) ([]*FolderWithStats, error) {
 		// Children of specific parent
 		query = `
 			SELECT f.id, f.name, f.parent_id, f.icon, f.color, f.sort_order, f.created_at, f.updated_at,
-			       (SELECT COUNT(*) FROM secrets s WHERE s.folder_id = f.id) as secret_count,
+			       (SELECT COUNT(*) FROM secrets s WHERE s.folder_id = f.id AND s.deleted_at IS NULL) as secret_count,
 			       (SELECT COUNT(*) FROM folders c WHERE c.parent_id = f.id) as subfolder_count
 			FROM folders f
 			WHERE f.parent_id = ?
@@ -386,6 +389,9 @@ func (v *Vault) UpdateFolder(folder *Folder) error {
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
 
 	// Validate folder name
 	if err := validateFolderName(folder.Name); err != nil {
@@ -458,6 +464,9 @@ func (v *Vault) DeleteFolder(id string, force bool) error {
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
 
 	// Check folder exists
 	var exists int
@@ -478,7 +487,7 @@ func (v *Vault) DeleteFolder(id string, force bool) error {
 
 	// Check for secrets
 	var secretCount int
-	err = v.db.QueryRow("SELECT COUNT(*) FROM secrets WHERE folder_id = ?", id).Scan(&secretCount)
+	err = v.db.QueryRow("SELECT COUNT(*) FROM secrets WHERE folder_id = ? AND deleted_at IS NULL", id).Scan(&secretCount)
 	if err != nil {
 		return fmt.Errorf("vault: failed to count secrets: %w", err)
 	}
@@ -508,12 +517,19 @@ func (v *Vault) DeleteFolder(id string, force bool) error {
 		}
 
 		// Move secrets to unfiled (folder_id = NULL)
-		_, err = tx.Exec("UPDATE secrets SET folder_id = NULL WHERE folder_id = ?", id)
+		_, err = v.execSecrets(tx, "UPDATE %s SET folder_id = NULL WHERE folder_id = ?", id)
 		if err != nil {
 			return fmt.Errorf("vault: failed to move secrets: %w", err)
 		}
 	}
 
+	// Secrets in the trash do not keep a folder from being deleted; they
+	// are restored unfiled
+	_, err = v.execSecrets(tx, "UPDATE %s SET folder_id = NULL WHERE folder_id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return fmt.Errorf("vault: failed to move deleted secrets: %w", err)
+	}
+
 	// Delete folder
 	_, err = tx.Exec("DELETE FROM folders WHERE id = ?", id)
 	if err != nil {
@@ -529,12 +545,21 @@ func (v *Vault) DeleteFolder(id string, force bool) error {
 
 // MoveSecretToFolder moves a secret to a folder (or unfiled if folderID is nil).
 func (v *Vault) MoveSecretToFolder(secretKey string, folderID *string) error {
+	return v.run(Op{Name: OpUpdate, Key: secretKey}, func() error {
+		return v.moveSecretToFolder(secretKey, folderID)
+	})
+}
+
+func (v *Vault) moveSecretToFolder(secretKey string, folderID *string) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
 
 	// Validate folder exists if specified
 	if folderID != nil && *folderID != "" {
@@ -552,15 +577,10 @@ func (v *Vault) MoveSecretToFolder(secretKey string, folderID *string) error {
 	keyHash := v.hashKey(secretKey)
 
 	// Update secret
-	result, err := v.db.Exec("UPDATE secrets SET folder_id = ? WHERE key_hash = ?", folderID, keyHash)
+	rowsAffected, err := v.execSecrets(v.db, "UPDATE %s SET folder_id = ? WHERE key_hash = ? AND deleted_at IS NULL", folderID, keyHash)
 	if err != nil {
 		return fmt.Errorf("vault: failed to move secret: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("vault: failed to get rows affected: %w", err)
-	}
 	if rowsAffected == 0 {
 		return ErrSecretNotFound
 	}
@@ -572,6 +592,12 @@ func (v *Vault) MoveSecretToFolder(secretKey string, folderID *string) error {
 // If folderID is nil, returns unfiled secrets (folder_id IS NULL).
 // If recursive is true, includes secrets from subfolders.
 func (v *Vault) ListSecretsInFolder(folderID *string, recursive bool) ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		return v.listSecretsInFolder(folderID, recursive)
+	})
+}
+
+func (v *Vault) listSecretsInFolder(folderID *string, recursive bool) ([]*SecretEntry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
@@ -585,9 +611,9 @@ func (v *Vault) ListSecretsInFolder(folderID *string, recursive bool) ([]*Secret
 	if folderID == nil || *folderID == "" {
 		// Unfiled secrets
 		query = `
-			SELECT encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, created_at, updated_at
+			SELECT ` + secretMetadataColumns + `
 			FROM secrets
-			WHERE folder_id IS NULL
+			WHERE folder_id IS NULL AND deleted_at IS NULL
 			ORDER BY created_at
 		`
 	} else if recursive {
@@ -599,18 +625,18 @@ func (v *Vault) ListSecretsInFolder(folderID *string, recursive bool) ([]*Secret
 				SELECT f.id FROM folders f
 				INNER JOIN folder_tree ft ON f.parent_id = ft.id
 			)
-			SELECT encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, created_at, updated_at
+			SELECT ` + secretMetadataColumns + `
 			FROM secrets
-			WHERE folder_id IN (SELECT id FROM folder_tree)
+			WHERE folder_id IN (SELECT id FROM folder_tree) AND deleted_at IS NULL
 			ORDER BY created_at
 		`
 		args = []any{*folderID}
 	} else {
 		// Secrets directly in folder
 		query = `
-			SELECT encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, created_at, updated_at
+			SELECT ` + secretMetadataColumns + `
 			FROM secrets
-			WHERE folder_id = ?
+			WHERE folder_id = ? AND deleted_at IS NULL
 			ORDER BY created_at
 		`
 		args = []any{*folderID}
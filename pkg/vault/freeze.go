@@ -0,0 +1,138 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Freeze window errors
+var (
+	ErrSecretFrozen        = errors.New("vault: secret is frozen (break-glass required)")
+	ErrInvalidFreezeWindow = errors.New("vault: invalid freeze window")
+	ErrFreezeNotFound      = errors.New("vault: freeze window not found")
+)
+
+// FreezeWindow makes secrets matching Pattern (path.Match syntax, e.g.
+// "prod/*") append-only from Start until End: new keys can be added, but
+// existing ones cannot be overwritten or deleted without break-glass.
+type FreezeWindow struct {
+	Pattern string    `json:"pattern"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// Active reports whether w is in effect at t.
+func (w FreezeWindow) Active(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Matches reports whether key falls under w's pattern.
+func (w FreezeWindow) Matches(key string) bool {
+	ok, _ := path.Match(w.Pattern, key)
+	return ok
+}
+
+// AddFreezeWindow saves w. Windows that have already ended are dropped
+// at the same time.
+func (v *Vault) AddFreezeWindow(w FreezeWindow) error {
+	if w.Pattern == "" {
+		return fmt.Errorf("%w: pattern is required", ErrInvalidFreezeWindow)
+	}
+	if _, err := path.Match(w.Pattern, ""); err != nil {
+		return fmt.Errorf("%w: pattern %q: %v", ErrInvalidFreezeWindow, w.Pattern, err)
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalidFreezeWindow)
+	}
+	now := time.Now()
+	if !w.End.After(now) {
+		return fmt.Errorf("%w: end is in the past", ErrInvalidFreezeWindow)
+	}
+	w.Start, w.End = w.Start.UTC(), w.End.UTC()
+	w.Reason = strings.TrimSpace(w.Reason)
+
+	err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.FreezeWindows = slices.DeleteFunc(s.FreezeWindows, func(old FreezeWindow) bool {
+			return !old.End.After(now)
+		})
+		s.FreezeWindows = append(s.FreezeWindows, w)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_ = v.audit.Log(audit.OpVaultFreeze, v.auditSource(), audit.ResultSuccess, w.Pattern, nil,
+		map[string]interface{}{"action": "add", "start": w.Start, "end": w.End, "reason": w.Reason})
+	return nil
+}
+
+// RemoveFreezeWindow ends every freeze window with the given pattern early.
+// It returns ErrFreezeNotFound if there is none.
+func (v *Vault) RemoveFreezeWindow(pattern string) error {
+	err := v.UpdateSettings(func(s *VaultSettings) error {
+		n := len(s.FreezeWindows)
+		s.FreezeWindows = slices.DeleteFunc(s.FreezeWindows, func(w FreezeWindow) bool {
+			return w.Pattern == pattern
+		})
+		if len(s.FreezeWindows) == n {
+			return fmt.Errorf("%w: %s", ErrFreezeNotFound, pattern)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_ = v.audit.Log(audit.OpVaultFreeze, v.auditSource(), audit.ResultSuccess, pattern, nil,
+		map[string]interface{}{"action": "remove"})
+	return nil
+}
+
+// FreezeWindows returns the configured freeze windows, including ones that
+// have not started or have ended. The vault does not need to be unlocked.
+func (v *Vault) FreezeWindows() ([]FreezeWindow, error) {
+	settings, err := v.Settings()
+	if err != nil {
+		return nil, err
+	}
+	return settings.FreezeWindows, nil
+}
+
+// ActiveFreeze returns the freeze window that currently protects key, or
+// nil if key can be changed.
+func (v *Vault) ActiveFreeze(key string) *FreezeWindow {
+	settings, err := v.Settings()
+	if err != nil {
+		return nil
+	}
+	return settings.activeFreeze(key, time.Now())
+}
+
+func (s *VaultSettings) activeFreeze(key string, now time.Time) *FreezeWindow {
+	for i, w := range s.FreezeWindows {
+		if w.Active(now) && w.Matches(key) {
+			return &s.FreezeWindows[i]
+		}
+	}
+	return nil
+}
+
+// checkNotFrozen returns ErrSecretFrozen if an existing key is protected by
+// an active freeze window and opts does not allow break-glass.
+func (v *Vault) checkNotFrozen(key, op string, opts writeOptions) error {
+	if opts.breakGlass {
+		return nil
+	}
+	w := v.ActiveFreeze(key)
+	if w == nil {
+		return nil
+	}
+	_ = v.audit.LogDenied(op, v.auditSource(), key, "frozen by "+w.Pattern)
+	return fmt.Errorf("%w: %s matches freeze window %s until %s", ErrSecretFrozen, key, w.Pattern, w.End.Format(time.RFC3339))
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFreezeWindow(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for _, key := range []string{"prod/db", "dev/db"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("v1"), Tags: []string{"db"}}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+
+	now := time.Now()
+	if err := v.AddFreezeWindow(FreezeWindow{Pattern: "prod/*", Start: now, End: now.Add(-time.Hour)}); !errors.Is(err, ErrInvalidFreezeWindow) {
+		t.Errorf("expected ErrInvalidFreezeWindow for end before start, got %v", err)
+	}
+	if err := v.AddFreezeWindow(FreezeWindow{Pattern: "[", Start: now, End: now.Add(time.Hour)}); !errors.Is(err, ErrInvalidFreezeWindow) {
+		t.Errorf("expected ErrInvalidFreezeWindow for a bad pattern, got %v", err)
+	}
+	if err := v.AddFreezeWindow(FreezeWindow{Pattern: "dev/*", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}); err != nil {
+		t.Fatalf("AddFreezeWindow failed: %v", err)
+	}
+	if err := v.AddFreezeWindow(FreezeWindow{Pattern: "prod/*", Start: now.Add(-time.Minute), End: now.Add(time.Hour), Reason: "release week"}); err != nil {
+		t.Fatalf("AddFreezeWindow failed: %v", err)
+	}
+
+	// Existing frozen keys are append-only
+	if err := v.SetSecret("prod/db", &SecretEntry{Value: []byte("v2")}); !errors.Is(err, ErrSecretFrozen) {
+		t.Errorf("expected ErrSecretFrozen on overwrite, got %v", err)
+	}
+	if err := v.DeleteSecret("prod/db"); !errors.Is(err, ErrSecretFrozen) {
+		t.Errorf("expected ErrSecretFrozen on delete, got %v", err)
+	}
+	expires := now.Add(24 * time.Hour)
+	if _, err := v.BulkUpdate(BulkFilter{Tag: "db"}, BulkPatch{ExpiresAt: &expires}); !errors.Is(err, ErrSecretFrozen) {
+		t.Errorf("expected ErrSecretFrozen on bulk update, got %v", err)
+	}
+	if err := v.SetSecret("prod/new", &SecretEntry{Value: []byte("v1")}); err != nil {
+		t.Errorf("adding a key during a freeze failed: %v", err)
+	}
+	// A window that has not started does not protect anything
+	if err := v.SetSecret("dev/db", &SecretEntry{Value: []byte("v2")}); err != nil {
+		t.Errorf("SetSecret before the window started failed: %v", err)
+	}
+
+	if err := v.SetSecretBreakGlass("prod/db", &SecretEntry{Value: []byte("v2")}, "hotfix"); err != nil {
+		t.Fatalf("SetSecretBreakGlass failed: %v", err)
+	}
+	if entry, _ := v.GetSecret("prod/db"); string(entry.Value) != "v2" {
+		t.Errorf("break-glass update not applied: %q", entry.Value)
+	}
+
+	if err := v.RemoveFreezeWindow("staging/*"); !errors.Is(err, ErrFreezeNotFound) {
+		t.Errorf("expected ErrFreezeNotFound, got %v", err)
+	}
+	if err := v.RemoveFreezeWindow("prod/*"); err != nil {
+		t.Fatalf("RemoveFreezeWindow failed: %v", err)
+	}
+	if err := v.DeleteSecret("prod/db"); err != nil {
+		t.Errorf("DeleteSecret after unfreezing failed: %v", err)
+	}
+	windows, err := v.FreezeWindows()
+	if err != nil || len(windows) != 1 || windows[0].Pattern != "dev/*" {
+		t.Errorf("FreezeWindows = %+v, %v", windows, err)
+	}
+}
@@ -0,0 +1,49 @@
+package vault
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func FuzzValidateKeyName(f *testing.F) {
+	for _, seed := range []string{
+		"api_key", "aws/prod/db-password", "a", "../etc/passwd", "a/../b", ".hidden",
+		"-flag", "/abs", "trailing/", "_internal/x", "key\x1b[2J", "key\nname", "ключ", "a\xffb",
+		strings.Repeat("k", MaxKeyLength+1),
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, key string) {
+		err := validateKeyName(key)
+		if err != nil {
+			if !errors.Is(err, ErrKeyTooShort) && !errors.Is(err, ErrKeyTooLong) && !errors.Is(err, ErrKeyInvalid) {
+				t.Fatalf("validateKeyName(%q) returned an unexpected error: %v", key, err)
+			}
+			// Keys come from users and agents; errors must not echo
+			// control characters such as terminal escapes
+			if i := strings.IndexFunc(err.Error(), unicode.IsControl); i >= 0 {
+				t.Fatalf("validateKeyName(%q) error contains a control character: %q", key, err)
+			}
+			return
+		}
+
+		if len(key) < MinKeyLength || len(key) > MaxKeyLength {
+			t.Fatalf("accepted key of length %d", len(key))
+		}
+		for _, r := range key {
+			if !isValidKeyChar(r) {
+				t.Fatalf("accepted key %q with character %q", key, r)
+			}
+		}
+		if strings.Contains(key, "..") || key[0] == '.' || key[0] == '-' ||
+			strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+			t.Fatalf("accepted unsafe key %q", key)
+		}
+		if strings.HasPrefix(key, "_internal/") || strings.HasPrefix(key, "_system/") {
+			t.Fatalf("accepted reserved key %q", key)
+		}
+	})
+}
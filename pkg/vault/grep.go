@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// Grep scopes select which decrypted content Grep searches.
+const (
+	GrepValues = "values" // the primary value of each secret
+	GrepNotes  = "notes"  // metadata notes
+	GrepFields = "fields" // named fields (the default field is covered by values)
+)
+
+// ErrInvalidGrepScope is returned for a scope other than values, notes or fields.
+var ErrInvalidGrepScope = errors.New("vault: invalid grep scope")
+
+// grepContextRunes is how much text around a match GrepOptions.Context returns.
+const grepContextRunes = 12
+
+// GrepOptions controls Grep.
+type GrepOptions struct {
+	// In lists the scopes to search. Empty means GrepValues.
+	In []string
+	// Context includes the text around each match. Off by default so that
+	// searching never prints secret material.
+	Context bool
+}
+
+// GrepMatch reports where a pattern matched.
+type GrepMatch struct {
+	Key string
+	// Location is "value", "notes" or "field:<name>".
+	Location string
+	// Context is the text around the match, only set with GrepOptions.Context.
+	Context string
+}
+
+// Grep searches decrypted secret content in memory and returns the locations
+// that match pattern, sorted by key. Access rules are not applied since the
+// search runs locally for the vault owner; callers decide what to print.
+func (v *Vault) Grep(pattern *regexp.Regexp, opts GrepOptions) ([]GrepMatch, error) {
+	return runValue(v, Op{Name: OpGrep}, func() ([]GrepMatch, error) {
+		return v.grep(pattern, opts)
+	})
+}
+
+func (v *Vault) grep(pattern *regexp.Regexp, opts GrepOptions) ([]GrepMatch, error) {
+	scopes := make(map[string]bool)
+	for _, in := range opts.In {
+		switch in {
+		case GrepValues, GrepNotes, GrepFields:
+			scopes[in] = true
+		default:
+			return nil, fmt.Errorf("%w: %q (use values, notes or fields)", ErrInvalidGrepScope, in)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes[GrepValues] = true
+	}
+
+	keys, err := v.listSecrets()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	var matches []GrepMatch
+	for _, key := range keys {
+		entry, err := v.getSecret(key, readOptions{inspect: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret '%s': %w", key, err)
+		}
+
+		check := func(location, text string) {
+			loc := pattern.FindStringIndex(text)
+			if loc == nil {
+				return
+			}
+			m := GrepMatch{Key: key, Location: location}
+			if opts.Context {
+				m.Context = grepContext(text, loc)
+			}
+			matches = append(matches, m)
+		}
+
+		if scopes[GrepValues] {
+			check("value", string(entry.Value))
+		}
+		if scopes[GrepNotes] && entry.Metadata != nil {
+			check("notes", entry.Metadata.Notes)
+		}
+		if scopes[GrepFields] {
+			names := make([]string, 0, len(entry.Fields))
+			for name := range entry.Fields {
+				if name != DefaultFieldName {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				check("field:"+name, entry.Fields[name].Value)
+			}
+		}
+		crypto.SecureWipe(entry.Value)
+	}
+
+	_ = v.audit.Log(audit.OpSecretGrep, v.auditSource(), audit.ResultSuccess, "", nil, map[string]interface{}{
+		"in":      strings.Join(opts.In, ","),
+		"matches": len(matches),
+		"context": opts.Context,
+	})
+	return matches, nil
+}
+
+// grepContext returns the match at loc with a few runes on either side.
+func grepContext(text string, loc []int) string {
+	before := []rune(text[:loc[0]])
+	after := []rune(text[loc[1]:])
+	prefix, suffix := "", ""
+	if len(before) > grepContextRunes {
+		before = before[len(before)-grepContextRunes:]
+		prefix = "..."
+	}
+	if len(after) > grepContextRunes {
+		after = after[:grepContextRunes]
+		suffix = "..."
+	}
+	ctx := prefix + string(before) + text[loc[0]:loc[1]] + string(after) + suffix
+	return strings.ReplaceAll(ctx, "\n", " ")
+}
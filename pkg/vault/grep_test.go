@@ -0,0 +1,75 @@
+package vault
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	secrets := map[string]*SecretEntry{
+		"stripe/live": {Value: []byte("sk_live_abcdef4f2a")},
+		"stripe/test": {Value: []byte("sk_test_999999"), Metadata: &SecretMetadata{Notes: "rotated after 4f2a leak"}},
+		"db/prod": {Fields: map[string]Field{
+			"username": {Value: "admin"},
+			"password": {Value: "pw-4f2a-xyz", Sensitive: true},
+		}},
+	}
+	for key, entry := range secrets {
+		if err := v.SetSecret(key, entry); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+
+	pattern := regexp.MustCompile(regexp.QuoteMeta("4f2a"))
+
+	matches, err := v.Grep(pattern, GrepOptions{})
+	if err != nil {
+		t.Fatalf("Grep failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Key != "stripe/live" || matches[0].Location != "value" {
+		t.Errorf("values: unexpected matches %+v", matches)
+	}
+	if matches[0].Context != "" {
+		t.Error("context must be empty unless requested")
+	}
+
+	matches, err = v.Grep(pattern, GrepOptions{In: []string{GrepNotes, GrepFields}, Context: true})
+	if err != nil {
+		t.Fatalf("Grep failed: %v", err)
+	}
+	want := []GrepMatch{
+		{Key: "db/prod", Location: "field:password", Context: "pw-4f2a-xyz"},
+		{Key: "stripe/test", Location: "notes", Context: "...tated after 4f2a leak"},
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("notes,fields: expected %d matches, got %+v", len(want), matches)
+	}
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("match %d = %+v, want %+v", i, matches[i], want[i])
+		}
+	}
+
+	if _, err := v.Grep(pattern, GrepOptions{In: []string{"urls"}}); !errors.Is(err, ErrInvalidGrepScope) {
+		t.Errorf("expected ErrInvalidGrepScope, got %v", err)
+	}
+}
+
+func TestGrepContext(t *testing.T) {
+	text := "0123456789abcdefghijTOKENklmnopqrstuvwxyz"
+	loc := regexp.MustCompile("TOKEN").FindStringIndex(text)
+	if got, want := grepContext(text, loc), "...89abcdefghijTOKENklmnopqrstuv..."; got != want {
+		t.Errorf("grepContext = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// HealthReport is the result of a lightweight health check on an unlocked vault.
+type HealthReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Healthy   bool      `json:"healthy"`
+	Problems  []string  `json:"problems,omitempty"`
+}
+
+// QuickCheck runs cheap health checks suitable for periodic use while the
+// vault stays unlocked (e.g. in the agent):
+//  1. PRAGMA quick_check on the open database
+//  2. Audit chain head verification (newest record only)
+//  3. Permission scan of the vault directory and critical files
+//
+// Unlike CheckIntegrity it reuses the open database connection and does not
+// re-read the whole audit log. Problems are reported in the result; an error
+// is returned only when the check itself cannot run.
+func (v *Vault) QuickCheck() (*HealthReport, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	report := &HealthReport{CheckedAt: time.Now(), Healthy: true}
+	addProblem := func(format string, args ...any) {
+		report.Healthy = false
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// 1. Database structure
+	var quickCheck string
+	if err := v.db.QueryRow("PRAGMA quick_check").Scan(&quickCheck); err != nil {
+		addProblem("database quick_check failed: %v", err)
+	} else if quickCheck != "ok" {
+		addProblem("database quick_check returned: %s", quickCheck)
+	}
+
+	// 2. Audit chain head
+	if err := v.audit.VerifyHead(); err != nil {
+		addProblem("%v", err)
+	}
+
+	// 3. Permissions (group/other must have no access; not meaningful on Windows)
+	if runtime.GOOS == "windows" {
+		return report, nil
+	}
+	if info, err := os.Stat(v.path); err != nil {
+		addProblem("vault directory not accessible: %v", err)
+	} else if perm := info.Mode().Perm(); perm&0077 != 0 {
+		addProblem("vault directory has insecure permissions %04o (expected 0700)", perm)
+	}
+	files := []string{SaltFileName, MetaFileName, DBFileName}
+	for _, ns := range v.shardFiles() {
+		files = append(files, filepath.Join(ShardsDirName, ns+".db"))
+	}
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(v.path, name))
+		if err != nil {
+			addProblem("%s not accessible: %v", name, err)
+			continue
+		}
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			addProblem("%s has insecure permissions %04o (expected 0600)", name, perm)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,282 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxHistoryEntries is the number of history entries kept per secret.
+// Older entries are pruned when a new version is saved.
+const MaxHistoryEntries = 50
+
+// Similarity buckets describe how much the secret values changed between
+// two versions without revealing anything about the values themselves.
+const (
+	SimilarityIdentical = "identical" // no field value changed (metadata-only update)
+	SimilarityHigh      = "high"      // small edit, e.g. one character changed
+	SimilarityMedium    = "medium"    // partially rewritten
+	SimilarityLow       = "low"       // effectively a new value
+)
+
+// maxDistanceCells bounds the edit-distance table size. Larger values fall
+// back to a prefix/suffix estimate.
+const maxDistanceCells = 1 << 22
+
+const secretHistoryTableSQL = `
+	CREATE TABLE IF NOT EXISTS secret_history (
+		id INTEGER PRIMARY KEY,
+		key_hash TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		encrypted_summary BLOB NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (key_hash, version)
+	)
+`
+
+const secretHistoryIndexSQL = "CREATE INDEX IF NOT EXISTS idx_secret_history_key ON secret_history(key_hash, version)"
+
+// ChangeSummary describes how a secret changed in one version.
+// It never contains secret values: only field names, the total length delta
+// and a coarse similarity bucket.
+type ChangeSummary struct {
+	// Created is true for the first recorded version of a secret.
+	Created bool `json:"created,omitempty"`
+	// LengthDelta is the change in total field value length (bytes).
+	LengthDelta int `json:"length_delta"`
+	// ChangedFields lists fields whose value changed.
+	ChangedFields []string `json:"changed_fields,omitempty"`
+	// AddedFields lists fields that did not exist in the previous version.
+	AddedFields []string `json:"added_fields,omitempty"`
+	// RemovedFields lists fields that no longer exist.
+	RemovedFields []string `json:"removed_fields,omitempty"`
+	// Similarity is one of the Similarity* buckets.
+	Similarity string `json:"similarity"`
+}
+
+// HistoryEntry is one saved version of a secret.
+type HistoryEntry struct {
+	Version   int
+	ChangedAt time.Time
+	Summary   ChangeSummary
+}
+
+// ValueChanged reports whether any field value was changed, added or removed.
+func (c *ChangeSummary) ValueChanged() bool {
+	return len(c.ChangedFields) > 0 || len(c.AddedFields) > 0 || len(c.RemovedFields) > 0
+}
+
+// summarizeChange compares two field sets. prev is nil for a new secret.
+func summarizeChange(prev, next map[string]Field) ChangeSummary {
+	summary := ChangeSummary{Created: prev == nil}
+
+	var distance, span int
+	for name, f := range next {
+		old, ok := prev[name]
+		summary.LengthDelta += len(f.Value)
+		if !ok {
+			summary.AddedFields = append(summary.AddedFields, name)
+			distance += len(f.Value)
+			span += len(f.Value)
+			continue
+		}
+		summary.LengthDelta -= len(old.Value)
+		if old.Value != f.Value {
+			summary.ChangedFields = append(summary.ChangedFields, name)
+			distance += editDistance(old.Value, f.Value)
+			span += max(len(old.Value), len(f.Value))
+		}
+	}
+	for name, old := range prev {
+		if _, ok := next[name]; !ok {
+			summary.RemovedFields = append(summary.RemovedFields, name)
+			summary.LengthDelta -= len(old.Value)
+			distance += len(old.Value)
+			span += len(old.Value)
+		}
+	}
+	sort.Strings(summary.ChangedFields)
+	sort.Strings(summary.AddedFields)
+	sort.Strings(summary.RemovedFields)
+
+	summary.Similarity = similarityBucket(distance, span, summary.ValueChanged())
+	return summary
+}
+
+// similarityBucket maps a normalized edit distance to a bucket.
+func similarityBucket(distance, span int, changed bool) string {
+	if !changed {
+		return SimilarityIdentical
+	}
+	if span == 0 {
+		return SimilarityLow
+	}
+	ratio := 1 - float64(distance)/float64(span)
+	switch {
+	case ratio >= 0.75:
+		return SimilarityHigh
+	case ratio >= 0.4:
+		return SimilarityMedium
+	default:
+		return SimilarityLow
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b in bytes.
+// For very large inputs it returns an upper bound based on the common
+// prefix and suffix, which is sufficient for bucketing.
+func editDistance(a, b string) int {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if len(a) == 0 || len(b) == 0 || len(a)*len(b) > maxDistanceCells {
+		return max(len(a), len(b))
+	}
+
+	prevRow := make([]int, len(b)+1)
+	row := make([]int, len(b)+1)
+	for j := range prevRow {
+		prevRow[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min(prevRow[j]+1, row[j-1]+1, prevRow[j-1]+cost)
+		}
+		prevRow, row = row, prevRow
+	}
+	return prevRow[len(b)]
+}
+
+// loadFieldsTx decrypts the current fields of a secret inside a transaction.
+// Returns nil, nil if the secret does not exist.
+func (v *Vault) loadFieldsTx(tx *sql.Tx, keyHash string) (map[string]Field, error) {
+	var encryptedValue, encryptedFields []byte
+	err := tx.QueryRow("SELECT encrypted_value, encrypted_fields FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).
+		Scan(&encryptedValue, &encryptedFields)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encryptedFields) > 0 {
+		fieldsJSON, err := v.decryptWithNonce(encryptedFields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt fields: %w", err)
+		}
+		var fields map[string]Field
+		if err := json.Unmarshal(fieldsJSON, &fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields: %w", err)
+		}
+		return fields, nil
+	}
+	if len(encryptedValue) > 0 {
+		value, err := v.decryptWithNonce(encryptedValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt value: %w", err)
+		}
+		return ConvertSingleValueToFields(value), nil
+	}
+	return map[string]Field{}, nil
+}
+
+// recordHistoryTx appends a history entry for keyHash and prunes old entries.
+func (v *Vault) recordHistoryTx(tx *sql.Tx, keyHash string, summary ChangeSummary) error {
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change summary: %w", err)
+	}
+	encryptedSummary, err := v.encryptWithNonce(summaryJSON)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt change summary: %w", err)
+	}
+
+	var version int
+	err = tx.QueryRow("SELECT COALESCE(MAX(version), 0) + 1 FROM secret_history WHERE key_hash = ?", keyHash).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to get next version: %w", err)
+	}
+
+	_, err = tx.Exec("INSERT INTO secret_history (key_hash, version, encrypted_summary) VALUES (?, ?, ?)",
+		keyHash, version, encryptedSummary)
+	if err != nil {
+		return fmt.Errorf("failed to insert history: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM secret_history WHERE key_hash = ? AND version <= ?", keyHash, version-MaxHistoryEntries)
+	if err != nil {
+		return fmt.Errorf("failed to prune history: %w", err)
+	}
+	return nil
+}
+
+// GetSecretHistory returns the recorded versions of a secret, newest first.
+// Secrets saved before history tracking existed start at their next update.
+func (v *Vault) GetSecretHistory(key string) ([]*HistoryEntry, error) {
+	return runValue(v, Op{Name: OpList, Key: key}, func() ([]*HistoryEntry, error) {
+		return v.getSecretHistory(key)
+	})
+}
+
+func (v *Vault) getSecretHistory(key string) ([]*HistoryEntry, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	keyHash := v.hashKey(key)
+
+	var exists int
+	err := v.db.QueryRow("SELECT 1 FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query secret: %w", err)
+	}
+
+	rows, err := v.db.Query(`
+		SELECT version, encrypted_summary, created_at FROM secret_history
+		WHERE key_hash = ? ORDER BY version DESC
+	`, keyHash)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var encryptedSummary []byte
+		if err := rows.Scan(&entry.Version, &encryptedSummary, &entry.ChangedAt); err != nil {
+			return nil, fmt.Errorf("vault: failed to scan history: %w", err)
+		}
+		summaryJSON, err := v.decryptWithNonce(encryptedSummary)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to decrypt change summary: %w", err)
+		}
+		if err := json.Unmarshal(summaryJSON, &entry.Summary); err != nil {
+			return nil, fmt.Errorf("vault: failed to unmarshal change summary: %w", err)
+		}
+		history = append(history, &entry)
+	}
+	return history, rows.Err()
+}
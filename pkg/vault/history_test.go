@@ -0,0 +1,163 @@
+package vault
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"password1", "password2", 1},
+		{"prefix-middle-suffix", "prefix-MIDDLE-suffix", 6},
+	}
+	for _, tc := range tests {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+
+	// Large inputs fall back to an upper bound
+	a := strings.Repeat("a", 4000)
+	b := strings.Repeat("b", 4000)
+	if got := editDistance(a, b); got != 4000 {
+		t.Errorf("expected fallback distance 4000, got %d", got)
+	}
+}
+
+func TestSummarizeChange(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		s := summarizeChange(nil, map[string]Field{"value": {Value: "abc"}})
+		if !s.Created || s.LengthDelta != 3 || !reflect.DeepEqual(s.AddedFields, []string{"value"}) {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		fields := map[string]Field{"value": {Value: "abc"}}
+		s := summarizeChange(fields, fields)
+		if s.ValueChanged() || s.Similarity != SimilarityIdentical {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("small edit", func(t *testing.T) {
+		s := summarizeChange(
+			map[string]Field{"value": {Value: "correct-horse-battery-1"}},
+			map[string]Field{"value": {Value: "correct-horse-battery-2"}},
+		)
+		if s.Similarity != SimilarityHigh || !reflect.DeepEqual(s.ChangedFields, []string{"value"}) || s.LengthDelta != 0 {
+			t.Errorf("unexpected summary: %+v", s)
+		}
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		s := summarizeChange(
+			map[string]Field{"password": {Value: "Xk29fjQ0pLmz"}, "user": {Value: "admin"}},
+			map[string]Field{"password": {Value: "v7Tq1bN8wRcYe4"}, "host": {Value: "db"}},
+		)
+		if s.Similarity != SimilarityLow {
+			t.Errorf("expected low similarity, got %s", s.Similarity)
+		}
+		if !reflect.DeepEqual(s.ChangedFields, []string{"password"}) ||
+			!reflect.DeepEqual(s.AddedFields, []string{"host"}) ||
+			!reflect.DeepEqual(s.RemovedFields, []string{"user"}) {
+			t.Errorf("unexpected field lists: %+v", s)
+		}
+		if s.LengthDelta != (14+2)-(12+5) {
+			t.Errorf("unexpected length delta %d", s.LengthDelta)
+		}
+	})
+}
+
+func TestSecretHistory(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if _, err := v.GetSecretHistory("missing"); err != ErrSecretNotFound {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+
+	for _, value := range []string{"first-value", "first-value", "second-VALUE!"} {
+		if err := v.SetSecret("api/key", &SecretEntry{Value: []byte(value)}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+
+	history, err := v.GetSecretHistory("api/key")
+	if err != nil {
+		t.Fatalf("GetSecretHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(history))
+	}
+	if history[0].Version != 3 || history[2].Version != 1 {
+		t.Errorf("expected newest first, got versions %d..%d", history[0].Version, history[2].Version)
+	}
+	if !history[2].Summary.Created {
+		t.Error("expected first version to be marked created")
+	}
+	if history[1].Summary.Similarity != SimilarityIdentical {
+		t.Errorf("expected identical for unchanged update, got %s", history[1].Summary.Similarity)
+	}
+	if history[0].Summary.LengthDelta != 2 || !history[0].Summary.ValueChanged() {
+		t.Errorf("unexpected latest summary: %+v", history[0].Summary)
+	}
+
+	// Deleting the secret removes its history
+	if err := v.DeleteSecret("api/key"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if err := v.SetSecret("api/key", &SecretEntry{Value: []byte("again")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	history, err = v.GetSecretHistory("api/key")
+	if err != nil {
+		t.Fatalf("GetSecretHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Version != 1 {
+		t.Errorf("expected fresh history after delete, got %d entries", len(history))
+	}
+}
+
+func TestSecretHistoryPruning(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for i := 0; i < MaxHistoryEntries+5; i++ {
+		if err := v.SetSecret("k", &SecretEntry{Value: []byte(strings.Repeat("x", i+1))}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+	history, err := v.GetSecretHistory("k")
+	if err != nil {
+		t.Fatalf("GetSecretHistory failed: %v", err)
+	}
+	if len(history) != MaxHistoryEntries {
+		t.Errorf("expected %d entries, got %d", MaxHistoryEntries, len(history))
+	}
+	if history[0].Version != MaxHistoryEntries+5 {
+		t.Errorf("expected newest version %d, got %d", MaxHistoryEntries+5, history[0].Version)
+	}
+}
@@ -0,0 +1,73 @@
+package vault
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Immutable secret errors
+var (
+	ErrSecretImmutable    = errors.New("vault: secret is immutable (break-glass required)")
+	ErrBreakGlassNoReason = errors.New("vault: break-glass requires a reason")
+)
+
+// writeOptions controls which write protections SetSecret/DeleteSecret enforce.
+type writeOptions struct {
+	breakGlass bool   // allow changing immutable and frozen secrets
+	reason     string // recorded in the break-glass audit event
+	canary     bool   // mark the secret as a canary (see CreateCanary)
+	// checkRevision makes the write fail unless the stored secret has
+	// revision (see SetSecretIfRevision)
+	checkRevision bool
+	revision      string
+}
+
+// SetSecretBreakGlass saves a secret like SetSecret but is allowed to
+// overwrite an immutable or frozen secret. The reason is recorded in a separate
+// secret.break_glass audit event. Whether the secret stays immutable is
+// decided by entry.Immutable.
+func (v *Vault) SetSecretBreakGlass(key string, entry *SecretEntry, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrBreakGlassNoReason
+	}
+	return v.setSecret(key, entry, writeOptions{breakGlass: true, reason: reason})
+}
+
+// DeleteSecretBreakGlass deletes a secret like DeleteSecret but is allowed
+// to delete an immutable or frozen secret. The reason is recorded in a separate
+// secret.break_glass audit event.
+func (v *Vault) DeleteSecretBreakGlass(key, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrBreakGlassNoReason
+	}
+	return v.deleteSecret(key, writeOptions{breakGlass: true, reason: reason})
+}
+
+// checkMutableTx returns ErrSecretImmutable if the stored secret is immutable,
+// or ErrSecretFrozen if it is in an active freeze window, and opts does not
+// allow break-glass. Missing secrets are mutable.
+func (v *Vault) checkMutableTx(tx *sql.Tx, keyHash, key, op string, opts writeOptions) error {
+	var immutable bool
+	err := tx.QueryRow("SELECT immutable FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).Scan(&immutable)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("vault: failed to read secret: %w", err)
+	}
+	if immutable && !opts.breakGlass {
+		_ = v.audit.LogDenied(op, v.auditSource(), key, "secret is immutable")
+		return ErrSecretImmutable
+	}
+	return v.checkNotFrozen(key, op, opts)
+}
+
+// logBreakGlass records a break-glass change of an immutable or frozen secret.
+func (v *Vault) logBreakGlass(key, action, reason string) {
+	_ = v.audit.Log(audit.OpSecretBreakGlass, v.auditSource(), audit.ResultSuccess, key, nil,
+		map[string]interface{}{"action": action, "reason": reason})
+}
@@ -0,0 +1,82 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+func TestImmutableSecret(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("ca/root", &SecretEntry{Value: []byte("v1"), Tags: []string{"pki"}, Immutable: true}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("ca/other", &SecretEntry{Value: []byte("x")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	entry, err := v.GetSecret("ca/root")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if !entry.Immutable {
+		t.Error("expected Immutable to round-trip")
+	}
+
+	if err := v.SetSecret("ca/root", &SecretEntry{Value: []byte("v2")}); !errors.Is(err, ErrSecretImmutable) {
+		t.Errorf("expected ErrSecretImmutable on update, got %v", err)
+	}
+	if err := v.DeleteSecret("ca/root"); !errors.Is(err, ErrSecretImmutable) {
+		t.Errorf("expected ErrSecretImmutable on delete, got %v", err)
+	}
+	if err := v.DeprecateSecret("ca/root", "ca/other"); !errors.Is(err, ErrSecretImmutable) {
+		t.Errorf("expected ErrSecretImmutable on deprecate, got %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	if _, err := v.BulkUpdate(BulkFilter{Tag: "pki"}, BulkPatch{ExpiresAt: &expires}); !errors.Is(err, ErrSecretImmutable) {
+		t.Errorf("expected ErrSecretImmutable on bulk update, got %v", err)
+	}
+
+	if err := v.SetSecretBreakGlass("ca/root", &SecretEntry{Value: []byte("v2"), Immutable: true}, " "); !errors.Is(err, ErrBreakGlassNoReason) {
+		t.Errorf("expected ErrBreakGlassNoReason, got %v", err)
+	}
+	if err := v.SetSecretBreakGlass("ca/root", &SecretEntry{Value: []byte("v2"), Immutable: true}, "rotation after incident"); err != nil {
+		t.Fatalf("SetSecretBreakGlass failed: %v", err)
+	}
+	entry, _ = v.GetSecret("ca/root")
+	if string(entry.Value) != "v2" || !entry.Immutable {
+		t.Errorf("expected break-glass update to apply and stay immutable, got %q immutable=%v", entry.Value, entry.Immutable)
+	}
+
+	events, err := v.Audit().ListEvents(0, time.Time{})
+	if err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	found := false
+	for _, e := range events {
+		if e.Operation == audit.OpSecretBreakGlass && e.Context["reason"] == "rotation after incident" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a secret.break_glass audit event with the reason")
+	}
+
+	if err := v.DeleteSecretBreakGlass("ca/root", "decommissioned"); err != nil {
+		t.Fatalf("DeleteSecretBreakGlass failed: %v", err)
+	}
+	if _, err := v.GetSecret("ca/root"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected secret to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// VaultInfo describes a vault for inventories of several vaults.
+type VaultInfo struct {
+	ID            string            `json:"id"`
+	Path          string            `json:"path"`
+	FormatVersion string            `json:"format_version"` // version of vault.meta
+	CreatedAt     time.Time         `json:"created_at"`
+	SchemaVersion int               `json:"schema_version"` // of vault.db
+	Cipher        string            `json:"cipher"`
+	KDF           crypto.KDFParams  `json:"kdf"`
+	Features      map[string]string `json:"features,omitempty"`
+	KeyRotatedAt  *time.Time        `json:"key_rotated_at,omitempty"`
+	// Supported is false if the vault uses a feature or schema this binary
+	// does not support.
+	Supported bool `json:"supported"`
+}
+
+// Info returns the identity and format of the vault. The vault does not
+// need to be unlocked. A vault created before IDs were recorded has no ID
+// until it is next unlocked.
+func (v *Vault) Info() (*VaultInfo, error) {
+	if !v.exists() {
+		return nil, ErrVaultNotFound
+	}
+	meta, err := v.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	kdf, err := v.kdfParams()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(v.path, DBFileName)+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to open database: %w", err)
+	}
+	defer db.Close()
+	schema, err := getSchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &VaultInfo{
+		ID:            meta.ID,
+		Path:          v.path,
+		FormatVersion: meta.Version,
+		CreatedAt:     meta.CreatedAt,
+		SchemaVersion: schema,
+		Cipher:        meta.cipher(),
+		KDF:           kdf,
+		Features:      meta.Features,
+		Supported:     schema <= CurrentSchemaVersion && meta.checkFeatures() == nil,
+	}
+	if !meta.KeyRotatedAt.IsZero() {
+		info.KeyRotatedAt = &meta.KeyRotatedAt
+	}
+	return info, nil
+}
@@ -0,0 +1,58 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+func TestInfo(t *testing.T) {
+	if _, err := New(t.TempDir()).Info(); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("expected ErrVaultNotFound, got %v", err)
+	}
+
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	info, err := v.Info()
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	id, err := v.ID()
+	if err != nil {
+		t.Fatalf("ID failed: %v", err)
+	}
+	if info.ID == "" || info.ID != id {
+		t.Errorf("ID = %q, want %q", info.ID, id)
+	}
+	if info.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", info.SchemaVersion, CurrentSchemaVersion)
+	}
+	if info.Cipher != CipherAES256GCM || info.Features[FeatureCipher] != CipherAES256GCM {
+		t.Errorf("Cipher = %q, features %v", info.Cipher, info.Features)
+	}
+	if !info.Supported {
+		t.Error("expected the vault to be supported")
+	}
+
+	// Audit events name the vault
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	events, err := v.Audit().ListEvents(0, time.Time{})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	for _, e := range events {
+		if e.Org == nil || e.Org.VaultID != id {
+			t.Errorf("%s event has org %+v, want vault ID %s", e.Operation, e.Org, id)
+		}
+	}
+	if len(events) == 0 || events[len(events)-1].Operation != audit.OpVaultUnlock {
+		t.Errorf("expected the unlock to be logged, got %d events", len(events))
+	}
+}
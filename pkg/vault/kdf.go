@@ -0,0 +1,203 @@
+package vault
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// KDFParams returns the Argon2id parameters that protect the master
+// password. It does not require the vault to be unlocked.
+func (v *Vault) KDFParams() (crypto.KDFParams, error) {
+	return v.kdfParams()
+}
+
+// kdfParams reads the KDF parameters from vault.meta. Vaults created before
+// the parameters were recorded, or whose meta file is missing or unreadable,
+// use the defaults. Recorded parameters outside the accepted bounds mean the
+// file was damaged or tampered with.
+func (v *Vault) kdfParams() (crypto.KDFParams, error) {
+	meta, err := v.readMeta()
+	if err != nil || meta.KDF == nil {
+		return crypto.DefaultKDFParams(), nil
+	}
+	if err := meta.KDF.Validate(); err != nil {
+		return crypto.KDFParams{}, fmt.Errorf("%w: %v", ErrVaultCorrupted, err)
+	}
+	return *meta.KDF, nil
+}
+
+// unwrapDEK derives the KEK from passwordBytes and salt and decrypts the
+// DEK. If a change of KDF parameters was interrupted after the database
+// was updated, the pending parameters unwrap it and the change is
+// completed; if it was interrupted before, the pending parameters are
+// discarded.
+func (v *Vault) unwrapDEK(passwordBytes, salt, encryptedDEK, nonce []byte) ([]byte, error) {
+	params, err := v.kdfParams()
+	if err != nil {
+		return nil, err
+	}
+	kek := crypto.DeriveKeyWithParams(passwordBytes, salt, params)
+	dek, err := crypto.Decrypt(kek, encryptedDEK, nonce)
+	crypto.SecureWipe(kek)
+
+	meta, metaErr := v.readMeta()
+	if metaErr != nil || meta.KDFPending == nil || meta.KDFPending.Validate() != nil {
+		return dek, err
+	}
+	if err == nil {
+		meta.KDFPending = nil
+		if err := v.writeMeta(meta); err != nil {
+			v.warn(EventWarning, "failed to discard interrupted key derivation change: %v", err)
+		}
+		return dek, nil
+	}
+
+	pending := *meta.KDFPending
+	kek = crypto.DeriveKeyWithParams(passwordBytes, salt, pending)
+	dek, err = crypto.Decrypt(kek, encryptedDEK, nonce)
+	crypto.SecureWipe(kek)
+	if err != nil {
+		return nil, err
+	}
+	meta.KDF, meta.KDFPending = &pending, nil
+	if err := v.writeMeta(meta); err != nil {
+		v.warn(EventWarning, "failed to complete interrupted key derivation change: %v", err)
+	}
+	return dek, nil
+}
+
+// SetKDFParams re-wraps the DEK with a key derived from the master
+// password under params, as chosen by `secretctl kdf tune`. Parameters
+// weaker than the defaults are refused, since unlocking would raise them
+// again. masterPassword is required; a wrong one counts as a failed unlock
+// attempt. The secrets themselves are not re-encrypted.
+func (v *Vault) SetKDFParams(masterPassword string, params crypto.KDFParams) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if params.WeakerThan(crypto.DefaultKDFParams()) {
+		return fmt.Errorf("%w: %s is weaker than the defaults (%s)", crypto.ErrInvalidKDFParams, params, crypto.DefaultKDFParams())
+	}
+	if remaining, err := v.checkCooldown(); err != nil {
+		if errors.Is(err, ErrCooldownActive) {
+			return fmt.Errorf("%w: please wait %v", ErrCooldownActive, remaining.Round(time.Second))
+		}
+		return err
+	}
+
+	passwordBytes := []byte(masterPassword)
+	defer crypto.SecureWipe(passwordBytes)
+	var salt, encryptedDEK, nonce []byte
+	err := v.db.QueryRow("SELECT salt, encrypted_dek, dek_nonce FROM vault_keys WHERE id = 1").
+		Scan(&salt, &encryptedDEK, &nonce)
+	if err != nil {
+		return fmt.Errorf("vault: failed to read vault keys: %w", err)
+	}
+	stored, err := v.unwrapDEK(passwordBytes, salt, encryptedDEK, nonce)
+	if err != nil || subtle.ConstantTimeCompare(stored, v.dek) != 1 {
+		crypto.SecureWipe(stored)
+		if _, recordErr := v.recordFailedAttempt(); recordErr != nil {
+			v.warn(EventWarning, "failed to record unlock attempt: %v", recordErr)
+		}
+		_ = v.audit.LogError(audit.OpVaultKDF, v.auditSource(), "", "AUTH_FAILED", "invalid master password")
+		return ErrInvalidPassword
+	}
+	crypto.SecureWipe(stored)
+
+	if params.Version == 0 {
+		params.Version = crypto.DefaultKDFParams().Version
+	}
+	if err := v.changeKDF(passwordBytes, params); err != nil {
+		_ = v.audit.LogError(audit.OpVaultKDF, v.auditSource(), "", "KDF_CHANGE_FAILED", err.Error())
+		return err
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultKDF, v.auditSource(), "")
+	return nil
+}
+
+// upgradeKDF raises KDF parameters weaker than the defaults, such as those
+// of a vault created by an older release or on a constrained machine,
+// after passwordBytes unlocked the vault, and records the parameters of
+// vaults created before they were recorded. Read-only vaults keep theirs.
+// Caller must hold v.mu.
+func (v *Vault) upgradeKDF(passwordBytes []byte) {
+	if v.checkWritable() != nil {
+		return
+	}
+	meta, err := v.readMeta()
+	if err != nil {
+		return
+	}
+	defaults := crypto.DefaultKDFParams()
+	if meta.KDF == nil {
+		meta.KDF = &defaults
+		if err := v.writeMeta(meta); err != nil {
+			v.warn(EventWarning, "failed to record key derivation parameters: %v", err)
+		}
+		return
+	}
+	params, err := v.kdfParams()
+	if err != nil || !params.WeakerThan(defaults) {
+		return
+	}
+	upgraded := params.Raise(defaults)
+	if err := v.changeKDF(passwordBytes, upgraded); err != nil {
+		v.warn(EventWarning, "failed to upgrade key derivation parameters: %v", err)
+		return
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultKDF, v.auditSource(), "")
+	v.warn(EventWarning, "key derivation parameters upgraded from %s to %s", params, upgraded)
+}
+
+// changeKDF re-wraps the DEK under params with a new salt. vault.meta
+// records params as pending while vault_keys is updated, so that
+// unwrapDEK can finish or discard a change interrupted by a crash. Caller
+// must hold v.mu with the vault unlocked.
+func (v *Vault) changeKDF(passwordBytes []byte, params crypto.KDFParams) error {
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	meta.KDFPending = &params
+	if err := v.writeMeta(meta); err != nil {
+		return err
+	}
+
+	salt := make([]byte, SaltLength)
+	if _, err = rand.Read(salt); err == nil {
+		kek := crypto.DeriveKeyWithParams(passwordBytes, salt, params)
+		var wrapped, nonce []byte
+		wrapped, nonce, err = crypto.Encrypt(kek, v.dek)
+		crypto.SecureWipe(kek)
+		if err == nil {
+			_, err = v.db.Exec("UPDATE vault_keys SET salt = ?, encrypted_dek = ?, dek_nonce = ? WHERE id = 1", salt, wrapped, nonce)
+		}
+	}
+	if err != nil {
+		// If this fails too, the next unlock discards the pending parameters
+		meta.KDFPending = nil
+		_ = v.writeMeta(meta)
+		return fmt.Errorf("vault: failed to re-wrap DEK: %w", err)
+	}
+
+	meta.KDF, meta.KDFPending = &params, nil
+	if err := v.writeMeta(meta); err != nil {
+		return fmt.Errorf("vault: failed to record key derivation parameters, the next unlock records them: %w", err)
+	}
+	return nil
+}
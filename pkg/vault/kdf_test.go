@@ -0,0 +1,211 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+func TestInitWithKDF(t *testing.T) {
+	dir := t.TempDir()
+	params := crypto.KDFParams{Memory: crypto.MinKDFMemory, Time: 1, Threads: 1, Version: crypto.DefaultKDFParams().Version}
+
+	v := New(dir)
+	if err := v.InitWithKDF("testpassword123", crypto.KDFParams{Memory: 1024, Time: 1, Threads: 1}); !errors.Is(err, crypto.ErrInvalidKDFParams) {
+		t.Errorf("expected ErrInvalidKDFParams, got %v", err)
+	}
+	if err := v.InitWithKDF("testpassword123", params); err != nil {
+		t.Fatalf("InitWithKDF failed: %v", err)
+	}
+	if got, err := v.KDFParams(); err != nil || got != params {
+		t.Errorf("KDFParams = %+v, %v; want %+v", got, err, params)
+	}
+
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.ChangePassword("testpassword123", "newpassword456"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	v.Lock()
+	if err := v.Unlock("newpassword456"); err != nil {
+		t.Fatalf("Unlock after password change failed: %v", err)
+	}
+	v.Lock()
+
+	// Parameters outside the accepted bounds mean vault.meta was tampered with
+	metaPath := filepath.Join(dir, MetaFileName)
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		t.Fatalf("failed to read meta: %v", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("failed to parse meta: %v", err)
+	}
+	meta["kdf"] = map[string]any{"memory": 8, "iterations": 1, "parallelism": 1}
+	data, _ = json.Marshal(meta)
+	if err := os.WriteFile(metaPath, data, FileMode); err != nil {
+		t.Fatalf("failed to write meta: %v", err)
+	}
+	if err := v.Unlock("newpassword456"); !errors.Is(err, ErrVaultCorrupted) {
+		t.Errorf("expected ErrVaultCorrupted, got %v", err)
+	}
+}
+
+func TestKDFParamsDefault(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	meta, err := v.readMeta()
+	if err != nil {
+		t.Fatalf("readMeta failed: %v", err)
+	}
+	if meta.KDF == nil || *meta.KDF != crypto.DefaultKDFParams() {
+		t.Errorf("expected the default parameters with their version to be recorded, got %+v", meta.KDF)
+	}
+	if got, _ := v.KDFParams(); got != crypto.DefaultKDFParams() {
+		t.Errorf("KDFParams = %+v, want defaults", got)
+	}
+
+	// Vaults created before the parameters were recorded get them on unlock
+	meta.KDF = nil
+	if err := v.writeMeta(meta); err != nil {
+		t.Fatalf("writeMeta failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	v.Lock()
+	if meta, _ := v.readMeta(); meta.KDF == nil || *meta.KDF != crypto.DefaultKDFParams() {
+		t.Errorf("expected the defaults to be recorded on unlock, got %+v", meta.KDF)
+	}
+}
+
+func TestUnlockUpgradesKDF(t *testing.T) {
+	v := New(t.TempDir())
+	weak := crypto.KDFParams{Memory: crypto.MinKDFMemory, Time: 5, Threads: 1}
+	if err := v.InitWithKDF("testpassword123", weak); err != nil {
+		t.Fatalf("InitWithKDF failed: %v", err)
+	}
+	events, cancel := v.Events()
+	defer cancel()
+
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	v.Lock()
+	want := weak.Raise(crypto.DefaultKDFParams())
+	if got, _ := v.KDFParams(); got != want {
+		t.Errorf("KDFParams after unlock = %+v, want %+v", got, want)
+	}
+	upgraded := false
+	for len(events) > 0 {
+		if e := <-events; e.Type == EventWarning && strings.Contains(e.Message, "upgraded") {
+			upgraded = true
+		}
+	}
+	if !upgraded {
+		t.Error("expected a warning about the upgrade")
+	}
+
+	// The DEK is wrapped under the new parameters
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock after upgrade failed: %v", err)
+	}
+	v.Lock()
+	if err := v.Unlock("wrongpassword1"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected ErrInvalidPassword, got %v", err)
+	}
+}
+
+func TestSetKDFParams(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	stronger := crypto.DefaultKDFParams()
+	stronger.Time = 4
+	if err := v.SetKDFParams("testpassword123", stronger); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetKDFParams("testpassword123", crypto.KDFParams{Memory: crypto.MinKDFMemory, Time: 3, Threads: 4}); !errors.Is(err, crypto.ErrInvalidKDFParams) {
+		t.Errorf("expected weaker parameters to be refused, got %v", err)
+	}
+	if err := v.SetKDFParams("wrongpassword1", stronger); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected ErrInvalidPassword, got %v", err)
+	}
+	if err := v.SetKDFParams("testpassword123", stronger); err != nil {
+		t.Fatalf("SetKDFParams failed: %v", err)
+	}
+	if err := v.SetSecret("KEY", &SecretEntry{Value: []byte("value")}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	v.Lock()
+
+	if got, _ := v.KDFParams(); got != stronger {
+		t.Errorf("KDFParams = %+v, want %+v", got, stronger)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock with new parameters failed: %v", err)
+	}
+	defer v.Lock()
+	if entry, err := v.GetSecret("KEY"); err != nil || string(entry.Value) != "value" {
+		t.Errorf("GetSecret = %v, %v", entry, err)
+	}
+}
+
+// TestKDFChangeInterrupted simulates crashes on either side of the
+// vault_keys update of a KDF change.
+func TestKDFChangeInterrupted(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defaults := crypto.DefaultKDFParams()
+	stronger := defaults
+	stronger.Time = 4
+
+	// Before: vault_keys still matches the recorded parameters
+	meta, _ := v.readMeta()
+	meta.KDFPending = &stronger
+	if err := v.writeMeta(meta); err != nil {
+		t.Fatalf("writeMeta failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if meta, _ := v.readMeta(); meta.KDFPending != nil || *meta.KDF != defaults {
+		t.Errorf("expected the pending parameters to be discarded, got %+v / %+v", meta.KDF, meta.KDFPending)
+	}
+
+	// After: vault_keys was re-wrapped but vault.meta not updated
+	v.mu.Lock()
+	err := v.changeKDF([]byte("testpassword123"), stronger)
+	v.mu.Unlock()
+	if err != nil {
+		t.Fatalf("changeKDF failed: %v", err)
+	}
+	v.Lock()
+	meta, _ = v.readMeta()
+	meta.KDF, meta.KDFPending = &defaults, &stronger
+	if err := v.writeMeta(meta); err != nil {
+		t.Fatalf("writeMeta failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock after interrupted change failed: %v", err)
+	}
+	v.Lock()
+	if meta, _ := v.readMeta(); meta.KDFPending != nil || *meta.KDF != stronger {
+		t.Errorf("expected the change to be completed, got %+v / %+v", meta.KDF, meta.KDFPending)
+	}
+}
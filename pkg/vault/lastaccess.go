@@ -0,0 +1,186 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// secretAccessTableSQL creates the table counting reads of secret values.
+// Like canaries it is keyed by key hash, so it does not reveal key names.
+const secretAccessTableSQL = `
+	CREATE TABLE IF NOT EXISTS secret_access (
+		key_hash TEXT PRIMARY KEY,
+		last_accessed_at TIMESTAMP NOT NULL,
+		access_count INTEGER NOT NULL DEFAULT 0
+	)
+`
+
+// accessFlushInterval is how long reads are counted in memory before
+// they are written to secret_access.
+const accessFlushInterval = 30 * time.Second
+
+// accessBatch counts reads of secret values in memory, so that reading a
+// secret does not take the write lock and commit a transaction. The counts
+// are written by flushAccess after accessFlushInterval, on Lock and before
+// ListStaleSecrets; reads counted when the process exits are lost.
+type accessBatch struct {
+	mu sync.Mutex
+	// pending maps keys to the reads counted since the last flush
+	pending map[string]pendingAccess
+	timer   *time.Timer
+}
+
+type pendingAccess struct {
+	count int
+	last  time.Time
+}
+
+// take returns the pending reads and starts a new batch.
+func (a *accessBatch) take() map[string]pendingAccess {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	pending := a.pending
+	a.pending = nil
+	return pending
+}
+
+// recordAccess notes that the values of keys were read. It is best
+// effort: a read never fails because its access could not be recorded,
+// and read-only vaults and snapshots are not changed.
+func (v *Vault) recordAccess(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+
+	a := &v.access
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pending == nil {
+		a.pending = make(map[string]pendingAccess)
+	}
+	for _, key := range keys {
+		p := a.pending[key]
+		a.pending[key] = pendingAccess{count: p.count + 1, last: now}
+	}
+	if a.timer == nil {
+		a.timer = time.AfterFunc(accessFlushInterval, v.flushAccess)
+	}
+}
+
+// flushAccess writes the reads counted by recordAccess to secret_access.
+func (v *Vault) flushAccess() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.flushAccessLocked()
+}
+
+// flushAccessLocked is flushAccess for callers that hold v.mu. Reads
+// counted while the vault is locked or cannot be written are dropped.
+func (v *Vault) flushAccessLocked() {
+	pending := v.access.take()
+	if len(pending) == 0 || v.dek == nil || v.checkWritable() != nil {
+		return
+	}
+	if err := v.writeAccessLocked(pending); err != nil {
+		slog.Debug("vault: failed to record secret access", "err", err)
+	}
+}
+
+func (v *Vault) writeAccessLocked(pending map[string]pendingAccess) error {
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, p := range pending {
+		// Skip secrets purged since they were read
+		keyHash := v.hashKey(key)
+		if _, err := tx.Exec(`
+			INSERT INTO secret_access (key_hash, last_accessed_at, access_count)
+			SELECT ?, ?, ? WHERE EXISTS (SELECT 1 FROM secrets WHERE key_hash = ?)
+			ON CONFLICT(key_hash) DO UPDATE SET
+				last_accessed_at = excluded.last_accessed_at,
+				access_count = access_count + excluded.access_count`,
+			keyHash, p.last, p.count, keyHash); err != nil {
+			return fmt.Errorf("vault: failed to record access: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListStaleSecrets returns the secrets that nobody has read or updated for
+// olderThan, with LastAccessedAt and AccessCount set, least recently
+// touched first. Only reads of values count, not metadata reads
+// (InspectSecret, list); reads made before the vault was upgraded to
+// schema version 16 were not recorded.
+func (v *Vault) ListStaleSecrets(olderThan time.Duration) ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		v.flushAccess()
+		return v.listStaleSecrets(olderThan)
+	})
+}
+
+func (v *Vault) listStaleSecrets(olderThan time.Duration) ([]*SecretEntry, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	rows, err := v.db.Query(`
+		SELECT ` + secretMetadataColumns + `, a.last_accessed_at, COALESCE(a.access_count, 0)
+		FROM secrets
+		LEFT JOIN secret_access a ON a.key_hash = secrets.key_hash
+		WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	cutoff := time.Now().Add(-olderThan)
+	var secrets []*SecretEntry
+	for rows.Next() {
+		var lastAccessed sql.NullTime
+		var count int
+		entry, err := v.scanSecretEntryRowWithMetadata(rows, &lastAccessed, &count)
+		if err != nil {
+			return nil, err
+		}
+		if lastAccessed.Valid {
+			entry.LastAccessedAt = &lastAccessed.Time
+		}
+		entry.AccessCount = count
+		if entry.lastTouched().After(cutoff) {
+			continue
+		}
+		secrets = append(secrets, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return secrets[i].lastTouched().Before(secrets[j].lastTouched())
+	})
+	return secrets, nil
+}
+
+// lastTouched is when the secret was last read or updated, whichever is
+// later.
+func (e *SecretEntry) lastTouched() time.Time {
+	if e.LastAccessedAt != nil && e.LastAccessedAt.After(e.UpdatedAt) {
+		return *e.LastAccessedAt
+	}
+	return e.UpdatedAt
+}
@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListStaleSecrets(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for _, key := range []string{"used", "batch", "unused"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("v")}); err != nil {
+			t.Fatalf("SetSecret %s failed: %v", key, err)
+		}
+	}
+	for range 2 {
+		if _, err := v.GetSecret("used"); err != nil {
+			t.Fatalf("GetSecret failed: %v", err)
+		}
+	}
+	if _, err := v.GetSecrets([]string{"batch"}); err != nil {
+		t.Fatalf("GetSecrets failed: %v", err)
+	}
+	// Metadata reads do not count
+	if _, err := v.InspectSecret("unused"); err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+
+	stale, err := v.ListStaleSecrets(0)
+	if err != nil {
+		t.Fatalf("ListStaleSecrets failed: %v", err)
+	}
+	counts := map[string]int{}
+	for _, e := range stale {
+		counts[e.Key] = e.AccessCount
+		if (e.LastAccessedAt != nil) != (e.AccessCount > 0) {
+			t.Errorf("%s: LastAccessedAt %v with %d reads", e.Key, e.LastAccessedAt, e.AccessCount)
+		}
+	}
+	if len(stale) != 3 || counts["used"] != 2 || counts["batch"] != 1 || counts["unused"] != 0 {
+		t.Fatalf("unexpected access counts %v", counts)
+	}
+	if stale[0].Key != "unused" {
+		t.Errorf("expected the never-read secret first, got %s", stale[0].Key)
+	}
+
+	if stale, err := v.ListStaleSecrets(time.Hour); err != nil || len(stale) != 0 {
+		t.Fatalf("expected no secrets unused for an hour, got %d (%v)", len(stale), err)
+	}
+
+	// A secret last read long ago is stale; updating it makes it fresh
+	old := time.Now().Add(-100 * 24 * time.Hour).UTC()
+	if _, err := v.db.Exec("UPDATE secret_access SET last_accessed_at = ? WHERE key_hash = ?", old, v.hashKey("used")); err != nil {
+		t.Fatalf("failed to age access record: %v", err)
+	}
+	if _, err := v.db.Exec("UPDATE secrets SET updated_at = ? WHERE key_hash = ?", old, v.hashKey("used")); err != nil {
+		t.Fatalf("failed to age secret: %v", err)
+	}
+	stale, err = v.ListStaleSecrets(90 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("ListStaleSecrets failed: %v", err)
+	}
+	if len(stale) != 1 || stale[0].Key != "used" || stale[0].AccessCount != 2 {
+		t.Fatalf("expected only 'used' to be unused for 90 days, got %+v", stale)
+	}
+	if err := v.SetSecret("used", &SecretEntry{Value: []byte("rotated")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if stale, err := v.ListStaleSecrets(90 * 24 * time.Hour); err != nil || len(stale) != 0 {
+		t.Fatalf("expected an updated secret not to be stale, got %d (%v)", len(stale), err)
+	}
+}
+
+func TestRecordAccessBatched(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("key", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	for range 3 {
+		if _, err := v.GetSecret("key"); err != nil {
+			t.Fatalf("GetSecret failed: %v", err)
+		}
+	}
+
+	// Reads are counted in memory, not written one by one
+	var rows int
+	if err := v.db.QueryRow("SELECT COUNT(*) FROM secret_access").Scan(&rows); err != nil || rows != 0 {
+		t.Fatalf("secret_access has %d rows (%v) before a flush, want 0", rows, err)
+	}
+
+	// Locking writes them
+	v.Lock()
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	var count int
+	if err := v.db.QueryRow("SELECT access_count FROM secret_access WHERE key_hash = ?", v.hashKey("key")).Scan(&count); err != nil || count != 3 {
+		t.Fatalf("access_count = %d (%v) after Lock, want 3", count, err)
+	}
+
+	// A secret purged before the flush gets no record
+	if err := v.SetSecret("gone", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("gone"); err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if _, err := v.db.Exec("DELETE FROM secrets WHERE key_hash = ?", v.hashKey("gone")); err != nil {
+		t.Fatal(err)
+	}
+	v.flushAccess()
+	if err := v.db.QueryRow("SELECT COUNT(*) FROM secret_access").Scan(&rows); err != nil || rows != 1 {
+		t.Fatalf("secret_access has %d rows (%v), want 1", rows, err)
+	}
+}
+
+func BenchmarkGetSecret(b *testing.B) {
+	v := New(b.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		b.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		b.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if err := v.SetSecret("key", &SecretEntry{Value: []byte("value")}); err != nil {
+		b.Fatalf("SetSecret failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := v.GetSecret("key"); err != nil {
+			b.Fatalf("GetSecret failed: %v", err)
+		}
+	}
+}
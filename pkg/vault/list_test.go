@@ -0,0 +1,116 @@
+package vault_test
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/forest6511/secretctl/pkg/vault/vaulttest"
+)
+
+// listFixture builds a generated vault and returns it with its entries.
+func listFixture(t *testing.T, seed uint64) (*vault.Vault, []string, map[string]*vault.SecretEntry) {
+	t.Helper()
+	builder := vaulttest.NewBuilder(seed).WithSecrets(30).WithAuditEvents(0)
+	keys, entries, err := builder.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	return builder.MustBuild(t), keys, entries
+}
+
+// entryKeys returns the sorted keys of entries.
+func entryKeys(entries []*vault.SecretEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestListSecretsByTag(t *testing.T) {
+	v, keys, entries := listFixture(t, 11)
+
+	for _, tag := range []string{"prod", "api", "team-a"} {
+		var want []string
+		for _, key := range keys {
+			if slices.Contains(entries[key].Tags, tag) {
+				want = append(want, key)
+			}
+		}
+		sort.Strings(want)
+
+		if tag == "prod" && len(want) == 0 {
+			t.Fatal("fixture has no secrets tagged prod")
+		}
+		got, err := v.ListSecretsByTag(tag)
+		if err != nil {
+			t.Fatalf("ListSecretsByTag(%s) failed: %v", tag, err)
+		}
+		if gotKeys := entryKeys(got); !slices.Equal(gotKeys, want) {
+			t.Errorf("ListSecretsByTag(%s) = %v, want %v", tag, gotKeys, want)
+		}
+		for _, e := range got {
+			if len(e.Value) != 0 {
+				t.Errorf("%s: tag listing includes the value", e.Key)
+			}
+		}
+	}
+}
+
+func TestListExpiringSecrets(t *testing.T) {
+	v, keys, entries := listFixture(t, 12)
+
+	// Expirations are whole days from now; half a day apart from the
+	// window keeps the test clear of the boundary
+	within := 30*24*time.Hour + 12*time.Hour
+	cutoff := time.Now().Add(within)
+	var want []string
+	for _, key := range keys {
+		if e := entries[key]; e.ExpiresAt != nil && e.ExpiresAt.Before(cutoff) {
+			want = append(want, key)
+		}
+	}
+	sort.Strings(want)
+	if len(want) == 0 || len(want) == len(keys) {
+		t.Fatalf("fixture has %d of %d secrets expiring, want some", len(want), len(keys))
+	}
+
+	got, err := v.ListExpiringSecrets(within)
+	if err != nil {
+		t.Fatalf("ListExpiringSecrets failed: %v", err)
+	}
+	if gotKeys := entryKeys(got); !slices.Equal(gotKeys, want) {
+		t.Errorf("ListExpiringSecrets = %v, want %v", gotKeys, want)
+	}
+}
+
+func TestListSecretsByPrefixFixture(t *testing.T) {
+	v, keys, _ := listFixture(t, 13)
+
+	for _, prefix := range []string{keys[0][:strings.Index(keys[0], "/")+1], keys[1][:strings.LastIndex(keys[1], "/")+1], keys[2], ""} {
+		var want []string
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				want = append(want, key)
+			}
+		}
+		sort.Strings(want)
+		if len(want) == 0 {
+			t.Fatalf("fixture has no keys under %q", prefix)
+		}
+
+		got, err := v.ListSecretsByPrefix(prefix)
+		if err != nil {
+			t.Fatalf("ListSecretsByPrefix(%q) failed: %v", prefix, err)
+		}
+		sort.Strings(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("ListSecretsByPrefix(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mask policy modes
+const (
+	// MaskDefault is the tiered rule: 1-4 characters fully masked, 5-8
+	// show the last 2, 9+ show the last 4.
+	MaskDefault = "default"
+	// MaskNone reveals no characters.
+	MaskNone = "none"
+	// MaskLast reveals the last N characters.
+	MaskLast = "last"
+	// MaskFirstLast reveals the first N and last N characters.
+	MaskFirstLast = "first-last"
+)
+
+// ErrInvalidMaskPolicy is returned for unparsable mask policies.
+var ErrInvalidMaskPolicy = errors.New("vault: invalid mask policy")
+
+// MaskPolicy decides how much of a sensitive value previews reveal
+// (MCP secret_get_masked, 'get --masked', the desktop read view).
+// The zero value is MaskDefault.
+type MaskPolicy struct {
+	Mode string
+	N    int
+}
+
+// MaskOverride applies Policy to keys matching Pattern (path.Match
+// syntax, e.g. "prod/*").
+type MaskOverride struct {
+	Pattern string `json:"pattern"`
+	Policy  string `json:"policy"`
+}
+
+// ParseMaskPolicy parses "default", "none", "last:N" or "first-last:N".
+func ParseMaskPolicy(s string) (MaskPolicy, error) {
+	mode, arg, hasArg := strings.Cut(strings.TrimSpace(s), ":")
+	switch mode {
+	case "", MaskDefault, MaskNone:
+		if hasArg {
+			return MaskPolicy{}, fmt.Errorf("%w: %q takes no argument", ErrInvalidMaskPolicy, mode)
+		}
+		if mode == "" {
+			mode = MaskDefault
+		}
+		return MaskPolicy{Mode: mode}, nil
+	case MaskLast, MaskFirstLast:
+		n, err := strconv.Atoi(arg)
+		if !hasArg || err != nil || n < 1 {
+			return MaskPolicy{}, fmt.Errorf("%w: expected %s:N with N >= 1, got %q", ErrInvalidMaskPolicy, mode, s)
+		}
+		return MaskPolicy{Mode: mode, N: n}, nil
+	}
+	return MaskPolicy{}, fmt.Errorf("%w: %q (use %s, %s, %s:N or %s:N)", ErrInvalidMaskPolicy, s, MaskDefault, MaskNone, MaskLast, MaskFirstLast)
+}
+
+// String returns the policy in ParseMaskPolicy syntax.
+func (p MaskPolicy) String() string {
+	switch p.Mode {
+	case MaskLast, MaskFirstLast:
+		return fmt.Sprintf("%s:%d", p.Mode, p.N)
+	case "":
+		return MaskDefault
+	}
+	return p.Mode
+}
+
+// Mask returns value with hidden characters replaced by '*'. The length
+// is preserved. last:N and first-last:N never reveal more than half of
+// the value, so short secrets stay mostly hidden.
+func (p MaskPolicy) Mask(value []byte) string {
+	return string(p.appendMask(nil, value))
+}
+
+// appendMask appends the masked form of value to dst. Only the revealed
+// bytes are copied; value is never converted to a string or rune slice,
+// which could not be wiped.
+func (p MaskPolicy) appendMask(dst, value []byte) []byte {
+	// Count by decoding: utf8.RuneCount converts to a string, which would
+	// copy the value
+	n := 0
+	for i := 0; i < len(value); n++ {
+		_, size := utf8.DecodeRune(value[i:])
+		i += size
+	}
+	if n == 0 {
+		return dst
+	}
+
+	var head, tail int
+	switch p.Mode {
+	case MaskNone:
+	case MaskLast:
+		tail = min(p.N, n/2)
+	case MaskFirstLast:
+		head = min(p.N, n/4)
+		tail = head
+	default:
+		switch {
+		case n <= 4:
+		case n <= 8:
+			tail = 2
+		default:
+			tail = 4
+		}
+	}
+
+	headEnd := 0
+	for range head {
+		_, size := utf8.DecodeRune(value[headEnd:])
+		headEnd += size
+	}
+	tailStart := len(value)
+	for range tail {
+		_, size := utf8.DecodeLastRune(value[:tailStart])
+		tailStart -= size
+	}
+	dst = append(dst, value[:headEnd]...)
+	for range n - head - tail {
+		dst = append(dst, '*')
+	}
+	return append(dst, value[tailStart:]...)
+}
+
+// MaskPolicyFor returns the mask policy for key: the first matching
+// override, else the vault's mask_policy. Invalid stored policies fall back
+// to MaskDefault. The vault does not need to be unlocked.
+func (v *Vault) MaskPolicyFor(key string) MaskPolicy {
+	settings, err := v.Settings()
+	if err != nil {
+		return MaskPolicy{Mode: MaskDefault}
+	}
+	return settings.maskPolicyFor(key)
+}
+
+func (s *VaultSettings) maskPolicyFor(key string) MaskPolicy {
+	policy := s.MaskPolicy
+	for _, o := range s.MaskOverrides {
+		if ok, _ := path.Match(o.Pattern, key); ok {
+			policy = o.Policy
+			break
+		}
+	}
+	p, err := ParseMaskPolicy(policy)
+	if err != nil {
+		return MaskPolicy{Mode: MaskDefault}
+	}
+	return p
+}
+
+// ParseMaskOverrides parses "pattern=policy" pairs separated by commas,
+// e.g. "prod/*=none,ci/*=last:2".
+func ParseMaskOverrides(s string) ([]MaskOverride, error) {
+	var overrides []MaskOverride
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, policy, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%w: expected pattern=policy, got %q", ErrInvalidMaskPolicy, item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: pattern %q: %v", ErrInvalidMaskPolicy, pattern, err)
+		}
+		if _, err := ParseMaskPolicy(policy); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, MaskOverride{Pattern: pattern, Policy: policy})
+	}
+	return overrides, nil
+}
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaskPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		value  string
+		want   string
+	}{
+		{"default", "abcd", "****"},
+		{"default", "abcdefgh", "******gh"},
+		{"default", "sk-proj-1234567890abcdef", "********************cdef"},
+		{"", "abcdefghij", "******ghij"},
+		{"none", "abcdefghij", "**********"},
+		{"last:3", "abcdefghij", "*******hij"},
+		{"last:8", "abcdefghij", "*****fghij"}, // capped at half
+		{"first-last:2", "abcdefghij", "ab******ij"},
+		{"first-last:2", "abcdef", "a****f"}, // capped at a quarter each side
+		{"first-last:2", "abc", "***"},
+		{"last:4", "パスワード秘密", "****ド秘密"}, // runes, not bytes
+		{"none", "", ""},
+	}
+
+	for _, tt := range tests {
+		p, err := ParseMaskPolicy(tt.policy)
+		if err != nil {
+			t.Fatalf("ParseMaskPolicy(%q) failed: %v", tt.policy, err)
+		}
+		if got := p.Mask([]byte(tt.value)); got != tt.want {
+			t.Errorf("%s.Mask(%q) = %q, want %q", tt.policy, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMaskPolicyAppendMaskNoAlloc(t *testing.T) {
+	p := MaskPolicy{Mode: MaskFirstLast, N: 2}
+	value := []byte("パスワード-sk-proj-1234567890")
+	dst := make([]byte, 0, 128)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst = p.appendMask(dst[:0], value)
+	})
+	if allocs != 0 {
+		t.Errorf("appendMask allocated %.0f times, want 0", allocs)
+	}
+	if got, want := string(dst), p.Mask(value); got != want {
+		t.Errorf("appendMask = %q, Mask = %q", got, want)
+	}
+}
+
+func TestParseMaskPolicyInvalid(t *testing.T) {
+	for _, s := range []string{"last", "last:0", "last:x", "none:2", "reveal-all"} {
+		if _, err := ParseMaskPolicy(s); !errors.Is(err, ErrInvalidMaskPolicy) {
+			t.Errorf("ParseMaskPolicy(%q): expected ErrInvalidMaskPolicy, got %v", s, err)
+		}
+	}
+}
+
+func TestMaskPolicyFor(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if p := v.MaskPolicyFor("any"); p.Mode != MaskDefault {
+		t.Errorf("expected default policy, got %v", p)
+	}
+
+	overrides, err := ParseMaskOverrides("prod/*=none, ci/*=last:2")
+	if err != nil {
+		t.Fatalf("ParseMaskOverrides failed: %v", err)
+	}
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.MaskPolicy = "first-last:1"
+		s.MaskOverrides = overrides
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	for key, want := range map[string]string{"prod/db": "none", "ci/token": "last:2", "dev/db": "first-last:1"} {
+		if got := v.MaskPolicyFor(key).String(); got != want {
+			t.Errorf("MaskPolicyFor(%q) = %s, want %s", key, got, want)
+		}
+	}
+
+	if _, err := ParseMaskOverrides("prod/*"); !errors.Is(err, ErrInvalidMaskPolicy) {
+		t.Errorf("expected ErrInvalidMaskPolicy, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"sync"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// MaskedSecret is a preview of a secret that carries no plaintext beyond
+// the characters its mask policy reveals.
+type MaskedSecret struct {
+	// Key is the secret that was read; it differs from the requested key
+	// when a deprecated key was redirected.
+	Key string
+	// Masked is the masked value (the default field).
+	Masked string
+	// Length is the length of the value in bytes.
+	Length int
+	// Fields holds a preview of every field.
+	Fields map[string]MaskedField
+	// ReferencedKeys lists the secrets read to resolve ref:// values.
+	ReferencedKeys []string
+}
+
+// MaskedField is a field preview. Sensitive values are masked;
+// non-sensitive ones are shown in full.
+type MaskedField struct {
+	Value     string
+	Sensitive bool
+	Length    int
+}
+
+// maskBuffers are the scratch buffers of GetSecretMasked, wiped before
+// they go back to the pool.
+type maskBuffers struct {
+	value, mask []byte
+}
+
+var maskBufferPool = sync.Pool{
+	New: func() any { return &maskBuffers{value: make([]byte, 0, 256), mask: make([]byte, 0, 256)} },
+}
+
+// GetSecretMasked reads key like GetSecret but returns only masked
+// previews and lengths, so preview-only callers such as MCP
+// secret_get_masked never hold the plaintext. Masks are built from the
+// decrypted entry through pooled buffers that are wiped after use.
+func (v *Vault) GetSecretMasked(key string) (*MaskedSecret, error) {
+	entry, err := v.readSecret(key, readOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(entry.Value)
+
+	bufs := maskBufferPool.Get().(*maskBuffers)
+	defer func() {
+		crypto.SecureWipe(bufs.value[:cap(bufs.value)])
+		crypto.SecureWipe(bufs.mask[:cap(bufs.mask)])
+		bufs.value, bufs.mask = bufs.value[:0], bufs.mask[:0]
+		maskBufferPool.Put(bufs)
+	}()
+	policy := v.MaskPolicyFor(key)
+	mask := func(value []byte) string {
+		bufs.mask = policy.appendMask(bufs.mask[:0], value)
+		return string(bufs.mask)
+	}
+
+	masked := &MaskedSecret{
+		Key:            entry.Key,
+		Masked:         mask(entry.Value),
+		Length:         len(entry.Value),
+		ReferencedKeys: entry.ReferencedKeys,
+	}
+	if len(entry.Fields) == 0 {
+		return masked, nil
+	}
+	masked.Fields = make(map[string]MaskedField, len(entry.Fields))
+	for name, field := range entry.Fields {
+		mf := MaskedField{Value: field.Value, Sensitive: field.Sensitive, Length: len(field.Value)}
+		if field.Sensitive {
+			bufs.value = append(bufs.value[:0], field.Value...)
+			mf.Value = mask(bufs.value)
+		}
+		masked.Fields[name] = mf
+	}
+	return masked, nil
+}
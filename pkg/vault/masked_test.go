@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetSecretMasked(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("api", &SecretEntry{Value: []byte("sk-proj-1234567890abcdef")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("db", &SecretEntry{Fields: map[string]Field{
+		"password": {Value: "hunter2-secret", Sensitive: true},
+		"host":     {Value: "db.internal"},
+	}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	m, err := v.GetSecretMasked("api")
+	if err != nil {
+		t.Fatalf("GetSecretMasked failed: %v", err)
+	}
+	if m.Masked != "********************cdef" || m.Length != 24 || m.Key != "api" {
+		t.Errorf("unexpected preview %+v", m)
+	}
+
+	m, err = v.GetSecretMasked("db")
+	if err != nil {
+		t.Fatalf("GetSecretMasked failed: %v", err)
+	}
+	if got := m.Fields["password"]; got.Value != "**********cret" || !got.Sensitive || got.Length != 14 {
+		t.Errorf("password preview = %+v", got)
+	}
+	if got := m.Fields["host"]; got.Value != "db.internal" || got.Sensitive {
+		t.Errorf("host preview = %+v", got)
+	}
+
+	// The per-key mask policy applies
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.MaskOverrides = []MaskOverride{{Pattern: "api", Policy: "none"}}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if m, err := v.GetSecretMasked("api"); err != nil || m.Masked != "************************" {
+		t.Errorf("expected a fully masked preview, got %+v (%v)", m, err)
+	}
+
+	if _, err := v.GetSecretMasked("missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package vault
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// Operations passed to middleware. They are named like the audit events
+// the operations record. Every exported method that reads or changes
+// secrets runs as one of them.
+const (
+	OpGet        = audit.OpSecretGet        // GetSecret, InspectSecret and the other reads of one secret
+	OpGetBatch   = audit.OpSecretGetBatch   // GetSecrets
+	OpSet        = audit.OpSecretSet        // SetSecret and its variants
+	OpSetBatch   = audit.OpSecretSetBatch   // SetSecrets
+	OpUpdate     = audit.OpSecretUpdate     // BulkUpdate, RenameTag, DeleteTag, MoveSecretToFolder
+	OpDelete     = audit.OpSecretDelete     // DeleteSecret and its variants
+	OpList       = audit.OpSecretList       // ListSecrets, the other listings and metadata reads such as GetSecretHistory
+	OpGrep       = audit.OpSecretGrep       // Grep
+	OpSearch     = audit.OpSecretSearch     // Search
+	OpDeprecate  = audit.OpSecretDeprecate  // DeprecateSecret, UndeprecateSecret
+	OpDuplicates = audit.OpSecretDuplicates // FindDuplicateValues
+	OpRestore    = audit.OpSecretRestore    // RestoreSecret
+	OpPurge      = audit.OpSecretPurge      // PurgeDeleted
+
+	OpAttachmentAdd    = audit.OpSecretAttach           // AddAttachment
+	OpAttachmentGet    = audit.OpSecretAttachmentGet    // GetAttachment, OpenAttachmentReader
+	OpAttachmentRemove = audit.OpSecretAttachmentRemove // RemoveAttachment
+)
+
+// ErrOperationSkipped is returned when a middleware returns nil without
+// calling next, since the caller would otherwise get an empty result
+// that looks like success.
+var ErrOperationSkipped = errors.New("vault: middleware returned without running the operation")
+
+// Op describes a vault operation to middleware.
+type Op struct {
+	// Name is one of the Op constants.
+	Name string
+	// Key is the secret of a single-secret operation, including metadata
+	// reads such as ListAttachments.
+	Key string
+	// Keys are the secrets of a batch operation, sorted.
+	Keys []string
+	// Source is the audit source of the vault (see SetAuditSource).
+	Source string
+}
+
+// Next runs the rest of the chain and then the operation itself.
+type Next func() error
+
+// Middleware wraps vault operations. It may act before and after calling
+// next, e.g. to time it, or return an error instead of calling it, e.g.
+// to enforce a rate limit or wait for an approval that was denied; that
+// error is returned to the caller unchanged.
+//
+// Middleware runs without any vault lock held, so it may call the vault,
+// but calls of wrapped operations pass through the chain again.
+type Middleware func(op Op, next Next) error
+
+// middlewareChain holds the middleware added with Use.
+type middlewareChain struct {
+	mu   sync.RWMutex
+	list []Middleware
+}
+
+// Use appends middleware to the chain wrapping every read, write, delete,
+// listing and search of secrets. The first middleware added is the outermost. The
+// chain survives Lock and Unlock.
+func (v *Vault) Use(mw ...Middleware) {
+	v.middleware.mu.Lock()
+	defer v.middleware.mu.Unlock()
+	v.middleware.list = append(v.middleware.list, mw...)
+}
+
+// run runs fn through the middleware chain. Caller must not hold v.mu.
+func (v *Vault) run(op Op, fn func() error) error {
+	v.middleware.mu.RLock()
+	chain := v.middleware.list
+	v.middleware.mu.RUnlock()
+	if len(chain) == 0 {
+		return fn()
+	}
+
+	op.Source = v.auditSource()
+	ran := false
+	var call func(i int) error
+	call = func(i int) error {
+		if i == len(chain) {
+			ran = true
+			return fn()
+		}
+		return chain[i](op, func() error { return call(i + 1) })
+	}
+	if err := call(0); err != nil {
+		return err
+	}
+	if !ran {
+		return ErrOperationSkipped
+	}
+	return nil
+}
+
+// runValue runs fn through the middleware chain like run and returns its
+// result. Caller must not hold v.mu.
+func runValue[T any](v *Vault, op Op, fn func() (T, error)) (T, error) {
+	var result T
+	err := v.run(op, func() error {
+		var err error
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
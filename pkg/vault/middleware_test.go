@@ -0,0 +1,230 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(op Op, next Next) error {
+			calls = append(calls, name+">"+op.Name+":"+op.Key)
+			err := next()
+			calls = append(calls, name+"<")
+			return err
+		}
+	}
+	v.Use(record("outer"), record("inner"))
+
+	if err := v.SetSecret("api/key", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	want := []string{"outer>" + OpSet + ":api/key", "inner>" + OpSet + ":api/key", "inner<", "outer<"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	// A middleware error is returned without running the operation
+	errLimited := errors.New("rate limited")
+	var ops []Op
+	v.Use(func(op Op, next Next) error {
+		ops = append(ops, op)
+		if op.Name == OpDelete {
+			return errLimited
+		}
+		return next()
+	})
+	if err := v.DeleteSecret("api/key"); !errors.Is(err, errLimited) {
+		t.Fatalf("expected middleware error, got %v", err)
+	}
+	if _, err := v.GetSecret("api/key"); err != nil {
+		t.Errorf("secret deleted despite middleware: %v", err)
+	}
+
+	if _, err := v.GetSecrets([]string{"b", "api/key"}); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, err := v.ListSecrets(); err != nil {
+		t.Fatalf("ListSecrets failed: %v", err)
+	}
+	names := make([]string, 0, len(ops))
+	for _, op := range ops {
+		names = append(names, op.Name)
+	}
+	if want := []string{OpDelete, OpGet, OpGetBatch, OpList}; !reflect.DeepEqual(names, want) {
+		t.Errorf("operations = %v, want %v", names, want)
+	}
+	if got := ops[2].Keys; !reflect.DeepEqual(got, []string{"api/key", "b"}) {
+		t.Errorf("batch keys = %v", got)
+	}
+	if ops[0].Source != v.auditSource() {
+		t.Errorf("Source = %q", ops[0].Source)
+	}
+}
+
+func TestMiddlewareSkipped(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	v.Use(func(op Op, next Next) error { return nil })
+	if _, err := v.GetSecret("missing"); !errors.Is(err, ErrOperationSkipped) {
+		t.Errorf("expected ErrOperationSkipped, got %v", err)
+	}
+}
+
+// TestMiddlewareCoversAPI fails when an exported method reads or changes
+// secrets without passing through the middleware chain. New methods must be
+// added to wrapped or, if they do not touch secrets, to exempt.
+func TestMiddlewareCoversAPI(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	entry := func() *SecretEntry { return &SecretEntry{Value: []byte("v")} }
+	ignore := func(_ any, err error) error { return err }
+	wrapped := map[string]struct {
+		op   string
+		call func() error
+	}{
+		"AddAttachment":    {OpAttachmentAdd, func() error { return v.AddAttachment("k", "a.txt", strings.NewReader("x")) }},
+		"ListAttachments":  {OpList, func() error { return ignore(v.ListAttachments("k")) }},
+		"GetAttachment":    {OpAttachmentGet, func() error { return ignore(v.GetAttachment("k", "a.txt")) }},
+		"RemoveAttachment": {OpAttachmentRemove, func() error { return v.RemoveAttachment("k", "a.txt") }},
+		"OpenAttachmentReader": {OpAttachmentGet, func() error {
+			return ignore(v.OpenAttachmentReader("k", "a.txt"))
+		}},
+		"BulkUpdate":          {OpUpdate, func() error { return ignore(v.BulkUpdate(BulkFilter{Tag: "t"}, BulkPatch{})) }},
+		"BulkUpdatePreview":   {OpList, func() error { return ignore(v.BulkUpdatePreview(BulkFilter{Tag: "t"}, BulkPatch{})) }},
+		"RenameTag":           {OpUpdate, func() error { return ignore(v.RenameTag("t", "u")) }},
+		"DeleteTag":           {OpUpdate, func() error { return ignore(v.DeleteTag("t")) }},
+		"ListTags":            {OpList, func() error { return ignore(v.ListTags()) }},
+		"CreateCanary":        {OpSet, func() error { return v.CreateCanary("k", entry()) }},
+		"ListCanaries":        {OpList, func() error { return ignore(v.ListCanaries()) }},
+		"ListCertificates":    {OpList, func() error { return ignore(v.ListCertificates()) }},
+		"DeprecateSecret":     {OpDeprecate, func() error { return v.DeprecateSecret("k", "j") }},
+		"UndeprecateSecret":   {OpDeprecate, func() error { return v.UndeprecateSecret("k") }},
+		"FindDuplicateValues": {OpDuplicates, func() error { return ignore(v.FindDuplicateValues()) }},
+		"MoveSecretToFolder":  {OpUpdate, func() error { return v.MoveSecretToFolder("k", nil) }},
+		"ListSecretsInFolder": {OpList, func() error { return ignore(v.ListSecretsInFolder(nil, true)) }},
+		"Grep": {OpGrep, func() error {
+			return ignore(v.Grep(regexp.MustCompile("x"), GrepOptions{}))
+		}},
+		"Search":             {OpSearch, func() error { return ignore(v.Search("x")) }},
+		"GetSecretHistory":   {OpList, func() error { return ignore(v.GetSecretHistory("k")) }},
+		"ListStaleSecrets":   {OpList, func() error { return ignore(v.ListStaleSecrets(time.Hour)) }},
+		"ListSecretsByOwner": {OpList, func() error { return ignore(v.ListSecretsByOwner("o", "")) }},
+		"ListSecretsPage":    {OpList, func() error { return ignore(v.ListSecretsPage("", 10)) }},
+		"ListDeleted":        {OpList, func() error { return ignore(v.ListDeleted()) }},
+		"RestoreSecret":      {OpRestore, func() error { return v.RestoreSecret("k") }},
+		"PurgeDeleted":       {OpPurge, func() error { return ignore(v.PurgeDeleted(0)) }},
+		"SetSecret":          {OpSet, func() error { return v.SetSecret("k", entry()) }},
+		"SetSecretIfRevision": {OpSet, func() error {
+			return v.SetSecretIfRevision("k", entry(), "")
+		}},
+		"SetSecretBreakGlass": {OpSet, func() error {
+			return v.SetSecretBreakGlass("k", entry(), "incident")
+		}},
+		"SetSecrets":             {OpSetBatch, func() error { return v.SetSecrets(map[string]*SecretEntry{"k": entry()}) }},
+		"DeleteSecret":           {OpDelete, func() error { return v.DeleteSecret("k") }},
+		"DeleteSecretBreakGlass": {OpDelete, func() error { return v.DeleteSecretBreakGlass("k", "incident") }},
+		"GetSecret":              {OpGet, func() error { return ignore(v.GetSecret("k")) }},
+		"GetSecretAllowExpired":  {OpGet, func() error { return ignore(v.GetSecretAllowExpired("k")) }},
+		"GetSecretMasked":        {OpGet, func() error { return ignore(v.GetSecretMasked("k")) }},
+		"InspectSecret":          {OpGet, func() error { return ignore(v.InspectSecret("k")) }},
+		"OpenSecretReader":       {OpGet, func() error { return ignore(v.OpenSecretReader("k", "")) }},
+		"OpenSecretReaderAllowExpired": {OpGet, func() error {
+			return ignore(v.OpenSecretReaderAllowExpired("k", ""))
+		}},
+		"GetSecrets":              {OpGetBatch, func() error { return ignore(v.GetSecrets([]string{"k"})) }},
+		"GetSecretsAllowExpired":  {OpGetBatch, func() error { return ignore(v.GetSecretsAllowExpired([]string{"k"})) }},
+		"ListSecrets":             {OpList, func() error { return ignore(v.ListSecrets()) }},
+		"ListSecretsByPrefix":     {OpList, func() error { return ignore(v.ListSecretsByPrefix("a/")) }},
+		"ListSecretsWithMetadata": {OpList, func() error { return ignore(v.ListSecretsWithMetadata()) }},
+		"ListSecretsByTag":        {OpList, func() error { return ignore(v.ListSecretsByTag("t")) }},
+		"ListExpiringSecrets":     {OpList, func() error { return ignore(v.ListExpiringSecrets(time.Hour)) }},
+	}
+	// Methods that do not read or change secrets: lifecycle, key
+	// management, vault-wide settings and bookkeeping.
+	exempt := map[string]bool{
+		"Init": true, "InitWithKDF": true, "InitWithOptions": true, "InitWithDEK": true,
+		"Unlock": true, "UnlockWithKey": true, "Lock": true, "IsLocked": true,
+		"ChangePassword": true, "ChangeMasterPassword": true, "KDFParams": true, "SetKDFParams": true,
+		"RotateDEK": true, "Reencrypt": true, "PendingRewrites": true, "ExportDEK": true,
+		"SessionKey": true, "Seal": true, "Unseal": true, "Destroy": true, "Repair": true,
+		"SetAutoLock": true, "AutoLock": true, "Touch": true, "GetLockState": true, "RemainingCooldown": true,
+		"Path": true, "ID": true, "Info": true, "Features": true, "UnlockTimings": true, "QuickCheck": true,
+		"CheckIntegrity": true, "CheckDiskSpace": true, "HasSufficientDiskSpace": true, "IsDiskSpaceLow": true,
+		"Audit": true, "AuditLogger": true, "AuditVerify": true, "SetAuditSource": true, "Events": true,
+		"WatchFiles": true, "Use": true, "Settings": true, "UpdateSettings": true, "MakeReadOnly": true,
+		"UseSnapshot": true, "SnapshotTime": true, "RecordUsage": true,
+		"ExpiryRuleFor": true, "MaskPolicyFor": true, "ActiveFreeze": true,
+		"AddFreezeWindow": true, "RemoveFreezeWindow": true, "FreezeWindows": true,
+		"CreateFolder": true, "GetFolder": true, "GetFolderByPath": true, "ListFolders": true,
+		"UpdateFolder": true, "DeleteFolder": true,
+		"AddShard": true, "RemoveShard": true, "Shards": true,
+		"PluginDir": true, "ListPlugins": true, "DynamicCacheStats": true,
+		"IssueRecoveryKit": true, "RecoveryArtifacts": true, "ForgetRecoveryKit": true,
+		"AddEmergencyContact": true, "RemoveEmergencyContact": true, "EmergencyContacts": true,
+		"RequestEmergencyAccess": true, "EmergencyRequests": true, "DenyEmergencyAccess": true,
+		"ReleaseEmergencyAccess": true,
+	}
+
+	errDenied := errors.New("denied")
+	var ops []string
+	v.Use(func(op Op, next Next) error {
+		ops = append(ops, op.Name)
+		return errDenied
+	})
+
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumMethod(); i++ {
+		name := typ.Method(i).Name
+		w, ok := wrapped[name]
+		if !ok {
+			if !exempt[name] {
+				t.Errorf("%s bypasses middleware: add it to wrapped or exempt", name)
+			}
+			continue
+		}
+		ops = nil
+		if err := w.call(); !errors.Is(err, errDenied) {
+			t.Errorf("%s ran despite middleware: err = %v", name, err)
+		}
+		if want := []string{w.op}; !reflect.DeepEqual(ops, want) {
+			t.Errorf("%s ran as %v, want %v", name, ops, want)
+		}
+	}
+	for name := range exempt {
+		if _, ok := typ.MethodByName(name); !ok {
+			t.Errorf("exempt lists unknown method %s", name)
+		}
+	}
+}
@@ -20,8 +20,34 @@ const (
 	SchemaVersion4 = 4
 	// SchemaVersion5 adds folders table and folder_id column (Phase 2c-X2: Folder Feature)
 	SchemaVersion5 = 5
+	// SchemaVersion6 adds secret_history table for change summaries
+	SchemaVersion6 = 6
+	// SchemaVersion7 adds encrypted_redirect column for key deprecation
+	SchemaVersion7 = 7
+	// SchemaVersion8 adds not_before and access_window columns for access rules
+	SchemaVersion8 = 8
+	// SchemaVersion9 adds immutable column for write-once secrets
+	SchemaVersion9 = 9
+	// SchemaVersion10 adds cert_not_after and encrypted_certificate columns
+	SchemaVersion10 = 10
+	// SchemaVersion11 adds canaries table for decoy secrets
+	SchemaVersion11 = 11
+	// SchemaVersion12 adds rewrite_jobs table for resumable vault-wide rewrites
+	SchemaVersion12 = 12
+	// SchemaVersion13 adds deleted_at column for the trash
+	SchemaVersion13 = 13
+	// SchemaVersion14 adds retired_audit_keys table for DEK rotation
+	SchemaVersion14 = 14
+	// SchemaVersion15 adds attachments table for files attached to secrets
+	SchemaVersion15 = 15
+	// SchemaVersion16 adds secret_access table for last-accessed tracking
+	SchemaVersion16 = 16
+	// SchemaVersion17 adds search_index table for the encrypted search index
+	SchemaVersion17 = 17
+	// SchemaVersion18 adds secret_paths table for prefix listings
+	SchemaVersion18 = 18
 	// CurrentSchemaVersion is the current schema version
-	CurrentSchemaVersion = SchemaVersion5
+	CurrentSchemaVersion = SchemaVersion18
 )
 
 // getSchemaVersion returns the current schema version from the database.
@@ -84,7 +110,12 @@ func migrateSchema(db *sql.DB, vaultPath string) error {
 	if err != nil {
 		return err
 	}
+	return migrateSchemaFrom(db, vaultPath, version)
+}
 
+// migrateSchemaFrom is migrateSchema for a database whose schema version
+// was already read.
+func migrateSchemaFrom(db *sql.DB, vaultPath string, version int) error {
 	// Apply migrations in order
 	if version < SchemaVersion2 {
 		if err := migrateToV2(db); err != nil {
@@ -110,6 +141,84 @@ func migrateSchema(db *sql.DB, vaultPath string) error {
 		}
 	}
 
+	if version < SchemaVersion6 {
+		if err := migrateToV6(db); err != nil {
+			return fmt.Errorf("vault: migration to v6 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion7 {
+		if err := migrateToV7(db); err != nil {
+			return fmt.Errorf("vault: migration to v7 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion8 {
+		if err := migrateToV8(db); err != nil {
+			return fmt.Errorf("vault: migration to v8 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion9 {
+		if err := migrateToV9(db); err != nil {
+			return fmt.Errorf("vault: migration to v9 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion10 {
+		if err := migrateToV10(db); err != nil {
+			return fmt.Errorf("vault: migration to v10 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion11 {
+		if err := migrateToV11(db); err != nil {
+			return fmt.Errorf("vault: migration to v11 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion12 {
+		if err := migrateToV12(db); err != nil {
+			return fmt.Errorf("vault: migration to v12 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion13 {
+		if err := migrateToV13(db); err != nil {
+			return fmt.Errorf("vault: migration to v13 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion14 {
+		if err := migrateToV14(db); err != nil {
+			return fmt.Errorf("vault: migration to v14 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion15 {
+		if err := migrateToV15(db); err != nil {
+			return fmt.Errorf("vault: migration to v15 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion16 {
+		if err := migrateToV16(db); err != nil {
+			return fmt.Errorf("vault: migration to v16 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion17 {
+		if err := migrateToV17(db); err != nil {
+			return fmt.Errorf("vault: migration to v17 failed: %w", err)
+		}
+	}
+
+	if version < SchemaVersion18 {
+		if err := migrateToV18(db); err != nil {
+			return fmt.Errorf("vault: migration to v18 failed: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -428,6 +537,292 @@ func migrateToV5(db *sql.DB) error {
 	return nil
 }
 
+// migrateToV6 adds the secret_history table.
+// This migration:
+// 1. Creates secret_history (one row per saved version of a secret)
+// 2. Creates an index for per-key lookups
+// 3. Updates schema version
+//
+// Existing secrets have no history; it starts with their next update.
+func migrateToV6(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(secretHistoryTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create secret_history table: %w", err)
+	}
+
+	_, err = tx.Exec(secretHistoryIndexSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create idx_secret_history_key: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion6)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV7 adds encrypted_redirect column for key deprecation.
+// This migration:
+// 1. Adds encrypted_redirect column (BLOB, NULL = not deprecated)
+// 2. Updates schema version
+//
+// The replacement key name is encrypted like all key names.
+func migrateToV7(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := getTableColumns(tx, "secrets")
+	if err != nil {
+		return fmt.Errorf("failed to get secrets columns: %w", err)
+	}
+
+	if !columns["encrypted_redirect"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN encrypted_redirect BLOB")
+		if err != nil {
+			return fmt.Errorf("failed to add encrypted_redirect column: %w", err)
+		}
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion7)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV8 adds access rule columns.
+// This migration:
+// 1. Adds not_before column (TIMESTAMP, NULL = no start date)
+// 2. Adds access_window column (TEXT JSON, NULL = readable at any time)
+// 3. Updates schema version
+func migrateToV8(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := getTableColumns(tx, "secrets")
+	if err != nil {
+		return fmt.Errorf("failed to get secrets columns: %w", err)
+	}
+
+	if !columns["not_before"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN not_before TIMESTAMP")
+		if err != nil {
+			return fmt.Errorf("failed to add not_before column: %w", err)
+		}
+	}
+
+	if !columns["access_window"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN access_window TEXT")
+		if err != nil {
+			return fmt.Errorf("failed to add access_window column: %w", err)
+		}
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion8)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV9 adds immutable column for write-once secrets.
+// This migration:
+// 1. Adds immutable column (INTEGER, 0 = mutable)
+// 2. Updates schema version
+func migrateToV9(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := getTableColumns(tx, "secrets")
+	if err != nil {
+		return fmt.Errorf("failed to get secrets columns: %w", err)
+	}
+
+	if !columns["immutable"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN immutable INTEGER NOT NULL DEFAULT 0")
+		if err != nil {
+			return fmt.Errorf("failed to add immutable column: %w", err)
+		}
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion9)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV10 adds certificate tracking columns.
+// This migration:
+// 1. Adds cert_not_after column (TIMESTAMP, plaintext like expires_at)
+// 2. Adds encrypted_certificate column (BLOB, encrypted subject/SANs/issuer)
+// 3. Updates schema version
+//
+// Existing certificates are picked up the next time their secret is written.
+func migrateToV10(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := getTableColumns(tx, "secrets")
+	if err != nil {
+		return fmt.Errorf("failed to get secrets columns: %w", err)
+	}
+
+	if !columns["cert_not_after"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN cert_not_after TIMESTAMP")
+		if err != nil {
+			return fmt.Errorf("failed to add cert_not_after column: %w", err)
+		}
+	}
+	if !columns["encrypted_certificate"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN encrypted_certificate BLOB")
+		if err != nil {
+			return fmt.Errorf("failed to add encrypted_certificate column: %w", err)
+		}
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion10)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV11 adds the canaries table for decoy secrets.
+func migrateToV11(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(canaryTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create canaries table: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion11)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV12 adds the rewrite_jobs table for resumable vault-wide rewrites.
+func migrateToV12(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(rewriteJobsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create rewrite_jobs table: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion12)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV13 adds the deleted_at column. Deleted secrets stay in the
+// table with deleted_at set until they are purged.
+func migrateToV13(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := getTableColumns(tx, "secrets")
+	if err != nil {
+		return fmt.Errorf("failed to get secrets columns: %w", err)
+	}
+
+	if !columns["deleted_at"] {
+		_, err = tx.Exec("ALTER TABLE secrets ADD COLUMN deleted_at TIMESTAMP")
+		if err != nil {
+			return fmt.Errorf("failed to add deleted_at column: %w", err)
+		}
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion13)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
 // getTableColumnsFromDB returns a map of column names for a table using db connection.
 // Unlike getTableColumns, this uses *sql.DB instead of *sql.Tx.
 func getTableColumnsFromDB(db *sql.DB, tableName string) (map[string]bool, error) {
@@ -451,3 +846,142 @@ func getTableColumnsFromDB(db *sql.DB, tableName string) (map[string]bool, error
 
 	return columns, rows.Err()
 }
+
+// migrateToV14 adds the retired_audit_keys table kept by RotateDEK.
+func migrateToV14(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(retiredAuditKeysTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create retired_audit_keys table: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion14)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV15 adds the attachments table.
+func migrateToV15(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(attachmentsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create attachments table: %w", err)
+	}
+	_, err = tx.Exec(attachmentsIndexSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create attachments index: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion15)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV16 adds the secret_access table.
+func migrateToV16(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(secretAccessTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create secret_access table: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion16)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV17 adds the search_index table.
+func migrateToV17(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(searchIndexTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create search_index table: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion17)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToV18 adds the secret_paths table. Its rows are keyed hashes, so
+// existing secrets are indexed by the first listing after unlock.
+func migrateToV18(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(secretPathsTableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create secret_paths table: %w", err)
+	}
+	_, err = tx.Exec(secretPathsIndexSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create secret_paths index: %w", err)
+	}
+
+	// Update schema version
+	_, err = tx.Exec("INSERT OR REPLACE INTO schema_version (version) VALUES (?)", SchemaVersion18)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+
+	return nil
+}
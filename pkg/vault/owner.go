@@ -0,0 +1,51 @@
+package vault
+
+import "strings"
+
+// ListSecretsByOwner retrieves secrets whose owner and team match
+// (includes metadata, NOT values). Names compare case-insensitively; an
+// empty owner or team matches any.
+func (v *Vault) ListSecretsByOwner(owner, team string) ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		return v.listSecretsByOwner(owner, team)
+	})
+}
+
+func (v *Vault) listSecretsByOwner(owner, team string) ([]*SecretEntry, error) {
+	entries, err := v.listSecretsWithMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []*SecretEntry
+	for _, entry := range entries {
+		var entryOwner, entryTeam string
+		if entry.Metadata != nil {
+			entryOwner, entryTeam = entry.Metadata.Owner, entry.Metadata.Team
+		}
+		if owner != "" && !strings.EqualFold(entryOwner, owner) {
+			continue
+		}
+		if team != "" && !strings.EqualFold(entryTeam, team) {
+			continue
+		}
+		secrets = append(secrets, entry)
+	}
+	return secrets, nil
+}
+
+// ownerContext returns the audit context for a secret's owner and team,
+// or nil if neither is set.
+func ownerContext(metadata *SecretMetadata) map[string]interface{} {
+	if metadata == nil || (metadata.Owner == "" && metadata.Team == "") {
+		return nil
+	}
+	ctx := make(map[string]interface{}, 2)
+	if metadata.Owner != "" {
+		ctx["owner"] = metadata.Owner
+	}
+	if metadata.Team != "" {
+		ctx["team"] = metadata.Team
+	}
+	return ctx
+}
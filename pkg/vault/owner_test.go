@@ -0,0 +1,62 @@
+package vault
+
+import "testing"
+
+func TestListSecretsByOwner(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	secrets := map[string]*SecretMetadata{
+		"db/prod":   {Owner: "alice", Team: "platform"},
+		"db/dev":    {Owner: "bob", Team: "platform"},
+		"stripe":    {Owner: "Alice", Team: "payments", Notes: "live key"},
+		"unowned":   nil,
+		"team-only": {Team: "payments"},
+	}
+	for key, meta := range secrets {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("x"), Metadata: meta}); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+
+	tests := []struct {
+		owner, team string
+		want        []string
+	}{
+		{"alice", "", []string{"db/prod", "stripe"}},
+		{"", "payments", []string{"stripe", "team-only"}},
+		{"alice", "platform", []string{"db/prod"}},
+		{"carol", "", nil},
+	}
+	for _, tt := range tests {
+		entries, err := v.ListSecretsByOwner(tt.owner, tt.team)
+		if err != nil {
+			t.Fatalf("ListSecretsByOwner(%q, %q) failed: %v", tt.owner, tt.team, err)
+		}
+		got := map[string]bool{}
+		for _, e := range entries {
+			got[e.Key] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ListSecretsByOwner(%q, %q) = %v, want %v", tt.owner, tt.team, got, tt.want)
+			continue
+		}
+		for _, key := range tt.want {
+			if !got[key] {
+				t.Errorf("ListSecretsByOwner(%q, %q) missing %s", tt.owner, tt.team, key)
+			}
+		}
+	}
+
+	entry, err := v.GetSecret("stripe")
+	if err != nil || entry.Metadata.Owner != "Alice" || entry.Metadata.Team != "payments" {
+		t.Errorf("GetSecret owner/team = %+v, %v", entry.Metadata, err)
+	}
+}
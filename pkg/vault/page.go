@@ -0,0 +1,113 @@
+package vault
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Page sizes for ListSecretsPage
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ErrInvalidCursor is returned for a cursor not produced by ListSecretsPage.
+var ErrInvalidCursor = errors.New("vault: invalid list cursor")
+
+// SecretPage is one page of secrets from ListSecretsPage.
+type SecretPage struct {
+	Secrets []*SecretEntry
+	// NextCursor continues the listing after this page; empty on the last page
+	NextCursor string
+}
+
+// ListSecretsPage returns up to limit secrets with metadata (NOT values),
+// in the order of ListSecretsWithMetadata, starting after cursor. Pass an
+// empty cursor for the first page and the previous page's NextCursor for
+// the next. limit is DefaultPageSize if not positive and capped at
+// MaxPageSize. Only the key names and metadata of one page are decrypted.
+//
+// Secrets added while paging appear on a later page if they sort after
+// the cursor; none are repeated or skipped otherwise.
+func (v *Vault) ListSecretsPage(cursor string, limit int) (*SecretPage, error) {
+	return runValue(v, Op{Name: OpList}, func() (*SecretPage, error) {
+		return v.listSecretsPage(cursor, limit)
+	})
+}
+
+func (v *Vault) listSecretsPage(cursor string, limit int) (*SecretPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	where := "deleted_at IS NULL"
+	var args []any
+	if cursor != "" {
+		createdAt, keyHash, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		where += " AND (created_at > ? OR (created_at = ? AND key_hash > ?))"
+		args = append(args, createdAt, createdAt, keyHash)
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	// One extra row tells whether there is a next page
+	rows, err := v.db.Query(`
+		SELECT `+secretMetadataColumns+`, key_hash, CAST(created_at AS TEXT)
+		FROM secrets
+		WHERE `+where+`
+		ORDER BY created_at, key_hash
+		LIMIT ?`, append(args, limit+1)...)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	page := &SecretPage{}
+	var lastCreatedAt, lastKeyHash string
+	for rows.Next() {
+		if len(page.Secrets) == limit {
+			page.NextCursor = encodeCursor(lastCreatedAt, lastKeyHash)
+			break
+		}
+		entry, err := v.scanSecretEntryRowWithMetadata(rows, &lastKeyHash, &lastCreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		page.Secrets = append(page.Secrets, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	return page, nil
+}
+
+// encodeCursor makes an opaque cursor from the sort position of a row.
+// Key hashes are not secret, but the cursor is still opaque to callers so
+// that its format can change.
+func encodeCursor(createdAt, keyHash string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt + "\n" + keyHash))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (createdAt, keyHash string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", ErrInvalidCursor
+	}
+	createdAt, keyHash, ok := strings.Cut(string(raw), "\n")
+	if !ok || createdAt == "" || keyHash == "" {
+		return "", "", ErrInvalidCursor
+	}
+	return createdAt, keyHash, nil
+}
@@ -0,0 +1,76 @@
+package vault_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+	"github.com/forest6511/secretctl/pkg/vault/vaulttest"
+)
+
+func TestListSecretsPage(t *testing.T) {
+	// Created within the same second, so the key hash breaks the ties
+	builder := vaulttest.NewBuilder(25).WithSecrets(25).WithAuditEvents(0)
+	keys, _, err := builder.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	v := builder.MustBuild(t)
+
+	want := make(map[string]bool)
+	for _, key := range keys {
+		want[key] = true
+	}
+	if err := v.DeleteSecret(keys[0]); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	delete(want, keys[0])
+
+	seen := make(map[string]bool)
+	cursor := ""
+	pages := 0
+	for {
+		page, err := v.ListSecretsPage(cursor, 10)
+		if err != nil {
+			t.Fatalf("ListSecretsPage failed: %v", err)
+		}
+		pages++
+		for _, entry := range page.Secrets {
+			if seen[entry.Key] {
+				t.Errorf("%s listed twice", entry.Key)
+			}
+			seen[entry.Key] = true
+			if entry.Value != nil {
+				t.Errorf("%s: page includes the value", entry.Key)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if len(page.Secrets) != 10 {
+			t.Errorf("page %d has %d secrets, want 10", pages, len(page.Secrets))
+		}
+		cursor = page.NextCursor
+	}
+	if pages != 3 {
+		t.Errorf("got %d pages, want 3", pages)
+	}
+	if len(seen) != len(want) {
+		t.Errorf("listed %d secrets, want %d", len(seen), len(want))
+	}
+	for key := range want {
+		if !seen[key] {
+			t.Errorf("%s not listed", key)
+		}
+	}
+
+	// An exact multiple of the limit ends without an empty page
+	page, err := v.ListSecretsPage("", 24)
+	if err != nil || len(page.Secrets) != 24 || page.NextCursor != "" {
+		t.Errorf("ListSecretsPage(24) = %d secrets, cursor %q, %v", len(page.Secrets), page.NextCursor, err)
+	}
+
+	if _, err := v.ListSecretsPage("not a cursor!", 10); !errors.Is(err, vault.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// secretPathsTableSQL creates the table that lets ListSecretsByPrefix
+// filter in SQL. Each secret has one row per directory of its key,
+// including the root: "aws/prod/DB_URL" has rows for "", "aws/" and
+// "aws/prod/". Directories are stored as keyed hashes like key_hash, so
+// the table reveals how keys nest but not their names.
+const secretPathsTableSQL = `
+	CREATE TABLE IF NOT EXISTS secret_paths (
+		path_hash TEXT NOT NULL,
+		key_hash TEXT NOT NULL,
+		PRIMARY KEY (path_hash, key_hash)
+	) WITHOUT ROWID
+`
+
+const secretPathsIndexSQL = "CREATE INDEX IF NOT EXISTS idx_secret_paths_key ON secret_paths(key_hash)"
+
+// keyDirs returns the directories of key: "" and every prefix of key
+// ending in '/'.
+func keyDirs(key string) []string {
+	dirs := []string{""}
+	for i, c := range key {
+		if c == '/' {
+			dirs = append(dirs, key[:i+1])
+		}
+	}
+	return dirs
+}
+
+// prefixDir returns the longest directory that prefix starts with.
+func prefixDir(prefix string) string {
+	return prefix[:strings.LastIndex(prefix, "/")+1]
+}
+
+// hashDir returns the path_hash of dir. The "path" label keeps directory
+// hashes apart from key hashes. Caller must hold v.mu.
+func (v *Vault) hashDir(dir string) string {
+	return hmacHex(v.dek, []byte("path\x00"+dir))
+}
+
+// indexPathsTx adds the secret_paths rows of key. Caller must hold v.mu.
+func (v *Vault) indexPathsTx(tx *sql.Tx, keyHash, key string) error {
+	for _, dir := range keyDirs(key) {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO secret_paths (path_hash, key_hash) VALUES (?, ?)", v.hashDir(dir), keyHash); err != nil {
+			return fmt.Errorf("vault: failed to index key path: %w", err)
+		}
+	}
+	return nil
+}
+
+// indexPaths adds the secret_paths rows of secrets that have none, given
+// as key hash to key. Secrets stored before schema version 18 or since the
+// last DEK rotation are indexed this way the first time a listing meets
+// them. It is best effort like recordAccess.
+func (v *Vault) indexPaths(keys map[string]string) {
+	if len(keys) == 0 {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil || v.checkWritable() != nil {
+		return
+	}
+	err := func() error {
+		tx, err := v.db.Begin()
+		if err != nil {
+			return fmt.Errorf("vault: failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for keyHash, key := range keys {
+			// The secret may have been purged since it was listed
+			var exists bool
+			if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM secrets WHERE key_hash = ?)", keyHash).Scan(&exists); err != nil {
+				return fmt.Errorf("vault: failed to query secrets: %w", err)
+			}
+			if !exists {
+				continue
+			}
+			if err := v.indexPathsTx(tx, keyHash, key); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		slog.Debug("vault: failed to index key paths", "err", err)
+	}
+}
@@ -0,0 +1,100 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// ErrFieldRequired is returned when a multi-field secret is read without
+// naming a field.
+var ErrFieldRequired = errors.New("vault: secret has multiple fields; a field name is required")
+
+// valueReader serves a decrypted value and wipes each chunk once it has
+// been copied out, so the plaintext does not outlive the read.
+type valueReader struct {
+	buf []byte
+	off int
+}
+
+func newValueReader(buf []byte) *valueReader {
+	return &valueReader{buf: buf}
+}
+
+func (r *valueReader) Read(p []byte) (int, error) {
+	if r.buf == nil || r.off >= len(r.buf) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.buf[r.off:])
+	crypto.SecureWipe(r.buf[r.off : r.off+n])
+	r.off += n
+	return n, nil
+}
+
+// Close wipes whatever was not read.
+func (r *valueReader) Close() error {
+	if r.buf != nil {
+		crypto.SecureWipe(r.buf)
+		r.buf = nil
+	}
+	return nil
+}
+
+// OpenSecretReader opens the value of a secret for streaming, e.g. to write
+// a certificate to a file. field selects a field by name or alias; empty
+// means the value of a single-value secret. Reads are checked and audited
+// like GetSecret.
+//
+// Each secret is sealed as one AES-GCM blob, which cannot be authenticated
+// until all of it is decrypted, so the value is decrypted up front into a
+// single buffer. The reader hands it out in the caller's chunks, wiping
+// each as it goes, and Close wipes the rest.
+func (v *Vault) OpenSecretReader(key, field string) (io.ReadCloser, error) {
+	return v.openSecretReader(key, field, readOptions{})
+}
+
+// OpenSecretReaderAllowExpired is OpenSecretReader ignoring the
+// block_expired_reads setting, like GetSecretAllowExpired.
+func (v *Vault) OpenSecretReaderAllowExpired(key, field string) (io.ReadCloser, error) {
+	return v.openSecretReader(key, field, readOptions{allowExpired: true})
+}
+
+func (v *Vault) openSecretReader(key, field string, opts readOptions) (io.ReadCloser, error) {
+	entry, err := v.readSecret(key, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(entry.Value)
+
+	var value string
+	switch {
+	case field != "":
+		_, f, err := ResolveFieldName(entry.Fields, field)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+		}
+		value = f.Value
+	case len(entry.Fields) == 0:
+		buf := make([]byte, len(entry.Value))
+		copy(buf, entry.Value)
+		return newValueReader(buf), nil
+	case IsSingleFieldSecret(entry.Fields):
+		value = GetDefaultFieldValue(entry.Fields)
+	default:
+		return nil, ErrFieldRequired
+	}
+	return newValueReader([]byte(value)), nil
+}
+
+// OpenAttachmentReader opens an attachment for streaming, with the same
+// checks and audit record as GetAttachment. Close wipes the decrypted data
+// that was not read.
+func (v *Vault) OpenAttachmentReader(key, name string) (io.ReadCloser, error) {
+	data, err := v.GetAttachment(key, name)
+	if err != nil {
+		return nil, err
+	}
+	return newValueReader(data), nil
+}
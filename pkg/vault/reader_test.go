@@ -0,0 +1,83 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestOpenSecretReader(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	cert := bytes.Repeat([]byte("-----CERT-----\n"), 4096)
+	if err := v.SetSecret("tls/cert", &SecretEntry{Value: cert}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("db", &SecretEntry{Fields: map[string]Field{
+		"password": {Value: "s3cret", Sensitive: true, Aliases: []string{"pw"}},
+		"username": {Value: "admin"},
+	}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	r, err := v.OpenSecretReader("tls/cert", "")
+	if err != nil {
+		t.Fatalf("OpenSecretReader failed: %v", err)
+	}
+	vr := r.(*valueReader)
+	chunk := make([]byte, 1000)
+	n, _ := io.ReadFull(r, chunk)
+	if !bytes.Equal(chunk[:n], cert[:n]) {
+		t.Fatal("first chunk does not match")
+	}
+	if !bytes.Equal(vr.buf[:n], make([]byte, n)) {
+		t.Error("read chunk was not wiped")
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(append(chunk[:n], rest...), cert) {
+		t.Fatalf("streamed value does not match (%v)", err)
+	}
+	buf := vr.buf
+	r.Close()
+	if !bytes.Equal(buf, make([]byte, len(buf))) {
+		t.Error("buffer not wiped on Close")
+	}
+
+	r, err = v.OpenSecretReader("db", "pw")
+	if err != nil {
+		t.Fatalf("OpenSecretReader by alias failed: %v", err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != "s3cret" {
+		t.Errorf("field value = %q", got)
+	}
+	r.Close()
+
+	if _, err := v.OpenSecretReader("db", ""); !errors.Is(err, ErrFieldRequired) {
+		t.Errorf("expected ErrFieldRequired, got %v", err)
+	}
+	if _, err := v.OpenSecretReader("db", "nope"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("expected ErrFieldNotFound, got %v", err)
+	}
+	if _, err := v.OpenSecretReader("missing", ""); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+
+	// Streaming reads count as accesses
+	stale, err := v.ListStaleSecrets(0)
+	if err != nil {
+		t.Fatalf("ListStaleSecrets failed: %v", err)
+	}
+	for _, e := range stale {
+		if e.AccessCount == 0 {
+			t.Errorf("%s: read not recorded", e.Key)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"errors"
+	"time"
+)
+
+// Read-only and expiring vault errors
+var (
+	ErrVaultReadOnly = errors.New("vault: vault is read-only")
+	ErrVaultExpired  = errors.New("vault: vault has expired")
+)
+
+// MakeReadOnly makes the vault read-only and, if expiresAt is non-nil,
+// unusable after expiresAt. It is the last change such a vault accepts.
+func (v *Vault) MakeReadOnly(expiresAt *time.Time) error {
+	return v.UpdateSettings(func(s *VaultSettings) error {
+		s.ReadOnly = true
+		s.ExpiresAt = expiresAt
+		return nil
+	})
+}
+
+// checkWritable returns ErrVaultReadOnly for read-only vaults,
+// ErrSnapshotReadOnly while the vault reads a snapshot, and ErrKeyRotated
+// once another process has rotated the DEK. It fails closed: if the
+// metadata cannot be read, neither can the read-only flag, so the write
+// is refused with the read error.
+func (v *Vault) checkWritable() error {
+	if v.snapshot != nil {
+		return ErrSnapshotReadOnly
+	}
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	if meta.Settings != nil && meta.Settings.ReadOnly {
+		return ErrVaultReadOnly
+	}
+	if v.dek != nil && !meta.KeyRotatedAt.Equal(v.keyRotatedAt) {
+		return ErrKeyRotated
+	}
+	return nil
+}
+
+// checkExpiry returns ErrVaultExpired once the vault's expires_at setting
+// has passed.
+func (v *Vault) checkExpiry(now time.Time) error {
+	if settings, err := v.Settings(); err == nil && settings.ExpiresAt != nil && !now.Before(*settings.ExpiresAt) {
+		return ErrVaultExpired
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMakeReadOnly(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("ci/token", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	if err := v.MakeReadOnly(&expires); err != nil {
+		t.Fatalf("MakeReadOnly failed: %v", err)
+	}
+	if _, err := v.GetSecret("ci/token"); err != nil {
+		t.Errorf("reads should still work: %v", err)
+	}
+	if err := v.SetSecret("ci/other", &SecretEntry{Value: []byte("v")}); !errors.Is(err, ErrVaultReadOnly) {
+		t.Errorf("SetSecret = %v, want ErrVaultReadOnly", err)
+	}
+	if err := v.DeleteSecret("ci/token"); !errors.Is(err, ErrVaultReadOnly) {
+		t.Errorf("DeleteSecret = %v, want ErrVaultReadOnly", err)
+	}
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.ReadOnly = false
+		return nil
+	}); !errors.Is(err, ErrVaultReadOnly) {
+		t.Errorf("UpdateSettings = %v, want ErrVaultReadOnly", err)
+	}
+	if err := v.CreateFolder(&Folder{Name: "f"}); !errors.Is(err, ErrVaultReadOnly) {
+		t.Errorf("CreateFolder = %v, want ErrVaultReadOnly", err)
+	}
+	v.Lock()
+
+	if err := v.checkExpiry(expires.Add(time.Second)); !errors.Is(err, ErrVaultExpired) {
+		t.Errorf("checkExpiry after expiry = %v, want ErrVaultExpired", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock before expiry failed: %v", err)
+	}
+}
+
+func TestCheckWritableFailsClosed(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	// Without readable metadata the read-only flag is unknown
+	if err := os.WriteFile(filepath.Join(dir, MetaFileName), []byte("{"), 0600); err != nil {
+		t.Fatalf("failed to corrupt metadata: %v", err)
+	}
+	if err := v.SetSecret("ci/token", &SecretEntry{Value: []byte("v")}); !errors.Is(err, ErrMetadataCorrupted) {
+		t.Errorf("SetSecret = %v, want ErrMetadataCorrupted", err)
+	}
+	if err := os.Remove(filepath.Join(dir, MetaFileName)); err != nil {
+		t.Fatalf("failed to remove metadata: %v", err)
+	}
+	if err := v.SetSecret("ci/token", &SecretEntry{Value: []byte("v")}); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("SetSecret = %v, want ErrVaultNotFound", err)
+	}
+}
@@ -0,0 +1,256 @@
+package vault
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// Rebuild errors
+var (
+	ErrInvalidDEK  = errors.New("vault: DEK must be 32 bytes")
+	ErrDEKMismatch = errors.New("vault: DEK does not decrypt the existing database")
+)
+
+// RecoveryKitFileName is the suggested file name of a recovery kit.
+const RecoveryKitFileName = "secretctl-recovery-kit.txt"
+
+// ExportDEK returns a copy of the DEK for escrow in a recovery kit, from
+// which InitWithDEK can rebuild the vault. The export is audited. Callers
+// must wipe the returned key.
+func (v *Vault) ExportDEK() ([]byte, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultDEKExport, v.auditSource(), "")
+	return append([]byte(nil), v.dek...), nil
+}
+
+// WriteRecoveryKit writes dek to a new file at path in a form accepted by
+// `secretctl init --from-dek-file`. An existing file is never replaced.
+func WriteRecoveryKit(path, vaultDir string, dek []byte, now time.Time) error {
+	if len(dek) != DEKLength {
+		return ErrInvalidDEK
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("recovery kit already exists: %s", path)
+		}
+		return fmt.Errorf("failed to create recovery kit: %w", err)
+	}
+	kit := fmt.Sprintf(`# secretctl recovery kit
+# Created: %s
+# Vault:   %s
+#
+# The line below is the vault's data encryption key. Together with vault.db
+# or a backup of it, it decrypts every secret without the master password.
+# Store this file offline (printed, or on encrypted removable media) and
+# never next to the vault.
+#
+# If vault.salt or vault.meta are lost, rebuild the vault with:
+#   secretctl init --from-dek-file <this file>
+%x
+`, now.UTC().Format(time.RFC3339), vaultDir, dek)
+	if _, err := f.WriteString(kit); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write recovery kit: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write recovery kit: %w", err)
+	}
+	return nil
+}
+
+// InitWithDEK initializes a vault around an existing DEK, for disaster
+// recovery when vault.salt or vault.meta were lost but the DEK was escrowed
+// (recovery kit, Shamir shares).
+//
+// If vault.db exists it is kept: the DEK is checked against the stored
+// secrets, the schema is migrated, and the DEK is re-wrapped with a new salt
+// and masterPassword. Otherwise an empty vault is created that uses dek.
+// An existing vault.meta is kept so that settings survive the rebuild.
+func (v *Vault) InitWithDEK(dek []byte, masterPassword string) (err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.exists() {
+		return ErrVaultAlreadyExists
+	}
+	if len(dek) != DEKLength {
+		return ErrInvalidDEK
+	}
+	if err := v.checkFeatures(); err != nil {
+		return err
+	}
+	if err := v.checkDiskSpaceForWrite(1024 * 1024); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(v.path, DirMode); err != nil {
+		return fmt.Errorf("vault: failed to create vault directory: %w", err)
+	}
+
+	// 1. New salt. The salt file is written first because migrating a
+	// pre-v4 database reads it; it is removed again if the rebuild fails
+	// so that the vault does not look initialized.
+	salt := make([]byte, SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("vault: failed to generate salt: %w", err)
+	}
+	saltPath := filepath.Join(v.path, SaltFileName)
+	if err := atomicfile.Write(saltPath, salt, FileMode); err != nil {
+		return fmt.Errorf("vault: failed to write salt file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(saltPath)
+		}
+	}()
+
+	// 2. Wrap the DEK with a KEK derived from the new password, using the
+	// KDF parameters of a surviving vault.meta
+	params, err := v.kdfParams()
+	if err != nil {
+		return err
+	}
+	passwordBytes := []byte(masterPassword)
+	defer crypto.SecureWipe(passwordBytes)
+	kek := crypto.DeriveKeyWithParams(passwordBytes, salt, params)
+	defer crypto.SecureWipe(kek)
+
+	encryptedDEK, nonce, err := crypto.Encrypt(kek, dek)
+	if err != nil {
+		return fmt.Errorf("vault: failed to encrypt DEK: %w", err)
+	}
+
+	// 3. Open the existing database or create a new one
+	dbPath := filepath.Join(v.path, DBFileName)
+	_, statErr := os.Stat(dbPath)
+	existing := statErr == nil
+
+	if !existing {
+		f, err := os.OpenFile(dbPath, os.O_CREATE|os.O_RDWR, FileMode)
+		if err != nil {
+			return fmt.Errorf("vault: failed to create database file: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("vault: failed to close database file: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				os.Remove(dbPath)
+			}
+		}()
+	}
+	if err := os.Chmod(dbPath, FileMode); err != nil {
+		return fmt.Errorf("vault: failed to set database permissions: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("vault: failed to open database: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// The cipher suite of the data comes from a surviving vault.meta, or
+	// else from the database itself
+	suite := CipherAES256GCM
+	meta, metaErr := v.readMeta()
+	if metaErr == nil {
+		suite = meta.cipher()
+	}
+	if existing {
+		if metaErr == nil {
+			err = verifyDEK(db, suite, dek)
+		} else {
+			suite, err = detectCipher(db, dek)
+		}
+		if err != nil {
+			return err
+		}
+		if err := migrateSchema(db, v.path); err != nil {
+			return fmt.Errorf("vault: schema migration failed: %w", err)
+		}
+	} else if err := v.createTables(db); err != nil {
+		return fmt.Errorf("vault: failed to create tables: %w", err)
+	}
+
+	// 4. Store the re-wrapped DEK
+	_, err = db.Exec("INSERT OR REPLACE INTO vault_keys(id, salt, encrypted_dek, dek_nonce) VALUES(1, ?, ?, ?)",
+		salt, encryptedDEK, nonce)
+	if err != nil {
+		return fmt.Errorf("vault: failed to save encrypted DEK: %w", err)
+	}
+
+	// 5. Recreate vault.meta if it was lost
+	if metaErr != nil {
+		meta := &VaultMeta{Version: "1.0.0", CreatedAt: time.Now().UTC(), Features: map[string]string{FeatureCipher: suite}}
+		// The shard list was lost with the settings; take it from the files
+		if shards := v.shardFiles(); len(shards) > 0 {
+			meta.Settings = &VaultSettings{Shards: shards}
+		}
+		if err := v.writeMeta(meta); err != nil {
+			return err
+		}
+	}
+
+	// Forget failed attempts recorded against the old password
+	_ = v.clearLockState()
+
+	if settings, err := v.Settings(); err == nil {
+		v.applyAuditSettings(settings)
+	}
+	if err := v.audit.SetHMACKey(dek); err != nil {
+		v.warn(EventWarning, "failed to initialize audit logger: %v", err)
+	} else if err := v.setRetiredAuditKeys(db, suite, dek); err != nil {
+		v.warn(EventWarning, "failed to initialize audit logger: %v", err)
+	} else {
+		_ = v.audit.LogSuccess(audit.OpVaultRebuild, v.auditSource(), "")
+	}
+
+	return nil
+}
+
+// verifyDEK checks that dek decrypts a stored key name with the cipher
+// suite. A database without secrets cannot be checked and is accepted.
+func verifyDEK(db *sql.DB, suite string, dek []byte) error {
+	var blob []byte
+	err := db.QueryRow("SELECT encrypted_key FROM secrets LIMIT 1").Scan(&blob)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("vault: failed to read existing database: %w", err)
+	}
+	plaintext, err := decryptBlob(suite, dek, blob)
+	if err != nil {
+		return ErrDEKMismatch
+	}
+	crypto.SecureWipe(plaintext)
+	return nil
+}
+
+// detectCipher returns the cipher suite under which dek decrypts the
+// database, for a vault whose vault.meta was lost.
+func detectCipher(db *sql.DB, dek []byte) (string, error) {
+	for _, suite := range crypto.Ciphers() {
+		err := verifyDEK(db, suite, dek)
+		if !errors.Is(err, ErrDEKMismatch) {
+			return suite, err
+		}
+	}
+	return "", ErrDEKMismatch
+}
@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitWithDEK(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	if err := v.Init("oldpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("oldpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("db/password", &SecretEntry{Value: []byte("hunter2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	dek := bytes.Clone(v.dek)
+	v.Lock()
+
+	if err := v.InitWithDEK(dek, "newpassword123"); !errors.Is(err, ErrVaultAlreadyExists) {
+		t.Errorf("expected ErrVaultAlreadyExists, got %v", err)
+	}
+
+	// Lose the salt and meta files
+	for _, name := range []string{SaltFileName, MetaFileName} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("failed to remove %s: %v", name, err)
+		}
+	}
+
+	if err := v.InitWithDEK(dek[:16], "newpassword123"); !errors.Is(err, ErrInvalidDEK) {
+		t.Errorf("expected ErrInvalidDEK, got %v", err)
+	}
+	wrongDEK := bytes.Repeat([]byte{0x42}, DEKLength)
+	if err := v.InitWithDEK(wrongDEK, "newpassword123"); !errors.Is(err, ErrDEKMismatch) {
+		t.Errorf("expected ErrDEKMismatch, got %v", err)
+	}
+	if v.exists() {
+		t.Fatal("failed rebuild must not leave a salt file behind")
+	}
+
+	v = New(dir)
+	if err := v.InitWithDEK(dek, "newpassword123"); err != nil {
+		t.Fatalf("InitWithDEK failed: %v", err)
+	}
+	if err := v.Unlock("oldpassword123"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("expected old password to be rejected, got %v", err)
+	}
+	if err := v.Unlock("newpassword123"); err != nil {
+		t.Fatalf("Unlock with new password failed: %v", err)
+	}
+	defer v.Lock()
+
+	entry, err := v.GetSecret("db/password")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if string(entry.Value) != "hunter2" {
+		t.Errorf("expected existing secret to survive, got %q", entry.Value)
+	}
+	if result, err := v.CheckIntegrity(); err != nil || !result.MetaValid {
+		t.Errorf("expected vault.meta to be recreated, got %+v, %v", result, err)
+	}
+}
+
+func TestInitWithDEKNewVault(t *testing.T) {
+	dir := t.TempDir()
+	dek := bytes.Repeat([]byte{0x07}, DEKLength)
+
+	v := New(dir)
+	if err := v.InitWithDEK(dek, "testpassword123"); err != nil {
+		t.Fatalf("InitWithDEK failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if !bytes.Equal(v.dek, dek) {
+		t.Error("expected the vault to use the given DEK")
+	}
+	if err := v.SetSecret("api/key", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Errorf("SetSecret failed: %v", err)
+	}
+}
+
+func TestExportDEK(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := v.ExportDEK(); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	dek, err := v.ExportDEK()
+	if err != nil {
+		t.Fatalf("ExportDEK failed: %v", err)
+	}
+	if !bytes.Equal(dek, v.dek) {
+		t.Error("ExportDEK returned a different key")
+	}
+	dek[0] ^= 0xff
+	if bytes.Equal(dek, v.dek) {
+		t.Error("ExportDEK must return a copy")
+	}
+}
@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// RecoveryKitRecord is a recovery kit written by IssueRecoveryKit, recorded
+// in vault.meta until RotateDEK invalidates it.
+type RecoveryKitRecord struct {
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Kinds of RecoveryArtifact
+const (
+	ArtifactRecoveryKit      = "recovery_kit"
+	ArtifactEmergencyContact = "emergency_contact"
+)
+
+// RecoveryArtifact is a way to open the vault without the master password.
+type RecoveryArtifact struct {
+	// Kind is ArtifactRecoveryKit or ArtifactEmergencyContact
+	Kind string
+	// Name is the path of a recovery kit or the name of a contact
+	Name      string
+	CreatedAt time.Time
+}
+
+// IssueRecoveryKit writes a recovery kit for the current DEK to a new file
+// at path (see WriteRecoveryKit) and records it, so that RecoveryArtifacts
+// can list it. The export is audited.
+func (v *Vault) IssueRecoveryKit(path string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	now := time.Now().UTC()
+	if err := WriteRecoveryKit(path, v.path, v.dek, now); err != nil {
+		return err
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultDEKExport, v.auditSource(), "")
+
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	meta.RecoveryKits = slices.DeleteFunc(meta.RecoveryKits, func(k RecoveryKitRecord) bool {
+		return k.Path == path
+	})
+	meta.RecoveryKits = append(meta.RecoveryKits, RecoveryKitRecord{Path: path, CreatedAt: now})
+	return v.writeMeta(meta)
+}
+
+// RecoveryArtifacts lists the recorded recovery kits and the emergency
+// contacts. They hold the DEK, or a copy sealed to the contact, so they
+// stay valid when the master password changes; only RotateDEK invalidates
+// them (and re-seals the DEK to the contacts that remain). Kits written
+// with WriteRecoveryKit directly, and agent sessions, are not listed. The
+// vault does not need to be unlocked.
+func (v *Vault) RecoveryArtifacts() ([]RecoveryArtifact, error) {
+	meta, err := v.readMeta()
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []RecoveryArtifact
+	for _, k := range meta.RecoveryKits {
+		artifacts = append(artifacts, RecoveryArtifact{Kind: ArtifactRecoveryKit, Name: k.Path, CreatedAt: k.CreatedAt})
+	}
+	if meta.Settings != nil {
+		for _, c := range meta.Settings.EmergencyContacts {
+			artifacts = append(artifacts, RecoveryArtifact{Kind: ArtifactEmergencyContact, Name: c.Name, CreatedAt: c.AddedAt})
+		}
+	}
+	return artifacts, nil
+}
+
+// ForgetRecoveryKit removes the record of the recovery kit at path, e.g.
+// after it was destroyed. The kit itself stays valid until RotateDEK.
+func (v *Vault) ForgetRecoveryKit(path string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	n := len(meta.RecoveryKits)
+	meta.RecoveryKits = slices.DeleteFunc(meta.RecoveryKits, func(k RecoveryKitRecord) bool {
+		return k.Path == path
+	})
+	if len(meta.RecoveryKits) == n {
+		return fmt.Errorf("vault: no recovery kit recorded at %s", path)
+	}
+	return v.writeMeta(meta)
+}
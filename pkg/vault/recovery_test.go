@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/emergency"
+)
+
+func TestRecoveryArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	v := New(filepath.Join(dir, "vault"))
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	kit := filepath.Join(dir, RecoveryKitFileName)
+	if err := v.IssueRecoveryKit(kit); err != nil {
+		t.Fatalf("IssueRecoveryKit failed: %v", err)
+	}
+	if info, err := os.Stat(kit); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("recovery kit not written with mode 0600: %v", err)
+	}
+	key, _ := emergency.GenerateKey()
+	if err := v.AddEmergencyContact("alice", key.Public(), 72*time.Hour); err != nil {
+		t.Fatalf("AddEmergencyContact failed: %v", err)
+	}
+
+	artifacts, err := v.RecoveryArtifacts()
+	if err != nil {
+		t.Fatalf("RecoveryArtifacts failed: %v", err)
+	}
+	if len(artifacts) != 2 || artifacts[0].Kind != ArtifactRecoveryKit || artifacts[0].Name != kit ||
+		artifacts[1].Kind != ArtifactEmergencyContact || artifacts[1].Name != "alice" {
+		t.Fatalf("RecoveryArtifacts = %+v", artifacts)
+	}
+
+	// A password change keeps the DEK, so the artifacts stay valid
+	oldDEK, _ := v.ExportDEK()
+	newPassword := "newpassword456"
+	if err := v.ChangeMasterPassword(password, newPassword); err != nil {
+		t.Fatalf("ChangeMasterPassword failed: %v", err)
+	}
+	if artifacts, _ := v.RecoveryArtifacts(); len(artifacts) != 2 {
+		t.Fatalf("artifacts after password change = %+v", artifacts)
+	}
+
+	// Rotation invalidates the kits and re-seals to the contacts
+	if err := v.RotateDEK(newPassword, nil); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+	artifacts, _ = v.RecoveryArtifacts()
+	if len(artifacts) != 1 || artifacts[0].Kind != ArtifactEmergencyContact {
+		t.Fatalf("artifacts after rotation = %+v", artifacts)
+	}
+	newDEK, _ := v.ExportDEK()
+	if bytes.Equal(oldDEK, newDEK) {
+		t.Fatal("RotateDEK kept the DEK")
+	}
+
+	// Re-issuing at the same path needs the stale file gone
+	if err := v.IssueRecoveryKit(kit); err == nil {
+		t.Fatal("IssueRecoveryKit replaced an existing file")
+	}
+	os.Remove(kit)
+	if err := v.IssueRecoveryKit(kit); err != nil {
+		t.Fatalf("IssueRecoveryKit failed: %v", err)
+	}
+	if err := v.ForgetRecoveryKit(kit); err != nil {
+		t.Fatalf("ForgetRecoveryKit failed: %v", err)
+	}
+	if err := v.ForgetRecoveryKit(kit); err == nil {
+		t.Fatal("ForgetRecoveryKit accepted an unrecorded path")
+	}
+	if artifacts, _ := v.RecoveryArtifacts(); len(artifacts) != 1 {
+		t.Fatalf("artifacts after ForgetRecoveryKit = %+v", artifacts)
+	}
+}
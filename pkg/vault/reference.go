@@ -0,0 +1,150 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Secret references
+//
+// A field whose value is "ref://<key>#<field>" takes the value of that
+// field of another secret when the secret is read; "ref://<key>" takes its
+// default field. A credential shared by several services is then stored
+// once and rotated in one place. References are resolved with the access
+// rules of the referenced secret, may point at secrets that are references
+// themselves, and are stored and backed up as written.
+const RefScheme = "ref://"
+
+// maxRefDepth bounds how many references a read follows from one field.
+const maxRefDepth = 8
+
+// Secret reference errors
+var (
+	ErrInvalidRef = errors.New("vault: invalid ref:// reference")
+	ErrRefCycle   = errors.New("vault: secret references form a cycle")
+)
+
+// SecretRef is a parsed ref:// value.
+type SecretRef struct {
+	Key   string
+	Field string // "" for the default field
+}
+
+// String returns the ref:// form of r.
+func (r SecretRef) String() string {
+	if r.Field == "" {
+		return RefScheme + r.Key
+	}
+	return RefScheme + r.Key + "#" + r.Field
+}
+
+// IsSecretRef reports whether value is a ref:// reference.
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, RefScheme)
+}
+
+// ParseSecretRef parses a ref://<key>[#<field>] value.
+func ParseSecretRef(s string) (*SecretRef, error) {
+	rest, ok := strings.CutPrefix(s, RefScheme)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s prefix", ErrInvalidRef, RefScheme)
+	}
+	key, field, hasField := strings.Cut(rest, "#")
+	if err := validateKeyName(key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRef, err)
+	}
+	if hasField {
+		if err := ValidateFieldName(field); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRef, err)
+		}
+	}
+	return &SecretRef{Key: key, Field: field}, nil
+}
+
+// validateRefs checks the references among the fields of key as written.
+// The referenced secrets need not exist yet.
+func validateRefs(key string, fields map[string]Field) error {
+	for name, field := range fields {
+		if !IsSecretRef(field.Value) {
+			continue
+		}
+		ref, err := ParseSecretRef(field.Value)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", name, err)
+		}
+		if ref.Key == key {
+			return fmt.Errorf("%w: field %q references its own secret", ErrRefCycle, name)
+		}
+	}
+	return nil
+}
+
+// hasRefs reports whether any field of entry is a reference.
+func (e *SecretEntry) hasRefs() bool {
+	for _, field := range e.Fields {
+		if IsSecretRef(field.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRefs replaces the reference fields of entry with the values they
+// point at, reading each referenced secret with opts. chain holds the keys
+// being resolved, entry's included, to detect cycles.
+func (v *Vault) resolveRefs(entry *SecretEntry, opts readOptions, chain []string) error {
+	if len(chain) > maxRefDepth {
+		return fmt.Errorf("%w: more than %d references from '%s'", ErrRefCycle, maxRefDepth, chain[0])
+	}
+	names := make([]string, 0, len(entry.Fields))
+	for name, field := range entry.Fields {
+		if IsSecretRef(field.Value) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := entry.Fields[name]
+		ref, err := ParseSecretRef(field.Value)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", name, err)
+		}
+		if slices.Contains(chain, ref.Key) {
+			return fmt.Errorf("%w: %s -> %s", ErrRefCycle, strings.Join(chain, " -> "), ref.Key)
+		}
+		target, err := v.readSecretChain(ref.Key, opts, chain)
+		if err != nil {
+			return fmt.Errorf("field %q references '%s': %w", name, ref.Key, err)
+		}
+
+		value := GetDefaultFieldValue(target.Fields)
+		sensitive := true
+		if ref.Field != "" {
+			_, targetField, err := ResolveFieldName(target.Fields, ref.Field)
+			if err != nil {
+				return fmt.Errorf("field %q references '%s': %w", name, ref, err)
+			}
+			value, sensitive = targetField.Value, targetField.Sensitive
+		}
+
+		if entry.References == nil {
+			entry.References = make(map[string]string)
+		}
+		entry.References[name] = field.Value
+		for _, key := range append([]string{target.Key}, target.ReferencedKeys...) {
+			if !slices.Contains(entry.ReferencedKeys, key) {
+				entry.ReferencedKeys = append(entry.ReferencedKeys, key)
+			}
+		}
+		field.Value = value
+		field.Sensitive = field.Sensitive || sensitive
+		entry.Fields[name] = field
+	}
+	sort.Strings(entry.ReferencedKeys)
+	entry.Value = []byte(GetDefaultFieldValue(entry.Fields))
+	return nil
+}
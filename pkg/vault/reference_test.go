@@ -0,0 +1,129 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	ref, err := ParseSecretRef("ref://shared/db#password")
+	if err != nil {
+		t.Fatalf("ParseSecretRef failed: %v", err)
+	}
+	if ref.Key != "shared/db" || ref.Field != "password" {
+		t.Errorf("unexpected ref %+v", ref)
+	}
+	if ref, err := ParseSecretRef("ref://shared/db"); err != nil || ref.Field != "" {
+		t.Errorf("ParseSecretRef without field: %+v, %v", ref, err)
+	}
+
+	for _, bad := range []string{"shared/db", "ref://", "ref://bad key", "ref://db#", "ref://db#bad field"} {
+		if _, err := ParseSecretRef(bad); !errors.Is(err, ErrInvalidRef) {
+			t.Errorf("ParseSecretRef(%q): expected ErrInvalidRef, got %v", bad, err)
+		}
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	set := func(key string, fields map[string]Field) error {
+		return v.SetSecret(key, &SecretEntry{Fields: fields})
+	}
+	if err := set("shared/db", map[string]Field{
+		"username": {Value: "app", Sensitive: false},
+		"password": {Value: "s3cret", Sensitive: true},
+	}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := set("api", map[string]Field{
+		"db_user":     {Value: "ref://shared/db#username"},
+		"db_password": {Value: "ref://shared/db#password"},
+		"value":       {Value: "ref://token", Sensitive: true},
+	}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("token", &SecretEntry{Value: []byte("tok-123")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	entry, err := v.GetSecret("api")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if got := entry.Fields["db_password"]; got.Value != "s3cret" || !got.Sensitive {
+		t.Errorf("db_password = %+v", got)
+	}
+	if got := entry.Fields["db_user"].Value; got != "app" {
+		t.Errorf("db_user = %q, want app", got)
+	}
+	if string(entry.Value) != "tok-123" {
+		t.Errorf("Value = %q, want the default field of token", entry.Value)
+	}
+	if entry.References["db_password"] != "ref://shared/db#password" {
+		t.Errorf("References = %v", entry.References)
+	}
+	if len(entry.ReferencedKeys) != 2 || entry.ReferencedKeys[0] != "shared/db" || entry.ReferencedKeys[1] != "token" {
+		t.Errorf("ReferencedKeys = %v", entry.ReferencedKeys)
+	}
+
+	// The reference is stored as written and follows the referenced secret
+	inspected, err := v.InspectSecret("api")
+	if err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	if inspected.Fields["db_password"].Value != "ref://shared/db#password" {
+		t.Errorf("stored value = %q", inspected.Fields["db_password"].Value)
+	}
+	if err := set("shared/db", map[string]Field{
+		"username": {Value: "app", Sensitive: false},
+		"password": {Value: "rotated", Sensitive: true},
+	}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	entries, err := v.GetSecrets([]string{"api"})
+	if err != nil {
+		t.Fatalf("GetSecrets failed: %v", err)
+	}
+	if got := entries["api"].Fields["db_password"].Value; got != "rotated" {
+		t.Errorf("batch db_password = %q, want rotated", got)
+	}
+
+	// Dropping a referenced field breaks the reference
+	if err := set("shared/db", map[string]Field{"password": {Value: "rotated", Sensitive: true}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("api"); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("expected ErrFieldNotFound, got %v", err)
+	}
+
+	// Cycles are refused when written directly and when read indirectly
+	if err := set("self", map[string]Field{"value": {Value: "ref://self#other"}}); !errors.Is(err, ErrRefCycle) {
+		t.Errorf("expected ErrRefCycle for a self reference, got %v", err)
+	}
+	if err := set("a", map[string]Field{"value": {Value: "ref://b"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := set("b", map[string]Field{"value": {Value: "ref://a"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("a"); !errors.Is(err, ErrRefCycle) {
+		t.Errorf("expected ErrRefCycle, got %v", err)
+	}
+	if _, err := v.GetSecrets([]string{"b"}); !errors.Is(err, ErrRefCycle) {
+		t.Errorf("expected ErrRefCycle from GetSecrets, got %v", err)
+	}
+	if err := set("c", map[string]Field{"value": {Value: "ref://missing"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("c"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
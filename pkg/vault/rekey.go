@@ -0,0 +1,389 @@
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/forest6511/secretctl/pkg/emergency"
+)
+
+// OpRekey is the operation name of RotateDEK in progress reports.
+const OpRekey = "rekey"
+
+// ErrKeyRotated is returned for changes by a vault that was unlocked before
+// another process rotated the DEK; it must be locked and unlocked again.
+var ErrKeyRotated = errors.New("vault: the data key was rotated since the vault was unlocked; unlock it again")
+
+// retiredAuditKeysTableSQL creates the table keeping the audit HMAC keys
+// derived from DEKs that RotateDEK replaced, encrypted with the current
+// DEK, so that audit records written before a rotation still verify.
+const retiredAuditKeysTableSQL = `
+	CREATE TABLE IF NOT EXISTS retired_audit_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		encrypted_key BLOB NOT NULL,
+		retired_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// RotateDEK replaces the DEK with a new random key, for when the old one
+// may have been exposed (for example in a process dump). Every secret,
+// metadata blob, key name and history entry is re-encrypted, key hashes
+// are recomputed, the new DEK is wrapped with the master password, and
+// emergency contact bundles are sealed again.
+//
+// The database changes are made in one transaction across vault.db and
+// its shards: if the rotation is interrupted nothing has changed, the old
+// DEK stays in effect, and RotateDEK can simply be run again. Other
+// processes are held off until it commits. masterPassword is required to
+// re-wrap the DEK; a wrong one counts as a failed unlock attempt. Every
+// shard must be available.
+//
+// The audit key derived from the old DEK is kept, encrypted, so earlier
+// audit records still verify. Recovery kits and session keys from before
+// the rotation hold the old DEK and no longer open the vault; sessions in
+// other processes fail writes with ErrKeyRotated until unlocked again.
+//
+// progress may be nil; updates are also published as EventProgress.
+// Unlike Reencrypt, the vault lock is held throughout, so progress must
+// not call back into the vault.
+func (v *Vault) RotateDEK(masterPassword string, progress ProgressFunc) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	for ns, err := range v.shards {
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrShardUnavailable, ns, err)
+		}
+	}
+	if remaining, err := v.checkCooldown(); err != nil {
+		if errors.Is(err, ErrCooldownActive) {
+			return fmt.Errorf("%w: please wait %v", ErrCooldownActive, remaining.Round(time.Second))
+		}
+		return err
+	}
+
+	// Verify the password by unwrapping the stored DEK
+	var salt, encryptedDEK, dekNonce []byte
+	err := v.db.QueryRow("SELECT salt, encrypted_dek, dek_nonce FROM vault_keys WHERE id = 1").
+		Scan(&salt, &encryptedDEK, &dekNonce)
+	if err != nil {
+		return fmt.Errorf("vault: failed to read vault keys: %w", err)
+	}
+	params, err := v.kdfParams()
+	if err != nil {
+		return err
+	}
+	passwordBytes := []byte(masterPassword)
+	defer crypto.SecureWipe(passwordBytes)
+	kek := crypto.DeriveKeyWithParams(passwordBytes, salt, params)
+	defer crypto.SecureWipe(kek)
+
+	stored, err := crypto.Decrypt(kek, encryptedDEK, dekNonce)
+	if err != nil || subtle.ConstantTimeCompare(stored, v.dek) != 1 {
+		crypto.SecureWipe(stored)
+		if _, recordErr := v.recordFailedAttempt(); recordErr != nil {
+			v.warn(EventWarning, "failed to record unlock attempt: %v", recordErr)
+		}
+		_ = v.audit.LogError(audit.OpVaultRekey, v.auditSource(), "", "AUTH_FAILED", "invalid master password")
+		return ErrInvalidPassword
+	}
+	crypto.SecureWipe(stored)
+
+	newDEK := make([]byte, DEKLength)
+	if _, err := rand.Read(newDEK); err != nil {
+		return fmt.Errorf("vault: failed to generate DEK: %w", err)
+	}
+	defer crypto.SecureWipe(newDEK)
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := v.rekeyTables(tx, newDEK, progress); err != nil {
+		_ = v.audit.LogError(audit.OpVaultRekey, v.auditSource(), "", "REKEY_FAILED", err.Error())
+		return err
+	}
+
+	// Keep the old audit key for records written before the rotation
+	if auditKey, err := v.audit.ExportKey(); err == nil {
+		blob, err := encryptBlob(v.cipher, newDEK, auditKey)
+		crypto.SecureWipe(auditKey)
+		if err != nil {
+			return fmt.Errorf("vault: failed to encrypt audit key: %w", err)
+		}
+		if _, err := tx.Exec("INSERT INTO retired_audit_keys (encrypted_key) VALUES (?)", blob); err != nil {
+			return fmt.Errorf("vault: failed to save audit key: %w", err)
+		}
+	}
+
+	wrapped, nonce, err := crypto.Encrypt(kek, newDEK)
+	if err != nil {
+		return fmt.Errorf("vault: failed to encrypt DEK: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE vault_keys SET encrypted_dek = ?, dek_nonce = ? WHERE id = 1", wrapped, nonce); err != nil {
+		return fmt.Errorf("vault: failed to update vault keys: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit key rotation: %w", err)
+	}
+	v.setDEK(newDEK)
+
+	// vault.meta is written after the commit: a process unlocking in
+	// between reads the old rotation time and the new DEK, so at worst it
+	// is refused writes it could have made
+	rotatedAt := time.Now().UTC()
+	if err := v.recordKeyRotation(rotatedAt); err != nil {
+		v.warn(EventWarning, "failed to record key rotation: %v; other sessions may still write with the old key, and emergency contacts must be added again", err)
+	}
+	v.keyRotatedAt = rotatedAt
+
+	if err := v.initAuditKeys(); err != nil {
+		v.warn(EventWarning, "failed to initialize audit logger: %v", err)
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultRekey, v.auditSource(), "")
+	v.warn(EventWarning, "the data key was rotated; recovery kits made before now no longer open the vault, so write a new one")
+	return nil
+}
+
+// rekeyTables re-encrypts every table of rewriteTables with newDEK and
+// replaces the key hashes of secrets, their history, attachments,
+// canaries and access records. Caller must hold v.mu.
+func (v *Vault) rekeyTables(tx *sql.Tx, newDEK []byte, progress ProgressFunc) error {
+	p := Progress{Op: OpRekey}
+	tables := v.rewriteTables()
+	for _, t := range tables {
+		var n int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM " + t.name).Scan(&n); err != nil {
+			return fmt.Errorf("vault: failed to count %s: %w", t.name, err)
+		}
+		p.Total += n
+	}
+	v.reportProgress(p, progress)
+
+	// Old key hash to new one, filled from the secrets tables, which
+	// rewriteTables lists first
+	hashes := make(map[string]string)
+	for _, t := range tables {
+		isSecrets := t.name == "secrets" || strings.HasSuffix(t.name, ".secrets")
+		columns := append([]string{"id"}, t.columns...)
+		if isSecrets || t.name == "secret_history" || t.name == "attachments" {
+			columns = append(columns, "key_hash")
+		}
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE id > ? ORDER BY id LIMIT ?", strings.Join(columns, ", "), t.name)
+		sets := make([]string, len(columns)-1)
+		for i, c := range columns[1:] {
+			sets[i] = c + " = ?"
+		}
+		update := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", t.name, strings.Join(sets, ", "))
+
+		var cursor int64
+		for {
+			batch, err := readRekeyBatch(tx, query, cursor, len(columns)-1)
+			if err != nil {
+				return fmt.Errorf("vault: failed to read %s: %w", t.name, err)
+			}
+			if len(batch) == 0 {
+				break
+			}
+			for _, r := range batch {
+				args := make([]interface{}, 0, len(columns))
+				for i, blob := range r.values[:len(t.columns)] {
+					if len(blob) > 0 {
+						plaintext, err := v.decryptWithNonce(blob)
+						if err != nil {
+							return fmt.Errorf("vault: failed to decrypt %s.%s of row %d: %w", t.name, t.columns[i], r.id, err)
+						}
+						if isSecrets && t.columns[i] == "encrypted_key" {
+							hashes[string(r.values[len(r.values)-1])] = hmacHex(newDEK, plaintext)
+						}
+						blob, err = encryptBlob(v.cipher, newDEK, plaintext)
+						crypto.SecureWipe(plaintext)
+						if err != nil {
+							return fmt.Errorf("vault: failed to encrypt %s.%s of row %d: %w", t.name, t.columns[i], r.id, err)
+						}
+					}
+					args = append(args, blob)
+				}
+				if len(r.values) > len(t.columns) {
+					// History of purged secrets keeps its old, unreachable hash
+					hash := string(r.values[len(r.values)-1])
+					if newHash, ok := hashes[hash]; ok {
+						hash = newHash
+					}
+					args = append(args, hash)
+				}
+				if _, err := tx.Exec(update, append(args, r.id)...); err != nil {
+					return fmt.Errorf("vault: failed to update %s: %w", t.name, err)
+				}
+				cursor = r.id
+			}
+			p.Done += len(batch)
+			v.reportProgress(p, progress)
+		}
+	}
+
+	for oldHash, newHash := range hashes {
+		if _, err := tx.Exec("UPDATE canaries SET key_hash = ? WHERE key_hash = ?", newHash, oldHash); err != nil {
+			return fmt.Errorf("vault: failed to update canaries: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE secret_access SET key_hash = ? WHERE key_hash = ?", newHash, oldHash); err != nil {
+			return fmt.Errorf("vault: failed to update secret_access: %w", err)
+		}
+	}
+	// Directory hashes are keyed with the DEK too; the next listing
+	// indexes every secret again
+	if _, err := tx.Exec("DELETE FROM secret_paths"); err != nil {
+		return fmt.Errorf("vault: failed to clear secret_paths: %w", err)
+	}
+	return nil
+}
+
+// rekeyRow is a row read by rekeyTables: its id and the remaining columns.
+type rekeyRow struct {
+	id     int64
+	values [][]byte
+}
+
+// readRekeyBatch reads the next rewriteBatchSize rows of query after
+// cursor, with n columns after the id.
+func readRekeyBatch(tx *sql.Tx, query string, cursor int64, n int) ([]rekeyRow, error) {
+	rows, err := tx.Query(query, cursor, rewriteBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []rekeyRow
+	for rows.Next() {
+		r := rekeyRow{values: make([][]byte, n)}
+		dest := []interface{}{&r.id}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		batch = append(batch, r)
+	}
+	return batch, rows.Err()
+}
+
+// hmacHex is hashKey with an explicit key.
+func hmacHex(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordKeyRotation records the rotation time in vault.meta, forgets the
+// recovery kits of the old DEK and seals the new DEK to every emergency
+// contact. Caller must hold v.mu with the new DEK in place.
+func (v *Vault) recordKeyRotation(at time.Time) error {
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	meta.KeyRotatedAt = at
+	meta.RecoveryKits = nil
+	if meta.Settings != nil {
+		for i, c := range meta.Settings.EmergencyContacts {
+			pub, err := emergency.ParsePublicKey(c.PublicKey)
+			if err != nil {
+				return fmt.Errorf("emergency contact %s: %w", c.Name, err)
+			}
+			bundle, err := emergency.Seal(pub, v.dek)
+			if err != nil {
+				return fmt.Errorf("emergency contact %s: %w", c.Name, err)
+			}
+			meta.Settings.EmergencyContacts[i].Bundle = bundle
+		}
+	}
+	return v.writeMeta(meta)
+}
+
+// keyRotation returns when the DEK was last rotated according to
+// vault.meta, or the zero time if it never was or the file is unreadable.
+func (v *Vault) keyRotation() time.Time {
+	meta, err := v.readMeta()
+	if err != nil {
+		return time.Time{}
+	}
+	return meta.KeyRotatedAt
+}
+
+// keyRotatedElsewhere reports whether another process rotated the DEK
+// while this vault was unlocked.
+func (v *Vault) keyRotatedElsewhere() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.dek != nil && !v.keyRotation().Equal(v.keyRotatedAt)
+}
+
+// lockRotated locks a vault whose DEK was rotated by another process. The
+// audit key is dropped first: it is retired, and records signed with it
+// after the rotation would not verify.
+func (v *Vault) lockRotated() {
+	v.audit.ClearHMACKey()
+	v.Lock()
+	v.warn(EventWarning, "the data key was rotated by another process; the vault was locked and must be unlocked again")
+}
+
+// initAuditKeys sets the audit HMAC key from the DEK, with the keys
+// retired by RotateDEK. Caller must hold v.mu.
+func (v *Vault) initAuditKeys() error {
+	if err := v.audit.SetHMACKey(v.dek); err != nil {
+		return err
+	}
+	return v.setRetiredAuditKeys(v.db, v.cipher, v.dek)
+}
+
+// setRetiredAuditKeys passes the audit keys retired by RotateDEK, stored in
+// db under dek with the cipher suite, to the audit logger.
+func (v *Vault) setRetiredAuditKeys(db *sql.DB, suite string, dek []byte) error {
+	rows, err := db.Query("SELECT encrypted_key FROM retired_audit_keys ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("vault: failed to read retired audit keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys [][]byte
+	defer func() {
+		for _, key := range keys {
+			crypto.SecureWipe(key)
+		}
+	}()
+	for rows.Next() {
+		var blob []byte
+		if err := rows.Scan(&blob); err != nil {
+			return fmt.Errorf("vault: failed to read retired audit keys: %w", err)
+		}
+		key, err := decryptBlob(suite, dek, blob)
+		if err != nil {
+			return fmt.Errorf("vault: failed to decrypt retired audit key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("vault: failed to read retired audit keys: %w", err)
+	}
+	v.audit.SetRetiredKeys(keys)
+	return nil
+}
@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/emergency"
+)
+
+func TestRotateDEK(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	for key, value := range map[string]string{"API_KEY": "v1", "prod/DB_URL": "postgres://prod"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte(value), Tags: []string{"t"}}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+	if err := v.SetSecret("API_KEY", &SecretEntry{Value: []byte("v2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.AddShard("prod"); err != nil {
+		t.Fatalf("AddShard failed: %v", err)
+	}
+	if err := v.CreateCanary("AWS_ROOT_KEY", &SecretEntry{Value: []byte("decoy")}); err != nil {
+		t.Fatalf("CreateCanary failed: %v", err)
+	}
+	contact, _ := emergency.GenerateKey()
+	if err := v.AddEmergencyContact("alice", contact.Public(), 72*time.Hour); err != nil {
+		t.Fatalf("AddEmergencyContact failed: %v", err)
+	}
+	oldDEK, _ := v.ExportDEK()
+
+	if err := v.RotateDEK("wrongpassword", nil); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("expected ErrInvalidPassword, got %v", err)
+	}
+	var updates []Progress
+	if err := v.RotateDEK(password, func(p Progress) { updates = append(updates, p) }); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+	if last := updates[len(updates)-1]; last.Op != OpRekey || last.Done != last.Total {
+		t.Errorf("last progress = %+v", last)
+	}
+	newDEK, _ := v.ExportDEK()
+	if bytes.Equal(oldDEK, newDEK) {
+		t.Fatal("DEK unchanged after RotateDEK")
+	}
+
+	check := func(v *Vault) {
+		t.Helper()
+		for key, want := range map[string]string{"API_KEY": "v2", "prod/DB_URL": "postgres://prod"} {
+			entry, err := v.GetSecret(key)
+			if err != nil || string(entry.Value) != want {
+				t.Errorf("GetSecret(%s) = %v, %v", key, entry, err)
+			}
+		}
+		if history, err := v.GetSecretHistory("API_KEY"); err != nil || len(history) == 0 {
+			t.Errorf("GetSecretHistory = %v, %v", history, err)
+		}
+		if canaries, err := v.ListCanaries(); err != nil || len(canaries) != 1 {
+			t.Errorf("ListCanaries = %v, %v", canaries, err)
+		}
+		if keys, err := v.ListSecretsByPrefix("prod/"); err != nil || !slices.Equal(keys, []string{"prod/DB_URL"}) {
+			t.Errorf("ListSecretsByPrefix = %v, %v", keys, err)
+		}
+	}
+	check(v)
+
+	if result, err := v.Audit().Verify(); err != nil || !result.Valid {
+		t.Errorf("audit log does not verify after rotation: %+v, %v", result, err)
+	}
+
+	// Emergency contacts receive the new key
+	contacts, _ := v.EmergencyContacts()
+	if dek, err := emergency.Open(contact, contacts[0].Bundle); err != nil || !bytes.Equal(dek, newDEK) {
+		t.Errorf("emergency bundle does not hold the new DEK: %v", err)
+	}
+	v.Lock()
+
+	if err := v.UnlockWithKey(oldDEK); !errors.Is(err, ErrDEKMismatch) {
+		t.Errorf("UnlockWithKey with the old DEK: expected ErrDEKMismatch, got %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock after rotation failed: %v", err)
+	}
+	check(v)
+	if err := v.SetSecret("NEW", &SecretEntry{Value: []byte("x")}); err != nil {
+		t.Errorf("SetSecret after unlocking again failed: %v", err)
+	}
+	if result, err := v.Audit().Verify(); err != nil || !result.Valid {
+		t.Errorf("audit log does not verify after unlocking again: %+v, %v", result, err)
+	}
+	v.Lock()
+}
+
+func TestRotateDEK_StaleSession(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if err := v.SetSecret("API_KEY", &SecretEntry{Value: []byte("v1")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	// Another process unlocked before the rotation
+	other := New(dir)
+	if err := other.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.RotateDEK(password, nil); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+
+	if err := other.SetSecret("NEW", &SecretEntry{Value: []byte("x")}); !errors.Is(err, ErrKeyRotated) {
+		t.Errorf("expected ErrKeyRotated, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go other.WatchFiles(ctx, 10*time.Millisecond, "test")
+	deadline := time.Now().Add(2 * time.Second)
+	for !other.IsLocked() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !other.IsLocked() {
+		t.Fatal("WatchFiles did not lock the stale session")
+	}
+
+	if err := other.Unlock(password); err != nil {
+		t.Fatalf("Unlock after rotation failed: %v", err)
+	}
+	defer other.Lock()
+	if err := other.SetSecret("NEW", &SecretEntry{Value: []byte("x")}); err != nil {
+		t.Errorf("SetSecret after unlocking again failed: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// ErrSecretChanged is returned by SetSecretIfRevision when the secret was
+// changed after the revision was read.
+var ErrSecretChanged = errors.New("vault: secret was changed since it was read")
+
+// ChangedError is returned by SetSecretIfRevision when the secret no longer
+// has the expected revision. Current is the stored secret, nil if it was
+// deleted, and Revision its revision.
+type ChangedError struct {
+	Key      string
+	Current  *SecretEntry
+	Revision string
+}
+
+func (e *ChangedError) Error() string {
+	if e.Current == nil {
+		return fmt.Sprintf("vault: secret '%s' was deleted since it was read", e.Key)
+	}
+	return fmt.Sprintf("vault: secret '%s' was changed since it was read", e.Key)
+}
+
+// Unwrap returns ErrSecretChanged.
+func (e *ChangedError) Unwrap() error {
+	return ErrSecretChanged
+}
+
+// revisionContent is what a revision covers: everything a caller can set
+// with SetSecret. The usage record is left out so that running a command
+// with a secret does not count as editing it.
+type revisionContent struct {
+	Fields       map[string]Field  `json:"fields,omitempty"`
+	Bindings     map[string]string `json:"bindings,omitempty"`
+	Schema       string            `json:"schema,omitempty"`
+	FolderID     *string           `json:"folder_id,omitempty"`
+	Notes        string            `json:"notes,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	Owner        string            `json:"owner,omitempty"`
+	Team         string            `json:"team,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`
+	NotBefore    *time.Time        `json:"not_before,omitempty"`
+	AccessWindow *AccessWindow     `json:"access_window,omitempty"`
+	Immutable    bool              `json:"immutable,omitempty"`
+}
+
+// Revision identifies the content of a stored secret, as returned by
+// InspectSecret; it changes whenever the secret is written with different
+// content. A nil entry, a secret that does not exist, has revision "".
+func (e *SecretEntry) Revision() string {
+	if e == nil {
+		return ""
+	}
+	utc := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		u := t.UTC()
+		return &u
+	}
+	c := revisionContent{
+		Fields:       e.Fields,
+		Bindings:     e.Bindings,
+		Schema:       e.Schema,
+		FolderID:     e.FolderID,
+		Tags:         e.Tags,
+		ExpiresAt:    utc(e.ExpiresAt),
+		NotBefore:    utc(e.NotBefore),
+		AccessWindow: e.AccessWindow,
+		Immutable:    e.Immutable,
+	}
+	if c.Fields == nil && len(e.Value) > 0 {
+		c.Fields = ConvertSingleValueToFields(e.Value)
+	}
+	if m := e.Metadata; m != nil {
+		c.Notes, c.URL, c.Owner, c.Team = m.Notes, m.URL, m.Owner, m.Team
+	}
+	data, _ := json.Marshal(c) // maps are marshaled in key order
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// SetSecretIfRevision saves a secret like SetSecret, but only if the stored
+// secret still has revision, as returned by SecretEntry.Revision when it
+// was read; "" means the secret must not exist. Otherwise it returns a
+// *ChangedError with the current version, so an editor can merge instead
+// of overwriting a change made elsewhere.
+func (v *Vault) SetSecretIfRevision(key string, entry *SecretEntry, revision string) error {
+	return v.setSecret(key, entry, writeOptions{checkRevision: true, revision: revision})
+}
+
+// checkRevisionTx returns a *ChangedError if the stored secret does not
+// have the revision expected by opts.
+func (v *Vault) checkRevisionTx(tx *sql.Tx, keyHash, key string, opts writeOptions) error {
+	var row secretRow
+	var current *SecretEntry
+	err := tx.QueryRow("SELECT "+secretRowColumns+" FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).Scan(row.dest()...)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("vault: failed to read secret: %w", err)
+	default:
+		if current, err = v.decodeSecret(key, &row, readOptions{inspect: true}, time.Now()); err != nil {
+			return err
+		}
+	}
+	if revision := current.Revision(); revision != opts.revision {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "CONFLICT", "secret was changed since it was read")
+		return &ChangedError{Key: key, Current: current, Revision: revision}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetSecretIfRevision(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	entry := func(value, notes string) *SecretEntry {
+		return &SecretEntry{
+			Fields:   map[string]Field{"password": {Value: value, Sensitive: true}},
+			Metadata: &SecretMetadata{Notes: notes},
+		}
+	}
+
+	// "" expects a new secret
+	if err := v.SetSecretIfRevision("db", entry("one", ""), ""); err != nil {
+		t.Fatalf("SetSecretIfRevision failed: %v", err)
+	}
+	base, err := v.InspectSecret("db")
+	if err != nil {
+		t.Fatalf("InspectSecret failed: %v", err)
+	}
+	rev := base.Revision()
+	if rev == "" {
+		t.Fatal("expected a revision")
+	}
+
+	// Recording usage is not an edit
+	if err := v.RecordUsage("db", "psql", "cli"); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if again, _ := v.InspectSecret("db"); again.Revision() != rev {
+		t.Error("revision changed without an edit")
+	}
+
+	// Another writer changes the secret
+	if err := v.SetSecret("db", entry("two", "rotated")); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	err = v.SetSecretIfRevision("db", entry("three", ""), rev)
+	var changed *ChangedError
+	if !errors.As(err, &changed) || !errors.Is(err, ErrSecretChanged) {
+		t.Fatalf("expected *ChangedError, got %v", err)
+	}
+	if changed.Current == nil || changed.Current.Fields["password"].Value != "two" || changed.Current.Metadata.Notes != "rotated" {
+		t.Errorf("unexpected current version %+v", changed.Current)
+	}
+	if got, _ := v.InspectSecret("db"); got.Fields["password"].Value != "two" {
+		t.Error("conflicting write was stored")
+	}
+
+	// Retrying with the current revision succeeds
+	if err := v.SetSecretIfRevision("db", entry("three", "rotated"), changed.Revision); err != nil {
+		t.Fatalf("SetSecretIfRevision failed: %v", err)
+	}
+	if err := v.SetSecretIfRevision("db", entry("four", ""), ""); !errors.Is(err, ErrSecretChanged) {
+		t.Errorf("expected ErrSecretChanged for an existing secret, got %v", err)
+	}
+
+	// A deleted secret has no current version
+	latest, _ := v.InspectSecret("db")
+	if err := v.DeleteSecret("db"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	err = v.SetSecretIfRevision("db", entry("five", ""), latest.Revision())
+	if !errors.As(err, &changed) || changed.Current != nil || changed.Revision != "" {
+		t.Errorf("expected *ChangedError without a current version, got %v", err)
+	}
+}
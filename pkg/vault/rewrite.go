@@ -0,0 +1,317 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// OpReencrypt is the operation name of Reencrypt in progress reports.
+const OpReencrypt = "reencrypt"
+
+// rewriteBatchSize is how many rows are rewritten per transaction.
+const rewriteBatchSize = 100
+
+// rewriteJobsTableSQL creates the table tracking interrupted vault-wide
+// rewrites. A row exists while a rewrite of that table is unfinished; its
+// cursor is the last row id that was rewritten.
+const rewriteJobsTableSQL = `
+	CREATE TABLE IF NOT EXISTS rewrite_jobs (
+		op TEXT NOT NULL,
+		table_name TEXT NOT NULL,
+		cursor INTEGER NOT NULL DEFAULT 0,
+		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (op, table_name)
+	)
+`
+
+// Progress reports how far a vault-wide operation has got.
+type Progress struct {
+	Op    string `json:"op"`
+	Done  int    `json:"done"` // rows rewritten, including those before a resume
+	Total int    `json:"total"`
+}
+
+// Percent returns the completed share from 0 to 100.
+func (p Progress) Percent() float64 {
+	if p.Total == 0 {
+		return 100
+	}
+	return float64(p.Done) * 100 / float64(p.Total)
+}
+
+// ProgressFunc receives progress updates after each committed batch. It is
+// called without the vault lock held but must not block for long.
+type ProgressFunc func(Progress)
+
+// rewriteTable lists the encrypted columns of a table that a rewrite covers.
+type rewriteTable struct {
+	name    string
+	columns []string
+}
+
+// secretsRewriteColumns are the encrypted columns of the secrets table.
+var secretsRewriteColumns = []string{"encrypted_key", "encrypted_value", "encrypted_fields", "encrypted_bindings",
+	"encrypted_metadata", "encrypted_redirect", "encrypted_certificate"}
+
+// rewriteTables returns all tables holding data encrypted with the DEK,
+// with the secrets table of every attached shard. Caller must hold v.mu.
+func (v *Vault) rewriteTables() []rewriteTable {
+	var tables []rewriteTable
+	for _, name := range v.secretTables() {
+		tables = append(tables, rewriteTable{name, secretsRewriteColumns})
+	}
+	return append(tables,
+		rewriteTable{"secret_history", []string{"encrypted_summary"}},
+		rewriteTable{"attachments", []string{"encrypted_name", "encrypted_data"}},
+		rewriteTable{"retired_audit_keys", []string{"encrypted_key"}},
+		rewriteTable{"search_index", []string{"encrypted_index"}})
+}
+
+// rewriteFunc transforms one encrypted blob (nonce prepended).
+type rewriteFunc func(blob []byte) ([]byte, error)
+
+// Reencrypt re-encrypts every secret and history entry under the current
+// DEK with fresh nonces. It runs in batches, each committed with its
+// position, so if it is interrupted the vault stays fully readable and
+// calling Reencrypt again continues where it stopped. progress may be nil;
+// updates are also published as EventProgress.
+func (v *Vault) Reencrypt(progress ProgressFunc) error {
+	err := v.rewrite(OpReencrypt, func(blob []byte) ([]byte, error) {
+		plaintext, err := v.decryptWithNonce(blob)
+		if err != nil {
+			return nil, err
+		}
+		return v.encryptWithNonce(plaintext)
+	}, progress)
+	if err != nil {
+		_ = v.audit.LogError(audit.OpVaultReencrypt, v.auditSource(), "", "REENCRYPT_FAILED", err.Error())
+		return err
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultReencrypt, v.auditSource(), "")
+	return nil
+}
+
+// PendingRewrites returns the progress of interrupted vault-wide operations.
+func (v *Vault) PendingRewrites() ([]Progress, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	rows, err := v.db.Query("SELECT DISTINCT op FROM rewrite_jobs ORDER BY op")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to read rewrite jobs: %w", err)
+	}
+	var ops []string
+	for rows.Next() {
+		var op string
+		if err := rows.Scan(&op); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("vault: failed to read rewrite jobs: %w", err)
+		}
+		ops = append(ops, op)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: failed to read rewrite jobs: %w", err)
+	}
+
+	var pending []Progress
+	for _, op := range ops {
+		p, err := v.rewriteProgress(op)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// warnPendingRewrites warns about interrupted vault-wide operations.
+// Caller must hold v.mu.
+func (v *Vault) warnPendingRewrites() {
+	var op string
+	err := v.db.QueryRow("SELECT op FROM rewrite_jobs LIMIT 1").Scan(&op)
+	if err == nil {
+		v.warn(EventWarning, "an interrupted vault %s has not finished; run it again to complete it", op)
+	}
+}
+
+// rewrite applies fn to every encrypted column of rewriteTables. The vault
+// lock is taken per batch, so other operations proceed in between; fn must
+// therefore keep rows readable by the current DEK and cipher.
+func (v *Vault) rewrite(op string, fn rewriteFunc, progress ProgressFunc) error {
+	v.mu.Lock()
+	if v.dek == nil {
+		v.mu.Unlock()
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		v.mu.Unlock()
+		return err
+	}
+	// Register all tables up front so a resume knows the job covers them
+	tables := v.rewriteTables()
+	for _, t := range tables {
+		if _, err := v.db.Exec("INSERT OR IGNORE INTO rewrite_jobs (op, table_name) VALUES (?, ?)", op, t.name); err != nil {
+			v.mu.Unlock()
+			return fmt.Errorf("vault: failed to start %s: %w", op, err)
+		}
+	}
+	p, err := v.rewriteProgress(op)
+	v.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	v.reportProgress(p, progress)
+
+	for _, t := range tables {
+		for {
+			n, err := v.rewriteBatch(op, t, fn)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				break
+			}
+			p.Done += n
+			v.reportProgress(p, progress)
+		}
+	}
+	return nil
+}
+
+// rewriteBatch rewrites the next batch of t and advances its cursor in the
+// same transaction. It returns the number of rows rewritten; 0 means the
+// table is done and its job row has been removed.
+func (v *Vault) rewriteBatch(op string, t rewriteTable, fn rewriteFunc) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return 0, ErrVaultLocked
+	}
+	tx, err := v.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var cursor int64
+	err = tx.QueryRow("SELECT cursor FROM rewrite_jobs WHERE op = ? AND table_name = ?", op, t.name).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to read rewrite job: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT id, %s FROM %s WHERE id > ? ORDER BY id LIMIT ?", strings.Join(t.columns, ", "), t.name)
+	rows, err := tx.Query(query, cursor, rewriteBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to read %s: %w", t.name, err)
+	}
+	type row struct {
+		id    int64
+		blobs [][]byte
+	}
+	var batch []row
+	for rows.Next() {
+		r := row{blobs: make([][]byte, len(t.columns))}
+		dest := []interface{}{&r.id}
+		for i := range r.blobs {
+			dest = append(dest, &r.blobs[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("vault: failed to read %s: %w", t.name, err)
+		}
+		batch = append(batch, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("vault: failed to read %s: %w", t.name, err)
+	}
+
+	if len(batch) == 0 {
+		if _, err := tx.Exec("DELETE FROM rewrite_jobs WHERE op = ? AND table_name = ?", op, t.name); err != nil {
+			return 0, fmt.Errorf("vault: failed to finish rewrite job: %w", err)
+		}
+		return 0, tx.Commit()
+	}
+
+	sets := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		sets[i] = c + " = ?"
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", t.name, strings.Join(sets, ", "))
+	for _, r := range batch {
+		args := make([]interface{}, 0, len(r.blobs)+1)
+		for i, blob := range r.blobs {
+			if len(blob) > 0 {
+				if blob, err = fn(blob); err != nil {
+					return 0, fmt.Errorf("vault: failed to rewrite %s.%s of row %d: %w", t.name, t.columns[i], r.id, err)
+				}
+			}
+			args = append(args, blob)
+		}
+		if _, err := tx.Exec(update, append(args, r.id)...); err != nil {
+			return 0, fmt.Errorf("vault: failed to update %s: %w", t.name, err)
+		}
+	}
+	last := batch[len(batch)-1].id
+	if _, err := tx.Exec("UPDATE rewrite_jobs SET cursor = ? WHERE op = ? AND table_name = ?", last, op, t.name); err != nil {
+		return 0, fmt.Errorf("vault: failed to save rewrite position: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+	return len(batch), nil
+}
+
+// rewriteProgress computes the progress of op from its job cursors. Tables
+// without a job row are either finished or not part of op. Caller must
+// hold v.mu.
+func (v *Vault) rewriteProgress(op string) (Progress, error) {
+	p := Progress{Op: op}
+	for _, t := range v.rewriteTables() {
+		var total int
+		if err := v.db.QueryRow("SELECT COUNT(*) FROM " + t.name).Scan(&total); err != nil {
+			return p, fmt.Errorf("vault: failed to count %s: %w", t.name, err)
+		}
+		p.Total += total
+
+		var cursor int64
+		err := v.db.QueryRow("SELECT cursor FROM rewrite_jobs WHERE op = ? AND table_name = ?", op, t.name).Scan(&cursor)
+		if err == sql.ErrNoRows {
+			// Finished earlier in this run of op
+			p.Done += total
+			continue
+		}
+		if err != nil {
+			return p, fmt.Errorf("vault: failed to read rewrite job: %w", err)
+		}
+		var done int
+		if err := v.db.QueryRow("SELECT COUNT(*) FROM "+t.name+" WHERE id <= ?", cursor).Scan(&done); err != nil {
+			return p, fmt.Errorf("vault: failed to count %s: %w", t.name, err)
+		}
+		p.Done += done
+	}
+	return p, nil
+}
+
+// reportProgress passes p to fn and publishes it as EventProgress.
+func (v *Vault) reportProgress(p Progress, fn ProgressFunc) {
+	if p.Done > p.Total {
+		// Rows added by concurrent writes after the count
+		p.Total = p.Done
+	}
+	if fn != nil {
+		fn(p)
+	}
+	v.emitEvent(Event{Type: EventProgress, Progress: &p})
+}
@@ -0,0 +1,96 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReencrypt(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	const n = rewriteBatchSize + 30
+	for i := 0; i < n; i++ {
+		entry := &SecretEntry{Value: []byte(fmt.Sprintf("value-%d", i)), Metadata: &SecretMetadata{Notes: "n"}}
+		if err := v.SetSecret(fmt.Sprintf("key/%03d", i), entry); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+	var before []byte
+	if err := v.db.QueryRow("SELECT encrypted_fields FROM secrets WHERE key_hash = ?", v.hashKey("key/000")).Scan(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt after the first batch
+	calls := 0
+	errStop := errors.New("stop")
+	err := v.rewrite(OpReencrypt, func(blob []byte) ([]byte, error) {
+		calls++
+		if calls > rewriteBatchSize*4 {
+			return nil, errStop
+		}
+		plaintext, err := v.decryptWithNonce(blob)
+		if err != nil {
+			return nil, err
+		}
+		return v.encryptWithNonce(plaintext)
+	}, nil)
+	if !errors.Is(err, errStop) {
+		t.Fatalf("rewrite = %v, want the injected error", err)
+	}
+	pending, err := v.PendingRewrites()
+	if err != nil {
+		t.Fatalf("PendingRewrites failed: %v", err)
+	}
+	// secrets plus one history entry per secret
+	if len(pending) != 1 || pending[0].Done != rewriteBatchSize || pending[0].Total != 2*n {
+		t.Fatalf("pending = %+v, want %d of %d done", pending, rewriteBatchSize, 2*n)
+	}
+
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+	var updates []Progress
+	if err := v.Reencrypt(func(p Progress) { updates = append(updates, p) }); err != nil {
+		t.Fatalf("Reencrypt failed: %v", err)
+	}
+	if len(updates) == 0 || updates[0].Done != rewriteBatchSize {
+		t.Fatalf("first update = %+v, want resume at %d", updates, rewriteBatchSize)
+	}
+	if last := updates[len(updates)-1]; last.Done != last.Total || last.Percent() != 100 {
+		t.Errorf("last update = %+v, want complete", last)
+	}
+	if e := <-events; e.Type != EventProgress || e.Progress == nil {
+		t.Errorf("event = %+v, want EventProgress", e)
+	}
+	if pending, _ := v.PendingRewrites(); len(pending) != 0 {
+		t.Errorf("pending after completion = %+v", pending)
+	}
+
+	var after []byte
+	if err := v.db.QueryRow("SELECT encrypted_fields FROM secrets WHERE key_hash = ?", v.hashKey("key/000")).Scan(&after); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before, after) {
+		t.Error("ciphertext unchanged")
+	}
+	for _, i := range []int{0, n - 1} {
+		entry, err := v.GetSecret(fmt.Sprintf("key/%03d", i))
+		if err != nil {
+			t.Fatalf("GetSecret failed: %v", err)
+		}
+		if string(entry.Value) != fmt.Sprintf("value-%d", i) || entry.Metadata.Notes != "n" {
+			t.Errorf("key/%03d = %q %+v after re-encryption", i, entry.Value, entry.Metadata)
+		}
+	}
+	if history, err := v.GetSecretHistory("key/000"); err != nil || len(history) != 1 {
+		t.Errorf("history after re-encryption = %v, %v", history, err)
+	}
+}
@@ -0,0 +1,31 @@
+package vault
+
+import "fmt"
+
+// Seal encrypts data with the vault DEK so that it can be stored outside the
+// database (e.g. MCP session pins). Sealed data can only be produced and read
+// by someone who unlocked the vault, which also makes it tamper-evident.
+func (v *Vault) Seal(data []byte) ([]byte, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	return v.encryptWithNonce(data)
+}
+
+// Unseal decrypts data produced by Seal.
+func (v *Vault) Unseal(sealed []byte) ([]byte, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	data, err := v.decryptWithNonce(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to unseal data: %w", err)
+	}
+	return data, nil
+}
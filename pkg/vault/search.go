@@ -0,0 +1,383 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// ErrEmptySearchQuery is returned when a query has no words to search for.
+var ErrEmptySearchQuery = errors.New("vault: search query has no words")
+
+// searchIndexTableSQL creates the table holding the encrypted search
+// index. It has at most one row: the whole index as a single blob.
+const searchIndexTableSQL = `
+	CREATE TABLE IF NOT EXISTS search_index (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		encrypted_index BLOB NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)
+`
+
+// minSearchTokenRunes drops single letters, which match nearly everything.
+const minSearchTokenRunes = 2
+
+// SearchResult is a secret matching every word of a query.
+type SearchResult struct {
+	Key string `json:"key"`
+	// Locations lists where the words matched: "notes", "url" or
+	// "field:<name>", sorted.
+	Locations []string `json:"locations"`
+}
+
+// searchIndex is the decrypted form of the search_index blob. Only notes,
+// URLs and non-sensitive field values are indexed, never secret values.
+type searchIndex struct {
+	// Docs maps the key hash of each indexed secret to its key and stamp.
+	Docs map[string]*searchDoc `json:"docs"`
+	// Terms maps a lowercase word to where it occurs.
+	Terms map[string][]searchPosting `json:"terms"`
+}
+
+type searchDoc struct {
+	Key string `json:"key"`
+	// Stamp identifies the stored ciphertext that was indexed. Every write
+	// encrypts with a fresh nonce, so a different stamp means the secret
+	// changed, whichever code path changed it.
+	Stamp string `json:"stamp"`
+}
+
+type searchPosting struct {
+	KeyHash  string `json:"h"`
+	Location string `json:"l"`
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{Docs: make(map[string]*searchDoc), Terms: make(map[string][]searchPosting)}
+}
+
+// add indexes the searchable text of one secret.
+func (idx *searchIndex) add(keyHash string, doc *searchDoc, fields map[string]Field, meta *SecretMetadata) {
+	idx.Docs[keyHash] = doc
+	addText := func(location, text string) {
+		for _, token := range searchTokens(text) {
+			idx.Terms[token] = append(idx.Terms[token], searchPosting{KeyHash: keyHash, Location: location})
+		}
+	}
+	if meta != nil {
+		addText("notes", meta.Notes)
+		addText("url", meta.URL)
+	}
+	for name, f := range fields {
+		if !f.Sensitive {
+			addText("field:"+name, f.Value)
+		}
+	}
+}
+
+// remove drops a secret from the index.
+func (idx *searchIndex) remove(keyHash string) {
+	delete(idx.Docs, keyHash)
+	for term, postings := range idx.Terms {
+		kept := postings[:0]
+		for _, p := range postings {
+			if p.KeyHash != keyHash {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			delete(idx.Terms, term)
+		} else {
+			idx.Terms[term] = kept
+		}
+	}
+}
+
+// match returns, for each secret containing a word starting with every
+// token, the locations where they occur.
+func (idx *searchIndex) match(tokens []string) map[string]map[string]bool {
+	var found map[string]map[string]bool
+	for _, token := range tokens {
+		hits := make(map[string]map[string]bool)
+		for term, postings := range idx.Terms {
+			if !strings.HasPrefix(term, token) {
+				continue
+			}
+			for _, p := range postings {
+				if found != nil && found[p.KeyHash] == nil {
+					continue
+				}
+				if hits[p.KeyHash] == nil {
+					hits[p.KeyHash] = make(map[string]bool)
+				}
+				hits[p.KeyHash][p.Location] = true
+			}
+		}
+		for hash, locations := range found {
+			if hits[hash] != nil {
+				for loc := range locations {
+					hits[hash][loc] = true
+				}
+			}
+		}
+		found = hits
+	}
+	return found
+}
+
+// searchTokens splits text into distinct lowercase words of letters and
+// digits, so "https://api.example.com" gives https, api, example and com.
+func searchTokens(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < minSearchTokenRunes || seen[word] {
+			continue
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// Search finds the secrets whose notes, URL or non-sensitive field values
+// contain a word starting with each word of query, case-insensitively.
+// Sensitive values are never searched; use Grep for those.
+//
+// With the search_index setting on, an inverted index of that text is
+// kept encrypted in the vault, and Search only decrypts the secrets that
+// changed since it was last brought up to date. Without it, every secret
+// is decrypted on each call.
+func (v *Vault) Search(query string) ([]SearchResult, error) {
+	return runValue(v, Op{Name: OpSearch}, func() ([]SearchResult, error) {
+		return v.search(query)
+	})
+}
+
+func (v *Vault) search(query string) ([]SearchResult, error) {
+	tokens := searchTokens(query)
+	if len(tokens) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	settings, err := v.Settings()
+	if err != nil {
+		return nil, err
+	}
+	idx := newSearchIndex()
+	if settings.SearchIndex {
+		idx = v.loadSearchIndex()
+	}
+	changed, err := v.syncSearchIndex(idx)
+	if err != nil {
+		return nil, err
+	}
+	if settings.SearchIndex && changed && v.checkWritable() == nil {
+		if err := v.saveSearchIndex(idx); err != nil {
+			slog.Debug("vault: failed to save search index", "err", err)
+		}
+	}
+
+	found := idx.match(tokens)
+	results := make([]SearchResult, 0, len(found))
+	for hash, locations := range found {
+		r := SearchResult{Key: idx.Docs[hash].Key, Locations: make([]string, 0, len(locations))}
+		for loc := range locations {
+			r.Locations = append(r.Locations, loc)
+		}
+		sort.Strings(r.Locations)
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	_ = v.audit.Log(audit.OpSecretSearch, v.auditSource(), audit.ResultSuccess, "", nil, map[string]interface{}{
+		"matches": len(results),
+		"indexed": settings.SearchIndex,
+	})
+	return results, nil
+}
+
+// loadSearchIndex reads the stored index. A missing or unreadable index
+// yields an empty one, which the next sync rebuilds. Caller must hold v.mu.
+func (v *Vault) loadSearchIndex() *searchIndex {
+	var blob []byte
+	err := v.db.QueryRow("SELECT encrypted_index FROM search_index WHERE id = 1").Scan(&blob)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Debug("vault: failed to read search index", "err", err)
+		}
+		return newSearchIndex()
+	}
+	data, err := v.decryptWithNonce(blob)
+	if err != nil {
+		slog.Debug("vault: failed to decrypt search index, rebuilding", "err", err)
+		return newSearchIndex()
+	}
+	defer crypto.SecureWipe(data)
+	idx := newSearchIndex()
+	if err := json.Unmarshal(data, idx); err != nil || idx.Docs == nil || idx.Terms == nil {
+		slog.Debug("vault: invalid search index, rebuilding", "err", err)
+		return newSearchIndex()
+	}
+	return idx
+}
+
+// saveSearchIndex encrypts and stores idx. Caller must hold v.mu.
+func (v *Vault) saveSearchIndex(idx *searchIndex) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("vault: failed to marshal search index: %w", err)
+	}
+	defer crypto.SecureWipe(data)
+	blob, err := v.encryptWithNonce(data)
+	if err != nil {
+		return fmt.Errorf("vault: failed to encrypt search index: %w", err)
+	}
+	if _, err := v.db.Exec(`
+		INSERT INTO search_index (id, encrypted_index, updated_at) VALUES (1, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(id) DO UPDATE SET encrypted_index = excluded.encrypted_index, updated_at = excluded.updated_at`,
+		blob); err != nil {
+		return fmt.Errorf("vault: failed to save search index: %w", err)
+	}
+	return nil
+}
+
+// syncSearchIndex brings idx up to date with the stored secrets: secrets
+// whose ciphertext changed are decrypted and re-indexed, and deleted ones
+// dropped. It reports whether idx changed. Caller must hold v.mu.
+func (v *Vault) syncSearchIndex(idx *searchIndex) (bool, error) {
+	changed := false
+	live := make(map[string]bool)
+	for _, table := range v.secretTables() {
+		rows, err := v.db.Query("SELECT key_hash, encrypted_key, encrypted_fields, encrypted_metadata FROM " + table + " WHERE deleted_at IS NULL")
+		if err != nil {
+			return false, fmt.Errorf("vault: failed to query secrets: %w", err)
+		}
+		for rows.Next() {
+			var keyHash string
+			var encryptedKey, encryptedFields, encryptedMetadata []byte
+			if err := rows.Scan(&keyHash, &encryptedKey, &encryptedFields, &encryptedMetadata); err != nil {
+				rows.Close()
+				return false, fmt.Errorf("vault: failed to scan row: %w", err)
+			}
+			live[keyHash] = true
+			stamp := searchStamp(encryptedFields, encryptedMetadata)
+			if doc := idx.Docs[keyHash]; doc != nil && doc.Stamp == stamp {
+				continue
+			}
+			doc, fields, meta, err := v.decodeSearchable(encryptedKey, encryptedFields, encryptedMetadata)
+			if err != nil {
+				rows.Close()
+				return false, err
+			}
+			doc.Stamp = stamp
+			idx.remove(keyHash)
+			idx.add(keyHash, doc, fields, meta)
+			changed = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return false, fmt.Errorf("vault: error iterating rows: %w", err)
+		}
+	}
+	for hash := range idx.Docs {
+		if !live[hash] {
+			idx.remove(hash)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// decodeSearchable decrypts the key name, fields and metadata of a secret
+// for indexing. Caller must hold v.mu.
+func (v *Vault) decodeSearchable(encryptedKey, encryptedFields, encryptedMetadata []byte) (*searchDoc, map[string]Field, *SecretMetadata, error) {
+	keyBytes, err := v.decryptWithNonce(encryptedKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("vault: failed to decrypt key name: %w", err)
+	}
+	doc := &searchDoc{Key: string(keyBytes)}
+
+	var fields map[string]Field
+	if len(encryptedFields) > 0 {
+		fieldsJSON, err := v.decryptWithNonce(encryptedFields)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("vault: failed to decrypt fields of '%s': %w", doc.Key, err)
+		}
+		err = json.Unmarshal(fieldsJSON, &fields)
+		crypto.SecureWipe(fieldsJSON)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("vault: failed to unmarshal fields of '%s': %w", doc.Key, err)
+		}
+	}
+
+	var meta *SecretMetadata
+	if len(encryptedMetadata) > 0 {
+		metadataJSON, err := v.decryptWithNonce(encryptedMetadata)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("vault: failed to decrypt metadata of '%s': %w", doc.Key, err)
+		}
+		meta = &SecretMetadata{}
+		if err := json.Unmarshal(metadataJSON, meta); err != nil {
+			return nil, nil, nil, fmt.Errorf("vault: failed to unmarshal metadata of '%s': %w", doc.Key, err)
+		}
+	}
+	return doc, fields, meta, nil
+}
+
+// searchStamp fingerprints the encrypted columns the index is built from.
+func searchStamp(encryptedFields, encryptedMetadata []byte) string {
+	h := sha256.New()
+	h.Write(encryptedFields)
+	h.Write([]byte{0})
+	h.Write(encryptedMetadata)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// updateSearchIndex brings the stored index up to date after a write, or
+// drops it when the search_index setting is off. It is best effort: a
+// write never fails because of the index, and Search catches up on
+// anything missed here. Caller must hold v.mu.
+func (v *Vault) updateSearchIndex() {
+	if v.checkWritable() != nil {
+		return
+	}
+	settings, err := v.Settings()
+	if err != nil {
+		return
+	}
+	if !settings.SearchIndex {
+		if _, err := v.db.Exec("DELETE FROM search_index"); err != nil {
+			slog.Debug("vault: failed to drop search index", "err", err)
+		}
+		return
+	}
+	idx := v.loadSearchIndex()
+	changed, err := v.syncSearchIndex(idx)
+	if err == nil && changed {
+		err = v.saveSearchIndex(idx)
+	}
+	if err != nil {
+		slog.Debug("vault: failed to update search index", "err", err)
+	}
+}
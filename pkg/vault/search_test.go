@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	secrets := map[string]*SecretEntry{
+		"billing/stripe": {Value: []byte("sk_live_staging"), Metadata: &SecretMetadata{
+			Notes: "Staging account, rotated by Finance", URL: "https://dashboard.stripe.com"}},
+		"db/main": {Fields: map[string]Field{
+			"password": {Value: "staging-hunter2", Sensitive: true},
+			"host":     {Value: "pg.staging.internal"},
+		}},
+		"db/prod": {Fields: map[string]Field{
+			"password": {Value: "prod-pass", Sensitive: true},
+			"host":     {Value: "pg.prod.internal"},
+		}},
+	}
+	for key, entry := range secrets {
+		if err := v.SetSecret(key, entry); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+
+	check := func(query string, want []SearchResult) {
+		t.Helper()
+		got, err := v.Search(query)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Search(%q) = %+v, want %+v", query, got, want)
+		}
+	}
+	runQueries := func() {
+		t.Helper()
+		check("STAG", []SearchResult{
+			{Key: "billing/stripe", Locations: []string{"notes"}},
+			{Key: "db/main", Locations: []string{"field:host"}},
+		})
+		check("stripe.com finance", []SearchResult{{Key: "billing/stripe", Locations: []string{"notes", "url"}}})
+		check("pg internal", []SearchResult{
+			{Key: "db/main", Locations: []string{"field:host"}},
+			{Key: "db/prod", Locations: []string{"field:host"}},
+		})
+		// Sensitive values are not searched
+		check("hunter2", []SearchResult{})
+		check("sk_live", []SearchResult{})
+	}
+
+	runQueries()
+	if _, err := v.Search(" -- "); !errors.Is(err, ErrEmptySearchQuery) {
+		t.Errorf("expected ErrEmptySearchQuery, got %v", err)
+	}
+
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.SearchIndex = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	runQueries()
+
+	var blob []byte
+	if err := v.db.QueryRow("SELECT encrypted_index FROM search_index").Scan(&blob); err != nil {
+		t.Fatalf("search index not stored: %v", err)
+	}
+	if bytes.Contains(blob, []byte("staging")) || bytes.Contains(blob, []byte("billing")) {
+		t.Error("search index is stored in plaintext")
+	}
+
+	// Writes update the index
+	if err := v.SetSecret("db/prod", &SecretEntry{Fields: map[string]Field{"host": {Value: "pg.eu.internal"}}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	check("eu", []SearchResult{{Key: "db/prod", Locations: []string{"field:host"}}})
+	if err := v.DeleteSecret("db/prod"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	check("eu", []SearchResult{})
+
+	// Changes made behind the index's back are picked up at search time
+	if _, err := v.db.Exec("UPDATE secrets SET deleted_at = CURRENT_TIMESTAMP WHERE key_hash = ?", v.hashKey("db/main")); err != nil {
+		t.Fatalf("failed to delete row: %v", err)
+	}
+	check("staging", []SearchResult{{Key: "billing/stripe", Locations: []string{"notes"}}})
+
+	// Turning the setting off drops the index on the next write
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.SearchIndex = false
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if err := v.SetSecret("other", &SecretEntry{Value: []byte("x")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	var n int
+	if err := v.db.QueryRow("SELECT COUNT(*) FROM search_index").Scan(&n); err != nil || n != 0 {
+		t.Errorf("search index not dropped: count %d, err %v", n, err)
+	}
+}
+
+func TestSearchIndexSurvivesRotateDEK(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.SearchIndex = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if err := v.SetSecret("api", &SecretEntry{Value: []byte("v"), Metadata: &SecretMetadata{Notes: "payments gateway"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.RotateDEK("testpassword123", nil); err != nil {
+		t.Fatalf("RotateDEK failed: %v", err)
+	}
+
+	got, err := v.Search("gateway")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Key != "api" {
+		t.Errorf("Search after RotateDEK = %+v", got)
+	}
+}
@@ -0,0 +1,251 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/atomicfile"
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/google/uuid"
+)
+
+// VaultSettings holds vault-level behavior options, stored in vault.meta.
+// The zero value is the default behavior.
+type VaultSettings struct {
+	// BlockExpiredReads makes GetSecret return ErrSecretExpired for expired
+	// secrets. Callers that need the value anyway must use GetSecretAllowExpired.
+	BlockExpiredReads bool `json:"block_expired_reads,omitempty"`
+
+	// DeprecatedReads selects how reads of deprecated keys behave:
+	// DeprecatedReadsError (default, also when empty) or DeprecatedReadsRedirect.
+	DeprecatedReads string `json:"deprecated_reads,omitempty"`
+
+	// AutoFixPermissions makes Unlock restore 0700/0600 on vault files
+	// owned by the current user whose permissions were loosened (e.g. by a
+	// umask or a copy), instead of only warning about them.
+	AutoFixPermissions bool `json:"auto_fix_permissions,omitempty"`
+
+	// OmitAuditHost stops recording hostname, OS user and process names
+	// in audit events.
+	OmitAuditHost bool `json:"omit_audit_host,omitempty"`
+
+	// LogLevel, LogFormat and LogFile configure diagnostic logging (see
+	// package logging). Flags and SECRETCTL_LOG_* variables take precedence.
+	LogLevel  string `json:"log_level,omitempty"`
+	LogFormat string `json:"log_format,omitempty"`
+	LogFile   string `json:"log_file,omitempty"`
+
+	// DynamicPlugins lists the plugins that may resolve dynamic:// secrets.
+	// Plugins not listed here are never run.
+	DynamicPlugins []string `json:"dynamic_plugins,omitempty"`
+
+	// MaskPolicy is how much of a sensitive value previews reveal (see
+	// ParseMaskPolicy). Empty means MaskDefault. MaskOverrides replace it
+	// for matching keys; the first match wins.
+	MaskPolicy    string         `json:"mask_policy,omitempty"`
+	MaskOverrides []MaskOverride `json:"mask_overrides,omitempty"`
+
+	// Timezone and Locale configure human-readable times (see package
+	// timefmt). Flags and SECRETCTL_TIMEZONE/SECRETCTL_LOCALE take precedence.
+	Timezone string `json:"timezone,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+
+	// ExpiryRules give secrets matching a pattern a default expiration
+	// date, or require one, when they are set without it. The first
+	// matching rule wins.
+	ExpiryRules []ExpiryRule `json:"expiry_rules,omitempty"`
+
+	// SearchIndex keeps an encrypted index of notes, URLs and non-sensitive
+	// field values, updated on write, so Search does not decrypt every
+	// secret. Turning it off drops the index on the next write.
+	SearchIndex bool `json:"search_index,omitempty"`
+
+	// BackupDir is where `secretctl backup` writes timestamped backups when
+	// no output is given.
+	BackupDir string `json:"backup_dir,omitempty"`
+
+	// AlertWebhook receives security alerts, such as a canary secret being
+	// read, as JSON POST requests (see package notify).
+	AlertWebhook string `json:"alert_webhook,omitempty"`
+
+	// FreezeWindows make matching secrets append-only for a while (see
+	// AddFreezeWindow).
+	FreezeWindows []FreezeWindow `json:"freeze_windows,omitempty"`
+
+	// EmergencyContacts can request access to the vault and receive the
+	// DEK if the owner does not deny the request in time (see
+	// AddEmergencyContact).
+	EmergencyContacts []EmergencyContact `json:"emergency_contacts,omitempty"`
+
+	// Shards are the namespaces whose secrets are stored in their own
+	// database file under shards/ (see AddShard).
+	Shards []string `json:"shards,omitempty"`
+
+	// ReadOnly rejects every change to secrets, folders and settings.
+	// ExpiresAt makes Unlock fail with ErrVaultExpired afterwards. Both are
+	// set on CI bundles and cannot be changed with config set.
+	ReadOnly  bool       `json:"read_only,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Settings returns the vault settings. The vault does not need to be
+// unlocked. While it is, the settings are read from vault.meta once and
+// read again only when the file changes.
+func (v *Vault) Settings() (*VaultSettings, error) {
+	cached := v.settings.Load()
+	if cached != nil {
+		if info, err := os.Stat(filepath.Join(v.path, MetaFileName)); err == nil && cached.matches(info) {
+			s := *cached.settings
+			return &s, nil
+		}
+	}
+	settings, info, err := v.readSettings()
+	if err != nil {
+		return nil, err
+	}
+	// Lock drops the cache; never bring it back afterwards
+	if cached != nil && info != nil {
+		v.settings.CompareAndSwap(cached, newSettingsCache(settings, info))
+	}
+	return settings, nil
+}
+
+// settingsCache holds the settings of an unlocked vault and the size and
+// modification time of vault.meta they were read from.
+type settingsCache struct {
+	settings *VaultSettings
+	size     int64
+	modTime  time.Time
+}
+
+func newSettingsCache(settings *VaultSettings, info os.FileInfo) *settingsCache {
+	s := *settings
+	return &settingsCache{settings: &s, size: info.Size(), modTime: info.ModTime()}
+}
+
+func (c *settingsCache) matches(info os.FileInfo) bool {
+	return info.Size() == c.size && info.ModTime().Equal(c.modTime)
+}
+
+// readSettings reads the settings from vault.meta, with the file info
+// taken before reading it, or nil if it could not be taken. A change made
+// in between makes the info stale, never the settings.
+func (v *Vault) readSettings() (*VaultSettings, os.FileInfo, error) {
+	info, statErr := os.Stat(filepath.Join(v.path, MetaFileName))
+	meta, err := v.readMeta()
+	if err != nil {
+		return nil, nil, err
+	}
+	if statErr != nil {
+		info = nil
+	}
+	if meta.Settings == nil {
+		return &VaultSettings{}, info, nil
+	}
+	return meta.Settings, info, nil
+}
+
+// UpdateSettings applies update to the vault settings and saves them.
+// If update returns an error, nothing is saved. The vault must be unlocked
+// so that only the owner can change behavior.
+func (v *Vault) UpdateSettings(update func(*VaultSettings) error) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+
+	meta, err := v.readMeta()
+	if err != nil {
+		return err
+	}
+	if err := meta.checkFeatures(); err != nil {
+		return err
+	}
+	if meta.Settings == nil {
+		meta.Settings = &VaultSettings{}
+	}
+	if meta.Settings.ReadOnly {
+		return ErrVaultReadOnly
+	}
+	if err := update(meta.Settings); err != nil {
+		return err
+	}
+
+	if err := v.writeMeta(meta); err != nil {
+		return err
+	}
+	v.applyAuditSettings(meta.Settings)
+	return nil
+}
+
+// applyAuditSettings configures the audit logger from the vault settings
+// and tags its events with the vault ID.
+func (v *Vault) applyAuditSettings(s *VaultSettings) {
+	if s != nil && s.OmitAuditHost {
+		v.audit.SetHost(nil)
+	} else {
+		v.audit.SetHost(audit.CurrentHost())
+	}
+	if id, err := v.ID(); err == nil {
+		v.audit.SetVaultID(id)
+	}
+}
+
+// readMeta reads and parses vault.meta.
+func (v *Vault) readMeta() (*VaultMeta, error) {
+	data, err := os.ReadFile(filepath.Join(v.path, MetaFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrVaultNotFound
+		}
+		return nil, fmt.Errorf("vault: failed to read metadata file: %w", err)
+	}
+	var meta VaultMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMetadataCorrupted, err)
+	}
+	return &meta, nil
+}
+
+// writeMeta atomically replaces vault.meta, updating the recorded features
+// and assigning an ID if the vault has none.
+func (v *Vault) writeMeta(meta *VaultMeta) error {
+	meta.updateFeatures()
+	if meta.ID == "" {
+		meta.ID = uuid.NewString()
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("vault: failed to marshal metadata: %w", err)
+	}
+	if err := atomicfile.Write(filepath.Join(v.path, MetaFileName), data, FileMode); err != nil {
+		return fmt.Errorf("vault: failed to write metadata file: %w", err)
+	}
+	// The modification time may not tell this write apart from the last
+	if v.settings.Load() != nil {
+		settings := meta.Settings
+		if settings == nil {
+			settings = &VaultSettings{}
+		}
+		if info, err := os.Stat(filepath.Join(v.path, MetaFileName)); err == nil {
+			v.settings.Store(newSettingsCache(settings, info))
+		} else {
+			v.settings.Store(nil)
+		}
+	}
+	return nil
+}
+
+// blockExpiredReads reports whether expired reads are blocked. A missing or
+// unreadable meta file falls back to the default (not blocked); integrity
+// problems are reported by CheckIntegrity.
+func (v *Vault) blockExpiredReads() bool {
+	settings, err := v.Settings()
+	return err == nil && settings.BlockExpiredReads
+}
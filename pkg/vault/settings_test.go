@@ -0,0 +1,229 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+func TestSettings(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	settings, err := v.Settings()
+	if err != nil {
+		t.Fatalf("Settings failed: %v", err)
+	}
+	if settings.BlockExpiredReads {
+		t.Error("expected block_expired_reads to default to false")
+	}
+
+	enable := func(s *VaultSettings) error {
+		s.BlockExpiredReads = true
+		return nil
+	}
+	if err := v.UpdateSettings(enable); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.UpdateSettings(enable); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	settings, _ = v.Settings()
+	if !settings.BlockExpiredReads {
+		t.Error("expected block_expired_reads to be saved")
+	}
+
+	// A failing update saves nothing
+	errBoom := errors.New("boom")
+	err = v.UpdateSettings(func(s *VaultSettings) error {
+		s.BlockExpiredReads = false
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("expected update error, got %v", err)
+	}
+	settings, _ = v.Settings()
+	if !settings.BlockExpiredReads {
+		t.Error("failed update must not be saved")
+	}
+
+	// Metadata survives a settings update
+	if result, err := v.CheckIntegrity(); err != nil || !result.MetaValid {
+		t.Errorf("expected valid metadata after update, got %+v (%v)", result, err)
+	}
+}
+
+func TestSettingsCache(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	metaPath := filepath.Join(dir, MetaFileName)
+	info, err := os.Stat(metaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unchanged file is not read again
+	if err := os.WriteFile(metaPath, bytes.Repeat([]byte("x"), int(info.Size())), FileMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(metaPath, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Settings(); err != nil {
+		t.Errorf("Settings read vault.meta again: %v", err)
+	}
+	if err := os.Chtimes(metaPath, info.ModTime().Add(time.Second), info.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Settings(); !errors.Is(err, ErrMetadataCorrupted) {
+		t.Errorf("expected a changed vault.meta to be read again, got %v", err)
+	}
+	v.Lock()
+
+	// Restore the file; it was only needed to observe reads
+	if err := os.WriteFile(metaPath, []byte(`{"version":"1.0.0"}`), FileMode); err != nil {
+		t.Fatal(err)
+	}
+	v = New(dir)
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	// UpdateSettings and other processes are seen right away
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.Timezone = "UTC"
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if s, _ := v.Settings(); s.Timezone != "UTC" {
+		t.Errorf("Timezone = %q after UpdateSettings, want UTC", s.Timezone)
+	}
+	other := New(dir)
+	if err := other.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := other.UpdateSettings(func(s *VaultSettings) error {
+		s.Timezone = "Asia/Tokyo"
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	other.Lock()
+	if s, _ := v.Settings(); s.Timezone != "Asia/Tokyo" {
+		t.Errorf("Timezone = %q after another process changed it, want Asia/Tokyo", s.Timezone)
+	}
+
+	// Callers cannot change the cached settings
+	s, _ := v.Settings()
+	s.Timezone = "changed"
+	if s, _ := v.Settings(); s.Timezone != "Asia/Tokyo" {
+		t.Errorf("Timezone = %q after changing a returned copy", s.Timezone)
+	}
+}
+
+func TestBlockExpiredReads(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("old", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	// Expiration dates in the past cannot be set through SetSecret
+	if _, err := v.db.Exec("UPDATE secrets SET expires_at = ?", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("failed to expire secret: %v", err)
+	}
+
+	// Default: expired secrets are readable
+	if _, err := v.GetSecret("old"); err != nil {
+		t.Errorf("expected expired read to succeed by default, got %v", err)
+	}
+
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.BlockExpiredReads = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+
+	if _, err := v.GetSecret("old"); !errors.Is(err, ErrSecretExpired) {
+		t.Errorf("expected ErrSecretExpired, got %v", err)
+	}
+	entry, err := v.GetSecretAllowExpired("old")
+	if err != nil {
+		t.Fatalf("GetSecretAllowExpired failed: %v", err)
+	}
+	if string(entry.Value) != "v" {
+		t.Errorf("unexpected value %q", entry.Value)
+	}
+}
+
+func TestSettings_OmitAuditHost(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	lastHost := func() *audit.Host {
+		t.Helper()
+		events, err := v.Audit().ListEvents(1, time.Time{})
+		if err != nil || len(events) != 1 {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		return events[0].Host
+	}
+
+	if err := v.SetSecret("k", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if lastHost() == nil {
+		t.Error("expected host identity by default")
+	}
+
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.OmitAuditHost = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if err := v.SetSecret("k", &SecretEntry{Value: []byte("v2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if host := lastHost(); host != nil {
+		t.Errorf("expected no host identity with omit_audit_host, got %+v", host)
+	}
+}
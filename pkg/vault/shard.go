@@ -0,0 +1,636 @@
+package vault
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// ShardsDirName is the directory, inside the vault, that holds the
+// database files of sharded namespaces.
+const ShardsDirName = "shards"
+
+// Shard errors
+var (
+	ErrInvalidShard     = errors.New("vault: invalid shard namespace")
+	ErrShardExists      = errors.New("vault: namespace is already sharded")
+	ErrShardNotFound    = errors.New("vault: namespace is not sharded")
+	ErrShardUnavailable = errors.New("vault: shard is unavailable")
+	ErrShardBusy        = errors.New("vault: cannot change shards while a vault-wide rewrite is unfinished")
+)
+
+// ShardInfo describes a sharded namespace.
+type ShardInfo struct {
+	Namespace string
+	Path      string
+	Secrets   int   // including secrets in the trash
+	Err       error // why the shard could not be attached; nil if available
+}
+
+// Namespace returns the namespace of key: the part before the first '/',
+// or "" if the key has none. Secrets without a namespace always live in
+// vault.db.
+func Namespace(key string) string {
+	ns, _, ok := strings.Cut(key, "/")
+	if !ok {
+		return ""
+	}
+	return ns
+}
+
+// shardForeignKey matches the folder reference of the secrets table. Shards
+// have no folders table, so folder_id is not enforced there; DeleteFolder
+// clears it in every shard instead.
+var shardForeignKey = regexp.MustCompile(`(?i)\s+REFERENCES\s+"?\w+"?\s*\([^)]*\)(\s+ON\s+(DELETE|UPDATE)\s+(SET\s+NULL|SET\s+DEFAULT|CASCADE|RESTRICT|NO\s+ACTION))*`)
+
+// shardTableName matches the start of the secrets table definition.
+var shardTableName = regexp.MustCompile(`(?i)^CREATE\s+TABLE\s+("secrets"|secrets)`)
+
+// shardPath returns the database file of the shard for ns, in the
+// snapshot if the vault is reading one.
+func (v *Vault) shardPath(ns string) string {
+	if v.snapshot != nil {
+		return filepath.Join(v.snapshot.dir, ShardsDirName, ns+".db")
+	}
+	return filepath.Join(v.path, ShardsDirName, ns+".db")
+}
+
+// shardFiles returns the namespaces that have a database file in the
+// shards directory, sorted.
+func (v *Vault) shardFiles() []string {
+	paths, _ := filepath.Glob(filepath.Join(v.path, ShardsDirName, "*.db"))
+	var names []string
+	for _, path := range paths {
+		if ns := strings.TrimSuffix(filepath.Base(path), ".db"); tagRegex.MatchString(ns) {
+			names = append(names, ns)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// shardSchema returns the quoted schema name ns is attached as.
+func shardSchema(ns string) string {
+	return `"shard_` + ns + `"`
+}
+
+// attachedShards returns the namespaces whose shard is attached, sorted.
+// Caller must hold v.mu.
+func (v *Vault) attachedShards() []string {
+	var names []string
+	for ns, err := range v.shards {
+		if err == nil {
+			names = append(names, ns)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// secretTables returns the physical secrets tables to write to: just
+// "secrets" in an unsharded vault, otherwise main.secrets and the secrets
+// table of every attached shard. Caller must hold v.mu.
+func (v *Vault) secretTables() []string {
+	shards := v.attachedShards()
+	if len(shards) == 0 {
+		return []string{"secrets"}
+	}
+	tables := []string{"main.secrets"}
+	for _, ns := range shards {
+		tables = append(tables, shardSchema(ns)+".secrets")
+	}
+	return tables
+}
+
+// checkShard returns ErrShardUnavailable if key belongs to a sharded
+// namespace whose shard could not be attached. Caller must hold v.mu.
+func (v *Vault) checkShard(key string) error {
+	ns := Namespace(key)
+	if ns == "" {
+		return nil
+	}
+	if err, ok := v.shards[ns]; ok && err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrShardUnavailable, ns, err)
+	}
+	return nil
+}
+
+// secretTable returns the physical table that key is stored in. Caller
+// must hold v.mu.
+func (v *Vault) secretTable(key string) (string, error) {
+	if err := v.checkShard(key); err != nil {
+		return "", err
+	}
+	if len(v.attachedShards()) == 0 {
+		return "secrets", nil
+	}
+	if ns := Namespace(key); ns != "" {
+		if _, ok := v.shards[ns]; ok {
+			return shardSchema(ns) + ".secrets", nil
+		}
+	}
+	return "main.secrets", nil
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// execSecrets runs an UPDATE or DELETE against every secrets table and
+// returns the total number of rows affected. query contains one %s where
+// the table name goes. Caller must hold v.mu.
+func (v *Vault) execSecrets(db execer, query string, args ...any) (int64, error) {
+	var total int64
+	for _, table := range v.secretTables() {
+		result, err := db.Exec(fmt.Sprintf(query, table), args...)
+		if err != nil {
+			return total, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// relocateSecretTx moves the row of keyHash into table if it is stored in
+// another secrets table, so that a write lands where the key belongs even
+// after an interrupted AddShard or RemoveShard. Caller must hold v.mu.
+func (v *Vault) relocateSecretTx(tx *sql.Tx, keyHash, table string) error {
+	tables := v.secretTables()
+	if len(tables) == 1 {
+		return nil
+	}
+	columns, err := secretColumns(tx)
+	if err != nil {
+		return err
+	}
+	for _, src := range tables {
+		if src == table {
+			continue
+		}
+		if err := moveSecretRows(tx, src, table, columns, "key_hash = ?", keyHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnInfo is a column of a secrets table.
+type columnInfo struct {
+	name    string
+	typ     string
+	notNull bool
+	dflt    sql.NullString
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// secretsColumnInfo returns the columns of the secrets table in schema.
+func secretsColumnInfo(q queryer, schema string) ([]columnInfo, error) {
+	rows, err := q.Query("PRAGMA " + schema + ".table_info(secrets)")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to read secrets columns: %w", err)
+	}
+	defer rows.Close()
+	var columns []columnInfo
+	for rows.Next() {
+		var cid, notNull, pk int
+		var c columnInfo
+		if err := rows.Scan(&cid, &c.name, &c.typ, &notNull, &c.dflt, &pk); err != nil {
+			return nil, fmt.Errorf("vault: failed to read secrets columns: %w", err)
+		}
+		c.notNull = notNull == 1
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: failed to read secrets columns: %w", err)
+	}
+	return columns, nil
+}
+
+// secretColumns returns the comma-separated columns of main.secrets except
+// id, which each table assigns itself.
+func secretColumns(q queryer) (string, error) {
+	info, err := secretsColumnInfo(q, "main")
+	if err != nil {
+		return "", err
+	}
+	var columns []string
+	for _, c := range info {
+		if c.name != "id" {
+			columns = append(columns, c.name)
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// moveSecretRows moves the rows of src matching where to dst.
+func moveSecretRows(tx *sql.Tx, src, dst, columns, where string, args ...any) error {
+	insert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s", dst, columns, columns, src, where)
+	if _, err := tx.Exec(insert, args...); err != nil {
+		return fmt.Errorf("vault: failed to move secrets to %s: %w", dst, err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", src, where), args...); err != nil {
+		return fmt.Errorf("vault: failed to move secrets from %s: %w", src, err)
+	}
+	return nil
+}
+
+// openShards attaches the shards listed in the vault settings. A shard
+// that fails to attach or is corrupted is skipped with a warning: its
+// secrets are unavailable, but the rest of the vault works. Caller must
+// hold v.mu.
+func (v *Vault) openShards() error {
+	v.shards = nil
+	settings, err := v.Settings()
+	if err != nil || len(settings.Shards) == 0 {
+		return nil
+	}
+	v.shards = make(map[string]error, len(settings.Shards))
+	for _, ns := range settings.Shards {
+		if err := v.attachShard(ns); err != nil {
+			v.shards[ns] = err
+			v.warn(EventWarning, "shard %s is unavailable, its secrets cannot be read or changed: %v", ns, err)
+			continue
+		}
+		v.shards[ns] = nil
+	}
+	return v.createSecretsView()
+}
+
+// attachShard attaches the shard for ns, creating its file if needed, and
+// brings its schema up to date with main.secrets. Caller must hold v.mu.
+func (v *Vault) attachShard(ns string) error {
+	path := v.shardPath(ns)
+	if v.snapshot != nil {
+		// Never substitute an empty shard for one that could not be copied
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("not in snapshot: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), DirMode); err != nil {
+		return fmt.Errorf("failed to create shards directory: %w", err)
+	}
+	// Create the file ourselves so that it gets the vault's permissions
+	if f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, FileMode); err == nil {
+		f.Close()
+	} else if !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("failed to create shard: %w", err)
+	}
+
+	schema := shardSchema(ns)
+	if _, err := v.db.Exec("ATTACH DATABASE ? AS "+schema, path); err != nil {
+		return fmt.Errorf("failed to attach shard: %w", err)
+	}
+	if err := v.checkShardSchema(ns); err != nil {
+		_, _ = v.db.Exec("DETACH DATABASE " + schema)
+		return err
+	}
+	return nil
+}
+
+// checkShardSchema verifies the integrity of the shard for ns and creates
+// or extends its secrets table to match main.secrets.
+func (v *Vault) checkShardSchema(ns string) error {
+	schema := shardSchema(ns)
+	var result string
+	if err := v.db.QueryRow("PRAGMA " + schema + ".quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var createSQL string
+	if err := v.db.QueryRow("SELECT sql FROM main.sqlite_master WHERE type = 'table' AND name = 'secrets'").Scan(&createSQL); err != nil {
+		return fmt.Errorf("failed to read secrets schema: %w", err)
+	}
+	if !shardTableName.MatchString(createSQL) {
+		return fmt.Errorf("unexpected secrets schema")
+	}
+	createSQL = shardTableName.ReplaceAllString(createSQL, "CREATE TABLE IF NOT EXISTS "+schema+".secrets")
+	createSQL = shardForeignKey.ReplaceAllString(createSQL, "")
+	if _, err := v.db.Exec(createSQL); err != nil {
+		return fmt.Errorf("failed to create shard schema: %w", err)
+	}
+	if _, err := v.db.Exec("CREATE INDEX IF NOT EXISTS " + schema + ".idx_secrets_folder ON secrets(folder_id)"); err != nil {
+		return fmt.Errorf("failed to create shard schema: %w", err)
+	}
+
+	// A shard created before a migration lacks the columns it added
+	have, err := secretsColumnInfo(v.db, schema)
+	if err != nil {
+		return err
+	}
+	want, err := secretsColumnInfo(v.db, "main")
+	if err != nil {
+		return err
+	}
+	for _, c := range want {
+		if slices.ContainsFunc(have, func(h columnInfo) bool { return h.name == c.name }) {
+			continue
+		}
+		def := c.name + " " + c.typ
+		if c.dflt.Valid {
+			if c.notNull {
+				def += " NOT NULL"
+			}
+			def += " DEFAULT " + c.dflt.String
+		}
+		if _, err := v.db.Exec("ALTER TABLE " + schema + ".secrets ADD COLUMN " + def); err != nil {
+			return fmt.Errorf("failed to update shard schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// createSecretsView replaces the secrets table, for reading, with a
+// temporary view over main.secrets and the attached shards, so that
+// queries see one table whichever file a secret is in. Writes must name
+// the physical table; see secretTable and execSecrets. Caller must hold
+// v.mu.
+func (v *Vault) createSecretsView() error {
+	if _, err := v.db.Exec("DROP VIEW IF EXISTS temp.secrets"); err != nil {
+		return fmt.Errorf("vault: failed to drop secrets view: %w", err)
+	}
+	shards := v.attachedShards()
+	if len(shards) == 0 {
+		return nil
+	}
+	columns, err := secretColumns(v.db)
+	if err != nil {
+		return err
+	}
+	columns = "id, " + columns
+	selects := []string{"SELECT " + columns + " FROM main.secrets"}
+	for _, ns := range shards {
+		selects = append(selects, "SELECT "+columns+" FROM "+shardSchema(ns)+".secrets")
+	}
+	view := "CREATE TEMP VIEW secrets AS " + strings.Join(selects, " UNION ALL ")
+	if _, err := v.db.Exec(view); err != nil {
+		return fmt.Errorf("vault: failed to create secrets view: %w", err)
+	}
+	return nil
+}
+
+// checkNoRewrite refuses to change shards while a rewrite is unfinished,
+// since its jobs are tracked per physical table. Caller must hold v.mu.
+func (v *Vault) checkNoRewrite() error {
+	var n int
+	if err := v.db.QueryRow("SELECT COUNT(*) FROM rewrite_jobs").Scan(&n); err != nil {
+		return fmt.Errorf("vault: failed to read rewrite jobs: %w", err)
+	}
+	if n > 0 {
+		return ErrShardBusy
+	}
+	return nil
+}
+
+// AddShard moves the secrets of namespace ns out of vault.db into their own
+// database file, shards/<ns>.db. Secrets whose key starts with "ns/" are
+// stored there from then on; the Vault API is unchanged.
+func (v *Vault) AddShard(ns string) error {
+	if ns == "" || !tagRegex.MatchString(ns) {
+		return fmt.Errorf("%w: namespace must contain only letters, numbers, '-' and '_'", ErrInvalidShard)
+	}
+
+	v.mu.RLock()
+	err := v.checkShardChange()
+	if err == nil {
+		if _, ok := v.shards[ns]; ok {
+			err = fmt.Errorf("%w: %s", ErrShardExists, ns)
+		}
+	}
+	v.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	err = v.UpdateSettings(func(s *VaultSettings) error {
+		if slices.Contains(s.Shards, ns) {
+			return fmt.Errorf("%w: %s", ErrShardExists, ns)
+		}
+		s.Shards = append(s.Shards, ns)
+		slices.Sort(s.Shards)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if v.shards == nil {
+		v.shards = make(map[string]error)
+	}
+	if err := v.attachShard(ns); err != nil {
+		v.shards[ns] = err
+		return fmt.Errorf("%w: %s: %v", ErrShardUnavailable, ns, err)
+	}
+	v.shards[ns] = nil
+	if err := v.createSecretsView(); err != nil {
+		return err
+	}
+
+	// Settings already route ns to the shard; rows left behind by a
+	// failure here are moved on their next write
+	moved, err := v.moveNamespace(ns)
+	if err != nil {
+		_ = v.audit.LogError(audit.OpVaultShard, v.auditSource(), ns, "SHARD_FAILED", err.Error())
+		return err
+	}
+	_ = v.audit.Log(audit.OpVaultShard, v.auditSource(), audit.ResultSuccess, ns, nil,
+		map[string]interface{}{"action": "add", "secrets": moved})
+	return nil
+}
+
+// moveNamespace moves the secrets of ns, including those in the trash,
+// from main.secrets to its shard in one transaction. Caller must hold
+// v.mu.
+func (v *Vault) moveNamespace(ns string) (int, error) {
+	tx, err := v.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The key is encrypted, so the namespace is only known after decrypting
+	rows, err := tx.Query("SELECT key_hash, encrypted_key FROM main.secrets")
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+	var hashes []string
+	for rows.Next() {
+		var keyHash string
+		var encryptedKey []byte
+		if err := rows.Scan(&keyHash, &encryptedKey); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		keyBytes, err := v.decryptWithNonce(encryptedKey)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("vault: failed to decrypt key: %w", err)
+		}
+		if Namespace(string(keyBytes)) == ns {
+			hashes = append(hashes, keyHash)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+
+	columns, err := secretColumns(tx)
+	if err != nil {
+		return 0, err
+	}
+	dst := shardSchema(ns) + ".secrets"
+	for _, keyHash := range hashes {
+		if err := moveSecretRows(tx, "main.secrets", dst, columns, "key_hash = ?", keyHash); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+	return len(hashes), nil
+}
+
+// RemoveShard moves the secrets of namespace ns back into vault.db and
+// deletes its shard file. The shard must be available.
+func (v *Vault) RemoveShard(ns string) error {
+	v.mu.Lock()
+	if err := v.checkShardChange(); err != nil {
+		v.mu.Unlock()
+		return err
+	}
+	shardErr, ok := v.shards[ns]
+	if !ok {
+		v.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrShardNotFound, ns)
+	}
+	if shardErr != nil {
+		v.mu.Unlock()
+		return fmt.Errorf("%w: %s: %v", ErrShardUnavailable, ns, shardErr)
+	}
+
+	moved, err := v.unshardNamespace(ns)
+	if err != nil {
+		v.mu.Unlock()
+		_ = v.audit.LogError(audit.OpVaultShard, v.auditSource(), ns, "SHARD_FAILED", err.Error())
+		return err
+	}
+	delete(v.shards, ns)
+	err = v.createSecretsView()
+	if err == nil {
+		if _, detachErr := v.db.Exec("DETACH DATABASE " + shardSchema(ns)); detachErr != nil {
+			err = fmt.Errorf("vault: failed to detach shard: %w", detachErr)
+		}
+	}
+	v.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// If this fails the empty shard is attached again at the next unlock,
+	// and secrets of ns written meanwhile move back into it
+	err = v.UpdateSettings(func(s *VaultSettings) error {
+		s.Shards = slices.DeleteFunc(s.Shards, func(name string) bool { return name == ns })
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(v.shardPath(ns)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("vault: failed to remove shard file: %w", err)
+	}
+	_ = v.audit.Log(audit.OpVaultShard, v.auditSource(), audit.ResultSuccess, ns, nil,
+		map[string]interface{}{"action": "remove", "secrets": moved})
+	return nil
+}
+
+// unshardNamespace moves every row of the shard for ns into main.secrets.
+// Caller must hold v.mu.
+func (v *Vault) unshardNamespace(ns string) (int, error) {
+	tx, err := v.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	src := shardSchema(ns) + ".secrets"
+	var n int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM " + src).Scan(&n); err != nil {
+		return 0, fmt.Errorf("vault: failed to count shard secrets: %w", err)
+	}
+	columns, err := secretColumns(tx)
+	if err != nil {
+		return 0, err
+	}
+	if err := moveSecretRows(tx, src, "main.secrets", columns, "1 = 1"); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
+
+// checkShardChange checks that the vault is unlocked and writable and no
+// rewrite is in progress. Caller must hold v.mu.
+func (v *Vault) checkShardChange() error {
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	return v.checkNoRewrite()
+}
+
+// Shards returns the sharded namespaces with the number of secrets in each,
+// sorted by namespace.
+func (v *Vault) Shards() ([]ShardInfo, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	names := make([]string, 0, len(v.shards))
+	for ns := range v.shards {
+		names = append(names, ns)
+	}
+	slices.Sort(names)
+
+	infos := make([]ShardInfo, 0, len(names))
+	for _, ns := range names {
+		info := ShardInfo{Namespace: ns, Path: v.shardPath(ns), Err: v.shards[ns]}
+		if info.Err == nil {
+			if err := v.db.QueryRow("SELECT COUNT(*) FROM " + shardSchema(ns) + ".secrets").Scan(&info.Secrets); err != nil {
+				return nil, fmt.Errorf("vault: failed to count shard secrets: %w", err)
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
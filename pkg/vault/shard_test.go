@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	for _, key := range []string{"prod/DB_URL", "prod/API_KEY", "dev/DB_URL", "TOP_LEVEL"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("value of " + key)}); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+	if err := v.DeleteSecret("prod/API_KEY"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+
+	if err := v.AddShard("bad/name"); !errors.Is(err, ErrInvalidShard) {
+		t.Errorf("expected ErrInvalidShard, got %v", err)
+	}
+	if err := v.AddShard("prod"); err != nil {
+		t.Fatalf("AddShard failed: %v", err)
+	}
+	if err := v.AddShard("prod"); !errors.Is(err, ErrShardExists) {
+		t.Errorf("expected ErrShardExists, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ShardsDirName, "prod.db")); err != nil {
+		t.Fatalf("shard file not created: %v", err)
+	}
+
+	shards, err := v.Shards()
+	if err != nil {
+		t.Fatalf("Shards failed: %v", err)
+	}
+	if len(shards) != 1 || shards[0].Namespace != "prod" || shards[0].Secrets != 2 || shards[0].Err != nil {
+		t.Fatalf("unexpected shards: %+v", shards)
+	}
+
+	// The API is unchanged: reads, lists, writes and the trash span all files
+	entry, err := v.GetSecret("prod/DB_URL")
+	if err != nil || string(entry.Value) != "value of prod/DB_URL" {
+		t.Fatalf("GetSecret from shard = %v, %v", entry, err)
+	}
+	keys, err := v.ListSecrets()
+	if err != nil || len(keys) != 3 {
+		t.Fatalf("ListSecrets = %v, %v", keys, err)
+	}
+	if err := v.SetSecret("prod/NEW", &SecretEntry{Value: []byte("new")}); err != nil {
+		t.Fatalf("SetSecret into shard failed: %v", err)
+	}
+	if err := v.RestoreSecret("prod/API_KEY"); err != nil {
+		t.Fatalf("RestoreSecret in shard failed: %v", err)
+	}
+	var inMain int
+	if err := v.db.QueryRow("SELECT COUNT(*) FROM main.secrets").Scan(&inMain); err != nil {
+		t.Fatal(err)
+	}
+	if inMain != 2 {
+		t.Errorf("main.secrets has %d rows, want 2", inMain)
+	}
+
+	// Shards are attached again on unlock
+	v.Lock()
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	entries, err := v.GetSecrets([]string{"prod/NEW", "dev/DB_URL"})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("GetSecrets after unlock = %v, %v", entries, err)
+	}
+
+	if err := v.RemoveShard("dev"); !errors.Is(err, ErrShardNotFound) {
+		t.Errorf("expected ErrShardNotFound, got %v", err)
+	}
+	if err := v.RemoveShard("prod"); err != nil {
+		t.Fatalf("RemoveShard failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ShardsDirName, "prod.db")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("shard file not removed: %v", err)
+	}
+	keys, err = v.ListSecrets()
+	if err != nil || len(keys) != 5 {
+		t.Fatalf("ListSecrets after RemoveShard = %v, %v", keys, err)
+	}
+	v.Lock()
+}
+
+func TestCorruptedShard(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("prod/DB_URL", &SecretEntry{Value: []byte("prod")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("dev/DB_URL", &SecretEntry{Value: []byte("dev")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.AddShard("prod"); err != nil {
+		t.Fatalf("AddShard failed: %v", err)
+	}
+	v.Lock()
+
+	if err := os.WriteFile(filepath.Join(dir, ShardsDirName, "prod.db"), []byte("not a database, just garbage bytes"), FileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rest of the vault still unlocks and works
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock with a corrupted shard failed: %v", err)
+	}
+	defer v.Lock()
+	if ev := nextEvent(t, events); ev.Type != EventWarning {
+		t.Errorf("expected a warning about the shard, got %+v", ev)
+	}
+	if _, err := v.GetSecret("dev/DB_URL"); err != nil {
+		t.Errorf("GetSecret outside the shard failed: %v", err)
+	}
+	if _, err := v.GetSecret("prod/DB_URL"); !errors.Is(err, ErrShardUnavailable) {
+		t.Errorf("expected ErrShardUnavailable, got %v", err)
+	}
+	if err := v.SetSecret("prod/OTHER", &SecretEntry{Value: []byte("x")}); !errors.Is(err, ErrShardUnavailable) {
+		t.Errorf("expected ErrShardUnavailable on write, got %v", err)
+	}
+	shards, err := v.Shards()
+	if err != nil || len(shards) != 1 || shards[0].Err == nil {
+		t.Errorf("Shards = %+v, %v", shards, err)
+	}
+}
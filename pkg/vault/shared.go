@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// SessionKey returns a copy of the DEK so that another process of the same
+// user can join this unlocked session with UnlockWithKey (e.g. an MCP server
+// attaching to the desktop app through the agent socket). Callers must wipe
+// the returned key.
+func (v *Vault) SessionKey() ([]byte, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	return append([]byte(nil), v.dek...), nil
+}
+
+// UnlockWithKey unlocks the vault with a DEK obtained from SessionKey instead
+// of the master password. The key is checked against the stored secrets and
+// copied, so the caller may wipe it afterwards.
+func (v *Vault) UnlockWithKey(dek []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.exists() {
+		return ErrVaultNotFound
+	}
+	if v.dek != nil {
+		return ErrVaultAlreadyUnlocked
+	}
+	if len(dek) != DEKLength {
+		return ErrInvalidDEK
+	}
+	if err := v.checkFeatures(); err != nil {
+		return err
+	}
+	suite := v.storedCipher()
+	rotatedAt := v.keyRotation()
+
+	dbPath := filepath.Join(v.path, DBFileName)
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("vault: failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	if err := verifyDEK(db, suite, dek); err != nil {
+		db.Close()
+		return err
+	}
+	// The session owner already migrated the schema when it unlocked
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		db.Close()
+		return fmt.Errorf("vault: failed to enable foreign keys: %w", err)
+	}
+
+	v.setDEK(dek)
+	v.cipher = suite
+	v.db = db
+	v.keyRotatedAt = rotatedAt
+	if err := v.openShards(); err != nil {
+		v.clearDEK()
+		v.db = nil
+		db.Close()
+		return err
+	}
+
+	if settings, info, err := v.readSettings(); err == nil {
+		v.applyAuditSettings(settings)
+		if info != nil {
+			v.settings.Store(newSettingsCache(settings, info))
+		}
+	}
+	if err := v.initAuditKeys(); err != nil {
+		v.warn(EventWarning, "failed to initialize audit logger: %v", err)
+	} else {
+		_ = v.audit.Log(audit.OpVaultUnlock, v.auditSource(), audit.ResultSuccess, "", nil, map[string]interface{}{
+			"shared_session": true,
+		})
+	}
+	v.Touch()
+	v.lockSignal.notify()
+	v.emit(EventUnlocked, "", "shared session")
+	return nil
+}
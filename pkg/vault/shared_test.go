@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestUnlockWithKey(t *testing.T) {
+	dir := t.TempDir()
+	owner := New(dir)
+	if err := owner.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := owner.SessionKey(); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("SessionKey on locked vault: expected ErrVaultLocked, got %v", err)
+	}
+	if err := owner.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer owner.Lock()
+	if err := owner.SetSecret("api/key", &SecretEntry{Value: []byte("s3cr3t")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	key, err := owner.SessionKey()
+	if err != nil {
+		t.Fatalf("SessionKey failed: %v", err)
+	}
+
+	wrong := bytes.Repeat([]byte{1}, DEKLength)
+	if err := New(dir).UnlockWithKey(wrong); !errors.Is(err, ErrDEKMismatch) {
+		t.Errorf("wrong key: expected ErrDEKMismatch, got %v", err)
+	}
+
+	attached := New(dir)
+	if err := attached.UnlockWithKey(key); err != nil {
+		t.Fatalf("UnlockWithKey failed: %v", err)
+	}
+	defer attached.Lock()
+	entry, err := attached.GetSecret("api/key")
+	if err != nil || string(entry.Value) != "s3cr3t" {
+		t.Fatalf("GetSecret = %v, %v", entry, err)
+	}
+
+	// Locking the attached handle must not affect the owner
+	attached.Lock()
+	if owner.IsLocked() {
+		t.Error("owner vault was locked by the attached handle")
+	}
+}
@@ -0,0 +1,129 @@
+package vault
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// ErrSnapshotReadOnly is returned for changes while the vault reads a
+// snapshot.
+var ErrSnapshotReadOnly = errors.New("vault: vault is reading a snapshot and cannot be changed")
+
+// snapshot is a point-in-time copy of the vault database that an unlocked
+// vault reads instead of vault.db.
+type snapshot struct {
+	dir       string // temporary directory with vault.db and shards/
+	createdAt time.Time
+}
+
+// UseSnapshot copies vault.db and its shards to a temporary directory and
+// switches the unlocked vault to read the copy, so that heavy read traffic
+// never contends with processes writing to vault.db. Every change fails
+// with ErrSnapshotReadOnly until the vault is locked, and changes made by
+// other processes are not seen until UseSnapshot is called again to
+// refresh the copy. Lock deletes the snapshot.
+func (v *Vault) UseSnapshot() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+
+	dir, err := os.MkdirTemp("", "secretctl-snapshot-*")
+	if err != nil {
+		return fmt.Errorf("vault: failed to create snapshot directory: %w", err)
+	}
+	if err := v.copyDatabase(dir); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, DBFileName)+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("vault: failed to open snapshot: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	oldDB, oldShards, oldSnapshot := v.db, v.shards, v.snapshot
+	v.db, v.snapshot = db, &snapshot{dir: dir, createdAt: time.Now()}
+	err = v.openShards()
+	if err == nil {
+		// Temporary views are created by openShards, so this comes last
+		if _, pragmaErr := db.Exec("PRAGMA query_only = ON"); pragmaErr != nil {
+			err = fmt.Errorf("vault: failed to open snapshot: %w", pragmaErr)
+		}
+	}
+	if err != nil {
+		v.db, v.shards, v.snapshot = oldDB, oldShards, oldSnapshot
+		db.Close()
+		os.RemoveAll(dir)
+		return err
+	}
+
+	oldDB.Close()
+	if oldSnapshot != nil {
+		os.RemoveAll(oldSnapshot.dir)
+	}
+	_ = v.audit.LogSuccess(audit.OpVaultSnapshot, v.auditSource(), "")
+	return nil
+}
+
+// SnapshotTime returns when the snapshot the vault reads was taken, and
+// false if it reads vault.db.
+func (v *Vault) SnapshotTime() (time.Time, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.snapshot == nil {
+		return time.Time{}, false
+	}
+	return v.snapshot.createdAt, true
+}
+
+// copyDatabase writes consistent copies of vault.db and every shard to
+// dir. VACUUM INTO reads each file in one transaction, so writers are only
+// held off while it runs. A shard that cannot be copied is left out and
+// reported as unavailable when the snapshot is opened. Caller must hold
+// v.mu.
+func (v *Vault) copyDatabase(dir string) error {
+	src, err := sql.Open("sqlite", filepath.Join(v.path, DBFileName)+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return fmt.Errorf("vault: failed to open database: %w", err)
+	}
+	defer src.Close()
+	if _, err := src.Exec("VACUUM INTO ?", filepath.Join(dir, DBFileName)); err != nil {
+		return fmt.Errorf("vault: failed to copy database: %w", err)
+	}
+
+	settings, err := v.Settings()
+	if err != nil || len(settings.Shards) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ShardsDirName), DirMode); err != nil {
+		return fmt.Errorf("vault: failed to create snapshot directory: %w", err)
+	}
+	for _, ns := range settings.Shards {
+		path := filepath.Join(v.path, ShardsDirName, ns+".db")
+		if _, err := os.Stat(path); err != nil {
+			continue // ATTACH would create it
+		}
+		if _, err := src.Exec("ATTACH DATABASE ? AS shard", path); err != nil {
+			continue
+		}
+		dst := filepath.Join(dir, ShardsDirName, ns+".db")
+		if _, err := src.Exec("VACUUM shard INTO ?", dst); err != nil {
+			os.Remove(dst)
+		}
+		_, _ = src.Exec("DETACH DATABASE shard")
+	}
+	return nil
+}
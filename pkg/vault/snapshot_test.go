@@ -0,0 +1,79 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestUseSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	v := New(dir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.SetSecret("prod/DB_URL", &SecretEntry{Value: []byte("prod")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("API_KEY", &SecretEntry{Value: []byte("old")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.AddShard("prod"); err != nil {
+		t.Fatalf("AddShard failed: %v", err)
+	}
+
+	if _, ok := v.SnapshotTime(); ok {
+		t.Error("SnapshotTime reported a snapshot before UseSnapshot")
+	}
+	if err := v.UseSnapshot(); err != nil {
+		t.Fatalf("UseSnapshot failed: %v", err)
+	}
+	if _, ok := v.SnapshotTime(); !ok {
+		t.Error("SnapshotTime reported no snapshot after UseSnapshot")
+	}
+	snapshotDir := v.snapshot.dir
+
+	// Reads span the copied shards
+	entry, err := v.GetSecret("prod/DB_URL")
+	if err != nil || string(entry.Value) != "prod" {
+		t.Fatalf("GetSecret from snapshot = %v, %v", entry, err)
+	}
+	if err := v.SetSecret("NEW", &SecretEntry{Value: []byte("x")}); !errors.Is(err, ErrSnapshotReadOnly) {
+		t.Errorf("expected ErrSnapshotReadOnly, got %v", err)
+	}
+
+	// Another process writes to vault.db
+	writer := New(dir)
+	if err := writer.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := writer.SetSecret("API_KEY", &SecretEntry{Value: []byte("new")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	writer.Lock()
+
+	entry, err = v.GetSecret("API_KEY")
+	if err != nil || string(entry.Value) != "old" {
+		t.Errorf("GetSecret before refresh = %v, %v", entry, err)
+	}
+	if err := v.UseSnapshot(); err != nil {
+		t.Fatalf("UseSnapshot refresh failed: %v", err)
+	}
+	entry, err = v.GetSecret("API_KEY")
+	if err != nil || string(entry.Value) != "new" {
+		t.Errorf("GetSecret after refresh = %v, %v", entry, err)
+	}
+	if _, err := os.Stat(snapshotDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("previous snapshot not removed: %v", err)
+	}
+
+	snapshotDir = v.snapshot.dir
+	v.Lock()
+	if _, err := os.Stat(snapshotDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("snapshot not removed on Lock: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// TagCount is a tag and the number of secrets that have it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// ListTags returns every tag in use with the number of secrets that have
+// it, sorted by tag. Secrets in the trash are not counted.
+func (v *Vault) ListTags() ([]TagCount, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]TagCount, error) {
+		return v.listTags()
+	})
+}
+
+func (v *Vault) listTags() ([]TagCount, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	rows, err := v.db.Query("SELECT tags FROM secrets WHERE deleted_at IS NULL AND tags IS NOT NULL AND tags != ''")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tagsStr sql.NullString
+		if err := rows.Scan(&tagsStr); err != nil {
+			return nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		var tags []string
+		_ = json.Unmarshal([]byte(tagsStr.String), &tags)
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+
+	result := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		result = append(result, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag < result[j].Tag })
+	return result, nil
+}
+
+// RenameTag replaces oldTag with newTag on every secret that has it, in a
+// single transaction, keeping the tag's position. Secrets that already
+// have newTag keep one copy. Like BulkUpdate, it fails without changing
+// anything if one of the secrets is immutable or frozen.
+func (v *Vault) RenameTag(oldTag, newTag string) (*BulkResult, error) {
+	return v.BulkUpdate(BulkFilter{Tag: oldTag}, BulkPatch{RenameTags: map[string]string{oldTag: newTag}})
+}
+
+// DeleteTag removes tag from every secret that has it, in a single
+// transaction. The secrets themselves are kept.
+func (v *Vault) DeleteTag(tag string) (*BulkResult, error) {
+	return v.BulkUpdate(BulkFilter{Tag: tag}, BulkPatch{RemoveTags: []string{tag}})
+}
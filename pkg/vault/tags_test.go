@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTagManagement(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	secrets := map[string][]string{
+		"db/main":  {"prod", "db"},
+		"db/copy":  {"db", "production"},
+		"api/key":  {"api", "prod", "billing"},
+		"untagged": nil,
+		"trashed":  {"prod"},
+	}
+	for key, tags := range secrets {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("v"), Tags: tags}); err != nil {
+			t.Fatalf("SetSecret failed: %v", err)
+		}
+	}
+	if err := v.DeleteSecret("trashed"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+
+	tags, err := v.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	want := []TagCount{{"api", 1}, {"billing", 1}, {"db", 2}, {"prod", 2}, {"production", 1}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListTags = %v, want %v", tags, want)
+	}
+
+	result, err := v.RenameTag("prod", "production")
+	if err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	if result.Matched != 2 || len(result.Changes) != 2 {
+		t.Errorf("expected 2 matched/changed, got %d/%d", result.Matched, len(result.Changes))
+	}
+	entry, _ := v.GetSecret("api/key")
+	if !reflect.DeepEqual(entry.Tags, []string{"api", "production", "billing"}) {
+		t.Errorf("rename should keep the position, got %v", entry.Tags)
+	}
+
+	if _, err := v.DeleteTag("db"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	tags, _ = v.ListTags()
+	want = []TagCount{{"api", 1}, {"billing", 1}, {"production", 3}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("ListTags = %v, want %v", tags, want)
+	}
+	entry, _ = v.GetSecret("db/copy")
+	if !reflect.DeepEqual(entry.Tags, []string{"production"}) {
+		t.Errorf("unexpected tags %v", entry.Tags)
+	}
+
+	if _, err := v.RenameTag("api", "bad tag"); !errors.Is(err, ErrTagInvalid) {
+		t.Errorf("expected ErrTagInvalid, got %v", err)
+	}
+
+	// Nothing changes if one secret cannot be updated
+	if err := v.SetSecret("pinned", &SecretEntry{Value: []byte("v"), Tags: []string{"billing"}, Immutable: true}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.RenameTag("billing", "finance"); !errors.Is(err, ErrSecretImmutable) {
+		t.Errorf("expected ErrSecretImmutable, got %v", err)
+	}
+	entry, _ = v.GetSecret("api/key")
+	if !reflect.DeepEqual(entry.Tags, []string{"api", "production", "billing"}) {
+		t.Errorf("failed rename must not change secrets, got %v", entry.Tags)
+	}
+}
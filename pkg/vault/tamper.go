@@ -0,0 +1,268 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/crypto"
+)
+
+// DefaultTamperCheckInterval is how often WatchFiles polls the vault files
+// where file system notifications are unavailable.
+const DefaultTamperCheckInterval = 2 * time.Second
+
+// fileBaseline is the state of the vault files when watching started.
+type fileBaseline struct {
+	salt      [sha256.Size]byte
+	saltFile  os.FileInfo
+	kdf       crypto.KDFParams
+	cipher    string
+	createdAt time.Time
+	db        os.FileInfo
+}
+
+// lockSignal tells WatchFiles that the vault was locked or unlocked. The
+// zero value is ready to use.
+type lockSignal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// wait returns a channel that is closed at the next notify.
+func (s *lockSignal) wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+func (s *lockSignal) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
+
+// WatchFiles watches the vault files while the vault is unlocked, until ctx
+// is canceled. When another process changes them in a way secretctl never
+// does, it logs a tamper-suspected audit event, publishes
+// EventTamperSuspected and locks the vault.
+//
+// Suspicious changes are: vault.salt written, even with the same content,
+// or removed; vault.meta removed, unreadable, its KDF parameters weakened
+// (stronger parameters come from SetKDFParams or an upgrade on unlock
+// elsewhere), its cipher suite or creation time changed; vault.db removed
+// or replaced by another file, or modified so that the unlocked key no
+// longer decrypts it. Writes by other secretctl processes (set, config)
+// are not reported. Replacing the files with 'secretctl restore' while an
+// agent or the desktop app is unlocked is reported, too. If another process
+// rotates the DEK with RotateDEK, the vault is locked without a report so
+// that it is unlocked again with the new key.
+//
+// The files are checked whenever the file system reports a change to them.
+// Where it cannot, they are polled every interval instead. source is
+// recorded in the audit event.
+func (v *Vault) WatchFiles(ctx context.Context, interval time.Duration, source string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = watcher.Add(v.path); err != nil {
+			watcher.Close()
+		}
+	}
+	if err != nil {
+		slog.Debug("vault: file notifications unavailable, polling vault files", "err", err)
+		v.pollFiles(ctx, interval, source)
+		return
+	}
+	defer watcher.Close()
+
+	changed := v.lockSignal.wait()
+	base := v.checkWatched(nil, source)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+			changed = v.lockSignal.wait()
+		case e, ok := <-watcher.Events:
+			if !ok {
+				v.pollFiles(ctx, interval, source)
+				return
+			}
+			if !isVaultFile(e.Name) {
+				continue
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				v.pollFiles(ctx, interval, source)
+				return
+			}
+			// Events may have been lost; check the files anyway
+			slog.Debug("vault: file notification error", "err", err)
+		}
+		base = v.checkWatched(base, source)
+	}
+}
+
+// pollFiles is WatchFiles for when file system notifications are
+// unavailable.
+func (v *Vault) pollFiles(ctx context.Context, interval time.Duration, source string) {
+	if interval <= 0 {
+		interval = DefaultTamperCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var base *fileBaseline
+	for {
+		base = v.checkWatched(base, source)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// isVaultFile reports whether path names one of the watched vault files.
+func isVaultFile(path string) bool {
+	switch filepath.Base(path) {
+	case DBFileName, SaltFileName, MetaFileName:
+		return true
+	}
+	return false
+}
+
+// checkWatched checks the vault files against base and returns the
+// baseline for the next check: nil while the vault is locked, a new one
+// once it is unlocked.
+func (v *Vault) checkWatched(base *fileBaseline, source string) *fileBaseline {
+	switch {
+	case v.IsLocked():
+		return nil
+	case v.keyRotatedElsewhere():
+		v.lockRotated()
+		return nil
+	case base == nil:
+		return v.fileBaseline()
+	}
+	if reason := v.checkFiles(base); reason != "" {
+		v.tamperSuspected(reason, source)
+		return nil
+	}
+	return base
+}
+
+// fileBaseline records the current vault files, or returns nil if they
+// cannot be read (the next check tries again).
+func (v *Vault) fileBaseline() *fileBaseline {
+	saltPath := filepath.Join(v.path, SaltFileName)
+	saltFile, err := os.Stat(saltPath)
+	if err != nil {
+		return nil
+	}
+	salt, err := os.ReadFile(saltPath)
+	if err != nil {
+		return nil
+	}
+	meta, err := v.readMeta()
+	if err != nil {
+		return nil
+	}
+	db, err := os.Stat(filepath.Join(v.path, DBFileName))
+	if err != nil {
+		return nil
+	}
+	base := &fileBaseline{salt: sha256.Sum256(salt), saltFile: saltFile, cipher: meta.cipher(), createdAt: meta.CreatedAt, db: db}
+	base.kdf = crypto.DefaultKDFParams()
+	if meta.KDF != nil {
+		base.kdf = *meta.KDF
+	}
+	return base
+}
+
+// checkFiles compares the vault files with base and describes the first
+// suspicious change, or returns "". Benign vault.db changes update base.
+func (v *Vault) checkFiles(base *fileBaseline) string {
+	saltPath := filepath.Join(v.path, SaltFileName)
+	saltFile, err := os.Stat(saltPath)
+	if err != nil {
+		return "vault.salt was removed or cannot be read"
+	}
+	salt, err := os.ReadFile(saltPath)
+	if err != nil {
+		return "vault.salt was removed or cannot be read"
+	}
+	// secretctl never writes vault.salt of an existing vault, so a write
+	// is suspicious even if it put the same salt back
+	if sha256.Sum256(salt) != base.salt || !os.SameFile(saltFile, base.saltFile) || !saltFile.ModTime().Equal(base.saltFile.ModTime()) {
+		return "vault.salt was modified"
+	}
+
+	meta, err := v.readMeta()
+	if err != nil {
+		return "vault.meta was removed or is corrupted"
+	}
+	kdf := crypto.DefaultKDFParams()
+	if meta.KDF != nil {
+		kdf = *meta.KDF
+	}
+	if kdf.WeakerThan(base.kdf) || !meta.CreatedAt.Equal(base.createdAt) {
+		return "vault.meta key derivation parameters were weakened or creation time was changed"
+	}
+	if meta.cipher() != base.cipher {
+		return "vault.meta cipher suite was changed"
+	}
+	base.kdf = kdf
+
+	db, err := os.Stat(filepath.Join(v.path, DBFileName))
+	if err != nil {
+		return "vault.db was removed or cannot be read"
+	}
+	if !os.SameFile(db, base.db) {
+		return "vault.db was replaced by another file"
+	}
+	if db.Size() != base.db.Size() || !db.ModTime().Equal(base.db.ModTime()) {
+		// Read errors (e.g. a busy database) are retried at the next check
+		if err := v.verifyOpenDB(); errors.Is(err, ErrDEKMismatch) {
+			return "vault.db no longer matches the vault key"
+		} else if err != nil {
+			return ""
+		}
+		base.db = db
+	}
+	return ""
+}
+
+// verifyOpenDB checks that the unlocked DEK still decrypts the database.
+func (v *Vault) verifyOpenDB() error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.dek == nil || v.db == nil {
+		return nil // locked meanwhile; nothing left to protect
+	}
+	return verifyDEK(v.db, v.cipher, v.dek)
+}
+
+// tamperSuspected records suspected tampering, locks the vault and then
+// announces it, so subscribers see a locked vault.
+func (v *Vault) tamperSuspected(reason, source string) {
+	_ = v.audit.LogError(audit.OpVaultTamper, source, "", "TAMPER_SUSPECTED", reason)
+	v.Lock()
+	if !v.emit(EventTamperSuspected, "", reason) {
+		slog.Error("vault locked: "+reason, "event", EventTamperSuspected)
+	}
+}
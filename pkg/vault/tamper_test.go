@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+func newWatchedVault(t *testing.T) (*Vault, string) {
+	t.Helper()
+	dir := t.TempDir()
+	v := New(dir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	t.Cleanup(v.Lock)
+	return v, dir
+}
+
+func TestCheckFiles(t *testing.T) {
+	v, dir := newWatchedVault(t)
+	base := v.fileBaseline()
+	if base == nil {
+		t.Fatal("fileBaseline returned nil")
+	}
+
+	// Our own writes and settings changes are not tampering
+	if err := v.SetSecret("db/password", &SecretEntry{Value: []byte("hunter2")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.BlockExpiredReads = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if reason := v.checkFiles(base); reason != "" {
+		t.Fatalf("benign changes reported as tampering: %s", reason)
+	}
+
+	// Replacing vault.db is tampering
+	dbPath := filepath.Join(dir, DBFileName)
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("failed to read db: %v", err)
+	}
+	if err := os.WriteFile(dbPath+".new", data, FileMode); err != nil {
+		t.Fatalf("failed to write db copy: %v", err)
+	}
+	if err := os.Rename(dbPath+".new", dbPath); err != nil {
+		t.Fatalf("failed to replace db: %v", err)
+	}
+	if reason := v.checkFiles(base); !strings.Contains(reason, "vault.db") {
+		t.Errorf("replaced db: reason = %q", reason)
+	}
+
+	// Modifying vault.salt is tampering
+	base = v.fileBaseline()
+	if err := os.WriteFile(filepath.Join(dir, SaltFileName), make([]byte, SaltLength), FileMode); err != nil {
+		t.Fatalf("failed to write salt: %v", err)
+	}
+	if reason := v.checkFiles(base); !strings.Contains(reason, "vault.salt") {
+		t.Errorf("modified salt: reason = %q", reason)
+	}
+}
+
+// waitTamper waits for an EventTamperSuspected whose message mentions want
+// and checks that the vault was locked.
+func waitTamper(t *testing.T, v *Vault, events <-chan Event, want string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Type != EventTamperSuspected {
+				continue
+			}
+			if !strings.Contains(e.Message, want) {
+				t.Errorf("message = %q, want it to mention %s", e.Message, want)
+			}
+			if !v.IsLocked() {
+				t.Error("vault should be locked after tampering")
+			}
+			return
+		case <-timeout:
+			t.Fatal("no tamper event")
+		}
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	v, dir := newWatchedVault(t)
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Polling every hour would not notice in time
+	go v.WatchFiles(ctx, time.Hour, audit.SourceAPI)
+
+	// Let the watcher take its baseline
+	time.Sleep(50 * time.Millisecond)
+	if err := os.Remove(filepath.Join(dir, MetaFileName)); err != nil {
+		t.Fatalf("failed to remove meta: %v", err)
+	}
+	waitTamper(t, v, events, "vault.meta")
+}
+
+func TestWatchFilesRevertedSalt(t *testing.T) {
+	v, dir := newWatchedVault(t)
+	v.Lock()
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go v.WatchFiles(ctx, time.Hour, audit.SourceAPI)
+
+	// The watcher takes its baseline when the vault is unlocked
+	time.Sleep(50 * time.Millisecond)
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// Writing the salt and putting the original back is still noticed
+	saltPath := filepath.Join(dir, SaltFileName)
+	salt, err := os.ReadFile(saltPath)
+	if err != nil {
+		t.Fatalf("failed to read salt: %v", err)
+	}
+	if err := os.WriteFile(saltPath, make([]byte, len(salt)), FileMode); err != nil {
+		t.Fatalf("failed to write salt: %v", err)
+	}
+	if err := os.WriteFile(saltPath, salt, FileMode); err != nil {
+		t.Fatalf("failed to restore salt: %v", err)
+	}
+	waitTamper(t, v, events, "vault.salt")
+}
+
+func TestPollFiles(t *testing.T) {
+	v, dir := newWatchedVault(t)
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go v.pollFiles(ctx, 10*time.Millisecond, audit.SourceAPI)
+
+	// Let the watcher take its baseline
+	time.Sleep(50 * time.Millisecond)
+	if err := os.Remove(filepath.Join(dir, MetaFileName)); err != nil {
+		t.Fatalf("failed to remove meta: %v", err)
+	}
+	waitTamper(t, v, events, "vault.meta")
+}
@@ -0,0 +1,156 @@
+package vault
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// UnlockPhase is how long one step of Unlock took. The steps are, in
+// order:
+//
+//   - checks: expiry, features and cooldown, read from vault.meta
+//   - open: opening vault.db and reading the wrapped key
+//   - prepare: reading the schema version and settings and setting up the
+//     connection; runs while the key is derived (Overlapped)
+//   - kdf: deriving the key from the master password and unwrapping the
+//     DEK, including the time prepare overlapped
+//   - migrate: schema migrations, if any
+//   - shards: attaching namespace shards
+//   - audit: lock state, features, backups and the audit log
+//   - finish: permission checks and warnings
+type UnlockPhase struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration_ns"`
+	// Overlapped is set for steps that ran alongside another one and
+	// are therefore not part of Total on their own.
+	Overlapped bool `json:"overlapped,omitempty"`
+}
+
+// UnlockTimings breaks down how long Unlock took.
+type UnlockTimings struct {
+	Total  time.Duration `json:"total_ns"`
+	Phases []UnlockPhase `json:"phases"`
+}
+
+// UnlockTimings returns the timings of the last successful Unlock of
+// this Vault, or nil if it has not been unlocked. They are kept after
+// Lock.
+func (v *Vault) UnlockTimings() *UnlockTimings {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.unlockTimings == nil {
+		return nil
+	}
+	t := *v.unlockTimings
+	t.Phases = slices.Clone(t.Phases)
+	return &t
+}
+
+// phaseTimer records the phases of an Unlock.
+type phaseTimer struct {
+	start, last time.Time
+	phases      []UnlockPhase
+}
+
+func newPhaseTimer() *phaseTimer {
+	now := time.Now()
+	return &phaseTimer{start: now, last: now}
+}
+
+// mark ends the phase name, which started at the previous mark.
+func (t *phaseTimer) mark(name string) {
+	now := time.Now()
+	t.phases = append(t.phases, UnlockPhase{Name: name, Duration: now.Sub(t.last)})
+	t.last = now
+}
+
+// overlapped records the phase name, which ran alongside the current one.
+func (t *phaseTimer) overlapped(name string, d time.Duration) {
+	t.phases = append(t.phases, UnlockPhase{Name: name, Duration: d, Overlapped: true})
+}
+
+func (t *phaseTimer) timings() *UnlockTimings {
+	return &UnlockTimings{Total: t.last.Sub(t.start), Phases: t.phases}
+}
+
+// unlockPrep is what Unlock reads from the database and vault.meta while
+// the key is derived.
+type unlockPrep struct {
+	version  int
+	settings *VaultSettings // nil if vault.meta could not be read
+	// settingsInfo is vault.meta's file info as settings were read
+	settingsInfo os.FileInfo
+	err          error
+}
+
+// vaultDSN returns the data source name Unlock opens vault.db with. When
+// the schema is known to be current, foreign keys are turned on as the
+// connection opens instead of by a statement after checking the schema.
+func vaultDSN(dbPath string, schemaCurrent bool) string {
+	dsn := dbPath + "?_pragma=busy_timeout(5000)"
+	if schemaCurrent {
+		dsn += "&_pragma=foreign_keys(1)"
+	}
+	return dsn
+}
+
+// cachedSchemaVersion returns VaultMeta.SchemaVersion, or 0 if vault.meta
+// cannot be read.
+func (v *Vault) cachedSchemaVersion() int {
+	meta, err := v.readMeta()
+	if err != nil {
+		return 0
+	}
+	return meta.SchemaVersion
+}
+
+// recordSchemaVersion records the current schema version in vault.meta
+// for the next Unlock. Caller must hold v.mu.
+func (v *Vault) recordSchemaVersion() error {
+	meta, err := v.readMeta()
+	if err != nil || meta.SchemaVersion == CurrentSchemaVersion {
+		return nil
+	}
+	if meta.Settings != nil && meta.Settings.ReadOnly {
+		return nil
+	}
+	meta.SchemaVersion = CurrentSchemaVersion
+	return v.writeMeta(meta)
+}
+
+// prepareUnlock does the part of opening db that does not need the DEK:
+// reading the schema version and the settings and, if no migration is
+// due, enabling foreign keys, which would otherwise get in the way of
+// migrations. fkOn reports whether db was opened with foreign keys on
+// because vault.meta recorded a current schema; it is checked all the
+// same, since vault.db may have been replaced since. It only reads the
+// database, so nothing changes before the master password is verified.
+// Caller must hold v.mu.
+func (v *Vault) prepareUnlock(db *sql.DB, fkOn bool) unlockPrep {
+	var p unlockPrep
+	if settings, info, err := v.readSettings(); err == nil {
+		p.settings, p.settingsInfo = settings, info
+	}
+	// With a current schema recorded the version table exists
+	if !fkOn || db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&p.version) != nil {
+		p.version, p.err = getSchemaVersion(db)
+	}
+	if p.err != nil {
+		p.err = fmt.Errorf("vault: schema migration failed: %w", p.err)
+		return p
+	}
+	switch current := p.version >= CurrentSchemaVersion; {
+	case current && !fkOn:
+		if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+			p.err = fmt.Errorf("vault: failed to enable foreign keys: %w", err)
+		}
+	case !current && fkOn:
+		if _, err := db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+			p.err = fmt.Errorf("vault: failed to disable foreign keys: %w", err)
+		}
+	}
+	return p
+}
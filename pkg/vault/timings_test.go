@@ -0,0 +1,110 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnlockTimings(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if v.UnlockTimings() != nil {
+		t.Error("expected no timings before Unlock")
+	}
+	if err := v.Unlock("wrongpassword1"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("expected ErrInvalidPassword, got %v", err)
+	}
+	if v.UnlockTimings() != nil {
+		t.Error("a failed Unlock must not record timings")
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	v.Lock()
+
+	timings := v.UnlockTimings()
+	if timings == nil {
+		t.Fatal("expected timings after Unlock")
+	}
+	var names []string
+	var sum int64
+	for _, p := range timings.Phases {
+		names = append(names, p.Name)
+		if p.Overlapped != (p.Name == "prepare") {
+			t.Errorf("phase %s: Overlapped = %v", p.Name, p.Overlapped)
+		}
+		if !p.Overlapped {
+			sum += int64(p.Duration)
+		}
+	}
+	want := []string{"checks", "open", "prepare", "kdf", "migrate", "shards", "audit", "finish"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("phases = %v, want %v", names, want)
+	}
+	if timings.Total <= 0 || sum != int64(timings.Total) {
+		t.Errorf("Total = %v, sum of phases = %v", timings.Total, sum)
+	}
+
+	// The connection is set up as before, with foreign keys enforced
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	var enabled int
+	if err := v.db.QueryRow("PRAGMA foreign_keys").Scan(&enabled); err != nil || enabled != 1 {
+		t.Errorf("foreign_keys = %d (%v), want 1", enabled, err)
+	}
+}
+
+func TestUnlockRecordsSchemaVersion(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if got := v.cachedSchemaVersion(); got != CurrentSchemaVersion {
+		t.Errorf("schema version after Init = %d, want %d", got, CurrentSchemaVersion)
+	}
+
+	// A vault.meta from before the version was recorded
+	meta, err := v.readMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.SchemaVersion = 0
+	if err := v.writeMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	v.Lock()
+	if got := v.cachedSchemaVersion(); got != CurrentSchemaVersion {
+		t.Errorf("schema version after Unlock = %d, want %d", got, CurrentSchemaVersion)
+	}
+
+	// A stale record does not skip migrations: foreign keys stay off
+	// while they run and are on afterwards
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if _, err := v.db.Exec("UPDATE schema_version SET version = ?", CurrentSchemaVersion-1); err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock with a stale schema version failed: %v", err)
+	}
+	defer v.Lock()
+	var version, enabled int
+	if err := v.db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil || version != CurrentSchemaVersion {
+		t.Errorf("schema version = %d (%v), want %d", version, err, CurrentSchemaVersion)
+	}
+	if err := v.db.QueryRow("PRAGMA foreign_keys").Scan(&enabled); err != nil || enabled != 1 {
+		t.Errorf("foreign_keys = %d (%v), want 1", enabled, err)
+	}
+}
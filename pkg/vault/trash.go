@@ -0,0 +1,182 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+)
+
+// ErrNotInTrash is returned by RestoreSecret when no deleted secret has the key.
+var ErrNotInTrash = errors.New("vault: secret is not in the trash")
+
+// DeletedSecret describes a secret in the trash. Values are not included.
+type DeletedSecret struct {
+	Key       string
+	Tags      []string
+	DeletedAt time.Time
+}
+
+// ListDeleted returns the secrets in the trash, most recently deleted first.
+func (v *Vault) ListDeleted() ([]*DeletedSecret, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*DeletedSecret, error) {
+		return v.listDeleted()
+	})
+}
+
+func (v *Vault) listDeleted() ([]*DeletedSecret, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+
+	rows, err := v.db.Query("SELECT encrypted_key, tags, deleted_at FROM secrets WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query deleted secrets: %w", err)
+	}
+	defer rows.Close()
+
+	var deleted []*DeletedSecret
+	for rows.Next() {
+		var encryptedKey []byte
+		var tagsStr sql.NullString
+		var d DeletedSecret
+		if err := rows.Scan(&encryptedKey, &tagsStr, &d.DeletedAt); err != nil {
+			return nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		keyBytes, err := v.decryptWithNonce(encryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to decrypt key name: %w", err)
+		}
+		d.Key = string(keyBytes)
+		if tagsStr.Valid && tagsStr.String != "" {
+			_ = json.Unmarshal([]byte(tagsStr.String), &d.Tags)
+		}
+		deleted = append(deleted, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	return deleted, nil
+}
+
+// RestoreSecret moves a deleted secret out of the trash with its fields,
+// metadata and change history.
+func (v *Vault) RestoreSecret(key string) error {
+	return v.run(Op{Name: OpRestore, Key: key}, func() error {
+		return v.restoreSecret(key)
+	})
+}
+
+func (v *Vault) restoreSecret(key string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := v.checkShard(key); err != nil {
+		return err
+	}
+
+	rowsAffected, err := v.execSecrets(v.db, "UPDATE %s SET deleted_at = NULL WHERE key_hash = ? AND deleted_at IS NOT NULL", v.hashKey(key))
+	if err != nil {
+		_ = v.audit.LogError(audit.OpSecretRestore, v.auditSource(), key, "DB_ERROR", err.Error())
+		return fmt.Errorf("vault: failed to restore secret: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotInTrash
+	}
+
+	_ = v.audit.LogSuccess(audit.OpSecretRestore, v.auditSource(), key)
+	v.emit(EventSecretChanged, key, "restored")
+	v.updateSearchIndex()
+	return nil
+}
+
+// PurgeDeleted permanently removes secrets that have been in the trash for
+// at least olderThan; zero empties the trash. It returns the purged keys.
+func (v *Vault) PurgeDeleted(olderThan time.Duration) ([]string, error) {
+	return runValue(v, Op{Name: OpPurge}, func() ([]string, error) {
+		return v.purgeDeleted(olderThan)
+	})
+}
+
+func (v *Vault) purgeDeleted(olderThan time.Duration) ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return nil, ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	keys, err := v.purgeSecretsTx(tx, "deleted_at IS NOT NULL AND deleted_at <= ?", time.Now().Add(-olderThan))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	for _, key := range keys {
+		_ = v.audit.LogSuccess(audit.OpSecretPurge, v.auditSource(), key)
+	}
+	return keys, nil
+}
+
+// purgeSecretsTx deletes the secrets matching where, with their change
+// history, attachments and canary markers, and returns their key names.
+func (v *Vault) purgeSecretsTx(tx *sql.Tx, where string, args ...any) ([]string, error) {
+	rows, err := tx.Query("SELECT key_hash, encrypted_key FROM secrets WHERE "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to query deleted secrets: %w", err)
+	}
+	var hashes, keys []string
+	for rows.Next() {
+		var keyHash string
+		var encryptedKey []byte
+		if err := rows.Scan(&keyHash, &encryptedKey); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		hashes = append(hashes, keyHash)
+		// The key name is only needed for the audit log
+		if keyBytes, err := v.decryptWithNonce(encryptedKey); err == nil {
+			keys = append(keys, string(keyBytes))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+
+	for _, keyHash := range hashes {
+		for _, table := range []string{"secret_history", "attachments", "canaries", "secret_access", "secret_paths"} {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE key_hash = ?", keyHash); err != nil {
+				return nil, fmt.Errorf("vault: failed to purge %s: %w", table, err)
+			}
+		}
+		if _, err := v.execSecrets(tx, "DELETE FROM %s WHERE key_hash = ?", keyHash); err != nil {
+			return nil, fmt.Errorf("vault: failed to purge secrets: %w", err)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("api/token", &SecretEntry{Value: []byte("v1"), Tags: []string{"api"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.SetSecret("api/token", &SecretEntry{Value: []byte("v2"), Tags: []string{"api"}}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.DeleteSecret("api/token"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+
+	// Deleted secrets are hidden
+	if _, err := v.GetSecret("api/token"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+	if keys, _ := v.ListSecrets(); len(keys) != 0 {
+		t.Errorf("ListSecrets = %v, want none", keys)
+	}
+	if err := v.DeleteSecret("api/token"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound deleting twice, got %v", err)
+	}
+
+	deleted, err := v.ListDeleted()
+	if err != nil || len(deleted) != 1 || deleted[0].Key != "api/token" || deleted[0].Tags[0] != "api" {
+		t.Fatalf("ListDeleted = %+v, %v", deleted, err)
+	}
+
+	// Restore brings back the value and history
+	if err := v.RestoreSecret("api/token"); err != nil {
+		t.Fatalf("RestoreSecret failed: %v", err)
+	}
+	if entry, err := v.GetSecret("api/token"); err != nil || string(entry.Value) != "v2" {
+		t.Errorf("GetSecret after restore = %v, %v", entry, err)
+	}
+	if history, err := v.GetSecretHistory("api/token"); err != nil || len(history) != 2 {
+		t.Errorf("history after restore = %d entries, %v", len(history), err)
+	}
+	if err := v.RestoreSecret("api/token"); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("expected ErrNotInTrash, got %v", err)
+	}
+
+	// Setting a deleted key replaces the trashed secret
+	if err := v.DeleteSecret("api/token"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if err := v.SetSecret("api/token", &SecretEntry{Value: []byte("new")}); err != nil {
+		t.Fatalf("SetSecret over a deleted key failed: %v", err)
+	}
+	if deleted, _ := v.ListDeleted(); len(deleted) != 0 {
+		t.Errorf("trash not cleared by SetSecret: %+v", deleted)
+	}
+	if history, _ := v.GetSecretHistory("api/token"); len(history) != 1 {
+		t.Errorf("history of the replaced secret = %d entries, want 1", len(history))
+	}
+
+	// Purge
+	if err := v.DeleteSecret("api/token"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	keys, err := v.PurgeDeleted(time.Hour)
+	if err != nil || len(keys) != 0 {
+		t.Errorf("PurgeDeleted(1h) = %v, %v; want nothing purged", keys, err)
+	}
+	keys, err = v.PurgeDeleted(0)
+	if err != nil || len(keys) != 1 || keys[0] != "api/token" {
+		t.Errorf("PurgeDeleted(0) = %v, %v", keys, err)
+	}
+	if err := v.RestoreSecret("api/token"); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("expected ErrNotInTrash after purge, got %v", err)
+	}
+}
+
+func TestTrashFolder(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	folder := &Folder{Name: "prod"}
+	if err := v.CreateFolder(folder); err != nil {
+		t.Fatalf("CreateFolder failed: %v", err)
+	}
+	if err := v.SetSecret("db", &SecretEntry{Value: []byte("x"), FolderID: &folder.ID}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if err := v.DeleteSecret("db"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+
+	// A folder holding only deleted secrets is empty
+	if err := v.DeleteFolder(folder.ID, false); err != nil {
+		t.Fatalf("DeleteFolder failed: %v", err)
+	}
+	if err := v.RestoreSecret("db"); err != nil {
+		t.Fatalf("RestoreSecret failed: %v", err)
+	}
+	if entry, err := v.GetSecret("db"); err != nil || entry.FolderID != nil {
+		t.Errorf("restored secret = %+v, %v; want unfiled", entry, err)
+	}
+}
@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// MaxUsageRecords is the number of consumers remembered per secret. The
+// least recently used consumer is dropped first.
+const MaxUsageRecords = 20
+
+// Usage records that a command was run with a secret injected.
+type Usage struct {
+	Command  string    `json:"command"`
+	Source   string    `json:"source"` // audit source, e.g. "cli" or "mcp"
+	LastUsed time.Time `json:"last_used"`
+}
+
+// RecordUsage remembers that command (reduced to its base name) was run
+// with key injected, so that the "used by" list in the secret's metadata
+// answers what a rotation will affect. It only touches the encrypted
+// metadata: the secret's update time and history are left alone, and
+// immutable secrets are recorded too. Read-only vaults are not changed.
+func (v *Vault) RecordUsage(key, command, source string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if v.checkWritable() != nil {
+		return nil
+	}
+	command = filepath.Base(command)
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	keyHash := v.hashKey(key)
+	metadata, err := v.loadMetadataTx(tx, keyHash)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		metadata = &SecretMetadata{}
+	}
+	metadata.UsedBy = addUsage(metadata.UsedBy, Usage{Command: command, Source: source, LastUsed: time.Now().UTC()})
+
+	encrypted, err := v.encryptMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	if _, err := v.execSecrets(tx, "UPDATE %s SET encrypted_metadata = ? WHERE key_hash = ? AND deleted_at IS NULL", encrypted, keyHash); err != nil {
+		return fmt.Errorf("vault: failed to update metadata: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// addUsage adds or refreshes u in records, most recent first.
+func addUsage(records []Usage, u Usage) []Usage {
+	out := []Usage{u}
+	for _, r := range records {
+		if r.Command != u.Command || r.Source != u.Source {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].LastUsed.After(out[j].LastUsed) })
+	if len(out) > MaxUsageRecords {
+		out = out[:MaxUsageRecords]
+	}
+	return out
+}
+
+// loadMetadataTx returns the decrypted metadata of a secret, nil if it has
+// none, or ErrSecretNotFound.
+func (v *Vault) loadMetadataTx(tx *sql.Tx, keyHash string) (*SecretMetadata, error) {
+	var encrypted []byte
+	err := tx.QueryRow("SELECT encrypted_metadata FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).Scan(&encrypted)
+	if err == sql.ErrNoRows {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to read secret: %w", err)
+	}
+	if len(encrypted) == 0 {
+		return nil, nil
+	}
+	metadataJSON, err := v.decryptWithNonce(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to decrypt metadata: %w", err)
+	}
+	var metadata SecretMetadata
+	if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+		return nil, fmt.Errorf("vault: failed to unmarshal metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// encryptMetadata encrypts metadata, returning nil if it is empty.
+func (v *Vault) encryptMetadata(metadata *SecretMetadata) ([]byte, error) {
+	if metadata == nil || (metadata.Notes == "" && metadata.URL == "" && metadata.Owner == "" && metadata.Team == "" && len(metadata.UsedBy) == 0) {
+		return nil, nil
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to marshal metadata: %w", err)
+	}
+	encrypted, err := v.encryptWithNonce(metadataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to encrypt metadata: %w", err)
+	}
+	return encrypted, nil
+}
@@ -0,0 +1,76 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordUsage(t *testing.T) {
+	v := New(t.TempDir())
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	entry := &SecretEntry{Value: []byte("v1"), Metadata: &SecretMetadata{Notes: "prod db"}}
+	if err := v.SetSecret("db/password", entry); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	before, _ := v.GetSecret("db/password")
+
+	if err := v.RecordUsage("db/password", "/usr/bin/psql", "cli"); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := v.RecordUsage("db/password", "migrate", "mcp"); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := v.RecordUsage("db/password", "psql", "cli"); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := v.RecordUsage("missing", "psql", "cli"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("RecordUsage on missing key = %v, want ErrSecretNotFound", err)
+	}
+
+	got, err := v.GetSecret("db/password")
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	used := got.Metadata.UsedBy
+	if len(used) != 2 || used[0].Command != "psql" || used[1].Command != "migrate" {
+		t.Fatalf("UsedBy = %+v, want psql then migrate", used)
+	}
+	if got.Metadata.Notes != "prod db" {
+		t.Errorf("notes lost: %q", got.Metadata.Notes)
+	}
+	if !got.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Error("RecordUsage should not change the update time")
+	}
+
+	// Rotating the secret keeps the record, even if the caller passes its own
+	rotated := &SecretEntry{Value: []byte("v2"), Metadata: &SecretMetadata{UsedBy: []Usage{{Command: "fake"}}}}
+	if err := v.SetSecret("db/password", rotated); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	got, _ = v.GetSecret("db/password")
+	if got.Metadata == nil || len(got.Metadata.UsedBy) != 2 || got.Metadata.UsedBy[0].Command != "psql" {
+		t.Errorf("UsedBy after rotation = %+v, want the recorded consumers", got.Metadata)
+	}
+}
+
+func TestAddUsageLimit(t *testing.T) {
+	var records []Usage
+	t0 := time.Now()
+	for i := 0; i < MaxUsageRecords+5; i++ {
+		records = addUsage(records, Usage{Command: string(rune('a' + i)), Source: "cli", LastUsed: t0.Add(time.Duration(i) * time.Second)})
+	}
+	if len(records) != MaxUsageRecords {
+		t.Fatalf("kept %d records, want %d", len(records), MaxUsageRecords)
+	}
+	if records[0].Command != string(rune('a'+MaxUsageRecords+4)) {
+		t.Errorf("most recent record first, got %q", records[0].Command)
+	}
+}
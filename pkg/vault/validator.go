@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Field value validators, referenced by name from Field.Validator
+const (
+	ValidatorAWSAccessKeyID = "aws_access_key_id"
+	ValidatorUUID           = "uuid"
+	ValidatorBase64         = "base64"
+	ValidatorPEM            = "pem"
+	ValidatorURL            = "url"
+)
+
+// Validator errors
+var (
+	ErrValidatorUnknown = errors.New("vault: unknown validator")
+	ErrValidationFailed = errors.New("vault: field value failed validation")
+)
+
+var (
+	awsAccessKeyIDRegex = regexp.MustCompile(`^(AKIA|ASIA|AIDA|AROA|AGPA|ANPA|ANVA|AIPA|APKA)[A-Z2-7]{16}$`)
+	uuidRegex           = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// validators maps validator names to a check returning a short reason on
+// failure. Checks must not include the value in the reason.
+var validators = map[string]func(value string) string{
+	ValidatorAWSAccessKeyID: func(value string) string {
+		if !awsAccessKeyIDRegex.MatchString(value) {
+			return "expected 20 characters like AKIA followed by 16 uppercase letters or digits"
+		}
+		return ""
+	},
+	ValidatorUUID: func(value string) string {
+		if !uuidRegex.MatchString(value) {
+			return "expected a UUID like 123e4567-e89b-12d3-a456-426614174000"
+		}
+		return ""
+	},
+	ValidatorBase64: func(value string) string {
+		s := strings.TrimSpace(value)
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if _, err := enc.DecodeString(s); err == nil {
+				return ""
+			}
+		}
+		return "not valid base64"
+	},
+	ValidatorPEM: func(value string) string {
+		block, rest := pem.Decode([]byte(strings.TrimSpace(value)))
+		if block == nil {
+			return "expected a PEM block (-----BEGIN ...-----)"
+		}
+		// Chains are fine; trailing garbage after the last block is not
+		for len(strings.TrimSpace(string(rest))) > 0 {
+			if block, rest = pem.Decode(rest); block == nil {
+				return "unexpected data after PEM block"
+			}
+		}
+		return ""
+	},
+	ValidatorURL: func(value string) string {
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "expected an absolute URL like https://example.com"
+		}
+		return ""
+	},
+}
+
+// Validators returns the names of the built-in field validators.
+func Validators() []string {
+	names := make([]string, 0, len(validators))
+	for name := range validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateFieldValue applies the field's validator, if any. Empty values
+// are not checked so optional fields can be left blank.
+func validateFieldValue(name string, field *Field) error {
+	if field.Validator == "" {
+		return nil
+	}
+	check, ok := validators[field.Validator]
+	if !ok {
+		return fmt.Errorf("%w: field %q has validator %q (available: %s)", ErrValidatorUnknown, name, field.Validator, strings.Join(Validators(), ", "))
+	}
+	// A ref:// value is validated where the referenced field is stored
+	if field.Value == "" || IsSecretRef(field.Value) {
+		return nil
+	}
+	if reason := check(field.Value); reason != "" {
+		return fmt.Errorf("%w: field %q (%s): %s", ErrValidationFailed, name, field.Validator, reason)
+	}
+	return nil
+}
@@ -39,17 +39,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/forest6511/secretctl/pkg/atomicfile"
 	"github.com/forest6511/secretctl/pkg/audit"
 	"github.com/forest6511/secretctl/pkg/crypto"
+	"github.com/google/uuid"
 
 	_ "modernc.org/sqlite"
 )
@@ -84,11 +89,12 @@ const (
 	MinKeyLength = 1           // Minimum key name length
 
 	// Metadata validation limits per requirements-ja.md §2.5
-	MaxNotesSize = 10 * 1024 // 10 KB maximum notes size
-	MaxURLLength = 2048      // Maximum URL length (RFC 3986)
-	MaxTagCount  = 10        // Maximum number of tags
-	MaxTagLength = 64        // Maximum length of each tag
-	MinTagLength = 1         // Minimum length of each tag
+	MaxNotesSize   = 10 * 1024 // 10 KB maximum notes size
+	MaxURLLength   = 2048      // Maximum URL length (RFC 3986)
+	MaxTagCount    = 10        // Maximum number of tags
+	MaxTagLength   = 64        // Maximum length of each tag
+	MinTagLength   = 1         // Minimum length of each tag
+	MaxOwnerLength = 64        // Maximum length of owner and team names
 )
 
 // Errors
@@ -101,6 +107,7 @@ var (
 	ErrSaltNotFound         = errors.New("vault: salt file not found")
 	ErrDEKNotFound          = errors.New("vault: encrypted DEK not found in database")
 	ErrSecretNotFound       = errors.New("vault: secret not found")
+	ErrSecretExpired        = errors.New("vault: secret has expired")
 	ErrVaultCorrupted       = errors.New("vault: vault is corrupted")
 	ErrMetadataCorrupted    = errors.New("vault: metadata file is corrupted")
 	ErrDatabaseCorrupted    = errors.New("vault: database is corrupted")
@@ -116,6 +123,7 @@ var (
 	ErrURLInvalid           = errors.New("vault: invalid url format")
 	ErrTooManyTags          = errors.New("vault: too many tags")
 	ErrTagInvalid           = errors.New("vault: invalid tag format")
+	ErrOwnerInvalid         = errors.New("vault: invalid owner or team name")
 	ErrExpiresInPast        = errors.New("vault: expires_at must be in the future")
 	ErrPasswordTooShort     = errors.New("vault: password must be at least 8 characters")
 	ErrPasswordTooLong      = errors.New("vault: password must be at most 128 characters")
@@ -186,24 +194,7 @@ func ValidateMasterPassword(password string) *PasswordValidationResult {
 	}
 
 	// Complexity checks (warnings only, per requirements)
-	hasUpper := regexp.MustCompile(`[A-Z]`).MatchString(password)
-	hasLower := regexp.MustCompile(`[a-z]`).MatchString(password)
-	hasDigit := regexp.MustCompile(`\d`).MatchString(password)
-	hasSpecial := regexp.MustCompile(`[!@#$%^&*(),.?":{}|<>\-_=+\[\]\\;'~/\x60]`).MatchString(password)
-
-	complexity := 0
-	if hasUpper {
-		complexity++
-	}
-	if hasLower {
-		complexity++
-	}
-	if hasDigit {
-		complexity++
-	}
-	if hasSpecial {
-		complexity++
-	}
+	complexity := passwordComplexity(password)
 
 	// Generate warnings for weak complexity
 	if complexity < 2 {
@@ -215,25 +206,63 @@ func ValidateMasterPassword(password string) *PasswordValidationResult {
 			"Longer passwords (12+ characters) are more secure")
 	}
 
-	// Determine strength based on complexity and length
+	result.Strength = RatePassword(password)
+	return result
+}
+
+// RatePassword estimates the strength of a password of any length the way
+// ValidateMasterPassword does, e.g. for generated secret values.
+func RatePassword(password string) PasswordStrength {
+	complexity := passwordComplexity(password)
 	switch {
 	case complexity >= 3 && len(password) >= 16:
-		result.Strength = PasswordStrong
+		return PasswordStrong
 	case complexity >= 2 && len(password) >= 12:
-		result.Strength = PasswordGood
+		return PasswordGood
 	case complexity >= 2 || len(password) >= 12:
-		result.Strength = PasswordFair
+		return PasswordFair
 	default:
-		result.Strength = PasswordWeak
+		return PasswordWeak
 	}
+}
 
-	return result
+// passwordComplexity counts the character classes in password: upper and
+// lower case letters, digits and symbols.
+func passwordComplexity(password string) int {
+	complexity := 0
+	for _, re := range []string{`[A-Z]`, `[a-z]`, `\d`, `[!@#$%^&*(),.?":{}|<>\-_=+\[\]\\;'~/\x60]`} {
+		if regexp.MustCompile(re).MatchString(password) {
+			complexity++
+		}
+	}
+	return complexity
 }
 
 // VaultMeta holds vault metadata
 type VaultMeta struct {
-	Version   string    `json:"version"`
-	CreatedAt time.Time `json:"created_at"`
+	// ID identifies the vault, e.g. in backups; it is assigned when the
+	// vault is created, or first unlocked by a version that records it
+	ID        string            `json:"id,omitempty"`
+	Version   string            `json:"version"`
+	CreatedAt time.Time         `json:"created_at"`
+	KDF       *crypto.KDFParams `json:"kdf,omitempty"` // nil means crypto.DefaultKDFParams
+	Settings  *VaultSettings    `json:"settings,omitempty"`
+	// KDFPending are the parameters a change of KDF parameters is
+	// switching to; set only until the change completes
+	KDFPending *crypto.KDFParams `json:"kdf_pending,omitempty"`
+	// Features maps the features the vault uses to their values (see
+	// FeatureCipher); a newer vault may use ones this binary refuses
+	Features map[string]string `json:"features,omitempty"`
+	// KeyRotatedAt is when RotateDEK last replaced the DEK; sessions
+	// unlocked before then must not write
+	KeyRotatedAt time.Time `json:"key_rotated_at,omitzero"`
+	// RecoveryKits are the kits written by IssueRecoveryKit for the
+	// current DEK
+	RecoveryKits []RecoveryKitRecord `json:"recovery_kits,omitempty"`
+	// SchemaVersion is the schema version of vault.db as of the last
+	// Unlock; when it is current, Unlock sets the connection up when
+	// opening it (see vaultDSN)
+	SchemaVersion int `json:"schema_version,omitempty"`
 }
 
 // LockState tracks failed unlock attempts for cooldown enforcement
@@ -249,6 +278,13 @@ type LockState struct {
 type SecretMetadata struct {
 	Notes string `json:"notes,omitempty"` // Encrypted: additional notes
 	URL   string `json:"url,omitempty"`   // Encrypted: associated URL
+	// Owner and Team record who is responsible for rotating the secret,
+	// e.g. "alice" or "alice@example.com" and "platform".
+	Owner string `json:"owner,omitempty"`
+	Team  string `json:"team,omitempty"`
+	// UsedBy lists the commands the secret was injected into, most recent
+	// first. It is maintained by RecordUsage; values set by callers are ignored.
+	UsedBy []Usage `json:"used_by,omitempty"`
 }
 
 // SecretEntry represents a complete secret with all its data
@@ -276,18 +312,65 @@ type SecretEntry struct {
 	Metadata   *SecretMetadata   // Encrypted metadata (notes, url)
 	Tags       []string          // Plaintext: searchable tags
 	ExpiresAt  *time.Time        // Plaintext: expiration date
+	NotBefore  *time.Time        // Plaintext: secret cannot be read before this time
 	FieldCount int               // Number of fields (plaintext for MCP secret_list)
 	CreatedAt  time.Time         // Creation timestamp
 	UpdatedAt  time.Time         // Last update timestamp
+
+	// LastAccessedAt and AccessCount tell when and how often the value was
+	// read; only ListStaleSecrets sets them.
+	LastAccessedAt *time.Time
+	AccessCount    int
+
+	// AccessWindow restricts reads to certain days and times (plaintext).
+	AccessWindow *AccessWindow
+
+	// Immutable secrets can only be updated or deleted via break-glass
+	// (SetSecretBreakGlass, DeleteSecretBreakGlass).
+	Immutable bool
+
+	// Deprecation is set when the key has been deprecated (encrypted).
+	Deprecation *Deprecation
+	// Certificate is set when a field holds an X.509 certificate. It is
+	// derived from the field on write; values set by callers are ignored.
+	Certificate *CertificateInfo
+
+	// RedirectedFrom is the deprecated key that was requested when this entry
+	// was returned by following a redirect (deprecated_reads: redirect).
+	RedirectedFrom string
+
+	// References maps each field that was read through a ref:// reference
+	// to the reference as stored. ReferencedKeys lists every secret read
+	// to resolve them, including references of references.
+	References     map[string]string
+	ReferencedKeys []string
 }
 
 // Vault manages the entire secret storage
 type Vault struct {
-	path  string        // Path to vault directory (e.g., ~/.secretctl)
-	dek   []byte        // Decrypted Data Encryption Key (held in memory when unlocked)
-	db    *sql.DB       // SQLite database connection
-	mu    sync.RWMutex  // Concurrency control
-	audit *audit.Logger // Audit logger
+	path     string               // Path to vault directory (e.g., ~/.secretctl)
+	dek      []byte               // Decrypted Data Encryption Key (held in memory when unlocked)
+	dekBuf   *crypto.LockedBuffer // Non-swappable memory holding dek
+	cipher   string               // Cipher suite of data encrypted with dek (FeatureCipher)
+	db       *sql.DB              // SQLite database connection
+	mu       sync.RWMutex         // Concurrency control
+	audit    *audit.Logger        // Audit logger
+	events   eventBus             // Subscribers of Events
+	dynamic  dynamicCache         // Plugin results for dynamic:// secrets
+	shards   map[string]error     // Sharded namespaces; non-nil if the shard could not be attached
+	snapshot *snapshot            // Copy of the database read instead of vault.db, if any
+	// keyRotatedAt is VaultMeta.KeyRotatedAt as of unlocking
+	keyRotatedAt time.Time
+	// memlockWarned is set once a DEK could not be locked in memory
+	memlockWarned bool
+	autoLock      autoLock        // See SetAutoLock
+	access        accessBatch     // See recordAccess
+	lockSignal    lockSignal      // See WatchFiles
+	source        atomic.Value    // See SetAuditSource
+	middleware    middlewareChain // See Use
+	unlockTimings *UnlockTimings  // See UnlockTimings
+	// settings caches Settings while the vault is unlocked
+	settings atomic.Pointer[settingsCache]
 }
 
 // New creates a new Vault management object for the specified path
@@ -299,6 +382,22 @@ func New(path string) *Vault {
 	}
 }
 
+// SetAuditSource sets the source recorded in the audit events of this
+// vault's operations. It defaults to audit.SourceCLI; the MCP server, the
+// desktop app (audit.SourceUI) and the agent and SDK (audit.SourceAPI) set
+// their own, so their changes are attributed to them.
+func (v *Vault) SetAuditSource(source string) {
+	v.source.Store(source)
+}
+
+// auditSource returns the source set with SetAuditSource.
+func (v *Vault) auditSource() string {
+	if source, ok := v.source.Load().(string); ok {
+		return source
+	}
+	return audit.SourceCLI
+}
+
 // Init initializes a new vault:
 // 1. Generate salt and save to vault.salt
 // 2. Derive KEK from master password and salt
@@ -308,6 +407,29 @@ func New(path string) *Vault {
 // 6. Save encrypted DEK to database
 // 7. Create vault.meta file
 func (v *Vault) Init(masterPassword string) error {
+	return v.InitWithKDF(masterPassword, crypto.DefaultKDFParams())
+}
+
+// InitWithKDF is Init with explicit Argon2id parameters (see
+// crypto.CalibrateKDF), which are recorded in vault.meta. Parameters weaker
+// than the defaults are raised when the vault is first unlocked.
+func (v *Vault) InitWithKDF(masterPassword string, params crypto.KDFParams) error {
+	return v.InitWithOptions(masterPassword, InitOptions{KDF: params})
+}
+
+// InitOptions configures a new vault.
+type InitOptions struct {
+	// KDF are the Argon2id parameters; zero means crypto.DefaultKDFParams
+	KDF crypto.KDFParams
+	// Cipher is the cipher suite that encrypts the vault's data, recorded
+	// as FeatureCipher; empty means AES-256-GCM. It cannot be changed
+	// later.
+	Cipher string
+}
+
+// InitWithOptions is Init with the key derivation parameters and cipher
+// suite of opts.
+func (v *Vault) InitWithOptions(masterPassword string, opts InitOptions) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
@@ -315,6 +437,23 @@ func (v *Vault) Init(masterPassword string) error {
 	if v.exists() {
 		return ErrVaultAlreadyExists
 	}
+	params := opts.KDF
+	if params == (crypto.KDFParams{}) {
+		params = crypto.DefaultKDFParams()
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if params.Version == 0 {
+		params.Version = crypto.DefaultKDFParams().Version
+	}
+	suite := opts.Cipher
+	if suite == "" {
+		suite = CipherAES256GCM
+	}
+	if !slices.Contains(supportedFeatures[FeatureCipher], suite) {
+		return fmt.Errorf("%w: %q", crypto.ErrUnsupportedCipher, suite)
+	}
 
 	// Check disk space before initialization (per Codex review)
 	if err := v.checkDiskSpaceForWrite(1024 * 1024); err != nil { // Require at least 1MB for init
@@ -332,7 +471,7 @@ func (v *Vault) Init(masterPassword string) error {
 		return fmt.Errorf("vault: failed to generate salt: %w", err)
 	}
 	saltPath := filepath.Join(v.path, SaltFileName)
-	if err := os.WriteFile(saltPath, salt, FileMode); err != nil {
+	if err := atomicfile.Write(saltPath, salt, FileMode); err != nil {
 		return fmt.Errorf("vault: failed to write salt file: %w", err)
 	}
 
@@ -340,7 +479,7 @@ func (v *Vault) Init(masterPassword string) error {
 	// Convert password to []byte and wipe after use to minimize memory exposure
 	passwordBytes := []byte(masterPassword)
 	defer crypto.SecureWipe(passwordBytes)
-	kek := crypto.DeriveKey(passwordBytes, salt)
+	kek := crypto.DeriveKeyWithParams(passwordBytes, salt, params)
 	defer crypto.SecureWipe(kek) // Wipe KEK when done
 
 	// 3. Generate DEK (32 bytes)
@@ -411,24 +550,25 @@ func (v *Vault) Init(masterPassword string) error {
 
 	// 7. Create metadata file
 	meta := VaultMeta{
+		ID:        uuid.NewString(),
 		Version:   "1.0.0",
 		CreatedAt: time.Now().UTC(),
+		KDF:       &params,
+		Features:  map[string]string{FeatureCipher: suite},
+		// createTables creates the current schema
+		SchemaVersion: CurrentSchemaVersion,
 	}
-	metaJSON, err := json.MarshalIndent(meta, "", "  ")
-	if err != nil {
-		return fmt.Errorf("vault: failed to marshal metadata: %w", err)
-	}
-	metaPath := filepath.Join(v.path, MetaFileName)
-	if err := os.WriteFile(metaPath, metaJSON, FileMode); err != nil {
-		return fmt.Errorf("vault: failed to write metadata file: %w", err)
+	if err := v.writeMeta(&meta); err != nil {
+		return err
 	}
 
 	// Initialize audit logger with derived key and log vault init
+	v.audit.SetVaultID(meta.ID)
 	if err := v.audit.SetHMACKey(dek); err != nil {
 		// Non-fatal: audit logging is best-effort in Phase 0
-		fmt.Fprintf(os.Stderr, "warning: failed to initialize audit logger: %v\n", err)
+		v.warn(EventWarning, "failed to initialize audit logger: %v", err)
 	} else {
-		_ = v.audit.LogSuccess(audit.OpVaultInit, audit.SourceCLI, "")
+		_ = v.audit.LogSuccess(audit.OpVaultInit, v.auditSource(), "")
 	}
 
 	return nil
@@ -436,11 +576,14 @@ func (v *Vault) Init(masterPassword string) error {
 
 // Unlock unlocks the vault using the master password:
 // 1. Check cooldown status
-// 2. Read salt file
-// 3. Derive KEK from master password and salt
-// 4. Read encrypted DEK and nonce from database
-// 5. Decrypt DEK using KEK
-// 6. Store decrypted DEK in Vault struct
+// 2. Read salt and encrypted DEK and nonce from database
+// 3. Derive KEK from master password and salt, preparing the database
+// meanwhile
+// 4. Decrypt DEK using KEK
+// 5. Store decrypted DEK in Vault struct
+// 6. Run schema migrations
+//
+// UnlockTimings reports how long each step took.
 func (v *Vault) Unlock(masterPassword string) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
@@ -455,6 +598,18 @@ func (v *Vault) Unlock(masterPassword string) error {
 		return ErrVaultAlreadyUnlocked
 	}
 
+	timer := newPhaseTimer()
+	if err := v.checkExpiry(time.Now()); err != nil {
+		return err
+	}
+	if err := v.checkFeatures(); err != nil {
+		return err
+	}
+	suite := v.storedCipher()
+	// Read before the DEK, so a rotation in between leaves this session
+	// unable to write rather than writing with a replaced DEK
+	rotatedAt := v.keyRotation()
+
 	// Check cooldown status
 	if remaining, err := v.checkCooldown(); err != nil {
 		if errors.Is(err, ErrCooldownActive) {
@@ -463,9 +618,12 @@ func (v *Vault) Unlock(masterPassword string) error {
 		return err
 	}
 
-	// 1. Open database to read salt (ADR-003: salt stored in DB for atomic password change)
-	dbPath := filepath.Join(v.path, DBFileName)
-	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+	timer.mark("checks")
+
+	// 1. Open database to read the salt and the wrapped DEK (ADR-003: salt
+	// stored in DB for atomic password change)
+	schemaCurrent := v.cachedSchemaVersion() == CurrentSchemaVersion
+	db, err := sql.Open("sqlite", vaultDSN(filepath.Join(v.path, DBFileName), schemaCurrent))
 	if err != nil {
 		return fmt.Errorf("vault: failed to open database: %w", err)
 	}
@@ -474,27 +632,30 @@ func (v *Vault) Unlock(masterPassword string) error {
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 
-	// Try to read salt from database first (v4+ schema)
-	var salt []byte
-	err = db.QueryRow("SELECT salt FROM vault_keys WHERE id = 1").Scan(&salt)
+	// Read salt and DEK in one query (v4+ schema)
+	var salt, encryptedDEK, nonce []byte
+	err = db.QueryRow("SELECT salt, encrypted_dek, dek_nonce FROM vault_keys WHERE id = 1").
+		Scan(&salt, &encryptedDEK, &nonce)
 	if err != nil || len(salt) == 0 {
 		// Fallback to file for pre-v4 vaults (migration will happen after unlock)
-		db.Close()
 		saltPath := filepath.Join(v.path, SaltFileName)
 		salt, err = os.ReadFile(saltPath)
 		if err != nil {
+			db.Close()
 			if os.IsNotExist(err) {
 				return ErrSaltNotFound
 			}
 			return fmt.Errorf("vault: failed to read salt file: %w", err)
 		}
-		// Reopen database for subsequent operations
-		db, err = sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+		err = db.QueryRow("SELECT encrypted_dek, dek_nonce FROM vault_keys WHERE id = 1").
+			Scan(&encryptedDEK, &nonce)
 		if err != nil {
-			return fmt.Errorf("vault: failed to reopen database: %w", err)
+			db.Close()
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrDEKNotFound
+			}
+			return fmt.Errorf("vault: failed to read encrypted DEK: %w", err)
 		}
-		db.SetMaxOpenConns(1)
-		db.SetMaxIdleConns(1)
 	}
 
 	// Validate salt length to detect corruption/tampering
@@ -502,28 +663,29 @@ func (v *Vault) Unlock(masterPassword string) error {
 		db.Close()
 		return ErrVaultCorrupted
 	}
+	timer.mark("open")
 
-	// 2. Derive KEK
 	// Convert password to []byte and wipe after use to minimize memory exposure
 	passwordBytes := []byte(masterPassword)
 	defer crypto.SecureWipe(passwordBytes)
-	kek := crypto.DeriveKey(passwordBytes, salt)
-	defer crypto.SecureWipe(kek) // Wipe KEK after decrypting DEK
-
-	// 3. Read encrypted DEK and nonce from database (db already opened in step 1)
-	var encryptedDEK, nonce []byte
-	err = db.QueryRow("SELECT encrypted_dek, dek_nonce FROM vault_keys WHERE id = 1").
-		Scan(&encryptedDEK, &nonce)
-	if err != nil {
-		db.Close()
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrDEKNotFound
-		}
-		return fmt.Errorf("vault: failed to read encrypted DEK: %w", err)
-	}
 
-	// 4. Decrypt DEK
-	dek, err := crypto.Decrypt(kek, encryptedDEK, nonce)
+	// 2-4. Derive KEK and decrypt DEK. Key derivation takes most of the
+	// time of Unlock, so the database is prepared meanwhile.
+	type unwrapped struct {
+		dek []byte
+		err error
+	}
+	derived := make(chan unwrapped, 1)
+	go func() {
+		dek, err := v.unwrapDEK(passwordBytes, salt, encryptedDEK, nonce)
+		derived <- unwrapped{dek, err}
+	}()
+	prepStart := time.Now()
+	prep := v.prepareUnlock(db, schemaCurrent)
+	timer.overlapped("prepare", time.Since(prepStart))
+	result := <-derived
+	dek, err := result.dek, result.err
+	timer.mark("kdf")
 	if err != nil {
 		db.Close()
 		if errors.Is(err, crypto.ErrDecryptionFailed) {
@@ -531,55 +693,97 @@ func (v *Vault) Unlock(masterPassword string) error {
 			cooldown, recordErr := v.recordFailedAttempt()
 			if recordErr != nil {
 				// Log but don't fail - security is more important than audit
-				fmt.Fprintf(os.Stderr, "warning: failed to record unlock attempt: %v\n", recordErr)
+				v.warn(EventWarning, "failed to record unlock attempt: %v", recordErr)
 			}
 			// Log failed unlock attempt
-			_ = v.audit.LogError(audit.OpVaultUnlockFailed, audit.SourceCLI, "", "AUTH_FAILED", "invalid master password")
+			_ = v.audit.LogError(audit.OpVaultUnlockFailed, v.auditSource(), "", "AUTH_FAILED", "invalid master password")
 			if cooldown > 0 {
+				v.emit(EventCooldownTriggered, "", fmt.Sprintf("too many failed unlock attempts, cooldown %v", cooldown.Round(time.Second)))
 				return fmt.Errorf("%w: cooldown activated for %v", ErrTooManyAttempts, cooldown.Round(time.Second))
 			}
 			return ErrInvalidPassword
 		}
 		return fmt.Errorf("vault: failed to decrypt DEK: %w", err)
 	}
+	if prep.err != nil {
+		crypto.SecureWipe(dek)
+		db.Close()
+		return prep.err
+	}
 
 	// 5. Store DEK in memory on success
-	v.dek = dek
+	v.setDEK(dek)
+	crypto.SecureWipe(dek)
+	v.cipher = suite
 	v.db = db
-
-	// 6. Run schema migrations if needed
-	if err := migrateSchema(db, v.path); err != nil {
-		v.dek = nil
-		v.db = nil
-		db.Close()
-		return fmt.Errorf("vault: schema migration failed: %w", err)
+	v.keyRotatedAt = rotatedAt
+
+	// 6. Run schema migrations if needed, then enable foreign keys
+	// (required for ON DELETE RESTRICT per ADR-007); prepareUnlock
+	// already did if there was nothing to migrate
+	if prep.version < CurrentSchemaVersion {
+		if err := migrateSchemaFrom(db, v.path, prep.version); err != nil {
+			v.clearDEK()
+			v.db = nil
+			db.Close()
+			return fmt.Errorf("vault: schema migration failed: %w", err)
+		}
+		if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+			v.clearDEK()
+			v.db = nil
+			db.Close()
+			return fmt.Errorf("vault: failed to enable foreign keys: %w", err)
+		}
 	}
+	timer.mark("migrate")
 
-	// 7. Enable foreign keys (required for ON DELETE RESTRICT per ADR-007)
-	_, err = db.Exec("PRAGMA foreign_keys = ON")
-	if err != nil {
-		v.dek = nil
+	// 7. Attach namespace shards; unavailable ones only produce a warning
+	if err := v.openShards(); err != nil {
+		v.clearDEK()
 		v.db = nil
 		db.Close()
-		return fmt.Errorf("vault: failed to enable foreign keys: %w", err)
+		return err
 	}
+	timer.mark("shards")
 
 	// Clear lock state on successful unlock
 	if err := v.clearLockState(); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to clear lock state: %v\n", err)
+		v.warn(EventWarning, "failed to clear lock state: %v", err)
+	}
+	if err := v.recordFeatures(); err != nil {
+		v.warn(EventWarning, "failed to record vault features: %v", err)
 	}
+	if err := v.recordSchemaVersion(); err != nil {
+		v.warn(EventWarning, "failed to record schema version: %v", err)
+	}
+	v.removeIncompleteBackups()
 
 	// Initialize audit logger with DEK and log successful unlock
-	if err := v.audit.SetHMACKey(dek); err != nil {
-		fmt.Fprintf(os.Stderr, "warning: failed to initialize audit logger: %v\n", err)
+	if prep.settings != nil {
+		v.applyAuditSettings(prep.settings)
+	}
+	if err := v.initAuditKeys(); err != nil {
+		v.warn(EventWarning, "failed to initialize audit logger: %v", err)
 	} else {
-		_ = v.audit.LogSuccess(audit.OpVaultUnlock, audit.SourceCLI, "")
+		_ = v.audit.LogSuccess(audit.OpVaultUnlock, v.auditSource(), "")
 	}
+	v.upgradeKDF(passwordBytes)
+	timer.mark("audit")
 
 	// Check file permissions and warn if insecure (per requirements-ja.md §4.1)
 	// This is a warning only, not blocking - user may have intentional reasons
 	v.checkAndWarnPermissions()
+	v.warnPendingRewrites()
+	v.warnEmergencyRequests()
+	timer.mark("finish")
+	v.unlockTimings = timer.timings()
+	if prep.settings != nil && prep.settingsInfo != nil {
+		v.settings.Store(newSettingsCache(prep.settings, prep.settingsInfo))
+	}
 
+	v.Touch()
+	v.lockSignal.notify()
+	v.emit(EventUnlocked, "", "")
 	return nil
 }
 
@@ -587,42 +791,85 @@ func (v *Vault) Unlock(masterPassword string) error {
 func (v *Vault) Lock() {
 	v.mu.Lock()
 	defer v.mu.Unlock()
+	v.lock("")
+}
+
+// lock is Lock, publishing message with EventLocked. Caller must hold v.mu.
+func (v *Vault) lock(message string) {
+	// Write counted reads while the DEK can still hash their keys
+	v.flushAccessLocked()
 
 	// Log lock operation before clearing DEK
 	if v.dek != nil {
-		_ = v.audit.LogSuccess(audit.OpVaultLock, audit.SourceCLI, "")
+		_ = v.audit.LogSuccess(audit.OpVaultLock, v.auditSource(), "")
+		defer v.emit(EventLocked, "", message)
 	}
 
 	// Overwrite DEK with zeros for secure destruction
-	// Use SecureWipe to prevent compiler optimization from removing the operation
-	if v.dek != nil {
-		crypto.SecureWipe(v.dek)
-		v.dek = nil
-	}
+	v.clearDEK()
+	v.settings.Store(nil)
+	v.lockSignal.notify()
 
 	// Clear audit logger HMAC key to minimize sensitive material lifetime
 	v.audit.ClearHMACKey()
 
+	// Drop cached dynamic secret values
+	v.dynamic.clear()
+
 	// Close database connection
 	if v.db != nil {
 		v.db.Close()
 		v.db = nil
 	}
+	v.shards = nil
+	if v.snapshot != nil {
+		os.RemoveAll(v.snapshot.dir)
+		v.snapshot = nil
+	}
+}
+
+// setDEK copies dek into locked memory and makes it the vault's DEK.
+// Where memory cannot be locked the DEK is kept anyway, with a warning
+// once per vault. Caller must hold v.mu.
+func (v *Vault) setDEK(dek []byte) {
+	v.clearDEK()
+	buf, err := crypto.NewLockedBufferFrom(dek)
+	if err != nil && !v.memlockWarned {
+		v.memlockWarned = true
+		v.warn(EventWarning, "the encryption key could not be locked in memory and may be swapped to disk: %v", err)
+	}
+	v.dekBuf = buf
+	v.dek = buf.Bytes()
+}
+
+// clearDEK wipes and releases the DEK. Caller must hold v.mu.
+func (v *Vault) clearDEK() {
+	v.dekBuf.Destroy()
+	v.dekBuf = nil
+	v.dek = nil
 }
 
-// ChangePassword changes the master password by re-wrapping the DEK.
-// The DEK itself remains unchanged, so all secrets remain accessible.
+// ChangePassword is ChangeMasterPassword.
+func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
+	return v.ChangeMasterPassword(currentPassword, newPassword)
+}
+
+// ChangeMasterPassword changes the master password by re-wrapping the DEK
+// with a KEK derived from the new password and a fresh salt. The DEK itself
+// remains unchanged, so all secrets remain accessible.
 //
 // Process (ADR-003):
-//  1. Validate inputs, reject same password
-//  2. Create backup (VACUUM INTO, 0600)
+//  1. Validate inputs, reject same password, check cooldown
+//  2. Create backup (VACUUM INTO a temporary file, 0600, renamed into place)
 //  3. Begin transaction (mutex protects in-process, SQLite file-lock for cross-process)
 //  4. Verify current password, unwrap DEK
 //  5. Generate new salt/nonce, derive new KEK
 //  6. Re-wrap DEK with new KEK
 //  7. Verify in-memory before commit
 //  8. UPDATE vault_keys, COMMIT
-//  9. Record audit log, SecureWipe key material
+//  9. Reset cooldown state, record audit log, SecureWipe key material
+//
+// A wrong current password counts as a failed unlock attempt.
 //
 // Concurrency: v.mu.Lock() serializes in-process calls; SQLite's file locking
 // prevents concurrent writes from multiple processes.
@@ -630,7 +877,8 @@ func (v *Vault) Lock() {
 // Crash safety: SQLite atomic commit ensures all-or-nothing.
 // Before COMMIT → auto-rollback → old password works.
 // After COMMIT → change complete → new password works.
-func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
+// A backup left incomplete by a crash is removed on the next unlock.
+func (v *Vault) ChangeMasterPassword(currentPassword, newPassword string) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
@@ -639,6 +887,15 @@ func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	if remaining, err := v.checkCooldown(); err != nil {
+		if errors.Is(err, ErrCooldownActive) {
+			return fmt.Errorf("%w: please wait %v", ErrCooldownActive, remaining.Round(time.Second))
+		}
+		return err
+	}
 
 	// Convert passwords to []byte early (avoid string copies)
 	currentPasswordBytes := []byte(currentPassword)
@@ -663,18 +920,24 @@ func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
 		}
 	}
 
-	// Step 2: Create backup (optional, for user safety)
+	// Step 2: Create backup (optional, for user safety). It is written
+	// under a temporary name so that a crash never leaves a partial copy
+	// that looks like a complete backup.
 	backupPath := filepath.Join(v.path, fmt.Sprintf("%s.backup-%d", DBFileName, time.Now().Unix()))
-	// Escape single quotes in path for SQL safety
-	escapedPath := strings.ReplaceAll(backupPath, "'", "''")
-	_, err := v.db.Exec(fmt.Sprintf("VACUUM INTO '%s'", escapedPath))
+	tmpPath := backupPath + passwordBackupTmpSuffix
+	_, err := v.db.Exec("VACUUM INTO ?", tmpPath)
 	if err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("vault: failed to create backup: %w", err)
 	}
 	// Set secure permissions on backup
-	if err := os.Chmod(backupPath, FileMode); err != nil {
+	if err := os.Chmod(tmpPath, FileMode); err != nil {
 		// Non-fatal, but warn
-		fmt.Fprintf(os.Stderr, "warning: failed to set backup permissions: %v\n", err)
+		v.warn(EventWarning, "failed to set backup permissions: %v", err)
+	}
+	if err := os.Rename(tmpPath, backupPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("vault: failed to create backup: %w", err)
 	}
 
 	// Step 3: Begin transaction
@@ -697,11 +960,19 @@ func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
 	}
 
 	// Derive old KEK and verify by unwrapping DEK
-	kekOld := crypto.DeriveKey(currentPasswordBytes, currentSalt)
+	params, err := v.kdfParams()
+	if err != nil {
+		return err
+	}
+	kekOld := crypto.DeriveKeyWithParams(currentPasswordBytes, currentSalt, params)
 	defer crypto.SecureWipe(kekOld)
 
 	dekCopy, err := crypto.Decrypt(kekOld, encryptedDEK, dekNonce)
 	if err != nil {
+		if _, recordErr := v.recordFailedAttempt(); recordErr != nil {
+			v.warn(EventWarning, "failed to record unlock attempt: %v", recordErr)
+		}
+		_ = v.audit.LogError(audit.OpPasswordChanged, v.auditSource(), "", "AUTH_FAILED", "invalid current password")
 		return ErrInvalidPassword
 	}
 	defer crypto.SecureWipe(dekCopy)
@@ -713,7 +984,7 @@ func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
 	}
 
 	// Derive new KEK
-	kekNew := crypto.DeriveKey(newPasswordBytes, newSalt)
+	kekNew := crypto.DeriveKeyWithParams(newPasswordBytes, newSalt, params)
 	defer crypto.SecureWipe(kekNew)
 
 	// Step 6: Re-wrap DEK with new KEK
@@ -741,8 +1012,12 @@ func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
 	}
 
 	// Step 9: Post-commit actions
+	// Failed attempts against the old password no longer matter
+	if err := v.clearLockState(); err != nil {
+		v.warn(EventWarning, "failed to clear lock state: %v", err)
+	}
 	// Record audit log (file-based, best-effort)
-	_ = v.audit.LogSuccess(audit.OpPasswordChanged, audit.SourceCLI, "")
+	_ = v.audit.LogSuccess(audit.OpPasswordChanged, v.auditSource(), "")
 
 	// Delete backup file (optional - keep for safety during initial rollout)
 	// TODO: Consider making this configurable in future versions
@@ -751,6 +1026,28 @@ func (v *Vault) ChangePassword(currentPassword, newPassword string) error {
 	return nil
 }
 
+// passwordBackupTmpSuffix marks a backup that ChangeMasterPassword has not
+// finished writing.
+const passwordBackupTmpSuffix = ".tmp"
+
+// removeIncompleteBackups deletes backups left unfinished by a password
+// change that was interrupted. The change itself was rolled back by SQLite,
+// so the old password still applies. Recently written files may belong to
+// a change still running in another process and are left alone.
+func (v *Vault) removeIncompleteBackups() {
+	matches, _ := filepath.Glob(filepath.Join(v.path, DBFileName+".backup-*"+passwordBackupTmpSuffix))
+	for _, path := range matches {
+		if info, err := os.Stat(path); err != nil || time.Since(info.ModTime()) < time.Minute {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			v.warn(EventWarning, "failed to remove incomplete backup %s: %v", filepath.Base(path), err)
+			continue
+		}
+		v.warn(EventWarning, "removed %s left by an interrupted password change; the previous master password is still in effect", filepath.Base(path))
+	}
+}
+
 // Audit returns the vault's audit logger for MCP and other external use.
 func (v *Vault) Audit() *audit.Logger {
 	return v.audit
@@ -768,6 +1065,17 @@ func (v *Vault) Path() string {
 	return v.path
 }
 
+// ID returns the vault's ID from vault.meta, or "" for a vault not yet
+// unlocked by a version that records it. The vault does not need to be
+// unlocked.
+func (v *Vault) ID() (string, error) {
+	meta, err := v.readMeta()
+	if err != nil {
+		return "", err
+	}
+	return meta.ID, nil
+}
+
 // exists checks if the vault exists
 func (v *Vault) exists() bool {
 	saltPath := filepath.Join(v.path, SaltFileName)
@@ -777,27 +1085,66 @@ func (v *Vault) exists() bool {
 
 // checkAndWarnPermissions checks file permissions and prints warnings if insecure.
 // Per requirements-ja.md §4.1: "Warn if permissions are not 0600"
-// This is advisory only and does not block operations.
+// This is advisory only and does not block operations. With
+// VaultSettings.AutoFixPermissions, insecure files owned by the current
+// user are repaired instead of warned about.
 func (v *Vault) checkAndWarnPermissions() {
+	autoFix := false
+	if settings, err := v.Settings(); err == nil {
+		autoFix = settings.AutoFixPermissions
+	}
+
 	// Check vault directory (should be 0700)
 	if info, err := os.Stat(v.path); err == nil {
 		if perm := info.Mode().Perm(); perm&0077 != 0 {
-			fmt.Fprintf(os.Stderr, "warning: vault directory has insecure permissions %04o (expected 0700)\n", perm)
+			if !autoFix || !v.fixPermissions(v.path, "vault directory", perm, DirMode) {
+				v.warn(EventWarning, "vault directory has insecure permissions %04o (expected 0700)", perm)
+			}
 		}
 	}
 
 	// Check critical files (should be 0600)
 	files := []string{SaltFileName, MetaFileName, DBFileName}
+	for _, ns := range v.shardFiles() {
+		files = append(files, filepath.Join(ShardsDirName, ns+".db"))
+	}
 	for _, fname := range files {
 		fpath := filepath.Join(v.path, fname)
 		if info, err := os.Stat(fpath); err == nil {
 			if perm := info.Mode().Perm(); perm&0077 != 0 {
-				fmt.Fprintf(os.Stderr, "warning: %s has insecure permissions %04o (expected 0600)\n", fname, perm)
+				if !autoFix || !v.fixPermissions(fpath, fname, perm, FileMode) {
+					v.warn(EventWarning, "%s has insecure permissions %04o (expected 0600)", fname, perm)
+				}
 			}
 		}
 	}
 }
 
+// fixPermissions restores mode on path, named name in messages, if it is
+// not a symlink and is owned by the current user, and audits the repair.
+// It reports whether the permissions were repaired.
+func (v *Vault) fixPermissions(path, name string, perm, mode os.FileMode) bool {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return false
+	}
+	if !ownedByCurrentUser(info) {
+		v.warn(EventWarning, "%s is not owned by the current user; not repairing its permissions", name)
+		return false
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		v.warn(EventWarning, "failed to repair permissions of %s: %v", name, err)
+		return false
+	}
+	_ = v.audit.Log(audit.OpVaultPermissions, v.auditSource(), audit.ResultSuccess, "", nil, map[string]interface{}{
+		"path":     name,
+		"old_mode": fmt.Sprintf("%04o", perm),
+		"new_mode": fmt.Sprintf("%04o", mode),
+	})
+	slog.Info("repaired insecure permissions", "path", name, "old_mode", fmt.Sprintf("%04o", perm), "new_mode", fmt.Sprintf("%04o", mode))
+	return true
+}
+
 // createTables creates the required SQLite tables
 func (v *Vault) createTables(db *sql.DB) error {
 	// Enable foreign keys (required for ON DELETE RESTRICT per ADR-007)
@@ -876,7 +1223,12 @@ func (v *Vault) createTables(db *sql.DB) error {
 	// - schema: plaintext schema name (reserved for Phase 3)
 	// - field_count: plaintext field count for MCP secret_list (Phase 2.5+)
 	// - folder_id: reference to folder (Phase 2c-X2, NULL = unfiled)
+	// - encrypted_redirect: encrypted deprecation JSON (NULL = not deprecated)
 	// - tags, expires_at: plaintext for searchability
+	// - not_before, access_window: plaintext access rules (window as JSON)
+	// - immutable: plaintext write-once flag (1 = break-glass required to change)
+	// - cert_not_after: plaintext certificate expiry; encrypted_certificate: subject, SANs etc.
+	// - deleted_at: when the secret was moved to the trash (NULL = live)
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS secrets (
 			id INTEGER PRIMARY KEY,
@@ -889,8 +1241,15 @@ func (v *Vault) createTables(db *sql.DB) error {
 			schema TEXT,
 			field_count INTEGER DEFAULT 1,
 			folder_id TEXT REFERENCES folders(id) ON DELETE RESTRICT,
+			encrypted_redirect BLOB,
 			tags TEXT,
 			expires_at TIMESTAMP,
+			not_before TIMESTAMP,
+			access_window TEXT,
+			immutable INTEGER NOT NULL DEFAULT 0,
+			cert_not_after TIMESTAMP,
+			encrypted_certificate BLOB,
+			deleted_at TIMESTAMP,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
@@ -905,6 +1264,66 @@ func (v *Vault) createTables(db *sql.DB) error {
 		return err
 	}
 
+	// secret_history table (change summaries, no secret values)
+	_, err = db.Exec(secretHistoryTableSQL)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(secretHistoryIndexSQL)
+	if err != nil {
+		return err
+	}
+
+	// canaries table (decoy secrets that raise an alert when read)
+	_, err = db.Exec(canaryTableSQL)
+	if err != nil {
+		return err
+	}
+
+	// attachments table (encrypted files attached to secrets)
+	_, err = db.Exec(attachmentsTableSQL)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(attachmentsIndexSQL)
+	if err != nil {
+		return err
+	}
+
+	// rewrite_jobs table (position of interrupted vault-wide rewrites)
+	_, err = db.Exec(rewriteJobsTableSQL)
+	if err != nil {
+		return err
+	}
+
+	// secret_access table (when secret values were last read)
+	_, err = db.Exec(secretAccessTableSQL)
+	if err != nil {
+		return err
+	}
+
+	// search_index table (encrypted index of notes, URLs and non-sensitive fields)
+	_, err = db.Exec(searchIndexTableSQL)
+	if err != nil {
+		return err
+	}
+
+	// secret_paths table (directories of keys, for prefix listings)
+	_, err = db.Exec(secretPathsTableSQL)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(secretPathsIndexSQL)
+	if err != nil {
+		return err
+	}
+
+	// retired_audit_keys table (audit keys from before a DEK rotation)
+	_, err = db.Exec(retiredAuditKeysTableSQL)
+	if err != nil {
+		return err
+	}
+
 	// schema_version table for migration tracking
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS schema_version (
@@ -929,6 +1348,7 @@ func (v *Vault) createTables(db *sql.DB) error {
 // Uses DEK as the HMAC key to prevent offline brute-force attacks on key names.
 // An attacker with database access cannot dictionary-attack key names without the DEK.
 func (v *Vault) hashKey(key string) string {
+	v.Touch()
 	mac := hmac.New(sha256.New, v.dek)
 	mac.Write([]byte(key))
 	return hex.EncodeToString(mac.Sum(nil))
@@ -937,21 +1357,35 @@ func (v *Vault) hashKey(key string) string {
 // encryptWithNonce encrypts data and prepends the nonce to the ciphertext.
 // This simplifies storage by combining nonce and ciphertext into a single blob.
 func (v *Vault) encryptWithNonce(plaintext []byte) ([]byte, error) {
-	ciphertext, nonce, err := crypto.Encrypt(v.dek, plaintext)
+	v.Touch()
+	return encryptBlob(v.cipher, v.dek, plaintext)
+}
+
+// decryptWithNonce decrypts data where the nonce is prepended to the ciphertext.
+func (v *Vault) decryptWithNonce(blob []byte) ([]byte, error) {
+	v.Touch()
+	return decryptBlob(v.cipher, v.dek, blob)
+}
+
+// encryptBlob is encryptWithNonce with an explicit cipher suite and key.
+func encryptBlob(suite string, key, plaintext []byte) ([]byte, error) {
+	ciphertext, nonce, err := crypto.EncryptWith(suite, key, plaintext)
 	if err != nil {
 		return nil, err
 	}
 	return append(nonce, ciphertext...), nil
 }
 
-// decryptWithNonce decrypts data where the nonce is prepended to the ciphertext.
-func (v *Vault) decryptWithNonce(blob []byte) ([]byte, error) {
-	if len(blob) < crypto.NonceLength {
+// decryptBlob is decryptWithNonce with an explicit cipher suite and key.
+func decryptBlob(suite string, key, blob []byte) ([]byte, error) {
+	size, err := crypto.NonceSize(suite)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < size {
 		return nil, fmt.Errorf("vault: invalid encrypted data: too short")
 	}
-	nonce := blob[:crypto.NonceLength]
-	ciphertext := blob[crypto.NonceLength:]
-	return crypto.Decrypt(v.dek, ciphertext, nonce)
+	return crypto.DecryptWith(suite, key, blob[size:], blob[:size])
 }
 
 // validateKeyName validates a secret key name per requirements-ja.md §2.1
@@ -966,7 +1400,7 @@ func validateKeyName(key string) error {
 	// Check for valid characters: alphanumeric, dash, underscore, dot, slash
 	for _, r := range key {
 		if !isValidKeyChar(r) {
-			return fmt.Errorf("%w: '%c' is not allowed", ErrKeyInvalid, r)
+			return fmt.Errorf("%w: %q is not allowed", ErrKeyInvalid, r)
 		}
 	}
 
@@ -1005,6 +1439,10 @@ func isValidKeyChar(r rune) bool {
 // tagRegex validates tag format: alphanumeric, underscore, hyphen only
 var tagRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
+// ownerRegex validates owner and team names: user names, email addresses
+// and team slugs
+var ownerRegex = regexp.MustCompile(`^[a-zA-Z0-9._@+-]+$`)
+
 // validateMetadata validates metadata fields per requirements-ja.md §2.5
 func validateMetadata(metadata *SecretMetadata, tags []string, expiresAt *time.Time) error {
 	// Validate metadata fields if present
@@ -1034,6 +1472,16 @@ func validateMetadata(metadata *SecretMetadata, tags []string, expiresAt *time.T
 				return fmt.Errorf("%w: URL must have a host", ErrURLInvalid)
 			}
 		}
+
+		for _, name := range []string{metadata.Owner, metadata.Team} {
+			if name == "" {
+				continue
+			}
+			if len(name) > MaxOwnerLength || !ownerRegex.MatchString(name) {
+				return fmt.Errorf("%w: '%s' must be at most %d letters, digits or . _ @ + -",
+					ErrOwnerInvalid, name, MaxOwnerLength)
+			}
+		}
 	}
 
 	// Validate tags: maximum 10 tags, each 1-64 characters, pattern [a-zA-Z0-9_-]
@@ -1068,7 +1516,86 @@ func validateMetadata(metadata *SecretMetadata, tags []string, expiresAt *time.T
 //   - If entry.Fields is nil but entry.Value is set, Value is converted to Fields["value"]
 //   - Both legacy format (encrypted_value) and new format (encrypted_fields) are stored
 //     for backward compatibility during transition period
+//
+// Immutable secrets cannot be overwritten (ErrSecretImmutable); see SetSecretBreakGlass.
 func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
+	return v.setSecret(key, entry, writeOptions{})
+}
+
+// SetSecrets writes entries in a single transaction: either all secrets
+// are stored or none are. Each entry is validated as in SetSecret, and the
+// first invalid one is returned as a *KeyError. The batch is recorded as one secret.set_batch audit event instead of one
+// event per secret.
+func (v *Vault) SetSecrets(entries map[string]*SecretEntry) error {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return v.run(Op{Name: OpSetBatch, Keys: keys}, func() error {
+		return v.setSecrets(entries)
+	})
+}
+
+func (v *Vault) setSecrets(entries map[string]*SecretEntry) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.dek == nil {
+		return ErrVaultLocked
+	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(entries))
+	dataSize := 0
+	for key, entry := range entries {
+		keys = append(keys, key)
+		dataSize += entryDataSize(key, entry)
+	}
+	sort.Strings(keys)
+
+	if err := v.checkDiskSpaceForWrite(dataSize); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSetBatch, v.auditSource(), "", "DISK_FULL", err.Error())
+		return err
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, key := range keys {
+		if _, err := v.setSecretTx(tx, key, entries[key], writeOptions{}); err != nil {
+			return &KeyError{Key: key, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	_ = v.audit.Log(audit.OpSecretSetBatch, v.auditSource(), audit.ResultSuccess, "", nil,
+		map[string]interface{}{"count": len(keys)})
+	for _, key := range keys {
+		v.emit(EventSecretChanged, key, "")
+	}
+	v.updateSearchIndex()
+	return nil
+}
+
+func (v *Vault) setSecret(key string, entry *SecretEntry, opts writeOptions) error {
+	return v.run(Op{Name: OpSet, Key: key}, func() error {
+		return v.setSecretUnwrapped(key, entry, opts)
+	})
+}
+
+func (v *Vault) setSecretUnwrapped(key string, entry *SecretEntry, opts writeOptions) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
@@ -1076,11 +1603,53 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := v.checkDiskSpaceForWrite(entryDataSize(key, entry)); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DISK_FULL", err.Error())
+		return err
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	metadata, err := v.setSecretTx(tx, key, entry, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	}
+
+	// Log successful operation; owner and team let audit exports show who
+	// is responsible for the secret
+	if ctx := ownerContext(metadata); ctx != nil {
+		_ = v.audit.Log(audit.OpSecretSet, v.auditSource(), audit.ResultSuccess, key, nil, ctx)
+	} else {
+		_ = v.audit.LogSuccess(audit.OpSecretSet, v.auditSource(), key)
+	}
+	if opts.breakGlass {
+		v.logBreakGlass(key, "update", opts.reason)
+	}
+	v.emit(EventSecretChanged, key, "")
+	v.updateSearchIndex()
+
+	return nil
+}
 
+// setSecretTx validates, encrypts and stores one secret inside tx. It
+// returns the stored metadata for the audit record.
+func (v *Vault) setSecretTx(tx *sql.Tx, key string, entry *SecretEntry, opts writeOptions) (*SecretMetadata, error) {
 	// Validate key name
 	if err := validateKeyName(key); err != nil {
-		_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "INVALID_KEY", err.Error())
-		return err
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_KEY", err.Error())
+		return nil, err
 	}
 
 	// Normalize Fields: if Fields is nil but Value is set, convert to Fields["value"]
@@ -1092,48 +1661,48 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	// Validate fields (multi-field format)
 	if fields != nil {
 		if err := ValidateFields(fields); err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "INVALID_FIELDS", err.Error())
-			return err
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_FIELDS", err.Error())
+			return nil, err
+		}
+		if err := validateRefs(key, fields); err != nil {
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_REF", err.Error())
+			return nil, err
 		}
 	}
 
 	// Validate bindings if present
 	if entry.Bindings != nil {
 		if len(fields) == 0 {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "INVALID_BINDINGS", "bindings require fields")
-			return fmt.Errorf("%w: bindings require fields", ErrBindingFieldNotFound)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_BINDINGS", "bindings require fields")
+			return nil, fmt.Errorf("%w: bindings require fields", ErrBindingFieldNotFound)
 		}
 		if err := ValidateBindings(entry.Bindings, fields); err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "INVALID_BINDINGS", err.Error())
-			return err
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_BINDINGS", err.Error())
+			return nil, err
 		}
 	}
 
-	// Calculate total data size for disk space check
-	dataSize := len(key)
-	for name, field := range fields {
-		dataSize += len(name) + len(field.Value) + len(field.Kind) + len(field.InputType) + len(field.Hint)
-		for _, alias := range field.Aliases {
-			dataSize += len(alias)
-		}
-	}
-	for envVar, fieldName := range entry.Bindings {
-		dataSize += len(envVar) + len(fieldName)
-	}
-	if entry.Metadata != nil {
-		dataSize += len(entry.Metadata.Notes) + len(entry.Metadata.URL)
+	// Apply the expiry rule for key; entry itself is left as given
+	entryExpiresAt, err := v.applyExpiryRule(key, entry.ExpiresAt, time.Now())
+	if err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "EXPIRATION_REQUIRED", err.Error())
+		return nil, err
 	}
 
 	// Validate metadata per requirements-ja.md §2.5
-	if err := validateMetadata(entry.Metadata, entry.Tags, entry.ExpiresAt); err != nil {
-		_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "INVALID_METADATA", err.Error())
-		return err
+	if err := validateMetadata(entry.Metadata, entry.Tags, entryExpiresAt); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_METADATA", err.Error())
+		return nil, err
+	}
+	if err := validateAccessRules(entry); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_METADATA", err.Error())
+		return nil, err
 	}
 
-	// Check disk space before write
-	if err := v.checkDiskSpaceForWrite(dataSize); err != nil {
-		_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "DISK_FULL", err.Error())
-		return err
+	cert, err := extractCertificate(fields)
+	if err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "INVALID_CERTIFICATE", err.Error())
+		return nil, err
 	}
 
 	// Compute key hash for lookup (HMAC-SHA256 with DEK)
@@ -1142,8 +1711,8 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	// Encrypt key name (nonce prepended)
 	encryptedKey, err := v.encryptWithNonce([]byte(key))
 	if err != nil {
-		_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "ENCRYPT_FAILED", err.Error())
-		return fmt.Errorf("vault: failed to encrypt key: %w", err)
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "ENCRYPT_FAILED", err.Error())
+		return nil, fmt.Errorf("vault: failed to encrypt key: %w", err)
 	}
 
 	// Encrypt legacy value for backward compatibility
@@ -1152,8 +1721,8 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	if defaultValue := GetDefaultFieldValue(fields); defaultValue != "" {
 		encryptedValue, err = v.encryptWithNonce([]byte(defaultValue))
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "ENCRYPT_FAILED", err.Error())
-			return fmt.Errorf("vault: failed to encrypt value: %w", err)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "ENCRYPT_FAILED", err.Error())
+			return nil, fmt.Errorf("vault: failed to encrypt value: %w", err)
 		}
 	}
 
@@ -1162,13 +1731,13 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	if len(fields) > 0 {
 		fieldsJSON, err := json.Marshal(fields)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "MARSHAL_FAILED", err.Error())
-			return fmt.Errorf("vault: failed to marshal fields: %w", err)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "MARSHAL_FAILED", err.Error())
+			return nil, fmt.Errorf("vault: failed to marshal fields: %w", err)
 		}
 		encryptedFields, err = v.encryptWithNonce(fieldsJSON)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "ENCRYPT_FAILED", "fields: "+err.Error())
-			return fmt.Errorf("vault: failed to encrypt fields: %w", err)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "ENCRYPT_FAILED", "fields: "+err.Error())
+			return nil, fmt.Errorf("vault: failed to encrypt fields: %w", err)
 		}
 	}
 
@@ -1177,29 +1746,31 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	if len(entry.Bindings) > 0 {
 		bindingsJSON, err := json.Marshal(entry.Bindings)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "MARSHAL_FAILED", err.Error())
-			return fmt.Errorf("vault: failed to marshal bindings: %w", err)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "MARSHAL_FAILED", err.Error())
+			return nil, fmt.Errorf("vault: failed to marshal bindings: %w", err)
 		}
 		encryptedBindings, err = v.encryptWithNonce(bindingsJSON)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "ENCRYPT_FAILED", "bindings: "+err.Error())
-			return fmt.Errorf("vault: failed to encrypt bindings: %w", err)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "ENCRYPT_FAILED", "bindings: "+err.Error())
+			return nil, fmt.Errorf("vault: failed to encrypt bindings: %w", err)
 		}
 	}
 
-	// Encrypt metadata as JSON blob (nonce prepended)
-	var encryptedMetadata []byte
-	if entry.Metadata != nil && (entry.Metadata.Notes != "" || entry.Metadata.URL != "") {
-		metadataJSON, err := json.Marshal(entry.Metadata)
+	// Encrypt certificate details; only notAfter stays plaintext
+	var certNotAfter sql.NullTime
+	var encryptedCertificate []byte
+	if cert != nil {
+		certJSON, err := json.Marshal(cert)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "MARSHAL_FAILED", err.Error())
-			return fmt.Errorf("vault: failed to marshal metadata: %w", err)
+			return nil, fmt.Errorf("vault: failed to marshal certificate: %w", err)
 		}
-		encryptedMetadata, err = v.encryptWithNonce(metadataJSON)
+		encryptedCertificate, err = v.encryptWithNonce(certJSON)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "ENCRYPT_FAILED", "metadata: "+err.Error())
-			return fmt.Errorf("vault: failed to encrypt metadata: %w", err)
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "ENCRYPT_FAILED", "certificate: "+err.Error())
+			return nil, fmt.Errorf("vault: failed to encrypt certificate: %w", err)
 		}
+		// Local like expires_at and query deadlines, as SQLite compares them as text
+		certNotAfter = sql.NullTime{Time: cert.NotAfter.Local(), Valid: true}
 	}
 
 	// Prepare plaintext fields for search
@@ -1212,8 +1783,22 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 	}
 
 	var expiresAt sql.NullTime
-	if entry.ExpiresAt != nil {
-		expiresAt = sql.NullTime{Time: *entry.ExpiresAt, Valid: true}
+	if entryExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *entryExpiresAt, Valid: true}
+	}
+
+	var notBefore sql.NullTime
+	if entry.NotBefore != nil {
+		notBefore = sql.NullTime{Time: *entry.NotBefore, Valid: true}
+	}
+
+	var accessWindow sql.NullString
+	if entry.AccessWindow != nil {
+		windowJSON, err := json.Marshal(entry.AccessWindow)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to marshal access window: %w", err)
+		}
+		accessWindow = sql.NullString{String: string(windowJSON), Valid: true}
 	}
 
 	// Calculate field count for MCP secret_list (plaintext, not sensitive)
@@ -1224,19 +1809,83 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 		fieldCount = 0
 	}
 
-	// Begin transaction
-	tx, err := v.db.Begin()
+	// Sharded namespaces are stored in their own database file
+	table, err := v.secretTable(key)
 	if err != nil {
-		return fmt.Errorf("vault: failed to begin transaction: %w", err)
+		return nil, err
+	}
+	if err := v.relocateSecretTx(tx, keyHash, table); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, err
+	}
+	if entry.FolderID != nil && strings.HasPrefix(table, `"shard_`) {
+		// Shards cannot reference the folders table, so check it here
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM folders WHERE id = ?", *entry.FolderID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("vault: failed to check folder: %w", err)
+		}
+		if exists == 0 {
+			return nil, ErrFolderNotFound
+		}
+	}
+
+	// A new secret replaces a deleted one with the same key
+	if _, err := v.purgeSecretsTx(tx, "key_hash = ? AND deleted_at IS NOT NULL", keyHash); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, err
+	}
+
+	if err := v.checkMutableTx(tx, keyHash, key, audit.OpSecretSet, opts); err != nil {
+		return nil, err
+	}
+	if opts.checkRevision {
+		if err := v.checkRevisionTx(tx, keyHash, key, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Record a change summary (no values) for `secretctl history`
+	prevFields, err := v.loadFieldsTx(tx, keyHash)
+	if err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, fmt.Errorf("vault: failed to read previous version: %w", err)
+	}
+	nextFields := fields
+	if nextFields == nil {
+		nextFields = map[string]Field{}
+	}
+	if err := v.recordHistoryTx(tx, keyHash, summarizeChange(prevFields, nextFields)); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, fmt.Errorf("vault: failed to record history: %w", err)
+	}
+
+	// Encrypt metadata as JSON blob (nonce prepended). The usage record is
+	// kept from the stored version; callers cannot set it.
+	metadata := &SecretMetadata{}
+	if entry.Metadata != nil {
+		metadata.Notes, metadata.URL = entry.Metadata.Notes, entry.Metadata.URL
+		metadata.Owner, metadata.Team = entry.Metadata.Owner, entry.Metadata.Team
+	}
+	prevMetadata, err := v.loadMetadataTx(tx, keyHash)
+	if err != nil && !errors.Is(err, ErrSecretNotFound) {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, err
+	}
+	if prevMetadata != nil {
+		metadata.UsedBy = prevMetadata.UsedBy
+	}
+	encryptedMetadata, err := v.encryptMetadata(metadata)
+	if err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "ENCRYPT_FAILED", "metadata: "+err.Error())
+		return nil, err
 	}
-	defer tx.Rollback()
 
 	// UPSERT: update if key exists, insert otherwise
 	// Store both legacy format (encrypted_value) and new format (encrypted_fields)
 	// Per ADR-007: folder_id is stored as plaintext reference to folders table
 	_, err = tx.Exec(`
-		INSERT INTO secrets (key_hash, encrypted_key, encrypted_value, encrypted_fields, encrypted_bindings, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		INSERT INTO `+table+` (key_hash, encrypted_key, encrypted_value, encrypted_fields, encrypted_bindings, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, not_before, access_window, immutable, cert_not_after, encrypted_certificate, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT(key_hash) DO UPDATE SET
 			encrypted_key = excluded.encrypted_key,
 			encrypted_value = excluded.encrypted_value,
@@ -1248,21 +1897,53 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 			folder_id = excluded.folder_id,
 			tags = excluded.tags,
 			expires_at = excluded.expires_at,
+			not_before = excluded.not_before,
+			access_window = excluded.access_window,
+			immutable = excluded.immutable,
+			cert_not_after = excluded.cert_not_after,
+			encrypted_certificate = excluded.encrypted_certificate,
 			updated_at = CURRENT_TIMESTAMP
-	`, keyHash, encryptedKey, encryptedValue, encryptedFields, encryptedBindings, encryptedMetadata, entry.Schema, fieldCount, entry.FolderID, tagsStr, expiresAt)
+	`, keyHash, encryptedKey, encryptedValue, encryptedFields, encryptedBindings, encryptedMetadata, entry.Schema, fieldCount, entry.FolderID, tagsStr, expiresAt, notBefore, accessWindow, entry.Immutable, certNotAfter, encryptedCertificate)
 	if err != nil {
-		_ = v.audit.LogError(audit.OpSecretSet, audit.SourceCLI, key, "DB_ERROR", err.Error())
-		return fmt.Errorf("vault: failed to save secret: %w", err)
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, fmt.Errorf("vault: failed to save secret: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("vault: failed to commit transaction: %w", err)
+	if err := v.indexPathsTx(tx, keyHash, key); err != nil {
+		_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+		return nil, err
 	}
 
-	// Log successful operation
-	_ = v.audit.LogSuccess(audit.OpSecretSet, audit.SourceCLI, key)
+	if opts.canary {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO canaries (key_hash) VALUES (?)", keyHash); err != nil {
+			_ = v.audit.LogError(audit.OpSecretSet, v.auditSource(), key, "DB_ERROR", err.Error())
+			return nil, fmt.Errorf("vault: failed to mark canary: %w", err)
+		}
+	}
 
-	return nil
+	return metadata, nil
+}
+
+// entryDataSize estimates the bytes written for entry, for the disk space check.
+func entryDataSize(key string, entry *SecretEntry) int {
+	fields := entry.Fields
+	if fields == nil && len(entry.Value) > 0 {
+		fields = ConvertSingleValueToFields(entry.Value)
+	}
+	dataSize := len(key)
+	for name, field := range fields {
+		dataSize += len(name) + len(field.Value) + len(field.Kind) + len(field.InputType) + len(field.Hint) + len(field.Validator)
+		for _, alias := range field.Aliases {
+			dataSize += len(alias)
+		}
+	}
+	for envVar, fieldName := range entry.Bindings {
+		dataSize += len(envVar) + len(fieldName)
+	}
+	if entry.Metadata != nil {
+		dataSize += len(entry.Metadata.Notes) + len(entry.Metadata.URL)
+	}
+	return dataSize
 }
 
 // GetSecret retrieves a complete secret entry by key name
@@ -1272,7 +1953,65 @@ func (v *Vault) SetSecret(key string, entry *SecretEntry) error {
 // - Falls back to encrypted_value if encrypted_fields is NULL (legacy format)
 // - Legacy data is auto-converted to Fields["value"]
 // - Value field is populated for backward compatibility
+//
+// If the block_expired_reads setting is enabled, expired secrets return
+// ErrSecretExpired. Use GetSecretAllowExpired for an explicit override.
+//
+// Deprecated keys return a *DeprecatedKeyError, or resolve to their
+// replacement when the deprecated_reads setting is "redirect".
 func (v *Vault) GetSecret(key string) (*SecretEntry, error) {
+	return v.readSecret(key, readOptions{})
+}
+
+// GetSecretAllowExpired retrieves a secret like GetSecret but ignores the
+// block_expired_reads setting. Use only for explicit user overrides
+// (--allow-expired).
+func (v *Vault) GetSecretAllowExpired(key string) (*SecretEntry, error) {
+	return v.readSecret(key, readOptions{allowExpired: true})
+}
+
+// InspectSecret retrieves a secret without enforcing access rules
+// (expiry blocking, deprecation). Use it for metadata views and local
+// analysis such as security scoring, never to hand values to a consumer.
+func (v *Vault) InspectSecret(key string) (*SecretEntry, error) {
+	return runValue(v, Op{Name: OpGet, Key: key}, func() (*SecretEntry, error) {
+		return v.getSecret(key, readOptions{inspect: true})
+	})
+}
+
+// GetSecrets retrieves several secrets with one database query. The
+// result maps each requested key to its entry. Access rules are enforced
+// as in GetSecret, and the first key that cannot be read fails the whole
+// call with a *KeyError. The read is recorded as one secret.get_batch
+// audit event instead of one event per secret.
+func (v *Vault) GetSecrets(keys []string) (map[string]*SecretEntry, error) {
+	return v.readSecrets(keys, readOptions{})
+}
+
+// GetSecretsAllowExpired retrieves secrets like GetSecrets but ignores the
+// block_expired_reads setting, like GetSecretAllowExpired.
+func (v *Vault) GetSecretsAllowExpired(keys []string) (map[string]*SecretEntry, error) {
+	return v.readSecrets(keys, readOptions{allowExpired: true})
+}
+
+// KeyError reports which key of a batch operation failed.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string { return e.Key + ": " + e.Err.Error() }
+
+func (e *KeyError) Unwrap() error { return e.Err }
+
+// readOptions controls which access rules getSecret enforces.
+type readOptions struct {
+	allowExpired    bool // ignore block_expired_reads
+	inspect         bool // ignore all access rules
+	followRedirects bool // deprecated keys are resolved by the caller (no denial logged)
+}
+
+func (v *Vault) getSecret(key string, opts readOptions) (*SecretEntry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
@@ -1285,43 +2024,250 @@ func (v *Vault) GetSecret(key string) (*SecretEntry, error) {
 	keyHash := v.hashKey(key)
 
 	// Get all fields from database (including new multi-field columns)
-	var encryptedValue, encryptedFields, encryptedBindings, encryptedMetadata []byte
-	var schema sql.NullString
-	var folderID sql.NullString
-	var tagsStr sql.NullString
-	var expiresAt sql.NullTime
-	var createdAt, updatedAt time.Time
-
-	err := v.db.QueryRow(`
-		SELECT encrypted_value, encrypted_fields, encrypted_bindings, encrypted_metadata, schema, folder_id, tags, expires_at, created_at, updated_at
-		FROM secrets WHERE key_hash = ?`,
-		keyHash,
-	).Scan(&encryptedValue, &encryptedFields, &encryptedBindings, &encryptedMetadata, &schema, &folderID, &tagsStr, &expiresAt, &createdAt, &updatedAt)
+	var row secretRow
+	err := v.db.QueryRow("SELECT "+secretRowColumns+" FROM secrets WHERE key_hash = ? AND deleted_at IS NULL", keyHash).Scan(row.dest()...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			_ = v.audit.LogError(audit.OpSecretGet, audit.SourceCLI, key, "NOT_FOUND", "secret not found")
+			if err := v.checkShard(key); err != nil {
+				_ = v.audit.LogError(audit.OpSecretGet, v.auditSource(), key, "SHARD_UNAVAILABLE", err.Error())
+				return nil, err
+			}
+			_ = v.audit.LogError(audit.OpSecretGet, v.auditSource(), key, "NOT_FOUND", "secret not found")
 			return nil, ErrSecretNotFound
 		}
 		return nil, fmt.Errorf("vault: failed to read secret: %w", err)
 	}
 
+	entry, err := v.decodeSecret(key, &row, opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Log successful operation
+	_ = v.audit.LogSuccess(audit.OpSecretGet, v.auditSource(), key)
+
+	return entry, nil
+}
+
+// readSecrets is the batch counterpart of readSecret: canary reads raise
+// alerts, dynamic:// values are resolved, and deprecated keys are followed
+// when deprecated_reads is "redirect".
+func (v *Vault) readSecrets(keys []string, opts readOptions) (map[string]*SecretEntry, error) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	var entries map[string]*SecretEntry
+	err := v.run(Op{Name: OpGetBatch, Keys: sorted}, func() error {
+		var err error
+		entries, err = v.readSecretsUnwrapped(keys, opts)
+		return err
+	})
+	return entries, err
+}
+
+func (v *Vault) readSecretsUnwrapped(keys []string, opts readOptions) (map[string]*SecretEntry, error) {
+	settings, err := v.Settings()
+	opts.followRedirects = err == nil && settings.DeprecatedReads == DeprecatedReadsRedirect
+
+	entries, deprecated, canaries, err := v.getSecrets(keys, opts)
+	for _, key := range canaries {
+		v.canaryTriggered(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !opts.inspect {
+		read := make([]string, 0, len(entries))
+		for key := range entries {
+			read = append(read, key)
+		}
+		v.recordAccess(read...)
+	}
+
+	for _, key := range deprecated {
+		entry, err := v.readSecretChain(key, opts, nil)
+		if err != nil {
+			return nil, &KeyError{Key: key, Err: err}
+		}
+		entries[key] = entry
+	}
+	for key, entry := range entries {
+		if IsDynamicRef(entry.Value) {
+			if err := v.resolveDynamic(entry); err != nil {
+				return nil, &KeyError{Key: key, Err: err}
+			}
+		}
+		if !opts.inspect && entry.hasRefs() {
+			if err := v.resolveRefs(entry, opts, []string{entry.Key}); err != nil {
+				return nil, &KeyError{Key: key, Err: err}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// getSecrets reads keys in one query. Deprecated keys that are to be
+// followed are returned separately without an entry. The canary keys among
+// those read are returned even if the batch fails.
+func (v *Vault) getSecrets(keys []string, opts readOptions) (entries map[string]*SecretEntry, deprecated, canaries []string, err error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, nil, nil, ErrVaultLocked
+	}
+
+	byHash := make(map[string]string, len(keys))
+	args := make([]any, 0, len(keys))
+	for _, key := range keys {
+		keyHash := v.hashKey(key)
+		if _, ok := byHash[keyHash]; !ok {
+			byHash[keyHash] = key
+			args = append(args, keyHash)
+		}
+	}
+	entries = make(map[string]*SecretEntry, len(byHash))
+	if len(args) == 0 {
+		return entries, nil, nil, nil
+	}
+
+	rows, err := v.db.Query(`
+		SELECT key_hash, EXISTS(SELECT 1 FROM canaries WHERE canaries.key_hash = secrets.key_hash), `+secretRowColumns+`
+		FROM secrets WHERE key_hash IN (?`+strings.Repeat(", ?", len(args)-1)+`) AND deleted_at IS NULL`,
+		args...,
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("vault: failed to read secrets: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		var keyHash string
+		var canary bool
+		var row secretRow
+		if err := rows.Scan(append([]any{&keyHash, &canary}, row.dest()...)...); err != nil {
+			return nil, nil, canaries, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		key := byHash[keyHash]
+		if canary && !opts.inspect {
+			canaries = append(canaries, key)
+		}
+
+		entry, err := v.decodeSecret(key, &row, opts, now)
+		var depErr *DeprecatedKeyError
+		if opts.followRedirects && errors.As(err, &depErr) {
+			deprecated = append(deprecated, key)
+			continue
+		}
+		if err != nil {
+			return nil, nil, canaries, &KeyError{Key: key, Err: err}
+		}
+		entries[key] = entry
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, canaries, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+
+	for _, key := range keys {
+		if _, ok := entries[key]; !ok && !slices.Contains(deprecated, key) {
+			if err := v.checkShard(key); err != nil {
+				return nil, nil, canaries, &KeyError{Key: key, Err: err}
+			}
+			_ = v.audit.LogError(audit.OpSecretGet, v.auditSource(), key, "NOT_FOUND", "secret not found")
+			return nil, nil, canaries, &KeyError{Key: key, Err: ErrSecretNotFound}
+		}
+	}
+
+	_ = v.audit.Log(audit.OpSecretGetBatch, v.auditSource(), audit.ResultSuccess, "", nil,
+		map[string]interface{}{"count": len(entries)})
+	return entries, deprecated, canaries, nil
+}
+
+// secretRowColumns are the columns of a secrets row scanned into secretRow.
+const secretRowColumns = "encrypted_value, encrypted_fields, encrypted_bindings, encrypted_metadata, schema, folder_id, tags, expires_at, not_before, access_window, immutable, created_at, updated_at, encrypted_redirect, encrypted_certificate"
+
+// secretRow holds the encrypted columns of one secret.
+type secretRow struct {
+	encryptedValue       []byte
+	encryptedFields      []byte
+	encryptedBindings    []byte
+	encryptedMetadata    []byte
+	schema               sql.NullString
+	folderID             sql.NullString
+	tags                 sql.NullString
+	expiresAt            sql.NullTime
+	notBefore            sql.NullTime
+	accessWindow         sql.NullString
+	immutable            bool
+	createdAt            time.Time
+	updatedAt            time.Time
+	encryptedRedirect    []byte
+	encryptedCertificate []byte
+}
+
+// dest returns the Scan destinations for secretRowColumns.
+func (r *secretRow) dest() []any {
+	return []any{&r.encryptedValue, &r.encryptedFields, &r.encryptedBindings, &r.encryptedMetadata, &r.schema, &r.folderID, &r.tags, &r.expiresAt, &r.notBefore, &r.accessWindow, &r.immutable, &r.createdAt, &r.updatedAt, &r.encryptedRedirect, &r.encryptedCertificate}
+}
+
+// decodeSecret enforces the access rules selected by opts and decrypts row
+// into an entry. Denials and decryption failures are audited; successful
+// reads are left to the caller.
+func (v *Vault) decodeSecret(key string, row *secretRow, opts readOptions, now time.Time) (*SecretEntry, error) {
+	var deprecation *Deprecation
+	var err error
+	if len(row.encryptedRedirect) > 0 {
+		deprecation, err = v.decryptDeprecation(row.encryptedRedirect)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to decrypt deprecation: %w", err)
+		}
+	}
+
+	var notBeforePtr *time.Time
+	if row.notBefore.Valid {
+		notBeforePtr = &row.notBefore.Time
+	}
+	accessWindow := parseAccessWindowColumn(row.accessWindow)
+
+	if !opts.inspect {
+		if deprecation != nil {
+			if !opts.followRedirects {
+				_ = v.audit.LogDenied(audit.OpSecretGet, v.auditSource(), key, "key deprecated, replaced by "+deprecation.ReplacedBy)
+			}
+			return nil, &DeprecatedKeyError{Key: key, ReplacedBy: deprecation.ReplacedBy}
+		}
+		if !opts.allowExpired && row.expiresAt.Valid && row.expiresAt.Time.Before(now) && v.blockExpiredReads() {
+			_ = v.audit.LogDenied(audit.OpSecretGet, v.auditSource(), key, "secret expired (block_expired_reads)")
+			return nil, ErrSecretExpired
+		}
+		// Access rules are not lifted by --allow-expired
+		if err := checkAccessRules(notBeforePtr, accessWindow, now); err != nil {
+			_ = v.audit.LogDenied(audit.OpSecretGet, v.auditSource(), key, err.Error())
+			return nil, err
+		}
+	}
+
 	entry := &SecretEntry{
-		Key:       key,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		Key:          key,
+		NotBefore:    notBeforePtr,
+		AccessWindow: accessWindow,
+		Immutable:    row.immutable,
+		CreatedAt:    row.createdAt,
+		UpdatedAt:    row.updatedAt,
+		Deprecation:  deprecation,
 	}
 
 	// Set folder ID if present (Phase 2c-X2)
-	if folderID.Valid {
-		entry.FolderID = &folderID.String
+	if row.folderID.Valid {
+		entry.FolderID = &row.folderID.String
 	}
 
 	// Decrypt fields (new format) or value (legacy format)
-	if len(encryptedFields) > 0 {
+	if len(row.encryptedFields) > 0 {
 		// New multi-field format
-		fieldsJSON, err := v.decryptWithNonce(encryptedFields)
+		fieldsJSON, err := v.decryptWithNonce(row.encryptedFields)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretGet, audit.SourceCLI, key, "DECRYPT_FAILED", err.Error())
+			_ = v.audit.LogError(audit.OpSecretGet, v.auditSource(), key, "DECRYPT_FAILED", err.Error())
 			return nil, fmt.Errorf("vault: failed to decrypt fields: %w", err)
 		}
 		var fields map[string]Field
@@ -1331,11 +2277,11 @@ func (v *Vault) GetSecret(key string) (*SecretEntry, error) {
 		entry.Fields = fields
 		// Populate legacy Value field for backward compatibility
 		entry.Value = []byte(GetDefaultFieldValue(fields))
-	} else if len(encryptedValue) > 0 {
+	} else if len(row.encryptedValue) > 0 {
 		// Legacy single-value format - auto-convert to Fields["value"]
-		plainValue, err := v.decryptWithNonce(encryptedValue)
+		plainValue, err := v.decryptWithNonce(row.encryptedValue)
 		if err != nil {
-			_ = v.audit.LogError(audit.OpSecretGet, audit.SourceCLI, key, "DECRYPT_FAILED", err.Error())
+			_ = v.audit.LogError(audit.OpSecretGet, v.auditSource(), key, "DECRYPT_FAILED", err.Error())
 			return nil, fmt.Errorf("vault: failed to decrypt secret: %w", err)
 		}
 		entry.Value = plainValue
@@ -1343,8 +2289,8 @@ func (v *Vault) GetSecret(key string) (*SecretEntry, error) {
 	}
 
 	// Decrypt bindings if present
-	if len(encryptedBindings) > 0 {
-		bindingsJSON, err := v.decryptWithNonce(encryptedBindings)
+	if len(row.encryptedBindings) > 0 {
+		bindingsJSON, err := v.decryptWithNonce(row.encryptedBindings)
 		if err != nil {
 			return nil, fmt.Errorf("vault: failed to decrypt bindings: %w", err)
 		}
@@ -1356,13 +2302,13 @@ func (v *Vault) GetSecret(key string) (*SecretEntry, error) {
 	}
 
 	// Set schema if present
-	if schema.Valid {
-		entry.Schema = schema.String
+	if row.schema.Valid {
+		entry.Schema = row.schema.String
 	}
 
 	// Decrypt metadata if present
-	if len(encryptedMetadata) > 0 {
-		metadataJSON, err := v.decryptWithNonce(encryptedMetadata)
+	if len(row.encryptedMetadata) > 0 {
+		metadataJSON, err := v.decryptWithNonce(row.encryptedMetadata)
 		if err != nil {
 			return nil, fmt.Errorf("vault: failed to decrypt metadata: %w", err)
 		}
@@ -1374,26 +2320,40 @@ func (v *Vault) GetSecret(key string) (*SecretEntry, error) {
 	}
 
 	// Parse tags from JSON
-	if tagsStr.Valid && tagsStr.String != "" {
+	if row.tags.Valid && row.tags.String != "" {
 		var tags []string
-		if err := json.Unmarshal([]byte(tagsStr.String), &tags); err == nil {
+		if err := json.Unmarshal([]byte(row.tags.String), &tags); err == nil {
 			entry.Tags = tags
 		}
 	}
 
 	// Set expiration
-	if expiresAt.Valid {
-		entry.ExpiresAt = &expiresAt.Time
+	if row.expiresAt.Valid {
+		entry.ExpiresAt = &row.expiresAt.Time
 	}
 
-	// Log successful operation
-	_ = v.audit.LogSuccess(audit.OpSecretGet, audit.SourceCLI, key)
+	if len(row.encryptedCertificate) > 0 {
+		entry.Certificate, err = v.decryptCertificate(row.encryptedCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("vault: failed to decrypt certificate: %w", err)
+		}
+	}
 
 	return entry, nil
 }
 
 // ListSecrets retrieves all secret key names
 func (v *Vault) ListSecrets() ([]string, error) {
+	var keys []string
+	err := v.run(Op{Name: OpList}, func() error {
+		var err error
+		keys, err = v.listSecrets()
+		return err
+	})
+	return keys, err
+}
+
+func (v *Vault) listSecrets() ([]string, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
@@ -1403,7 +2363,7 @@ func (v *Vault) ListSecrets() ([]string, error) {
 	}
 
 	// Get all encrypted_key records
-	rows, err := v.db.Query("SELECT encrypted_key FROM secrets ORDER BY created_at")
+	rows, err := v.db.Query("SELECT encrypted_key FROM secrets WHERE deleted_at IS NULL ORDER BY created_at")
 	if err != nil {
 		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
 	}
@@ -1430,13 +2390,103 @@ func (v *Vault) ListSecrets() ([]string, error) {
 	}
 
 	// Log successful operation
-	_ = v.audit.LogSuccess(audit.OpSecretList, audit.SourceCLI, "")
+	_ = v.audit.LogSuccess(audit.OpSecretList, v.auditSource(), "")
 
 	return keys, nil
 }
 
-// DeleteSecret deletes a secret by key name
+// ListSecretsByPrefix returns the keys that start with prefix, sorted by
+// key. Use a trailing '/' to list one level of the key hierarchy and
+// everything below it: "aws/prod/" matches "aws/prod/DB_URL" but not
+// "aws/production". An empty prefix lists every key.
+//
+// Only the keys under the last '/' of prefix are decrypted: the
+// secret_paths table maps each directory of a key to the secret.
+func (v *Vault) ListSecretsByPrefix(prefix string) ([]string, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]string, error) {
+		return v.listSecretsByPrefix(prefix)
+	})
+}
+
+func (v *Vault) listSecretsByPrefix(prefix string) ([]string, error) {
+	keys, unindexed, err := v.listSecretsByPrefixLocked(prefix)
+	if err != nil {
+		return nil, err
+	}
+	v.indexPaths(unindexed)
+	return keys, nil
+}
+
+// listSecretsByPrefixLocked looks prefix up in secret_paths and also
+// returns the secrets that have no rows there yet, as key hash to key.
+func (v *Vault) listSecretsByPrefixLocked(prefix string) ([]string, map[string]string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.dek == nil {
+		return nil, nil, ErrVaultLocked
+	}
+
+	// Only the secrets under the directory of prefix are decrypted, plus
+	// those not indexed yet, which can be anywhere
+	rows, err := v.db.Query(`
+		SELECT s.key_hash, s.encrypted_key, 1 FROM secret_paths p
+		JOIN secrets s ON s.key_hash = p.key_hash
+		WHERE p.path_hash = ? AND s.deleted_at IS NULL
+		UNION ALL
+		SELECT s.key_hash, s.encrypted_key, 0 FROM secrets s
+		WHERE s.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM secret_paths p WHERE p.key_hash = s.key_hash)`,
+		v.hashDir(prefixDir(prefix)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("vault: failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	unindexed := make(map[string]string)
+	for rows.Next() {
+		var keyHash string
+		var encryptedKey []byte
+		var indexed bool
+		if err := rows.Scan(&keyHash, &encryptedKey, &indexed); err != nil {
+			return nil, nil, fmt.Errorf("vault: failed to scan row: %w", err)
+		}
+		keyBytes, err := v.decryptWithNonce(encryptedKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("vault: failed to decrypt key name: %w", err)
+		}
+		key := string(keyBytes)
+		if !indexed {
+			unindexed[keyHash] = key
+		}
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("vault: error iterating rows: %w", err)
+	}
+	sort.Strings(keys)
+
+	_ = v.audit.LogSuccess(audit.OpSecretList, v.auditSource(), prefix)
+
+	return keys, unindexed, nil
+}
+
+// DeleteSecret moves a secret to the trash. It is hidden from reads and
+// lists until RestoreSecret brings it back or PurgeDeleted removes it.
 func (v *Vault) DeleteSecret(key string) error {
+	return v.deleteSecret(key, writeOptions{})
+}
+
+func (v *Vault) deleteSecret(key string, opts writeOptions) error {
+	return v.run(Op{Name: OpDelete, Key: key}, func() error {
+		return v.deleteSecretUnwrapped(key, opts)
+	})
+}
+
+func (v *Vault) deleteSecretUnwrapped(key string, opts writeOptions) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
@@ -1444,6 +2494,9 @@ func (v *Vault) DeleteSecret(key string) error {
 	if v.dek == nil {
 		return ErrVaultLocked
 	}
+	if err := v.checkWritable(); err != nil {
+		return err
+	}
 
 	// Compute key hash (HMAC-SHA256 with DEK)
 	keyHash := v.hashKey(key)
@@ -1455,20 +2508,22 @@ func (v *Vault) DeleteSecret(key string) error {
 	}
 	defer tx.Rollback()
 
-	// Delete record
-	result, err := tx.Exec("DELETE FROM secrets WHERE key_hash = ?", keyHash)
-	if err != nil {
-		_ = v.audit.LogError(audit.OpSecretDelete, audit.SourceCLI, key, "DB_ERROR", err.Error())
-		return fmt.Errorf("vault: failed to delete secret: %w", err)
+	if err := v.checkMutableTx(tx, keyHash, key, audit.OpSecretDelete, opts); err != nil {
+		return err
 	}
 
-	// Check rows affected
-	rowsAffected, err := result.RowsAffected()
+	// Move to the trash. History and the canary marker stay with the row
+	// so RestoreSecret brings them back; PurgeDeleted removes them.
+	rowsAffected, err := v.execSecrets(tx, "UPDATE %s SET deleted_at = ? WHERE key_hash = ? AND deleted_at IS NULL", time.Now(), keyHash)
 	if err != nil {
-		return fmt.Errorf("vault: failed to get rows affected: %w", err)
+		_ = v.audit.LogError(audit.OpSecretDelete, v.auditSource(), key, "DB_ERROR", err.Error())
+		return fmt.Errorf("vault: failed to delete secret: %w", err)
 	}
 	if rowsAffected == 0 {
-		_ = v.audit.LogError(audit.OpSecretDelete, audit.SourceCLI, key, "NOT_FOUND", "secret not found")
+		if err := v.checkShard(key); err != nil {
+			return err
+		}
+		_ = v.audit.LogError(audit.OpSecretDelete, v.auditSource(), key, "NOT_FOUND", "secret not found")
 		return ErrSecretNotFound
 	}
 
@@ -1477,11 +2532,19 @@ func (v *Vault) DeleteSecret(key string) error {
 	}
 
 	// Log successful operation
-	_ = v.audit.LogSuccess(audit.OpSecretDelete, audit.SourceCLI, key)
+	_ = v.audit.LogSuccess(audit.OpSecretDelete, v.auditSource(), key)
+	if opts.breakGlass {
+		v.logBreakGlass(key, "delete", opts.reason)
+	}
+	v.emit(EventSecretChanged, key, "deleted")
+	v.updateSearchIndex()
 
 	return nil
 }
 
+// secretMetadataColumns are the columns read by scanSecretEntryRowWithMetadata.
+const secretMetadataColumns = "encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, not_before, access_window, immutable, created_at, updated_at, encrypted_redirect, encrypted_certificate"
+
 // scanSecretEntryRowWithMetadata scans a row including metadata (but NOT secret value).
 // This decrypts key name and metadata, but never touches the secret value.
 // Use this for list operations that need metadata presence (HasNotes, HasURL).
@@ -1492,18 +2555,24 @@ func (v *Vault) DeleteSecret(key string) error {
 //
 // Folder support (Phase 2c-X2):
 // - Reads folder_id for folder-based filtering and display
-func (v *Vault) scanSecretEntryRowWithMetadata(rows *sql.Rows) (*SecretEntry, error) {
+//
+// extra receives any columns selected after secretMetadataColumns.
+func (v *Vault) scanSecretEntryRowWithMetadata(rows *sql.Rows, extra ...any) (*SecretEntry, error) {
 	var encryptedKey []byte
 	var encryptedMetadata []byte
 	var schema sql.NullString
 	var fieldCount sql.NullInt64
 	var folderID sql.NullString
 	var tagsStr sql.NullString
-	var expiresAt sql.NullTime
+	var expiresAt, notBefore sql.NullTime
+	var accessWindowStr sql.NullString
+	var immutable bool
 	var createdAt, updatedAt time.Time
+	var encryptedRedirect, encryptedCertificate []byte
 
-	if err := rows.Scan(&encryptedKey, &encryptedMetadata, &schema, &fieldCount, &folderID, &tagsStr, &expiresAt,
-		&createdAt, &updatedAt); err != nil {
+	dest := []any{&encryptedKey, &encryptedMetadata, &schema, &fieldCount, &folderID, &tagsStr, &expiresAt,
+		&notBefore, &accessWindowStr, &immutable, &createdAt, &updatedAt, &encryptedRedirect, &encryptedCertificate}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
 		return nil, fmt.Errorf("vault: failed to scan row: %w", err)
 	}
 
@@ -1516,6 +2585,7 @@ func (v *Vault) scanSecretEntryRowWithMetadata(rows *sql.Rows) (*SecretEntry, er
 	entry := &SecretEntry{
 		Key:        string(keyBytes),
 		FieldCount: 1, // Default for legacy secrets
+		Immutable:  immutable,
 		CreatedAt:  createdAt,
 		UpdatedAt:  updatedAt,
 	}
@@ -1541,7 +2611,7 @@ func (v *Vault) scanSecretEntryRowWithMetadata(rows *sql.Rows) (*SecretEntry, er
 		if err != nil {
 			// Log but continue - metadata decryption failure is non-fatal for listing
 			// We still need to parse tags and expiration below
-			log.Printf("vault: metadata decryption failed for key %q: %v", entry.Key, err)
+			slog.Warn("vault: metadata decryption failed", "key", entry.Key, "err", err)
 		} else {
 			var meta SecretMetadata
 			if err := json.Unmarshal(metadataJSON, &meta); err == nil {
@@ -1561,6 +2631,29 @@ func (v *Vault) scanSecretEntryRowWithMetadata(rows *sql.Rows) (*SecretEntry, er
 	if expiresAt.Valid {
 		entry.ExpiresAt = &expiresAt.Time
 	}
+	if notBefore.Valid {
+		entry.NotBefore = &notBefore.Time
+	}
+	entry.AccessWindow = parseAccessWindowColumn(accessWindowStr)
+
+	// Decrypt deprecation info if present (non-fatal for listing)
+	if len(encryptedRedirect) > 0 {
+		deprecation, err := v.decryptDeprecation(encryptedRedirect)
+		if err != nil {
+			slog.Warn("vault: deprecation decryption failed", "key", entry.Key, "err", err)
+		} else {
+			entry.Deprecation = deprecation
+		}
+	}
+
+	if len(encryptedCertificate) > 0 {
+		cert, err := v.decryptCertificate(encryptedCertificate)
+		if err != nil {
+			slog.Warn("vault: certificate decryption failed", "key", entry.Key, "err", err)
+		} else {
+			entry.Certificate = cert
+		}
+	}
 
 	return entry, nil
 }
@@ -1568,6 +2661,12 @@ func (v *Vault) scanSecretEntryRowWithMetadata(rows *sql.Rows) (*SecretEntry, er
 // ListSecretsWithMetadata lists all secrets with metadata but WITHOUT decrypting values.
 // This is more secure than GetSecret for list operations as it never exposes secret values.
 func (v *Vault) ListSecretsWithMetadata() ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		return v.listSecretsWithMetadata()
+	})
+}
+
+func (v *Vault) listSecretsWithMetadata() ([]*SecretEntry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
@@ -1577,8 +2676,9 @@ func (v *Vault) ListSecretsWithMetadata() ([]*SecretEntry, error) {
 	}
 
 	rows, err := v.db.Query(`
-		SELECT encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, created_at, updated_at
+		SELECT ` + secretMetadataColumns + `
 		FROM secrets
+		WHERE deleted_at IS NULL
 		ORDER BY created_at`)
 	if err != nil {
 		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
@@ -1603,6 +2703,12 @@ func (v *Vault) ListSecretsWithMetadata() ([]*SecretEntry, error) {
 
 // ListSecretsByTag retrieves secrets filtered by tag (includes metadata, NOT values)
 func (v *Vault) ListSecretsByTag(tag string) ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		return v.listSecretsByTag(tag)
+	})
+}
+
+func (v *Vault) listSecretsByTag(tag string) ([]*SecretEntry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
@@ -1615,9 +2721,9 @@ func (v *Vault) ListSecretsByTag(tag string) ([]*SecretEntry, error) {
 	// This searches for the tag within the JSON array string
 	// Include encrypted_metadata and schema for HasNotes/HasURL support and Phase 3
 	rows, err := v.db.Query(`
-		SELECT encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, created_at, updated_at
+		SELECT `+secretMetadataColumns+`
 		FROM secrets
-		WHERE tags LIKE ?
+		WHERE tags LIKE ? AND deleted_at IS NULL
 		ORDER BY created_at`,
 		`%"`+tag+`"%`)
 	if err != nil {
@@ -1647,8 +2753,15 @@ func (v *Vault) ListSecretsByTag(tag string) ([]*SecretEntry, error) {
 	return secrets, nil
 }
 
-// ListExpiringSecrets retrieves secrets expiring within the specified duration (includes metadata, NOT values)
+// ListExpiringSecrets retrieves secrets expiring within the specified duration (includes metadata, NOT values).
+// Secrets whose certificate's notAfter falls within the duration are included; see SecretEntry.Expiry.
 func (v *Vault) ListExpiringSecrets(within time.Duration) ([]*SecretEntry, error) {
+	return runValue(v, Op{Name: OpList}, func() ([]*SecretEntry, error) {
+		return v.listExpiringSecrets(within)
+	})
+}
+
+func (v *Vault) listExpiringSecrets(within time.Duration) ([]*SecretEntry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
@@ -1661,11 +2774,12 @@ func (v *Vault) ListExpiringSecrets(within time.Duration) ([]*SecretEntry, error
 
 	// Include encrypted_metadata and schema for HasNotes/HasURL support and Phase 3
 	rows, err := v.db.Query(`
-		SELECT encrypted_key, encrypted_metadata, schema, field_count, folder_id, tags, expires_at, created_at, updated_at
+		SELECT `+secretMetadataColumns+`
 		FROM secrets
-		WHERE expires_at IS NOT NULL AND expires_at <= ?
-		ORDER BY expires_at`,
-		deadline)
+		WHERE ((expires_at IS NOT NULL AND expires_at <= ?) OR (cert_not_after IS NOT NULL AND cert_not_after <= ?))
+			AND deleted_at IS NULL
+		ORDER BY MIN(COALESCE(expires_at, cert_not_after), COALESCE(cert_not_after, expires_at))`,
+		deadline, deadline)
 	if err != nil {
 		return nil, fmt.Errorf("vault: failed to query secrets: %w", err)
 	}
@@ -1889,7 +3003,7 @@ func (v *Vault) saveLockState(state *LockState) error {
 	if err != nil {
 		return fmt.Errorf("vault: failed to marshal lock state: %w", err)
 	}
-	if err := os.WriteFile(lockPath, data, FileMode); err != nil {
+	if err := atomicfile.Write(lockPath, data, FileMode); err != nil {
 		return fmt.Errorf("vault: failed to write lock state: %w", err)
 	}
 	return nil
@@ -2011,7 +3125,7 @@ func (v *Vault) checkDiskSpaceForWrite(dataSize int) error {
 	info, err := v.CheckDiskSpace()
 	if err != nil {
 		// Log warning but don't block operation
-		fmt.Fprintf(os.Stderr, "warning: failed to check disk space: %v\n", err)
+		v.warn(EventWarning, "failed to check disk space: %v", err)
 		return nil
 	}
 
@@ -2030,7 +3144,7 @@ func (v *Vault) checkDiskSpaceForWrite(dataSize int) error {
 
 	// Warn if disk space is low
 	if info.UsedPct >= DiskWarningPercent {
-		fmt.Fprintf(os.Stderr, "warning: disk is %d%% full, consider freeing space\n", info.UsedPct)
+		v.warn(EventLowDisk, "disk is %d%% full, consider freeing space", info.UsedPct)
 	}
 
 	return nil
@@ -2077,7 +3191,7 @@ func (v *Vault) Repair() error {
 	if err != nil {
 		return fmt.Errorf("vault: failed to marshal metadata: %w", err)
 	}
-	if err := os.WriteFile(metaPath, metaJSON, FileMode); err != nil {
+	if err := atomicfile.Write(metaPath, metaJSON, FileMode); err != nil {
 		return fmt.Errorf("vault: failed to write metadata file: %w", err)
 	}
 
@@ -2,11 +2,17 @@
 package vault
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
 )
 
 func TestNew(t *testing.T) {
@@ -292,6 +298,53 @@ func TestCheckIntegrityMissingMeta(t *testing.T) {
 	}
 }
 
+func TestQuickCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Chmod(tmpDir, 0700); err != nil {
+		t.Fatalf("failed to set directory permissions: %v", err)
+	}
+	v := New(tmpDir)
+	password := "testpassword123"
+
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := v.QuickCheck(); err != ErrVaultLocked {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("key", &SecretEntry{Value: []byte("value")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	report, err := v.QuickCheck()
+	if err != nil {
+		t.Fatalf("QuickCheck failed: %v", err)
+	}
+	if !report.Healthy {
+		t.Errorf("expected healthy vault, got problems: %v", report.Problems)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err := os.Chmod(filepath.Join(tmpDir, DBFileName), 0644); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	report, err = v.QuickCheck()
+	if err != nil {
+		t.Fatalf("QuickCheck failed: %v", err)
+	}
+	if report.Healthy || len(report.Problems) != 1 {
+		t.Errorf("expected one permission problem, got %v", report.Problems)
+	}
+}
+
 func TestRepair(t *testing.T) {
 	tmpDir := t.TempDir()
 	v := New(tmpDir)
@@ -1024,6 +1077,75 @@ func TestUnlockPermissionWarning(t *testing.T) {
 	v.Lock()
 }
 
+// TestUnlockAutoFixPermissions verifies that auto_fix_permissions repairs
+// loosened permissions on unlock and audits the repair
+func TestUnlockAutoFixPermissions(t *testing.T) {
+	if filepath.Separator == '\\' {
+		t.Skip("Skipping permission tests on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	v := New(tmpDir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := v.UpdateSettings(func(s *VaultSettings) error {
+		s.AutoFixPermissions = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	v.Lock()
+
+	saltPath := filepath.Join(tmpDir, SaltFileName)
+	if err := os.Chmod(saltPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(tmpDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	events, cancel := v.Events()
+	defer cancel()
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for path, want := range map[string]os.FileMode{saltPath: FileMode, tmpDir: DirMode} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != want {
+			t.Errorf("%s: permissions %04o, want %04o", path, perm, want)
+		}
+	}
+	for len(events) > 0 {
+		if e := <-events; e.Type == EventWarning && strings.Contains(e.Message, "insecure permissions") {
+			t.Errorf("unexpected warning after repair: %s", e.Message)
+		}
+	}
+
+	entries, err := v.Audit().ListEvents(0, time.Time{})
+	if err != nil {
+		t.Fatalf("audit query failed: %v", err)
+	}
+	fixed := map[string]bool{}
+	for _, e := range entries {
+		if e.Operation == audit.OpVaultPermissions && e.Context["old_mode"] != nil {
+			fixed[e.Context["path"].(string)] = true
+		}
+	}
+	if !fixed[SaltFileName] || !fixed["vault directory"] {
+		t.Errorf("expected vault.permissions_fixed audit events, got %v", fixed)
+	}
+}
+
 // contains is a helper function for string containment check
 func contains(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
@@ -1214,6 +1336,32 @@ func TestMetadataValidation(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "owner with invalid characters",
+			entry: &SecretEntry{
+				Value:    []byte("test"),
+				Metadata: &SecretMetadata{Owner: "alice smith"},
+			},
+			wantErr:   ErrOwnerInvalid,
+			errSubstr: "invalid owner or team name",
+		},
+		{
+			name: "team too long",
+			entry: &SecretEntry{
+				Value:    []byte("test"),
+				Metadata: &SecretMetadata{Team: strings.Repeat("t", MaxOwnerLength+1)},
+			},
+			wantErr:   ErrOwnerInvalid,
+			errSubstr: "at most 64",
+		},
+		{
+			name: "valid owner email and team",
+			entry: &SecretEntry{
+				Value:    []byte("test"),
+				Metadata: &SecretMetadata{Owner: "alice.smith@example.com", Team: "platform-infra"},
+			},
+			wantErr: nil,
+		},
 		// URL scheme validation tests
 		{
 			name: "javascript url scheme rejected",
@@ -1429,6 +1577,17 @@ func TestValidateMasterPassword(t *testing.T) {
 }
 
 // TestPasswordStrengthString tests the String method of PasswordStrength
+func TestRatePassword(t *testing.T) {
+	// Unlike ValidateMasterPassword, lengths beyond MaxPasswordLength are rated
+	long := strings.Repeat("aB3!", 50)
+	if got := RatePassword(long); got != PasswordStrong {
+		t.Errorf("RatePassword(200 chars) = %v, want strong", got)
+	}
+	if got := RatePassword("abcdefgh"); got != PasswordWeak {
+		t.Errorf("RatePassword(abcdefgh) = %v, want weak", got)
+	}
+}
+
 func TestPasswordStrengthString(t *testing.T) {
 	tests := []struct {
 		strength PasswordStrength
@@ -1597,6 +1756,113 @@ func TestListSecretsByTagNoValueDecryption(t *testing.T) {
 	}
 }
 
+func TestListSecretsByPrefix(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := New(tmpDir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for _, key := range []string{"aws/prod/DB_URL", "aws/production", "aws/prod/API_KEY", "gcp/prod/DB_URL", "aws/prod/OLD"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("value")}); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+	if err := v.DeleteSecret("aws/prod/OLD"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+
+	keys, err := v.ListSecretsByPrefix("aws/prod/")
+	if err != nil {
+		t.Fatalf("ListSecretsByPrefix failed: %v", err)
+	}
+	if want := []string{"aws/prod/API_KEY", "aws/prod/DB_URL"}; !slices.Equal(keys, want) {
+		t.Errorf("ListSecretsByPrefix(aws/prod/) = %v, want %v", keys, want)
+	}
+	if keys, _ := v.ListSecretsByPrefix(""); len(keys) != 4 {
+		t.Errorf("ListSecretsByPrefix(\"\") = %v, want all 4 keys", keys)
+	}
+	if keys, _ := v.ListSecretsByPrefix("azure/"); len(keys) != 0 {
+		t.Errorf("ListSecretsByPrefix(azure/) = %v, want none", keys)
+	}
+}
+
+func TestListSecretsByPrefixUsesIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := New(tmpDir)
+	if err := v.Init("testpassword123"); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock("testpassword123"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for _, key := range []string{"aws/prod/DB_URL", "aws/prod/API_KEY", "gcp/prod/DB_URL", "TOP"} {
+		if err := v.SetSecret(key, &SecretEntry{Value: []byte("value")}); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+	countPaths := func() int {
+		var n int
+		if err := v.db.QueryRow("SELECT COUNT(*) FROM secret_paths").Scan(&n); err != nil {
+			t.Fatalf("count failed: %v", err)
+		}
+		return n
+	}
+	if n := countPaths(); n != 10 {
+		t.Errorf("secret_paths has %d rows, want 10", n)
+	}
+
+	// A key outside the directory is never decrypted
+	if _, err := v.db.Exec("UPDATE secrets SET encrypted_key = ? WHERE key_hash = ?", []byte("garbage"), v.hashKey("gcp/prod/DB_URL")); err != nil {
+		t.Fatalf("corrupt failed: %v", err)
+	}
+	keys, err := v.ListSecretsByPrefix("aws/prod/D")
+	if err != nil {
+		t.Fatalf("ListSecretsByPrefix failed: %v", err)
+	}
+	if want := []string{"aws/prod/DB_URL"}; !slices.Equal(keys, want) {
+		t.Errorf("ListSecretsByPrefix(aws/prod/D) = %v, want %v", keys, want)
+	}
+	if _, err := v.ListSecretsByPrefix("gcp/"); err == nil {
+		t.Error("expected the corrupt key to be decrypted for gcp/")
+	}
+	if err := v.SetSecret("gcp/prod/DB_URL", &SecretEntry{Value: []byte("value")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+
+	// Secrets without rows, as after an upgrade, are listed and indexed
+	if _, err := v.db.Exec("DELETE FROM secret_paths WHERE key_hash = ?", v.hashKey("aws/prod/API_KEY")); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	keys, err = v.ListSecretsByPrefix("aws/prod/")
+	if err != nil {
+		t.Fatalf("ListSecretsByPrefix failed: %v", err)
+	}
+	if want := []string{"aws/prod/API_KEY", "aws/prod/DB_URL"}; !slices.Equal(keys, want) {
+		t.Errorf("ListSecretsByPrefix(aws/prod/) = %v, want %v", keys, want)
+	}
+	if n := countPaths(); n != 10 {
+		t.Errorf("secret_paths has %d rows after indexing, want 10", n)
+	}
+
+	// Purging removes the rows of the secret
+	if err := v.DeleteSecret("TOP"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if _, err := v.PurgeDeleted(0); err != nil {
+		t.Fatalf("PurgeDeleted failed: %v", err)
+	}
+	if n := countPaths(); n != 9 {
+		t.Errorf("secret_paths has %d rows after purge, want 9", n)
+	}
+}
+
 // TestListExpiringSecretsNoValueDecryption verifies that ListExpiringSecrets
 // does NOT return secret values (they should be nil/empty).
 func TestListExpiringSecretsNoValueDecryption(t *testing.T) {
@@ -2505,3 +2771,219 @@ func TestChangePassword_AuditLog(t *testing.T) {
 		t.Error("password.changed event not found in audit log")
 	}
 }
+
+// TestChangeMasterPassword_LockState tests that a wrong current password
+// counts as a failed attempt and a successful change resets the count.
+func TestChangeMasterPassword_LockState(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := New(tmpDir)
+	password := "testpassword123"
+
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.ChangeMasterPassword("wrongpassword", "newpassword456"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("expected ErrInvalidPassword, got %v", err)
+	}
+	state, err := v.loadLockState()
+	if err != nil || state.FailedAttempts != 1 {
+		t.Fatalf("lock state after wrong password = %+v, %v", state, err)
+	}
+
+	if err := v.ChangeMasterPassword(password, "newpassword456"); err != nil {
+		t.Fatalf("ChangeMasterPassword failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, LockFileName)); !os.IsNotExist(err) {
+		t.Errorf("lock state not cleared: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(tmpDir, "*"+passwordBackupTmpSuffix)); len(matches) != 0 {
+		t.Errorf("temporary backup left behind: %v", matches)
+	}
+}
+
+// TestUnlock_RemovesIncompleteBackup tests recovery from a password change
+// that crashed while writing its backup.
+func TestUnlock_RemovesIncompleteBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := New(tmpDir)
+	password := "testpassword123"
+
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	partial := filepath.Join(tmpDir, DBFileName+".backup-1700000000"+passwordBackupTmpSuffix)
+	if err := os.WriteFile(partial, []byte("partial"), FileMode); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(partial, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("incomplete backup not removed: %v", err)
+	}
+}
+
+func TestSetSecrets(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	entries := make(map[string]*SecretEntry)
+	for i := range 50 {
+		entries[fmt.Sprintf("batch/key%02d", i)] = &SecretEntry{Value: []byte(fmt.Sprintf("value%d", i)), Tags: []string{"batch"}}
+	}
+	if err := v.SetSecrets(entries); err != nil {
+		t.Fatalf("SetSecrets failed: %v", err)
+	}
+	keys, err := v.ListSecrets()
+	if err != nil || len(keys) != 50 {
+		t.Fatalf("ListSecrets = %d keys, %v; want 50", len(keys), err)
+	}
+	if entry, err := v.GetSecret("batch/key07"); err != nil || string(entry.Value) != "value7" {
+		t.Errorf("GetSecret = %v, %v", entry, err)
+	}
+
+	// One invalid entry rolls back the whole batch
+	err = v.SetSecrets(map[string]*SecretEntry{
+		"batch/key00": {Value: []byte("changed")},
+		"batch/new":   {Value: []byte("x"), Tags: []string{"bad tag"}},
+	})
+	if !errors.Is(err, ErrTagInvalid) || !strings.Contains(err.Error(), "batch/new") {
+		t.Errorf("expected ErrTagInvalid naming the key, got %v", err)
+	}
+	if entry, _ := v.GetSecret("batch/key00"); string(entry.Value) != "value0" {
+		t.Errorf("failed batch was partially applied: %q", entry.Value)
+	}
+	if _, err := v.GetSecret("batch/new"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound for rolled back key, got %v", err)
+	}
+}
+
+func TestGetSecrets(t *testing.T) {
+	v := New(t.TempDir())
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	for key, entry := range map[string]*SecretEntry{
+		"a":       {Value: []byte("1"), Tags: []string{"x"}},
+		"b":       {Value: []byte("2"), Metadata: &SecretMetadata{Owner: "alice"}},
+		"expired": {Value: []byte("3")},
+	} {
+		if err := v.SetSecret(key, entry); err != nil {
+			t.Fatalf("SetSecret(%s) failed: %v", key, err)
+		}
+	}
+	if _, err := v.db.Exec("UPDATE secrets SET expires_at = ? WHERE key_hash = ?", time.Now().Add(-time.Hour), v.hashKey("expired")); err != nil {
+		t.Fatalf("failed to expire secret: %v", err)
+	}
+	if err := v.CreateCanary("trap", &SecretEntry{Value: []byte("bait")}); err != nil {
+		t.Fatalf("CreateCanary failed: %v", err)
+	}
+
+	entries, err := v.GetSecrets([]string{"a", "b", "a", "expired"})
+	if err != nil {
+		t.Fatalf("GetSecrets failed: %v", err)
+	}
+	if len(entries) != 3 || string(entries["a"].Value) != "1" || entries["b"].Metadata.Owner != "alice" || entries["a"].Tags[0] != "x" {
+		t.Errorf("GetSecrets = %+v", entries)
+	}
+
+	if entries, err := v.GetSecrets(nil); err != nil || len(entries) != 0 {
+		t.Errorf("GetSecrets(nil) = %v, %v", entries, err)
+	}
+
+	_, err = v.GetSecrets([]string{"a", "missing"})
+	var keyErr *KeyError
+	if !errors.As(err, &keyErr) || keyErr.Key != "missing" || !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected a KeyError for missing, got %v", err)
+	}
+
+	// Access rules apply per key
+	if err := v.UpdateSettings(func(s *VaultSettings) error { s.BlockExpiredReads = true; return nil }); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if _, err := v.GetSecrets([]string{"a", "expired"}); !errors.Is(err, ErrSecretExpired) {
+		t.Errorf("expected ErrSecretExpired, got %v", err)
+	}
+	if _, err := v.GetSecretsAllowExpired([]string{"a", "expired"}); err != nil {
+		t.Errorf("GetSecretsAllowExpired failed: %v", err)
+	}
+
+	// Reading a canary in a batch raises the alert
+	events, unsubscribe := v.Events()
+	defer unsubscribe()
+	if _, err := v.GetSecrets([]string{"a", "trap"}); err != nil {
+		t.Fatalf("GetSecrets failed: %v", err)
+	}
+	if e := nextEvent(t, events); e.Type != EventCanaryTriggered || e.Key != "trap" {
+		t.Errorf("expected a canary event for the batch read, got %+v", e)
+	}
+
+	v.Lock()
+	if _, err := v.GetSecrets([]string{"a"}); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("expected ErrVaultLocked, got %v", err)
+	}
+}
+
+func TestSetAuditSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	v := New(tmpDir)
+	password := "testpassword123"
+	if err := v.Init(password); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	defer v.Lock()
+
+	if err := v.SetSecret("CLI_KEY", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	v.SetAuditSource(audit.SourceUI)
+	if err := v.SetSecret("UI_KEY", &SecretEntry{Value: []byte("v")}); err != nil {
+		t.Fatalf("SetSecret failed: %v", err)
+	}
+	if _, err := v.GetSecret("UI_KEY"); err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+
+	events, err := v.Audit().ListEvents(0, time.Time{})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	// Key names are recorded as HMACs, so events are told apart by order
+	var sources []string
+	for _, e := range events {
+		if e.Operation == audit.OpSecretSet || e.Operation == audit.OpSecretGet {
+			sources = append(sources, e.Operation+" "+e.Actor.Source)
+		}
+	}
+	want := []string{"secret.set cli", "secret.set ui", "secret.get ui"}
+	if !slices.Equal(sources, want) {
+		t.Errorf("sources = %v, want %v", sources, want)
+	}
+}
@@ -4,10 +4,18 @@ package vault
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"syscall"
 )
 
+// ownedByCurrentUser reports whether the file described by info belongs to
+// the user running this process.
+func ownedByCurrentUser(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
+
 // CheckDiskSpace returns disk space information for the vault directory
 func (v *Vault) CheckDiskSpace() (*DiskSpaceInfo, error) {
 	var stat syscall.Statfs_t
@@ -41,3 +41,9 @@ func (v *Vault) CheckDiskSpace() (*DiskSpaceInfo, error) {
 		UsedPct:   usedPct,
 	}, nil
 }
+
+// ownedByCurrentUser reports false: Windows protects files with ACLs
+// rather than permission bits, so they are never repaired with chmod.
+func ownedByCurrentUser(info os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,301 @@
+// Package vaulttest builds populated vaults from a seed for tests and demos.
+//
+// The same seed always produces the same secret keys, field values, tags,
+// expiration offsets and audit history, so fixtures are reproducible across
+// runs. Timestamps written by the vault itself (created_at, audit timestamps)
+// still reflect wall-clock time.
+package vaulttest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/audit"
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+// Default fixture sizes used by NewBuilder.
+const (
+	DefaultSecretCount = 20
+	DefaultAuditEvents = 50
+	DefaultPassword    = "demo-password-123"
+)
+
+// Fixture builder errors.
+var (
+	ErrInvalidSecretCount = errors.New("vaulttest: a fixture needs at least one secret and a non-negative count")
+	ErrInvalidAuditEvents = errors.New("vaulttest: audit event count must not be negative")
+)
+
+// Fake data vocabularies. Keys are composed as <service>/<env>/<kind>.
+var (
+	services = []string{"aws", "github", "stripe", "postgres", "redis", "slack", "sendgrid", "datadog", "openai", "sentry"}
+	envs     = []string{"dev", "staging", "prod"}
+	tagPool  = []string{"dev", "staging", "prod", "api", "db", "ci", "infra", "payments", "team-a", "team-b"}
+)
+
+// kind describes one shape of fake secret.
+type kind struct {
+	name   string
+	fields func(r *rand.Rand, service string) map[string]vault.Field
+}
+
+var kinds = []kind{
+	{name: "api-key", fields: func(r *rand.Rand, service string) map[string]vault.Field {
+		return map[string]vault.Field{
+			"api_key": {Value: randomString(r, 32), Sensitive: true, Kind: "api_key"},
+		}
+	}},
+	{name: "login", fields: func(r *rand.Rand, service string) map[string]vault.Field {
+		return map[string]vault.Field{
+			"username": {Value: fmt.Sprintf("%s-user%d", service, r.IntN(1000)), Sensitive: false},
+			"password": {Value: randomString(r, 20), Sensitive: true, Kind: "password"},
+		}
+	}},
+	{name: "database", fields: func(r *rand.Rand, service string) map[string]vault.Field {
+		return map[string]vault.Field{
+			"host":     {Value: fmt.Sprintf("%s-%d.internal.example.com", service, r.IntN(10)), Sensitive: false},
+			"port":     {Value: fmt.Sprintf("%d", 5000+r.IntN(1000)), Sensitive: false},
+			"username": {Value: "app", Sensitive: false},
+			"password": {Value: randomString(r, 24), Sensitive: true, Kind: "password"},
+		}
+	}},
+	{name: "token", fields: func(r *rand.Rand, service string) map[string]vault.Field {
+		return map[string]vault.Field{
+			vault.DefaultFieldName: {Value: "tok_" + randomString(r, 28), Sensitive: true},
+		}
+	}},
+}
+
+// Builder creates a populated vault deterministically from a seed.
+type Builder struct {
+	seed        uint64
+	secrets     int
+	auditEvents int
+	tagRatio    float64
+	expiryRatio float64
+	// fixed are the secrets added with WithEntry, in order
+	fixed []fixedEntry
+}
+
+type fixedEntry struct {
+	key   string
+	entry *vault.SecretEntry
+}
+
+// NewBuilder returns a Builder with default sizes for the given seed.
+func NewBuilder(seed uint64) *Builder {
+	return &Builder{
+		seed:        seed,
+		secrets:     DefaultSecretCount,
+		auditEvents: DefaultAuditEvents,
+		tagRatio:    0.7,
+		expiryRatio: 0.3,
+	}
+}
+
+// WithSecrets sets the number of secrets to create.
+func (b *Builder) WithSecrets(n int) *Builder {
+	b.secrets = n
+	return b
+}
+
+// WithAuditEvents sets the number of synthetic audit events to record
+// in addition to the events produced by creating the secrets.
+func (b *Builder) WithAuditEvents(n int) *Builder {
+	b.auditEvents = n
+	return b
+}
+
+// WithTagRatio sets the fraction (0-1) of secrets that receive tags.
+func (b *Builder) WithTagRatio(ratio float64) *Builder {
+	b.tagRatio = ratio
+	return b
+}
+
+// WithExpiryRatio sets the fraction (0-1) of secrets that receive an expiration.
+func (b *Builder) WithExpiryRatio(ratio float64) *Builder {
+	b.expiryRatio = ratio
+	return b
+}
+
+// WithEntry adds a secret with exactly the given contents, after the
+// generated ones. It replaces a generated secret with the same key. Tests
+// use it for the secrets their assertions name; WithSecrets(0) leaves only
+// these.
+func (b *Builder) WithEntry(key string, entry *vault.SecretEntry) *Builder {
+	b.fixed = append(b.fixed, fixedEntry{key: key, entry: entry})
+	return b
+}
+
+// Entries generates the secret entries without touching disk.
+// Calling Entries twice on the same Builder returns identical data
+// (expiration times are relative to now).
+func (b *Builder) Entries() ([]string, map[string]*vault.SecretEntry, error) {
+	if b.secrets < 0 || b.secrets+len(b.fixed) < 1 {
+		return nil, nil, ErrInvalidSecretCount
+	}
+
+	r := rand.New(rand.NewPCG(b.seed, b.seed^0x9e3779b97f4a7c15))
+	now := time.Now()
+
+	keys := make([]string, 0, b.secrets)
+	entries := make(map[string]*vault.SecretEntry, b.secrets)
+	for i := 0; len(keys) < b.secrets; i++ {
+		service := services[r.IntN(len(services))]
+		env := envs[r.IntN(len(envs))]
+		k := kinds[r.IntN(len(kinds))]
+
+		key := fmt.Sprintf("%s/%s/%s", service, env, k.name)
+		if _, dup := entries[key]; dup {
+			key = fmt.Sprintf("%s-%d", key, i)
+		}
+
+		entry := &vault.SecretEntry{
+			Key:    key,
+			Fields: k.fields(r, service),
+			Metadata: &vault.SecretMetadata{
+				Notes: fmt.Sprintf("Demo %s credential for %s (%s)", k.name, service, env),
+				URL:   fmt.Sprintf("https://%s.example.com", service),
+			},
+		}
+
+		if r.Float64() < b.tagRatio {
+			entry.Tags = pickTags(r, env)
+		}
+		if r.Float64() < b.expiryRatio {
+			days := 1 + r.IntN(90)
+			expiresAt := now.Add(time.Duration(days) * 24 * time.Hour)
+			entry.ExpiresAt = &expiresAt
+		}
+
+		keys = append(keys, key)
+		entries[key] = entry
+	}
+	for _, f := range b.fixed {
+		if _, dup := entries[f.key]; !dup {
+			keys = append(keys, f.key)
+		}
+		entries[f.key] = f.entry
+	}
+
+	return keys, entries, nil
+}
+
+// Build initializes a new vault at path, populates it and returns it unlocked.
+// The caller is responsible for calling Lock on the returned vault.
+func (b *Builder) Build(path, password string) (*vault.Vault, error) {
+	if b.auditEvents < 0 {
+		return nil, ErrInvalidAuditEvents
+	}
+	keys, entries, err := b.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	v := vault.New(path)
+	if err := v.Init(password); err != nil {
+		return nil, fmt.Errorf("vaulttest: failed to init vault: %w", err)
+	}
+	if err := v.Unlock(password); err != nil {
+		return nil, fmt.Errorf("vaulttest: failed to unlock vault: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := v.SetSecret(key, entries[key]); err != nil {
+			v.Lock()
+			return nil, fmt.Errorf("vaulttest: failed to set %s: %w", key, err)
+		}
+	}
+
+	if err := b.writeAuditHistory(v, keys); err != nil {
+		v.Lock()
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// TB is the part of testing.TB that MustBuild uses.
+type TB interface {
+	Helper()
+	TempDir() string
+	Fatalf(format string, args ...any)
+	Cleanup(f func())
+}
+
+// MustBuild builds the vault in a temporary directory of tb with
+// DefaultPassword and returns it unlocked. It fails tb if the vault cannot
+// be built and locks the vault when tb ends.
+func (b *Builder) MustBuild(tb TB) *vault.Vault {
+	tb.Helper()
+	v, err := b.Build(tb.TempDir(), DefaultPassword)
+	if err != nil {
+		tb.Fatalf("vaulttest: %v", err)
+	}
+	tb.Cleanup(v.Lock)
+	return v
+}
+
+// writeAuditHistory records a deterministic mix of read, list and denied events.
+func (b *Builder) writeAuditHistory(v *vault.Vault, keys []string) error {
+	r := rand.New(rand.NewPCG(b.seed^0xa5a5a5a5, b.seed))
+	logger := v.Audit()
+	sources := []string{audit.SourceCLI, audit.SourceMCP}
+
+	for i := 0; i < b.auditEvents; i++ {
+		key := keys[r.IntN(len(keys))]
+		source := sources[r.IntN(len(sources))]
+
+		var err error
+		switch n := r.IntN(10); {
+		case n < 6:
+			err = logger.LogSuccess(audit.OpSecretGet, source, key)
+		case n < 8:
+			err = logger.LogSuccess(audit.OpSecretList, source, "")
+		case n < 9:
+			err = logger.LogSuccess(audit.OpSecretRun, source, key)
+		default:
+			err = logger.LogDenied(audit.OpSecretRunDenied, audit.SourceMCP, key, "command denied by policy")
+		}
+		if err != nil {
+			return fmt.Errorf("vaulttest: failed to write audit event: %w", err)
+		}
+	}
+	return nil
+}
+
+func pickTags(r *rand.Rand, env string) []string {
+	tags := []string{env}
+	extra := r.IntN(3)
+	for i := 0; i < extra; i++ {
+		tag := tagPool[r.IntN(len(tagPool))]
+		if !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+const randomCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomString returns a seeded (non-cryptographic) string. Fixture values
+// are fake by design and must never be used as real credentials.
+func randomString(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = randomCharset[r.IntN(len(randomCharset))]
+	}
+	return string(b)
+}
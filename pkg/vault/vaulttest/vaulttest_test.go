@@ -0,0 +1,115 @@
+package vaulttest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/forest6511/secretctl/pkg/vault"
+)
+
+func TestEntriesDeterministic(t *testing.T) {
+	keys1, entries1, err := NewBuilder(42).WithSecrets(30).Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	keys2, entries2, err := NewBuilder(42).WithSecrets(30).Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(keys1, keys2) {
+		t.Fatalf("keys differ for same seed:\n%v\n%v", keys1, keys2)
+	}
+	for _, key := range keys1 {
+		e1, e2 := entries1[key], entries2[key]
+		if !reflect.DeepEqual(e1.Fields, e2.Fields) {
+			t.Errorf("fields differ for %s", key)
+		}
+		if !reflect.DeepEqual(e1.Tags, e2.Tags) {
+			t.Errorf("tags differ for %s", key)
+		}
+		if (e1.ExpiresAt == nil) != (e2.ExpiresAt == nil) {
+			t.Errorf("expiration presence differs for %s", key)
+		}
+	}
+
+	keys3, _, err := NewBuilder(43).WithSecrets(30).Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if reflect.DeepEqual(keys1, keys3) {
+		t.Error("expected different keys for different seeds")
+	}
+}
+
+func TestEntriesInvalidCount(t *testing.T) {
+	if _, _, err := NewBuilder(1).WithSecrets(0).Entries(); err != ErrInvalidSecretCount {
+		t.Errorf("expected ErrInvalidSecretCount, got %v", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	v, err := NewBuilder(7).WithSecrets(12).WithAuditEvents(25).Build(tmpDir, DefaultPassword)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	defer v.Lock()
+
+	keys, err := v.ListSecrets()
+	if err != nil {
+		t.Fatalf("ListSecrets failed: %v", err)
+	}
+	if len(keys) != 12 {
+		t.Errorf("expected 12 secrets, got %d", len(keys))
+	}
+
+	entry, err := v.GetSecret(keys[0])
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if len(entry.Fields) == 0 {
+		t.Error("expected generated secret to have fields")
+	}
+
+	result, err := v.AuditVerify()
+	if err != nil {
+		t.Fatalf("AuditVerify failed: %v", err)
+	}
+	if !result.Valid {
+		t.Errorf("expected valid audit chain, got errors: %v", result.Errors)
+	}
+	// init + 12 sets + 25 synthetic events (+ unlock and reads)
+	if result.RecordsTotal < 38 {
+		t.Errorf("expected at least 38 audit records, got %d", result.RecordsTotal)
+	}
+}
+
+func TestWithEntry(t *testing.T) {
+	keys, entries, err := NewBuilder(5).WithSecrets(3).
+		WithEntry("api_key", &vault.SecretEntry{Value: []byte("secret123"), Tags: []string{"prod"}}).
+		Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(keys) != 4 || keys[3] != "api_key" {
+		t.Fatalf("keys = %v, want 3 generated keys and api_key", keys)
+	}
+	if got := string(entries["api_key"].Value); got != "secret123" {
+		t.Errorf("api_key = %q, want secret123", got)
+	}
+
+	// WithSecrets(0) leaves only the given secrets
+	v := NewBuilder(5).WithSecrets(0).WithAuditEvents(0).
+		WithEntry("api_key", &vault.SecretEntry{Value: []byte("secret123")}).
+		MustBuild(t)
+	listed, err := v.ListSecrets()
+	if err != nil || !reflect.DeepEqual(listed, []string{"api_key"}) {
+		t.Errorf("ListSecrets = %v, %v; want [api_key]", listed, err)
+	}
+
+	if _, _, err := NewBuilder(5).WithSecrets(-1).WithEntry("k", &vault.SecretEntry{Value: []byte("v")}).Entries(); err != ErrInvalidSecretCount {
+		t.Errorf("expected ErrInvalidSecretCount for a negative count, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Command update-manifest writes the signed manifest of a desktop release
+// channel, as read by pkg/update. It is run by the release workflow:
+//
+//	UPDATE_SIGNING_KEY=<base64 ed25519 seed> go run ./scripts/update-manifest \
+//	    -channel stable -version 1.2.0 -base-url <release download URL> -out dist \
+//	    linux/amd64=dist/secretctl-desktop-linux-amd64.tar.gz \
+//	    darwin/universal=dist/secretctl-desktop-macos-universal.zip \
+//	    windows/amd64=dist/secretctl-desktop.exe
+//
+// It writes <out>/<channel>.json and <out>/<channel>.json.sig, and refuses
+// to sign with a key that is not one of update.ReleasePublicKeys.
+//
+// With -genkey it generates a new signing key for the key ceremony
+// (website/docs/contributing/release-signing.md) instead: the base64 seed,
+// the value of UPDATE_SIGNING_KEY, goes to stdout and the public key to
+// add to update.ReleasePublicKeys to stderr.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/forest6511/secretctl/pkg/update"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "update-manifest:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	channel := flag.String("channel", "stable", "release channel (stable or beta)")
+	version := flag.String("version", "", "release version, e.g. 1.2.0")
+	baseURL := flag.String("base-url", "", "URL the artifacts are downloaded from")
+	notesURL := flag.String("notes-url", "", "release notes URL")
+	out := flag.String("out", ".", "output directory")
+	genkey := flag.Bool("genkey", false, "generate a signing key instead")
+	flag.Parse()
+
+	if *genkey {
+		return generateKey()
+	}
+
+	if err := update.ValidateChannel(update.Channel(*channel)); err != nil {
+		return err
+	}
+	if *version == "" || *baseURL == "" || flag.NArg() == 0 {
+		return fmt.Errorf("-version, -base-url and at least one os/arch=file artifact are required")
+	}
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv("UPDATE_SIGNING_KEY"))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("UPDATE_SIGNING_KEY must be a base64 ed25519 seed")
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	public := key.Public().(ed25519.PublicKey)
+	if !slices.ContainsFunc(update.ReleasePublicKeys, func(k ed25519.PublicKey) bool { return k.Equal(public) }) {
+		return fmt.Errorf("UPDATE_SIGNING_KEY (public key %s) is not in update.ReleasePublicKeys",
+			base64.StdEncoding.EncodeToString(public))
+	}
+
+	m := update.Manifest{
+		Channel:    update.Channel(*channel),
+		Version:    strings.TrimPrefix(*version, "v"),
+		ReleasedAt: time.Now().UTC().Truncate(time.Second),
+		NotesURL:   *notesURL,
+	}
+	for _, arg := range flag.Args() {
+		platform, file, ok := strings.Cut(arg, "=")
+		goos, goarch, ok2 := strings.Cut(platform, "/")
+		if !ok || !ok2 {
+			return fmt.Errorf("invalid artifact %q, want os/arch=file", arg)
+		}
+		sum, size, err := digest(file)
+		if err != nil {
+			return err
+		}
+		m.Assets = append(m.Assets, update.Asset{
+			OS:     goos,
+			Arch:   goarch,
+			URL:    strings.TrimSuffix(*baseURL, "/") + "/" + filepath.Base(file),
+			SHA256: sum,
+			Size:   size,
+		})
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Refuse to publish a manifest the desktop app would reject
+	if _, err := update.ParseManifest(data, update.SignManifest(key, data), update.ReleasePublicKeys...); err != nil {
+		return err
+	}
+	name := filepath.Join(*out, *channel+".json")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(name+".sig", update.SignManifest(key, data), 0o644)
+}
+
+// generateKey prints a new signing key: the seed to stdout, so that it can
+// be piped into a file or secret store without showing up on the terminal,
+// and the public key to stderr.
+func generateKey() error {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+	fmt.Println(base64.StdEncoding.EncodeToString(private.Seed()))
+	fmt.Fprintln(os.Stderr, "public key:", base64.StdEncoding.EncodeToString(public))
+	return nil
+}
+
+func digest(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
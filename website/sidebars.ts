@@ -100,6 +100,7 @@ const sidebars: SidebarsConfig = {
       items: [
         'contributing/index',
         'contributing/development-setup',
+        'contributing/release-signing',
       ],
     },
     {